@@ -36,3 +36,33 @@ func (ch *Chain) FindVotesByHash(hash common.Hash) *score.VoteSet {
 func (ch *Chain) RemoveVotesByHash(hash common.Hash) {
 	ch.store.Delete(voteIndexKey(hash))
 }
+
+// commitCertificateIndexKey constructs the DB key for the aggregated commit certificate of the given block hash.
+func commitCertificateIndexKey(hash common.Hash) common.Bytes {
+	return append(common.Bytes("cc/"), hash[:]...)
+}
+
+// AddCommitCertificate saves an aggregated commit certificate, whose individual votes are not available.
+func (ch *Chain) AddCommitCertificate(cc score.CommitCertificate) {
+	if cc.BlockHash.IsEmpty() || cc.Aggregated == nil {
+		return
+	}
+	err := ch.store.Put(commitCertificateIndexKey(cc.BlockHash), cc)
+	if err != nil {
+		logger.Panic(err)
+	}
+}
+
+// FindCommitCertificate looks up the aggregated commit certificate by block hash.
+func (ch *Chain) FindCommitCertificate(hash common.Hash) *score.CommitCertificate {
+	cc := &score.CommitCertificate{}
+	if err := ch.store.Get(commitCertificateIndexKey(hash), cc); err != nil {
+		return nil
+	}
+	return cc
+}
+
+// RemoveCommitCertificate removes the aggregated commit certificate for given block.
+func (ch *Chain) RemoveCommitCertificate(hash common.Hash) {
+	ch.store.Delete(commitCertificateIndexKey(hash))
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/thetatoken/thetasubchain/cmd/thetasubcli/cmd/utils"
 	"github.com/thetatoken/thetasubchain/core"
 	"github.com/thetatoken/thetasubchain/node"
+	"github.com/thetatoken/thetasubchain/signer"
 	"github.com/thetatoken/thetasubchain/snapshot"
 	"github.com/thetatoken/thetasubchain/store/rollingdb"
 	"github.com/thetatoken/thetasubchain/version"
@@ -135,9 +136,19 @@ func runStart(cmd *cobra.Command, args []string) {
 		networkOld = newMessengerOld(privKey, peerSeedsOld, portOld, ctx)
 	}
 
+	consensusSigner, err := signer.NewSignerFromConfig(privKey)
+	if err != nil {
+		log.Fatalf("Failed to create the consensus signer: %v", err)
+	}
+	consensusSigner, err = signer.NewFailoverSignerFromConfig(ctx, consensusSigner)
+	if err != nil {
+		log.Fatalf("Failed to set up the validator failover: %v", err)
+	}
+
 	params := &node.Params{
 		ChainID:             root.ChainID,
 		PrivateKey:          privKey,
+		Signer:              consensusSigner,
 		Root:                root,
 		NetworkOld:          networkOld,
 		Network:             network,
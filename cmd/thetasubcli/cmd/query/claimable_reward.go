@@ -0,0 +1,49 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/thetasubchain/cmd/thetasubcli/cmd/utils"
+	"github.com/thetatoken/thetasubchain/rpc"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// claimableRewardCmd represents the claimable_reward command.
+// Example:
+//		thetasubcli query claimable_reward --address=0x2E833968E5bB786Ae419c4d13189fB081Cc43bab
+var claimableRewardCmd = &cobra.Command{
+	Use:     "claimable_reward",
+	Short:   "Get the claimable reward of a delegator",
+	Long:    `Get the gas fee rewards (in TFuelWei) a delegator can claim with "thetasubcli tx claim_reward".`,
+	Example: `thetasubcli query claimable_reward --address=0x2E833968E5bB786Ae419c4d13189fB081Cc43bab`,
+	Run:     doClaimableRewardCmd,
+}
+
+func doClaimableRewardCmd(cmd *cobra.Command, args []string) {
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	res, err := client.Call("theta.GetClaimableReward", rpc.GetClaimableRewardArgs{
+		Address: common.HexToAddress(addressFlag),
+	})
+	if err != nil {
+		utils.Error("Failed to get claimable reward: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.Error("Failed to retrieve claimable reward: %v\n", res.Error)
+	}
+	json, err := json.MarshalIndent(res.Result, "", "    ")
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n%v\n", err, string(json))
+	}
+	fmt.Println(string(json))
+}
+
+func init() {
+	claimableRewardCmd.Flags().StringVar(&addressFlag, "address", "", "Address of the delegator")
+	claimableRewardCmd.MarkFlagRequired("address")
+}
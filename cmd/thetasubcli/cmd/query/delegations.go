@@ -0,0 +1,49 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/thetasubchain/cmd/thetasubcli/cmd/utils"
+	"github.com/thetatoken/thetasubchain/rpc"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// delegationsCmd represents the delegations command.
+// Example:
+//		thetasubcli query delegations --address=0x2E833968E5bB786Ae419c4d13189fB081Cc43bab
+var delegationsCmd = &cobra.Command{
+	Use:     "delegations",
+	Short:   "Get the delegations to a validator",
+	Long:    `Get the commission rate of a validator and the shares its delegators stake to it on the mainchain.`,
+	Example: `thetasubcli query delegations --address=0x2E833968E5bB786Ae419c4d13189fB081Cc43bab`,
+	Run:     doDelegationsCmd,
+}
+
+func doDelegationsCmd(cmd *cobra.Command, args []string) {
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	res, err := client.Call("theta.GetDelegations", rpc.GetDelegationsArgs{
+		Validator: common.HexToAddress(addressFlag),
+	})
+	if err != nil {
+		utils.Error("Failed to get delegations: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.Error("Failed to retrieve delegations: %v\n", res.Error)
+	}
+	json, err := json.MarshalIndent(res.Result, "", "    ")
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n%v\n", err, string(json))
+	}
+	fmt.Println(string(json))
+}
+
+func init() {
+	delegationsCmd.Flags().StringVar(&addressFlag, "address", "", "Address of the validator")
+	delegationsCmd.MarkFlagRequired("address")
+}
@@ -0,0 +1,59 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/thetasubchain/cmd/thetasubcli/cmd/utils"
+	"github.com/thetatoken/thetasubchain/rpc"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// dynastyCmd represents the dynasty command.
+// Example:
+//		thetasubcli query dynasty
+//		thetasubcli query dynasty --dynasty=12
+var dynastyCmd = &cobra.Command{
+	Use:   "dynasty",
+	Short: "Get the upcoming dynasty transition, or the validator set of a dynasty",
+	Long: `Without --dynasty, get the current and the next validator sets, the mainchain height at which the next dynasty starts, and the validators joining, leaving or changing stake.
+With --dynasty, get the validator set of the given dynasty as registered on the mainchain.`,
+	Example: `thetasubcli query dynasty`,
+	Run:     doDynastyCmd,
+}
+
+func doDynastyCmd(cmd *cobra.Command, args []string) {
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	var res *rpcc.RPCResponse
+	var err error
+	if dynastyFlag == "" {
+		res, err = client.Call("theta.GetDynastyTransition", rpc.GetDynastyTransitionArgs{})
+	} else {
+		dynasty, ok := new(big.Int).SetString(dynastyFlag, 10)
+		if !ok {
+			utils.Error("Invalid dynasty: %v\n", dynastyFlag)
+		}
+		res, err = client.Call("theta.GetValidatorSetByDynasty", rpc.GetValidatorSetByDynastyArgs{Dynasty: (*common.JSONBig)(dynasty)})
+	}
+	if err != nil {
+		utils.Error("Failed to get dynasty: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.Error("Failed to retrieve dynasty: %v\n", res.Error)
+	}
+	json, err := json.MarshalIndent(res.Result, "", "    ")
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n%v\n", err, string(json))
+	}
+	fmt.Println(string(json))
+}
+
+func init() {
+	dynastyCmd.Flags().StringVar(&dynastyFlag, "dynasty", "", "dynasty of the validator set to query, defaults to the upcoming transition")
+}
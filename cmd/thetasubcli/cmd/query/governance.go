@@ -0,0 +1,56 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/thetasubchain/cmd/thetasubcli/cmd/utils"
+	"github.com/thetatoken/thetasubchain/rpc"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// governanceCmd represents the governance command.
+// Example:
+//		thetasubcli query governance
+//		thetasubcli query governance --proposal_id=1
+var governanceCmd = &cobra.Command{
+	Use:   "governance",
+	Short: "Get the governed runtime parameters and upgrade plans, or a governance proposal",
+	Long: `Get the runtime parameters governed by the validators with their current and scheduled values, and the approved upgrade plans, ` +
+		`or the governance proposal with the given ID.`,
+	Example: `thetasubcli query governance --proposal_id=1`,
+	Run:     doGovernanceCmd,
+}
+
+func doGovernanceCmd(cmd *cobra.Command, args []string) {
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	var res *rpcc.RPCResponse
+	var err error
+	if proposalIDFlag == 0 {
+		res, err = client.Call("theta.GetGovernanceParams", rpc.GetGovernanceParamsArgs{})
+	} else {
+		res, err = client.Call("theta.GetGovernanceProposal", rpc.GetGovernanceProposalArgs{
+			ID: common.JSONUint64(proposalIDFlag),
+		})
+	}
+	if err != nil {
+		utils.Error("Failed to get governance info: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.Error("Failed to retrieve governance info: %v\n", res.Error)
+	}
+	json, err := json.MarshalIndent(res.Result, "", "    ")
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n%v\n", err, string(json))
+	}
+	fmt.Println(string(json))
+}
+
+func init() {
+	governanceCmd.Flags().Uint64Var(&proposalIDFlag, "proposal_id", 0, "ID of the governance proposal, the governed parameters are returned if not set")
+}
@@ -0,0 +1,56 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/thetasubchain/cmd/thetasubcli/cmd/utils"
+	"github.com/thetatoken/thetasubchain/rpc"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// livenessCmd represents the liveness command.
+// Example:
+//		thetasubcli query liveness --dynasty=12
+var livenessCmd = &cobra.Command{
+	Use:     "liveness",
+	Short:   "Get the validator liveness and downtime report of a dynasty",
+	Long:    `Get the proposal slots, proposals and commit certificate votes of each validator within a dynasty, and the validators flagged for downtime.`,
+	Example: `thetasubcli query liveness --dynasty=12`,
+	Run:     doLivenessCmd,
+}
+
+func doLivenessCmd(cmd *cobra.Command, args []string) {
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	livenessArgs := rpc.GetValidatorLivenessArgs{}
+	if dynastyFlag != "" {
+		dynasty, ok := new(big.Int).SetString(dynastyFlag, 10)
+		if !ok {
+			utils.Error("Invalid dynasty: %v\n", dynastyFlag)
+		}
+		livenessArgs.Dynasty = (*common.JSONBig)(dynasty)
+	}
+
+	res, err := client.Call("theta.GetValidatorLiveness", livenessArgs)
+	if err != nil {
+		utils.Error("Failed to get validator liveness: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.Error("Failed to retrieve validator liveness: %v\n", res.Error)
+	}
+	json, err := json.MarshalIndent(res.Result, "", "    ")
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n%v\n", err, string(json))
+	}
+	fmt.Println(string(json))
+}
+
+func init() {
+	livenessCmd.Flags().StringVar(&dynastyFlag, "dynasty", "", "dynasty to query, defaults to the current one")
+}
@@ -15,7 +15,14 @@ var (
 	endFlag              uint64
 	skipEdgeNodeFlag     bool
 	includeEthTxHashFlag bool
+	verifyFlag           bool
 	tokenTypeFlag        int
+	nonceFlag            string
+	dynastyFlag          string
+	proposalIDFlag       uint64
+	validatorSetFileFlag string
+	mainchainEthRpcFlag  string
+	chainRegistrarFlag   string
 )
 
 // QueryCmd represents the query command
@@ -33,4 +40,16 @@ func init() {
 	QueryCmd.AddCommand(peersCmd)
 	QueryCmd.AddCommand(versionCmd)
 	QueryCmd.AddCommand(tokenBankAddrCmd)
+	QueryCmd.AddCommand(withdrawalProofCmd)
+	QueryCmd.AddCommand(relayStreamsCmd)
+	QueryCmd.AddCommand(relayOutcomesCmd)
+	QueryCmd.AddCommand(relayFeesCmd)
+	QueryCmd.AddCommand(livenessCmd)
+	QueryCmd.AddCommand(dynastyCmd)
+	QueryCmd.AddCommand(rewardsCmd)
+	QueryCmd.AddCommand(delegationsCmd)
+	QueryCmd.AddCommand(nativeStakesCmd)
+	QueryCmd.AddCommand(claimableRewardCmd)
+	QueryCmd.AddCommand(signingKeysCmd)
+	QueryCmd.AddCommand(governanceCmd)
 }
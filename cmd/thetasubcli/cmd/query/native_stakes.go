@@ -0,0 +1,49 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/thetasubchain/cmd/thetasubcli/cmd/utils"
+	"github.com/thetatoken/thetasubchain/rpc"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// nativeStakesCmd represents the native stakes command.
+// Example:
+//		thetasubcli query native_stakes --address=0x2E833968E5bB786Ae419c4d13189fB081Cc43bab
+var nativeStakesCmd = &cobra.Command{
+	Use:     "native_stakes",
+	Short:   "Get the stakes locked on the subchain for a validator",
+	Long:    `Get the governance token vouchers staked to a validator on the subchain, and the unstaked amounts waiting for the end of the unbonding period.`,
+	Example: `thetasubcli query native_stakes --address=0x2E833968E5bB786Ae419c4d13189fB081Cc43bab`,
+	Run:     doNativeStakesCmd,
+}
+
+func doNativeStakesCmd(cmd *cobra.Command, args []string) {
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	res, err := client.Call("theta.GetNativeStakes", rpc.GetNativeStakesArgs{
+		Validator: common.HexToAddress(addressFlag),
+	})
+	if err != nil {
+		utils.Error("Failed to get native stakes: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.Error("Failed to retrieve native stakes: %v\n", res.Error)
+	}
+	json, err := json.MarshalIndent(res.Result, "", "    ")
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n%v\n", err, string(json))
+	}
+	fmt.Println(string(json))
+}
+
+func init() {
+	nativeStakesCmd.Flags().StringVar(&addressFlag, "address", "", "Address of the validator")
+	nativeStakesCmd.MarkFlagRequired("address")
+}
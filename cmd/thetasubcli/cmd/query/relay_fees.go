@@ -0,0 +1,39 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/thetatoken/thetasubchain/cmd/thetasubcli/cmd/utils"
+	"github.com/thetatoken/thetasubchain/rpc"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// relayFeesCmd represents the relay_fees command.
+// Example:
+//		thetasubcli query relay_fees
+var relayFeesCmd = &cobra.Command{
+	Use:     "relay_fees",
+	Short:   "Get the relay gas costs and cross-chain fee income of the validator",
+	Long:    `Get the relay gas costs, cross-chain fee income, profit or loss of the validator, and the cross-chain fee updates it proposed.`,
+	Example: `thetasubcli query relay_fees`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+		res, err := client.Call("theta.GetRelayFeeReport", rpc.GetRelayFeeReportArgs{})
+		if err != nil {
+			utils.Error("Failed to get relay fee report: %v\n", err)
+		}
+		if res.Error != nil {
+			utils.Error("Failed to retrieve relay fee report: %v\n", res.Error)
+		}
+		json, err := json.MarshalIndent(res.Result, "", "    ")
+		if err != nil {
+			utils.Error("Failed to parse server response: %v\n%v\n", err, string(json))
+		}
+		fmt.Println(string(json))
+	},
+}
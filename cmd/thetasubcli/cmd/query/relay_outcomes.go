@@ -0,0 +1,39 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/thetatoken/thetasubchain/cmd/thetasubcli/cmd/utils"
+	"github.com/thetatoken/thetasubchain/rpc"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// relayOutcomesCmd represents the relay_outcomes command.
+// Example:
+//		thetasubcli query relay_outcomes
+var relayOutcomesCmd = &cobra.Command{
+	Use:     "relay_outcomes",
+	Short:   "Get the outcomes of the latest cross-chain relay attempts",
+	Long:    `Get the outcomes (e.g. simulation revert reasons) of the latest relay attempts of the pending cross-chain events.`,
+	Example: `thetasubcli query relay_outcomes`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+		res, err := client.Call("theta.GetRelayOutcomes", rpc.GetRelayOutcomesArgs{})
+		if err != nil {
+			utils.Error("Failed to get relay outcomes: %v\n", err)
+		}
+		if res.Error != nil {
+			utils.Error("Failed to retrieve relay outcomes: %v\n", res.Error)
+		}
+		json, err := json.MarshalIndent(res.Result, "", "    ")
+		if err != nil {
+			utils.Error("Failed to parse server response: %v\n%v\n", err, string(json))
+		}
+		fmt.Println(string(json))
+	},
+}
@@ -0,0 +1,39 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/thetatoken/thetasubchain/cmd/thetasubcli/cmd/utils"
+	"github.com/thetatoken/thetasubchain/rpc"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// relayStreamsCmd represents the relay_streams command.
+// Example:
+//		thetasubcli query relay_streams
+var relayStreamsCmd = &cobra.Command{
+	Use:     "relay_streams",
+	Short:   "Get the health of the cross-chain relay streams",
+	Long:    `Get the health of the cross-chain relay streams processed by the orchestrator of the node.`,
+	Example: `thetasubcli query relay_streams`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+		res, err := client.Call("theta.GetRelayStreamHealth", rpc.GetRelayStreamHealthArgs{})
+		if err != nil {
+			utils.Error("Failed to get relay stream health: %v\n", err)
+		}
+		if res.Error != nil {
+			utils.Error("Failed to retrieve relay stream health: %v\n", res.Error)
+		}
+		json, err := json.MarshalIndent(res.Result, "", "    ")
+		if err != nil {
+			utils.Error("Failed to parse server response: %v\n%v\n", err, string(json))
+		}
+		fmt.Println(string(json))
+	},
+}
@@ -0,0 +1,41 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/thetatoken/thetasubchain/cmd/thetasubcli/cmd/utils"
+	"github.com/thetatoken/thetasubchain/rpc"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// rewardsCmd represents the rewards command.
+// Example:
+//		thetasubcli query rewards
+var rewardsCmd = &cobra.Command{
+	Use:     "rewards",
+	Short:   "Get the reward schedule of the coinbase transactions",
+	Long:    `Get the reward schedule of the coinbase transactions, the gas fees pending distribution, and the staker rewards accrued by the current validators.`,
+	Example: `thetasubcli query rewards`,
+	Run:     doRewardsCmd,
+}
+
+func doRewardsCmd(cmd *cobra.Command, args []string) {
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	res, err := client.Call("theta.GetRewardSchedule", rpc.GetRewardScheduleArgs{})
+	if err != nil {
+		utils.Error("Failed to get reward schedule: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.Error("Failed to retrieve reward schedule: %v\n", res.Error)
+	}
+	json, err := json.MarshalIndent(res.Result, "", "    ")
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n%v\n", err, string(json))
+	}
+	fmt.Println(string(json))
+}
@@ -0,0 +1,49 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/thetasubchain/cmd/thetasubcli/cmd/utils"
+	"github.com/thetatoken/thetasubchain/rpc"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// signingKeysCmd represents the signing_keys command.
+// Example:
+//		thetasubcli query signing_keys --address=0x2E833968E5bB786Ae419c4d13189fB081Cc43bab
+var signingKeysCmd = &cobra.Command{
+	Use:     "signing_keys",
+	Short:   "Get the signing keys of a validator",
+	Long:    `Get the key a validator signs with in the current dynasty, and the keys it has rotated to.`,
+	Example: `thetasubcli query signing_keys --address=0x2E833968E5bB786Ae419c4d13189fB081Cc43bab`,
+	Run:     doSigningKeysCmd,
+}
+
+func doSigningKeysCmd(cmd *cobra.Command, args []string) {
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	res, err := client.Call("theta.GetSigningKeys", rpc.GetSigningKeysArgs{
+		Validator: common.HexToAddress(addressFlag),
+	})
+	if err != nil {
+		utils.Error("Failed to get signing keys: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.Error("Failed to retrieve signing keys: %v\n", res.Error)
+	}
+	json, err := json.MarshalIndent(res.Result, "", "    ")
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n%v\n", err, string(json))
+	}
+	fmt.Println(string(json))
+}
+
+func init() {
+	signingKeysCmd.Flags().StringVar(&addressFlag, "address", "", "Address of the validator")
+	signingKeysCmd.MarkFlagRequired("address")
+}
@@ -0,0 +1,166 @@
+package query
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/rlp"
+	"github.com/thetatoken/thetasubchain/cmd/thetasubcli/cmd/utils"
+	scom "github.com/thetatoken/thetasubchain/common"
+	"github.com/thetatoken/thetasubchain/core"
+	ec "github.com/thetatoken/thetasubchain/eth/ethclient"
+	scta "github.com/thetatoken/thetasubchain/interchain/contracts/accessors"
+	"github.com/thetatoken/thetasubchain/rpc"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// withdrawalProofCmd represents the withdrawal proof command.
+// Example:
+//		thetasubcli query withdrawal_proof --token_type=0 --nonce=3
+//		thetasubcli query withdrawal_proof --token_type=0 --nonce=3 --verify --validator_set=./validator_set.json
+var withdrawalProofCmd = &cobra.Command{
+	Use:   "withdrawal_proof",
+	Short: "Get the Merkle proof of a voucher burn against a finalized checkpoint",
+	Long: `Get the Merkle proof of a voucher burn against the state of a finalized checkpoint block.
+With --verify, check the proof against the validator set of the checkpoint dynasty, instead of trusting the node that
+served it. The validator set is read from the --validator_set file, or from the chain registrar on the mainchain through
+a trusted mainchain node. As the registrar does not record the BLS keys, a proof whose commit certificate is aggregated
+can only be checked against a validator set file.`,
+	Example: `thetasubcli query withdrawal_proof --token_type=0 --nonce=3 --verify --mainchain_eth_rpc=http://localhost:18888/rpc --chain_registrar=0x...`,
+	Run:     doWithdrawalProofCmd,
+}
+
+func doWithdrawalProofCmd(cmd *cobra.Command, args []string) {
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	nonce, ok := new(big.Int).SetString(nonceFlag, 10)
+	if !ok {
+		utils.Error("Invalid voucher burn nonce: %v\n", nonceFlag)
+	}
+
+	res, err := client.Call("theta.GetWithdrawalProof", rpc.GetWithdrawalProofArgs{
+		TokenType:        core.CrossChainTokenType(tokenTypeFlag),
+		VoucherBurnNonce: (*common.JSONBig)(nonce),
+		CheckpointHeight: common.JSONUint64(heightFlag),
+	})
+	if err != nil {
+		utils.Error("Failed to get withdrawal proof: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.Error("Failed to get withdrawal proof: %v\n", res.Error)
+	}
+	if verifyFlag {
+		verifyWithdrawalProof(res)
+	}
+	json, err := json.MarshalIndent(res.Result, "", "    ")
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n%v\n", err, string(json))
+	}
+	fmt.Println(string(json))
+}
+
+// verifyWithdrawalProof checks the withdrawal proof against the validator set of the checkpoint dynasty, taken from
+// a source independent of the node that served the proof.
+func verifyWithdrawalProof(res *rpcc.RPCResponse) {
+	result := &rpc.GetWithdrawalProofResult{}
+	if err := res.GetObject(result); err != nil {
+		utils.Error("Failed to parse server response: %v\n", err)
+	}
+	proofBytes, err := hex.DecodeString(result.Proof)
+	if err != nil {
+		utils.Error("Failed to decode withdrawal proof: %v\n", err)
+	}
+	proof := &core.WithdrawalProof{}
+	if err := rlp.DecodeBytes(proofBytes, proof); err != nil {
+		utils.Error("Failed to decode withdrawal proof: %v\n", err)
+	}
+	if proof.CheckpointHeader == nil || proof.CheckpointHeader.Hash() != result.CheckpointHash {
+		utils.Error("The withdrawal proof is not for checkpoint %v\n", result.CheckpointHash.Hex())
+	}
+
+	dynasty := (*big.Int)(result.Dynasty)
+	validatorSet, err := getTrustedValidatorSet(proof, dynasty)
+	if err != nil {
+		utils.Error("Failed to get the validator set of dynasty %v: %v\n", dynasty, err)
+	}
+	if _, err := core.VerifyWithdrawalProof(proof, validatorSet); err != nil {
+		utils.Error("The withdrawal proof is invalid: %v\n", err)
+	}
+	fmt.Printf("Verified the withdrawal proof against the validator set of dynasty %v\n", dynasty)
+}
+
+// getTrustedValidatorSet reads the validator set of the dynasty from the --validator_set file, or from the chain
+// registrar on the mainchain. The registrar only records the stakes of the validators, so a commit certificate
+// aggregated with BLS signatures can only be checked against a validator set file, which carries the BLS keys.
+func getTrustedValidatorSet(proof *core.WithdrawalProof, dynasty *big.Int) (*core.ValidatorSet, error) {
+	if len(validatorSetFileFlag) != 0 {
+		raw, err := ioutil.ReadFile(validatorSetFileFlag)
+		if err != nil {
+			return nil, err
+		}
+		vs := rpc.ValidatorSet{}
+		if err := json.Unmarshal(raw, &vs); err != nil {
+			return nil, err
+		}
+		if vs.Dynasty == nil || vs.Dynasty.Cmp(dynasty) != 0 {
+			return nil, fmt.Errorf("the validator set file is for dynasty %v", vs.Dynasty)
+		}
+		validatorSet := core.NewValidatorSet(dynasty)
+		for _, v := range vs.Validators {
+			validatorSet.AddValidator(v)
+		}
+		for _, pk := range vs.BLSPubKeys {
+			validatorSet.SetBLSPubKey(pk.Address, pk.PubKey)
+		}
+		return validatorSet, nil
+	}
+
+	if len(mainchainEthRpcFlag) == 0 || len(chainRegistrarFlag) == 0 {
+		return nil, errors.New("no trusted validator set, set either --validator_set, or --mainchain_eth_rpc and --chain_registrar")
+	}
+	if proof.CommitCert.Aggregated != nil {
+		return nil, errors.New("the chain registrar does not record the BLS keys of the aggregated commit certificate, set --validator_set instead")
+	}
+	client, err := ec.Dial(mainchainEthRpcFlag)
+	if err != nil {
+		return nil, err
+	}
+	registrar, err := scta.NewChainRegistrarOnMainchain(common.HexToAddress(chainRegistrarFlag), client)
+	if err != nil {
+		return nil, err
+	}
+	subchainID := scom.MapChainID(proof.CheckpointHeader.ChainID)
+	queryHeight := new(big.Int).Add(scom.DynastyStartHeight(dynasty), big.NewInt(1)) // a mainchain height within the dynasty
+	vs, err := registrar.GetValidatorSet(nil, subchainID, queryHeight)
+	if err != nil {
+		return nil, err
+	}
+	if len(vs.Validators) != len(vs.ShareAmounts) {
+		return nil, fmt.Errorf("the chain registrar returned %v validators, but %v stakes", len(vs.Validators), len(vs.ShareAmounts))
+	}
+	validatorSet := core.NewValidatorSet(dynasty)
+	for i, addr := range vs.Validators {
+		validatorSet.AddValidator(core.NewValidator(addr.Hex(), vs.ShareAmounts[i]))
+	}
+	return validatorSet, nil
+}
+
+func init() {
+	withdrawalProofCmd.Flags().IntVar(&tokenTypeFlag, "token_type", int(0), "token type")
+	withdrawalProofCmd.Flags().StringVar(&nonceFlag, "nonce", "", "voucher burn nonce")
+	withdrawalProofCmd.Flags().Uint64Var(&heightFlag, "checkpoint_height", uint64(0), "height of the checkpoint block, defaults to the latest finalized checkpoint")
+	withdrawalProofCmd.Flags().BoolVar(&verifyFlag, "verify", false, "verify the proof against the validator set of the checkpoint dynasty")
+	withdrawalProofCmd.Flags().StringVar(&validatorSetFileFlag, "validator_set", "", "with --verify, JSON file of the trusted validator set of the checkpoint dynasty, including the BLS keys")
+	withdrawalProofCmd.Flags().StringVar(&mainchainEthRpcFlag, "mainchain_eth_rpc", "", "with --verify, ETH RPC endpoint of a trusted mainchain node, to read the validator set from the chain registrar")
+	withdrawalProofCmd.Flags().StringVar(&chainRegistrarFlag, "chain_registrar", "", "with --verify, address of the chain registrar contract on the mainchain")
+	withdrawalProofCmd.MarkFlagRequired("token_type")
+	withdrawalProofCmd.MarkFlagRequired("nonce")
+}
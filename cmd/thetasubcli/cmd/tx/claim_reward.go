@@ -0,0 +1,111 @@
+package tx
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/ledger/types"
+	wtypes "github.com/thetatoken/theta/wallet/types"
+	"github.com/thetatoken/thetasubchain/cmd/thetasubcli/cmd/utils"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+	"github.com/thetatoken/thetasubchain/rpc"
+
+	"github.com/ybbus/jsonrpc"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// claimRewardCmd represents the claim_reward command
+// Example:
+//		thetasubcli tx claim_reward --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --seq=1
+var claimRewardCmd = &cobra.Command{
+	Use:     "claim_reward",
+	Short:   "Claim the gas fee rewards of a delegator",
+	Long:    `Transfer the gas fee rewards the delegator has earned through its validators to its account. The fee can be paid out of the claimed rewards.`,
+	Example: `thetasubcli tx claim_reward --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --seq=1`,
+	Run:     doClaimRewardCmd,
+}
+
+func doClaimRewardCmd(cmd *cobra.Command, args []string) {
+	walletType := getWalletType(cmd)
+	if walletType == wtypes.WalletTypeSoft && len(fromFlag) == 0 {
+		utils.Error("The from address cannot be empty") // we don't need to specify the "from address" for hardware wallets
+		return
+	}
+
+	wallet, fromAddress, err := walletUnlockWithPath(cmd, fromFlag, pathFlag, passwordFlag)
+	if err != nil || wallet == nil {
+		return
+	}
+	defer wallet.Lock(fromAddress)
+
+	fee, ok := types.ParseCoinAmount(feeFlag)
+	if !ok {
+		utils.Error("Failed to parse fee")
+	}
+	claimTx := &stypes.SubchainRewardClaimTx{
+		Fee: types.Coins{
+			ThetaWei: new(big.Int).SetUint64(0),
+			TFuelWei: fee,
+		},
+		Claimer: types.TxInput{
+			Address:  fromAddress,
+			Sequence: uint64(seqFlag),
+		},
+	}
+
+	sig, err := wallet.Sign(fromAddress, claimTx.SignBytes(chainIDFlag))
+	if err != nil {
+		utils.Error("Failed to sign transaction: %v\n", err)
+	}
+	claimTx.SetSignature(fromAddress, sig)
+
+	raw, err := stypes.TxToBytes(claimTx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	signedTx := hex.EncodeToString(raw)
+
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	var res *jsonrpc.RPCResponse
+	if asyncFlag {
+		res, err = client.Call("theta.BroadcastRawTransactionAsync", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	} else {
+		res, err = client.Call("theta.BroadcastRawTransaction", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	}
+
+	if err != nil {
+		utils.Error("Failed to broadcast transaction: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.Error("Server returned error: %v\n", res.Error)
+	}
+	result := &rpc.BroadcastRawTransactionResult{}
+	err = res.GetObject(result)
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n", err)
+	}
+	formatted, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n", err)
+	}
+	fmt.Printf("Successfully broadcasted transaction:\n%s\n", formatted)
+}
+
+func init() {
+	claimRewardCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	claimRewardCmd.Flags().StringVar(&fromFlag, "from", "", "Address of the delegator")
+	claimRewardCmd.Flags().StringVar(&pathFlag, "path", "", "Wallet derivation path")
+	claimRewardCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+	claimRewardCmd.Flags().StringVar(&feeFlag, "fee", fmt.Sprintf("%dwei", types.MinimumTransactionFeeTFuelWeiJune2021), "Fee")
+	claimRewardCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor)")
+	claimRewardCmd.Flags().BoolVar(&asyncFlag, "async", false, "block until tx has been included in the blockchain")
+	claimRewardCmd.Flags().StringVar(&passwordFlag, "password", "", "password to unlock the wallet")
+
+	claimRewardCmd.MarkFlagRequired("chain")
+	claimRewardCmd.MarkFlagRequired("seq")
+}
@@ -0,0 +1,120 @@
+package tx
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/ledger/types"
+	wtypes "github.com/thetatoken/theta/wallet/types"
+	"github.com/thetatoken/thetasubchain/cmd/thetasubcli/cmd/utils"
+	score "github.com/thetatoken/thetasubchain/core"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+	"github.com/thetatoken/thetasubchain/rpc"
+
+	"github.com/ybbus/jsonrpc"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// commissionCmd represents the commission command
+// Example:
+//		thetasubcli tx commission --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --rate=500 --seq=1
+var commissionCmd = &cobra.Command{
+	Use:     "commission",
+	Short:   "Set the commission rate of a validator",
+	Long:    `Set the share of the rewards (in basis points) the validator keeps before the rest is split among its delegators.`,
+	Example: `thetasubcli tx commission --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --rate=500 --seq=1`,
+	Run:     doCommissionCmd,
+}
+
+func doCommissionCmd(cmd *cobra.Command, args []string) {
+	walletType := getWalletType(cmd)
+	if walletType == wtypes.WalletTypeSoft && len(fromFlag) == 0 {
+		utils.Error("The from address cannot be empty") // we don't need to specify the "from address" for hardware wallets
+		return
+	}
+
+	wallet, fromAddress, err := walletUnlockWithPath(cmd, fromFlag, pathFlag, passwordFlag)
+	if err != nil || wallet == nil {
+		return
+	}
+	defer wallet.Lock(fromAddress)
+
+	if commissionRateFlag > score.MaxCommissionRateInBasisPoints {
+		utils.Error("The commission rate cannot exceed %v basis points", score.MaxCommissionRateInBasisPoints)
+		return
+	}
+
+	fee, ok := types.ParseCoinAmount(feeFlag)
+	if !ok {
+		utils.Error("Failed to parse fee")
+	}
+	commissionTx := &stypes.SubchainCommissionRateUpdateTx{
+		Fee: types.Coins{
+			ThetaWei: new(big.Int).SetUint64(0),
+			TFuelWei: fee,
+		},
+		Validator: types.TxInput{
+			Address:  fromAddress,
+			Sequence: uint64(seqFlag),
+		},
+		CommissionRateInBasisPoints: commissionRateFlag,
+	}
+
+	sig, err := wallet.Sign(fromAddress, commissionTx.SignBytes(chainIDFlag))
+	if err != nil {
+		utils.Error("Failed to sign transaction: %v\n", err)
+	}
+	commissionTx.SetSignature(fromAddress, sig)
+
+	raw, err := stypes.TxToBytes(commissionTx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	signedTx := hex.EncodeToString(raw)
+
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	var res *jsonrpc.RPCResponse
+	if asyncFlag {
+		res, err = client.Call("theta.BroadcastRawTransactionAsync", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	} else {
+		res, err = client.Call("theta.BroadcastRawTransaction", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	}
+
+	if err != nil {
+		utils.Error("Failed to broadcast transaction: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.Error("Server returned error: %v\n", res.Error)
+	}
+	result := &rpc.BroadcastRawTransactionResult{}
+	err = res.GetObject(result)
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n", err)
+	}
+	formatted, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n", err)
+	}
+	fmt.Printf("Successfully broadcasted transaction:\n%s\n", formatted)
+}
+
+func init() {
+	commissionCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	commissionCmd.Flags().StringVar(&fromFlag, "from", "", "Address of the validator")
+	commissionCmd.Flags().Uint64Var(&commissionRateFlag, "rate", 0, "Commission rate in basis points")
+	commissionCmd.Flags().StringVar(&pathFlag, "path", "", "Wallet derivation path")
+	commissionCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+	commissionCmd.Flags().StringVar(&feeFlag, "fee", fmt.Sprintf("%dwei", types.MinimumTransactionFeeTFuelWeiJune2021), "Fee")
+	commissionCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor)")
+	commissionCmd.Flags().BoolVar(&asyncFlag, "async", false, "block until tx has been included in the blockchain")
+	commissionCmd.Flags().StringVar(&passwordFlag, "password", "", "password to unlock the wallet")
+
+	commissionCmd.MarkFlagRequired("chain")
+	commissionCmd.MarkFlagRequired("rate")
+	commissionCmd.MarkFlagRequired("seq")
+}
@@ -0,0 +1,135 @@
+package tx
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/ledger/types"
+	wtypes "github.com/thetatoken/theta/wallet/types"
+	"github.com/thetatoken/thetasubchain/cmd/thetasubcli/cmd/utils"
+	score "github.com/thetatoken/thetasubchain/core"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+	"github.com/thetatoken/thetasubchain/rpc"
+
+	"github.com/ybbus/jsonrpc"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// governanceCmd represents the governance command
+// Example:
+//		thetasubcli tx governance --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --param=max_num_regular_txs_per_block --value=50 --activation_height=20000 --seq=1
+//		thetasubcli tx governance --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --proposal_id=1 --seq=2
+var governanceCmd = &cobra.Command{
+	Use:   "governance",
+	Short: "Propose or vote for a change of a runtime parameter",
+	Long: `Propose a new value of a runtime parameter taking effect at the activation height, or vote for a pending ` +
+		`proposal. The proposal is approved once its voters hold more than 2/3 of the stake of the validator set. ` +
+		`Supported parameters: ` + strings.Join(score.GovernanceParams(), ", ") + `. The upgrade plans submitted ` +
+		`with "tx upgrade_plan" are voted for the same way.`,
+	Example: `thetasubcli tx governance --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --proposal_id=1 --seq=2`,
+	Run:     doGovernanceCmd,
+}
+
+func doGovernanceCmd(cmd *cobra.Command, args []string) {
+	walletType := getWalletType(cmd)
+	if walletType == wtypes.WalletTypeSoft && len(fromFlag) == 0 {
+		utils.Error("The from address cannot be empty") // we don't need to specify the "from address" for hardware wallets
+		return
+	}
+
+	if proposalIDFlag == 0 {
+		if len(paramFlag) == 0 || len(valueFlag) == 0 || activationHeightFlag == 0 {
+			utils.Error("The parameter, the value and the activation height are required for new proposals")
+			return
+		}
+	} else if len(paramFlag) != 0 || len(valueFlag) != 0 || activationHeightFlag != 0 {
+		utils.Error("The parameter, the value and the activation height can only be set for new proposals")
+		return
+	}
+
+	wallet, fromAddress, err := walletUnlockWithPath(cmd, fromFlag, pathFlag, passwordFlag)
+	if err != nil || wallet == nil {
+		return
+	}
+	defer wallet.Lock(fromAddress)
+
+	fee, ok := types.ParseCoinAmount(feeFlag)
+	if !ok {
+		utils.Error("Failed to parse fee")
+	}
+	governanceTx := &stypes.SubchainGovernanceVoteTx{
+		Fee: types.Coins{
+			ThetaWei: new(big.Int).SetUint64(0),
+			TFuelWei: fee,
+		},
+		Validator: types.TxInput{
+			Address:  fromAddress,
+			Sequence: uint64(seqFlag),
+		},
+		ProposalID:       proposalIDFlag,
+		Param:            paramFlag,
+		Value:            valueFlag,
+		ActivationHeight: activationHeightFlag,
+	}
+
+	sig, err := wallet.Sign(fromAddress, governanceTx.SignBytes(chainIDFlag))
+	if err != nil {
+		utils.Error("Failed to sign transaction: %v\n", err)
+	}
+	governanceTx.SetSignature(fromAddress, sig)
+
+	raw, err := stypes.TxToBytes(governanceTx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	signedTx := hex.EncodeToString(raw)
+
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	var res *jsonrpc.RPCResponse
+	if asyncFlag {
+		res, err = client.Call("theta.BroadcastRawTransactionAsync", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	} else {
+		res, err = client.Call("theta.BroadcastRawTransaction", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	}
+
+	if err != nil {
+		utils.Error("Failed to broadcast transaction: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.Error("Server returned error: %v\n", res.Error)
+	}
+	result := &rpc.BroadcastRawTransactionResult{}
+	err = res.GetObject(result)
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n", err)
+	}
+	formatted, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n", err)
+	}
+	fmt.Printf("Successfully broadcasted transaction:\n%s\n", formatted)
+}
+
+func init() {
+	governanceCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	governanceCmd.Flags().StringVar(&fromFlag, "from", "", "Address of the validator")
+	governanceCmd.Flags().Uint64Var(&proposalIDFlag, "proposal_id", 0, "ID of the proposal to vote for, a new proposal is submitted if not set")
+	governanceCmd.Flags().StringVar(&paramFlag, "param", "", "Runtime parameter of the new proposal")
+	governanceCmd.Flags().StringVar(&valueFlag, "value", "", "Value of the runtime parameter of the new proposal")
+	governanceCmd.Flags().Uint64Var(&activationHeightFlag, "activation_height", 0, "Block height from which the new proposal takes effect")
+	governanceCmd.Flags().StringVar(&pathFlag, "path", "", "Wallet derivation path")
+	governanceCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+	governanceCmd.Flags().StringVar(&feeFlag, "fee", fmt.Sprintf("%dwei", types.MinimumTransactionFeeTFuelWeiJune2021), "Fee")
+	governanceCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor)")
+	governanceCmd.Flags().BoolVar(&asyncFlag, "async", false, "block until tx has been included in the blockchain")
+	governanceCmd.Flags().StringVar(&passwordFlag, "password", "", "password to unlock the wallet")
+
+	governanceCmd.MarkFlagRequired("chain")
+	governanceCmd.MarkFlagRequired("seq")
+}
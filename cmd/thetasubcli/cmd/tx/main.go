@@ -36,6 +36,14 @@ var (
 	beneficiaryFlag              string
 	splitBasisPointFlag          uint64
 	passwordFlag                 string
+	commissionRateFlag           uint64
+	proposalIDFlag               uint64
+	paramFlag                    string
+	activationHeightFlag         uint64
+	upgradeVersionFlag           string
+	upgradeHeightFlag            uint64
+	validatorFlag                string
+	stakeAmountFlag              string
 )
 
 // TxCmd represents the Tx command
@@ -48,4 +56,10 @@ var TxCmd = &cobra.Command{
 func init() {
 	TxCmd.AddCommand(sendCmd)
 	TxCmd.AddCommand(smartContractCmd)
+	TxCmd.AddCommand(claimRewardCmd)
+	TxCmd.AddCommand(commissionCmd)
+	TxCmd.AddCommand(governanceCmd)
+	TxCmd.AddCommand(upgradePlanCmd)
+	TxCmd.AddCommand(stakeCmd)
+	TxCmd.AddCommand(unstakeCmd)
 }
@@ -0,0 +1,172 @@
+package tx
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/types"
+	wtypes "github.com/thetatoken/theta/wallet/types"
+	"github.com/thetatoken/thetasubchain/cmd/thetasubcli/cmd/utils"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+	"github.com/thetatoken/thetasubchain/rpc"
+
+	"github.com/ybbus/jsonrpc"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// stakeCmd represents the stake command
+// Example:
+//		thetasubcli tx stake --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --validator=0x2E833968E5bB786Ae419c4d13189fB081Cc43bab --amount=1000e18 --seq=1
+var stakeCmd = &cobra.Command{
+	Use:   "stake",
+	Short: "Stake governance tokens held on the subchain to a validator",
+	Long: `Stake governance tokens held on the subchain to a validator. The governance token vouchers are locked ` +
+		`on the subchain, and the stake is relayed to the chain registrar on the mainchain, which counts it in the ` +
+		`validator sets of the next dynasties.`,
+	Example: `thetasubcli tx stake --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --validator=0x2E833968E5bB786Ae419c4d13189fB081Cc43bab --amount=1000e18 --seq=1`,
+	Run:     doStakeCmd,
+}
+
+// unstakeCmd represents the unstake command
+// Example:
+//		thetasubcli tx unstake --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --validator=0x2E833968E5bB786Ae419c4d13189fB081Cc43bab --amount=1000e18 --seq=2
+var unstakeCmd = &cobra.Command{
+	Use:   "unstake",
+	Short: "Unstake governance tokens staked on the subchain",
+	Long: `Unstake governance tokens staked on the subchain with "tx stake". The governance token vouchers are ` +
+		`released after the unbonding period, and the withdrawal is relayed to the chain registrar on the mainchain.`,
+	Example: `thetasubcli tx unstake --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --validator=0x2E833968E5bB786Ae419c4d13189fB081Cc43bab --amount=1000e18 --seq=2`,
+	Run:     doUnstakeCmd,
+}
+
+func doStakeCmd(cmd *cobra.Command, args []string) {
+	doNativeStakeCmd(cmd, false)
+}
+
+func doUnstakeCmd(cmd *cobra.Command, args []string) {
+	doNativeStakeCmd(cmd, true)
+}
+
+func doNativeStakeCmd(cmd *cobra.Command, unstake bool) {
+	walletType := getWalletType(cmd)
+	if walletType == wtypes.WalletTypeSoft && len(fromFlag) == 0 {
+		utils.Error("The from address cannot be empty") // we don't need to specify the "from address" for hardware wallets
+		return
+	}
+
+	if !common.IsHexAddress(validatorFlag) {
+		utils.Error("Invalid validator address: %v\n", validatorFlag)
+		return
+	}
+	amount, ok := types.ParseCoinAmount(stakeAmountFlag)
+	if !ok || amount.Sign() <= 0 {
+		utils.Error("Failed to parse amount")
+		return
+	}
+
+	wallet, fromAddress, err := walletUnlockWithPath(cmd, fromFlag, pathFlag, passwordFlag)
+	if err != nil || wallet == nil {
+		return
+	}
+	defer wallet.Lock(fromAddress)
+
+	fee, ok := types.ParseCoinAmount(feeFlag)
+	if !ok {
+		utils.Error("Failed to parse fee")
+	}
+	fees := types.Coins{
+		ThetaWei: new(big.Int).SetUint64(0),
+		TFuelWei: fee,
+	}
+	staker := types.TxInput{
+		Address:  fromAddress,
+		Sequence: uint64(seqFlag),
+	}
+	validator := common.HexToAddress(validatorFlag)
+
+	var tx types.Tx
+	if unstake {
+		unstakeTx := &stypes.SubchainUnstakeTx{
+			Fee:       fees,
+			Staker:    staker,
+			Validator: validator,
+			Amount:    amount,
+		}
+		sig, err := wallet.Sign(fromAddress, unstakeTx.SignBytes(chainIDFlag))
+		if err != nil {
+			utils.Error("Failed to sign transaction: %v\n", err)
+		}
+		unstakeTx.SetSignature(fromAddress, sig)
+		tx = unstakeTx
+	} else {
+		stakeTx := &stypes.SubchainStakeTx{
+			Fee:       fees,
+			Staker:    staker,
+			Validator: validator,
+			Amount:    amount,
+		}
+		sig, err := wallet.Sign(fromAddress, stakeTx.SignBytes(chainIDFlag))
+		if err != nil {
+			utils.Error("Failed to sign transaction: %v\n", err)
+		}
+		stakeTx.SetSignature(fromAddress, sig)
+		tx = stakeTx
+	}
+
+	raw, err := stypes.TxToBytes(tx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	signedTx := hex.EncodeToString(raw)
+
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	var res *jsonrpc.RPCResponse
+	if asyncFlag {
+		res, err = client.Call("theta.BroadcastRawTransactionAsync", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	} else {
+		res, err = client.Call("theta.BroadcastRawTransaction", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	}
+
+	if err != nil {
+		utils.Error("Failed to broadcast transaction: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.Error("Server returned error: %v\n", res.Error)
+	}
+	result := &rpc.BroadcastRawTransactionResult{}
+	err = res.GetObject(result)
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n", err)
+	}
+	formatted, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n", err)
+	}
+	fmt.Printf("Successfully broadcasted transaction:\n%s\n", formatted)
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{stakeCmd, unstakeCmd} {
+		cmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+		cmd.Flags().StringVar(&fromFlag, "from", "", "Address of the staker")
+		cmd.Flags().StringVar(&validatorFlag, "validator", "", "Address of the validator")
+		cmd.Flags().StringVar(&stakeAmountFlag, "amount", "", "Amount of the governance token, e.g. 1000e18")
+		cmd.Flags().StringVar(&pathFlag, "path", "", "Wallet derivation path")
+		cmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+		cmd.Flags().StringVar(&feeFlag, "fee", fmt.Sprintf("%dwei", types.MinimumTransactionFeeTFuelWeiJune2021), "Fee")
+		cmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor)")
+		cmd.Flags().BoolVar(&asyncFlag, "async", false, "block until tx has been included in the blockchain")
+		cmd.Flags().StringVar(&passwordFlag, "password", "", "password to unlock the wallet")
+
+		cmd.MarkFlagRequired("chain")
+		cmd.MarkFlagRequired("validator")
+		cmd.MarkFlagRequired("amount")
+		cmd.MarkFlagRequired("seq")
+	}
+}
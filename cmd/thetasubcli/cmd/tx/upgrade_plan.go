@@ -0,0 +1,123 @@
+package tx
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/ledger/types"
+	wtypes "github.com/thetatoken/theta/wallet/types"
+	"github.com/thetatoken/thetasubchain/cmd/thetasubcli/cmd/utils"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+	"github.com/thetatoken/thetasubchain/rpc"
+
+	"github.com/ybbus/jsonrpc"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// upgradePlanCmd represents the upgrade plan command
+// Example:
+//		thetasubcli tx upgrade_plan --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --version=0.1.0 --height=20000 --seq=1
+var upgradePlanCmd = &cobra.Command{
+	Use:   "upgrade_plan",
+	Short: "Propose a software upgrade",
+	Long: `Propose a software upgrade requiring the validators to run at least the given version from the given ` +
+		`height on. The other validators vote for the plan with "tx governance --proposal_id", and the plan is ` +
+		`approved once its voters hold more than 2/3 of the stake of the validator set. The nodes running older ` +
+		`versions halt at the upgrade height.`,
+	Example: `thetasubcli tx upgrade_plan --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --version=0.1.0 --height=20000 --seq=1`,
+	Run:     doUpgradePlanCmd,
+}
+
+func doUpgradePlanCmd(cmd *cobra.Command, args []string) {
+	walletType := getWalletType(cmd)
+	if walletType == wtypes.WalletTypeSoft && len(fromFlag) == 0 {
+		utils.Error("The from address cannot be empty") // we don't need to specify the "from address" for hardware wallets
+		return
+	}
+
+	if len(upgradeVersionFlag) == 0 || upgradeHeightFlag == 0 {
+		utils.Error("The version and the height of the upgrade are required")
+		return
+	}
+
+	wallet, fromAddress, err := walletUnlockWithPath(cmd, fromFlag, pathFlag, passwordFlag)
+	if err != nil || wallet == nil {
+		return
+	}
+	defer wallet.Lock(fromAddress)
+
+	fee, ok := types.ParseCoinAmount(feeFlag)
+	if !ok {
+		utils.Error("Failed to parse fee")
+	}
+	upgradePlanTx := &stypes.SubchainUpgradePlanTx{
+		Fee: types.Coins{
+			ThetaWei: new(big.Int).SetUint64(0),
+			TFuelWei: fee,
+		},
+		Validator: types.TxInput{
+			Address:  fromAddress,
+			Sequence: uint64(seqFlag),
+		},
+		Version: upgradeVersionFlag,
+		Height:  upgradeHeightFlag,
+	}
+
+	sig, err := wallet.Sign(fromAddress, upgradePlanTx.SignBytes(chainIDFlag))
+	if err != nil {
+		utils.Error("Failed to sign transaction: %v\n", err)
+	}
+	upgradePlanTx.SetSignature(fromAddress, sig)
+
+	raw, err := stypes.TxToBytes(upgradePlanTx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	signedTx := hex.EncodeToString(raw)
+
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	var res *jsonrpc.RPCResponse
+	if asyncFlag {
+		res, err = client.Call("theta.BroadcastRawTransactionAsync", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	} else {
+		res, err = client.Call("theta.BroadcastRawTransaction", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	}
+
+	if err != nil {
+		utils.Error("Failed to broadcast transaction: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.Error("Server returned error: %v\n", res.Error)
+	}
+	result := &rpc.BroadcastRawTransactionResult{}
+	err = res.GetObject(result)
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n", err)
+	}
+	formatted, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n", err)
+	}
+	fmt.Printf("Successfully broadcasted transaction:\n%s\n", formatted)
+}
+
+func init() {
+	upgradePlanCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	upgradePlanCmd.Flags().StringVar(&fromFlag, "from", "", "Address of the validator")
+	upgradePlanCmd.Flags().StringVar(&upgradeVersionFlag, "version", "", "Minimal software version required by the upgrade, e.g. 0.1.0")
+	upgradePlanCmd.Flags().Uint64Var(&upgradeHeightFlag, "height", 0, "Block height from which the upgrade is required")
+	upgradePlanCmd.Flags().StringVar(&pathFlag, "path", "", "Wallet derivation path")
+	upgradePlanCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+	upgradePlanCmd.Flags().StringVar(&feeFlag, "fee", fmt.Sprintf("%dwei", types.MinimumTransactionFeeTFuelWeiJune2021), "Fee")
+	upgradePlanCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor)")
+	upgradePlanCmd.Flags().BoolVar(&asyncFlag, "async", false, "block until tx has been included in the blockchain")
+	upgradePlanCmd.Flags().StringVar(&passwordFlag, "password", "", "password to unlock the wallet")
+
+	upgradePlanCmd.MarkFlagRequired("chain")
+	upgradePlanCmd.MarkFlagRequired("seq")
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/theta/common"
+	ks "github.com/thetatoken/theta/wallet/softwallet/keystore"
+
+	"github.com/thetatoken/thetasubchain/cmd/thetasubcli/cmd/utils"
+	"github.com/thetatoken/thetasubchain/signer"
+)
+
+//
+// thetasubsigner holds the validator key in a separate process, and signs the consensus messages for the
+// subchain node, refusing to sign votes or proposals conflicting with the ones it signed before.
+//
+// Usage: thetasubsigner -keys=<keystore_dir> -address=<validator_address> -listen=unix:///var/run/thetasubsigner.sock
+//        thetasubsigner -keys=<keystore_dir> -address=<validator_address> -listen=tcp://0.0.0.0:7000 \
+//            -tls-cert=<cert> -tls-key=<key> -tls-ca=<client_ca>
+//
+func main() {
+	keysDirPtr := flag.String("keys", "./key", "the folder of the encrypted validator key")
+	addressPtr := flag.String("address", "", "the address of the validator")
+	passwordPtr := flag.String("password", "", "the password of the validator key, prompted if not specified")
+	listenPtr := flag.String("listen", "", "the address to listen on, unix://<path> or tcp://<host:port>")
+	statePtr := flag.String("state", "./signer_state.json", "the file persisting the high-water marks of the signed messages")
+	tlsCertPtr := flag.String("tls-cert", "", "the TLS certificate of the signer, required for TCP")
+	tlsKeyPtr := flag.String("tls-key", "", "the TLS key of the signer, required for TCP")
+	tlsCAPtr := flag.String("tls-ca", "", "the CA certificate the node certificates must be signed by, required for TCP")
+
+	flag.Parse()
+
+	if !common.IsHexAddress(*addressPtr) {
+		log.Fatalf("Invalid validator address: %v", *addressPtr)
+	}
+	password := *passwordPtr
+	if password == "" {
+		var err error
+		password, err = utils.GetPassword("Please enter the password of the validator key: ")
+		if err != nil {
+			log.Fatalf("Failed to get password: %v", err)
+		}
+	}
+
+	keystore, err := ks.NewKeystoreEncrypted(filepath.Clean(*keysDirPtr), ks.StandardScryptN, ks.StandardScryptP)
+	if err != nil {
+		log.Fatalf("Failed to open the key store: %v", err)
+	}
+	key, err := keystore.GetKey(common.HexToAddress(*addressPtr), password)
+	if err != nil {
+		log.Fatalf("Failed to load the validator key: %v", err)
+	}
+
+	server, err := signer.NewServer(key.PrivateKey, *statePtr)
+	if err != nil {
+		log.Fatalf("Failed to create the signer: %v", err)
+	}
+
+	var tlsConfig *tls.Config
+	if *tlsCertPtr != "" {
+		tlsConfig, err = signer.NewServerTLSConfig(*tlsCertPtr, *tlsKeyPtr, *tlsCAPtr)
+		if err != nil {
+			log.Fatalf("Failed to load the TLS config: %v", err)
+		}
+	}
+	listener, err := signer.Listen(*listenPtr, tlsConfig)
+	if err != nil {
+		log.Fatalf("Failed to listen on %v: %v", *listenPtr, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	go func() {
+		<-c
+		cancel()
+	}()
+
+	if err := server.Serve(ctx, listener); err != nil {
+		log.Fatalf("Signer stopped: %v", err)
+	}
+}
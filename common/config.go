@@ -29,6 +29,16 @@ const (
 	CfgConsensusMaxEpochLength = "consensus.maxEpochLength"
 	// CfgConsensusMinBlockTime defines the minimal block interval (in seconds)
 	CfgConsensusMinBlockInterval = "consensus.minBlockInterval"
+	// CfgConsensusMinBlockIntervalInMilliseconds defines the minimal block interval (in milliseconds). It takes precedence over
+	// CfgConsensusMinBlockInterval if set, and only takes effect after the millisecond timestamp fork
+	CfgConsensusMinBlockIntervalInMilliseconds = "consensus.minBlockIntervalInMilliseconds"
+	// CfgConsensusMaxTimestampDriftInMilliseconds defines how far the timestamp of a block can be ahead of the local
+	// clock (in milliseconds) before the block is held back until the clock catches up. It takes effect after the
+	// future timestamp bound fork. Zero disables the check.
+	CfgConsensusMaxTimestampDriftInMilliseconds = "consensus.maxTimestampDriftInMilliseconds"
+	// CfgConsensusClockDriftWarningThresholdInMilliseconds defines the drift of the local clock from the validator
+	// majority (in milliseconds) above which a clock drift warning is logged
+	CfgConsensusClockDriftWarningThresholdInMilliseconds = "consensus.clockDriftWarningThresholdInMilliseconds"
 	// CfgConsensusMessageQueueSize defines the capacity of consensus message queue.
 	CfgConsensusMessageQueueSize = "consensus.messageQueueSize"
 	// CfgConsensusEdgeNodeVoteQueueSize defines the capacity of edge node vote message queue.
@@ -158,6 +168,124 @@ const (
 	CfgSubchainID = "subchain.chainID"
 	// CfgSubchainUpdateIntervalInMilliseconds defines the time interval in millisecond for the subchain to obtain the status update from the mainchain
 	CfgSubchainUpdateIntervalInMilliseconds = "subchain.updateInterval"
+	// CfgSubchainOrchestratorSweepIntervalInMilliseconds defines the time interval in millisecond for the orchestrator to sweep
+	// all the relay streams. Relaying is normally triggered by the witness as soon as new events arrive, the sweep is a safety net
+	CfgSubchainOrchestratorSweepIntervalInMilliseconds = "subchain.orchestratorSweepInterval"
+	// CfgSubchainFeeManagerEnabled indicates whether the orchestrator should propose cross-chain fee updates based on the relay gas costs
+	CfgSubchainFeeManagerEnabled = "subchain.feeManager.enabled"
+	// CfgSubchainFeeManagerUpdateIntervalInMilliseconds defines the time interval in millisecond between two cross-chain fee evaluations
+	CfgSubchainFeeManagerUpdateIntervalInMilliseconds = "subchain.feeManager.updateInterval"
+	// CfgSubchainFeeManagerMinCrossChainFee defines the lower bound (in wei) of the proposed cross-chain fee
+	CfgSubchainFeeManagerMinCrossChainFee = "subchain.feeManager.minCrossChainFee"
+	// CfgSubchainFeeManagerMaxCrossChainFee defines the upper bound (in wei) of the proposed cross-chain fee
+	CfgSubchainFeeManagerMaxCrossChainFee = "subchain.feeManager.maxCrossChainFee"
+	// CfgSubchainFeeManagerMarginPercent defines the margin added on top of the average relay gas cost
+	CfgSubchainFeeManagerMarginPercent = "subchain.feeManager.marginPercent"
+	// CfgSubchainFeeManagerMinChangePercent defines the minimal relative change for a fee update to be proposed
+	CfgSubchainFeeManagerMinChangePercent = "subchain.feeManager.minChangePercent"
+	// CfgSubchainFeeManagerSubchainGasTokenPrice defines the price of the subchain gas token in mainchain TFuelWei per 1e18
+	// subchain wei, which converts the relay gas costs into the unit of the cross-chain fee of the other chain
+	CfgSubchainFeeManagerSubchainGasTokenPrice = "subchain.feeManager.subchainGasTokenPrice"
+	// CfgSubchainSlashingEnabled indicates whether the orchestrator should submit the recorded equivocations to the mainchain for slashing
+	CfgSubchainSlashingEnabled = "subchain.slashing.enabled"
+	// CfgSubchainSlashingValidatorCollateralSlashAmount defines the amount (in wei) of validator collateral slashed per equivocation
+	CfgSubchainSlashingValidatorCollateralSlashAmount = "subchain.slashing.validatorCollateralSlashAmount"
+	// CfgSubchainSlashingGuarantors lists the guarantors of the validators as "validator:guarantor" address pairs.
+	// A validator not in the list is assumed to be its own guarantor
+	CfgSubchainSlashingGuarantors = "subchain.slashing.guarantors"
+	// CfgSubchainSlashingDowntimeSlashAmount defines the amount (in wei) of validator collateral slashed for the validators
+	// whose downtime the validators approved through the governance proposals. Zero disables the downtime slashing
+	CfgSubchainSlashingDowntimeSlashAmount = "subchain.slashing.downtimeSlashAmount"
+	// CfgSubchainSlashingVoteDowntime indicates whether the validator should propose or vote for slashing the validators
+	// flagged in the downtime reports of this node. The validators are slashed once the proposals get 2/3 of the stake
+	CfgSubchainSlashingVoteDowntime = "subchain.slashing.voteDowntime"
+	// CfgSubchainStakeRelayEnabled indicates whether the orchestrator should relay the stake and unstake transactions of the
+	// subchain to the chain registrar on the mainchain. Only the node whose key is the native stake relayer designated through
+	// the governance proposals relays them, and deposits the stakes from its account, which needs to hold the governance token
+	// on the mainchain and approve the chain registrar to spend it. The validators enabling it vote on the rejected deposits
+	CfgSubchainStakeRelayEnabled = "subchain.stakeRelay.enabled"
+	// CfgSubchainStakerRewardMintEnabled indicates whether the orchestrator should mint the staker rewards accrued on the
+	// subchain through SubchainGovernanceToken.MintStakerReward on the mainchain. The node key needs to be the minter of the token
+	CfgSubchainStakerRewardMintEnabled = "subchain.stakerRewardMint.enabled"
+	// CfgSubchainLivenessMaxMissedProposalsPercent defines the percentage of missed proposal slots above which a validator is flagged
+	CfgSubchainLivenessMaxMissedProposalsPercent = "subchain.liveness.maxMissedProposalsPercent"
+	// CfgSubchainLivenessMaxMissedVotesPercent defines the percentage of commit certificates missing its vote above which a validator is flagged
+	CfgSubchainLivenessMaxMissedVotesPercent = "subchain.liveness.maxMissedVotesPercent"
+	// CfgSubchainLivenessMinSamples defines the minimal number of proposal slots or commit certificates needed to flag a validator
+	CfgSubchainLivenessMinSamples = "subchain.liveness.minSamples"
+	// CfgSubchainForkSmartContractHeight defines the block height from which the smart contract transactions are enabled
+	CfgSubchainForkSmartContractHeight = "subchain.fork.smartContractHeight"
+	// CfgSubchainForkTheta3Height defines the block height from which the stake reward distribution transactions are enabled
+	CfgSubchainForkTheta3Height = "subchain.fork.theta3Height"
+	// CfgSubchainForkJune2021FeeAdjustmentHeight defines the block height from which the adjusted transaction fees apply
+	CfgSubchainForkJune2021FeeAdjustmentHeight = "subchain.fork.june2021FeeAdjustmentHeight"
+	// CfgSubchainForkTxWrapperExtensionHeight defines the block height from which the smart contract transactions can be
+	// wrapped Ethereum transactions
+	CfgSubchainForkTxWrapperExtensionHeight = "subchain.fork.txWrapperExtensionHeight"
+	// CfgSubchainForkRPCCompatibilityHeight defines the block height from which the Ethereum RPC compatible signatures apply
+	CfgSubchainForkRPCCompatibilityHeight = "subchain.fork.rpcCompatibilityHeight"
+	// CfgSubchainForkEVMConstantinopleHeight defines the block height from which the EVM runs the Constantinople instruction set
+	CfgSubchainForkEVMConstantinopleHeight = "subchain.fork.evmConstantinopleHeight"
+	// CfgSubchainForkVRFProposerSelectionHeight defines the block height from which the proposers are selected with
+	// the verifiable randomness carried by the blocks. It must be the same on all the validators of the subchain
+	CfgSubchainForkVRFProposerSelectionHeight = "subchain.fork.vrfProposerSelectionHeight"
+	// CfgSubchainForkBLSCommitCertificateHeight defines the block height from which the validators register BLS keys,
+	// sign their votes with them, and the commit certificates aggregate the votes into one signature
+	CfgSubchainForkBLSCommitCertificateHeight = "subchain.fork.blsCommitCertificateHeight"
+	// CfgSubchainForkMillisecondTimestampHeight defines the block height from which the block timestamps are in Unix milliseconds,
+	// allowing for sub-second block intervals
+	CfgSubchainForkMillisecondTimestampHeight = "subchain.fork.millisecondTimestampHeight"
+	// CfgSubchainForkDynastyLengthMainchainHeight defines the mainchain height from which the dynasty length changes to
+	// CfgSubchainForkNumMainchainBlocksPerDynasty. It must start a dynasty under the dynasty length in the genesis state,
+	// and is ignored if the genesis state declares the dynasty length changes
+	CfgSubchainForkDynastyLengthMainchainHeight = "subchain.fork.dynastyLengthMainchainHeight"
+	// CfgSubchainForkNumMainchainBlocksPerDynasty defines the new dynasty length, the dynasty length does not change if
+	// it is not configured or zero
+	CfgSubchainForkNumMainchainBlocksPerDynasty = "subchain.fork.numMainchainBlocksPerDynasty"
+	// CfgSubchainForkAnchoredValidatorSetUpdateHeight defines the block height from which the validator set update transactions
+	// reference the mainchain block they are derived from, and every validator checks them against that block
+	CfgSubchainForkAnchoredValidatorSetUpdateHeight = "subchain.fork.anchoredValidatorSetUpdateHeight"
+	// CfgSubchainForkGovernanceHeight defines the block height from which the validators can change the runtime parameters
+	// through the governance vote transactions
+	CfgSubchainForkGovernanceHeight = "subchain.fork.governanceHeight"
+	// CfgSubchainForkNativeStakingHeight defines the block height from which the stakers can stake the governance token
+	// vouchers they hold on the subchain through the stake and unstake transactions
+	CfgSubchainForkNativeStakingHeight = "subchain.fork.nativeStakingHeight"
+	// CfgSubchainForkVoucherBurnRecordsHeight defines the block height from which the voucher burn events are recorded in
+	// the ledger state, so that the withdrawals to the target chains can be proven with Merkle proofs
+	CfgSubchainForkVoucherBurnRecordsHeight = "subchain.fork.voucherBurnRecordsHeight"
+	// CfgSubchainForkEquivocationEvidenceHeight defines the block height from which the proposers include the equivocation
+	// evidence transactions, which slash the validators that signed conflicting votes
+	CfgSubchainForkEquivocationEvidenceHeight = "subchain.fork.equivocationEvidenceHeight"
+	// CfgSubchainForkStakeEventsHeight defines the block height from which the proposers mirror the stake events of the mainchain,
+	// and the validators can set their commission rates and the delegators claim their rewards
+	CfgSubchainForkStakeEventsHeight = "subchain.fork.stakeEventsHeight"
+	// CfgSubchainForkFutureTimestampBoundHeight defines the block height from which the blocks with timestamps too far ahead of
+	// the local clock are held back until the clock catches up, and the votes carry the local time of the voters
+	CfgSubchainForkFutureTimestampBoundHeight = "subchain.fork.futureTimestampBoundHeight"
+	// CfgSubchainSignerRemoteAddress defines the address of the remote signer holding the validator key, e.g.
+	// unix:///var/run/thetasubsigner.sock or tcp://10.0.0.2:7000. The key of the node is used if empty
+	CfgSubchainSignerRemoteAddress = "subchain.signer.remoteAddress"
+	// CfgSubchainSignerTLSCert defines the client certificate presented to a remote signer over TCP
+	CfgSubchainSignerTLSCert = "subchain.signer.tlsCert"
+	// CfgSubchainSignerTLSKey defines the key of the client certificate presented to a remote signer over TCP
+	CfgSubchainSignerTLSKey = "subchain.signer.tlsKey"
+	// CfgSubchainSignerTLSCA defines the CA certificate the certificate of a remote signer must be signed by
+	CfgSubchainSignerTLSCA = "subchain.signer.tlsCA"
+	// CfgSubchainSignerTimeoutInMilliseconds defines the timeout of a signing request to the remote signer
+	CfgSubchainSignerTimeoutInMilliseconds = "subchain.signer.timeout"
+	// CfgSubchainFailoverMode defines the role of the node in an active/standby validator pair, "primary" or "standby".
+	// The failover is disabled if empty
+	CfgSubchainFailoverMode = "subchain.failover.mode"
+	// CfgSubchainFailoverLeaseFile defines the lease file on the storage shared by the primary and the standby
+	CfgSubchainFailoverLeaseFile = "subchain.failover.leaseFile"
+	// CfgSubchainFailoverHeartbeatAddress defines the address the primary serves the heartbeats on, and the standby
+	// polls them from, if no lease file is configured
+	CfgSubchainFailoverHeartbeatAddress = "subchain.failover.heartbeatAddress"
+	// CfgSubchainFailoverLeaseDurationInMilliseconds defines how long the signing lease lasts without being renewed
+	CfgSubchainFailoverLeaseDurationInMilliseconds = "subchain.failover.leaseDuration"
+	// CfgSubchainFailoverStatePath defines the file the high-water marks of the votes and proposals signed by the node are persisted to
+	CfgSubchainFailoverStatePath = "subchain.failover.statePath"
 	// CfgSubchainTestID defines the ID of this node in a test case
 	CfgSubchainTestID = "subchain.testID"
 )
@@ -175,6 +303,9 @@ func init() {
 
 	viper.SetDefault(CfgConsensusMaxEpochLength, 3)
 	viper.SetDefault(CfgConsensusMinBlockInterval, 1)
+	viper.SetDefault(CfgConsensusMinBlockIntervalInMilliseconds, 0)
+	viper.SetDefault(CfgConsensusMaxTimestampDriftInMilliseconds, 15000)
+	viper.SetDefault(CfgConsensusClockDriftWarningThresholdInMilliseconds, 5000)
 	viper.SetDefault(CfgConsensusMessageQueueSize, 512)
 	viper.SetDefault(CfgConsensusEdgeNodeVoteQueueSize, 100000)
 
@@ -225,7 +356,31 @@ func init() {
 	viper.SetDefault(CfgForceGCEnabled, true)
 
 	viper.SetDefault(CfgSubchainUpdateIntervalInMilliseconds, 1000)
+	viper.SetDefault(CfgSubchainOrchestratorSweepIntervalInMilliseconds, 30000)
 	viper.SetDefault(CfgSubchainMainchainBlockIntervalInSeconds, 6)
+	viper.SetDefault(CfgSubchainFeeManagerEnabled, false)
+	viper.SetDefault(CfgSubchainFeeManagerUpdateIntervalInMilliseconds, 600000)
+	viper.SetDefault(CfgSubchainFeeManagerMinCrossChainFee, "0")
+	viper.SetDefault(CfgSubchainFeeManagerMaxCrossChainFee, "100000000000000000000") // 100 TFuel
+	viper.SetDefault(CfgSubchainFeeManagerMarginPercent, 20)
+	viper.SetDefault(CfgSubchainFeeManagerMinChangePercent, 10)
+	viper.SetDefault(CfgSubchainFeeManagerSubchainGasTokenPrice, "1000000000000000000") // 1 TFuel per subchain gas token
+	viper.SetDefault(CfgSubchainSlashingEnabled, false)
+	viper.SetDefault(CfgSubchainSlashingValidatorCollateralSlashAmount, "1000000000000000000000") // 1000 wTHETA
+	viper.SetDefault(CfgSubchainSlashingGuarantors, []string{})
+	viper.SetDefault(CfgSubchainSlashingDowntimeSlashAmount, "0")
+	viper.SetDefault(CfgSubchainSlashingVoteDowntime, false)
+	viper.SetDefault(CfgSubchainStakeRelayEnabled, false)
+	viper.SetDefault(CfgSubchainStakerRewardMintEnabled, false)
+	viper.SetDefault(CfgSubchainLivenessMaxMissedProposalsPercent, 50)
+	viper.SetDefault(CfgSubchainLivenessMaxMissedVotesPercent, 50)
+	viper.SetDefault(CfgSubchainLivenessMinSamples, 10)
+	// the fork heights and the dynasty length change have no defaults here, so that the configured values can be told apart
+	viper.SetDefault(CfgSubchainSignerRemoteAddress, "")
+	viper.SetDefault(CfgSubchainSignerTimeoutInMilliseconds, 2000)
+	viper.SetDefault(CfgSubchainFailoverMode, "")
+	viper.SetDefault(CfgSubchainFailoverLeaseDurationInMilliseconds, 10000)
+	viper.SetDefault(CfgSubchainFailoverStatePath, "./failover_signer_state.json")
 	viper.SetDefault(CfgMainchainEthRpcURL, "http://127.0.0.1:18888")
 	viper.SetDefault(CfgSubchainEthRpcURL, "http://127.0.0.1:19888")
 
@@ -1,5 +1,21 @@
 package common
 
-const NumMainchainBlocksPerDynasty int64 = 400 // TODO: set proper value
+import tcom "github.com/thetatoken/theta/common"
+
+// DefaultNumMainchainBlocksPerDynasty is the dynasty length of the subchains whose genesis state does not specify one
+const DefaultNumMainchainBlocksPerDynasty int64 = 400
 
 const MinimumGasPrice uint64 = 1e8
+
+// ChannelIDEquivocationEvidence is the p2p channel over which the validators gossip equivocation evidence.
+// The value is chosen well above the channel IDs used by the Theta protocol.
+const ChannelIDEquivocationEvidence tcom.ChannelIDEnum = 0x30
+
+// MaxNumEquivocationEvidenceTxsPerBlock caps the number of equivocation evidence txs a proposer adds to a block
+const MaxNumEquivocationEvidenceTxsPerBlock = 8
+
+// DefaultBlockGasLimit is the block gas limit written into the genesis state when none is specified
+const DefaultBlockGasLimit uint64 = 30000000
+
+// MaxStakeEventQueryRange caps the number of mainchain blocks whose stake events a proposer mirrors in a block
+const MaxStakeEventQueryRange int64 = 1000
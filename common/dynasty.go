@@ -0,0 +1,196 @@
+package common
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// DynastySchedule maps the mainchain heights to the dynasties of the subchain. The dynasty length can change
+// at a mainchain height which starts a dynasty under the previous length, so that the dynasties before the
+// change are not affected.
+type DynastySchedule struct {
+	entries []dynastyScheduleEntry
+}
+
+type dynastyScheduleEntry struct {
+	startHeight         *big.Int // the first mainchain height the dynasty length applies to
+	startDynasty        *big.Int // the dynasty starting at startHeight
+	numBlocksPerDynasty *big.Int
+}
+
+// NewDynastySchedule creates a dynasty schedule with the given dynasty length from mainchain height zero
+func NewDynastySchedule(numMainchainBlocksPerDynasty int64) *DynastySchedule {
+	return &DynastySchedule{
+		entries: []dynastyScheduleEntry{{
+			startHeight:         big.NewInt(0),
+			startDynasty:        big.NewInt(0),
+			numBlocksPerDynasty: big.NewInt(numMainchainBlocksPerDynasty),
+		}},
+	}
+}
+
+// ChangeDynastyLength changes the dynasty length starting from the given mainchain height, which must start a
+// dynasty under the current schedule
+func (s *DynastySchedule) ChangeDynastyLength(startHeight *big.Int, numMainchainBlocksPerDynasty int64) error {
+	if numMainchainBlocksPerDynasty <= 0 {
+		return fmt.Errorf("invalid number of mainchain blocks per dynasty: %v", numMainchainBlocksPerDynasty)
+	}
+	last := s.entries[len(s.entries)-1]
+	if startHeight.Cmp(last.startHeight) <= 0 {
+		return fmt.Errorf("the dynasty length can only change after mainchain height %v", last.startHeight)
+	}
+	startDynasty := s.CalculateDynasty(startHeight)
+	if s.DynastyStartHeight(startDynasty).Cmp(startHeight) != 0 {
+		return fmt.Errorf("mainchain height %v does not start a dynasty", startHeight)
+	}
+	s.entries = append(s.entries, dynastyScheduleEntry{
+		startHeight:         new(big.Int).Set(startHeight),
+		startDynasty:        startDynasty,
+		numBlocksPerDynasty: big.NewInt(numMainchainBlocksPerDynasty),
+	})
+	return nil
+}
+
+// CalculateDynasty returns the dynasty the given mainchain height falls into
+func (s *DynastySchedule) CalculateDynasty(mainchainHeight *big.Int) *big.Int {
+	entry := s.entries[0]
+	for _, e := range s.entries[1:] {
+		if mainchainHeight.Cmp(e.startHeight) < 0 {
+			break
+		}
+		entry = e
+	}
+	dynasty := new(big.Int).Sub(mainchainHeight, entry.startHeight)
+	dynasty.Div(dynasty, entry.numBlocksPerDynasty)
+	return dynasty.Add(dynasty, entry.startDynasty)
+}
+
+// DynastyStartHeight returns the first mainchain height of the given dynasty
+func (s *DynastySchedule) DynastyStartHeight(dynasty *big.Int) *big.Int {
+	entry := s.entries[0]
+	for _, e := range s.entries[1:] {
+		if dynasty.Cmp(e.startDynasty) < 0 {
+			break
+		}
+		entry = e
+	}
+	height := new(big.Int).Sub(dynasty, entry.startDynasty)
+	height.Mul(height, entry.numBlocksPerDynasty)
+	return height.Add(height, entry.startHeight)
+}
+
+// NumMainchainBlocksPerDynasty returns the dynasty length at the given mainchain height
+func (s *DynastySchedule) NumMainchainBlocksPerDynasty(mainchainHeight *big.Int) int64 {
+	entry := s.entries[0]
+	for _, e := range s.entries[1:] {
+		if mainchainHeight.Cmp(e.startHeight) < 0 {
+			break
+		}
+		entry = e
+	}
+	return entry.numBlocksPerDynasty.Int64()
+}
+
+// DynastyLengthChange changes the dynasty length of the subchain from the given mainchain height on, which must start a
+// dynasty under the previous dynasty length
+type DynastyLengthChange struct {
+	MainchainHeight              uint64
+	NumMainchainBlocksPerDynasty uint64 // unsigned for the RLP encoding
+}
+
+// String represents the string representation of the dynasty length change
+func (c DynastyLengthChange) String() string {
+	return fmt.Sprintf("{MainchainHeight: %v, NumMainchainBlocksPerDynasty: %v}", c.MainchainHeight, c.NumMainchainBlocksPerDynasty)
+}
+
+// NewDynastyScheduleWithChanges creates a dynasty schedule with the given dynasty length from mainchain height zero,
+// changed by the given dynasty length changes in the order of their mainchain heights
+func NewDynastyScheduleWithChanges(numMainchainBlocksPerDynasty int64, changes []DynastyLengthChange) (*DynastySchedule, error) {
+	if numMainchainBlocksPerDynasty <= 0 {
+		return nil, fmt.Errorf("invalid number of mainchain blocks per dynasty: %v", numMainchainBlocksPerDynasty)
+	}
+	schedule := NewDynastySchedule(numMainchainBlocksPerDynasty)
+	for _, change := range changes {
+		startHeight := new(big.Int).SetUint64(change.MainchainHeight)
+		if err := schedule.ChangeDynastyLength(startHeight, int64(change.NumMainchainBlocksPerDynasty)); err != nil {
+			return nil, err
+		}
+	}
+	return schedule, nil
+}
+
+var (
+	dynastySchedule      = NewDynastySchedule(DefaultNumMainchainBlocksPerDynasty)
+	dynastyScheduleMutex = &sync.RWMutex{}
+)
+
+// configuredDynastyLengthChanges returns the dynasty length change configured as a fork, if any
+func configuredDynastyLengthChanges() ([]DynastyLengthChange, error) {
+	if !viper.IsSet(CfgSubchainForkNumMainchainBlocksPerDynasty) {
+		return []DynastyLengthChange{}, nil
+	}
+	numBlocks := viper.GetUint64(CfgSubchainForkNumMainchainBlocksPerDynasty)
+	if numBlocks == 0 {
+		return []DynastyLengthChange{}, nil
+	}
+	if !viper.IsSet(CfgSubchainForkDynastyLengthMainchainHeight) {
+		return nil, fmt.Errorf("%v is configured without %v", CfgSubchainForkNumMainchainBlocksPerDynasty,
+			CfgSubchainForkDynastyLengthMainchainHeight)
+	}
+	return []DynastyLengthChange{{
+		MainchainHeight:              viper.GetUint64(CfgSubchainForkDynastyLengthMainchainHeight),
+		NumMainchainBlocksPerDynasty: numBlocks,
+	}}, nil
+}
+
+// InitDynastySchedule sets up the dynasty schedule of the subchain from the dynasty length and the dynasty length changes
+// in its genesis state. A zero length stands for the default length, for the subchains whose genesis state predates the
+// configurable dynasty length. The dynasty length change configured as a fork only applies to the subchains whose genesis
+// state declares no change, since all the nodes must agree on the dynasties.
+func InitDynastySchedule(numMainchainBlocksPerDynasty int64, genesisChanges []DynastyLengthChange) error {
+	if numMainchainBlocksPerDynasty == 0 {
+		numMainchainBlocksPerDynasty = DefaultNumMainchainBlocksPerDynasty
+	}
+
+	changes := genesisChanges
+	configured, err := configuredDynastyLengthChanges()
+	if err != nil {
+		return err
+	}
+	if len(genesisChanges) == 0 {
+		changes = configured
+	} else if len(configured) > 0 {
+		logger.Warnf("Ignoring the configured dynasty length change %v, the genesis state declares the dynasty length changes %v",
+			configured, genesisChanges)
+	}
+
+	schedule, err := NewDynastyScheduleWithChanges(numMainchainBlocksPerDynasty, changes)
+	if err != nil {
+		return err
+	}
+
+	dynastyScheduleMutex.Lock()
+	defer dynastyScheduleMutex.Unlock()
+	dynastySchedule = schedule
+	return nil
+}
+
+// GetDynastySchedule returns the dynasty schedule of the subchain
+func GetDynastySchedule() *DynastySchedule {
+	dynastyScheduleMutex.RLock()
+	defer dynastyScheduleMutex.RUnlock()
+	return dynastySchedule
+}
+
+// CalculateDynasty returns the dynasty the given mainchain height falls into
+func CalculateDynasty(mainchainHeight *big.Int) *big.Int {
+	return GetDynastySchedule().CalculateDynasty(mainchainHeight)
+}
+
+// DynastyStartHeight returns the first mainchain height of the given dynasty
+func DynastyStartHeight(dynasty *big.Int) *big.Int {
+	return GetDynastySchedule().DynastyStartHeight(dynasty)
+}
@@ -0,0 +1,156 @@
+package common
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDynastySchedule(t *testing.T) {
+	assert := assert.New(t)
+
+	schedule, err := NewDynastyScheduleWithChanges(100, []DynastyLengthChange{
+		{MainchainHeight: 1000, NumMainchainBlocksPerDynasty: 50},
+		{MainchainHeight: 2000, NumMainchainBlocksPerDynasty: 200},
+	})
+	assert.Nil(err)
+
+	tests := []struct {
+		height     int64
+		dynasty    int64
+		startOfDyn int64
+		numBlocks  int64
+	}{
+		{0, 0, 0, 100},
+		{99, 0, 0, 100},
+		{100, 1, 100, 100},
+		{999, 9, 900, 100},
+		{1000, 10, 1000, 50},
+		{1049, 10, 1000, 50},
+		{1050, 11, 1050, 50},
+		{1999, 29, 1950, 50},
+		{2000, 30, 2000, 200},
+		{2199, 30, 2000, 200},
+		{2200, 31, 2200, 200},
+	}
+	for _, test := range tests {
+		height := big.NewInt(test.height)
+		dynasty := schedule.CalculateDynasty(height)
+		assert.Equal(test.dynasty, dynasty.Int64(), "height %v", test.height)
+		assert.Equal(test.startOfDyn, schedule.DynastyStartHeight(dynasty).Int64(), "height %v", test.height)
+		assert.Equal(test.numBlocks, schedule.NumMainchainBlocksPerDynasty(height), "height %v", test.height)
+	}
+}
+
+func TestChangeDynastyLength(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		name        string
+		startHeight int64
+		numBlocks   int64
+		valid       bool
+	}{
+		{"zero length", 1000, 0, false},
+		{"negative length", 1000, -50, false},
+		{"from height zero", 0, 50, false},
+		{"height not starting a dynasty", 1050, 50, false},
+		{"before the previous change", 400, 50, false},
+		{"at the previous change", 500, 50, false},
+		{"after the previous change", 700, 50, true},
+	}
+	for _, test := range tests {
+		schedule := NewDynastySchedule(100)
+		assert.Nil(schedule.ChangeDynastyLength(big.NewInt(500), 200))
+		err := schedule.ChangeDynastyLength(big.NewInt(test.startHeight), test.numBlocks)
+		assert.Equal(test.valid, err == nil, "%v: %v", test.name, err)
+	}
+}
+
+func TestInitDynastySchedule(t *testing.T) {
+	assert := assert.New(t)
+
+	type config struct {
+		numBlocks interface{}
+		height    interface{}
+	}
+	tests := []struct {
+		name           string
+		numBlocks      int64
+		genesisChanges []DynastyLengthChange
+		configured     config
+		valid          bool
+		numBlocksAt    map[int64]int64
+	}{
+		{
+			name:        "default length",
+			valid:       true,
+			numBlocksAt: map[int64]int64{0: DefaultNumMainchainBlocksPerDynasty, 100000: DefaultNumMainchainBlocksPerDynasty},
+		},
+		{
+			name:        "length in the genesis state",
+			numBlocks:   100,
+			valid:       true,
+			numBlocksAt: map[int64]int64{0: 100, 100000: 100},
+		},
+		{
+			name:        "configured change",
+			numBlocks:   100,
+			configured:  config{50, 1000},
+			valid:       true,
+			numBlocksAt: map[int64]int64{999: 100, 1000: 50},
+		},
+		{
+			name:        "configured zero length",
+			numBlocks:   100,
+			configured:  config{0, 1000},
+			valid:       true,
+			numBlocksAt: map[int64]int64{999: 100, 1000: 100},
+		},
+		{
+			name:        "configured length without a height",
+			numBlocks:   100,
+			configured:  config{50, nil},
+			valid:       false,
+			numBlocksAt: map[int64]int64{},
+		},
+		{
+			name:        "configured change not starting a dynasty",
+			numBlocks:   100,
+			configured:  config{50, 1050},
+			valid:       false,
+			numBlocksAt: map[int64]int64{},
+		},
+		{
+			name:           "genesis changes override the configured change",
+			numBlocks:      100,
+			genesisChanges: []DynastyLengthChange{{MainchainHeight: 2000, NumMainchainBlocksPerDynasty: 200}},
+			configured:     config{50, 1000},
+			valid:          true,
+			numBlocksAt:    map[int64]int64{1000: 100, 1999: 100, 2000: 200},
+		},
+		{
+			name:           "genesis change not starting a dynasty",
+			numBlocks:      100,
+			genesisChanges: []DynastyLengthChange{{MainchainHeight: 2050, NumMainchainBlocksPerDynasty: 200}},
+			valid:          false,
+			numBlocksAt:    map[int64]int64{},
+		},
+	}
+	for _, test := range tests {
+		viper.Set(CfgSubchainForkNumMainchainBlocksPerDynasty, test.configured.numBlocks)
+		viper.Set(CfgSubchainForkDynastyLengthMainchainHeight, test.configured.height)
+
+		err := InitDynastySchedule(test.numBlocks, test.genesisChanges)
+		assert.Equal(test.valid, err == nil, "%v: %v", test.name, err)
+		for height, numBlocks := range test.numBlocksAt {
+			assert.Equal(numBlocks, GetDynastySchedule().NumMainchainBlocksPerDynasty(big.NewInt(height)), "%v: height %v", test.name, height)
+		}
+	}
+
+	viper.Set(CfgSubchainForkNumMainchainBlocksPerDynasty, nil)
+	viper.Set(CfgSubchainForkDynastyLengthMainchainHeight, nil)
+	assert.Nil(InitDynastySchedule(0, nil))
+}
@@ -0,0 +1,189 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/spf13/viper"
+	tcom "github.com/thetatoken/theta/common"
+)
+
+// Names of the subchain forks. A fork activates a protocol change from its activation height on, which is
+// declared in the genesis state of the subchain, or configured with the subchain.fork.<name>Height key.
+const (
+	// ForkSmartContract enables the smart contract transactions
+	ForkSmartContract = "smartContract"
+	// ForkTheta3 enables the stake reward distribution transactions
+	ForkTheta3 = "theta3"
+	// ForkJune2021FeeAdjustment switches to the adjusted transaction fees and precompiled contract gas costs
+	ForkJune2021FeeAdjustment = "june2021FeeAdjustment"
+	// ForkTxWrapperExtension enables the Ethereum transaction wrapper of the smart contract transactions
+	ForkTxWrapperExtension = "txWrapperExtension"
+	// ForkRPCCompatibility enables the Ethereum RPC compatible transaction signatures
+	ForkRPCCompatibility = "rpcCompatibility"
+	// ForkEVMConstantinople switches the EVM from the Byzantium to the Constantinople instruction set
+	ForkEVMConstantinople = "evmConstantinople"
+	// ForkVRFProposerSelection selects the proposers with the verifiable randomness carried by the blocks
+	ForkVRFProposerSelection = "vrfProposerSelection"
+	// ForkBLSCommitCertificate aggregates the votes of the commit certificates into one BLS signature
+	ForkBLSCommitCertificate = "blsCommitCertificate"
+	// ForkMillisecondTimestamp switches the block timestamps to Unix milliseconds
+	ForkMillisecondTimestamp = "millisecondTimestamp"
+	// ForkAnchoredValidatorSetUpdate anchors the validator set updates to a mainchain block
+	ForkAnchoredValidatorSetUpdate = "anchoredValidatorSetUpdate"
+	// ForkGovernance enables the governance vote and the upgrade plan transactions
+	ForkGovernance = "governance"
+	// ForkNativeStaking enables the stake and unstake transactions of the governance token vouchers
+	ForkNativeStaking = "nativeStaking"
+	// ForkVoucherBurnRecords records the voucher burn events in the ledger state, so the withdrawals can be proven
+	ForkVoucherBurnRecords = "voucherBurnRecords"
+	// ForkEquivocationEvidence enables the equivocation evidence transactions, which slash the double signing validators
+	ForkEquivocationEvidence = "equivocationEvidence"
+	// ForkStakeEvents mirrors the stake events of the mainchain, and enables the commission rate update and reward claim transactions
+	ForkStakeEvents = "stakeEvents"
+	// ForkFutureTimestampBound holds back the blocks with timestamps too far ahead of the local clock, and adds timestamps to the votes
+	ForkFutureTimestampBound = "futureTimestampBound"
+)
+
+type forkDefinition struct {
+	name          string
+	cfgKey        string
+	defaultHeight uint64
+}
+
+// forkDefinitions lists the forks known to the node software. The forks inherited from the mainchain default
+// to the mainchain heights, which the existing subchains have been running with.
+var forkDefinitions = []forkDefinition{
+	{ForkSmartContract, CfgSubchainForkSmartContractHeight, tcom.HeightEnableSmartContract},
+	{ForkTheta3, CfgSubchainForkTheta3Height, tcom.HeightEnableTheta3},
+	{ForkJune2021FeeAdjustment, CfgSubchainForkJune2021FeeAdjustmentHeight, tcom.HeightJune2021FeeAdjustment},
+	{ForkTxWrapperExtension, CfgSubchainForkTxWrapperExtensionHeight, tcom.HeightTxWrapperExtension},
+	{ForkRPCCompatibility, CfgSubchainForkRPCCompatibilityHeight, tcom.HeightRPCCompatibility},
+	{ForkEVMConstantinople, CfgSubchainForkEVMConstantinopleHeight, 0},
+	{ForkVRFProposerSelection, CfgSubchainForkVRFProposerSelectionHeight, math.MaxUint64},             // disabled unless configured
+	{ForkBLSCommitCertificate, CfgSubchainForkBLSCommitCertificateHeight, math.MaxUint64},             // disabled unless configured
+	{ForkMillisecondTimestamp, CfgSubchainForkMillisecondTimestampHeight, math.MaxUint64},             // disabled unless configured
+	{ForkAnchoredValidatorSetUpdate, CfgSubchainForkAnchoredValidatorSetUpdateHeight, math.MaxUint64}, // disabled unless configured
+	{ForkGovernance, CfgSubchainForkGovernanceHeight, math.MaxUint64},                                 // disabled unless configured
+	{ForkNativeStaking, CfgSubchainForkNativeStakingHeight, math.MaxUint64},                           // disabled unless configured
+	{ForkVoucherBurnRecords, CfgSubchainForkVoucherBurnRecordsHeight, math.MaxUint64},                 // disabled unless configured
+	{ForkEquivocationEvidence, CfgSubchainForkEquivocationEvidenceHeight, math.MaxUint64},             // disabled unless configured
+	{ForkStakeEvents, CfgSubchainForkStakeEventsHeight, math.MaxUint64},                               // disabled unless configured
+	{ForkFutureTimestampBound, CfgSubchainForkFutureTimestampBoundHeight, math.MaxUint64},             // disabled unless configured
+}
+
+// Fork is a named protocol change activated at the given height
+type Fork struct {
+	Name   string
+	Height uint64
+}
+
+// String represents the string representation of the fork
+func (f Fork) String() string {
+	return fmt.Sprintf("{Name: %v, Height: %v}", f.Name, f.Height)
+}
+
+// ForkSchedule holds the activation heights of the forks of the subchain
+type ForkSchedule struct {
+	heights map[string]uint64
+}
+
+// NewForkSchedule creates the fork schedule from the forks declared in the genesis state, and the configured
+// activation heights of the other forks, which default to the heights in forkDefinitions. A fork declared in
+// the genesis state cannot be explicitly configured to a different height, and the genesis state cannot
+// declare a fork unknown to the node software.
+func NewForkSchedule(genesisForks []Fork) (*ForkSchedule, error) {
+	heights := make(map[string]uint64)
+	configured := make(map[string]bool)
+	for _, def := range forkDefinitions {
+		heights[def.name] = def.defaultHeight
+		if viper.IsSet(def.cfgKey) {
+			heights[def.name] = viper.GetUint64(def.cfgKey)
+			configured[def.name] = true
+		}
+	}
+	for _, fork := range genesisForks {
+		configuredHeight, ok := heights[fork.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown fork %v in the genesis state, the node software might need an upgrade", fork.Name)
+		}
+		if configured[fork.Name] && configuredHeight != fork.Height {
+			return nil, fmt.Errorf("fork %v is declared at height %v in the genesis state, but configured at height %v",
+				fork.Name, fork.Height, configuredHeight)
+		}
+		heights[fork.Name] = fork.Height
+	}
+	return &ForkSchedule{heights: heights}, nil
+}
+
+// IsActive returns whether the given fork is active at the given height
+func (s *ForkSchedule) IsActive(name string, height uint64) bool {
+	activationHeight, ok := s.heights[name]
+	if !ok {
+		logger.Panicf("Unknown fork: %v", name)
+	}
+	return height >= activationHeight
+}
+
+// ActivationHeight returns the activation height of the given fork
+func (s *ForkSchedule) ActivationHeight(name string) uint64 {
+	activationHeight, ok := s.heights[name]
+	if !ok {
+		logger.Panicf("Unknown fork: %v", name)
+	}
+	return activationHeight
+}
+
+// Forks returns all the forks, ordered by the activation height
+func (s *ForkSchedule) Forks() []Fork {
+	forks := []Fork{}
+	for name, height := range s.heights {
+		forks = append(forks, Fork{Name: name, Height: height})
+	}
+	sort.Slice(forks, func(i, j int) bool {
+		if forks[i].Height != forks[j].Height {
+			return forks[i].Height < forks[j].Height
+		}
+		return forks[i].Name < forks[j].Name
+	})
+	return forks
+}
+
+var (
+	forkSchedule      *ForkSchedule
+	forkScheduleMutex = &sync.RWMutex{}
+)
+
+// InitForkSchedule sets up the fork schedule of the subchain from the forks declared in its genesis state
+// and the configuration
+func InitForkSchedule(genesisForks []Fork) error {
+	schedule, err := NewForkSchedule(genesisForks)
+	if err != nil {
+		return err
+	}
+
+	forkScheduleMutex.Lock()
+	defer forkScheduleMutex.Unlock()
+	forkSchedule = schedule
+	return nil
+}
+
+// GetForkSchedule returns the fork schedule of the subchain. Until it is set up, the schedule is read from
+// the configuration, e.g. for the tools which do not load the genesis state.
+func GetForkSchedule() *ForkSchedule {
+	forkScheduleMutex.RLock()
+	schedule := forkSchedule
+	forkScheduleMutex.RUnlock()
+	if schedule != nil {
+		return schedule
+	}
+	schedule, _ = NewForkSchedule(nil) // cannot fail without the genesis forks
+	return schedule
+}
+
+// IsForkActive returns whether the given fork is active at the given height
+func IsForkActive(name string, height uint64) bool {
+	return GetForkSchedule().IsActive(name, height)
+}
@@ -0,0 +1,159 @@
+package common
+
+import (
+	"math"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	tcom "github.com/thetatoken/theta/common"
+)
+
+// configureForks configures the given fork heights, and returns a function removing them from the configuration
+func configureForks(heights map[string]interface{}) func() {
+	for cfgKey, height := range heights {
+		viper.Set(cfgKey, height)
+	}
+	return func() {
+		for cfgKey := range heights {
+			viper.Set(cfgKey, nil)
+		}
+	}
+}
+
+func TestNewForkSchedule(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		name         string
+		configured   map[string]interface{}
+		genesisForks []Fork
+		valid        bool
+		heights      map[string]uint64
+	}{
+		{
+			name:  "default heights",
+			valid: true,
+			heights: map[string]uint64{
+				ForkSmartContract:     tcom.HeightEnableSmartContract,
+				ForkEVMConstantinople: 0,
+				ForkGovernance:        math.MaxUint64,
+				ForkStakeEvents:       math.MaxUint64,
+			},
+		},
+		{
+			name:       "configured heights",
+			configured: map[string]interface{}{CfgSubchainForkGovernanceHeight: 1000, CfgSubchainForkSmartContractHeight: "20"},
+			valid:      true,
+			heights:    map[string]uint64{ForkGovernance: 1000, ForkSmartContract: 20, ForkNativeStaking: math.MaxUint64},
+		},
+		{
+			name:       "configured to height zero",
+			configured: map[string]interface{}{CfgSubchainForkGovernanceHeight: 0},
+			valid:      true,
+			heights:    map[string]uint64{ForkGovernance: 0},
+		},
+		{
+			name:         "declared in the genesis state",
+			genesisForks: []Fork{{ForkGovernance, 500}, {ForkSmartContract, 0}},
+			valid:        true,
+			heights:      map[string]uint64{ForkGovernance: 500, ForkSmartContract: 0, ForkNativeStaking: math.MaxUint64},
+		},
+		{
+			name:         "configured to the genesis height",
+			configured:   map[string]interface{}{CfgSubchainForkGovernanceHeight: 500},
+			genesisForks: []Fork{{ForkGovernance, 500}},
+			valid:        true,
+			heights:      map[string]uint64{ForkGovernance: 500},
+		},
+		{
+			name:         "genesis state overrides the other configured forks",
+			configured:   map[string]interface{}{CfgSubchainForkNativeStakingHeight: 800},
+			genesisForks: []Fork{{ForkGovernance, 500}},
+			valid:        true,
+			heights:      map[string]uint64{ForkGovernance: 500, ForkNativeStaking: 800},
+		},
+		{
+			name:         "configured to another height than the genesis state",
+			configured:   map[string]interface{}{CfgSubchainForkGovernanceHeight: 1000},
+			genesisForks: []Fork{{ForkGovernance, 500}},
+			valid:        false,
+		},
+		{
+			name:         "configured to the default height explicitly",
+			configured:   map[string]interface{}{CfgSubchainForkGovernanceHeight: uint64(math.MaxUint64)},
+			genesisForks: []Fork{{ForkGovernance, 500}},
+			valid:        false,
+		},
+		{
+			name:         "unknown fork in the genesis state",
+			genesisForks: []Fork{{"unknownFork", 500}},
+			valid:        false,
+		},
+	}
+	for _, test := range tests {
+		restore := configureForks(test.configured)
+		schedule, err := NewForkSchedule(test.genesisForks)
+		restore()
+
+		assert.Equal(test.valid, err == nil, "%v: %v", test.name, err)
+		if err != nil {
+			continue
+		}
+		for name, height := range test.heights {
+			assert.Equal(height, schedule.ActivationHeight(name), "%v: %v", test.name, name)
+		}
+		assert.Equal(len(forkDefinitions), len(schedule.Forks()), test.name)
+	}
+}
+
+func TestForkScheduleIsActive(t *testing.T) {
+	assert := assert.New(t)
+
+	schedule, err := NewForkSchedule([]Fork{{ForkGovernance, 500}, {ForkSmartContract, 0}})
+	assert.Nil(err)
+
+	tests := []struct {
+		name   string
+		height uint64
+		active bool
+	}{
+		{ForkSmartContract, 0, true},
+		{ForkSmartContract, 1, true},
+		{ForkGovernance, 0, false},
+		{ForkGovernance, 499, false},
+		{ForkGovernance, 500, true},
+		{ForkGovernance, math.MaxUint64, true},
+		{ForkNativeStaking, math.MaxUint64 - 1, false},
+	}
+	for _, test := range tests {
+		assert.Equal(test.active, schedule.IsActive(test.name, test.height), "%v at height %v", test.name, test.height)
+	}
+	assert.Panics(func() { schedule.IsActive("unknownFork", 0) })
+
+	forks := schedule.Forks()
+	assert.Equal(Fork{ForkEVMConstantinople, 0}, forks[0])
+	assert.Equal(Fork{ForkSmartContract, 0}, forks[1])
+	for i := 1; i < len(forks); i++ {
+		assert.True(forks[i-1].Height <= forks[i].Height)
+	}
+}
+
+func TestInitForkSchedule(t *testing.T) {
+	assert := assert.New(t)
+
+	defer InitForkSchedule(nil)
+
+	assert.Nil(InitForkSchedule([]Fork{{ForkGovernance, 500}}))
+	assert.True(IsForkActive(ForkGovernance, 500))
+
+	// An invalid schedule leaves the current one in place
+	assert.NotNil(InitForkSchedule([]Fork{{"unknownFork", 100}}))
+	assert.Equal(uint64(500), GetForkSchedule().ActivationHeight(ForkGovernance))
+
+	defer configureForks(map[string]interface{}{CfgSubchainForkGovernanceHeight: 1000})()
+	assert.NotNil(InitForkSchedule([]Fork{{ForkGovernance, 500}}))
+	assert.Nil(InitForkSchedule(nil))
+	assert.False(IsForkActive(ForkGovernance, 999))
+	assert.True(IsForkActive(ForkGovernance, 1000))
+}
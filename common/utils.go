@@ -2,17 +2,15 @@ package common
 
 import (
 	"math/big"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 	"github.com/thetatoken/theta/ledger/types"
 )
 
 var logger *log.Entry = log.WithFields(log.Fields{"prefix": "common"})
 
-func CalculateDynasty(mainchainHeight *big.Int) *big.Int {
-	return new(big.Int).Div(mainchainHeight, big.NewInt(NumMainchainBlocksPerDynasty))
-}
-
 func MapChainID(chainIDStr string) *big.Int {
 	mainchainBlockHeight := uint64(1000000000) // doesn't really matter for subchains, just set it to a sufficiently large number
 	return types.MapChainID(chainIDStr, mainchainBlockHeight)
@@ -21,3 +19,25 @@ func MapChainID(chainIDStr string) *big.Int {
 func GetMinimumGasPrice() *big.Int {
 	return big.NewInt(int64(MinimumGasPrice))
 }
+
+// MillisecondTimestampEnabled returns true if the timestamp of the block at the given height is in Unix milliseconds
+func MillisecondTimestampEnabled(height uint64) bool {
+	return IsForkActive(ForkMillisecondTimestamp, height)
+}
+
+// AnchoredValidatorSetUpdateEnabled returns true if the validator set update transactions in the block at the given
+// height need to reference the mainchain block they are derived from
+func AnchoredValidatorSetUpdateEnabled(height uint64) bool {
+	return IsForkActive(ForkAnchoredValidatorSetUpdate, height)
+}
+
+// GetMinBlockInterval returns the minimal interval between the blocks proposed at the given height. Sub-second
+// intervals are only possible once the block timestamps are in milliseconds.
+func GetMinBlockInterval(height uint64) time.Duration {
+	if MillisecondTimestampEnabled(height) {
+		if intervalInMs := viper.GetInt64(CfgConsensusMinBlockIntervalInMilliseconds); intervalInMs > 0 {
+			return time.Duration(intervalInMs) * time.Millisecond
+		}
+	}
+	return time.Duration(viper.GetInt(CfgConsensusMinBlockInterval)) * time.Second
+}
@@ -0,0 +1,47 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMinBlockInterval(t *testing.T) {
+	assert := assert.New(t)
+
+	// the block timestamps switch to milliseconds from height 1000 on
+	defer configureForks(map[string]interface{}{CfgSubchainForkMillisecondTimestampHeight: 1000})()
+	InitForkSchedule(nil)
+	defer InitForkSchedule(nil)
+
+	previousInterval := viper.Get(CfgConsensusMinBlockInterval)
+	previousIntervalInMs := viper.Get(CfgConsensusMinBlockIntervalInMilliseconds)
+	defer func() {
+		viper.Set(CfgConsensusMinBlockInterval, previousInterval)
+		viper.Set(CfgConsensusMinBlockIntervalInMilliseconds, previousIntervalInMs)
+	}()
+	viper.Set(CfgConsensusMinBlockInterval, 2)
+
+	tests := []struct {
+		name          string
+		intervalInMs  int64
+		height        uint64
+		msTimestamp   bool
+		blockInterval time.Duration
+	}{
+		{"sub-second interval, before the fork", 500, 999, false, 2 * time.Second},
+		{"sub-second interval, at the fork", 500, 1000, true, 500 * time.Millisecond},
+		{"sub-second interval, after the fork", 500, 1001, true, 500 * time.Millisecond},
+		{"interval in seconds, before the fork", 0, 999, false, 2 * time.Second},
+		{"interval in seconds, at the fork", 0, 1000, true, 2 * time.Second},
+		{"interval in seconds, after the fork", 0, 1001, true, 2 * time.Second},
+		{"interval above a second, at the fork", 1500, 1000, true, 1500 * time.Millisecond},
+	}
+	for _, test := range tests {
+		viper.Set(CfgConsensusMinBlockIntervalInMilliseconds, test.intervalInMs)
+		assert.Equal(test.msTimestamp, MillisecondTimestampEnabled(test.height), test.name)
+		assert.Equal(test.blockInterval, GetMinBlockInterval(test.height), test.name)
+	}
+}
@@ -0,0 +1,11 @@
+package consensus
+
+import (
+	scom "github.com/thetatoken/thetasubchain/common"
+)
+
+// blsCommitCertificateEnabled returns true if the votes on the block at the given height carry a BLS
+// signature, and the commit certificate carried by the block may aggregate them
+func blsCommitCertificateEnabled(height uint64) bool {
+	return scom.IsForkActive(scom.ForkBLSCommitCertificate, height)
+}
@@ -0,0 +1,77 @@
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+)
+
+// setForkHeight activates a fork at the given height, and returns the function restoring the fork schedule
+func setForkHeight(cfgKey string, height uint64) func() {
+	previous := viper.Get(cfgKey)
+	viper.Set(cfgKey, height)
+	scom.InitForkSchedule(nil)
+	return func() {
+		viper.Set(cfgKey, previous)
+		scom.InitForkSchedule(nil)
+	}
+}
+
+func TestBLSCommitCertificateEnabled(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.False(blsCommitCertificateEnabled(1000000))
+
+	defer setForkHeight(scom.CfgSubchainForkBLSCommitCertificateHeight, 100)()
+	tests := []struct {
+		height  uint64
+		enabled bool
+	}{
+		{0, false},
+		{99, false},
+		{100, true},
+		{101, true},
+	}
+	for _, test := range tests {
+		assert.Equal(test.enabled, blsCommitCertificateEnabled(test.height), "height %v", test.height)
+	}
+}
+
+func TestSimulationAcrossBLSCommitCertificateFork(t *testing.T) {
+	assert := assert.New(t)
+
+	const forkHeight = 10
+	defer setForkHeight(scom.CfgSubchainForkBLSCommitCertificateHeight, forkHeight)()
+
+	sim := NewSimulation(SimulationConfig{
+		NumNodes: 4,
+		Seed:     5,
+		MinDelay: 50 * time.Millisecond,
+		MaxDelay: 200 * time.Millisecond,
+	})
+	sim.Run(60 * time.Second)
+
+	sim.AssertSafety(assert)
+	sim.AssertLiveness(assert, 2*forkHeight)
+
+	// The commit certificates are only aggregated after the fork
+	node := sim.Nodes()[0]
+	aggregated := 0
+	for block := node.Engine.GetLastFinalizedBlock(); block.Height > node.Chain.Root().Height; {
+		if block.HCC.Aggregated != nil {
+			assert.True(block.Height >= forkHeight, "Aggregated HCC at height %v", block.Height)
+			assert.True(block.HCC.IsValid(node.Engine.validatorManager.GetValidatorSet(block.HCC.BlockHash)))
+			aggregated++
+		}
+		parent, err := node.Chain.FindBlock(block.Parent)
+		if !assert.Nil(err) {
+			break
+		}
+		block = parent
+	}
+	assert.True(aggregated > 0)
+}
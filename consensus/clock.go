@@ -0,0 +1,43 @@
+package consensus
+
+import (
+	"time"
+)
+
+// Clock is the source of time of the consensus engine. The consensus simulations replace the
+// system clock with a virtual clock, so that the epochs advance deterministically.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single-shot timer created by a Clock.
+type Timer interface {
+	Chan() <-chan time.Time
+	Stop() bool
+}
+
+var _ Clock = systemClock{}
+
+// systemClock is the Clock backed by the system time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return &systemTimer{timer: time.NewTimer(d)}
+}
+
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func (t *systemTimer) Chan() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t *systemTimer) Stop() bool {
+	return t.timer.Stop()
+}
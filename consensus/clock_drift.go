@@ -0,0 +1,95 @@
+package consensus
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+)
+
+// clockDriftGauge reports the estimated drift of the validator majority's clocks from the local clock, in milliseconds
+var clockDriftGauge = metrics.NewRegisteredGauge("consensus/clockdrift", nil)
+
+// ClockDriftMonitor estimates how far the local clock drifts from the clocks of the validators. Every
+// proposal received in the current epoch yields a sample of the proposer's clock skew, i.e. the block
+// timestamp minus the local time the block arrived. After the future timestamp bound fork, the votes
+// carry the local time of the voters, and yield samples of the voters' clock skew the same way. Once
+// the validators with samples hold the majority of the stake, their median skew is taken as the drift
+// of the local clock.
+type ClockDriftMonitor struct {
+	mu *sync.Mutex
+
+	skews    map[common.Address]int64 // latest clock skew of each validator, in milliseconds
+	drift    int64
+	hasDrift bool // whether the drift is known
+	drifting bool // whether the drift exceeds the warning threshold
+}
+
+// NewClockDriftMonitor creates an instance of ClockDriftMonitor.
+func NewClockDriftMonitor() *ClockDriftMonitor {
+	return &ClockDriftMonitor{
+		mu:    &sync.Mutex{},
+		skews: make(map[common.Address]int64),
+	}
+}
+
+// AddSample records the clock skew of the validator, and re-estimates the drift of the local clock against
+// the given validator set.
+func (m *ClockDriftMonitor) AddSample(validator common.Address, skewInMs int64, validators *score.ValidatorSet) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.skews[validator] = skewInMs
+
+	sampled := []common.Address{}
+	skews := []int64{}
+	for _, v := range validators.Validators() {
+		if skew, ok := m.skews[v.Address]; ok {
+			sampled = append(sampled, v.Address)
+			skews = append(skews, skew)
+		}
+	}
+	if !validators.HasMajorityVoters(sampled) {
+		return
+	}
+
+	sort.Slice(skews, func(i, j int) bool { return skews[i] < skews[j] })
+	m.drift = skews[len(skews)/2]
+	m.hasDrift = true
+	clockDriftGauge.Update(m.drift)
+
+	threshold := viper.GetInt64(scom.CfgConsensusClockDriftWarningThresholdInMilliseconds)
+	drifting := m.drift > threshold || m.drift < -threshold
+	if drifting {
+		logger.WithFields(log.Fields{
+			"drift(ms)":     m.drift,
+			"threshold(ms)": threshold,
+		}).Warn("The local clock disagrees with the clocks of the validator majority, please check the system time")
+	} else if m.drifting {
+		logger.WithFields(log.Fields{"drift(ms)": m.drift}).Info("The local clock agrees with the clocks of the validator majority again")
+	}
+	m.drifting = drifting
+}
+
+// GetDrift returns the estimated drift of the validator majority's clocks from the local clock in
+// milliseconds, positive if the local clock is behind. It returns false if there are not enough samples yet.
+func (m *ClockDriftMonitor) GetDrift() (int64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.drift, m.hasDrift
+}
+
+// IsDrifting returns whether the drift of the local clock exceeds the warning threshold.
+func (m *ClockDriftMonitor) IsDrifting() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.drifting
+}
@@ -0,0 +1,181 @@
+package consensus
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+)
+
+func TestClockDriftMonitor(t *testing.T) {
+	assert := assert.New(t)
+
+	a := common.HexToAddress("0x0a")
+	b := common.HexToAddress("0x0b")
+	c := common.HexToAddress("0x0c")
+	d := common.HexToAddress("0x0d")
+	outsider := common.HexToAddress("0x0e")
+	validators := score.NewValidatorSet(big.NewInt(1))
+	for _, addr := range []common.Address{a, b, c, d} {
+		validators.AddValidator(score.NewValidator(addr.Hex(), big.NewInt(100)))
+	}
+
+	type sample struct {
+		validator common.Address
+		skew      int64
+	}
+	tests := []struct {
+		name     string
+		samples  []sample
+		hasDrift bool
+		drift    int64
+		drifting bool
+	}{
+		{"no sample", []sample{}, false, 0, false},
+		{"no majority", []sample{{a, 100}, {b, 200}}, false, 0, false},
+		{"outsiders do not count", []sample{{a, 100}, {b, 200}, {outsider, 300}}, false, 0, false},
+		{"median of the majority", []sample{{a, 100}, {b, 300}, {c, 200}}, true, 200, false},
+		{"latest sample of each validator", []sample{{a, 100}, {b, 300}, {c, 200}, {b, 50}, {d, 60}}, true, 100, false},
+		{"lagging local clock", []sample{{a, 9000}, {b, 8000}, {c, 10000}}, true, 9000, true},
+		{"local clock ahead", []sample{{a, -9000}, {b, -8000}, {c, -10000}}, true, -9000, true},
+		{"one validator drifting", []sample{{a, 100}, {b, 200}, {c, 60000}}, true, 200, false},
+	}
+	for _, test := range tests {
+		m := NewClockDriftMonitor()
+		for _, s := range test.samples {
+			m.AddSample(s.validator, s.skew, validators)
+		}
+		drift, hasDrift := m.GetDrift()
+		assert.Equal(test.hasDrift, hasDrift, test.name)
+		assert.Equal(test.drift, drift, test.name)
+		assert.Equal(test.drifting, m.IsDrifting(), test.name)
+	}
+}
+
+func TestSimulationWithLaggingClock(t *testing.T) {
+	assert := assert.New(t)
+
+	defer setForkHeight(scom.CfgSubchainForkFutureTimestampBoundHeight, 0)()
+
+	sim := NewSimulation(SimulationConfig{
+		NumNodes: 4,
+		Seed:     6,
+		MinDelay: 50 * time.Millisecond,
+		MaxDelay: 200 * time.Millisecond,
+	})
+	lagging := sim.Node(3)
+	sim.SetClockOffset(lagging.Index, -60*time.Second)
+	sim.Run(30 * time.Second)
+
+	// The lagging node holds back the blocks of the others, and learns its drift from their votes
+	assert.True(len(lagging.Engine.futureBlocks) > 0)
+	assert.True(sim.Node(0).Engine.GetLastFinalizedBlock().Height > lagging.Engine.GetLastFinalizedBlock().Height)
+	drift, ok := lagging.Engine.clockDrift.GetDrift()
+	assert.True(ok)
+	assert.InDelta(60000, drift, 1000)
+	assert.True(lagging.Engine.clockDrift.IsDrifting())
+
+	// The held back blocks are still pending, so the node catches up once its clock is corrected
+	for _, block := range lagging.Engine.futureBlocks {
+		eb, err := lagging.Chain.FindBlock(block.Hash())
+		assert.Nil(err)
+		assert.True(eb.Status.IsPending())
+	}
+	sim.SetClockOffset(lagging.Index, 0)
+	sim.Run(60 * time.Second)
+
+	assert.Equal(0, len(lagging.Engine.futureBlocks))
+	sim.AssertSafety(assert)
+	sim.AssertLiveness(assert, 10)
+}
+
+func TestDeferFutureBlockFull(t *testing.T) {
+	assert := assert.New(t)
+
+	defer setForkHeight(scom.CfgSubchainForkFutureTimestampBoundHeight, 0)()
+
+	sim := NewSimulation(SimulationConfig{NumNodes: 1, Seed: 1})
+	node := sim.Node(0)
+	engine := node.Engine
+	now := sim.Now().Unix()
+
+	newBlock := func(parent *score.Block, timestamp int64) *score.Block {
+		block := score.NewBlock()
+		block.ChainID = parent.ChainID
+		block.Height = parent.Height + 1
+		block.Epoch = parent.Epoch + 1
+		block.Parent = parent.Hash()
+		block.HCC.BlockHash = parent.Hash()
+		block.Timestamp = big.NewInt(timestamp)
+		if _, err := node.Chain.AddBlock(block); err != nil {
+			t.Fatal(err)
+		}
+		return block
+	}
+	isInvalid := func(block *score.Block) bool {
+		eb, err := node.Chain.FindBlock(block.Hash())
+		assert.Nil(err)
+		return eb.Status.IsInvalid()
+	}
+
+	// Fill the held back blocks, with the furthest one followed by a child, held back along with its parent
+	// even though its own timestamp is not in the future
+	furthest := newBlock(sim.root, now+1000)
+	child := newBlock(furthest, now+10)
+	assert.True(engine.deferFutureBlock(furthest))
+	assert.True(engine.deferFutureBlock(child))
+	for i := 0; i < maxNumFutureBlocks-2; i++ {
+		assert.True(engine.deferFutureBlock(newBlock(sim.root, now+600+int64(i))))
+	}
+	assert.Equal(maxNumFutureBlocks, len(engine.futureBlocks))
+	assert.False(isInvalid(furthest))
+
+	// A new block makes room for itself by rejecting the furthest block, and its descendants
+	near := newBlock(sim.root, now+300)
+	assert.True(engine.deferFutureBlock(near))
+	assert.Contains(engine.futureBlocks, near.Hash())
+	assert.NotContains(engine.futureBlocks, furthest.Hash())
+	assert.NotContains(engine.futureBlocks, child.Hash())
+	assert.True(isInvalid(furthest))
+	assert.True(isInvalid(child))
+	assert.False(isInvalid(near))
+	assert.Equal(maxNumFutureBlocks-1, len(engine.futureBlocks))
+
+	// A new block further in the future than all the held back ones is rejected itself
+	assert.True(engine.deferFutureBlock(newBlock(sim.root, now+700)))
+	assert.Equal(maxNumFutureBlocks, len(engine.futureBlocks))
+	far := newBlock(sim.root, now+2000)
+	assert.True(engine.deferFutureBlock(far))
+	assert.NotContains(engine.futureBlocks, far.Hash())
+	assert.True(isInvalid(far))
+	assert.Equal(maxNumFutureBlocks, len(engine.futureBlocks))
+
+	// A block within the drift bound is not held back
+	assert.False(engine.deferFutureBlock(newBlock(sim.root, now+1)))
+}
+
+func TestMinBlockTimestamp(t *testing.T) {
+	assert := assert.New(t)
+
+	defer setForkHeight(scom.CfgSubchainForkMillisecondTimestampHeight, 1000)()
+
+	tests := []struct {
+		name            string
+		height          uint64
+		parentTimestamp int64
+		minTimestamp    int64
+	}{
+		{"before the fork, in seconds", 999, 1700000000, 1700000001},
+		{"at the fork, the parent timestamp is converted to milliseconds", 1000, 1700000000, 1700000000001},
+		{"after the fork, in milliseconds", 1001, 1700000000001, 1700000000002},
+	}
+	for _, test := range tests {
+		parent := &score.BlockHeader{Height: test.height - 1, Timestamp: big.NewInt(test.parentTimestamp)}
+		assert.Equal(0, big.NewInt(test.minTimestamp).Cmp(minBlockTimestamp(test.height, parent)), test.name)
+	}
+}
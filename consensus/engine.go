@@ -1,8 +1,10 @@
 package consensus
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"math"
 	"math/big"
 	"sort"
 
@@ -10,8 +12,6 @@ import (
 	"sync"
 	"time"
 
-	// "github.com/thetatoken/theta/crypto/bls"
-
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"github.com/thetatoken/theta/common"
@@ -19,44 +19,66 @@ import (
 	"github.com/thetatoken/theta/common/util"
 	"github.com/thetatoken/theta/crypto"
 	"github.com/thetatoken/theta/dispatcher"
+	"github.com/thetatoken/theta/ledger/types"
 	"github.com/thetatoken/theta/rlp"
 	"github.com/thetatoken/theta/store"
 
 	sbc "github.com/thetatoken/thetasubchain/blockchain"
 	scom "github.com/thetatoken/thetasubchain/common"
 	score "github.com/thetatoken/thetasubchain/core"
+	"github.com/thetatoken/thetasubchain/crypto/vrf"
 	"github.com/thetatoken/thetasubchain/interchain/witness"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+	ssigner "github.com/thetatoken/thetasubchain/signer"
+	sversion "github.com/thetatoken/thetasubchain/version"
 )
 
 var logger = log.WithFields(log.Fields{"prefix": "consensus"})
 
 var _ score.ConsensusEngine = (*ConsensusEngine)(nil)
 
+// maxNumFutureBlocks is the maximal number of blocks held back until the local clock catches up with their timestamps
+const maxNumFutureBlocks = 256
+
+// MessageSender sends the consensus messages to the peers. It is implemented by the dispatcher,
+// and by the simulated network of the consensus simulations.
+type MessageSender interface {
+	SendData(peerIDs []string, datamsg dispatcher.DataResponse)
+}
+
 // ConsensusEngine is the default implementation of the Engine interface.
 type ConsensusEngine struct {
 	logger *log.Entry
 
 	privateKey *crypto.PrivateKey
+	signer     score.Signer
 
 	chain            *sbc.Chain
-	dispatcher       *dispatcher.Dispatcher
+	dispatcher       MessageSender
 	validatorManager score.ValidatorManager
 	ledger           score.Ledger
 	metachainWitness witness.ChainWitness
+	evidencePool     *EvidencePool
+	livenessTracker  *LivenessTracker
+	clockDrift       *ClockDriftMonitor
+	futureBlocks     map[common.Hash]*score.Block // blocks held back until the local clock catches up with their timestamps
 
 	incoming        chan interface{}
+	loopback        func(msg interface{}) // handles the messages created by the engine itself, nil to use the incoming queue
 	finalizedBlocks chan *score.Block
 	hasSynced       bool
+	clock           Clock
 
 	// Life cycle
 	wg      *sync.WaitGroup
 	ctx     context.Context
 	cancel  context.CancelFunc
 	stopped bool
+	halted  chan struct{} // closed when the engine halts for an upgrade plan the node software does not support
 
 	mu         *sync.Mutex
-	voteTimer  *time.Timer
-	epochTimer *time.Timer
+	voteTimer  Timer
+	epochTimer Timer
 
 	voteTimerReady bool
 	blockProcessed bool
@@ -72,11 +94,15 @@ func NewConsensusEngine(privateKey *crypto.PrivateKey, db store.Store, chain *sb
 		dispatcher: dispatcher,
 
 		privateKey: privateKey,
+		signer:     ssigner.NewLocalSigner(privateKey),
 
 		incoming:        make(chan interface{}, viper.GetInt(common.CfgConsensusMessageQueueSize)),
 		finalizedBlocks: make(chan *score.Block, viper.GetInt(common.CfgConsensusMessageQueueSize)),
 
-		wg: &sync.WaitGroup{},
+		clock: systemClock{},
+
+		wg:     &sync.WaitGroup{},
+		halted: make(chan struct{}),
 
 		mu:    &sync.Mutex{},
 		state: NewState(db, chain),
@@ -87,6 +113,10 @@ func NewConsensusEngine(privateKey *crypto.PrivateKey, db store.Store, chain *sb
 		blockProcessed: false,
 
 		metachainWitness: metachainWitness,
+		evidencePool:     NewEvidencePool(db, chain),
+		livenessTracker:  NewLivenessTracker(db, chain, validatorManager),
+		clockDrift:       NewClockDriftMonitor(),
+		futureBlocks:     make(map[common.Hash]*score.Block),
 	}
 
 	logger = util.GetLoggerForModule("consensus")
@@ -106,9 +136,81 @@ func (e *ConsensusEngine) GetLedger() score.Ledger {
 	return e.ledger
 }
 
+// GetEvidencePool returns the pool of the detected equivocation evidence
+func (e *ConsensusEngine) GetEvidencePool() score.EvidencePool {
+	return e.evidencePool
+}
+
+// GetLivenessTracker returns the tracker of the validator liveness
+func (e *ConsensusEngine) GetLivenessTracker() *LivenessTracker {
+	return e.livenessTracker
+}
+
+// GetMetachainWitness returns the witness of the mainchain
+func (e *ConsensusEngine) GetMetachainWitness() witness.ChainWitness {
+	return e.metachainWitness
+}
+
+// GetClockDriftMonitor returns the monitor of the local clock drift from the validators
+func (e *ConsensusEngine) GetClockDriftMonitor() *ClockDriftMonitor {
+	return e.clockDrift
+}
+
+// SetSigner sets the signer of the consensus messages, e.g. a remote signer holding the validator key
+func (e *ConsensusEngine) SetSigner(signer score.Signer) {
+	e.signer = signer
+}
+
+// isStandingBy returns whether the node is a hot standby which follows the chain without signing
+func (e *ConsensusEngine) isStandingBy() bool {
+	standbySigner, ok := e.signer.(score.StandbySigner)
+	return ok && standbySigner.IsStandingBy()
+}
+
+// Halted returns a channel which is closed when the engine halts at the height of an upgrade plan
+// requiring a newer version of the node software
+func (e *ConsensusEngine) Halted() <-chan struct{} {
+	return e.halted
+}
+
+// isHalted returns whether the engine has halted for an upgrade plan
+func (e *ConsensusEngine) isHalted() bool {
+	select {
+	case <-e.halted:
+		return true
+	default:
+		return false
+	}
+}
+
+// checkUpgradePlans halts the engine if an upgrade plan approved in the state of the parent block requires
+// a newer version of the node software at the given height. Returns whether the engine has halted.
+func (e *ConsensusEngine) checkUpgradePlans(parent *score.Block, height uint64) bool {
+	if e.isHalted() {
+		return true
+	}
+	plan := score.FindUnsupportedUpgradePlan(e.ledger.GetUpgradePlans(parent), height, sversion.Version)
+	if plan == nil {
+		return false
+	}
+	e.logger.WithFields(log.Fields{
+		"version":         sversion.Version,
+		"upgrade.Version": plan.Version,
+		"upgrade.Height":  plan.Height,
+	}).Errorf("Halting at height %v: the upgrade plan requires version %v or later of the node software, running version %v. Please upgrade the node and restart",
+		height, plan.Version, sversion.Version)
+	close(e.halted)
+	return true
+}
+
+// GetSigner returns the signer of the consensus messages
+func (e *ConsensusEngine) GetSigner() score.Signer {
+	return e.signer
+}
+
 // ID returns the identifier of current node.
 func (e *ConsensusEngine) ID() string {
-	return e.privateKey.PublicKey().Address().Hex()
+	return e.signer.Address().Hex()
 }
 
 // PrivateKey returns the private key
@@ -137,11 +239,21 @@ func (e *ConsensusEngine) Start(ctx context.Context) {
 	e.ctx = c
 	e.cancel = cancel
 
+	e.initialize()
+
+	e.wg.Add(1)
+	go e.mainLoop()
+}
+
+// initialize verifies the configurations and points the ledger state to the highest CC block.
+func (e *ConsensusEngine) initialize() {
 	// Verify configurations
-	if viper.GetInt(common.CfgConsensusMaxEpochLength) <= viper.GetInt(common.CfgConsensusMinBlockInterval) {
+	maxEpochLength := time.Duration(viper.GetInt(common.CfgConsensusMaxEpochLength)) * time.Second
+	if maxEpochLength <= scom.GetMinBlockInterval(0) || maxEpochLength <= scom.GetMinBlockInterval(math.MaxUint64) {
 		log.WithFields(log.Fields{
-			"CfgConsensusMaxEpochLength":   viper.GetInt(common.CfgConsensusMaxEpochLength),
-			"CfgConsensusMinBlockInterval": viper.GetInt(common.CfgConsensusMinBlockInterval),
+			"CfgConsensusMaxEpochLength":                 viper.GetInt(common.CfgConsensusMaxEpochLength),
+			"CfgConsensusMinBlockInterval":               viper.GetInt(scom.CfgConsensusMinBlockInterval),
+			"CfgConsensusMinBlockIntervalInMilliseconds": viper.GetInt(scom.CfgConsensusMinBlockIntervalInMilliseconds),
 		}).Fatal("Invalid configuration: max epoch length must be larger than minimal proposal wait")
 	}
 
@@ -151,9 +263,6 @@ func (e *ConsensusEngine) Start(ctx context.Context) {
 	e.ledger.ResetState(lastCC.Block)
 
 	e.checkSyncStatus()
-
-	e.wg.Add(1)
-	go e.mainLoop()
 }
 
 func (e *ConsensusEngine) autoRewind(lastCC *score.ExtendedBlock) *score.ExtendedBlock {
@@ -212,6 +321,7 @@ func (e *ConsensusEngine) autoRewind(lastCC *score.ExtendedBlock) *score.Extende
 				lastCC.Status = score.BlockStatusDisposed
 				e.chain.SaveBlock(lastCC)
 				e.chain.RemoveVotesByHash(lastCC.Hash())
+				e.chain.RemoveCommitCertificate(lastCC.Hash())
 
 				parent, err := e.chain.FindBlock(lastCC.Parent)
 				if err != nil {
@@ -249,34 +359,55 @@ func (e *ConsensusEngine) Wait() {
 func (e *ConsensusEngine) mainLoop() {
 	defer e.wg.Done()
 
+	e.startEpoch()
 	for {
-		e.enterEpoch()
-		e.propose()
-	Epoch:
-		for {
-			select {
-			case <-e.ctx.Done():
-				e.stopped = true
-				return
-			case msg := <-e.incoming:
-				endEpoch := e.processMessage(msg)
-				if endEpoch {
-					break Epoch
-				}
-			case <-e.voteTimer.C:
-				e.voteTimerReady = true
-				if e.blockProcessed {
-					e.vote()
-				}
-			case <-e.epochTimer.C:
-				e.logger.WithFields(log.Fields{"e.epoch": e.GetEpoch()}).Debug("Epoch timeout. Repeating epoch")
-				e.vote()
-				break Epoch
-			}
+		select {
+		case <-e.ctx.Done():
+			e.stopped = true
+			return
+		case msg := <-e.incoming:
+			e.handleMessage(msg)
+		case <-e.voteTimer.Chan():
+			e.handleVoteTimeout()
+		case <-e.epochTimer.Chan():
+			e.handleEpochTimeout()
 		}
 	}
 }
 
+// startEpoch enters the current epoch and proposes a block if the node is the proposer.
+func (e *ConsensusEngine) startEpoch() {
+	e.enterEpoch()
+	e.propose()
+}
+
+// handleMessage processes a message from the incoming queue, and starts a new epoch if the
+// message ends the current one.
+func (e *ConsensusEngine) handleMessage(msg interface{}) {
+	e.processFutureBlocks()
+	if endEpoch := e.processMessage(msg); endEpoch {
+		e.startEpoch()
+	}
+}
+
+// handleVoteTimeout votes once the minimal block interval has passed and the block of the epoch
+// has been processed.
+func (e *ConsensusEngine) handleVoteTimeout() {
+	e.processFutureBlocks()
+	e.voteTimerReady = true
+	if e.blockProcessed {
+		e.vote()
+	}
+}
+
+// handleEpochTimeout repeats the vote and starts the next epoch.
+func (e *ConsensusEngine) handleEpochTimeout() {
+	e.logger.WithFields(log.Fields{"e.epoch": e.GetEpoch()}).Debug("Epoch timeout. Repeating epoch")
+	e.processFutureBlocks()
+	e.vote()
+	e.startEpoch()
+}
+
 // enterEpoch is called when engine enters a new epoch.
 func (e *ConsensusEngine) enterEpoch() {
 	logger.Debugf("Enter epoch %v", e.GetEpoch())
@@ -285,12 +416,12 @@ func (e *ConsensusEngine) enterEpoch() {
 	if e.epochTimer != nil {
 		e.epochTimer.Stop()
 	}
-	e.epochTimer = time.NewTimer(time.Duration(viper.GetInt(common.CfgConsensusMaxEpochLength)) * time.Second)
+	e.epochTimer = e.clock.NewTimer(time.Duration(viper.GetInt(common.CfgConsensusMaxEpochLength)) * time.Second)
 
 	if e.voteTimer != nil {
 		e.voteTimer.Stop()
 	}
-	e.voteTimer = time.NewTimer(time.Duration(viper.GetInt(common.CfgConsensusMinBlockInterval)) * time.Second)
+	e.voteTimer = e.clock.NewTimer(e.ledger.GetMinBlockInterval(e.state.GetLastFinalizedBlock().Height + 1))
 
 	e.voteTimerReady = false
 	e.blockProcessed = false
@@ -310,10 +441,22 @@ func (e *ConsensusEngine) AddMessage(msg interface{}) {
 	e.incoming <- msg
 }
 
+// addOwnMessage passes a vote or block created by the engine itself back to the engine.
+func (e *ConsensusEngine) addOwnMessage(msg interface{}) {
+	if e.loopback != nil {
+		e.loopback(msg)
+		return
+	}
+	go func() {
+		e.AddMessage(msg)
+	}()
+}
+
 func (e *ConsensusEngine) processMessage(msg interface{}) (endEpoch bool) {
 	switch m := msg.(type) {
 	case score.Vote:
 		e.logger.WithFields(log.Fields{"vote": m}).Debug("Received vote")
+		e.recordVoteClockSkew(m)
 		endEpoch = e.handleVote(m)
 		e.checkCC(m.Block)
 		return endEpoch
@@ -321,6 +464,7 @@ func (e *ConsensusEngine) processMessage(msg interface{}) (endEpoch bool) {
 		e.logger.WithFields(log.Fields{
 			"block": m.BlockHeader,
 		}).Debug("Received block")
+		e.recordBlockClockSkew(m)
 		e.handleBlock(m)
 	default:
 		// Should not happen.
@@ -346,7 +490,7 @@ func (e *ConsensusEngine) checkSyncStatus() error {
 	// current finalized height is at most maxVoteHeight-1
 	currentHeight := uint64(maxVoteHeight - 1)
 
-	e.hasSynced = !isSyncing(e.GetLastFinalizedBlock(), currentHeight)
+	e.hasSynced = !isSyncing(e.GetLastFinalizedBlock(), currentHeight, e.clock.Now())
 
 	return nil
 }
@@ -376,8 +520,7 @@ func (e *ConsensusEngine) validateBlock(block *score.Block, parent *score.Extend
 		}).Warn("Block.Height != parent.Height + 1")
 		return result.Error("Block height is incorrect")
 	}
-	minBlockTimestamp := big.NewInt(0).Add(parent.Timestamp, big.NewInt(1))
-	if block.Timestamp.Cmp(minBlockTimestamp) < 0 {
+	if block.Timestamp.Cmp(minBlockTimestamp(block.Height, parent.BlockHeader)) < 0 {
 		e.logger.WithFields(log.Fields{
 			"parent":           block.Parent.Hex(),
 			"parent.Timestamp": parent.Timestamp,
@@ -413,6 +556,13 @@ func (e *ConsensusEngine) validateBlock(block *score.Block, parent *score.Extend
 	}
 
 	// Validate HCC.
+	if block.HCC.Aggregated != nil && !blsCommitCertificateEnabled(block.Height) {
+		e.logger.WithFields(log.Fields{
+			"block":        block.Hash().Hex(),
+			"block.Height": block.Height,
+		}).Warn("Aggregated HCC before the BLS commit certificate fork")
+		return result.Error("HCC must not be aggregated before the BLS commit certificate fork")
+	}
 	if !e.chain.IsDescendant(block.HCC.BlockHash, block.Hash()) {
 		e.logger.WithFields(log.Fields{
 			"block.HCC": block.HCC.BlockHash.Hex(),
@@ -481,9 +631,216 @@ func (e *ConsensusEngine) validateBlock(block *score.Block, parent *score.Extend
 		return result.Error("Invalid proposer")
 	}
 
+	if res := e.validateVRFProof(block, parent); res.IsError() {
+		e.logger.WithFields(log.Fields{
+			"block":          block.Hash().Hex(),
+			"block.Height":   block.Height,
+			"block.proposer": block.Proposer.Hex(),
+			"error":          res.Message,
+		}).Warn("Invalid VRF proof")
+		return res
+	}
+
+	if res := e.validateBlockGasLimit(block, parent); res.IsError() {
+		e.logger.WithFields(log.Fields{
+			"block":        block.Hash().Hex(),
+			"block.Height": block.Height,
+			"error":        res.Message,
+		}).Warn("Invalid block gas limit")
+		return res
+	}
+
+	return result.OK
+}
+
+// validateBlockGasLimit checks that none of the smart contract transactions of the block could consume
+// more gas than the block gas limit. The total gas used is enforced when the block transactions are applied.
+func (e *ConsensusEngine) validateBlockGasLimit(block *score.Block, parent *score.ExtendedBlock) result.Result {
+	blockGasLimit := e.ledger.GetBlockGasLimit(parent.Block)
+	for _, rawTx := range block.Txs {
+		tx, err := stypes.TxFromBytes(rawTx)
+		if err != nil {
+			return result.Error("Failed to parse transaction: %v", err)
+		}
+		if sctx, ok := tx.(*types.SmartContractTx); ok && sctx.GasLimit > blockGasLimit {
+			return result.Error("Smart contract transaction gas limit %v exceeds the block gas limit %v", sctx.GasLimit, blockGasLimit)
+		}
+	}
 	return result.OK
 }
 
+// validateVRFProof checks that the block carries the VRF proof of its proposer on the seed of the parent
+// block after the VRF proposer selection fork, and no proof before it.
+func (e *ConsensusEngine) validateVRFProof(block *score.Block, parent *score.ExtendedBlock) result.Result {
+	if !vrfProposerSelectionEnabled(block.Height) {
+		if len(block.VRFProof) != 0 {
+			return result.Error("VRF proof is not expected before the fork height")
+		}
+		return result.OK
+	}
+
+	prover, _, err := vrf.Verify(block.VRFProof, vrfInput(block.ChainID, blockSeed(parent.BlockHeader), block.Epoch))
+	if err != nil {
+		return result.Error("VRF proof verification failed: %v", err)
+	}
+	if prover != block.Proposer {
+		return result.Error("VRF proof is not from the proposer")
+	}
+	return result.OK
+}
+
+// maxBlockTimestamp returns the maximal timestamp in milliseconds of a valid block according to the local
+// clock, or nil if the future timestamps are not bounded.
+func (e *ConsensusEngine) maxBlockTimestamp() *big.Int {
+	maxDrift := viper.GetInt64(scom.CfgConsensusMaxTimestampDriftInMilliseconds)
+	if maxDrift <= 0 {
+		return nil
+	}
+	now := e.clock.Now().UnixNano() / int64(time.Millisecond)
+	return big.NewInt(now + maxDrift)
+}
+
+// isFutureBlock returns whether the timestamp of the block is too far ahead of the local clock. The
+// timestamps are only bounded after the future timestamp bound fork.
+func (e *ConsensusEngine) isFutureBlock(block *score.Block) bool {
+	if !scom.IsForkActive(scom.ForkFutureTimestampBound, block.Height) {
+		return false
+	}
+	maxTimestamp := e.maxBlockTimestamp()
+	return maxTimestamp != nil && block.TimestampInMilliseconds().Cmp(maxTimestamp) > 0
+}
+
+// deferFutureBlock holds back the block if its timestamp is too far ahead of the local clock, or if its
+// parent is held back. The block remains pending rather than invalid, since the local clock might be the
+// one lagging behind, and is processed once the local clock catches up. When too many blocks are held back,
+// the one furthest in the future is rejected, which might be the given block.
+func (e *ConsensusEngine) deferFutureBlock(block *score.Block) bool {
+	if _, ok := e.futureBlocks[block.Parent]; !ok && !e.isFutureBlock(block) {
+		return false
+	}
+	e.logger.WithFields(log.Fields{
+		"block":           block.Hash().Hex(),
+		"block.Timestamp": block.Timestamp,
+		"maxTimestamp":    e.maxBlockTimestamp(),
+	}).Warn("Block.Timestamp is too far in the future, holding back the block")
+	e.futureBlocks[block.Hash()] = block
+	if len(e.futureBlocks) > maxNumFutureBlocks {
+		e.rejectFurthestFutureBlock()
+	}
+	return true
+}
+
+// rejectFurthestFutureBlock marks the held back block with the timestamp furthest in the future invalid, along
+// with its held back descendants. It is the block least likely to become valid as the local clock advances.
+func (e *ConsensusEngine) rejectFurthestFutureBlock() {
+	var furthest *score.Block
+	for _, block := range e.futureBlocks {
+		if furthest == nil || isFurtherInFuture(block, furthest) {
+			furthest = block
+		}
+	}
+	rejected := map[common.Hash]bool{furthest.Hash(): true}
+	for found := true; found; {
+		found = false
+		for hash, block := range e.futureBlocks {
+			if !rejected[hash] && rejected[block.Parent] {
+				rejected[hash] = true
+				found = true
+			}
+		}
+	}
+	for hash := range rejected {
+		delete(e.futureBlocks, hash)
+		e.chain.MarkBlockInvalid(hash)
+	}
+	e.logger.WithFields(log.Fields{
+		"block":           furthest.Hash().Hex(),
+		"block.Timestamp": furthest.Timestamp,
+		"numRejected":     len(rejected),
+	}).Warn("Too many blocks with future timestamps, rejecting the block furthest in the future and its descendants")
+}
+
+// isFurtherInFuture returns whether the timestamp of block a is further in the future than the one of block b.
+// The ties are broken by the height and the hash, so the choice does not depend on the order of the map.
+func isFurtherInFuture(a, b *score.Block) bool {
+	if cmp := a.TimestampInMilliseconds().Cmp(b.TimestampInMilliseconds()); cmp != 0 {
+		return cmp > 0
+	}
+	if a.Height != b.Height {
+		return a.Height > b.Height
+	}
+	return bytes.Compare(a.Hash().Bytes(), b.Hash().Bytes()) > 0
+}
+
+// processFutureBlocks processes the held back blocks the local clock has caught up with. The children of
+// a block still held back wait for the next round.
+func (e *ConsensusEngine) processFutureBlocks() {
+	if len(e.futureBlocks) == 0 {
+		return
+	}
+	ready := []*score.Block{}
+	for _, block := range e.futureBlocks {
+		if _, ok := e.futureBlocks[block.Parent]; ok {
+			continue
+		}
+		if e.isFutureBlock(block) {
+			continue
+		}
+		ready = append(ready, block)
+	}
+	sort.Slice(ready, func(i, j int) bool {
+		if ready[i].Height != ready[j].Height {
+			return ready[i].Height < ready[j].Height
+		}
+		return bytes.Compare(ready[i].Hash().Bytes(), ready[j].Hash().Bytes()) < 0
+	})
+	for _, block := range ready {
+		delete(e.futureBlocks, block.Hash())
+	}
+	for _, block := range ready {
+		e.handleBlock(block)
+	}
+}
+
+// recordBlockClockSkew samples the clock skew of the proposer of a block of the current epoch. The blocks
+// of the past epochs, e.g. those downloaded while syncing, do not tell the clock skew.
+func (e *ConsensusEngine) recordBlockClockSkew(block *score.Block) {
+	if !e.hasSynced || block.Epoch != e.GetEpoch() || block.Proposer == e.signer.Address() {
+		return
+	}
+	e.recordClockSkew(block.Proposer, block.TimestampInMilliseconds())
+}
+
+// recordVoteClockSkew samples the clock skew of the voter of a vote of the current epoch. Only the votes
+// created after the future timestamp bound fork carry a timestamp, which is covered by the signature.
+func (e *ConsensusEngine) recordVoteClockSkew(vote score.Vote) {
+	if !e.hasSynced || vote.Timestamp == nil || vote.Epoch != e.GetEpoch() || vote.ID == e.signer.Address() {
+		return
+	}
+	if vote.Validate().IsError() {
+		return
+	}
+	e.recordClockSkew(vote.ID, vote.Timestamp)
+}
+
+// recordClockSkew samples the clock skew of the validator, i.e. the given timestamp in milliseconds of the
+// validator minus the local time.
+func (e *ConsensusEngine) recordClockSkew(validator common.Address, timestamp *big.Int) {
+	now := e.clock.Now().UnixNano() / int64(time.Millisecond)
+	skew := timestamp.Int64() - now
+	validators := e.validatorManager.GetNextValidatorSet(e.state.GetLastFinalizedBlock().Hash())
+	e.clockDrift.AddSample(validator, skew, validators)
+}
+
+// minBlockTimestamp returns the minimal timestamp of a block at the given height on top of the parent. After the
+// millisecond timestamp fork, the timestamps increase by at least one millisecond, otherwise by at least one second.
+func minBlockTimestamp(height uint64, parent *score.BlockHeader) *big.Int {
+	if scom.MillisecondTimestampEnabled(height) {
+		return new(big.Int).Add(parent.TimestampInMilliseconds(), big.NewInt(1))
+	}
+	return new(big.Int).Add(parent.Timestamp, big.NewInt(1))
+}
+
 func (e *ConsensusEngine) handleBlock(block *score.Block) {
 	eb, err := e.chain.FindBlock(block.Hash())
 	if err != nil {
@@ -573,6 +930,16 @@ func (e *ConsensusEngine) handleNormalBlock(eb *score.ExtendedBlock) {
 		}).Fatal("Failed to find parent block")
 	}
 
+	if e.deferFutureBlock(block) {
+		return
+	}
+
+	if e.checkUpgradePlans(parent.Block, block.Height) {
+		return
+	}
+
+	e.checkProposalEquivocation(block.BlockHeader)
+
 	start1 := time.Now()
 	if e.validateBlock(block, parent).IsError() {
 		e.logger.WithFields(log.Fields{
@@ -583,8 +950,22 @@ func (e *ConsensusEngine) handleNormalBlock(eb *score.ExtendedBlock) {
 	}
 	validateBlockTime := time.Since(start1)
 
-	for _, vote := range block.HCC.Votes.Votes() {
-		e.handleVote(vote)
+	if block.HCC.Aggregated != nil {
+		// The individual votes are not available, keep the certificate so that the HCC block can be
+		// committed, and the certificate carried by the blocks this node proposes. Only a certificate
+		// valid for the validator set of the HCC block is kept, so that an invalid one is not passed on.
+		if validators := e.validatorManager.GetValidatorSet(block.HCC.BlockHash); block.HCC.IsValid(validators) {
+			e.chain.AddCommitCertificate(block.HCC)
+		} else {
+			e.logger.WithFields(log.Fields{
+				"block":               block.Hash().Hex(),
+				"block.HCC.BlockHash": block.HCC.BlockHash.Hex(),
+			}).Warn("Ignoring the invalid aggregated HCC of the block")
+		}
+	} else {
+		for _, vote := range block.HCC.Votes.Votes() {
+			e.handleVote(vote)
+		}
 	}
 	if localHCC := e.state.GetHighestCCBlock().Hash(); localHCC != block.HCC.BlockHash {
 		e.logger.WithFields(log.Fields{
@@ -674,7 +1055,7 @@ func (e *ConsensusEngine) handleNormalBlock(eb *score.ExtendedBlock) {
 }
 
 func (e *ConsensusEngine) shouldVote(block common.Hash) bool {
-	return e.shouldVoteByID(e.privateKey.PublicKey().Address(), block)
+	return e.shouldVoteByID(e.signer.Address(), block)
 }
 
 func (e *ConsensusEngine) shouldVoteByID(id common.Address, block common.Hash) bool {
@@ -684,6 +1065,10 @@ func (e *ConsensusEngine) shouldVoteByID(id common.Address, block common.Hash) b
 }
 
 func (e *ConsensusEngine) vote() {
+	if e.isStandingBy() || e.isHalted() {
+		return
+	}
+
 	tip := e.GetTipToVote()
 
 	if !e.shouldVote(tip.Hash()) {
@@ -691,6 +1076,7 @@ func (e *ConsensusEngine) vote() {
 	}
 
 	var vote score.Vote
+	var err error
 	lastVote := e.state.GetLastVote()
 	shouldRepeatVote := false
 	if lastVote.Height != 0 && lastVote.Height >= tip.Height {
@@ -719,19 +1105,24 @@ func (e *ConsensusEngine) vote() {
 			log.Panic(err)
 		}
 		// Recreating vote so that it has updated epoch and signature.
-		vote = e.createVote(block.Block)
+		vote, err = e.createVote(block.Block)
+		if err != nil {
+			e.logger.WithFields(log.Fields{"error": err}).Error("Failed to sign vote")
+			return
+		}
 	} else {
-		vote = e.createVote(tip.Block)
+		vote, err = e.createVote(tip.Block)
+		if err != nil {
+			e.logger.WithFields(log.Fields{"error": err}).Error("Failed to sign vote")
+			return
+		}
 		e.state.SetLastVote(vote)
 	}
 	e.logger.WithFields(log.Fields{
 		"vote": vote,
 	}).Debug("Sending vote")
 	e.broadcastVote(vote)
-
-	go func() {
-		e.AddMessage(vote)
-	}()
+	e.addOwnMessage(vote)
 }
 
 func (e *ConsensusEngine) broadcastVote(vote score.Vote) {
@@ -747,7 +1138,34 @@ func (e *ConsensusEngine) broadcastVote(vote score.Vote) {
 	e.dispatcher.SendData([]string{}, voteMsg)
 }
 
-func (e *ConsensusEngine) createVote(block *score.Block) score.Vote {
+// checkVoteEquivocation records the vote in the evidence pool, and gossips the evidence if the voter equivocated
+func (e *ConsensusEngine) checkVoteEquivocation(vote score.Vote) {
+	for _, ev := range e.evidencePool.AddVote(vote) {
+		e.broadcastEvidence(ev)
+	}
+}
+
+// checkProposalEquivocation records the block header in the evidence pool, and gossips the evidence if the proposer equivocated
+func (e *ConsensusEngine) checkProposalEquivocation(header *score.BlockHeader) {
+	for _, ev := range e.evidencePool.AddProposal(header) {
+		e.broadcastEvidence(ev)
+	}
+}
+
+func (e *ConsensusEngine) broadcastEvidence(ev *score.EquivocationEvidence) {
+	payload, err := rlp.EncodeToBytes(ev)
+	if err != nil {
+		e.logger.WithFields(log.Fields{"evidence": ev}).Error("Failed to encode equivocation evidence")
+		return
+	}
+	evidenceMsg := dispatcher.DataResponse{
+		ChannelID: scom.ChannelIDEquivocationEvidence,
+		Payload:   payload,
+	}
+	e.dispatcher.SendData([]string{}, evidenceMsg)
+}
+
+func (e *ConsensusEngine) createVote(block *score.Block) (score.Vote, error) {
 	mainchainHeightBigInt, err := e.metachainWitness.GetMainchainBlockHeight()
 	var mainchainHeight uint64
 	if err != nil {
@@ -760,11 +1178,21 @@ func (e *ConsensusEngine) createVote(block *score.Block) score.Vote {
 		Block:           block.Hash(),
 		Height:          block.Height,
 		MainchainHeight: mainchainHeight,
-		ID:              e.privateKey.PublicKey().Address(),
+		ID:              e.signer.Address(),
 		Epoch:           e.GetEpoch(),
 	}
-	vote.Sign(e.privateKey)
-	return vote
+	if scom.IsForkActive(scom.ForkFutureTimestampBound, block.Height) {
+		vote.Timestamp = big.NewInt(e.clock.Now().UnixNano() / int64(time.Millisecond))
+	}
+	if blsCommitCertificateEnabled(block.Height) {
+		err = e.signer.SignVoteBLS(&vote)
+	} else {
+		err = e.signer.SignVote(&vote)
+	}
+	if err != nil {
+		return score.Vote{}, err
+	}
+	return vote, nil
 }
 
 func (e *ConsensusEngine) validateVote(vote score.Vote) bool {
@@ -782,6 +1210,7 @@ func (e *ConsensusEngine) handleVote(vote score.Vote) (endEpoch bool) {
 	if !e.validateVote(vote) {
 		return
 	}
+	e.checkVoteEquivocation(vote)
 
 	// Save vote.
 	err := e.state.AddVote(&vote)
@@ -867,6 +1296,10 @@ func (e *ConsensusEngine) checkCC(hash common.Hash) {
 	validators := e.validatorManager.GetValidatorSet(hash)
 	if validators.HasMajority(votes) {
 		e.processCCBlock(block)
+		return
+	}
+	if cc := e.chain.FindCommitCertificate(hash); cc != nil && cc.IsValid(validators) {
+		e.processCCBlock(block)
 	}
 }
 
@@ -982,6 +1415,8 @@ func (e *ConsensusEngine) finalizeBlock(block *score.ExtendedBlock) error {
 	// duplicate TX in fork.
 	e.chain.AddTxsToIndex(block, true)
 
+	e.livenessTracker.ProcessFinalizedBlock(block)
+
 	select {
 	case e.finalizedBlocks <- block.Block:
 		e.logger.Infof("Notified finalized block, height=%v", block.Height)
@@ -1093,7 +1528,6 @@ func (e *ConsensusEngine) createProposal(validatorMajorityInTheSameDynasty bool)
 	if err != nil {
 		logger.Fatalf("Failed to find parent block with hash: %v, err: %v", parentBlockHash.Hex(), err) // should not happen
 	}
-	minBlockTimestamp := big.NewInt(0).Add(parentBlock.Timestamp, big.NewInt(1))
 
 	// Add block.
 	block := score.NewBlock()
@@ -1101,14 +1535,22 @@ func (e *ConsensusEngine) createProposal(validatorMajorityInTheSameDynasty bool)
 	block.Epoch = e.GetEpoch()
 	block.Parent = parentBlockHash
 	block.Height = tip.Height + 1
-	block.Proposer = e.privateKey.PublicKey().Address()
-	block.Timestamp = big.NewInt(time.Now().Unix())
-	if block.Timestamp.Cmp(minBlockTimestamp) < 0 {
-		block.Timestamp.Set(minBlockTimestamp) // keep the block timestamp monotonically increasing to be compatible with Ethereum, block.timestamp >= parent.timestamp + 1
+	block.Proposer = e.signer.Address()
+	if scom.MillisecondTimestampEnabled(block.Height) {
+		block.Timestamp = big.NewInt(e.clock.Now().UnixNano() / int64(time.Millisecond))
+	} else {
+		block.Timestamp = big.NewInt(e.clock.Now().Unix())
 	}
-	block.HCC.BlockHash = e.state.GetHighestCCBlock().Hash()
-	hccValidators := e.validatorManager.GetValidatorSet(block.HCC.BlockHash)
-	block.HCC.Votes = e.chain.FindVotesByHash(block.HCC.BlockHash).UniqueVoter().FilterByValidators(hccValidators)
+	if minTimestamp := minBlockTimestamp(block.Height, parentBlock.BlockHeader); block.Timestamp.Cmp(minTimestamp) < 0 {
+		block.Timestamp.Set(minTimestamp) // keep the block timestamp monotonically increasing to be compatible with Ethereum, block.timestamp >= parent.timestamp + 1
+	}
+	if vrfProposerSelectionEnabled(block.Height) {
+		block.VRFProof, err = e.signer.ProveVRF(vrfInput(block.ChainID, blockSeed(tip.BlockHeader), block.Epoch))
+		if err != nil {
+			return score.Proposal{}, fmt.Errorf("Failed to compute the VRF proof: %v", err)
+		}
+	}
+	block.HCC = e.createCommitCertificate(e.state.GetHighestCCBlock().Hash(), block.Height)
 
 	// Add Txs.
 	newRoot, txs, result := e.ledger.ProposeBlockTxs(block, validatorMajorityInTheSameDynasty)
@@ -1120,11 +1562,9 @@ func (e *ConsensusEngine) createProposal(validatorMajorityInTheSameDynasty bool)
 	block.StateHash = newRoot
 
 	// Sign block.
-	sig, err := e.privateKey.Sign(block.SignBytes())
-	if err != nil {
-		e.logger.WithFields(log.Fields{"error": err}).Panic("Failed to sign vote")
+	if err := e.signer.SignProposal(block.BlockHeader); err != nil {
+		return score.Proposal{}, fmt.Errorf("Failed to sign block: %v", err)
 	}
-	block.SetSignature(sig)
 
 	proposal := score.Proposal{
 		Block:      block,
@@ -1147,8 +1587,57 @@ func (e *ConsensusEngine) createProposal(validatorMajorityInTheSameDynasty bool)
 	return proposal, nil
 }
 
+// createCommitCertificate creates the commit certificate of the given block, to be carried by a new block
+// at the given height. After the BLS commit certificate fork, the votes are aggregated into one signature
+// if the voters with a valid BLS signature hold the majority of the stake.
+func (e *ConsensusEngine) createCommitCertificate(hash common.Hash, height uint64) score.CommitCertificate {
+	validators := e.validatorManager.GetValidatorSet(hash)
+	votes := e.chain.FindVotesByHash(hash).UniqueVoter().FilterByValidators(validators)
+	cc := score.CommitCertificate{
+		BlockHash: hash,
+		Votes:     votes,
+	}
+	if !blsCommitCertificateEnabled(height) {
+		return cc
+	}
+
+	blsVotes := []score.Vote{}
+	for _, vote := range votes.Votes() {
+		pubKey, err := validators.GetBLSPubKey(vote.ID)
+		if err != nil || vote.Block != hash || vote.ValidateBLS(pubKey).IsError() {
+			continue
+		}
+		blsVotes = append(blsVotes, vote)
+	}
+	if validators.HasMajorityVotes(blsVotes) {
+		aggregated, err := score.AggregateVotes(validators, blsVotes)
+		if err == nil {
+			return score.CommitCertificate{
+				BlockHash:  hash,
+				Aggregated: aggregated,
+			}
+		}
+		e.logger.WithFields(log.Fields{"error": err, "block": hash.Hex()}).Warn("Failed to aggregate the votes")
+	}
+
+	// The block might have been committed by an aggregated certificate received from other proposers
+	if !validators.HasMajority(votes) {
+		if stored := e.chain.FindCommitCertificate(hash); stored != nil && stored.IsValid(validators) {
+			return *stored
+		}
+	}
+	return cc
+}
+
 func (e *ConsensusEngine) propose() {
+	if e.isStandingBy() {
+		return
+	}
+
 	tip := e.GetTipToExtend()
+	if e.checkUpgradePlans(tip.Block, tip.Height+1) {
+		return
+	}
 	if !e.shouldPropose(tip, e.GetEpoch()) {
 		return
 	}
@@ -1186,10 +1675,7 @@ func (e *ConsensusEngine) propose() {
 		Payload:   payload,
 	}
 	e.dispatcher.SendData([]string{}, proposalMsg)
-
-	go func() {
-		e.AddMessage(proposal.Block)
-	}()
+	e.addOwnMessage(proposal.Block)
 }
 
 func (e *ConsensusEngine) pruneState(currentBlockHeight uint64) {
@@ -1218,14 +1704,14 @@ func (e *ConsensusEngine) State() *State {
 	return e.state
 }
 
-func isSyncing(lastestFinalizedBlock *score.ExtendedBlock, currentHeight uint64) bool {
+func isSyncing(lastestFinalizedBlock *score.ExtendedBlock, currentHeight uint64, now time.Time) bool {
 	if lastestFinalizedBlock == nil {
 		return true
 	}
-	currentTime := big.NewInt(time.Now().Unix())
+	currentTime := big.NewInt(now.Unix())
 	maxDiff := new(big.Int).SetUint64(30) // thirty seconds, about 5 blocks
 	threshold := new(big.Int).Sub(currentTime, maxDiff)
-	isSyncing := lastestFinalizedBlock.Timestamp.Cmp(threshold) < 0
+	isSyncing := lastestFinalizedBlock.TimestampInSeconds().Cmp(threshold) < 0
 
 	if isSyncing { // sometimes the validator node clock is off, so here we also compare the block heights
 		isSyncing = (currentHeight - lastestFinalizedBlock.Height) > 5
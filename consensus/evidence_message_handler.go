@@ -0,0 +1,90 @@
+package consensus
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+
+	"github.com/thetatoken/theta/common"
+	dp "github.com/thetatoken/theta/dispatcher"
+	"github.com/thetatoken/theta/p2p/types"
+	"github.com/thetatoken/theta/rlp"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+)
+
+//
+// EvidenceMessageHandler handles the equivocation evidence received over the
+// ChannelIDEquivocationEvidence channel
+//
+type EvidenceMessageHandler struct {
+	engine *ConsensusEngine
+}
+
+// CreateEvidenceMessageHandler create an instance of the EvidenceMessageHandler
+func CreateEvidenceMessageHandler(engine *ConsensusEngine) *EvidenceMessageHandler {
+	return &EvidenceMessageHandler{
+		engine: engine,
+	}
+}
+
+// GetChannelIDs implements the p2p.MessageHandler interface
+func (emh *EvidenceMessageHandler) GetChannelIDs() []common.ChannelIDEnum {
+	return []common.ChannelIDEnum{
+		scom.ChannelIDEquivocationEvidence,
+	}
+}
+
+// EncodeMessage implements the p2p.MessageHandler interface
+func (emh *EvidenceMessageHandler) EncodeMessage(message interface{}) (common.Bytes, error) {
+	return rlp.EncodeToBytes(message)
+}
+
+// ParseMessage implements the p2p.MessageHandler interface
+func (emh *EvidenceMessageHandler) ParseMessage(peerID string, channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (types.Message, error) {
+	var dataResponse dp.DataResponse
+	err := rlp.DecodeBytes(rawMessageBytes, &dataResponse)
+	if err != nil {
+		return types.Message{}, err
+	}
+
+	ev := &score.EquivocationEvidence{}
+	err = rlp.DecodeBytes(dataResponse.Payload, ev)
+	if err != nil {
+		return types.Message{}, err
+	}
+	message := types.Message{
+		PeerID:    peerID,
+		ChannelID: channelID,
+		Content:   ev,
+	}
+	return message, nil
+}
+
+// HandleMessage implements the p2p.MessageHandler interface
+func (emh *EvidenceMessageHandler) HandleMessage(message types.Message) error {
+	if message.ChannelID != scom.ChannelIDEquivocationEvidence {
+		return fmt.Errorf("Invalid channel for EvidenceMessageHandler: %v", message.ChannelID)
+	}
+	ev, ok := message.Content.(*score.EquivocationEvidence)
+	if !ok {
+		return fmt.Errorf("Invalid message content for EvidenceMessageHandler")
+	}
+
+	isNew, err := emh.engine.evidencePool.AddEvidence(ev)
+	if err != nil {
+		return err
+	}
+	if !isNew {
+		return nil
+	}
+
+	// When using libp2p gossip, we don't need to re-broadcast the evidence received from other nodes.
+	p2pOpt := common.P2POptEnum(viper.GetInt(common.CfgP2POpt))
+	if p2pOpt != common.P2POptLibp2p {
+		emh.engine.broadcastEvidence(ev)
+	}
+
+	return nil
+}
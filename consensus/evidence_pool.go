@@ -0,0 +1,273 @@
+package consensus
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/store"
+
+	sbc "github.com/thetatoken/thetasubchain/blockchain"
+	score "github.com/thetatoken/thetasubchain/core"
+)
+
+const (
+	DBEvidencePoolKey = "cs/evp"
+
+	// Number of epochs for which the observed votes and proposals are kept for equivocation detection
+	evidenceObservationWindow uint64 = 64
+
+	// Maximum number of distinct messages kept per (signer, epoch), honest validators sign at most two
+	maxObservationsPerKey = 8
+)
+
+var _ score.EvidencePool = (*EvidencePool)(nil)
+
+type evidencePoolStub struct {
+	Evidences []*score.EquivocationEvidence
+}
+
+type observationKey struct {
+	signer common.Address
+	epoch  uint64
+}
+
+// EvidencePool detects validators signing conflicting votes or proposals, and keeps the resulting
+// evidence until it is included in a block. The pending evidence is persisted so that it survives
+// restarts.
+type EvidencePool struct {
+	mu *sync.Mutex
+
+	db    store.Store
+	chain *sbc.Chain
+
+	votes     map[observationKey][]score.Vote
+	proposals map[observationKey][]*score.BlockHeader
+	maxEpoch  uint64
+
+	pending map[common.Hash]*score.EquivocationEvidence
+}
+
+// NewEvidencePool creates an instance of EvidencePool
+func NewEvidencePool(db store.Store, chain *sbc.Chain) *EvidencePool {
+	ep := &EvidencePool{
+		mu:        &sync.Mutex{},
+		db:        db,
+		chain:     chain,
+		votes:     make(map[observationKey][]score.Vote),
+		proposals: make(map[observationKey][]*score.BlockHeader),
+		pending:   make(map[common.Hash]*score.EquivocationEvidence),
+	}
+
+	stub := &evidencePoolStub{}
+	if err := db.Get([]byte(DBEvidencePoolKey), stub); err == nil {
+		for _, ev := range stub.Evidences {
+			ep.pending[ev.ID()] = ev
+		}
+	}
+	return ep
+}
+
+// AddVote records a vote with a valid signature, and returns the evidence if the voter has voted
+// for a different block at the same height in the same epoch
+func (ep *EvidencePool) AddVote(vote score.Vote) []*score.EquivocationEvidence {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+
+	key := observationKey{signer: vote.ID, epoch: vote.Epoch}
+	if !ep.observeEpoch(vote.Epoch) {
+		return nil
+	}
+	observed := ep.votes[key]
+	for _, v := range observed {
+		if v.Block == vote.Block {
+			return nil
+		}
+	}
+	if len(observed) >= maxObservationsPerKey {
+		return nil
+	}
+	ep.votes[key] = append(observed, vote)
+
+	evidences := []*score.EquivocationEvidence{}
+	for _, v := range observed {
+		if ev := ep.checkConflictingVotes(v, vote); ev != nil {
+			evidences = append(evidences, ev)
+		}
+	}
+	return evidences
+}
+
+// AddProposal records a proposed block header, and returns the evidence if the proposer has proposed
+// a different block in the same epoch. It also checks the recorded votes for the block, since a vote
+// can only be proven conflicting once the height of the voted block is known.
+func (ep *EvidencePool) AddProposal(header *score.BlockHeader) []*score.EquivocationEvidence {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+
+	if res := header.Validate(ep.chain.ChainID); res.IsError() {
+		return nil
+	}
+
+	evidences := []*score.EquivocationEvidence{}
+	key := observationKey{signer: header.Proposer, epoch: header.Epoch}
+	if ep.observeEpoch(header.Epoch) {
+		observed := ep.proposals[key]
+		isNew := true
+		for _, h := range observed {
+			if h.Hash() == header.Hash() {
+				isNew = false
+				break
+			}
+		}
+		if isNew && len(observed) < maxObservationsPerKey {
+			ep.proposals[key] = append(observed, header)
+			for _, h := range observed {
+				if bytes.Equal(h.SignBytes(), header.SignBytes()) {
+					continue
+				}
+				ev := score.NewProposalEquivocationEvidence(h, header)
+				if ep.addPendingEvidence(ev) {
+					evidences = append(evidences, ev)
+				}
+			}
+		}
+	}
+
+	blockHash := header.Hash()
+	for _, observed := range ep.votes {
+		for i, v := range observed {
+			if v.Block != blockHash {
+				continue
+			}
+			for j, other := range observed {
+				if i == j {
+					continue
+				}
+				if ev := ep.checkConflictingVotes(other, v); ev != nil {
+					evidences = append(evidences, ev)
+				}
+			}
+		}
+	}
+
+	return evidences
+}
+
+// AddEvidence adds an evidence received from a peer. It returns true if the evidence is valid and new.
+func (ep *EvidencePool) AddEvidence(ev *score.EquivocationEvidence) (bool, error) {
+	if res := ev.Validate(ep.chain.ChainID); res.IsError() {
+		return false, fmt.Errorf("invalid equivocation evidence: %v", res.Message)
+	}
+
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+
+	return ep.addPendingEvidence(ev), nil
+}
+
+// GetPendingEvidence returns the evidence not yet known to be included in a block, sorted by ID
+func (ep *EvidencePool) GetPendingEvidence() []*score.EquivocationEvidence {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+
+	return ep.getPendingEvidenceUnsafe()
+}
+
+// RemoveEvidence drops the evidence already included in the chain
+func (ep *EvidencePool) RemoveEvidence(ids []common.Hash) {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+
+	removed := false
+	for _, id := range ids {
+		if _, exists := ep.pending[id]; exists {
+			delete(ep.pending, id)
+			removed = true
+		}
+	}
+	if removed {
+		ep.commit()
+	}
+}
+
+// checkConflictingVotes returns the evidence if the two votes are for different blocks at the same height.
+// Returns nil if the votes do not conflict, or if the voted blocks are not known yet.
+func (ep *EvidencePool) checkConflictingVotes(voteA score.Vote, voteB score.Vote) *score.EquivocationEvidence {
+	if voteA.Block == voteB.Block {
+		return nil
+	}
+	blockA, err := ep.chain.FindBlock(voteA.Block)
+	if err != nil {
+		return nil
+	}
+	blockB, err := ep.chain.FindBlock(voteB.Block)
+	if err != nil {
+		return nil
+	}
+	if blockA.Height != blockB.Height {
+		return nil
+	}
+	ev := score.NewVoteEquivocationEvidence(voteA, blockA.BlockHeader, voteB, blockB.BlockHeader)
+	if !ep.addPendingEvidence(ev) {
+		return nil
+	}
+	return ev
+}
+
+// addPendingEvidence persists the evidence, returns false if the offence is already known
+func (ep *EvidencePool) addPendingEvidence(ev *score.EquivocationEvidence) bool {
+	id := ev.ID()
+	if _, exists := ep.pending[id]; exists {
+		return false
+	}
+	ep.pending[id] = ev
+	ep.commit()
+
+	logger.WithFields(log.Fields{
+		"evidence": ev,
+	}).Warn("Detected validator equivocation")
+	return true
+}
+
+// observeEpoch advances the observation window, and returns false if the epoch is already out of the window
+func (ep *EvidencePool) observeEpoch(epoch uint64) bool {
+	if epoch > ep.maxEpoch {
+		ep.maxEpoch = epoch
+		for key := range ep.votes {
+			if key.epoch+evidenceObservationWindow < ep.maxEpoch {
+				delete(ep.votes, key)
+			}
+		}
+		for key := range ep.proposals {
+			if key.epoch+evidenceObservationWindow < ep.maxEpoch {
+				delete(ep.proposals, key)
+			}
+		}
+	}
+	return epoch+evidenceObservationWindow >= ep.maxEpoch
+}
+
+func (ep *EvidencePool) getPendingEvidenceUnsafe() []*score.EquivocationEvidence {
+	evidences := []*score.EquivocationEvidence{}
+	for _, ev := range ep.pending {
+		evidences = append(evidences, ev)
+	}
+	sort.Slice(evidences, func(i, j int) bool {
+		idi, idj := evidences[i].ID(), evidences[j].ID()
+		return bytes.Compare(idi[:], idj[:]) < 0
+	})
+	return evidences
+}
+
+func (ep *EvidencePool) commit() {
+	stub := &evidencePoolStub{
+		Evidences: ep.getPendingEvidenceUnsafe(),
+	}
+	if err := ep.db.Put([]byte(DBEvidencePoolKey), stub); err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Failed to persist the evidence pool")
+	}
+}
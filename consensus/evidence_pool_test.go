@@ -0,0 +1,197 @@
+package consensus
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/store"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/kvstore"
+
+	score "github.com/thetatoken/thetasubchain/core"
+)
+
+// evidenceTest runs the evidence pool of the first node of a two-node simulation, the second node equivocates
+type evidenceTest struct {
+	t        *testing.T
+	sim      *Simulation
+	node     *SimNode
+	offender *SimNode
+	db       store.Store
+	pool     *EvidencePool
+}
+
+func newEvidenceTest(t *testing.T) *evidenceTest {
+	sim := NewSimulation(SimulationConfig{NumNodes: 2, Seed: 5})
+	db := kvstore.NewKVStore(backend.NewMemDatabase())
+	return &evidenceTest{
+		t:        t,
+		sim:      sim,
+		node:     sim.Node(0),
+		offender: sim.Node(1),
+		db:       db,
+		pool:     NewEvidencePool(db, sim.Node(0).Chain),
+	}
+}
+
+// newBlock creates a child of the root block in the given epoch, signed by the proposer. The block is added to
+// the chain of the node if known is true.
+func (et *evidenceTest) newBlock(proposer *SimNode, epoch uint64, timestamp int64, known bool) *score.Block {
+	block := score.NewBlock()
+	block.ChainID = et.sim.root.ChainID
+	block.Height = et.sim.root.Height + 1
+	block.Epoch = epoch
+	block.Parent = et.sim.root.Hash()
+	block.HCC.BlockHash = et.sim.root.Hash()
+	block.Timestamp = big.NewInt(timestamp)
+	block.Proposer = proposer.Address()
+	if err := proposer.signer.SignProposal(block.BlockHeader); err != nil {
+		et.t.Fatal(err)
+	}
+	if known {
+		if _, err := et.node.Chain.AddBlock(block); err != nil {
+			et.t.Fatal(err)
+		}
+	}
+	return block
+}
+
+func (et *evidenceTest) vote(voter *SimNode, block *score.Block, epoch uint64) score.Vote {
+	vote := score.Vote{Block: block.Hash(), Height: block.Height, Epoch: epoch, ID: voter.Address()}
+	if err := voter.signer.SignVote(&vote); err != nil {
+		et.t.Fatal(err)
+	}
+	return vote
+}
+
+func TestEvidencePoolVoteEquivocation(t *testing.T) {
+	assert := assert.New(t)
+
+	et := newEvidenceTest(t)
+	pool := et.pool
+	blockA := et.newBlock(et.node, 10, 1000, true)
+	blockB := et.newBlock(et.node, 10, 1001, true)
+	blockC := et.newBlock(et.node, 10, 1002, true)
+
+	// Voting again for the same block, or for another block in another epoch, is not an equivocation
+	assert.Equal(0, len(pool.AddVote(et.vote(et.offender, blockA, 10))))
+	assert.Equal(0, len(pool.AddVote(et.vote(et.offender, blockA, 10))))
+	assert.Equal(0, len(pool.AddVote(et.vote(et.node, blockA, 10))))
+	assert.Equal(0, len(pool.AddVote(et.vote(et.node, blockB, 11))))
+
+	// Voting for another block at the same height in the same epoch is
+	evidences := pool.AddVote(et.vote(et.offender, blockB, 10))
+	assert.Equal(1, len(evidences))
+	ev := evidences[0]
+	assert.Equal(score.EquivocationTypeVote, ev.Type)
+	assert.Equal(et.offender.Address(), ev.Offender)
+	assert.Equal(uint64(10), ev.Epoch)
+	assert.Equal(blockA.Height, ev.Height)
+	assert.True(ev.Validate(et.sim.root.ChainID).IsOK())
+
+	// The same offence is reported only once
+	assert.Equal(0, len(pool.AddVote(et.vote(et.offender, blockB, 10))))
+	assert.Equal(0, len(pool.AddVote(et.vote(et.offender, blockC, 10))))
+	assert.Equal(1, len(pool.GetPendingEvidence()))
+
+	// A conflicting vote for a block not received yet is detected once the block is proposed
+	unknown := et.newBlock(et.node, 12, 1003, false)
+	assert.Equal(0, len(pool.AddVote(et.vote(et.offender, blockA, 12))))
+	assert.Equal(0, len(pool.AddVote(et.vote(et.offender, unknown, 12))))
+	if _, err := et.node.Chain.AddBlock(unknown); err != nil {
+		t.Fatal(err)
+	}
+	evidences = pool.AddProposal(unknown.BlockHeader)
+	assert.Equal(1, len(evidences))
+	assert.Equal(uint64(12), evidences[0].Epoch)
+	assert.Equal(2, len(pool.GetPendingEvidence()))
+
+	// The votes of the epochs out of the observation window are ignored
+	assert.Equal(0, len(pool.AddVote(et.vote(et.node, blockA, 12+evidenceObservationWindow+1))))
+	assert.Equal(0, len(pool.AddVote(et.vote(et.node, blockA, 11))))
+	assert.Equal(0, len(pool.AddVote(et.vote(et.node, blockC, 11))))
+	assert.Equal(2, len(pool.GetPendingEvidence()))
+}
+
+func TestEvidencePoolProposalEquivocation(t *testing.T) {
+	assert := assert.New(t)
+
+	et := newEvidenceTest(t)
+	pool := et.pool
+	proposalA := et.newBlock(et.offender, 10, 1000, false)
+	proposalB := et.newBlock(et.offender, 10, 1001, false)
+	proposalC := et.newBlock(et.offender, 10, 1002, false)
+
+	assert.Equal(0, len(pool.AddProposal(proposalA.BlockHeader)))
+	assert.Equal(0, len(pool.AddProposal(proposalA.BlockHeader)))
+	assert.Equal(0, len(pool.AddProposal(et.newBlock(et.node, 10, 1001, false).BlockHeader)))
+	assert.Equal(0, len(pool.AddProposal(et.newBlock(et.offender, 11, 1001, false).BlockHeader)))
+
+	evidences := pool.AddProposal(proposalB.BlockHeader)
+	assert.Equal(1, len(evidences))
+	ev := evidences[0]
+	assert.Equal(score.EquivocationTypeProposal, ev.Type)
+	assert.Equal(et.offender.Address(), ev.Offender)
+	assert.Equal(uint64(10), ev.Epoch)
+	assert.True(ev.Validate(et.sim.root.ChainID).IsOK())
+
+	// The same offence is reported only once
+	assert.Equal(0, len(pool.AddProposal(proposalC.BlockHeader)))
+	assert.Equal(1, len(pool.GetPendingEvidence()))
+
+	// A proposal claimed to be from the offender but signed by another node is not an evidence
+	forged := et.newBlock(et.node, 13, 1000, false)
+	forged.Proposer = et.offender.Address()
+	forged.UpdateHash()
+	assert.Equal(0, len(pool.AddProposal(et.newBlock(et.offender, 13, 1001, false).BlockHeader)))
+	assert.Equal(0, len(pool.AddProposal(forged.BlockHeader)))
+	assert.Equal(1, len(pool.GetPendingEvidence()))
+}
+
+func TestEvidencePoolAddEvidence(t *testing.T) {
+	assert := assert.New(t)
+
+	et := newEvidenceTest(t)
+	blockA := et.newBlock(et.node, 10, 1000, true)
+	blockB := et.newBlock(et.node, 10, 1001, true)
+	ev := score.NewVoteEquivocationEvidence(et.vote(et.offender, blockA, 10), blockA.BlockHeader,
+		et.vote(et.offender, blockB, 10), blockB.BlockHeader)
+
+	// An evidence with a vote signed by another node is rejected
+	forgedVote := et.vote(et.node, blockB, 10)
+	forgedVote.ID = et.offender.Address()
+	forged := score.NewVoteEquivocationEvidence(et.vote(et.offender, blockA, 10), blockA.BlockHeader, forgedVote, blockB.BlockHeader)
+	added, err := et.pool.AddEvidence(forged)
+	assert.False(added)
+	assert.NotNil(err)
+
+	// So is an evidence whose votes do not match the blocks
+	mismatched := score.NewVoteEquivocationEvidence(et.vote(et.offender, blockA, 10), blockB.BlockHeader,
+		et.vote(et.offender, blockB, 10), blockA.BlockHeader)
+	added, err = et.pool.AddEvidence(mismatched)
+	assert.False(added)
+	assert.NotNil(err)
+	assert.Equal(0, len(et.pool.GetPendingEvidence()))
+
+	// A valid evidence is added once
+	added, err = et.pool.AddEvidence(ev)
+	assert.True(added)
+	assert.Nil(err)
+	added, err = et.pool.AddEvidence(ev)
+	assert.False(added)
+	assert.Nil(err)
+	assert.Equal(0, len(et.pool.AddVote(et.vote(et.offender, blockA, 10))))
+	assert.Equal(0, len(et.pool.AddVote(et.vote(et.offender, blockB, 10))))
+
+	// The pending evidence survives restarts, until it is included in a block
+	restarted := NewEvidencePool(et.db, et.node.Chain)
+	pending := restarted.GetPendingEvidence()
+	assert.Equal(1, len(pending))
+	assert.Equal(ev.ID(), pending[0].ID())
+
+	restarted.RemoveEvidence([]common.Hash{ev.ID()})
+	assert.Equal(0, len(restarted.GetPendingEvidence()))
+	assert.Equal(0, len(NewEvidencePool(et.db, et.node.Chain).GetPendingEvidence()))
+}
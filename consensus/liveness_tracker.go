@@ -0,0 +1,254 @@
+package consensus
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/store"
+
+	sbc "github.com/thetatoken/thetasubchain/blockchain"
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+)
+
+const (
+	DBLivenessProgressKey = "cs/lvp"
+	DBLivenessKeyPrefix   = "cs/lv/"
+
+	// Maximum number of skipped epochs between two finalized blocks accounted as missed proposal slots,
+	// so that a long chain halt does not make the tracker iterate over an unbounded number of epochs
+	maxLivenessEpochGap uint64 = 1000
+
+	// Maximum number of finalized blocks walked back to catch up with the last processed block
+	maxLivenessCatchUpBlocks = 10000
+)
+
+var _ score.DowntimeReporter = (*LivenessTracker)(nil)
+
+type livenessProgress struct {
+	LastBlock  common.Hash
+	LastHeight uint64
+	Dynasty    *big.Int
+}
+
+func livenessKey(dynasty *big.Int) []byte {
+	return []byte(fmt.Sprintf("%s%v", DBLivenessKeyPrefix, dynasty))
+}
+
+// LivenessTracker records, per dynasty, the proposal slots of each validator, the proposals it
+// actually made, and its votes included in the commit certificates. It only processes finalized
+// blocks, so that all the nodes derive the same figures regardless of the messages they received.
+type LivenessTracker struct {
+	mu *sync.Mutex
+
+	db               store.Store
+	chain            *sbc.Chain
+	validatorManager score.ValidatorManager
+
+	maxMissedProposalsPercent uint64
+	maxMissedVotesPercent     uint64
+	minSamples                uint64
+}
+
+// NewLivenessTracker creates an instance of LivenessTracker
+func NewLivenessTracker(db store.Store, chain *sbc.Chain, validatorManager score.ValidatorManager) *LivenessTracker {
+	return &LivenessTracker{
+		mu:                        &sync.Mutex{},
+		db:                        db,
+		chain:                     chain,
+		validatorManager:          validatorManager,
+		maxMissedProposalsPercent: uint64(viper.GetInt(scom.CfgSubchainLivenessMaxMissedProposalsPercent)),
+		maxMissedVotesPercent:     uint64(viper.GetInt(scom.CfgSubchainLivenessMaxMissedVotesPercent)),
+		minSamples:                uint64(viper.GetInt(scom.CfgSubchainLivenessMinSamples)),
+	}
+}
+
+// ProcessFinalizedBlock accounts the newly finalized block, and the finalized ancestors not processed yet
+func (lt *LivenessTracker) ProcessFinalizedBlock(block *score.ExtendedBlock) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	progress := lt.getProgress()
+	if progress != nil && block.Height <= progress.LastHeight {
+		return
+	}
+
+	// Walk back to the last processed block. If it cannot be reached, e.g. the node was restored
+	// from a snapshot, the tracking restarts from the earliest block found.
+	blocks := []*score.ExtendedBlock{block}
+	for progress != nil && len(blocks) < maxLivenessCatchUpBlocks {
+		earliest := blocks[len(blocks)-1]
+		if earliest.Parent == progress.LastBlock || earliest.Height <= progress.LastHeight+1 {
+			break
+		}
+		parent, err := lt.chain.FindBlock(earliest.Parent)
+		if err != nil {
+			break
+		}
+		blocks = append(blocks, parent)
+	}
+	if progress != nil && blocks[len(blocks)-1].Parent != progress.LastBlock {
+		logger.WithFields(log.Fields{
+			"lastProcessedHeight": progress.LastHeight,
+			"block.Height":        block.Height,
+		}).Warn("Liveness tracking restarted, the last processed block is not an ancestor")
+		progress = nil
+	}
+
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if err := lt.processBlock(blocks[i], progress); err != nil {
+			logger.WithFields(log.Fields{
+				"block":        blocks[i].Hash().Hex(),
+				"block.Height": blocks[i].Height,
+				"err":          err,
+			}).Warn("Failed to track the validator liveness")
+			return
+		}
+		progress = lt.getProgress()
+	}
+}
+
+func (lt *LivenessTracker) processBlock(block *score.ExtendedBlock, progress *livenessProgress) error {
+	parent, err := lt.chain.FindBlock(block.Parent)
+	if err != nil {
+		return err
+	}
+
+	validators := lt.validatorManager.GetNextValidatorSet(parent.Hash())
+	dynasty := validators.Dynasty()
+	dl, err := lt.GetDynastyLiveness(dynasty)
+	if err != nil {
+		// The figures are complete only if the dynasty transition is observed
+		dl = &score.DynastyLiveness{
+			Dynasty:     dynasty,
+			StartHeight: block.Height,
+			Complete:    progress != nil && progress.Dynasty != nil && progress.Dynasty.Cmp(dynasty) < 0,
+			Validators:  []*score.ValidatorLiveness{},
+		}
+	}
+	stats := make(map[common.Address]*score.ValidatorLiveness)
+	for _, vl := range dl.Validators {
+		stats[vl.Address] = vl
+	}
+	getStats := func(addr common.Address) *score.ValidatorLiveness {
+		vl, ok := stats[addr]
+		if !ok {
+			vl = &score.ValidatorLiveness{Address: addr}
+			stats[addr] = vl
+		}
+		return vl
+	}
+
+	// Every epoch since the parent block is a proposal slot, only the last one is filled by this block
+	startEpoch := parent.Epoch + 1
+	if block.Epoch-parent.Epoch > maxLivenessEpochGap {
+		startEpoch = block.Epoch - maxLivenessEpochGap + 1
+	}
+	for epoch := startEpoch; epoch <= block.Epoch; epoch++ {
+		proposer := lt.validatorManager.GetNextProposer(parent.Hash(), epoch)
+		getStats(proposer.ID()).ProposalSlots++
+	}
+	getStats(block.Proposer).Proposals++
+
+	// Each commit certificate is accounted once, by the first block carrying it
+	if block.HCC.BlockHash != parent.HCC.BlockHash && !block.HCC.IsEmpty() {
+		ccValidators := lt.validatorManager.GetValidatorSet(block.HCC.BlockHash)
+		voted := make(map[common.Address]bool)
+		for _, voter := range block.HCC.Voters(ccValidators) {
+			voted[voter] = true
+		}
+		for _, v := range ccValidators.Validators() {
+			vl := getStats(v.ID())
+			vl.CommitCertificates++
+			if voted[v.ID()] {
+				vl.VotesInCCs++
+			}
+		}
+	}
+
+	dl.EndHeight = block.Height
+	dl.Validators = []*score.ValidatorLiveness{}
+	for _, vl := range stats {
+		dl.Validators = append(dl.Validators, vl)
+	}
+	sort.Slice(dl.Validators, func(i, j int) bool {
+		return bytes.Compare(dl.Validators[i].Address[:], dl.Validators[j].Address[:]) < 0
+	})
+	if err := lt.db.Put(livenessKey(dynasty), dl); err != nil {
+		return err
+	}
+
+	return lt.db.Put([]byte(DBLivenessProgressKey), &livenessProgress{
+		LastBlock:  block.Hash(),
+		LastHeight: block.Height,
+		Dynasty:    dynasty,
+	})
+}
+
+// GetDynastyLiveness returns the liveness of the validators within the given dynasty
+func (lt *LivenessTracker) GetDynastyLiveness(dynasty *big.Int) (*score.DynastyLiveness, error) {
+	dl := &score.DynastyLiveness{}
+	if err := lt.db.Get(livenessKey(dynasty), dl); err != nil {
+		return nil, fmt.Errorf("no liveness record for dynasty %v: %v", dynasty, err)
+	}
+	return dl, nil
+}
+
+// GetLatestLivenessDynasty returns the dynasty of the last processed block, or nil if no block has been processed
+func (lt *LivenessTracker) GetLatestLivenessDynasty() *big.Int {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	progress := lt.getProgress()
+	if progress == nil {
+		return nil
+	}
+	return progress.Dynasty
+}
+
+// GetDowntimeReport flags the validators that missed more proposal slots or commit certificates than the
+// configured thresholds within the given dynasty
+func (lt *LivenessTracker) GetDowntimeReport(dynasty *big.Int) (*score.DowntimeReport, error) {
+	dl, err := lt.GetDynastyLiveness(dynasty)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &score.DowntimeReport{
+		Dynasty:     dl.Dynasty,
+		StartHeight: dl.StartHeight,
+		EndHeight:   dl.EndHeight,
+		Complete:    dl.Complete,
+		Offenders:   []*score.DowntimeRecord{},
+	}
+	for _, vl := range dl.Validators {
+		reasons := []string{}
+		if vl.ProposalSlots >= lt.minSamples && vl.MissedProposals()*100 > lt.maxMissedProposalsPercent*vl.ProposalSlots {
+			reasons = append(reasons, fmt.Sprintf("missed %v of %v proposal slots", vl.MissedProposals(), vl.ProposalSlots))
+		}
+		if vl.CommitCertificates >= lt.minSamples && vl.MissedVotes()*100 > lt.maxMissedVotesPercent*vl.CommitCertificates {
+			reasons = append(reasons, fmt.Sprintf("missing from %v of %v commit certificates", vl.MissedVotes(), vl.CommitCertificates))
+		}
+		if len(reasons) > 0 {
+			report.Offenders = append(report.Offenders, &score.DowntimeRecord{
+				Liveness: *vl,
+				Reasons:  reasons,
+			})
+		}
+	}
+	return report, nil
+}
+
+func (lt *LivenessTracker) getProgress() *livenessProgress {
+	progress := &livenessProgress{}
+	if err := lt.db.Get([]byte(DBLivenessProgressKey), progress); err != nil {
+		return nil
+	}
+	return progress
+}
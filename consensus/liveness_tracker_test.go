@@ -0,0 +1,175 @@
+package consensus
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/kvstore"
+
+	sbc "github.com/thetatoken/thetasubchain/blockchain"
+	score "github.com/thetatoken/thetasubchain/core"
+)
+
+// livenessTestValidatorManager rotates the proposers of the validator sets by epoch. The blocks above the
+// rollover height are validated by the second validator set.
+type livenessTestValidatorManager struct {
+	chain          *sbc.Chain
+	rolloverHeight uint64
+	validatorSets  []*score.ValidatorSet
+}
+
+func (vm *livenessTestValidatorManager) SetConsensusEngine(consensus score.ConsensusEngine) {}
+
+func (vm *livenessTestValidatorManager) GetProposer(blockHash common.Hash, epoch uint64) score.Validator {
+	validators := vm.GetValidatorSet(blockHash).Validators()
+	return validators[epoch%uint64(len(validators))]
+}
+
+func (vm *livenessTestValidatorManager) GetNextProposer(blockHash common.Hash, epoch uint64) score.Validator {
+	validators := vm.GetNextValidatorSet(blockHash).Validators()
+	return validators[epoch%uint64(len(validators))]
+}
+
+func (vm *livenessTestValidatorManager) GetValidatorSet(blockHash common.Hash) *score.ValidatorSet {
+	block, err := vm.chain.FindBlock(blockHash)
+	if err != nil || block.Height <= vm.rolloverHeight {
+		return vm.validatorSets[0]
+	}
+	return vm.validatorSets[1]
+}
+
+func (vm *livenessTestValidatorManager) GetNextValidatorSet(blockHash common.Hash) *score.ValidatorSet {
+	block, err := vm.chain.FindBlock(blockHash)
+	if err != nil || block.Height+1 <= vm.rolloverHeight {
+		return vm.validatorSets[0]
+	}
+	return vm.validatorSets[1]
+}
+
+func TestLivenessTracker(t *testing.T) {
+	assert := assert.New(t)
+
+	val0 := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	val1 := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	val2 := common.HexToAddress("0x0000000000000000000000000000000000000003")
+	newValidatorSet := func(dynasty int64) *score.ValidatorSet {
+		vs := score.NewValidatorSet(big.NewInt(dynasty))
+		for _, addr := range []common.Address{val0, val1, val2} {
+			vs.AddValidator(score.NewValidator(addr.Hex(), big.NewInt(100)))
+		}
+		return vs
+	}
+
+	root := score.NewBlock()
+	root.ChainID = "tsub_liveness"
+	root.Timestamp = big.NewInt(0)
+	chain := sbc.NewChain(root.ChainID, kvstore.NewKVStore(backend.NewMemDatabase()), root)
+	vm := &livenessTestValidatorManager{
+		chain:          chain,
+		rolloverHeight: 4,
+		validatorSets:  []*score.ValidatorSet{newValidatorSet(1), newValidatorSet(2)},
+	}
+	lt := NewLivenessTracker(kvstore.NewKVStore(backend.NewMemDatabase()), chain, vm)
+	lt.maxMissedProposalsPercent = 50
+	lt.maxMissedVotesPercent = 50
+	lt.minSamples = 1
+
+	// Each block carries the commit certificate of its parent, which the third validator never votes in
+	blocks := []*score.ExtendedBlock{chain.Root()}
+	addBlock := func(epoch uint64, proposer common.Address) *score.ExtendedBlock {
+		parent := blocks[len(blocks)-1]
+		votes := score.NewVoteSet()
+		for _, voter := range []common.Address{val0, val1} {
+			votes.AddVote(score.Vote{Block: parent.Hash(), Height: parent.Height, Epoch: epoch, ID: voter})
+		}
+		block := score.NewBlock()
+		block.ChainID = root.ChainID
+		block.Height = parent.Height + 1
+		block.Epoch = epoch
+		block.Parent = parent.Hash()
+		block.HCC = score.CommitCertificate{BlockHash: parent.Hash(), Votes: votes}
+		block.Timestamp = big.NewInt(int64(epoch))
+		block.Proposer = proposer
+		eb, err := chain.AddBlock(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, eb)
+		return eb
+	}
+
+	// The proposer of epoch 3 misses its slot
+	addBlock(1, val1)
+	addBlock(2, val2)
+	addBlock(4, val1)
+	addBlock(5, val2)
+	addBlock(6, val0)
+	addBlock(7, val1)
+
+	assert.Nil(lt.GetLatestLivenessDynasty())
+
+	// The finalized ancestors not processed yet are caught up with
+	lt.ProcessFinalizedBlock(blocks[1])
+	lt.ProcessFinalizedBlock(blocks[4])
+	lt.ProcessFinalizedBlock(blocks[3])
+	assert.Equal(0, big.NewInt(1).Cmp(lt.GetLatestLivenessDynasty()))
+
+	dl, err := lt.GetDynastyLiveness(big.NewInt(1))
+	assert.Nil(err)
+	assert.Equal(uint64(1), dl.StartHeight)
+	assert.Equal(uint64(4), dl.EndHeight)
+	assert.False(dl.Complete, "the first dynasty is tracked from its first block")
+	assert.Equal([]*score.ValidatorLiveness{
+		{Address: val0, ProposalSlots: 1, Proposals: 0, CommitCertificates: 4, VotesInCCs: 4},
+		{Address: val1, ProposalSlots: 2, Proposals: 2, CommitCertificates: 4, VotesInCCs: 4},
+		{Address: val2, ProposalSlots: 2, Proposals: 2, CommitCertificates: 4, VotesInCCs: 0},
+	}, dl.Validators)
+	_, err = lt.GetDynastyLiveness(big.NewInt(2))
+	assert.NotNil(err)
+
+	// The counters start over with the next dynasty, which is tracked from its first block
+	lt.ProcessFinalizedBlock(blocks[6])
+	assert.Equal(0, big.NewInt(2).Cmp(lt.GetLatestLivenessDynasty()))
+
+	dl, err = lt.GetDynastyLiveness(big.NewInt(2))
+	assert.Nil(err)
+	assert.Equal(uint64(5), dl.StartHeight)
+	assert.Equal(uint64(6), dl.EndHeight)
+	assert.True(dl.Complete)
+	assert.Equal([]*score.ValidatorLiveness{
+		{Address: val0, ProposalSlots: 1, Proposals: 1, CommitCertificates: 2, VotesInCCs: 2},
+		{Address: val1, ProposalSlots: 1, Proposals: 1, CommitCertificates: 2, VotesInCCs: 2},
+		{Address: val2, ProposalSlots: 0, Proposals: 0, CommitCertificates: 2, VotesInCCs: 0},
+	}, dl.Validators)
+
+	// The previous dynasty is not affected
+	dl, err = lt.GetDynastyLiveness(big.NewInt(1))
+	assert.Nil(err)
+	assert.Equal(uint64(4), dl.EndHeight)
+	assert.Equal(uint64(4), dl.Validators[2].CommitCertificates)
+
+	// The validators missing more than half of their proposal slots or commit certificates are flagged
+	report, err := lt.GetDowntimeReport(big.NewInt(1))
+	assert.Nil(err)
+	assert.False(report.Complete)
+	assert.Equal(2, len(report.Offenders))
+	assert.Equal(val0, report.Offenders[0].Liveness.Address)
+	assert.Equal([]string{"missed 1 of 1 proposal slots"}, report.Offenders[0].Reasons)
+	assert.Equal(val2, report.Offenders[1].Liveness.Address)
+	assert.Equal([]string{"missing from 4 of 4 commit certificates"}, report.Offenders[1].Reasons)
+
+	report, err = lt.GetDowntimeReport(big.NewInt(2))
+	assert.Nil(err)
+	assert.True(report.Complete)
+	assert.Equal(1, len(report.Offenders))
+	assert.Equal(val2, report.Offenders[0].Liveness.Address)
+
+	// No validator is flagged below the minimum number of samples
+	lt.minSamples = 3
+	report, err = lt.GetDowntimeReport(big.NewInt(2))
+	assert.Nil(err)
+	assert.Equal(0, len(report.Offenders))
+}
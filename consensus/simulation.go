@@ -0,0 +1,882 @@
+package consensus
+
+import (
+	"container/heap"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/dispatcher"
+	"github.com/thetatoken/theta/rlp"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/kvstore"
+
+	sbc "github.com/thetatoken/thetasubchain/blockchain"
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+	siu "github.com/thetatoken/thetasubchain/interchain/utils"
+	ssigner "github.com/thetatoken/thetasubchain/signer"
+)
+
+//
+// -------------------------------- Simulation ----------------------------------
+//
+// A Simulation runs a number of consensus engines in a single goroutine over a simulated network,
+// driven by a virtual clock. Every source of randomness is derived from the seed of the simulation,
+// so that a run can be reproduced exactly. The simulated network can delay, drop, reorder and
+// partition the messages, and the byzantine validators equivocate on their proposals and votes.
+//
+
+// SimulationConfig configures a consensus simulation.
+type SimulationConfig struct {
+	ChainID  string
+	NumNodes int
+	Seed     int64
+
+	// Validators are the indices of the nodes in the initial validator set, all the nodes if empty
+	Validators []int
+
+	// ValidatorSetUpdates maps block heights to the indices of the nodes in the validator set after the
+	// block at that height. Each update starts a new dynasty.
+	ValidatorSetUpdates map[uint64][]int
+
+	// Byzantine are the indices of the nodes that send conflicting proposals and votes to different peers
+	Byzantine []int
+
+	// MinDelay and MaxDelay bound the delay of each message. A MaxDelay larger than MinDelay reorders messages.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+
+	// DropRate is the probability that a message is lost
+	DropRate float64
+}
+
+// Simulation runs the consensus engines of the simulated nodes.
+type Simulation struct {
+	config SimulationConfig
+	rand   *rand.Rand
+
+	now    time.Time
+	seq    uint64
+	events simEventQueue
+
+	root            *score.Block
+	nodes           []*SimNode
+	partition       []int // partition group of each node, nil if the network is not partitioned
+	mainchainHeight *big.Int
+}
+
+// NewSimulation creates the nodes of the simulation and starts their consensus engines.
+func NewSimulation(config SimulationConfig) *Simulation {
+	if config.ChainID == "" {
+		config.ChainID = "tsub_sim"
+	}
+	if config.MaxDelay < config.MinDelay {
+		config.MaxDelay = config.MinDelay
+	}
+
+	s := &Simulation{
+		config:          config,
+		rand:            rand.New(rand.NewSource(config.Seed)),
+		now:             time.Unix(1600000000, 0),
+		mainchainHeight: big.NewInt(0),
+	}
+
+	s.root = score.NewBlock()
+	s.root.ChainID = config.ChainID
+	s.root.Height = score.GenesisBlockHeight
+	s.root.Epoch = score.GenesisBlockHeight
+	s.root.Timestamp = big.NewInt(s.now.Unix())
+	s.root.StateHash = crypto.Keccak256Hash([]byte(config.ChainID))
+
+	byzantine := make(map[int]bool)
+	for _, idx := range config.Byzantine {
+		byzantine[idx] = true
+	}
+	for i := 0; i < config.NumNodes; i++ {
+		s.nodes = append(s.nodes, newSimNode(s, i, byzantine[i]))
+	}
+	for _, node := range s.nodes {
+		node.start()
+	}
+
+	return s
+}
+
+// Nodes returns the simulated nodes.
+func (s *Simulation) Nodes() []*SimNode {
+	return s.nodes
+}
+
+// Node returns the simulated node with the given index.
+func (s *Simulation) Node(idx int) *SimNode {
+	return s.nodes[idx]
+}
+
+// Now implements the Clock interface.
+func (s *Simulation) Now() time.Time {
+	return s.now
+}
+
+// NewTimer implements the Clock interface.
+func (s *Simulation) NewTimer(d time.Duration) Timer {
+	t := &simTimer{
+		ch: make(chan time.Time, 1),
+	}
+	s.schedule(&simEvent{at: s.now.Add(d), timer: t})
+	return t
+}
+
+// SetMainchainHeight sets the mainchain height the nodes witness.
+func (s *Simulation) SetMainchainHeight(height uint64) {
+	s.mainchainHeight = new(big.Int).SetUint64(height)
+}
+
+// SetClockOffset sets how far the local clock of the node is ahead of the virtual clock, negative if behind.
+func (s *Simulation) SetClockOffset(idx int, offset time.Duration) {
+	s.nodes[idx].clockOffset = offset
+}
+
+// Partition splits the network into the given groups of node indices. The messages between nodes in
+// different groups, including those already in flight, are lost until Heal is called. Nodes not listed
+// in any group are isolated.
+func (s *Simulation) Partition(groups ...[]int) {
+	s.partition = make([]int, len(s.nodes))
+	for i := range s.partition {
+		s.partition[i] = -1 - i
+	}
+	for g, group := range groups {
+		for _, idx := range group {
+			s.partition[idx] = g
+		}
+	}
+}
+
+// Heal removes the network partition.
+func (s *Simulation) Heal() {
+	s.partition = nil
+}
+
+func (s *Simulation) connected(from, to int) bool {
+	return s.partition == nil || s.partition[from] == s.partition[to]
+}
+
+// Run processes the events of the simulation until the virtual clock advances by the given duration.
+func (s *Simulation) Run(d time.Duration) {
+	end := s.now.Add(d)
+	for s.events.Len() > 0 && !s.events[0].at.After(end) {
+		ev := heap.Pop(&s.events).(*simEvent)
+		s.now = ev.at
+		s.process(ev)
+	}
+	s.now = end
+}
+
+func (s *Simulation) schedule(ev *simEvent) {
+	ev.seq = s.seq
+	s.seq++
+	heap.Push(&s.events, ev)
+}
+
+func (s *Simulation) process(ev *simEvent) {
+	if ev.timer != nil {
+		if ev.timer.stopped {
+			return
+		}
+		ev.timer.fired = true
+		ev.timer.ch <- s.now
+		for _, node := range s.nodes {
+			node.handleTimers()
+		}
+		return
+	}
+
+	if ev.from >= 0 && !s.connected(ev.from, ev.to.Index) {
+		return
+	}
+	ev.to.receive(ev)
+}
+
+// send delivers the message to the node after a random delay, unless the message is lost.
+func (s *Simulation) send(from int, to *SimNode, kind simMessageKind, payload common.Bytes) {
+	if s.config.DropRate > 0 && s.rand.Float64() < s.config.DropRate {
+		return
+	}
+	delay := s.config.MinDelay
+	if jitter := s.config.MaxDelay - s.config.MinDelay; jitter > 0 {
+		delay += time.Duration(s.rand.Int63n(int64(jitter) + 1))
+	}
+	s.schedule(&simEvent{
+		at:      s.now.Add(delay),
+		from:    from,
+		to:      to,
+		kind:    kind,
+		payload: payload,
+	})
+}
+
+// validatorSetAt returns the validator set in the ledger state after the block at the given height.
+func (s *Simulation) validatorSetAt(height uint64) *score.ValidatorSet {
+	members := s.config.Validators
+	dynasty := int64(0)
+	updateHeight := uint64(0)
+	for h, update := range s.config.ValidatorSetUpdates {
+		if h > height {
+			continue
+		}
+		dynasty++
+		if h >= updateHeight {
+			updateHeight = h
+			members = update
+		}
+	}
+	if len(members) == 0 {
+		members = make([]int, len(s.nodes))
+		for i := range members {
+			members[i] = i
+		}
+	}
+
+	vs := score.NewValidatorSet(big.NewInt(dynasty))
+	for _, idx := range members {
+		node := s.nodes[idx]
+		vs.AddValidator(score.NewValidator(node.Address().Hex(), big.NewInt(1)))
+		// The keys only take effect after the BLS commit certificate fork
+		if pubKey, _, err := node.signer.BLSPubKey(); err == nil {
+			vs.SetBLSPubKey(node.Address(), pubKey)
+		}
+	}
+	return vs
+}
+
+// AssertSafety asserts that no two honest nodes have finalized conflicting blocks.
+func (s *Simulation) AssertSafety(assert *assert.Assertions) {
+	honest := s.honestNodes()
+	for i := 0; i < len(honest); i++ {
+		for j := i + 1; j < len(honest); j++ {
+			AssertFinalizedBlocksNotConflicting(assert, honest[i].FinalizedChain(), honest[j].FinalizedChain(),
+				fmt.Sprintf("Node %v and node %v finalized conflicting blocks", honest[i].Index, honest[j].Index))
+		}
+	}
+}
+
+// AssertLiveness asserts that every honest node has finalized a block at or above the given height.
+func (s *Simulation) AssertLiveness(assert *assert.Assertions, minHeight uint64) {
+	for _, node := range s.honestNodes() {
+		height := node.Engine.GetLastFinalizedBlock().Height
+		assert.True(height >= minHeight, "Node %v finalized height %v, expected at least %v", node.Index, height, minHeight)
+	}
+}
+
+func (s *Simulation) honestNodes() []*SimNode {
+	honest := []*SimNode{}
+	for _, node := range s.nodes {
+		if !node.Byzantine {
+			honest = append(honest, node)
+		}
+	}
+	return honest
+}
+
+//
+// -------------------------------- SimNode ----------------------------------
+//
+
+// SimNode is a node of the simulation running its own consensus engine, chain and simulated ledger.
+type SimNode struct {
+	Index     int
+	Byzantine bool
+	Engine    *ConsensusEngine
+	Chain     *sbc.Chain
+
+	sim         *Simulation
+	signer      *ssigner.LocalSigner
+	orphans     map[common.Hash][]*score.Block // blocks waiting for their parent, indexed by the parent hash
+	clockOffset time.Duration                  // offset of the local clock from the virtual clock
+
+	conflicts map[common.Hash]common.Hash // conflicting blocks proposed by a byzantine node
+}
+
+func newSimNode(sim *Simulation, idx int, byzantine bool) *SimNode {
+	privKey, _, err := crypto.TEST_GenerateKeyPairWithSeed(fmt.Sprintf("sim-%v-%v", sim.config.Seed, idx))
+	if err != nil {
+		panic(err)
+	}
+
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	chain := sbc.NewChain(sim.config.ChainID, store, sim.root)
+	validatorManager := NewRotatingValidatorManager(chain)
+	engine := NewConsensusEngine(privKey, store, chain, nil, validatorManager, &simWitness{sim: sim})
+
+	node := &SimNode{
+		Index:     idx,
+		Byzantine: byzantine,
+		Engine:    engine,
+		Chain:     chain,
+		sim:       sim,
+		signer:    ssigner.NewLocalSigner(privKey),
+		orphans:   make(map[common.Hash][]*score.Block),
+		conflicts: make(map[common.Hash]common.Hash),
+	}
+
+	engine.clock = &simNodeClock{node: node}
+	engine.dispatcher = &simSender{node: node}
+	engine.loopback = func(msg interface{}) {
+		sim.schedule(&simEvent{at: sim.now, from: -1, to: node, kind: simMessageOwn, own: msg})
+	}
+	engine.SetLedger(&simLedger{sim: sim, chain: chain, state: sim.root})
+	validatorManager.SetConsensusEngine(engine)
+
+	return node
+}
+
+// Address returns the address of the validator key of the node.
+func (n *SimNode) Address() common.Address {
+	return n.signer.Address()
+}
+
+// FinalizedChain returns the hashes of the blocks finalized by the node, from the root block to the last finalized block.
+func (n *SimNode) FinalizedChain() []string {
+	chain := []string{}
+	block := n.Engine.GetLastFinalizedBlock()
+	for block != nil {
+		chain = append([]string{block.Hash().String()}, chain...)
+		if block.Hash() == n.Chain.Root().Hash() {
+			break
+		}
+		parent, err := n.Chain.FindBlock(block.Parent)
+		if err != nil {
+			break
+		}
+		block = parent
+	}
+	return chain
+}
+
+// start mirrors ConsensusEngine.Start, except that the events are driven by the simulation instead of the main loop.
+func (n *SimNode) start() {
+	e := n.Engine
+	e.ctx, e.cancel = context.WithCancel(context.Background())
+	e.initialize()
+	e.startEpoch()
+}
+
+// handleTimers processes the timers of the engine that have fired.
+func (n *SimNode) handleTimers() {
+	e := n.Engine
+	select {
+	case <-e.voteTimer.Chan():
+		e.handleVoteTimeout()
+	default:
+	}
+	select {
+	case <-e.epochTimer.Chan():
+		e.handleEpochTimeout()
+	default:
+	}
+	n.passReadyOrphans()
+}
+
+// passReadyOrphans mirrors the request manager, which passes the blocks whose parent has become valid since
+// they arrived, e.g. the children of a block held back until the local clock caught up with its timestamp.
+func (n *SimNode) passReadyOrphans() {
+	for parentHash, children := range n.orphans {
+		if parent, err := n.Chain.FindBlock(parentHash); err != nil || !parent.Status.IsValid() {
+			continue
+		}
+		delete(n.orphans, parentHash)
+		for _, child := range children {
+			n.receiveBlock(child, -1)
+		}
+	}
+}
+
+// receive mirrors the sync manager, which decodes the messages from the peers before passing them to the engine.
+func (n *SimNode) receive(ev *simEvent) {
+	e := n.Engine
+	switch ev.kind {
+	case simMessageOwn:
+		e.handleMessage(ev.own)
+	case simMessageVote:
+		vote := score.Vote{}
+		if err := rlp.DecodeBytes(ev.payload, &vote); err != nil {
+			return
+		}
+		n.receiveVote(vote)
+	case simMessageProposal:
+		proposal := score.Proposal{}
+		if err := rlp.DecodeBytes(ev.payload, &proposal); err != nil {
+			return
+		}
+		if proposal.Votes != nil {
+			for _, vote := range proposal.Votes.Votes() {
+				n.receiveVote(vote)
+			}
+		}
+		n.receiveBlock(proposal.Block, ev.from)
+	case simMessageBlock:
+		block := &score.Block{}
+		if err := rlp.DecodeBytes(ev.payload, block); err != nil {
+			return
+		}
+		n.receiveBlock(block, ev.from)
+	case simMessageBlockRequest:
+		hash := common.BytesToHash(ev.payload)
+		eb, err := n.Chain.FindBlock(hash)
+		if err != nil {
+			return
+		}
+		payload, err := rlp.EncodeToBytes(eb.Block)
+		if err != nil {
+			return
+		}
+		n.sim.send(n.Index, n.sim.nodes[ev.from], simMessageBlock, payload)
+	case simMessageEvidence:
+		evidence := &score.EquivocationEvidence{}
+		if err := rlp.DecodeBytes(ev.payload, evidence); err != nil {
+			return
+		}
+		e.evidencePool.AddEvidence(evidence)
+	}
+}
+
+func (n *SimNode) receiveVote(vote score.Vote) {
+	for _, v := range n.Chain.FindVotesByHash(vote.Block).Votes() {
+		if v.Block == vote.Block && v.Epoch == vote.Epoch && v.Height == vote.Height && v.ID == vote.ID {
+			return
+		}
+	}
+	if b, err := n.Chain.FindBlock(vote.Block); err == nil && b.Status == score.BlockStatusDisposed {
+		return
+	}
+	n.Engine.handleMessage(vote)
+}
+
+// receiveBlock adds the block to the chain once its parent has been processed, and requests the missing
+// parent from the sender otherwise.
+func (n *SimNode) receiveBlock(block *score.Block, from int) {
+	if block == nil {
+		return
+	}
+	if eb, err := n.Chain.FindBlock(block.Hash()); err == nil && !eb.Status.IsPending() {
+		return
+	}
+	if res := block.Validate(n.Chain.ChainID); res.IsError() {
+		return
+	}
+
+	parent, err := n.Chain.FindBlock(block.Parent)
+	if err != nil || parent.Status.IsPending() {
+		n.orphans[block.Parent] = append(n.orphans[block.Parent], block)
+		if err != nil && from >= 0 {
+			n.sim.send(n.Index, n.sim.nodes[from], simMessageBlockRequest, block.Parent.Bytes())
+		}
+		return
+	}
+
+	if _, err := n.Chain.AddBlock(block); err != nil {
+		return
+	}
+	n.Engine.handleMessage(block)
+
+	children := n.orphans[block.Hash()]
+	delete(n.orphans, block.Hash())
+	for _, child := range children {
+		n.receiveBlock(child, from)
+	}
+}
+
+//
+// -------------------------------- Simulated network ----------------------------------
+//
+
+type simMessageKind int
+
+const (
+	simMessageOwn simMessageKind = iota
+	simMessageVote
+	simMessageProposal
+	simMessageEvidence
+	simMessageBlock
+	simMessageBlockRequest
+)
+
+var _ MessageSender = (*simSender)(nil)
+
+// simSender broadcasts the messages of a node over the simulated network.
+type simSender struct {
+	node *SimNode
+}
+
+// SendData implements the MessageSender interface.
+func (ss *simSender) SendData(peerIDs []string, datamsg dispatcher.DataResponse) {
+	var kind simMessageKind
+	switch datamsg.ChannelID {
+	case common.ChannelIDVote:
+		kind = simMessageVote
+	case common.ChannelIDProposal:
+		kind = simMessageProposal
+	case scom.ChannelIDEquivocationEvidence:
+		kind = simMessageEvidence
+	default:
+		return
+	}
+
+	conflicting := datamsg.Payload
+	if ss.node.Byzantine {
+		conflicting = ss.node.equivocate(kind, datamsg.Payload)
+	}
+
+	for _, peer := range ss.node.sim.nodes {
+		if peer == ss.node {
+			continue
+		}
+		// A byzantine node sends the conflicting message to half of its peers
+		payload := datamsg.Payload
+		if peer.Index%2 == 1 {
+			payload = conflicting
+		}
+		ss.node.sim.send(ss.node.Index, peer, kind, payload)
+	}
+}
+
+// equivocate creates a message conflicting with the proposal or vote of the node. Other messages are returned as is.
+func (n *SimNode) equivocate(kind simMessageKind, payload common.Bytes) common.Bytes {
+	switch kind {
+	case simMessageProposal:
+		proposal := score.Proposal{}
+		if err := rlp.DecodeBytes(payload, &proposal); err != nil || proposal.Block == nil ||
+			proposal.Block.Proposer != n.Address() {
+			return payload
+		}
+		original := proposal.Block.Hash()
+		proposal.Block.Timestamp = new(big.Int).Add(proposal.Block.Timestamp, big.NewInt(1))
+		if err := n.signer.SignProposal(proposal.Block.BlockHeader); err != nil {
+			return payload
+		}
+		n.conflicts[original] = proposal.Block.Hash()
+		conflicting, err := rlp.EncodeToBytes(proposal)
+		if err != nil {
+			return payload
+		}
+		return conflicting
+	case simMessageVote:
+		vote := score.Vote{}
+		if err := rlp.DecodeBytes(payload, &vote); err != nil || vote.ID != n.Address() {
+			return payload
+		}
+		if conflict, ok := n.conflicts[vote.Block]; ok {
+			vote.Block = conflict
+		} else {
+			vote.Block = crypto.Keccak256Hash(vote.Block.Bytes())
+		}
+		vote.BLSSignature = nil
+		if err := n.signer.SignVote(&vote); err != nil {
+			return payload
+		}
+		conflicting, err := rlp.EncodeToBytes(vote)
+		if err != nil {
+			return payload
+		}
+		return conflicting
+	}
+	return payload
+}
+
+//
+// -------------------------------- Virtual clock ----------------------------------
+//
+
+type simEvent struct {
+	at  time.Time
+	seq uint64
+
+	timer *simTimer // the timer to fire, nil for messages
+
+	from    int // index of the sender, -1 for the messages of the engine to itself
+	to      *SimNode
+	kind    simMessageKind
+	payload common.Bytes
+	own     interface{}
+}
+
+// simEventQueue orders the events by time, and by the order they were scheduled for the same time.
+type simEventQueue []*simEvent
+
+func (q simEventQueue) Len() int { return len(q) }
+
+func (q simEventQueue) Less(i, j int) bool {
+	if q[i].at.Equal(q[j].at) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].at.Before(q[j].at)
+}
+
+func (q simEventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *simEventQueue) Push(x interface{}) {
+	*q = append(*q, x.(*simEvent))
+}
+
+func (q *simEventQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	ev := old[n-1]
+	*q = old[:n-1]
+	return ev
+}
+
+var _ Clock = (*simNodeClock)(nil)
+
+// simNodeClock is the local clock of a node, which runs at the pace of the virtual clock with an offset.
+type simNodeClock struct {
+	node *SimNode
+}
+
+// Now implements the Clock interface.
+func (c *simNodeClock) Now() time.Time {
+	return c.node.sim.now.Add(c.node.clockOffset)
+}
+
+// NewTimer implements the Clock interface.
+func (c *simNodeClock) NewTimer(d time.Duration) Timer {
+	return c.node.sim.NewTimer(d)
+}
+
+var _ Timer = (*simTimer)(nil)
+
+// simTimer is a timer of the virtual clock.
+type simTimer struct {
+	ch      chan time.Time
+	fired   bool
+	stopped bool
+}
+
+func (t *simTimer) Chan() <-chan time.Time {
+	return t.ch
+}
+
+func (t *simTimer) Stop() bool {
+	active := !t.fired && !t.stopped
+	t.stopped = true
+	return active
+}
+
+//
+// -------------------------------- Simulated ledger ----------------------------------
+//
+
+var _ score.Ledger = (*simLedger)(nil)
+
+// simLedger is a ledger without transactions. The state of a block is derived from the state of its parent
+// and its height, and the validator sets follow the schedule of the simulation.
+type simLedger struct {
+	sim   *Simulation
+	chain *sbc.Chain
+	state *score.Block // the block the ledger state points to
+}
+
+func (l *simLedger) stateHashOf(height uint64) common.Hash {
+	heightBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(heightBytes, height)
+	return crypto.Keccak256Hash(l.state.StateHash.Bytes(), heightBytes)
+}
+
+func (l *simLedger) GetCurrentBlock() *score.Block {
+	return nil
+}
+
+func (l *simLedger) GetDynasty() *big.Int {
+	return l.sim.validatorSetAt(l.state.Height).Dynasty()
+}
+
+func (l *simLedger) ScreenTxUnsafe(rawTx common.Bytes) result.Result {
+	return result.Error("Transactions are not supported in simulations")
+}
+
+func (l *simLedger) ScreenTx(rawTx common.Bytes) (*score.TxInfo, result.Result) {
+	return nil, result.Error("Transactions are not supported in simulations")
+}
+
+func (l *simLedger) ProposeBlockTxs(block *score.Block, shouldIncludeValidatorUpdateTxs bool) (common.Hash, []common.Bytes, result.Result) {
+	return l.stateHashOf(block.Height), []common.Bytes{}, result.OK
+}
+
+func (l *simLedger) ApplyBlockTxs(block *score.Block) result.Result {
+	if expected := l.stateHashOf(block.Height); block.StateHash != expected {
+		return result.Error("State root mismatch! root: %v, exptected: %v", block.StateHash.Hex(), expected.Hex())
+	}
+	l.state = block
+	_, hasValidatorUpdate := l.sim.config.ValidatorSetUpdates[block.Height]
+	return result.OKWith(result.Info{"hasValidatorUpdate": hasValidatorUpdate})
+}
+
+func (l *simLedger) ApplyBlockTxsForChainCorrection(block *score.Block) (common.Hash, result.Result) {
+	stateHash := l.stateHashOf(block.Height)
+	l.state = block
+	return stateHash, result.OK
+}
+
+func (l *simLedger) ResetState(block *score.Block) result.Result {
+	l.state = block
+	return result.OK
+}
+
+func (l *simLedger) FinalizeState(height uint64, rootHash common.Hash) result.Result {
+	return result.OK
+}
+
+// GetFinalizedValidatorSet mirrors the ledger, which reads the validator set from the state of the
+// grandparent, or the nearest directly finalized ancestor.
+func (l *simLedger) GetFinalizedValidatorSet(blockHash common.Hash, isNext bool) (*score.ValidatorSet, error) {
+	i := 2
+	if isNext {
+		i = 1
+	}
+	for ; ; i-- {
+		block, err := l.chain.FindBlock(blockHash)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 || block.HCC.BlockHash.IsEmpty() || block.Status.IsTrusted() {
+			return l.sim.validatorSetAt(block.Height), nil
+		}
+		blockHash = block.HCC.BlockHash
+	}
+}
+
+func (l *simLedger) PruneState(endHeight uint64) error {
+	return nil
+}
+
+func (l *simLedger) GetTokenBankContractAddress(tokenType score.CrossChainTokenType) *common.Address {
+	return nil
+}
+
+func (l *simLedger) GetSubchainRegisterContractAddress() *common.Address {
+	return nil
+}
+
+func (l *simLedger) GetTxInfo(rawTx common.Bytes) (*score.TxInfo, result.Result) {
+	return nil, result.Error("Transactions are not supported in simulations")
+}
+
+func (l *simLedger) GetFinalizedEquivocationRecords(startIndex uint64, maxCount int) ([]*score.EquivocationRecord, error) {
+	return []*score.EquivocationRecord{}, nil
+}
+
+func (l *simLedger) GetFinalizedNativeStakeRecords(startIndex uint64, maxCount int) ([]*score.NativeStakeRecord, error) {
+	return []*score.NativeStakeRecord{}, nil
+}
+
+func (l *simLedger) GetFinalizedDelegatedShares(validator common.Address, staker common.Address) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (l *simLedger) GetFinalizedGovernanceProposals(startID uint64, maxCount int) ([]*score.GovernanceProposal, uint64, error) {
+	return []*score.GovernanceProposal{}, 0, nil
+}
+
+func (l *simLedger) GetFinalizedDowntimeSlashProposal(value string) (*score.GovernanceProposal, error) {
+	return nil, nil
+}
+
+func (l *simLedger) GetFinalizedNativeStakeRejectionProposal(recordIndex uint64) (*score.GovernanceProposal, uint64, error) {
+	return nil, 0, nil
+}
+
+func (l *simLedger) GetFinalizedNativeStakeRelayer() (common.Address, bool, error) {
+	return common.Address{}, false, nil
+}
+
+func (l *simLedger) GetFinalizedAccountSequence(address common.Address) (uint64, error) {
+	return 0, nil
+}
+
+func (l *simLedger) GetFinalizedStakerRewards() (*score.RewardSchedule, []score.StakerReward, uint64, error) {
+	return nil, []score.StakerReward{}, 0, nil
+}
+
+func (l *simLedger) GetBlockGasLimit(parent *score.Block) uint64 {
+	return math.MaxUint64
+}
+
+func (l *simLedger) GetMinBlockInterval(height uint64) time.Duration {
+	return scom.GetMinBlockInterval(height)
+}
+
+func (l *simLedger) GetMinimumGasPrice(height uint64) *big.Int {
+	return scom.GetMinimumGasPrice()
+}
+
+func (l *simLedger) GetUpgradePlans(parent *score.Block) []score.UpgradePlan {
+	return []score.UpgradePlan{}
+}
+
+func (l *simLedger) GetValidatorIdentity(key common.Address) (common.Address, error) {
+	return key, nil
+}
+
+//
+// -------------------------------- Simulated witness ----------------------------------
+//
+
+// simWitness witnesses the mainchain height set by the simulation. The simulated nodes do not
+// process inter-chain messages.
+type simWitness struct {
+	sim *Simulation
+}
+
+func (w *simWitness) Start(ctx context.Context) {}
+
+func (w *simWitness) Stop() {}
+
+func (w *simWitness) Wait() {}
+
+func (w *simWitness) GetMainchainBlockHeight() (*big.Int, error) {
+	return new(big.Int).Set(w.sim.mainchainHeight), nil
+}
+
+func (w *simWitness) GetValidatorSetByDynasty(dynasty *big.Int) (*score.ValidatorSet, error) {
+	return nil, fmt.Errorf("Validator sets are not witnessed in simulations")
+}
+
+func (w *simWitness) GetValidatorSetByDynastyForChain(dynasty *big.Int, subchainID *big.Int) (*score.ValidatorSet, error) {
+	return nil, fmt.Errorf("Validator sets are not witnessed in simulations")
+}
+
+func (w *simWitness) GetValidatorSetByDynastyAtMainchainHeight(dynasty *big.Int, mainchainHeight *big.Int) (*score.ValidatorSet, error) {
+	return nil, fmt.Errorf("Validator sets are not witnessed in simulations")
+}
+
+func (w *simWitness) GetMainchainBlockHash(mainchainHeight *big.Int) (common.Hash, error) {
+	return common.Hash{}, fmt.Errorf("Mainchain blocks are not witnessed in simulations")
+}
+
+func (w *simWitness) GetInterChainEventCache() *siu.InterChainEventCache {
+	return nil
+}
+
+func (w *simWitness) GetInterSubchainChannelWatchList() []*big.Int {
+	return []*big.Int{}
+}
+
+func (w *simWitness) GetStakeEvents(fromHeight *big.Int, toHeight *big.Int) ([]*score.StakeEvent, error) {
+	return nil, fmt.Errorf("Stake events are not witnessed in simulations")
+}
+
+func (w *simWitness) GetSubchainRegistrationHeight() (*big.Int, error) {
+	return nil, fmt.Errorf("Stake events are not witnessed in simulations")
+}
+
+func (w *simWitness) GetKeyRotations(fromHeight *big.Int, toHeight *big.Int) ([]*score.KeyRotation, error) {
+	return nil, fmt.Errorf("Key rotations are not witnessed in simulations")
+}
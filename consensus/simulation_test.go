@@ -0,0 +1,81 @@
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulationWithMessageDelays(t *testing.T) {
+	assert := assert.New(t)
+
+	sim := NewSimulation(SimulationConfig{
+		NumNodes: 4,
+		Seed:     1,
+		MinDelay: 50 * time.Millisecond,
+		MaxDelay: 500 * time.Millisecond,
+		DropRate: 0.05,
+	})
+	sim.Run(60 * time.Second)
+
+	sim.AssertSafety(assert)
+	sim.AssertLiveness(assert, 5)
+}
+
+func TestSimulationWithPartition(t *testing.T) {
+	assert := assert.New(t)
+
+	sim := NewSimulation(SimulationConfig{
+		NumNodes: 4,
+		Seed:     2,
+		MinDelay: 50 * time.Millisecond,
+		MaxDelay: 200 * time.Millisecond,
+	})
+	sim.Run(10 * time.Second)
+
+	// Neither side holds the majority of the stake
+	sim.Partition([]int{0, 1}, []int{2, 3})
+	sim.Run(30 * time.Second)
+	sim.AssertSafety(assert)
+
+	sim.Heal()
+	sim.Run(60 * time.Second)
+	sim.AssertSafety(assert)
+	sim.AssertLiveness(assert, 5)
+}
+
+func TestSimulationWithByzantineValidator(t *testing.T) {
+	assert := assert.New(t)
+
+	sim := NewSimulation(SimulationConfig{
+		NumNodes:  4,
+		Seed:      3,
+		Byzantine: []int{3},
+		MinDelay:  50 * time.Millisecond,
+		MaxDelay:  300 * time.Millisecond,
+	})
+	sim.Run(90 * time.Second)
+
+	sim.AssertSafety(assert)
+	sim.AssertLiveness(assert, 3)
+}
+
+func TestSimulationWithDynastyTransition(t *testing.T) {
+	assert := assert.New(t)
+
+	sim := NewSimulation(SimulationConfig{
+		NumNodes:   5,
+		Seed:       4,
+		Validators: []int{0, 1, 2, 3},
+		ValidatorSetUpdates: map[uint64][]int{
+			5: {1, 2, 3, 4},
+		},
+		MinDelay: 50 * time.Millisecond,
+		MaxDelay: 200 * time.Millisecond,
+	})
+	sim.Run(90 * time.Second)
+
+	sim.AssertSafety(assert)
+	sim.AssertLiveness(assert, 10)
+}
@@ -6,6 +6,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/thetatoken/theta/common"
+	sbc "github.com/thetatoken/thetasubchain/blockchain"
 	score "github.com/thetatoken/thetasubchain/core"
 )
 
@@ -70,14 +71,18 @@ func (m *FixedValidatorManager) GetNextValidatorSet(blockHash common.Hash) *scor
 var _ score.ValidatorManager = &RotatingValidatorManager{}
 
 // RotatingValidatorManager is an implementation of ValidatorManager interface that selects a random validator as
-// the proposer using validator's stake as weight.
+// the proposer using validator's stake as weight. After the VRF proposer selection fork, the randomness is the
+// VRF output carried by the parent block instead of the epoch number.
 type RotatingValidatorManager struct {
 	consensus score.ConsensusEngine
+	chain     *sbc.Chain
 }
 
 // NewRotatingValidatorManager creates an instance of RotatingValidatorManager.
-func NewRotatingValidatorManager() *RotatingValidatorManager {
-	m := &RotatingValidatorManager{}
+func NewRotatingValidatorManager(chain *sbc.Chain) *RotatingValidatorManager {
+	m := &RotatingValidatorManager{
+		chain: chain,
+	}
 	return m
 }
 
@@ -88,15 +93,29 @@ func (m *RotatingValidatorManager) SetConsensusEngine(consensus score.ConsensusE
 
 // GetProposer implements ValidatorManager interface.
 func (m *RotatingValidatorManager) GetProposer(blockHash common.Hash, epoch uint64) score.Validator {
-	return m.getProposerFromValidators(m.GetValidatorSet(blockHash), epoch)
+	var seed *common.Hash
+	if block, ok := m.findBlock(blockHash); ok && vrfProposerSelectionEnabled(block.Height) {
+		if parent, ok := m.findBlock(block.Parent); ok {
+			parentSeed := blockSeed(parent.BlockHeader)
+			seed = &parentSeed
+		}
+	}
+	return m.getProposerFromValidators(m.GetValidatorSet(blockHash), epoch, seed)
 }
 
 // GetNextProposer implements ValidatorManager interface.
 func (m *RotatingValidatorManager) GetNextProposer(blockHash common.Hash, epoch uint64) score.Validator {
-	return m.getProposerFromValidators(m.GetNextValidatorSet(blockHash), epoch)
+	var seed *common.Hash
+	if block, ok := m.findBlock(blockHash); ok && vrfProposerSelectionEnabled(block.Height+1) {
+		parentSeed := blockSeed(block.BlockHeader)
+		seed = &parentSeed
+	}
+	return m.getProposerFromValidators(m.GetNextValidatorSet(blockHash), epoch, seed)
 }
 
-func (m *RotatingValidatorManager) getProposerFromValidators(valSet *score.ValidatorSet, epoch uint64) score.Validator {
+// getProposerFromValidators selects the proposer of the epoch weighted by stake. The seed is the randomness
+// of the parent block, nil before the VRF proposer selection fork.
+func (m *RotatingValidatorManager) getProposerFromValidators(valSet *score.ValidatorSet, epoch uint64, seed *common.Hash) score.Validator {
 	if valSet.Size() == 0 {
 		log.Panic("No validators have been added")
 	}
@@ -106,9 +125,14 @@ func (m *RotatingValidatorManager) getProposerFromValidators(valSet *score.Valid
 	scalingFactor = new(big.Int).Add(scalingFactor, common.Big1)
 	scaledTotalStake := scaleDown(totalStake, scalingFactor)
 
-	// TODO: replace with more secure randomness.
-	rnd := rand.New(rand.NewSource(int64(epoch)))
-	r := randUint64(rnd, scaledTotalStake)
+	var r uint64
+	if seed != nil {
+		r = vrfSelectionPoint(*seed, epoch, scaledTotalStake)
+	} else {
+		// Legacy selection, predictable since it only depends on the epoch
+		rnd := rand.New(rand.NewSource(int64(epoch)))
+		r = randUint64(rnd, scaledTotalStake)
+	}
 	curr := uint64(0)
 	validators := valSet.Validators()
 	for _, v := range validators {
@@ -123,6 +147,21 @@ func (m *RotatingValidatorManager) getProposerFromValidators(valSet *score.Valid
 	panic("Should not reach here")
 }
 
+// findBlock returns the block for the proposer selection. The blocks without a parent in the chain, i.e. the
+// genesis block and the root of a snapshot whose ancestors were pruned, fall back to the legacy selection. All
+// the nodes agree on it, since the proposers of those blocks are never checked against a VRF seed.
+func (m *RotatingValidatorManager) findBlock(blockHash common.Hash) (*score.ExtendedBlock, bool) {
+	if blockHash.IsEmpty() {
+		return nil, false // the parent of the genesis block
+	}
+	block, err := m.chain.FindBlock(blockHash)
+	if err != nil {
+		log.Debugf("Block %v is not available for proposer selection, using the legacy selection: %v", blockHash.Hex(), err)
+		return nil, false
+	}
+	return block, true
+}
+
 // GetValidatorSet returns the validator set for given block.
 func (m *RotatingValidatorManager) GetValidatorSet(blockHash common.Hash) *score.ValidatorSet {
 	valSet := selectValidatorsForBlock(m.consensus, blockHash, false)
@@ -150,6 +189,11 @@ func FilterValidators(vs *score.ValidatorSet) *score.ValidatorSet {
 		validator := score.NewValidator(valAddr, valStake)
 		valSet.AddValidator(validator)
 	}
+	for _, pk := range vs.BLSPubKeys() {
+		if _, err := valSet.GetValidator(pk.Address); err == nil {
+			valSet.SetBLSPubKey(pk.Address, pk.PubKey)
+		}
+	}
 
 	return valSet
 }
@@ -0,0 +1,49 @@
+package consensus
+
+import (
+	"math/big"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/rlp"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+	"github.com/thetatoken/thetasubchain/crypto/vrf"
+)
+
+//
+// The proposer of a block proves the VRF output on the seed of the parent block, and the output becomes
+// the seed used to select the proposers of the next block. Since the output is unique, the proposer cannot
+// grind for a favorable schedule, and since it is unknown until the block is published, the proposers can
+// not be precomputed.
+//
+
+// vrfProposerSelectionEnabled returns true if the block at the given height carries a VRF proof,
+// and its proposer is selected with the randomness of its parent
+func vrfProposerSelectionEnabled(height uint64) bool {
+	return scom.IsForkActive(scom.ForkVRFProposerSelection, height)
+}
+
+// vrfInput returns the VRF input of a block proposed in the given epoch on top of a parent with the given seed
+func vrfInput(chainID string, parentSeed common.Hash, epoch uint64) []byte {
+	raw, _ := rlp.EncodeToBytes([]interface{}{chainID, parentSeed, epoch})
+	return raw
+}
+
+// blockSeed returns the randomness used to select the proposers of the children of the block. The
+// blocks before the fork carry no VRF proof, their hash is used instead.
+func blockSeed(header *score.BlockHeader) common.Hash {
+	output, err := vrf.Output(header.VRFProof)
+	if err != nil {
+		return header.Hash()
+	}
+	return output
+}
+
+// vrfSelectionPoint returns a uniformly distributed number in [0, max) derived from the seed and the epoch
+func vrfSelectionPoint(seed common.Hash, epoch uint64, max uint64) uint64 {
+	raw, _ := rlp.EncodeToBytes([]interface{}{seed, epoch})
+	r := new(big.Int).SetBytes(ethcrypto.Keccak256(raw))
+	return r.Mod(r, new(big.Int).SetUint64(max)).Uint64()
+}
@@ -13,6 +13,8 @@ import (
 	"github.com/thetatoken/theta/crypto"
 	"github.com/thetatoken/theta/rlp"
 	"github.com/thetatoken/theta/store/trie"
+
+	scom "github.com/thetatoken/thetasubchain/common"
 )
 
 const (
@@ -133,6 +135,7 @@ type BlockHeader struct {
 	Timestamp   *big.Int
 	Proposer    common.Address
 	Signature   *crypto.Signature
+	VRFProof    common.Bytes // proof of the proposer randomness, only present after the VRF proposer selection fork
 
 	hash common.Hash // Cache of calculated hash.
 }
@@ -144,7 +147,7 @@ func (h *BlockHeader) EncodeRLP(w io.Writer) error {
 	if h == nil {
 		return rlp.Encode(w, &BlockHeader{})
 	}
-	return rlp.Encode(w, []interface{}{
+	fields := []interface{}{
 		h.ChainID,
 		h.Epoch,
 		h.Height,
@@ -157,7 +160,12 @@ func (h *BlockHeader) EncodeRLP(w io.Writer) error {
 		h.Timestamp,
 		h.Proposer,
 		h.Signature,
-	})
+	}
+	// Only append the VRF proof when present, so that the hashes of the blocks before the fork are unchanged
+	if len(h.VRFProof) > 0 {
+		fields = append(fields, h.VRFProof)
+	}
+	return rlp.Encode(w, fields)
 }
 
 var _ rlp.Decoder = (*BlockHeader)(nil)
@@ -229,6 +237,12 @@ func (h *BlockHeader) DecodeRLP(stream *rlp.Stream) error {
 		return err
 	}
 
+	// The VRF proof is optional
+	err = stream.Decode(&h.VRFProof)
+	if err != nil && err != rlp.EOL {
+		return err
+	}
+
 	return stream.ListEnd()
 }
 
@@ -280,6 +294,30 @@ func (h *BlockHeader) SetSignature(sig *crypto.Signature) {
 	h.Signature = sig
 }
 
+// TimestampInMilliseconds returns the block timestamp in Unix milliseconds. Before the millisecond timestamp
+// fork, the timestamp is in Unix seconds.
+func (h *BlockHeader) TimestampInMilliseconds() *big.Int {
+	if h.Timestamp == nil {
+		return nil
+	}
+	if scom.MillisecondTimestampEnabled(h.Height) {
+		return new(big.Int).Set(h.Timestamp)
+	}
+	return new(big.Int).Mul(h.Timestamp, big.NewInt(1000))
+}
+
+// TimestampInSeconds returns the block timestamp in Unix seconds, as exposed to the EVM TIMESTAMP opcode and the
+// Ethereum tooling. With sub-second block intervals, consecutive blocks may share the same value in seconds.
+func (h *BlockHeader) TimestampInSeconds() *big.Int {
+	if h.Timestamp == nil {
+		return nil
+	}
+	if scom.MillisecondTimestampEnabled(h.Height) {
+		return new(big.Int).Div(h.Timestamp, big.NewInt(1000))
+	}
+	return new(big.Int).Set(h.Timestamp)
+}
+
 // Validate checks the header is legitimate.
 func (h *BlockHeader) Validate(chainID string) result.Result {
 	if chainID != h.ChainID {
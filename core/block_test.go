@@ -0,0 +1,49 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+)
+
+func TestBlockTimestampUnits(t *testing.T) {
+	assert := assert.New(t)
+
+	// the block timestamps are in milliseconds from height 1000 on
+	previous := viper.Get(scom.CfgSubchainForkMillisecondTimestampHeight)
+	viper.Set(scom.CfgSubchainForkMillisecondTimestampHeight, 1000)
+	scom.InitForkSchedule(nil)
+	defer func() {
+		viper.Set(scom.CfgSubchainForkMillisecondTimestampHeight, previous)
+		scom.InitForkSchedule(nil)
+	}()
+
+	tests := []struct {
+		name           string
+		height         uint64
+		timestamp      *big.Int
+		inMilliseconds *big.Int
+		inSeconds      *big.Int
+	}{
+		{"before the fork", 999, big.NewInt(1700000000), big.NewInt(1700000000000), big.NewInt(1700000000)},
+		{"at the fork", 1000, big.NewInt(1700000000500), big.NewInt(1700000000500), big.NewInt(1700000000)},
+		{"after the fork, within the same second", 1001, big.NewInt(1700000000999), big.NewInt(1700000000999), big.NewInt(1700000000)},
+		{"after the fork, next second", 1002, big.NewInt(1700000001000), big.NewInt(1700000001000), big.NewInt(1700000001)},
+		{"no timestamp", 1000, nil, nil, nil},
+	}
+	for _, test := range tests {
+		header := &BlockHeader{Height: test.height, Timestamp: test.timestamp}
+		assert.Equal(test.inMilliseconds, header.TimestampInMilliseconds(), test.name)
+		assert.Equal(test.inSeconds, header.TimestampInSeconds(), test.name)
+	}
+
+	// the conversions do not alter the timestamp of the header
+	header := &BlockHeader{Height: 999, Timestamp: big.NewInt(1700000000)}
+	header.TimestampInMilliseconds().SetInt64(0)
+	header.TimestampInSeconds().SetInt64(0)
+	assert.Equal(big.NewInt(1700000000), header.Timestamp)
+}
@@ -15,6 +15,14 @@ type ConsensusEngine interface {
 	AddMessage(msg interface{})
 	FinalizedBlocks() chan *Block
 	GetLastFinalizedBlock() *ExtendedBlock
+	GetEvidencePool() EvidencePool
+	GetSigner() Signer
+}
+
+// EvidencePool collects the proofs of validator misbehavior to be included in blocks.
+type EvidencePool interface {
+	GetPendingEvidence() []*EquivocationEvidence
+	RemoveEvidence(ids []common.Hash)
 }
 
 // ValidatorManager is the component for managing validator related logic for consensus engine.
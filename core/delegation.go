@@ -0,0 +1,78 @@
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+)
+
+// MaxCommissionRateInBasisPoints is the commission rate with which a validator keeps all the rewards.
+const MaxCommissionRateInBasisPoints uint64 = 10000
+
+// DefaultCommissionRateInBasisPoints is the commission rate of the validators who have not set one.
+const DefaultCommissionRateInBasisPoints uint64 = 1000
+
+// StakeEventType is the type of the stake changes on the mainchain.
+type StakeEventType byte
+
+const (
+	StakeEventTypeDeposit StakeEventType = iota
+	StakeEventTypeWithdrawal
+)
+
+func (t StakeEventType) String() string {
+	switch t {
+	case StakeEventTypeDeposit:
+		return "DepositStake"
+	case StakeEventTypeWithdrawal:
+		return "WithdrawStake"
+	default:
+		return fmt.Sprintf("StakeEventType(%d)", byte(t))
+	}
+}
+
+// StakeEvent records a change of the shares a staker delegates to a validator of the subchain, emitted by
+// the DepositStake and the WithdrawStake calls on the mainchain.
+type StakeEvent struct {
+	Type            StakeEventType
+	Validator       common.Address
+	Staker          common.Address
+	ShareAmount     *big.Int
+	MainchainHeight *big.Int
+}
+
+func (e StakeEvent) String() string {
+	return fmt.Sprintf("StakeEvent{Type: %v, Validator: %v, Staker: %v, ShareAmount: %v, MainchainHeight: %v}",
+		e.Type, e.Validator.Hex(), e.Staker.Hex(), e.ShareAmount, e.MainchainHeight)
+}
+
+// Delegation is the shares a staker delegates to a validator.
+type Delegation struct {
+	Staker common.Address
+	Shares *big.Int
+}
+
+// SplitByCommission splits the reward of a validator into the commission the validator keeps and the
+// shares of its delegators, in proportion to the delegated shares. The rounding remainder is added to the
+// commission. The validator keeps the whole reward if it has no delegators.
+func SplitByCommission(amount *big.Int, commissionRateInBasisPoints uint64, delegations []Delegation) (commission *big.Int, delegatorRewards []*big.Int) {
+	if len(delegations) == 0 {
+		return new(big.Int).Set(amount), []*big.Int{}
+	}
+
+	commission = new(big.Int).Mul(amount, new(big.Int).SetUint64(commissionRateInBasisPoints))
+	commission.Div(commission, new(big.Int).SetUint64(MaxCommissionRateInBasisPoints))
+	remainder := new(big.Int).Sub(amount, commission)
+
+	shares := make([]*big.Int, len(delegations))
+	for i, delegation := range delegations {
+		shares[i] = delegation.Shares
+	}
+	delegatorRewards = SplitByWeight(remainder, shares)
+	for _, reward := range delegatorRewards {
+		remainder.Sub(remainder, reward)
+	}
+	commission.Add(commission, remainder)
+	return commission, delegatorRewards
+}
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/rlp"
+)
+
+// EquivocationType is the type of a validator misbehavior
+type EquivocationType byte
+
+const (
+	EquivocationTypeVote     EquivocationType = 1 // voted for two different blocks at the same height in the same epoch
+	EquivocationTypeProposal EquivocationType = 2 // proposed two different blocks in the same epoch
+)
+
+func (t EquivocationType) String() string {
+	switch t {
+	case EquivocationTypeVote:
+		return "vote"
+	case EquivocationTypeProposal:
+		return "proposal"
+	default:
+		return fmt.Sprintf("unknown(%d)", byte(t))
+	}
+}
+
+// EquivocationEvidence is a self-contained proof that a validator signed two conflicting messages.
+// For a vote equivocation, Votes holds the two signed votes and Headers holds the headers of the
+// voted blocks, which bind the heights (not covered by the vote signatures) to the voted block hashes.
+// For a proposal equivocation, Headers holds the two block headers signed by the proposer.
+type EquivocationEvidence struct {
+	Type     EquivocationType
+	Offender common.Address
+	Epoch    uint64
+	Height   uint64
+	Votes    []Vote
+	Headers  []*BlockHeader
+}
+
+// NewVoteEquivocationEvidence creates the evidence for two conflicting votes. The headers are those of the voted blocks.
+func NewVoteEquivocationEvidence(voteA Vote, headerA *BlockHeader, voteB Vote, headerB *BlockHeader) *EquivocationEvidence {
+	// Order the conflicting messages by block hash so that the evidence of an offence is deterministic
+	if bytes.Compare(voteA.Block[:], voteB.Block[:]) > 0 {
+		voteA, voteB = voteB, voteA
+		headerA, headerB = headerB, headerA
+	}
+	return &EquivocationEvidence{
+		Type:     EquivocationTypeVote,
+		Offender: voteA.ID,
+		Epoch:    voteA.Epoch,
+		Height:   headerA.Height,
+		Votes:    []Vote{voteA, voteB},
+		Headers:  []*BlockHeader{headerA, headerB},
+	}
+}
+
+// NewProposalEquivocationEvidence creates the evidence for two conflicting block proposals
+func NewProposalEquivocationEvidence(headerA *BlockHeader, headerB *BlockHeader) *EquivocationEvidence {
+	hashA, hashB := headerA.Hash(), headerB.Hash()
+	if bytes.Compare(hashA[:], hashB[:]) > 0 {
+		headerA, headerB = headerB, headerA
+	}
+	return &EquivocationEvidence{
+		Type:     EquivocationTypeProposal,
+		Offender: headerA.Proposer,
+		Epoch:    headerA.Epoch,
+		Height:   headerA.Height,
+		Votes:    []Vote{},
+		Headers:  []*BlockHeader{headerA, headerB},
+	}
+}
+
+// ID identifies the offence. Different evidences of the same offence share the same ID, so that
+// a validator is punished at most once per offence.
+func (ev *EquivocationEvidence) ID() common.Hash {
+	raw, _ := rlp.EncodeToBytes([]interface{}{ev.Type, ev.Offender, ev.Epoch})
+	return crypto.Keccak256Hash(raw)
+}
+
+// Validate checks that the evidence proves the offender signed two conflicting messages
+func (ev *EquivocationEvidence) Validate(chainID string) result.Result {
+	if ev.Offender.IsEmpty() {
+		return result.Error("Offender is not specified")
+	}
+	if len(ev.Headers) != 2 || ev.Headers[0] == nil || ev.Headers[1] == nil {
+		return result.Error("Evidence needs exactly two block headers")
+	}
+	for _, header := range ev.Headers {
+		if header.ChainID != chainID {
+			return result.Error("ChainID mismatch")
+		}
+		if header.Height != ev.Height {
+			return result.Error("Block height mismatch: %v vs %v", header.Height, ev.Height)
+		}
+	}
+
+	switch ev.Type {
+	case EquivocationTypeVote:
+		if len(ev.Votes) != 2 {
+			return result.Error("Vote equivocation evidence needs exactly two votes")
+		}
+		for i, vote := range ev.Votes {
+			if res := vote.Validate(); res.IsError() {
+				return res
+			}
+			if vote.ID != ev.Offender {
+				return result.Error("Vote is not signed by the offender")
+			}
+			if vote.Epoch != ev.Epoch {
+				return result.Error("Vote epoch mismatch: %v vs %v", vote.Epoch, ev.Epoch)
+			}
+			if vote.Block != ev.Headers[i].Hash() {
+				return result.Error("Voted block does not match the block header")
+			}
+		}
+		if ev.Votes[0].Block == ev.Votes[1].Block {
+			return result.Error("Votes are not conflicting")
+		}
+	case EquivocationTypeProposal:
+		for _, header := range ev.Headers {
+			if res := header.Validate(chainID); res.IsError() {
+				return res
+			}
+			if header.Proposer != ev.Offender {
+				return result.Error("Block is not proposed by the offender")
+			}
+			if header.Epoch != ev.Epoch {
+				return result.Error("Block epoch mismatch: %v vs %v", header.Epoch, ev.Epoch)
+			}
+		}
+		// Compare the signed content rather than the hashes, which also cover the signatures
+		if bytes.Equal(ev.Headers[0].SignBytes(), ev.Headers[1].SignBytes()) {
+			return result.Error("Proposals are not conflicting")
+		}
+	default:
+		return result.Error("Unknown equivocation type: %v", ev.Type)
+	}
+
+	return result.OK
+}
+
+func (ev *EquivocationEvidence) String() string {
+	blocks := []string{}
+	for _, header := range ev.Headers {
+		blocks = append(blocks, header.Hash().Hex())
+	}
+	return fmt.Sprintf("EquivocationEvidence{type: %v, offender: %v, epoch: %v, height: %v, blocks: %v}",
+		ev.Type, ev.Offender.Hex(), ev.Epoch, ev.Height, blocks)
+}
+
+// EquivocationRecord records an equivocation evidence included in a block. The records are
+// indexed in the order of inclusion, so that they can be relayed to the mainchain in order.
+type EquivocationRecord struct {
+	Index       uint64
+	BlockHeight uint64 // height of the block that included the evidence
+	Evidence    EquivocationEvidence
+}
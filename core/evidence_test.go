@@ -0,0 +1,167 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+)
+
+const testEvidenceChainID = "tsub360777"
+
+// newSignedHeader creates a block header at the given height and epoch, signed by the proposer
+func newSignedHeader(t *testing.T, proposer testVoter, chainID string, epoch uint64, height uint64, timestamp int64) *BlockHeader {
+	header := &BlockHeader{
+		ChainID:   chainID,
+		Epoch:     epoch,
+		Height:    height,
+		Parent:    common.HexToHash("0x01"),
+		HCC:       CommitCertificate{BlockHash: common.HexToHash("0x01")},
+		Timestamp: big.NewInt(timestamp),
+		Proposer:  proposer.address(),
+	}
+	sig, err := proposer.privKey.Sign(header.SignBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	header.SetSignature(sig)
+	return header
+}
+
+// signedVote creates a vote of the voter ID on the block, signed with the given key
+func signedVote(t *testing.T, voterID common.Address, signer *crypto.PrivateKey, block common.Hash, epoch uint64) Vote {
+	vote := Vote{Block: block, Height: 10, Epoch: epoch, ID: voterID}
+	sig, err := signer.Sign(vote.SignBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	vote.SetSignature(sig)
+	return vote
+}
+
+func TestEquivocationEvidenceValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	voters, _ := newTestVoters(t, 2)
+	offender, other := voters[0], voters[1]
+	offenderID := offender.address()
+
+	// Two blocks at height 10 proposed by another validator, and a block at the next height
+	headerA := newSignedHeader(t, other, testEvidenceChainID, 5, 10, 1000)
+	headerB := newSignedHeader(t, other, testEvidenceChainID, 5, 10, 1001)
+	headerNext := newSignedHeader(t, other, testEvidenceChainID, 5, 11, 1002)
+	voteA := signedVote(t, offenderID, offender.privKey, headerA.Hash(), 5)
+	voteB := signedVote(t, offenderID, offender.privKey, headerB.Hash(), 5)
+
+	// Two blocks proposed by the offender in the same epoch
+	proposalA := newSignedHeader(t, offender, testEvidenceChainID, 5, 10, 1000)
+	proposalB := newSignedHeader(t, offender, testEvidenceChainID, 5, 10, 1001)
+
+	voteEvidence := func(voteA Vote, headerA *BlockHeader, voteB Vote, headerB *BlockHeader) *EquivocationEvidence {
+		return &EquivocationEvidence{
+			Type:     EquivocationTypeVote,
+			Offender: offenderID,
+			Epoch:    5,
+			Height:   10,
+			Votes:    []Vote{voteA, voteB},
+			Headers:  []*BlockHeader{headerA, headerB},
+		}
+	}
+	proposalEvidence := func(headerA *BlockHeader, headerB *BlockHeader) *EquivocationEvidence {
+		return &EquivocationEvidence{
+			Type:     EquivocationTypeProposal,
+			Offender: offenderID,
+			Epoch:    5,
+			Height:   10,
+			Votes:    []Vote{},
+			Headers:  []*BlockHeader{headerA, headerB},
+		}
+	}
+	unsignedVote := voteB
+	unsignedVote.Signature = nil
+	otherEpochVote := signedVote(t, offenderID, offender.privKey, headerB.Hash(), 6)
+	wrongChainHeader := newSignedHeader(t, other, "tsub360888", 5, 10, 1001)
+	wrongChainVote := signedVote(t, offenderID, offender.privKey, wrongChainHeader.Hash(), 5)
+	nextHeightVote := signedVote(t, offenderID, offender.privKey, headerNext.Hash(), 5)
+	noOffender := voteEvidence(voteA, headerA, voteB, headerB)
+	noOffender.Offender = common.Address{}
+	unknownType := voteEvidence(voteA, headerA, voteB, headerB)
+	unknownType.Type = EquivocationType(3)
+	singleVote := voteEvidence(voteA, headerA, voteB, headerB)
+	singleVote.Votes = []Vote{voteA}
+
+	// A block signed by another validator, but claimed to be proposed by the offender
+	forgedProposal := newSignedHeader(t, other, testEvidenceChainID, 5, 10, 1001)
+	forgedProposal.Proposer = offenderID
+	forgedProposal.UpdateHash()
+
+	tests := []struct {
+		name     string
+		evidence *EquivocationEvidence
+		valid    bool
+	}{
+		{"conflicting votes", NewVoteEquivocationEvidence(voteA, headerA, voteB, headerB), true},
+		{"forged vote signature", voteEvidence(voteA, headerA, signedVote(t, offenderID, other.privKey, headerB.Hash(), 5), headerB), false},
+		{"unsigned vote", voteEvidence(voteA, headerA, unsignedVote, headerB), false},
+		{"vote of another validator", voteEvidence(voteA, headerA, signedVote(t, other.address(), other.privKey, headerB.Hash(), 5), headerB), false},
+		{"vote not matching the header", voteEvidence(voteA, headerA, voteB, headerA), false},
+		{"same vote twice", voteEvidence(voteA, headerA, voteA, headerA), false},
+		{"votes in different epochs", voteEvidence(voteA, headerA, otherEpochVote, headerB), false},
+		{"votes at different heights", voteEvidence(voteA, headerA, nextHeightVote, headerNext), false},
+		{"block of another chain", voteEvidence(voteA, headerA, wrongChainVote, wrongChainHeader), false},
+		{"single vote", singleVote, false},
+		{"missing header", voteEvidence(voteA, headerA, voteB, nil), false},
+		{"no offender", noOffender, false},
+		{"unknown type", unknownType, false},
+		{"conflicting proposals", NewProposalEquivocationEvidence(proposalA, proposalB), true},
+		{"forged proposal signature", proposalEvidence(proposalA, forgedProposal), false},
+		{"proposals of another validator", proposalEvidence(headerA, headerB), false},
+		{"same proposal twice", proposalEvidence(proposalA, proposalA), false},
+		{"proposals in different epochs", proposalEvidence(proposalA, newSignedHeader(t, offender, testEvidenceChainID, 6, 10, 1001)), false},
+	}
+	for _, test := range tests {
+		res := test.evidence.Validate(testEvidenceChainID)
+		assert.Equal(test.valid, res.IsOK(), "%v: %v", test.name, res.Message)
+	}
+}
+
+func TestEquivocationEvidenceID(t *testing.T) {
+	assert := assert.New(t)
+
+	voters, _ := newTestVoters(t, 2)
+	offender, other := voters[0], voters[1]
+	offenderID := offender.address()
+	headers := []*BlockHeader{}
+	for i := int64(0); i < 3; i++ {
+		headers = append(headers, newSignedHeader(t, other, testEvidenceChainID, 5, 10, 1000+i))
+	}
+	votes := []Vote{}
+	for _, header := range headers {
+		votes = append(votes, signedVote(t, offenderID, offender.privKey, header.Hash(), 5))
+	}
+
+	// The evidence does not depend on the order the conflicting votes are observed in
+	evA := NewVoteEquivocationEvidence(votes[0], headers[0], votes[1], headers[1])
+	evB := NewVoteEquivocationEvidence(votes[1], headers[1], votes[0], headers[0])
+	assert.Equal(evA.ID(), evB.ID())
+	assert.Equal(evA.Votes[0].Block, evB.Votes[0].Block)
+	assert.Equal(evA.Headers[0].Hash(), evB.Headers[0].Hash())
+
+	// Another conflicting vote in the same epoch is the same offence
+	evC := NewVoteEquivocationEvidence(votes[0], headers[0], votes[2], headers[2])
+	assert.Equal(evA.ID(), evC.ID())
+
+	// A proposal equivocation in the same epoch is another offence
+	proposalA := newSignedHeader(t, offender, testEvidenceChainID, 5, 10, 1000)
+	proposalB := newSignedHeader(t, offender, testEvidenceChainID, 5, 10, 1001)
+	assert.NotEqual(evA.ID(), NewProposalEquivocationEvidence(proposalA, proposalB).ID())
+
+	// So is an equivocation in another epoch
+	headerD := newSignedHeader(t, other, testEvidenceChainID, 6, 10, 1003)
+	headerE := newSignedHeader(t, other, testEvidenceChainID, 6, 10, 1004)
+	evD := NewVoteEquivocationEvidence(signedVote(t, offenderID, offender.privKey, headerD.Hash(), 6), headerD,
+		signedVote(t, offenderID, offender.privKey, headerE.Hash(), 6), headerE)
+	assert.NotEqual(evA.ID(), evD.ID())
+}
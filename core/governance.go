@@ -0,0 +1,228 @@
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/thetatoken/theta/common"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+)
+
+// The runtime parameters of the subchain which the validators can change through governance proposals
+const (
+	// GovParamMinimumGasPrice is the minimum gas price in TFuelWei of the smart contract transactions
+	GovParamMinimumGasPrice = "minimum_gas_price"
+
+	// GovParamMinBlockIntervalInMilliseconds is the minimal interval between the blocks
+	GovParamMinBlockIntervalInMilliseconds = "min_block_interval_in_milliseconds"
+
+	// GovParamMaxNumRegularTxsPerBlock is the max number of regular transactions a proposer includes in a block
+	GovParamMaxNumRegularTxsPerBlock = "max_num_regular_txs_per_block"
+
+	// GovParamCrossChainFeeSetter is the address allowed to update the cross-chain fee of the chain registrar
+	GovParamCrossChainFeeSetter = "cross_chain_fee_setter"
+
+	// GovParamBlockGasLimit is the maximum total gas the smart contract transactions of a block can consume
+	GovParamBlockGasLimit = "block_gas_limit"
+
+	// GovParamDowntimeSlash is not a runtime parameter. Its proposals, valued "<dynasty>:<validator>", ask the
+	// validators to agree that the validator was down in the dynasty. The collateral of the validator is only
+	// slashed once such a proposal is approved, and the activation height ends the voting.
+	GovParamDowntimeSlash = "downtime_slash"
+
+	// GovParamNativeStakeRelayer is the address designated to relay the native stake records to the chain registrar
+	// on the mainchain. The stakes relayed are held by that address on the mainchain, so no other node relays them.
+	GovParamNativeStakeRelayer = "native_stake_relayer"
+
+	// GovParamNativeStakeRejection is not a runtime parameter either. Its proposals, valued with the index of a native
+	// stake record, ask the validators to agree that the mainchain rejects the deposit of the record. The stake is
+	// returned to the staker once such a proposal is approved, and the relayer skips the record.
+	GovParamNativeStakeRejection = "native_stake_rejection"
+)
+
+const (
+	// MinGovernanceActivationDelayInBlocks is the minimal number of blocks between the submission of a proposal
+	// and its activation, which leaves the validators time to vote
+	MinGovernanceActivationDelayInBlocks uint64 = 100
+
+	// MaxGovernedMinBlockIntervalInMilliseconds bounds the block interval the proposals can set, so that it
+	// stays well below the max epoch length
+	MaxGovernedMinBlockIntervalInMilliseconds uint64 = 10000
+
+	// MaxGovernedNumRegularTxsPerBlock bounds the number of regular transactions per block the proposals can set
+	MaxGovernedNumRegularTxsPerBlock uint64 = 10000
+
+	// MinGovernedBlockGasLimit and MaxGovernedBlockGasLimit bound the block gas limit the proposals can set. The
+	// lower bound leaves room for the contract calls made by the ledger itself.
+	MinGovernedBlockGasLimit uint64 = 1000000
+	MaxGovernedBlockGasLimit uint64 = 1000000000
+)
+
+// GovernanceProposal proposes to change a runtime parameter starting from the activation height. The proposal
+// is approved once the validators who voted for it hold more than 2/3 of the stake of the current validator
+// set. Votes are no longer accepted from the activation height on.
+type GovernanceProposal struct {
+	ID               uint64
+	Proposer         common.Address
+	Param            string
+	Value            string
+	ActivationHeight uint64
+	Voters           []common.Address // validator addresses, the proposer included
+	Approved         bool
+	ApprovalHeight   uint64
+}
+
+// HasVoted returns whether the validator has voted for the proposal.
+func (p *GovernanceProposal) HasVoted(validator common.Address) bool {
+	for _, voter := range p.Voters {
+		if voter == validator {
+			return true
+		}
+	}
+	return false
+}
+
+// HasMajority returns whether the voters hold more than 2/3 of the stake of the validator set. The votes are
+// cast with the validator addresses, which differ from the keys in the validator set after key rotations.
+func (p *GovernanceProposal) HasMajority(vs *ValidatorSet) bool {
+	keys := []common.Address{}
+	for _, v := range vs.Validators() {
+		if p.HasVoted(vs.ValidatorIdentity(v.ID())) {
+			keys = append(keys, v.ID())
+		}
+	}
+	return vs.HasMajorityVoters(keys)
+}
+
+// String represents the string representation of the proposal
+func (p *GovernanceProposal) String() string {
+	return fmt.Sprintf("{ID: %v, Proposer: %v, Param: %v, Value: %v, ActivationHeight: %v, Voters: %v, Approved: %v}",
+		p.ID, p.Proposer.Hex(), p.Param, p.Value, p.ActivationHeight, len(p.Voters), p.Approved)
+}
+
+// GovernanceParamValue is the value of a runtime parameter starting from the activation height.
+type GovernanceParamValue struct {
+	ActivationHeight uint64
+	Value            string
+}
+
+// ValidateGovernanceParam checks the proposed value of a runtime parameter taking effect at the given height.
+func ValidateGovernanceParam(param string, value string, activationHeight uint64) error {
+	switch param {
+	case GovParamMinimumGasPrice:
+		gasPrice, ok := new(big.Int).SetString(value, 10)
+		if !ok || gasPrice.Sign() <= 0 {
+			return fmt.Errorf("invalid minimum gas price: %v", value)
+		}
+	case GovParamMinBlockIntervalInMilliseconds:
+		interval, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || interval == 0 || interval > MaxGovernedMinBlockIntervalInMilliseconds {
+			return fmt.Errorf("invalid min block interval, expected 1 to %v milliseconds: %v",
+				MaxGovernedMinBlockIntervalInMilliseconds, value)
+		}
+		if !scom.MillisecondTimestampEnabled(activationHeight) && interval%1000 != 0 {
+			return fmt.Errorf("sub-second block intervals require the millisecond timestamp fork: %v", value)
+		}
+	case GovParamMaxNumRegularTxsPerBlock:
+		numTxs, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || numTxs == 0 || numTxs > MaxGovernedNumRegularTxsPerBlock {
+			return fmt.Errorf("invalid max number of regular transactions per block, expected 1 to %v: %v",
+				MaxGovernedNumRegularTxsPerBlock, value)
+		}
+	case GovParamBlockGasLimit:
+		limit, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || limit < MinGovernedBlockGasLimit || limit > MaxGovernedBlockGasLimit {
+			return fmt.Errorf("invalid block gas limit, expected %v to %v: %v",
+				MinGovernedBlockGasLimit, MaxGovernedBlockGasLimit, value)
+		}
+	case GovParamCrossChainFeeSetter:
+		if !common.IsHexAddress(value) || common.HexToAddress(value) == (common.Address{}) {
+			return fmt.Errorf("invalid fee setter address: %v", value)
+		}
+	case GovParamDowntimeSlash:
+		if _, _, err := ParseDowntimeSlashValue(value); err != nil {
+			return err
+		}
+	case GovParamNativeStakeRelayer:
+		if !scom.IsForkActive(scom.ForkNativeStaking, activationHeight) {
+			return fmt.Errorf("the native stake relayer requires the native staking fork")
+		}
+		if !common.IsHexAddress(value) || common.HexToAddress(value) == (common.Address{}) {
+			return fmt.Errorf("invalid native stake relayer address: %v", value)
+		}
+	case GovParamNativeStakeRejection:
+		if !scom.IsForkActive(scom.ForkNativeStaking, activationHeight) {
+			return fmt.Errorf("the native stake rejection requires the native staking fork")
+		}
+		if _, err := ParseNativeStakeRejectionValue(value); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown governance parameter: %v, supported: %v", param, strings.Join(GovernanceParams(), ", "))
+	}
+	return nil
+}
+
+// GovernanceParams returns the runtime parameters which can be changed through governance proposals.
+func GovernanceParams() []string {
+	return []string{
+		GovParamMinimumGasPrice,
+		GovParamMinBlockIntervalInMilliseconds,
+		GovParamMaxNumRegularTxsPerBlock,
+		GovParamCrossChainFeeSetter,
+		GovParamBlockGasLimit,
+		GovParamNativeStakeRelayer,
+	}
+}
+
+// IsDecisionParam returns whether the proposals of the parameter decide on a single case instead of setting a
+// runtime parameter. The votes on a case go to a single proposal, and the approved ones are read from the proposals.
+func IsDecisionParam(param string) bool {
+	return param == GovParamDowntimeSlash || param == GovParamNativeStakeRejection
+}
+
+// DowntimeSlashValue returns the value of the downtime slash proposal for the validator and the dynasty.
+func DowntimeSlashValue(dynasty *big.Int, validator common.Address) string {
+	return dynasty.String() + ":" + validator.Hex()
+}
+
+// ParseDowntimeSlashValue parses the value of a downtime slash proposal into the dynasty and the validator.
+func ParseDowntimeSlashValue(value string) (*big.Int, common.Address, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return nil, common.Address{}, fmt.Errorf("invalid downtime slash, expected <dynasty>:<validator>: %v", value)
+	}
+	dynasty, ok := new(big.Int).SetString(parts[0], 10)
+	if !ok || dynasty.Sign() < 0 {
+		return nil, common.Address{}, fmt.Errorf("invalid dynasty of the downtime slash: %v", value)
+	}
+	if !common.IsHexAddress(parts[1]) || common.HexToAddress(parts[1]) == (common.Address{}) {
+		return nil, common.Address{}, fmt.Errorf("invalid validator of the downtime slash: %v", value)
+	}
+	validator := common.HexToAddress(parts[1])
+	if DowntimeSlashValue(dynasty, validator) != value {
+		return nil, common.Address{}, fmt.Errorf("downtime slash not in canonical form %v: %v",
+			DowntimeSlashValue(dynasty, validator), value)
+	}
+	return dynasty, validator, nil
+}
+
+// NativeStakeRejectionValue returns the value of the native stake rejection proposal for the record index.
+func NativeStakeRejectionValue(recordIndex uint64) string {
+	return strconv.FormatUint(recordIndex, 10)
+}
+
+// ParseNativeStakeRejectionValue parses the value of a native stake rejection proposal into the record index.
+func ParseNativeStakeRejectionValue(value string) (uint64, error) {
+	recordIndex, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid native stake rejection, expected <record index>: %v", value)
+	}
+	if NativeStakeRejectionValue(recordIndex) != value {
+		return 0, fmt.Errorf("native stake rejection not in canonical form %v: %v", NativeStakeRejectionValue(recordIndex), value)
+	}
+	return recordIndex, nil
+}
@@ -0,0 +1,128 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+)
+
+func TestValidateGovernanceParam(t *testing.T) {
+	assert := assert.New(t)
+
+	// sub-second block intervals take effect from height 2000 on, and the native staking from height 1500 on
+	previous := viper.Get(scom.CfgSubchainForkMillisecondTimestampHeight)
+	previousNativeStaking := viper.Get(scom.CfgSubchainForkNativeStakingHeight)
+	viper.Set(scom.CfgSubchainForkMillisecondTimestampHeight, 2000)
+	viper.Set(scom.CfgSubchainForkNativeStakingHeight, 1500)
+	scom.InitForkSchedule(nil)
+	defer func() {
+		viper.Set(scom.CfgSubchainForkMillisecondTimestampHeight, previous)
+		viper.Set(scom.CfgSubchainForkNativeStakingHeight, previousNativeStaking)
+		scom.InitForkSchedule(nil)
+	}()
+
+	feeSetter := common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+
+	tests := []struct {
+		param            string
+		value            string
+		activationHeight uint64
+		valid            bool
+	}{
+		{GovParamMinimumGasPrice, "4000000000000", 1000, true},
+		{GovParamMinimumGasPrice, "1", 1000, true},
+		{GovParamMinimumGasPrice, "0", 1000, false},
+		{GovParamMinimumGasPrice, "-1", 1000, false},
+		{GovParamMinimumGasPrice, "0x10", 1000, false},
+		{GovParamMinimumGasPrice, "", 1000, false},
+
+		{GovParamMinBlockIntervalInMilliseconds, "1000", 1000, true},
+		{GovParamMinBlockIntervalInMilliseconds, "10000", 1000, true},
+		{GovParamMinBlockIntervalInMilliseconds, "10001", 1000, false},
+		{GovParamMinBlockIntervalInMilliseconds, "0", 1000, false},
+		{GovParamMinBlockIntervalInMilliseconds, "500", 1999, false},
+		{GovParamMinBlockIntervalInMilliseconds, "500", 2000, true},
+		{GovParamMinBlockIntervalInMilliseconds, "1.5", 2000, false},
+
+		{GovParamMaxNumRegularTxsPerBlock, "1", 1000, true},
+		{GovParamMaxNumRegularTxsPerBlock, "10000", 1000, true},
+		{GovParamMaxNumRegularTxsPerBlock, "10001", 1000, false},
+		{GovParamMaxNumRegularTxsPerBlock, "0", 1000, false},
+		{GovParamMaxNumRegularTxsPerBlock, "-1", 1000, false},
+
+		{GovParamBlockGasLimit, "30000000", 1000, true},
+		{GovParamBlockGasLimit, "1000000", 1000, true},
+		{GovParamBlockGasLimit, "999999", 1000, false},
+		{GovParamBlockGasLimit, "1000000000", 1000, true},
+		{GovParamBlockGasLimit, "1000000001", 1000, false},
+		{GovParamBlockGasLimit, "0", 1000, false},
+		{GovParamBlockGasLimit, "-30000000", 1000, false},
+		{GovParamBlockGasLimit, "18446744073709551616", 1000, false},
+		{GovParamBlockGasLimit, "0x1c9c380", 1000, false},
+		{GovParamBlockGasLimit, "", 1000, false},
+
+		{GovParamCrossChainFeeSetter, feeSetter.Hex(), 1000, true},
+		{GovParamCrossChainFeeSetter, common.Address{}.Hex(), 1000, false},
+		{GovParamCrossChainFeeSetter, "0x2E83", 1000, false},
+
+		{GovParamDowntimeSlash, DowntimeSlashValue(big.NewInt(5), feeSetter), 1000, true},
+		{GovParamDowntimeSlash, "05:" + feeSetter.Hex(), 1000, false},
+		{GovParamDowntimeSlash, "-1:" + feeSetter.Hex(), 1000, false},
+		{GovParamDowntimeSlash, "5:" + common.Address{}.Hex(), 1000, false},
+		{GovParamDowntimeSlash, "5", 1000, false},
+
+		{GovParamNativeStakeRelayer, feeSetter.Hex(), 1500, true},
+		{GovParamNativeStakeRelayer, feeSetter.Hex(), 1499, false},
+		{GovParamNativeStakeRelayer, common.Address{}.Hex(), 1500, false},
+		{GovParamNativeStakeRelayer, "0x2E83", 1500, false},
+
+		{GovParamNativeStakeRejection, NativeStakeRejectionValue(0), 1500, true},
+		{GovParamNativeStakeRejection, NativeStakeRejectionValue(42), 1500, true},
+		{GovParamNativeStakeRejection, NativeStakeRejectionValue(42), 1499, false},
+		{GovParamNativeStakeRejection, "042", 1500, false},
+		{GovParamNativeStakeRejection, "-1", 1500, false},
+		{GovParamNativeStakeRejection, "", 1500, false},
+
+		{GovParamUpgradeVersion, "1.2.0", 1000, false}, // proposed through the upgrade plan transactions
+		{"unknown_param", "1", 1000, false},
+	}
+	for _, test := range tests {
+		err := ValidateGovernanceParam(test.param, test.value, test.activationHeight)
+		assert.Equal(test.valid, err == nil, "%v=%v at height %v: %v", test.param, test.value, test.activationHeight, err)
+	}
+}
+
+func TestGovernanceProposalHasMajority(t *testing.T) {
+	assert := assert.New(t)
+
+	a := common.HexToAddress("0x0a")
+	b := common.HexToAddress("0x0b")
+	c := common.HexToAddress("0x0c")
+	rotated := common.HexToAddress("0x1c")
+	vs := NewValidatorSet(big.NewInt(5))
+	vs.AddValidator(NewValidator(a.Hex(), big.NewInt(100)))
+	vs.AddValidator(NewValidator(b.Hex(), big.NewInt(100)))
+	vs.AddValidator(NewValidator(c.Hex(), big.NewInt(50)))
+
+	tests := []struct {
+		name     string
+		voters   []common.Address
+		majority bool
+	}{
+		{"no voter", []common.Address{}, false},
+		{"one third of the stake", []common.Address{a}, false},
+		{"less than two thirds of the stake", []common.Address{a, c}, false},
+		{"more than two thirds of the stake", []common.Address{a, b}, true},
+		{"all validators", []common.Address{a, b, c}, true},
+		{"outsiders do not count", []common.Address{a, rotated}, false},
+	}
+	for _, test := range tests {
+		proposal := &GovernanceProposal{Voters: test.voters}
+		assert.Equal(test.majority, proposal.HasMajority(vs), test.name)
+	}
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+)
+
+// KeyRotation replaces the key a validator signs its blocks and votes with, starting from the effective
+// dynasty. The validator address remains the identity the stake, the delegations and the rewards are
+// bound to. It is registered on the mainchain by the validator, with a signature of the new key which
+// proves the validator holds it.
+type KeyRotation struct {
+	Validator        common.Address
+	NewKey           common.Address
+	EffectiveDynasty *big.Int
+	Signature        *crypto.Signature
+	MainchainHeight  *big.Int
+}
+
+// KeyRotationSignBytes returns the message the new key signs, which is abi.encodePacked(subchainID,
+// validator, newKey, effectiveDynasty), so that the mainchain contracts can verify it as well. It can be signed
+// with the subchain_sign_hex_msg tool.
+func KeyRotationSignBytes(subchainID *big.Int, validator common.Address, newKey common.Address, effectiveDynasty *big.Int) common.Bytes {
+	signBytes := common.LeftPadBytes(subchainID.Bytes(), 32)
+	signBytes = append(signBytes, validator.Bytes()...)
+	signBytes = append(signBytes, newKey.Bytes()...)
+	signBytes = append(signBytes, common.LeftPadBytes(effectiveDynasty.Bytes(), 32)...)
+	return signBytes
+}
+
+// Verify checks that the rotation is signed by the new key
+func (r *KeyRotation) Verify(subchainID *big.Int) error {
+	if r.NewKey == (common.Address{}) || r.NewKey == r.Validator {
+		return fmt.Errorf("invalid new key %v for validator %v", r.NewKey.Hex(), r.Validator.Hex())
+	}
+	if r.EffectiveDynasty == nil || r.Signature == nil {
+		return fmt.Errorf("incomplete key rotation of validator %v", r.Validator.Hex())
+	}
+	signBytes := KeyRotationSignBytes(subchainID, r.Validator, r.NewKey, r.EffectiveDynasty)
+	if !r.Signature.Verify(signBytes, r.NewKey) {
+		return fmt.Errorf("invalid signature of the new key %v", r.NewKey.Hex())
+	}
+	return nil
+}
+
+func (r KeyRotation) String() string {
+	return fmt.Sprintf("KeyRotation{Validator: %v, NewKey: %v, EffectiveDynasty: %v, MainchainHeight: %v}",
+		r.Validator.Hex(), r.NewKey.Hex(), r.EffectiveDynasty, r.MainchainHeight)
+}
+
+// SigningKey is a key a validator signs with, starting from the effective dynasty.
+type SigningKey struct {
+	Key              common.Address
+	EffectiveDynasty *big.Int
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/common/result"
@@ -43,4 +44,18 @@ type Ledger interface {
 	GetTokenBankContractAddress(tokenType CrossChainTokenType) *common.Address
 	GetSubchainRegisterContractAddress() *common.Address
 	GetTxInfo(rawTx common.Bytes) (*TxInfo, result.Result)
+	GetFinalizedEquivocationRecords(startIndex uint64, maxCount int) ([]*EquivocationRecord, error)
+	GetFinalizedNativeStakeRecords(startIndex uint64, maxCount int) ([]*NativeStakeRecord, error)
+	GetFinalizedDelegatedShares(validator common.Address, staker common.Address) (*big.Int, error)
+	GetFinalizedGovernanceProposals(startID uint64, maxCount int) ([]*GovernanceProposal, uint64, error)
+	GetFinalizedDowntimeSlashProposal(value string) (*GovernanceProposal, error)
+	GetFinalizedNativeStakeRejectionProposal(recordIndex uint64) (*GovernanceProposal, uint64, error)
+	GetFinalizedNativeStakeRelayer() (common.Address, bool, error)
+	GetFinalizedAccountSequence(address common.Address) (uint64, error)
+	GetFinalizedStakerRewards() (*RewardSchedule, []StakerReward, uint64, error)
+	GetBlockGasLimit(parent *Block) uint64
+	GetMinBlockInterval(height uint64) time.Duration
+	GetMinimumGasPrice(height uint64) *big.Int
+	GetUpgradePlans(parent *Block) []UpgradePlan
+	GetValidatorIdentity(key common.Address) (common.Address, error)
 }
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+)
+
+// ValidatorLiveness summarizes the participation of a validator within a dynasty, as observed
+// from the finalized blocks.
+type ValidatorLiveness struct {
+	Address            common.Address
+	ProposalSlots      uint64 // number of epochs in which the validator was the expected proposer
+	Proposals          uint64 // number of finalized blocks proposed by the validator
+	CommitCertificates uint64 // number of commit certificates the validator was expected to vote in
+	VotesInCCs         uint64 // number of commit certificates including the vote of the validator
+}
+
+// MissedProposals returns the number of proposal slots without a finalized block from the validator
+func (vl *ValidatorLiveness) MissedProposals() uint64 {
+	if vl.Proposals >= vl.ProposalSlots {
+		return 0
+	}
+	return vl.ProposalSlots - vl.Proposals
+}
+
+// MissedVotes returns the number of commit certificates not including the vote of the validator
+func (vl *ValidatorLiveness) MissedVotes() uint64 {
+	if vl.VotesInCCs >= vl.CommitCertificates {
+		return 0
+	}
+	return vl.CommitCertificates - vl.VotesInCCs
+}
+
+func (vl *ValidatorLiveness) String() string {
+	return fmt.Sprintf("{Address: %v, ProposalSlots: %v, Proposals: %v, CommitCertificates: %v, VotesInCCs: %v}",
+		vl.Address.Hex(), vl.ProposalSlots, vl.Proposals, vl.CommitCertificates, vl.VotesInCCs)
+}
+
+// DynastyLiveness holds the liveness of all the validators within a dynasty. Complete is true if the
+// node has processed the dynasty from its first finalized block, otherwise the figures only cover
+// the blocks since StartHeight.
+type DynastyLiveness struct {
+	Dynasty     *big.Int
+	StartHeight uint64
+	EndHeight   uint64
+	Complete    bool
+	Validators  []*ValidatorLiveness
+}
+
+// DowntimeRecord is a validator flagged in a downtime report, along with the reasons
+type DowntimeRecord struct {
+	Liveness ValidatorLiveness
+	Reasons  []string
+}
+
+// DowntimeReport lists the validators that missed more proposals or votes than allowed within a dynasty
+type DowntimeReport struct {
+	Dynasty     *big.Int
+	StartHeight uint64
+	EndHeight   uint64
+	Complete    bool
+	Offenders   []*DowntimeRecord
+}
+
+// DowntimeReporter provides the downtime reports of the dynasties
+type DowntimeReporter interface {
+	GetDowntimeReport(dynasty *big.Int) (*DowntimeReport, error)
+	GetLatestLivenessDynasty() *big.Int
+}
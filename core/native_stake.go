@@ -0,0 +1,65 @@
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+)
+
+// NativeStakeUnbondingPeriodInBlocks is the number of blocks the unstaked amount stays locked before it is
+// released to the staker, which covers the time the withdrawal takes to be relayed to the mainchain.
+const NativeStakeUnbondingPeriodInBlocks uint64 = 28800
+
+// NativeStakeEscrowAddress holds the governance token vouchers locked by the SubchainStakeTx. No one has the
+// private key of the address, the vouchers are only moved by the ledger itself.
+var NativeStakeEscrowAddress = common.BytesToAddress(crypto.Keccak256(common.Bytes("thetasubchain/native_stake_escrow"))[12:])
+
+// NativeStakeRecordType is the type of the stake changes made on the subchain.
+type NativeStakeRecordType byte
+
+const (
+	NativeStakeRecordTypeDeposit NativeStakeRecordType = iota
+	NativeStakeRecordTypeWithdrawal
+)
+
+func (t NativeStakeRecordType) String() string {
+	switch t {
+	case NativeStakeRecordTypeDeposit:
+		return "Stake"
+	case NativeStakeRecordTypeWithdrawal:
+		return "Unstake"
+	default:
+		return fmt.Sprintf("NativeStakeRecordType(%d)", byte(t))
+	}
+}
+
+// NativeStakeRecord records a stake change made on the subchain. The records are indexed in the order of
+// inclusion, so that the orchestrator can relay them to the chain registrar on the mainchain in order.
+type NativeStakeRecord struct {
+	Index       uint64
+	Type        NativeStakeRecordType
+	Staker      common.Address
+	Validator   common.Address
+	Amount      *big.Int // amount of the governance token
+	BlockHeight uint64   // height of the block that included the transaction
+}
+
+func (r NativeStakeRecord) String() string {
+	return fmt.Sprintf("NativeStakeRecord{Index: %v, Type: %v, Staker: %v, Validator: %v, Amount: %v, BlockHeight: %v}",
+		r.Index, r.Type, r.Staker.Hex(), r.Validator.Hex(), r.Amount, r.BlockHeight)
+}
+
+// NativeUnbonding is an unstaked amount waiting for the end of the unbonding period.
+type NativeUnbonding struct {
+	Staker        common.Address
+	Validator     common.Address
+	Amount        *big.Int
+	ReleaseHeight uint64
+}
+
+func (u NativeUnbonding) String() string {
+	return fmt.Sprintf("NativeUnbonding{Staker: %v, Validator: %v, Amount: %v, ReleaseHeight: %v}",
+		u.Staker.Hex(), u.Validator.Hex(), u.Amount, u.ReleaseHeight)
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+)
+
+// RewardSchedule specifies the rewards paid to the validators through the coinbase transactions. For each
+// block, the validators who signed the HCC carried by the block share, in proportion to their stakes,
+// the staker reward of the subchain governance token, and the gas fees collected since the previous
+// coinbase transaction. The governance token lives on the mainchain, so the staker rewards are accrued
+// on the subchain and minted by the token minter through SubchainGovernanceToken.MintStakerReward.
+type RewardSchedule struct {
+	GovernanceToken common.Address        // address of the subchain governance token on the mainchain
+	Entries         []RewardScheduleEntry // sorted by the start height
+}
+
+// RewardScheduleEntry specifies the staker reward per block starting from the given height, mirroring
+// SubchainGovernanceToken.UpdateStakerRewardPerBlock.
+type RewardScheduleEntry struct {
+	StartHeight          uint64
+	StakerRewardPerBlock *big.Int
+}
+
+// NewRewardSchedule creates a reward schedule with the given initial staker reward per block.
+func NewRewardSchedule(governanceToken common.Address, stakerRewardPerBlock *big.Int) *RewardSchedule {
+	return &RewardSchedule{
+		GovernanceToken: governanceToken,
+		Entries: []RewardScheduleEntry{
+			{StartHeight: 0, StakerRewardPerBlock: stakerRewardPerBlock},
+		},
+	}
+}
+
+// StakerRewardPerBlock returns the staker reward per block in effect at the given height.
+func (rs *RewardSchedule) StakerRewardPerBlock(height uint64) *big.Int {
+	reward := big.NewInt(0)
+	for _, entry := range rs.Entries {
+		if entry.StartHeight > height {
+			break
+		}
+		reward = entry.StakerRewardPerBlock
+	}
+	return new(big.Int).Set(reward)
+}
+
+// UpdateStakerRewardPerBlock schedules a new staker reward per block starting from the given height.
+func (rs *RewardSchedule) UpdateStakerRewardPerBlock(startHeight uint64, stakerRewardPerBlock *big.Int) error {
+	if len(rs.Entries) > 0 && rs.Entries[len(rs.Entries)-1].StartHeight >= startHeight {
+		return fmt.Errorf("start height %v is not after the last scheduled height %v",
+			startHeight, rs.Entries[len(rs.Entries)-1].StartHeight)
+	}
+	if stakerRewardPerBlock == nil || stakerRewardPerBlock.Sign() < 0 {
+		return fmt.Errorf("invalid staker reward per block: %v", stakerRewardPerBlock)
+	}
+	rs.Entries = append(rs.Entries, RewardScheduleEntry{
+		StartHeight:          startHeight,
+		StakerRewardPerBlock: new(big.Int).Set(stakerRewardPerBlock),
+	})
+	return nil
+}
+
+// StakerReward is the total staker reward accrued by an account on the subchain.
+type StakerReward struct {
+	Account common.Address
+	Amount  *big.Int
+}
+
+func (rs *RewardSchedule) String() string {
+	return fmt.Sprintf("RewardSchedule{GovernanceToken: %v, Entries: %v}", rs.GovernanceToken.Hex(), rs.Entries)
+}
+
+// SplitByStake splits the amount among the given validators in proportion to their stakes. The amounts
+// are rounded down, so the sum of the shares might be less than the amount.
+func SplitByStake(amount *big.Int, validators []Validator) []*big.Int {
+	stakes := make([]*big.Int, len(validators))
+	for i, v := range validators {
+		stakes[i] = v.Stake
+	}
+	return SplitByWeight(amount, stakes)
+}
+
+// SplitByWeight splits the amount in proportion to the given weights. The amounts are rounded down, so
+// the sum of the shares might be less than the amount.
+func SplitByWeight(amount *big.Int, weights []*big.Int) []*big.Int {
+	totalWeight := big.NewInt(0)
+	for _, weight := range weights {
+		totalWeight.Add(totalWeight, weight)
+	}
+	shares := make([]*big.Int, len(weights))
+	for i, weight := range weights {
+		if totalWeight.Sign() == 0 {
+			shares[i] = big.NewInt(0)
+			continue
+		}
+		share := new(big.Int).Mul(amount, weight)
+		shares[i] = share.Div(share, totalWeight)
+	}
+	return shares
+}
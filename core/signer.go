@@ -0,0 +1,40 @@
+package core
+
+import (
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// Signer signs the consensus messages on behalf of the validator. The key may be held by the node
+// itself, or by a remote signer process which refuses to sign conflicting messages.
+type Signer interface {
+	// Address returns the address of the validator
+	Address() common.Address
+
+	// SignVote sets the signature of the vote
+	SignVote(vote *Vote) error
+
+	// SignVoteBLS sets both the signature and the BLS signature of the vote, the latter can be
+	// aggregated into a commit certificate
+	SignVoteBLS(vote *Vote) error
+
+	// BLSPubKey returns the BLS public key of the validator along with its proof of possession
+	BLSPubKey() (pubKey common.Bytes, pop common.Bytes, err error)
+
+	// SignProposal sets the signature of the proposed block header
+	SignProposal(header *BlockHeader) error
+
+	// ProveVRF returns the VRF proof of the input
+	ProveVRF(alpha []byte) (common.Bytes, error)
+
+	// SignTx signs a transaction issued by the block proposer
+	SignTx(chainID string, tx types.Tx) (*crypto.Signature, error)
+}
+
+// StandbySigner is implemented by the signers of hot-standby nodes, which follow the chain without
+// signing until they take over from the primary node.
+type StandbySigner interface {
+	// IsStandingBy returns whether the signer refuses to sign for now
+	IsStandingBy() bool
+}
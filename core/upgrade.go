@@ -0,0 +1,88 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GovParamUpgradeVersion keeps the approved upgrade plans among the governed values. The upgrade plans are
+// proposed through the upgrade plan transactions rather than the governance vote transactions.
+const GovParamUpgradeVersion = "upgrade_version"
+
+// UpgradePlan requires the validators to run at least the given software version from the given height on.
+// The nodes running older versions halt before the block at that height.
+type UpgradePlan struct {
+	Version string
+	Height  uint64
+}
+
+// String represents the string representation of the upgrade plan
+func (p UpgradePlan) String() string {
+	return fmt.Sprintf("{Version: %v, Height: %v}", p.Version, p.Height)
+}
+
+// IsSupportedBy returns whether the software version satisfies the upgrade plan.
+func (p UpgradePlan) IsSupportedBy(version string) bool {
+	cmp, err := CompareVersions(version, p.Version)
+	return err == nil && cmp >= 0
+}
+
+// FindUnsupportedUpgradePlan returns the earliest upgrade plan taking effect at or before the given height
+// which the software version does not satisfy, or nil if there is none.
+func FindUnsupportedUpgradePlan(plans []UpgradePlan, height uint64, version string) *UpgradePlan {
+	for _, plan := range plans {
+		if plan.Height > height {
+			break
+		}
+		if !plan.IsSupportedBy(version) {
+			plan := plan
+			return &plan
+		}
+	}
+	return nil
+}
+
+// ValidateUpgradeVersion checks the version string of an upgrade plan, e.g. 1.2.0.
+func ValidateUpgradeVersion(version string) error {
+	_, err := parseVersion(version)
+	return err
+}
+
+// CompareVersions compares two major.minor.patch versions, and returns -1, 0 or 1 if the first version is
+// older than, equal to or newer than the second one.
+func CompareVersions(a, b string) (int, error) {
+	va, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := range va {
+		if va[i] < vb[i] {
+			return -1, nil
+		}
+		if va[i] > vb[i] {
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func parseVersion(version string) ([3]uint64, error) {
+	ret := [3]uint64{}
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(version), "v"), ".")
+	if len(parts) != 3 {
+		return ret, fmt.Errorf("invalid version %v, expected major.minor.patch", version)
+	}
+	for i, part := range parts {
+		num, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return ret, fmt.Errorf("invalid version %v, expected major.minor.patch", version)
+		}
+		ret[i] = num
+	}
+	return ret, nil
+}
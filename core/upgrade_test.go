@@ -0,0 +1,68 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareVersions(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		a, b  string
+		cmp   int
+		valid bool
+	}{
+		{"1.2.0", "1.2.0", 0, true},
+		{"v1.2.0", "1.2.0", 0, true},
+		{" 1.2.0 ", "v1.2.0", 0, true},
+		{"1.2.1", "1.2.0", 1, true},
+		{"1.2.0", "1.2.1", -1, true},
+		{"1.10.0", "1.9.0", 1, true},
+		{"2.0.0", "1.99.99", 1, true},
+		{"0.9.9", "1.0.0", -1, true},
+		{"1.2", "1.2.0", 0, false},
+		{"1.2.0", "1.2.0.1", 0, false},
+		{"1.2.x", "1.2.0", 0, false},
+		{"1.-2.0", "1.2.0", 0, false},
+		{"", "1.2.0", 0, false},
+		{"1.2.0", "latest", 0, false},
+	}
+	for _, test := range tests {
+		cmp, err := CompareVersions(test.a, test.b)
+		assert.Equal(test.valid, err == nil, "%v vs %v: %v", test.a, test.b, err)
+		assert.Equal(test.cmp, cmp, "%v vs %v", test.a, test.b)
+	}
+}
+
+func TestFindUnsupportedUpgradePlan(t *testing.T) {
+	assert := assert.New(t)
+
+	plans := []UpgradePlan{
+		{Version: "1.1.0", Height: 100},
+		{Version: "1.2.0", Height: 200},
+		{Version: "2.0.0", Height: 300},
+	}
+
+	tests := []struct {
+		name        string
+		plans       []UpgradePlan
+		height      uint64
+		version     string
+		unsupported *UpgradePlan
+	}{
+		{"no plan", []UpgradePlan{}, 1000, "1.0.0", nil},
+		{"before the first upgrade", plans, 99, "1.0.0", nil},
+		{"at the first upgrade", plans, 100, "1.0.0", &plans[0]},
+		{"the earliest unsupported plan", plans, 1000, "1.0.0", &plans[0]},
+		{"upgraded to the first plan", plans, 250, "1.1.5", &plans[1]},
+		{"before the pending upgrade", plans, 299, "1.2.0", nil},
+		{"at the pending upgrade", plans, 300, "1.2.0", &plans[2]},
+		{"newer version", plans, 1000, "2.1.0", nil},
+		{"invalid version", plans, 100, "dev", &plans[0]},
+	}
+	for _, test := range tests {
+		assert.Equal(test.unsupported, FindUnsupportedUpgradePlan(test.plans, test.height, test.version), test.name)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto/bls"
 	"github.com/thetatoken/theta/rlp"
 )
 
@@ -54,10 +55,42 @@ func (v Validator) String() string {
 	return fmt.Sprintf("{ID: %v, Stake: %v}", v.ID(), v.Stake)
 }
 
-// ValidatorSet represents a set of validators.
+// MainchainBlockRef identifies the mainchain block a validator set update is derived from, so that each
+// validator can check the update against its own view of the mainchain.
+type MainchainBlockRef struct {
+	Height *big.Int
+	Hash   common.Hash
+}
+
+// String represents the string representation of the mainchain block reference
+func (r *MainchainBlockRef) String() string {
+	if r == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("{Height: %v, Hash: %v}", r.Height, r.Hash.Hex())
+}
+
+// ValidatorBLSPubKey is the BLS public key registered by a validator.
+type ValidatorBLSPubKey struct {
+	Address common.Address
+	PubKey  common.Bytes
+}
+
+// ValidatorIdentity maps the key a validator signs with to the validator address, for the validators
+// who have rotated their keys.
+type ValidatorIdentity struct {
+	Key       common.Address
+	Validator common.Address
+}
+
+// ValidatorSet represents a set of validators. The validators are identified by the keys they sign with,
+// which differ from the validator addresses the stake is bound to for the validators who have rotated
+// their keys.
 type ValidatorSet struct {
 	dynasty    *big.Int
 	validators []Validator
+	blsPubKeys map[common.Address]common.Bytes
+	identities map[common.Address]common.Address
 }
 
 // NewValidatorSet returns a new instance of ValidatorSet.
@@ -65,6 +98,8 @@ func NewValidatorSet(dynasty *big.Int) *ValidatorSet {
 	return &ValidatorSet{
 		dynasty:    dynasty,
 		validators: []Validator{},
+		blsPubKeys: make(map[common.Address]common.Bytes),
+		identities: make(map[common.Address]common.Address),
 	}
 }
 
@@ -84,6 +119,12 @@ func (s *ValidatorSet) Copy() *ValidatorSet {
 	for _, v := range s.Validators() {
 		ret.AddValidator(v)
 	}
+	for addr, pubKey := range s.blsPubKeys {
+		ret.SetBLSPubKey(addr, pubKey)
+	}
+	for key, validator := range s.identities {
+		ret.SetValidatorIdentity(key, validator)
+	}
 	return ret
 }
 
@@ -92,7 +133,8 @@ func (s *ValidatorSet) Size() int {
 	return len(s.validators)
 }
 
-// Equals checks whether the validator set is the same as another validator set
+// Equals checks whether the validator set is the same as another validator set. The BLS public keys
+// are registered on the subchain, and are not compared.
 func (s *ValidatorSet) Equals(t *ValidatorSet) bool {
 	if s.dynasty.Cmp(t.dynasty) != 0 {
 		return false
@@ -106,6 +148,10 @@ func (s *ValidatorSet) Equals(t *ValidatorSet) bool {
 		if !s.validators[i].Equals(t.validators[i]) {
 			return false
 		}
+		key := s.validators[i].ID()
+		if s.ValidatorIdentity(key) != t.ValidatorIdentity(key) {
+			return false
+		}
 	}
 	return true
 }
@@ -132,6 +178,78 @@ func (s *ValidatorSet) GetValidator(id common.Address) (Validator, error) {
 	return Validator{}, ErrValidatorNotFound
 }
 
+// IndexOf returns the index of the validator in the validator set, or -1 if it is not found.
+func (s *ValidatorSet) IndexOf(id common.Address) int {
+	for i, v := range s.validators {
+		if v.ID() == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetBLSPubKey sets the BLS public key of a validator.
+func (s *ValidatorSet) SetBLSPubKey(id common.Address, pubKey common.Bytes) {
+	if s.blsPubKeys == nil {
+		s.blsPubKeys = make(map[common.Address]common.Bytes)
+	}
+	s.blsPubKeys[id] = pubKey
+}
+
+// HasBLSPubKey returns whether the validator has registered a BLS public key.
+func (s *ValidatorSet) HasBLSPubKey(id common.Address) bool {
+	return len(s.blsPubKeys[id]) > 0
+}
+
+// GetBLSPubKey returns the BLS public key registered by the validator.
+func (s *ValidatorSet) GetBLSPubKey(id common.Address) (*bls.PublicKey, error) {
+	raw, ok := s.blsPubKeys[id]
+	if !ok || len(raw) == 0 {
+		return nil, fmt.Errorf("validator %v has no BLS public key", id.Hex())
+	}
+	return bls.PublicKeyFromBytes(raw)
+}
+
+// BLSPubKeys returns the registered BLS public keys sorted by the validator address.
+func (s *ValidatorSet) BLSPubKeys() []ValidatorBLSPubKey {
+	ret := []ValidatorBLSPubKey{}
+	for addr, pubKey := range s.blsPubKeys {
+		ret = append(ret, ValidatorBLSPubKey{Address: addr, PubKey: pubKey})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return bytes.Compare(ret[i].Address.Bytes(), ret[j].Address.Bytes()) < 0
+	})
+	return ret
+}
+
+// SetValidatorIdentity records the validator address of a rotated key.
+func (s *ValidatorSet) SetValidatorIdentity(key common.Address, validator common.Address) {
+	if s.identities == nil {
+		s.identities = make(map[common.Address]common.Address)
+	}
+	s.identities[key] = validator
+}
+
+// ValidatorIdentity returns the validator address the key signs for, which is the key itself unless
+// the validator has rotated its key.
+func (s *ValidatorSet) ValidatorIdentity(key common.Address) common.Address {
+	if validator, ok := s.identities[key]; ok {
+		return validator
+	}
+	return key
+}
+
+// ValidatorIdentities returns the validator addresses of the rotated keys, sorted by key.
+func (s *ValidatorSet) ValidatorIdentities() []ValidatorIdentity {
+	ret := []ValidatorIdentity{}
+	for _, v := range s.validators {
+		if validator, ok := s.identities[v.ID()]; ok {
+			ret = append(ret, ValidatorIdentity{Key: v.ID(), Validator: validator})
+		}
+	}
+	return ret
+}
+
 // AddValidator adds a validator to the validator set.
 func (s *ValidatorSet) AddValidator(validator Validator) {
 	s.validators = append(s.validators, validator)
@@ -149,9 +267,18 @@ func (s *ValidatorSet) TotalStake() *big.Int {
 
 // HasMajorityVotes checks whether a vote set has reach majority.
 func (s *ValidatorSet) HasMajorityVotes(votes []Vote) bool {
+	voters := make([]common.Address, len(votes))
+	for i, vote := range votes {
+		voters[i] = vote.ID
+	}
+	return s.HasMajorityVoters(voters)
+}
+
+// HasMajorityVoters checks whether the voters hold the majority of the stake.
+func (s *ValidatorSet) HasMajorityVoters(voters []common.Address) bool {
 	votedStake := new(big.Int).SetUint64(0)
-	for _, vote := range votes {
-		validator, err := s.GetValidator(vote.ID)
+	for _, voter := range voters {
+		validator, err := s.GetValidator(voter)
 		if err == nil {
 			votedStake = new(big.Int).Add(votedStake, validator.Stake)
 		}
@@ -183,9 +310,27 @@ func (vs *ValidatorSet) EncodeRLP(w io.Writer) error {
 	if vs == nil {
 		return rlp.Encode(w, &ValidatorSet{})
 	}
+	// The BLS public keys are only encoded once registered, so that the encoding of the validator
+	// sets without BLS keys remains the same
+	if len(vs.blsPubKeys) == 0 && len(vs.identities) == 0 {
+		return rlp.Encode(w, []interface{}{
+			vs.dynasty,
+			vs.validators,
+		})
+	}
+	// Likewise, the identities of the rotated keys are only encoded once a validator rotates its key
+	if len(vs.identities) == 0 {
+		return rlp.Encode(w, []interface{}{
+			vs.dynasty,
+			vs.validators,
+			vs.BLSPubKeys(),
+		})
+	}
 	return rlp.Encode(w, []interface{}{
 		vs.dynasty,
 		vs.validators,
+		vs.BLSPubKeys(),
+		vs.ValidatorIdentities(),
 	})
 }
 
@@ -210,5 +355,25 @@ func (vs *ValidatorSet) DecodeRLP(stream *rlp.Stream) error {
 	}
 	vs.validators = validators
 
+	vs.blsPubKeys = make(map[common.Address]common.Bytes)
+	blsPubKeys := []ValidatorBLSPubKey{}
+	err = stream.Decode(&blsPubKeys)
+	if err != nil && err != rlp.EOL {
+		return err
+	}
+	for _, pk := range blsPubKeys {
+		vs.blsPubKeys[pk.Address] = pk.PubKey
+	}
+
+	vs.identities = make(map[common.Address]common.Address)
+	identities := []ValidatorIdentity{}
+	err = stream.Decode(&identities)
+	if err != nil && err != rlp.EOL {
+		return err
+	}
+	for _, identity := range identities {
+		vs.identities[identity.Key] = identity.Validator
+	}
+
 	return stream.ListEnd()
 }
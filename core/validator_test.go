@@ -0,0 +1,103 @@
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/rlp"
+)
+
+func TestValidatorSetRLP(t *testing.T) {
+	assert := assert.New(t)
+
+	voters, withKeys := newTestVoters(t, 3)
+	legacy := NewValidatorSet(withKeys.Dynasty())
+	for _, v := range withKeys.Validators() {
+		legacy.AddValidator(v)
+	}
+
+	// Validator sets without BLS keys keep the encoding from before the fork
+	raw, err := rlp.EncodeToBytes(legacy)
+	assert.Nil(err)
+	expected, err := rlp.EncodeToBytes([]interface{}{legacy.Dynasty(), legacy.Validators()})
+	assert.Nil(err)
+	assert.Equal(expected, raw)
+
+	decoded := &ValidatorSet{}
+	assert.Nil(rlp.DecodeBytes(raw, decoded))
+	assert.True(legacy.Equals(decoded))
+	assert.Equal(0, len(decoded.BLSPubKeys()))
+
+	raw, err = rlp.EncodeToBytes(withKeys)
+	assert.Nil(err)
+	decoded = &ValidatorSet{}
+	assert.Nil(rlp.DecodeBytes(raw, decoded))
+	assert.True(withKeys.Equals(decoded))
+	assert.Equal(withKeys.BLSPubKeys(), decoded.BLSPubKeys())
+	for _, voter := range voters {
+		assert.True(decoded.HasBLSPubKey(voter.address()))
+		pubKey, err := decoded.GetBLSPubKey(voter.address())
+		assert.Nil(err)
+		assert.Equal(voter.blsKey.PublicKey().ToBytes(), pubKey.ToBytes())
+	}
+
+	// The keys are registered on the subchain, and do not take part in the comparison with the mainchain validator set
+	assert.True(legacy.Equals(withKeys))
+	assert.Equal(withKeys.BLSPubKeys(), withKeys.Copy().BLSPubKeys())
+}
+
+func TestValidatorSetBLSPubKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	voters, valSet := newTestVoters(t, 2)
+	outsider := common.HexToAddress("0x1234")
+
+	assert.Equal(0, valSet.IndexOf(voters[0].address()))
+	assert.Equal(1, valSet.IndexOf(voters[1].address()))
+	assert.Equal(-1, valSet.IndexOf(outsider))
+
+	assert.False(valSet.HasBLSPubKey(outsider))
+	_, err := valSet.GetBLSPubKey(outsider)
+	assert.NotNil(err)
+
+	valSet.SetBLSPubKey(outsider, common.Bytes{0x01})
+	_, err = valSet.GetBLSPubKey(outsider)
+	assert.NotNil(err)
+
+	pubKeys := valSet.BLSPubKeys()
+	for i := 1; i < len(pubKeys); i++ {
+		assert.True(bytes.Compare(pubKeys[i-1].Address.Bytes(), pubKeys[i].Address.Bytes()) < 0)
+	}
+}
+
+func TestValidatorSetHasMajorityVoters(t *testing.T) {
+	assert := assert.New(t)
+
+	a := common.HexToAddress("0x0a")
+	b := common.HexToAddress("0x0b")
+	c := common.HexToAddress("0x0c")
+	outsider := common.HexToAddress("0x0d")
+	valSet := NewValidatorSet(big.NewInt(1))
+	valSet.AddValidator(NewValidator(a.Hex(), big.NewInt(400)))
+	valSet.AddValidator(NewValidator(b.Hex(), big.NewInt(200)))
+	valSet.AddValidator(NewValidator(c.Hex(), big.NewInt(300)))
+
+	tests := []struct {
+		name     string
+		voters   []common.Address
+		majority bool
+	}{
+		{"all", []common.Address{a, b, c}, true},
+		{"more than two thirds", []common.Address{a, c}, true},
+		{"exactly two thirds", []common.Address{a, b}, false},
+		{"less than two thirds", []common.Address{b, c}, false},
+		{"outsiders do not count", []common.Address{b, c, outsider}, false},
+		{"none", []common.Address{}, false},
+	}
+	for _, test := range tests {
+		assert.Equal(test.majority, valSet.HasMajorityVoters(test.voters), test.name)
+	}
+}
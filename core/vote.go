@@ -2,15 +2,19 @@ package core
 
 import (
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"sort"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/crypto/bls"
 	"github.com/thetatoken/theta/rlp"
 )
 
@@ -25,10 +29,76 @@ func (p Proposal) String() string {
 	return fmt.Sprintf("Proposal{block: %v, proposer: %v, votes: %v}", p.Block, p.ProposerID, p.Votes)
 }
 
-// CommitCertificate represents a commit made a majority of validators.
+// CommitCertificate represents a commit made a majority of validators. The votes are either carried
+// individually, or aggregated into a single BLS signature after the BLS commit certificate fork.
 type CommitCertificate struct {
-	Votes     *VoteSet `rlp:"nil"`
-	BlockHash common.Hash
+	Votes      *VoteSet `rlp:"nil"`
+	BlockHash  common.Hash
+	Aggregated *AggregatedVotes `rlp:"nil"`
+}
+
+// AggregatedVotes represents the votes of a set of validators on the same block with a single BLS
+// signature. Bit i of the Voters bitmap is set if the i-th validator of the validator set voted.
+type AggregatedVotes struct {
+	Voters    common.Bytes
+	Signature common.Bytes
+}
+
+// Copy creates a copy of the aggregated votes.
+func (a *AggregatedVotes) Copy() *AggregatedVotes {
+	return &AggregatedVotes{
+		Voters:    append(common.Bytes{}, a.Voters...),
+		Signature: append(common.Bytes{}, a.Signature...),
+	}
+}
+
+func (a *AggregatedVotes) String() string {
+	if a == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("AggregatedVotes{Voters: %v, Signature: %v}", hex.EncodeToString(a.Voters), hex.EncodeToString(a.Signature))
+}
+
+// HasVoter returns whether the validator with the given index in the validator set is a voter.
+func (a *AggregatedVotes) HasVoter(index int) bool {
+	if index/8 >= len(a.Voters) {
+		return false
+	}
+	return a.Voters[index/8]&(1<<uint(index%8)) != 0
+}
+
+// AggregateVotes aggregates the BLS signatures of the votes on the same block. It returns an error if
+// a vote has no BLS signature, or if a voter is not in the validator set.
+func AggregateVotes(validators *ValidatorSet, votes []Vote) (*AggregatedVotes, error) {
+	if len(votes) == 0 {
+		return nil, errors.New("no vote to aggregate")
+	}
+	voters := make(common.Bytes, (validators.Size()+7)/8)
+	var aggregated *bls.Signature
+	for _, vote := range votes {
+		index := validators.IndexOf(vote.ID)
+		if index < 0 {
+			return nil, fmt.Errorf("voter %v is not a validator", vote.ID.Hex())
+		}
+		if voters[index/8]&(1<<uint(index%8)) != 0 {
+			return nil, fmt.Errorf("duplicate votes from %v", vote.ID.Hex())
+		}
+		voters[index/8] |= 1 << uint(index%8)
+
+		sig, err := bls.SignatureFromBytes(vote.BLSSignature)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLS signature from %v: %v", vote.ID.Hex(), err)
+		}
+		if aggregated == nil {
+			aggregated = sig.Copy()
+		} else {
+			aggregated.Aggregate(sig)
+		}
+	}
+	return &AggregatedVotes{
+		Voters:    voters,
+		Signature: aggregated.ToBytes(),
+	}, nil
 }
 
 // Copy creates a copy of this commit certificate.
@@ -39,15 +109,49 @@ func (cc CommitCertificate) Copy() CommitCertificate {
 	if cc.Votes != nil {
 		ret.Votes = cc.Votes.Copy()
 	}
+	if cc.Aggregated != nil {
+		ret.Aggregated = cc.Aggregated.Copy()
+	}
 	return ret
 }
 
 func (cc CommitCertificate) String() string {
+	if cc.Aggregated != nil {
+		return fmt.Sprintf("CC{BlockHash: %v, Aggregated: %v}", cc.BlockHash.Hex(), cc.Aggregated)
+	}
 	return fmt.Sprintf("CC{BlockHash: %v, Votes: %v}", cc.BlockHash.Hex(), cc.Votes)
 }
 
+// IsEmpty returns whether the commit certificate carries no vote.
+func (cc CommitCertificate) IsEmpty() bool {
+	return cc.Aggregated == nil && (cc.Votes == nil || cc.Votes.IsEmpty())
+}
+
+// Voters returns the addresses of the validators who voted for the block.
+func (cc CommitCertificate) Voters(validators *ValidatorSet) []common.Address {
+	voters := []common.Address{}
+	if cc.Aggregated != nil {
+		for i, v := range validators.Validators() {
+			if cc.Aggregated.HasVoter(i) {
+				voters = append(voters, v.Address)
+			}
+		}
+		return voters
+	}
+	if cc.Votes == nil {
+		return voters
+	}
+	for _, vote := range cc.Votes.UniqueVoter().Votes() {
+		voters = append(voters, vote.ID)
+	}
+	return voters
+}
+
 // IsValid checks if a CommitCertificate is valid.
 func (cc CommitCertificate) IsValid(validators *ValidatorSet) bool {
+	if cc.Aggregated != nil {
+		return cc.isValidAggregated(validators)
+	}
 	if cc.Votes == nil || cc.Votes.IsEmpty() {
 		return false
 	}
@@ -69,6 +173,104 @@ func (cc CommitCertificate) IsValid(validators *ValidatorSet) bool {
 	return validators.HasMajority(filtered)
 }
 
+// isValidAggregated checks the aggregated signature against the BLS public keys of the voters, which
+// must hold the majority of the stake.
+func (cc CommitCertificate) isValidAggregated(validators *ValidatorSet) bool {
+	if cc.Votes != nil && !cc.Votes.IsEmpty() {
+		return false
+	}
+	if len(cc.Aggregated.Voters) != (validators.Size()+7)/8 {
+		return false
+	}
+	for i := validators.Size(); i < len(cc.Aggregated.Voters)*8; i++ {
+		if cc.Aggregated.HasVoter(i) {
+			return false // bits beyond the validator set must not be set
+		}
+	}
+
+	var aggregatedPubKey *bls.PublicKey
+	voters := []common.Address{}
+	for i, v := range validators.Validators() {
+		if !cc.Aggregated.HasVoter(i) {
+			continue
+		}
+		pubKey, err := validators.GetBLSPubKey(v.Address)
+		if err != nil {
+			return false
+		}
+		if aggregatedPubKey == nil {
+			aggregatedPubKey = pubKey.Copy()
+		} else {
+			aggregatedPubKey.Aggregate(pubKey)
+		}
+		voters = append(voters, v.Address)
+	}
+	if aggregatedPubKey == nil || !validators.HasMajorityVoters(voters) {
+		return false
+	}
+
+	sig, err := bls.SignatureFromBytes(cc.Aggregated.Signature)
+	if err != nil {
+		return false
+	}
+	return sig.Verify(BLSVoteSignBytes(cc.BlockHash), aggregatedPubKey)
+}
+
+// commitCertificateRLP is the encoding of the commit certificates with individual votes, which
+// remains the same as before the aggregated votes were introduced, so that the block hashes do not change
+type commitCertificateRLP struct {
+	Votes     *VoteSet `rlp:"nil"`
+	BlockHash common.Hash
+}
+
+type aggregatedCommitCertificateRLP struct {
+	Votes      *VoteSet `rlp:"nil"`
+	BlockHash  common.Hash
+	Aggregated *AggregatedVotes
+}
+
+var _ rlp.Encoder = CommitCertificate{}
+
+// EncodeRLP implements RLP Encoder interface.
+func (cc CommitCertificate) EncodeRLP(w io.Writer) error {
+	if cc.Aggregated == nil {
+		return rlp.Encode(w, commitCertificateRLP{
+			Votes:     cc.Votes,
+			BlockHash: cc.BlockHash,
+		})
+	}
+	return rlp.Encode(w, aggregatedCommitCertificateRLP{
+		Votes:      cc.Votes,
+		BlockHash:  cc.BlockHash,
+		Aggregated: cc.Aggregated,
+	})
+}
+
+var _ rlp.Decoder = (*CommitCertificate)(nil)
+
+// DecodeRLP implements RLP Decoder interface.
+func (cc *CommitCertificate) DecodeRLP(stream *rlp.Stream) error {
+	raw, err := stream.Raw()
+	if err != nil {
+		return err
+	}
+	legacy := commitCertificateRLP{}
+	if err := rlp.DecodeBytes(raw, &legacy); err == nil {
+		cc.Votes = legacy.Votes
+		cc.BlockHash = legacy.BlockHash
+		cc.Aggregated = nil
+		return nil
+	}
+	aggregated := aggregatedCommitCertificateRLP{}
+	if err := rlp.DecodeBytes(raw, &aggregated); err != nil {
+		return err
+	}
+	cc.Votes = aggregated.Votes
+	cc.BlockHash = aggregated.BlockHash
+	cc.Aggregated = aggregated.Aggregated
+	return nil
+}
+
 // Vote represents a vote on a block by a validaor.
 type Vote struct {
 	Block           common.Hash    // Hash of the tip as seen by the voter.
@@ -77,18 +279,97 @@ type Vote struct {
 	Epoch           uint64         // Voter's current epoch. It doesn't need to equal the epoch in the block above.
 	ID              common.Address // Voter's address.
 	Signature       *crypto.Signature
+	BLSSignature    common.Bytes // Aggregatable signature of the block hash, only set after the BLS commit certificate fork.
+	Timestamp       *big.Int     // Voter's local time in milliseconds, only set after the future timestamp bound fork.
+}
+
+// voteRLP is the encoding of the votes without a BLS signature, which remains the same as before the
+// BLS signatures were introduced
+type voteRLP struct {
+	Block           common.Hash
+	Height          uint64
+	MainchainHeight uint64
+	Epoch           uint64
+	ID              common.Address
+	Signature       *crypto.Signature
+}
+
+type blsVoteRLP struct {
+	Block           common.Hash
+	Height          uint64
+	MainchainHeight uint64
+	Epoch           uint64
+	ID              common.Address
+	Signature       *crypto.Signature
+	BLSSignature    common.Bytes
+}
+
+// timestampVoteRLP is the encoding of the votes with a timestamp, whose BLS signature might be empty
+type timestampVoteRLP struct {
+	Block           common.Hash
+	Height          uint64
+	MainchainHeight uint64
+	Epoch           uint64
+	ID              common.Address
+	Signature       *crypto.Signature
+	BLSSignature    common.Bytes
+	Timestamp       *big.Int
+}
+
+var _ rlp.Encoder = Vote{}
+
+// EncodeRLP implements RLP Encoder interface.
+func (v Vote) EncodeRLP(w io.Writer) error {
+	if v.Timestamp != nil {
+		return rlp.Encode(w, timestampVoteRLP{v.Block, v.Height, v.MainchainHeight, v.Epoch, v.ID, v.Signature, v.BLSSignature, v.Timestamp})
+	}
+	if len(v.BLSSignature) == 0 {
+		return rlp.Encode(w, voteRLP{v.Block, v.Height, v.MainchainHeight, v.Epoch, v.ID, v.Signature})
+	}
+	return rlp.Encode(w, blsVoteRLP{v.Block, v.Height, v.MainchainHeight, v.Epoch, v.ID, v.Signature, v.BLSSignature})
+}
+
+var _ rlp.Decoder = (*Vote)(nil)
+
+// DecodeRLP implements RLP Decoder interface.
+func (v *Vote) DecodeRLP(stream *rlp.Stream) error {
+	raw, err := stream.Raw()
+	if err != nil {
+		return err
+	}
+	legacy := voteRLP{}
+	if err := rlp.DecodeBytes(raw, &legacy); err == nil {
+		*v = Vote{legacy.Block, legacy.Height, legacy.MainchainHeight, legacy.Epoch, legacy.ID, legacy.Signature, nil, nil}
+		return nil
+	}
+	bv := blsVoteRLP{}
+	if err := rlp.DecodeBytes(raw, &bv); err == nil {
+		*v = Vote{bv.Block, bv.Height, bv.MainchainHeight, bv.Epoch, bv.ID, bv.Signature, bv.BLSSignature, nil}
+		return nil
+	}
+	tv := timestampVoteRLP{}
+	if err := rlp.DecodeBytes(raw, &tv); err != nil {
+		return err
+	}
+	if len(tv.BLSSignature) == 0 {
+		tv.BLSSignature = nil
+	}
+	*v = Vote{tv.Block, tv.Height, tv.MainchainHeight, tv.Epoch, tv.ID, tv.Signature, tv.BLSSignature, tv.Timestamp}
+	return nil
 }
 
 func (v Vote) String() string {
 	return fmt.Sprintf("Vote{ID: %s, block: %s,  Epoch: %v, MainchainHeight: %v}", v.ID, v.Block.Hex(), v.Epoch, v.MainchainHeight)
 }
 
-// SignBytes returns raw bytes to be signed.
+// SignBytes returns raw bytes to be signed. The timestamp is signed as well if set, so that it cannot be
+// altered when the vote is relayed.
 func (v Vote) SignBytes() common.Bytes {
 	vv := Vote{
-		Block: v.Block,
-		Epoch: v.Epoch,
-		ID:    v.ID,
+		Block:     v.Block,
+		Epoch:     v.Epoch,
+		ID:        v.ID,
+		Timestamp: v.Timestamp,
 	}
 	raw, _ := rlp.EncodeToBytes(vv)
 	return raw
@@ -109,6 +390,33 @@ func (v *Vote) SetSignature(sig *crypto.Signature) {
 	v.Signature = sig
 }
 
+// BLSVoteSignBytes returns the raw bytes signed by the BLS key of the voters. Unlike SignBytes, it
+// only depends on the block, so that all the votes on a block can be aggregated into one signature.
+func BLSVoteSignBytes(block common.Hash) common.Bytes {
+	raw, _ := rlp.EncodeToBytes([]interface{}{"BLSVote", block})
+	return raw
+}
+
+// SetBLSSignature sets given BLS signature in vote.
+func (v *Vote) SetBLSSignature(sig *bls.Signature) {
+	v.BLSSignature = sig.ToBytes()
+}
+
+// ValidateBLS checks the BLS signature of the vote against the public key of the voter.
+func (v Vote) ValidateBLS(pubKey *bls.PublicKey) result.Result {
+	if len(v.BLSSignature) == 0 {
+		return result.Error("Vote has no BLS signature")
+	}
+	sig, err := bls.SignatureFromBytes(v.BLSSignature)
+	if err != nil {
+		return result.Error("Invalid BLS signature: %v", err)
+	}
+	if !sig.Verify(BLSVoteSignBytes(v.Block), pubKey) {
+		return result.Error("BLS signature verification failed")
+	}
+	return result.OK
+}
+
 // Validate checks the vote is legitimate.
 func (v Vote) Validate() result.Result {
 	if v.Block.IsEmpty() {
@@ -161,6 +469,9 @@ func (s *VoteSet) AddVote(vote Vote) {
 
 // Size returns the number of votes in the vote set.
 func (s *VoteSet) Size() int {
+	if s == nil {
+		return 0
+	}
 	return len(s.votes)
 }
 
@@ -171,6 +482,9 @@ func (s *VoteSet) IsEmpty() bool {
 
 // Votes return a slice of votes in the vote set.
 func (s *VoteSet) Votes() []Vote {
+	if s == nil {
+		return []Vote{}
+	}
 	ret := make([]Vote, 0, len(s.votes))
 	for _, v := range s.votes {
 		ret = append(ret, v)
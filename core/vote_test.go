@@ -0,0 +1,265 @@
+package core
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/crypto/bls"
+	"github.com/thetatoken/theta/rlp"
+)
+
+type testVoter struct {
+	privKey *crypto.PrivateKey
+	blsKey  *bls.SecretKey
+}
+
+func (v testVoter) address() common.Address {
+	return v.privKey.PublicKey().Address()
+}
+
+// vote creates a vote on the block, signed with the BLS key as well if withBLS is true
+func (v testVoter) vote(block common.Hash, withBLS bool) Vote {
+	vote := Vote{
+		Block:  block,
+		Height: 10,
+		Epoch:  5,
+		ID:     v.address(),
+	}
+	vote.Sign(v.privKey)
+	if withBLS {
+		vote.SetBLSSignature(v.blsKey.Sign(BLSVoteSignBytes(block)))
+	}
+	return vote
+}
+
+// newTestVoters creates n validators of equal stake, all with a registered BLS key
+func newTestVoters(t *testing.T, n int) ([]testVoter, *ValidatorSet) {
+	voters := []testVoter{}
+	valSet := NewValidatorSet(big.NewInt(1))
+	for i := 0; i < n; i++ {
+		privKey, _, err := crypto.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		blsKey, err := bls.GenKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		voter := testVoter{privKey: privKey, blsKey: blsKey}
+		voters = append(voters, voter)
+		valSet.AddValidator(NewValidator(voter.address().Hex(), big.NewInt(100)))
+		valSet.SetBLSPubKey(voter.address(), blsKey.PublicKey().ToBytes())
+	}
+	return voters, valSet
+}
+
+func TestVoteRLP(t *testing.T) {
+	assert := assert.New(t)
+
+	voters, _ := newTestVoters(t, 1)
+	block := common.HexToHash("a1")
+
+	// Votes without a BLS signature keep the encoding from before the fork
+	legacy := voters[0].vote(block, false)
+	raw, err := rlp.EncodeToBytes(legacy)
+	assert.Nil(err)
+	expected, err := rlp.EncodeToBytes(voteRLP{legacy.Block, legacy.Height, legacy.MainchainHeight, legacy.Epoch, legacy.ID, legacy.Signature})
+	assert.Nil(err)
+	assert.Equal(expected, raw)
+
+	var decoded Vote
+	assert.Nil(rlp.DecodeBytes(raw, &decoded))
+	assert.Equal(legacy.Hash(), decoded.Hash())
+	assert.Nil(decoded.BLSSignature)
+	assert.True(decoded.Validate().IsOK())
+
+	withBLS := voters[0].vote(block, true)
+	raw, err = rlp.EncodeToBytes(withBLS)
+	assert.Nil(err)
+	assert.NotEqual(expected, raw)
+
+	decoded = Vote{}
+	assert.Nil(rlp.DecodeBytes(raw, &decoded))
+	assert.Equal(withBLS.BLSSignature, decoded.BLSSignature)
+	assert.True(decoded.Validate().IsOK())
+	assert.True(decoded.ValidateBLS(voters[0].blsKey.PublicKey()).IsOK())
+
+	// The BLS signature is bound to the block
+	other := voters[0].vote(common.HexToHash("a2"), true)
+	decoded.BLSSignature = other.BLSSignature
+	assert.True(decoded.ValidateBLS(voters[0].blsKey.PublicKey()).IsError())
+}
+
+func TestVoteWithTimestampRLP(t *testing.T) {
+	assert := assert.New(t)
+
+	voters, _ := newTestVoters(t, 1)
+	block := common.HexToHash("a1")
+
+	for _, withBLS := range []bool{false, true} {
+		vote := Vote{
+			Block:     block,
+			Height:    10,
+			Epoch:     5,
+			ID:        voters[0].address(),
+			Timestamp: big.NewInt(1600000000123),
+		}
+		vote.Sign(voters[0].privKey)
+		if withBLS {
+			vote.SetBLSSignature(voters[0].blsKey.Sign(BLSVoteSignBytes(block)))
+		}
+
+		raw, err := rlp.EncodeToBytes(vote)
+		assert.Nil(err)
+		var decoded Vote
+		assert.Nil(rlp.DecodeBytes(raw, &decoded))
+		assert.Equal(vote.Hash(), decoded.Hash())
+		assert.Equal(vote.BLSSignature, decoded.BLSSignature)
+		assert.Equal(0, vote.Timestamp.Cmp(decoded.Timestamp))
+		assert.True(decoded.Validate().IsOK())
+		if withBLS {
+			assert.True(decoded.ValidateBLS(voters[0].blsKey.PublicKey()).IsOK())
+		}
+
+		// The timestamp is covered by the signature
+		decoded.Timestamp = big.NewInt(1600000060123)
+		assert.True(decoded.Validate().IsError())
+		decoded.Timestamp = nil
+		assert.True(decoded.Validate().IsError())
+	}
+}
+
+func TestCommitCertificateRLP(t *testing.T) {
+	assert := assert.New(t)
+
+	voters, valSet := newTestVoters(t, 4)
+	block := common.HexToHash("a1")
+
+	votes := NewVoteSet()
+	for _, voter := range voters {
+		votes.AddVote(voter.vote(block, false))
+	}
+	legacy := CommitCertificate{Votes: votes, BlockHash: block}
+
+	// Commit certificates with individual votes keep the encoding from before the fork, so the block hashes do not change
+	raw, err := rlp.EncodeToBytes(legacy)
+	assert.Nil(err)
+	expected, err := rlp.EncodeToBytes(commitCertificateRLP{Votes: votes, BlockHash: block})
+	assert.Nil(err)
+	assert.Equal(expected, raw)
+
+	var decoded CommitCertificate
+	assert.Nil(rlp.DecodeBytes(raw, &decoded))
+	assert.Nil(decoded.Aggregated)
+	assert.Equal(block, decoded.BlockHash)
+	assert.Equal(votes.Size(), decoded.Votes.Size())
+	assert.True(decoded.IsValid(valSet))
+
+	blsVotes := []Vote{}
+	for _, voter := range voters {
+		blsVotes = append(blsVotes, voter.vote(block, true))
+	}
+	aggregated, err := AggregateVotes(valSet, blsVotes)
+	assert.Nil(err)
+	cc := CommitCertificate{BlockHash: block, Aggregated: aggregated}
+
+	raw, err = rlp.EncodeToBytes(cc)
+	assert.Nil(err)
+	decoded = CommitCertificate{}
+	assert.Nil(rlp.DecodeBytes(raw, &decoded))
+	assert.NotNil(decoded.Aggregated)
+	assert.Equal(aggregated.Voters, decoded.Aggregated.Voters)
+	assert.Equal(aggregated.Signature, decoded.Aggregated.Signature)
+	assert.True(decoded.Votes == nil || decoded.Votes.IsEmpty())
+	assert.True(decoded.IsValid(valSet))
+	assert.Equal(4, len(decoded.Voters(valSet)))
+}
+
+func TestAggregateVotes(t *testing.T) {
+	assert := assert.New(t)
+
+	voters, valSet := newTestVoters(t, 3)
+	outsiders, _ := newTestVoters(t, 1)
+	block := common.HexToHash("a1")
+
+	tests := []struct {
+		name  string
+		votes []Vote
+		valid bool
+	}{
+		{"all voters", []Vote{voters[0].vote(block, true), voters[1].vote(block, true), voters[2].vote(block, true)}, true},
+		{"no vote", []Vote{}, false},
+		{"duplicate voter", []Vote{voters[0].vote(block, true), voters[0].vote(block, true)}, false},
+		{"not a validator", []Vote{voters[0].vote(block, true), outsiders[0].vote(block, true)}, false},
+		{"no BLS signature", []Vote{voters[0].vote(block, true), voters[1].vote(block, false)}, false},
+	}
+	for _, test := range tests {
+		_, err := AggregateVotes(valSet, test.votes)
+		assert.Equal(test.valid, err == nil, test.name)
+	}
+}
+
+func TestAggregatedCommitCertificateIsValid(t *testing.T) {
+	assert := assert.New(t)
+
+	voters, valSet := newTestVoters(t, 4)
+	block := common.HexToHash("a1")
+
+	aggregate := func(block common.Hash, indices ...int) *AggregatedVotes {
+		votes := []Vote{}
+		for _, i := range indices {
+			votes = append(votes, voters[i].vote(block, true))
+		}
+		aggregated, err := AggregateVotes(valSet, votes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return aggregated
+	}
+
+	tampered := aggregate(block, 0, 1, 2)
+	tampered.Signature = aggregate(block, 0, 1, 3).Signature
+
+	extraVoter := aggregate(block, 0, 1, 2)
+	extraVoter.Voters[0] |= 1 << 3 // claims a vote the signature does not cover
+
+	outOfRange := aggregate(block, 0, 1, 2)
+	outOfRange.Voters[0] |= 1 << 7
+
+	wrongLength := aggregate(block, 0, 1, 2)
+	wrongLength.Voters = append(wrongLength.Voters, 0)
+
+	individualVotes := NewVoteSet()
+	individualVotes.AddVote(voters[0].vote(block, false))
+
+	noBLSKey := valSet.Copy()
+	noBLSKey.blsPubKeys = map[common.Address]common.Bytes{}
+	for _, voter := range voters[:2] {
+		noBLSKey.SetBLSPubKey(voter.address(), voter.blsKey.PublicKey().ToBytes())
+	}
+
+	tests := []struct {
+		name   string
+		cc     CommitCertificate
+		valSet *ValidatorSet
+		valid  bool
+	}{
+		{"all voters", CommitCertificate{BlockHash: block, Aggregated: aggregate(block, 0, 1, 2, 3)}, valSet, true},
+		{"three of four voters", CommitCertificate{BlockHash: block, Aggregated: aggregate(block, 1, 2, 3)}, valSet, true},
+		{"no majority", CommitCertificate{BlockHash: block, Aggregated: aggregate(block, 0, 1)}, valSet, false},
+		{"signed another block", CommitCertificate{BlockHash: block, Aggregated: aggregate(common.HexToHash("a2"), 0, 1, 2)}, valSet, false},
+		{"signature of other voters", CommitCertificate{BlockHash: block, Aggregated: tampered}, valSet, false},
+		{"voter not covered by the signature", CommitCertificate{BlockHash: block, Aggregated: extraVoter}, valSet, false},
+		{"voter beyond the validator set", CommitCertificate{BlockHash: block, Aggregated: outOfRange}, valSet, false},
+		{"bitmap of a wrong length", CommitCertificate{BlockHash: block, Aggregated: wrongLength}, valSet, false},
+		{"individual votes along the aggregate", CommitCertificate{BlockHash: block, Votes: individualVotes, Aggregated: aggregate(block, 0, 1, 2)}, valSet, false},
+		{"voter without a BLS key", CommitCertificate{BlockHash: block, Aggregated: aggregate(block, 0, 1, 2)}, noBLSKey, false},
+	}
+	for _, test := range tests {
+		assert.Equal(test.valid, test.cc.IsValid(test.valSet), test.name)
+	}
+}
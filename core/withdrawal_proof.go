@@ -0,0 +1,179 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/rlp"
+	"github.com/thetatoken/theta/store/trie"
+	scom "github.com/thetatoken/thetasubchain/common"
+	"github.com/thetatoken/thetasubchain/eth/abi"
+	scta "github.com/thetatoken/thetasubchain/interchain/contracts/accessors"
+)
+
+var (
+	ErrWithdrawalProofNotAtCheckpoint   = errors.New("withdrawal proof is not anchored at a checkpoint block")
+	ErrWithdrawalProofInvalidCommit     = errors.New("withdrawal proof does not carry a valid commit certificate for the checkpoint block")
+	ErrWithdrawalProofEventMismatch     = errors.New("proven voucher burn record does not match the claimed event")
+	ErrWithdrawalProofUnsupportedEvent  = errors.New("withdrawal proofs are only supported for voucher burn events")
+	ErrWithdrawalProofMissingCheckpoint = errors.New("withdrawal proof is missing the checkpoint header")
+	ErrWithdrawalProofWrongSourceChain  = errors.New("voucher burn event was not emitted on the chain of the checkpoint block")
+)
+
+// StateProof is a Merkle proof for a key in the ledger state trie. It shares the
+// encoding of the ValidatorSetProof used in snapshots.
+type StateProof = ValidatorSetProof
+
+// WithdrawalProof allows anyone to check that a voucher burn happened on the subchain
+// with the validator set of the checkpoint dynasty alone, without trusting the node that
+// served it. The burn record is proven against the state root of a checkpoint block, and
+// the checkpoint block is proven final by the commit certificate carried in the HCC of
+// its child. It is checked by "thetasubcli query withdrawal_proof --verify"; the token
+// banks on the mainchain still unlock the tokens on the votes of the validators.
+type WithdrawalProof struct {
+	Event            *InterChainMessageEvent // the voucher burn event on the subchain
+	CheckpointHeader *BlockHeader            // the checkpoint block whose state contains the burn record
+	CommitCert       CommitCertificate       // votes by the validators of the checkpoint dynasty
+	RecordProof      StateProof              // Merkle proof of the burn record against CheckpointHeader.StateHash
+}
+
+// VoucherBurnEventNames maps voucher burn event types to the event names in the token bank ABIs
+var VoucherBurnEventNames = map[InterChainMessageEventType]string{
+	IMCEventTypeCrossChainVoucherBurnTFuel:  "TFuelVoucherBurned",
+	IMCEventTypeCrossChainVoucherBurnTNT20:  "TNT20VoucherBurned",
+	IMCEventTypeCrossChainVoucherBurnTNT721: "TNT721VoucherBurned",
+}
+
+var voucherBurnEventSignatures = map[InterChainMessageEventType]string{
+	IMCEventTypeCrossChainVoucherBurnTFuel:  "TFuelVoucherBurned(string,address,address,uint256,uint256)",
+	IMCEventTypeCrossChainVoucherBurnTNT20:  "TNT20VoucherBurned(string,address,address,uint256,uint256)",
+	IMCEventTypeCrossChainVoucherBurnTNT721: "TNT721VoucherBurned(string,address,address,uint256,uint256)",
+}
+
+// VoucherBurnEventTopic returns the log topic of the given voucher burn event type
+func VoucherBurnEventTopic(eventType InterChainMessageEventType) common.Hash {
+	return crypto.Keccak256Hash([]byte(voucherBurnEventSignatures[eventType]))
+}
+
+// ExtractVoucherBurnEventFromLog converts a voucher burn log (given by its topics and data) emitted
+// by a token bank contract into an inter-chain message event. It returns nil if the log is not a
+// voucher burn log of the given type.
+func ExtractVoucherBurnEventFromLog(sourceChainID *big.Int, eventType InterChainMessageEventType,
+	topics []common.Hash, data common.Bytes, blockHeight uint64) (*InterChainMessageEvent, error) {
+	if len(topics) == 0 || topics[0] != VoucherBurnEventTopic(eventType) {
+		return nil, nil
+	}
+
+	var contractABI string
+	switch eventType {
+	case IMCEventTypeCrossChainVoucherBurnTFuel:
+		contractABI = scta.TFuelTokenBankABI
+	case IMCEventTypeCrossChainVoucherBurnTNT20:
+		contractABI = scta.TNT20TokenBankABI
+	case IMCEventTypeCrossChainVoucherBurnTNT721:
+		contractABI = scta.TNT721TokenBankABI
+	default:
+		return nil, ErrWithdrawalProofUnsupportedEvent
+	}
+	parsedABI, err := abi.JSON(strings.NewReader(contractABI))
+	if err != nil {
+		return nil, err
+	}
+
+	// All three voucher burn events share the same leading fields
+	var burned struct {
+		Denom                    string
+		SourceChainVoucherOwner  common.Address
+		TargetChainTokenReceiver common.Address
+		BurnedAmount             *big.Int
+		TokenID                  *big.Int
+		VoucherBurnNonce         *big.Int
+	}
+	err = parsedABI.UnpackIntoInterface(&burned, VoucherBurnEventNames[eventType], data)
+	if err != nil {
+		return nil, err
+	}
+	if burned.VoucherBurnNonce == nil {
+		return nil, fmt.Errorf("voucher burn nonce missing in log of type %v", eventType)
+	}
+	targetChainID, err := ExtractOriginatedChainIDFromDenom(burned.Denom)
+	if err != nil {
+		return nil, err
+	}
+
+	event := NewInterChainMessageEvent(eventType, sourceChainID, targetChainID, burned.SourceChainVoucherOwner,
+		burned.TargetChainTokenReceiver, data, burned.VoucherBurnNonce, new(big.Int).SetUint64(blockHeight))
+	return event, nil
+}
+
+// VoucherBurnRecordKey returns the state key for the record of a voucher burn event emitted
+// by the token bank on the given source chain. The record can be proven against a block's
+// state root.
+func VoucherBurnRecordKey(sourceChainID *big.Int, eventType InterChainMessageEventType, nonce *big.Int) common.Bytes {
+	key := common.Bytes("ls/vbr/")
+	key = append(key, common.Bytes(sourceChainID.String())...)
+	key = append(key, common.Bytes("/")...)
+	key = append(key, common.Bytes(strconv.FormatUint(uint64(eventType), 10))...)
+	key = append(key, common.Bytes("/")...)
+	key = append(key, common.Bytes(nonce.String())...)
+	return key
+}
+
+// VerifyWithdrawalProof checks the proof against the validator set of the checkpoint dynasty
+// (as registered on the mainchain), and returns the proven voucher burn event. The state key
+// of the record is derived from the claimed event and the chain ID of the checkpoint block,
+// so a proof of one record cannot be passed off as the proof of another.
+func VerifyWithdrawalProof(proof *WithdrawalProof, validatorSet *ValidatorSet) (*InterChainMessageEvent, error) {
+	event := proof.Event
+	if event == nil || VoucherBurnEventNames[event.Type] == "" {
+		return nil, ErrWithdrawalProofUnsupportedEvent
+	}
+	if event.SourceChainID == nil || event.TargetChainID == nil || event.Nonce == nil || event.BlockHeight == nil {
+		return nil, ErrWithdrawalProofEventMismatch
+	}
+	header := proof.CheckpointHeader
+	if header == nil {
+		return nil, ErrWithdrawalProofMissingCheckpoint
+	}
+	sourceChainID := scom.MapChainID(header.ChainID)
+	if event.SourceChainID.Cmp(sourceChainID) != 0 {
+		return nil, ErrWithdrawalProofWrongSourceChain
+	}
+	if !common.IsCheckPointHeight(header.Height) {
+		return nil, ErrWithdrawalProofNotAtCheckpoint
+	}
+	if proof.CommitCert.BlockHash != header.Hash() || !proof.CommitCert.IsValid(validatorSet) {
+		return nil, ErrWithdrawalProofInvalidCommit
+	}
+
+	recordKey := VoucherBurnRecordKey(sourceChainID, event.Type, event.Nonce)
+	serializedRecord, _, err := trie.VerifyProof(header.StateHash, recordKey, &proof.RecordProof)
+	if err != nil {
+		return nil, err
+	}
+	if len(serializedRecord) == 0 {
+		return nil, ErrWithdrawalProofEventMismatch // the proof shows the record is absent
+	}
+	record := &InterChainMessageEvent{}
+	err = rlp.DecodeBytes(serializedRecord, record)
+	if err != nil {
+		return nil, err
+	}
+	if record.Type != event.Type ||
+		record.SourceChainID.Cmp(event.SourceChainID) != 0 ||
+		record.TargetChainID.Cmp(event.TargetChainID) != 0 ||
+		record.Nonce.Cmp(event.Nonce) != 0 ||
+		record.Receiver != event.Receiver ||
+		string(record.Data) != string(event.Data) {
+		return nil, ErrWithdrawalProofEventMismatch
+	}
+	if record.BlockHeight.Uint64() > header.Height {
+		return nil, ErrWithdrawalProofEventMismatch
+	}
+	return record, nil
+}
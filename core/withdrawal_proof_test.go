@@ -0,0 +1,259 @@
+package core
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/rlp"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/trie"
+	scom "github.com/thetatoken/thetasubchain/common"
+	"github.com/thetatoken/thetasubchain/eth/abi"
+	scta "github.com/thetatoken/thetasubchain/interchain/contracts/accessors"
+)
+
+const testWithdrawalChainID = "tsub360777"
+
+var testMainchainID = big.NewInt(366)
+
+func newTestVoucherBurnEvent(nonce int64) *InterChainMessageEvent {
+	return NewInterChainMessageEvent(IMCEventTypeCrossChainVoucherBurnTFuel, scom.MapChainID(testWithdrawalChainID),
+		testMainchainID, common.HexToAddress("a1"), common.HexToAddress("b1"), common.Bytes("burn"),
+		big.NewInt(nonce), big.NewInt(90))
+}
+
+// newTestStateTrie creates a state trie holding the voucher burn records of the given events
+func newTestStateTrie(t *testing.T, events ...*InterChainMessageEvent) *trie.Trie {
+	tr, err := trie.New(common.Hash{}, trie.NewDatabase(backend.NewMemDatabase()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range events {
+		raw, err := rlp.EncodeToBytes(event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr.Update(VoucherBurnRecordKey(event.SourceChainID, event.Type, event.Nonce), raw)
+	}
+	return tr
+}
+
+func proveVoucherBurnRecord(t *testing.T, tr *trie.Trie, event *InterChainMessageEvent) StateProof {
+	proof := StateProof{}
+	if err := tr.Prove(VoucherBurnRecordKey(event.SourceChainID, event.Type, event.Nonce), 0, &proof); err != nil {
+		t.Fatal(err)
+	}
+	return proof
+}
+
+// certify creates the commit certificate of the header with the votes of the given voters, aggregated or not
+func certify(t *testing.T, voters []testVoter, valSet *ValidatorSet, header *BlockHeader, aggregated bool, indices ...int) CommitCertificate {
+	votes := []Vote{}
+	for _, i := range indices {
+		votes = append(votes, voters[i].vote(header.Hash(), aggregated))
+	}
+	if aggregated {
+		aggregatedVotes, err := AggregateVotes(valSet, votes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return CommitCertificate{BlockHash: header.Hash(), Aggregated: aggregatedVotes}
+	}
+	voteSet := NewVoteSet()
+	for _, vote := range votes {
+		voteSet.AddVote(vote)
+	}
+	return CommitCertificate{BlockHash: header.Hash(), Votes: voteSet}
+}
+
+// newTestWithdrawalProof proves the voucher burn with nonce 3, recorded along with the one with nonce 4 in the
+// state of a checkpoint block, certified by three of the four validators
+func newTestWithdrawalProof(t *testing.T, voters []testVoter, valSet *ValidatorSet) *WithdrawalProof {
+	event := newTestVoucherBurnEvent(3)
+	tr := newTestStateTrie(t, event, newTestVoucherBurnEvent(4))
+
+	header := NewBlock().BlockHeader
+	header.ChainID = testWithdrawalChainID
+	header.Height = uint64(common.CheckpointInterval)
+	header.StateHash = tr.Hash()
+	header.Timestamp = big.NewInt(1700000000)
+
+	return &WithdrawalProof{
+		Event:            event,
+		CheckpointHeader: header,
+		CommitCert:       certify(t, voters, valSet, header, false, 0, 1, 2),
+		RecordProof:      proveVoucherBurnRecord(t, tr, event),
+	}
+}
+
+func TestVerifyWithdrawalProof(t *testing.T) {
+	assert := assert.New(t)
+
+	voters, valSet := newTestVoters(t, 4)
+	otherVoters, otherValSet := newTestVoters(t, 4)
+
+	noBLSKey := valSet.Copy()
+	noBLSKey.blsPubKeys = map[common.Address]common.Bytes{}
+
+	tests := []struct {
+		name   string
+		modify func(proof *WithdrawalProof)
+		valSet *ValidatorSet
+		err    error
+		valid  bool
+	}{
+		{"valid proof", func(proof *WithdrawalProof) {}, valSet, nil, true},
+		{"valid proof with an aggregated commit certificate", func(proof *WithdrawalProof) {
+			proof.CommitCert = certify(t, voters, valSet, proof.CheckpointHeader, true, 1, 2, 3)
+		}, valSet, nil, true},
+		{"missing event", func(proof *WithdrawalProof) {
+			proof.Event = nil
+		}, valSet, ErrWithdrawalProofUnsupportedEvent, false},
+		{"not a voucher burn event", func(proof *WithdrawalProof) {
+			proof.Event.Type = IMCEventTypeCrossChainTokenLockTFuel
+		}, valSet, ErrWithdrawalProofUnsupportedEvent, false},
+		{"missing checkpoint header", func(proof *WithdrawalProof) {
+			proof.CheckpointHeader = nil
+		}, valSet, ErrWithdrawalProofMissingCheckpoint, false},
+		{"event of another chain", func(proof *WithdrawalProof) {
+			proof.Event.SourceChainID = scom.MapChainID("tsub360888")
+		}, valSet, ErrWithdrawalProofWrongSourceChain, false},
+		{"checkpoint header of another chain", func(proof *WithdrawalProof) {
+			proof.CheckpointHeader.ChainID = "tsub360888"
+			proof.CommitCert = certify(t, voters, valSet, proof.CheckpointHeader, false, 0, 1, 2)
+		}, valSet, ErrWithdrawalProofWrongSourceChain, false},
+		{"not a checkpoint", func(proof *WithdrawalProof) {
+			proof.CheckpointHeader.Height++
+			proof.CommitCert = certify(t, voters, valSet, proof.CheckpointHeader, false, 0, 1, 2)
+		}, valSet, ErrWithdrawalProofNotAtCheckpoint, false},
+		{"votes of a minority", func(proof *WithdrawalProof) {
+			proof.CommitCert = certify(t, voters, valSet, proof.CheckpointHeader, false, 0, 1)
+		}, valSet, ErrWithdrawalProofInvalidCommit, false},
+		{"votes for another block", func(proof *WithdrawalProof) {
+			other := *proof.CheckpointHeader
+			other.Epoch++
+			proof.CommitCert = certify(t, voters, valSet, &other, false, 0, 1, 2)
+		}, valSet, ErrWithdrawalProofInvalidCommit, false},
+		{"votes of another validator set", func(proof *WithdrawalProof) {
+			proof.CommitCert = certify(t, otherVoters, otherValSet, proof.CheckpointHeader, false, 0, 1, 2)
+		}, valSet, ErrWithdrawalProofInvalidCommit, false},
+		{"aggregated votes of another validator set", func(proof *WithdrawalProof) {
+			proof.CommitCert = certify(t, otherVoters, otherValSet, proof.CheckpointHeader, true, 0, 1, 2)
+		}, valSet, ErrWithdrawalProofInvalidCommit, false},
+		{"aggregated votes of a minority", func(proof *WithdrawalProof) {
+			proof.CommitCert = certify(t, voters, valSet, proof.CheckpointHeader, true, 0, 1)
+		}, valSet, ErrWithdrawalProofInvalidCommit, false},
+		{"aggregated votes without the BLS keys", func(proof *WithdrawalProof) {
+			proof.CommitCert = certify(t, voters, valSet, proof.CheckpointHeader, true, 0, 1, 2)
+		}, noBLSKey, ErrWithdrawalProofInvalidCommit, false},
+		{"record proof against another state", func(proof *WithdrawalProof) {
+			other := newTestVoucherBurnEvent(3)
+			other.Receiver = common.HexToAddress("b2")
+			proof.RecordProof = proveVoucherBurnRecord(t, newTestStateTrie(t, other), other)
+		}, valSet, nil, false},
+		{"empty record proof", func(proof *WithdrawalProof) {
+			proof.RecordProof = StateProof{}
+		}, valSet, nil, false},
+		{"proof of another nonce", func(proof *WithdrawalProof) {
+			proof.Event.Nonce = big.NewInt(4)
+		}, valSet, nil, false},
+		{"proof of an absent nonce", func(proof *WithdrawalProof) {
+			proof.Event.Nonce = big.NewInt(5)
+		}, valSet, nil, false},
+		{"claimed receiver differs from the record", func(proof *WithdrawalProof) {
+			proof.Event.Receiver = common.HexToAddress("b2")
+		}, valSet, ErrWithdrawalProofEventMismatch, false},
+		{"claimed data differs from the record", func(proof *WithdrawalProof) {
+			proof.Event.Data = common.Bytes("other")
+		}, valSet, ErrWithdrawalProofEventMismatch, false},
+	}
+	for _, test := range tests {
+		proof := newTestWithdrawalProof(t, voters, valSet)
+		test.modify(proof)
+		record, err := VerifyWithdrawalProof(proof, test.valSet)
+		if test.valid {
+			assert.Nil(err, test.name)
+			assert.Equal(newTestVoucherBurnEvent(3).ID(), record.ID(), test.name)
+			assert.Equal(common.HexToAddress("b1"), record.Receiver, test.name)
+			continue
+		}
+		assert.NotNil(err, test.name)
+		assert.Nil(record, test.name)
+		if test.err != nil {
+			assert.Equal(test.err, err, test.name)
+		}
+	}
+}
+
+func TestExtractVoucherBurnEventFromLog(t *testing.T) {
+	assert := assert.New(t)
+
+	sourceChainID := scom.MapChainID(testWithdrawalChainID)
+	owner := common.HexToAddress("a1")
+	receiver := common.HexToAddress("b1")
+
+	pack := func(contractABI, eventName string, args ...interface{}) common.Bytes {
+		parsedABI, err := abi.JSON(strings.NewReader(contractABI))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := parsedABI.Events[eventName].Inputs.Pack(args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	tfuelData := pack(scta.TFuelTokenBankABI, "TFuelVoucherBurned", TFuelDenom(testMainchainID), owner, receiver,
+		big.NewInt(1000), big.NewInt(3))
+	tnt721Data := pack(scta.TNT721TokenBankABI, "TNT721VoucherBurned", TNT721Denom(testMainchainID, common.HexToAddress("c1")),
+		owner, receiver, big.NewInt(42), big.NewInt(7))
+	invalidDenomData := pack(scta.TFuelTokenBankABI, "TFuelVoucherBurned", "tfuel", owner, receiver,
+		big.NewInt(1000), big.NewInt(3))
+
+	tfuelTopic := VoucherBurnEventTopic(IMCEventTypeCrossChainVoucherBurnTFuel)
+	tnt721Topic := VoucherBurnEventTopic(IMCEventTypeCrossChainVoucherBurnTNT721)
+
+	tests := []struct {
+		name      string
+		eventType InterChainMessageEventType
+		topics    []common.Hash
+		data      common.Bytes
+		nonce     int64
+		isError   bool
+	}{
+		{"TFuel voucher burn", IMCEventTypeCrossChainVoucherBurnTFuel, []common.Hash{tfuelTopic}, tfuelData, 3, false},
+		{"TNT721 voucher burn", IMCEventTypeCrossChainVoucherBurnTNT721, []common.Hash{tnt721Topic}, tnt721Data, 7, false},
+		{"no topic", IMCEventTypeCrossChainVoucherBurnTFuel, []common.Hash{}, tfuelData, 0, false},
+		{"another event", IMCEventTypeCrossChainVoucherBurnTFuel, []common.Hash{common.HexToHash("d1")}, tfuelData, 0, false},
+		{"voucher burn of another type", IMCEventTypeCrossChainVoucherBurnTFuel, []common.Hash{tnt721Topic}, tnt721Data, 0, false},
+		{"not a voucher burn type", IMCEventTypeCrossChainTokenLockTFuel,
+			[]common.Hash{VoucherBurnEventTopic(IMCEventTypeCrossChainTokenLockTFuel)}, tfuelData, 0, true},
+		{"truncated data", IMCEventTypeCrossChainVoucherBurnTFuel, []common.Hash{tfuelTopic}, tfuelData[:64], 0, true},
+		{"invalid denom", IMCEventTypeCrossChainVoucherBurnTFuel, []common.Hash{tfuelTopic}, invalidDenomData, 0, true},
+	}
+	for _, test := range tests {
+		event, err := ExtractVoucherBurnEventFromLog(sourceChainID, test.eventType, test.topics, test.data, 90)
+		if test.isError {
+			assert.NotNil(err, test.name)
+			assert.Nil(event, test.name)
+			continue
+		}
+		assert.Nil(err, test.name)
+		if test.nonce == 0 {
+			assert.Nil(event, test.name)
+			continue
+		}
+		assert.NotNil(event, test.name)
+		assert.Equal(test.eventType, event.Type, test.name)
+		assert.Equal(0, sourceChainID.Cmp(event.SourceChainID), test.name)
+		assert.Equal(0, testMainchainID.Cmp(event.TargetChainID), test.name)
+		assert.Equal(owner, event.Sender, test.name)
+		assert.Equal(receiver, event.Receiver, test.name)
+		assert.Equal(test.data, event.Data, test.name)
+		assert.Equal(0, big.NewInt(test.nonce).Cmp(event.Nonce), test.name)
+		assert.Equal(uint64(90), event.BlockHeight.Uint64(), test.name)
+	}
+}
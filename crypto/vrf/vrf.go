@@ -0,0 +1,201 @@
+// Package vrf implements an ECVRF over secp256k1 (in the spirit of RFC 9381, with a try-and-increment
+// hash to curve and Keccak256 as the hash function). Given the private key and an input, the prover
+// obtains a unique output and a proof which anyone holding the public key can verify.
+package vrf
+
+import (
+	"errors"
+	"math/big"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+)
+
+const vrfSuite = "THETA-SUBCHAIN-ECVRF-SECP256K1-KECCAK256"
+
+const (
+	vrfPointLen  = 65 // uncompressed point encoding
+	vrfScalarLen = 32
+
+	// ProofLen is the length of a VRF proof: public key || gamma || c || s
+	ProofLen = 2*vrfPointLen + 2*vrfScalarLen
+)
+
+const (
+	vrfDomainHashToCurve byte = 0x01
+	vrfDomainNonce       byte = 0x02
+	vrfDomainChallenge   byte = 0x03
+	vrfDomainOutput      byte = 0x04
+)
+
+var (
+	errInvalidProof = errors.New("invalid VRF proof")
+	vrfCurve        = ethcrypto.S256()
+)
+
+type vrfPoint struct {
+	x, y *big.Int
+}
+
+// Prove computes the VRF proof of the input with the private key
+func Prove(privateKey *crypto.PrivateKey, alpha []byte) (common.Bytes, error) {
+	n := vrfCurve.Params().N
+	skBytes := privateKey.ToBytes()
+	sk := new(big.Int).SetBytes(skBytes)
+	pkBytes := privateKey.PublicKey().ToBytes()
+
+	h, err := vrfHashToCurve(pkBytes, alpha)
+	if err != nil {
+		return nil, err
+	}
+	gamma := vrfScalarMult(h, sk)
+
+	// Deterministic nonce, so that the same input never leaks the key through two different nonces
+	k := new(big.Int).SetBytes(ethcrypto.Keccak256([]byte(vrfSuite), []byte{vrfDomainNonce}, skBytes, vrfMarshalPoint(h)))
+	k.Mod(k, n)
+	if k.Sign() == 0 {
+		return nil, errors.New("failed to generate the VRF nonce")
+	}
+	u := vrfScalarBaseMult(k)
+	v := vrfScalarMult(h, k)
+
+	c := vrfChallenge(h, gamma, u, v)
+	s := new(big.Int).Mul(c, sk)
+	s.Add(s, k)
+	s.Mod(s, n)
+
+	proof := make([]byte, 0, ProofLen)
+	proof = append(proof, pkBytes...)
+	proof = append(proof, vrfMarshalPoint(gamma)...)
+	proof = append(proof, c.FillBytes(make([]byte, vrfScalarLen))...)
+	proof = append(proof, s.FillBytes(make([]byte, vrfScalarLen))...)
+	return proof, nil
+}
+
+// Verify verifies the VRF proof of the input, and returns the address of the prover along with the VRF output
+func Verify(proof common.Bytes, alpha []byte) (common.Address, common.Hash, error) {
+	if len(proof) != ProofLen {
+		return common.Address{}, common.Hash{}, errInvalidProof
+	}
+	n := vrfCurve.Params().N
+	pkBytes := proof[:vrfPointLen]
+	gammaBytes := proof[vrfPointLen : 2*vrfPointLen]
+	c := new(big.Int).SetBytes(proof[2*vrfPointLen : 2*vrfPointLen+vrfScalarLen])
+	s := new(big.Int).SetBytes(proof[2*vrfPointLen+vrfScalarLen:])
+
+	pk, ok := vrfUnmarshalPoint(pkBytes)
+	if !ok {
+		return common.Address{}, common.Hash{}, errInvalidProof
+	}
+	gamma, ok := vrfUnmarshalPoint(gammaBytes)
+	if !ok {
+		return common.Address{}, common.Hash{}, errInvalidProof
+	}
+	if c.Sign() == 0 || c.Cmp(n) >= 0 || s.Sign() == 0 || s.Cmp(n) >= 0 {
+		return common.Address{}, common.Hash{}, errInvalidProof
+	}
+
+	h, err := vrfHashToCurve(pkBytes, alpha)
+	if err != nil {
+		return common.Address{}, common.Hash{}, err
+	}
+
+	// U = s*G - c*PK, V = s*H - c*Gamma
+	negC := new(big.Int).Sub(n, c)
+	u := vrfAdd(vrfScalarBaseMult(s), vrfScalarMult(pk, negC))
+	v := vrfAdd(vrfScalarMult(h, s), vrfScalarMult(gamma, negC))
+	if vrfChallenge(h, gamma, u, v).Cmp(c) != 0 {
+		return common.Address{}, common.Hash{}, errInvalidProof
+	}
+
+	publicKey, err := crypto.PublicKeyFromBytes(pkBytes)
+	if err != nil {
+		return common.Address{}, common.Hash{}, errInvalidProof
+	}
+	return publicKey.Address(), vrfOutput(gammaBytes), nil
+}
+
+// Output returns the VRF output of a proof, without verifying the proof
+func Output(proof common.Bytes) (common.Hash, error) {
+	if len(proof) != ProofLen {
+		return common.Hash{}, errInvalidProof
+	}
+	return vrfOutput(proof[vrfPointLen : 2*vrfPointLen]), nil
+}
+
+func vrfOutput(gammaBytes []byte) common.Hash {
+	return common.BytesToHash(ethcrypto.Keccak256([]byte(vrfSuite), []byte{vrfDomainOutput}, gammaBytes))
+}
+
+// vrfHashToCurve maps the public key and the input to a curve point with the try-and-increment method
+func vrfHashToCurve(pkBytes []byte, alpha []byte) (vrfPoint, error) {
+	p := vrfCurve.Params().P
+	exp := new(big.Int).Add(p, big.NewInt(1))
+	exp.Rsh(exp, 2) // p = 3 mod 4, so sqrt(a) = a^((p+1)/4)
+	for ctr := 0; ctr < 256; ctr++ {
+		digest := ethcrypto.Keccak256([]byte(vrfSuite), []byte{vrfDomainHashToCurve}, pkBytes, alpha, []byte{byte(ctr)})
+		x := new(big.Int).SetBytes(digest)
+		if x.Cmp(p) >= 0 {
+			continue
+		}
+		// y^2 = x^3 + 7
+		rhs := new(big.Int).Exp(x, big.NewInt(3), p)
+		rhs.Add(rhs, big.NewInt(7))
+		rhs.Mod(rhs, p)
+		y := new(big.Int).Exp(rhs, exp, p)
+		if new(big.Int).Exp(y, big.NewInt(2), p).Cmp(rhs) != 0 {
+			continue
+		}
+		if y.Bit(0) == 1 {
+			y.Sub(p, y)
+		}
+		return vrfPoint{x: x, y: y}, nil
+	}
+	return vrfPoint{}, errors.New("failed to hash the VRF input to the curve")
+}
+
+func vrfChallenge(points ...vrfPoint) *big.Int {
+	data := [][]byte{[]byte(vrfSuite), {vrfDomainChallenge}}
+	for _, pt := range points {
+		data = append(data, vrfMarshalPoint(pt))
+	}
+	c := new(big.Int).SetBytes(ethcrypto.Keccak256(data...))
+	return c.Mod(c, vrfCurve.Params().N)
+}
+
+func vrfScalarMult(pt vrfPoint, k *big.Int) vrfPoint {
+	x, y := vrfCurve.ScalarMult(pt.x, pt.y, k.FillBytes(make([]byte, vrfScalarLen)))
+	return vrfPoint{x: x, y: y}
+}
+
+func vrfScalarBaseMult(k *big.Int) vrfPoint {
+	x, y := vrfCurve.ScalarBaseMult(k.FillBytes(make([]byte, vrfScalarLen)))
+	return vrfPoint{x: x, y: y}
+}
+
+func vrfAdd(a, b vrfPoint) vrfPoint {
+	x, y := vrfCurve.Add(a.x, a.y, b.x, b.y)
+	return vrfPoint{x: x, y: y}
+}
+
+func vrfMarshalPoint(pt vrfPoint) []byte {
+	ret := make([]byte, vrfPointLen)
+	ret[0] = 0x04
+	pt.x.FillBytes(ret[1 : 1+vrfScalarLen])
+	pt.y.FillBytes(ret[1+vrfScalarLen:])
+	return ret
+}
+
+func vrfUnmarshalPoint(data []byte) (vrfPoint, bool) {
+	if len(data) != vrfPointLen || data[0] != 0x04 {
+		return vrfPoint{}, false
+	}
+	p := vrfCurve.Params().P
+	x := new(big.Int).SetBytes(data[1 : 1+vrfScalarLen])
+	y := new(big.Int).SetBytes(data[1+vrfScalarLen:])
+	if x.Cmp(p) >= 0 || y.Cmp(p) >= 0 || !vrfCurve.IsOnCurve(x, y) {
+		return vrfPoint{}, false
+	}
+	return vrfPoint{x: x, y: y}, true
+}
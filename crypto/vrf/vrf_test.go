@@ -0,0 +1,142 @@
+package vrf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+)
+
+// Known answer computed with an independent implementation of the same construction
+const (
+	katPrivateKey = "93a90ea508331dfdf27fb79757d4250b4e84954927ba0073cd67454ac432c737"
+	katAlpha      = "theta subchain"
+	katAddress    = "0x2e833968e5bb786ae419c4d13189fb081cc43bab"
+	katProof      = "048e8d53fd435265ad074597cc3e202f8e935cfb57925bb51316252027cb08767fb8099226414732543c4b5cbaa64b4ee8f173ba559258a0b5f633a0d11509e78b048f4a7872c41bbb32ac56304ad8c5296a9aeaeb435436ca83c7f8a4365945ffe17654feaf05498b88bfce17556a08670c0278a3993dcb0bd6ee273310b5aaa66e6cc73c651ee3c7e50f0680fc467311b11caa97052898ce344e3ae5afcacd22285fe0f013b680e61f6194b71820418cbd3496d70c4add1a2d92f91906116461cf"
+	katOutput     = "0x68ba771fc281a272cbf1213b8f2946f3ad837a800cc70f88368911634ef176cc"
+)
+
+func TestVRFKnownAnswer(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, err := crypto.PrivateKeyFromBytes(common.Hex2Bytes(katPrivateKey))
+	assert.Nil(err)
+
+	proof, err := Prove(privKey, []byte(katAlpha))
+	assert.Nil(err)
+	assert.Equal(katProof, common.Bytes2Hex(proof))
+
+	address, output, err := Verify(proof, []byte(katAlpha))
+	assert.Nil(err)
+	assert.Equal(common.HexToAddress(katAddress), address)
+	assert.Equal(common.HexToHash(katOutput), output)
+}
+
+func TestVRFRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+
+	for _, alpha := range [][]byte{nil, []byte("a"), common.HexToHash("0x1234").Bytes()} {
+		proof, err := Prove(privKey, alpha)
+		assert.Nil(err)
+		assert.Equal(ProofLen, len(proof))
+
+		address, output, err := Verify(proof, alpha)
+		assert.Nil(err)
+		assert.Equal(privKey.PublicKey().Address(), address)
+
+		unverified, err := Output(proof)
+		assert.Nil(err)
+		assert.Equal(output, unverified)
+	}
+}
+
+func TestVRFTamperedProof(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+	alpha := []byte("alpha")
+	proof, err := Prove(privKey, alpha)
+	assert.Nil(err)
+
+	tests := []struct {
+		name   string
+		offset int
+	}{
+		{"public key", 1},
+		{"gamma", vrfPointLen + 1},
+		{"c", 2 * vrfPointLen},
+		{"s", 2*vrfPointLen + vrfScalarLen},
+		{"last byte", ProofLen - 1},
+	}
+	for _, test := range tests {
+		tampered := append(common.Bytes{}, proof...)
+		tampered[test.offset] ^= 0x01
+		_, _, err := Verify(tampered, alpha)
+		assert.NotNil(err, test.name)
+	}
+
+	_, _, err = Verify(proof[:ProofLen-1], alpha)
+	assert.NotNil(err)
+	_, _, err = Verify(append(proof, 0x00), alpha)
+	assert.NotNil(err)
+	_, err = Output(proof[:ProofLen-1])
+	assert.NotNil(err)
+}
+
+func TestVRFWrongKeyOrInput(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey1, _, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+	privKey2, _, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+	alpha := []byte("alpha")
+
+	proof, err := Prove(privKey1, alpha)
+	assert.Nil(err)
+
+	// Presenting the proof under another public key
+	forged := append(common.Bytes{}, proof...)
+	copy(forged[:vrfPointLen], privKey2.PublicKey().ToBytes())
+	_, _, err = Verify(forged, alpha)
+	assert.NotNil(err)
+
+	// The proof does not hold for another input
+	_, _, err = Verify(proof, []byte("beta"))
+	assert.NotNil(err)
+}
+
+func TestVRFUniqueness(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey1, _, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+	privKey2, _, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+
+	proof1, err := Prove(privKey1, []byte("alpha"))
+	assert.Nil(err)
+	proof2, err := Prove(privKey1, []byte("alpha"))
+	assert.Nil(err)
+	assert.Equal(proof1, proof2)
+
+	_, output1, err := Verify(proof1, []byte("alpha"))
+	assert.Nil(err)
+
+	proof3, err := Prove(privKey1, []byte("beta"))
+	assert.Nil(err)
+	_, output3, err := Verify(proof3, []byte("beta"))
+	assert.Nil(err)
+	assert.NotEqual(output1, output3)
+
+	proof4, err := Prove(privKey2, []byte("alpha"))
+	assert.Nil(err)
+	_, output4, err := Verify(proof4, []byte("alpha"))
+	assert.Nil(err)
+	assert.NotEqual(output1, output4)
+}
@@ -10,6 +10,7 @@ import (
 	"io/ioutil"
 	"math/big"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -51,15 +52,25 @@ type Validator struct {
 // subchain_generate_genesis -mainchainID=privatenet -subchainID=tsub360777 -initValidatorSet=./data/init_validator_set.json -feeSetter=0x2E833968E5bB786Ae419c4d13189fB081Cc43bab -genesis=./genesis
 //
 func main() {
-	mainchainID, subchainID, initValidatorSetPath, genesisSnapshotFilePath, feeSetter := parseArguments()
+	mainchainID, subchainID, initValidatorSetPath, genesisSnapshotFilePath, feeSetter, blockGasLimit, numMainchainBlocksPerDynasty, dynastyLengthChanges, rewardSchedule, forks := parseArguments()
 
-	db, sv, metadata, err := generateGenesisSnapshot(mainchainID, subchainID, initValidatorSetPath, genesisSnapshotFilePath, feeSetter)
+	// the initial smart contracts are deployed under the fork schedule of the subchain
+	if err := scom.InitForkSchedule(forks); err != nil {
+		panic(fmt.Sprintf("Invalid fork schedule: %v", err))
+	}
+
+	db, sv, metadata, err := generateGenesisSnapshot(mainchainID, subchainID, initValidatorSetPath, genesisSnapshotFilePath, feeSetter, blockGasLimit, numMainchainBlocksPerDynasty, dynastyLengthChanges, rewardSchedule, forks)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to generate genesis snapshot: %v", err))
 	}
 
 	logger.Infof("-----------------------------------------------------------------------------")
 	logger.Infof("Cross-chain fee setter: %v", feeSetter)
+	logger.Infof("Block gas limit: %v", blockGasLimit)
+	logger.Infof("Mainchain blocks per dynasty: %v", numMainchainBlocksPerDynasty)
+	logger.Infof("Dynasty length changes: %v", dynastyLengthChanges)
+	logger.Infof("Reward schedule: %v", rewardSchedule)
+	logger.Infof("Forks: %v", forks)
 	err = sanityChecks(sv)
 	logger.Infof("-----------------------------------------------------------------------------")
 
@@ -84,12 +95,18 @@ func main() {
 	fmt.Println("")
 }
 
-func parseArguments() (mainchainID, subchainID, initValidatorSetPath, genesisSnapshotFilePath string, feeSetter common.Address) {
+func parseArguments() (mainchainID, subchainID, initValidatorSetPath, genesisSnapshotFilePath string, feeSetter common.Address, blockGasLimit uint64, numMainchainBlocksPerDynasty int64, dynastyLengthChanges []scom.DynastyLengthChange, rewardSchedule *score.RewardSchedule, forks []scom.Fork) {
 	mainchainIDPtr := flag.String("mainchainID", "privatenet", "the ID of the mainchain")
 	subchainIDPtr := flag.String("subchainID", "tsub360777", "the ID of the subchain")
 	initValidatorSetPathPtr := flag.String("initValidatorSet", "./init_validator_set.json", "the initial validator set")
 	genesisSnapshotFilePathPtr := flag.String("genesis", "./genesis", "the genesis snapshot")
 	feeSetterPtr := flag.String("feeSetter", "", "the wallet address of the fee setter")
+	blockGasLimitPtr := flag.Uint64("blockGasLimit", scom.DefaultBlockGasLimit, "the maximum total gas the smart contract transactions of a block can consume")
+	numMainchainBlocksPerDynastyPtr := flag.Int64("numMainchainBlocksPerDynasty", scom.DefaultNumMainchainBlocksPerDynasty, "the number of mainchain blocks per dynasty, must match the chain registrar on the mainchain")
+	dynastyLengthChangesPtr := flag.String("dynastyLengthChanges", "", "the dynasty length changes as comma separated mainchainHeight=numMainchainBlocksPerDynasty pairs, each mainchain height must start a dynasty")
+	governanceTokenPtr := flag.String("governanceToken", "", "the address of the subchain governance token on the mainchain, the validators are paid no rewards if not specified")
+	stakerRewardPerBlockPtr := flag.String("stakerRewardPerBlock", "0", "the staker reward per block in the subchain governance token (in wei)")
+	forksPtr := flag.String("forks", "", "the fork schedule of the subchain as comma separated name=height pairs, e.g. smartContract=0,governance=1000")
 	flag.Parse()
 
 	mainchainID = *mainchainIDPtr
@@ -97,18 +114,90 @@ func parseArguments() (mainchainID, subchainID, initValidatorSetPath, genesisSna
 	initValidatorSetPath = *initValidatorSetPathPtr
 	genesisSnapshotFilePath = *genesisSnapshotFilePathPtr
 	feeSetter = common.HexToAddress(*feeSetterPtr)
+	blockGasLimit = *blockGasLimitPtr
+	numMainchainBlocksPerDynasty = *numMainchainBlocksPerDynastyPtr
+	if numMainchainBlocksPerDynasty <= 0 {
+		panic(fmt.Sprintf("Invalid number of mainchain blocks per dynasty: %v", numMainchainBlocksPerDynasty))
+	}
+	dynastyLengthChanges = parseDynastyLengthChanges(*dynastyLengthChangesPtr)
+	if _, err := scom.NewDynastyScheduleWithChanges(numMainchainBlocksPerDynasty, dynastyLengthChanges); err != nil {
+		panic(fmt.Sprintf("Invalid dynasty length changes: %v", err))
+	}
+
+	if *governanceTokenPtr != "" {
+		stakerRewardPerBlock, ok := new(big.Int).SetString(*stakerRewardPerBlockPtr, 10)
+		if !ok || stakerRewardPerBlock.Sign() < 0 {
+			panic(fmt.Sprintf("Invalid staker reward per block: %v", *stakerRewardPerBlockPtr))
+		}
+		rewardSchedule = score.NewRewardSchedule(common.HexToAddress(*governanceTokenPtr), stakerRewardPerBlock)
+	}
+
+	forks = parseForks(*forksPtr)
 
 	return
 }
 
+func parseDynastyLengthChanges(changesStr string) []scom.DynastyLengthChange {
+	changes := []scom.DynastyLengthChange{}
+	if len(changesStr) == 0 {
+		return changes
+	}
+	for _, pair := range strings.Split(changesStr, ",") {
+		parts := strings.Split(strings.TrimSpace(pair), "=")
+		if len(parts) != 2 {
+			panic(fmt.Sprintf("Invalid dynasty length change: %v, expected mainchainHeight=numMainchainBlocksPerDynasty", pair))
+		}
+		height, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			panic(fmt.Sprintf("Invalid mainchain height of dynasty length change: %v", parts[0]))
+		}
+		numBlocks, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			panic(fmt.Sprintf("Invalid number of mainchain blocks per dynasty at mainchain height %v: %v", parts[0], parts[1]))
+		}
+		changes = append(changes, scom.DynastyLengthChange{MainchainHeight: height, NumMainchainBlocksPerDynasty: numBlocks})
+	}
+	return changes
+}
+
+func parseForks(forksStr string) []scom.Fork {
+	forks := []scom.Fork{}
+	if len(forksStr) == 0 {
+		return forks
+	}
+	for _, pair := range strings.Split(forksStr, ",") {
+		parts := strings.Split(strings.TrimSpace(pair), "=")
+		if len(parts) != 2 {
+			panic(fmt.Sprintf("Invalid fork: %v, expected name=height", pair))
+		}
+		height, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			panic(fmt.Sprintf("Invalid height of fork %v: %v", parts[0], parts[1]))
+		}
+		forks = append(forks, scom.Fork{Name: parts[0], Height: height})
+	}
+	return forks
+}
+
 // generateGenesisSnapshot generates the genesis snapshot.
-func generateGenesisSnapshot(mainchainID, subchainID, initValidatorSetFilePath, genesisSnapshotFilePath string, feeSetter common.Address) (database.Database, *slst.StoreView, *score.SnapshotMetadata, error) {
+func generateGenesisSnapshot(mainchainID, subchainID, initValidatorSetFilePath, genesisSnapshotFilePath string, feeSetter common.Address, blockGasLimit uint64, numMainchainBlocksPerDynasty int64, dynastyLengthChanges []scom.DynastyLengthChange, rewardSchedule *score.RewardSchedule, forks []scom.Fork) (database.Database, *slst.StoreView, *score.SnapshotMetadata, error) {
 	metadata := &score.SnapshotMetadata{}
 	genesisHeight := score.GenesisBlockHeight
 
 	db := backend.NewMemDatabase()
 	sv := slst.NewStoreView(0, common.Hash{}, db)
 
+	sv.SetBlockGasLimit(blockGasLimit)
+	sv.SetNumMainchainBlocksPerDynasty(numMainchainBlocksPerDynasty)
+	if len(dynastyLengthChanges) > 0 {
+		sv.SetGenesisDynastyLengthChanges(dynastyLengthChanges)
+	}
+	if len(forks) > 0 {
+		sv.SetGenesisForks(forks)
+	}
+	if rewardSchedule != nil {
+		sv.SetRewardSchedule(rewardSchedule)
+	}
 	setInitialValidatorSet(subchainID, initValidatorSetFilePath, genesisHeight, sv)
 	deployInitialSmartContracts(mainchainID, subchainID, feeSetter, sv)
 
@@ -203,7 +292,7 @@ func deployInitialSmartContracts(mainchainID, subchainID string, feeSetter commo
 	//
 
 	sequence := 0
-	numMainchainBlockPerDynastyBigInt := big.NewInt(scom.NumMainchainBlocksPerDynasty)
+	numMainchainBlockPerDynastyBigInt := big.NewInt(sv.GetNumMainchainBlocksPerDynasty())
 	dec18, _ := big.NewInt(0).SetString("1000000000000000000", 10)
 	initialCrossChainFee := big.NewInt(0).Mul(big.NewInt(10), dec18)
 	chainRegistrarContractAddr, err := deploySmartContract(subchainID, sv, addConstructorArgumentForChainRegistrarBytecode(predeployed.ChainRegistrarContractBytecode, numMainchainBlockPerDynastyBigInt, initialCrossChainFee, feeSetter),
@@ -318,7 +407,7 @@ func deploySmartContract(subchainID string, sv *slst.StoreView, contractBytecode
 		GasPrice: dummyGasPrice,
 		Data:     contractBytecode,
 	}
-	parentBlockInfo := svm.NewBlockInfo(0, big.NewInt(0), subchainID)
+	parentBlockInfo := svm.NewBlockInfo(0, big.NewInt(0), subchainID, sv.GetBlockGasLimit(sv.Height()+1))
 	_, contractAddr, _, evmErr := svm.Execute(parentBlockInfo, &deploySCTx, sv)
 	if evmErr != nil {
 		return common.Address{}, evmErr
@@ -0,0 +1,428 @@
+package orchestrator
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/thetasubchain/eth/core/types"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+)
+
+// relay txs without a receipt after this period are considered dropped
+const relayTxReceiptTimeout = 1 * time.Hour
+
+// RelayCostStats summarizes the gas spent by this validator to relay one type of events from a source chain to a target chain
+type RelayCostStats struct {
+	SourceChainID       *big.Int                         `json:"source_chain_id"`
+	TargetChainID       *big.Int                         `json:"target_chain_id"`
+	EventType           score.InterChainMessageEventType `json:"event_type"`
+	NumRelays           uint64                           `json:"num_relays"`
+	NumDropped          uint64                           `json:"num_dropped"`
+	TotalGasUsed        uint64                           `json:"total_gas_used"`
+	TotalCost           *big.Int                         `json:"total_cost"`            // in wei of the target chain
+	AverageCost         *big.Int                         `json:"average_cost"`          // in wei of the target chain
+	TotalCostInFeeWei   *big.Int                         `json:"total_cost_in_fee_wei"` // converted to wei of the source chain
+	AverageCostInFeeWei *big.Int                         `json:"average_cost_in_fee_wei"`
+	TotalFeeIncome      *big.Int                         `json:"total_fee_income"` // cross-chain fees charged on the source chain for the relayed events
+	Profit              *big.Int                         `json:"profit"`           // in wei of the source chain, negative in case of a loss
+}
+
+// RelayFeeReport summarizes the relay profit or loss of the validator running this orchestrator,
+// and the cross-chain fee state of the mainchain and the subchain
+type RelayFeeReport struct {
+	Validator      common.Address   `json:"validator"`
+	Streams        []RelayCostStats `json:"streams"`
+	TotalCost      *big.Int         `json:"total_cost"`       // in mainchain wei
+	TotalFeeIncome *big.Int         `json:"total_fee_income"` // in mainchain wei
+	Profit         *big.Int         `json:"profit"`           // in mainchain wei
+	MainchainFee   *big.Int         `json:"mainchain_cross_chain_fee"`
+	SubchainFee    *big.Int         `json:"subchain_cross_chain_fee"`
+	ProposedFees   []FeeProposal    `json:"proposed_fees"`
+}
+
+// FeeProposal records a cross-chain fee update proposed by the fee manager
+type FeeProposal struct {
+	ChainID *big.Int    `json:"chain_id"`
+	OldFee  *big.Int    `json:"old_fee"`
+	NewFee  *big.Int    `json:"new_fee"`
+	TxHash  common.Hash `json:"tx_hash"`
+	Error   string      `json:"error"`
+	Time    time.Time   `json:"time"`
+}
+
+type pendingRelayTx struct {
+	sourceChainID   *big.Int
+	targetChainID   *big.Int
+	sourceEventType score.InterChainMessageEventType
+	feeCharged      *big.Int // the cross-chain fee on the source chain when the tx was submitted
+	tx              *types.Transaction
+	submittedAt     time.Time
+}
+
+// relayFeeManager tracks the actual gas spent on the relay txs, and proposes cross-chain fee updates
+// through the fee setter role so that the fee covers the relay costs within the configured bounds
+type relayFeeManager struct {
+	oc *Orchestrator
+
+	enabled          bool
+	updateInterval   time.Duration
+	minFee           *big.Int
+	maxFee           *big.Int
+	marginPercent    int64
+	minChangePercent int64
+	gasTokenPrice    *big.Int // subchain gas token price in mainchain wei per 1e18 subchain wei
+
+	mutex        *sync.Mutex
+	pendingTxs   []*pendingRelayTx
+	stats        map[string]*RelayCostStats
+	mainchainFee *big.Int
+	subchainFee  *big.Int
+	proposals    []FeeProposal
+}
+
+func newRelayFeeManager(oc *Orchestrator) *relayFeeManager {
+	minFee, ok := new(big.Int).SetString(viper.GetString(scom.CfgSubchainFeeManagerMinCrossChainFee), 10)
+	if !ok {
+		logger.Fatalf("invalid minimum cross-chain fee: %v", viper.GetString(scom.CfgSubchainFeeManagerMinCrossChainFee))
+	}
+	maxFee, ok := new(big.Int).SetString(viper.GetString(scom.CfgSubchainFeeManagerMaxCrossChainFee), 10)
+	if !ok {
+		logger.Fatalf("invalid maximum cross-chain fee: %v", viper.GetString(scom.CfgSubchainFeeManagerMaxCrossChainFee))
+	}
+	if minFee.Cmp(maxFee) > 0 {
+		logger.Fatalf("the minimum cross-chain fee %v exceeds the maximum cross-chain fee %v", minFee, maxFee)
+	}
+	gasTokenPrice, ok := new(big.Int).SetString(viper.GetString(scom.CfgSubchainFeeManagerSubchainGasTokenPrice), 10)
+	if !ok || gasTokenPrice.Sign() <= 0 {
+		logger.Fatalf("invalid subchain gas token price: %v", viper.GetString(scom.CfgSubchainFeeManagerSubchainGasTokenPrice))
+	}
+
+	return &relayFeeManager{
+		oc:               oc,
+		enabled:          viper.GetBool(scom.CfgSubchainFeeManagerEnabled),
+		updateInterval:   time.Duration(viper.GetInt(scom.CfgSubchainFeeManagerUpdateIntervalInMilliseconds)) * time.Millisecond,
+		minFee:           minFee,
+		maxFee:           maxFee,
+		marginPercent:    viper.GetInt64(scom.CfgSubchainFeeManagerMarginPercent),
+		minChangePercent: viper.GetInt64(scom.CfgSubchainFeeManagerMinChangePercent),
+		gasTokenPrice:    gasTokenPrice,
+		mutex:            &sync.Mutex{},
+		stats:            make(map[string]*RelayCostStats),
+		mainchainFee:     big.NewInt(0),
+		subchainFee:      big.NewInt(0),
+	}
+}
+
+func (fm *relayFeeManager) mainloop(ctx context.Context) {
+	defer fm.oc.wg.Done()
+
+	ticker := time.NewTicker(fm.updateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fm.refreshCrossChainFees()
+			fm.collectReceipts()
+			if fm.enabled {
+				fm.proposeFeeUpdate(fm.oc.mainchainID, fm.oc.subchainID)
+				fm.proposeFeeUpdate(fm.oc.subchainID, fm.oc.mainchainID)
+			}
+		}
+	}
+}
+
+// trackRelayTx records a submitted relay tx, its gas cost is accounted once its receipt is available
+func (fm *relayFeeManager) trackRelayTx(sourceEvent *score.InterChainMessageEvent, targetChainID *big.Int, tx *types.Transaction) {
+	fm.mutex.Lock()
+	defer fm.mutex.Unlock()
+
+	fm.pendingTxs = append(fm.pendingTxs, &pendingRelayTx{
+		sourceChainID:   sourceEvent.SourceChainID,
+		targetChainID:   targetChainID,
+		sourceEventType: sourceEvent.Type,
+		feeCharged:      new(big.Int).Set(fm.getFeeUnsafe(sourceEvent.SourceChainID)),
+		tx:              tx,
+		submittedAt:     time.Now(),
+	})
+}
+
+func (fm *relayFeeManager) refreshCrossChainFees() {
+	mainchainFee, err := fm.oc.chainRegistrarOnMainchain.GetCrossChainFee(nil)
+	if err != nil {
+		logger.Warnf("Failed to query the mainchain cross-chain fee: %v", err)
+	}
+	subchainFee, err2 := fm.oc.subchainRegister.GetCrossChainFee(nil)
+	if err2 != nil {
+		logger.Warnf("Failed to query the subchain cross-chain fee: %v", err2)
+	}
+
+	fm.mutex.Lock()
+	defer fm.mutex.Unlock()
+	if err == nil {
+		fm.mainchainFee = mainchainFee
+	}
+	if err2 == nil {
+		fm.subchainFee = subchainFee
+	}
+}
+
+// collectReceipts accounts the gas cost of the relay txs which have been included in the target chains
+func (fm *relayFeeManager) collectReceipts() {
+	fm.mutex.Lock()
+	pendingTxs := fm.pendingTxs
+	fm.pendingTxs = nil
+	fm.mutex.Unlock()
+
+	stillPending := []*pendingRelayTx{}
+	for _, ptx := range pendingTxs {
+		ecClient := fm.oc.getEthRpcClient(ptx.targetChainID)
+		if ecClient == nil {
+			continue
+		}
+		receipt, err := ecClient.TransactionReceipt(context.Background(), ptx.tx.Hash())
+		if err != nil {
+			if time.Since(ptx.submittedAt) > relayTxReceiptTimeout {
+				fm.accountDroppedTx(ptx)
+			} else {
+				stillPending = append(stillPending, ptx)
+			}
+			continue
+		}
+		fm.accountReceipt(ptx, receipt)
+	}
+
+	fm.mutex.Lock()
+	fm.pendingTxs = append(stillPending, fm.pendingTxs...)
+	fm.mutex.Unlock()
+}
+
+func (fm *relayFeeManager) accountReceipt(ptx *pendingRelayTx, receipt *types.Receipt) {
+	fm.mutex.Lock()
+	defer fm.mutex.Unlock()
+
+	stats := fm.getStatsUnsafe(ptx.sourceChainID, ptx.targetChainID, ptx.sourceEventType)
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), ptx.tx.GasPrice())
+	numRelays := new(big.Int).SetUint64(stats.NumRelays + 1)
+	stats.NumRelays++
+	stats.TotalGasUsed += receipt.GasUsed
+	stats.TotalCost.Add(stats.TotalCost, cost)
+	stats.AverageCost = new(big.Int).Div(stats.TotalCost, numRelays)
+	stats.TotalCostInFeeWei.Add(stats.TotalCostInFeeWei, fm.convertAmount(cost, ptx.targetChainID, ptx.sourceChainID))
+	stats.AverageCostInFeeWei = new(big.Int).Div(stats.TotalCostInFeeWei, numRelays)
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		// Only the successful relays earn the fee, the failed ones are a pure loss
+		stats.TotalFeeIncome.Add(stats.TotalFeeIncome, ptx.feeCharged)
+	}
+	stats.Profit = new(big.Int).Sub(stats.TotalFeeIncome, stats.TotalCostInFeeWei)
+}
+
+func (fm *relayFeeManager) accountDroppedTx(ptx *pendingRelayTx) {
+	fm.mutex.Lock()
+	defer fm.mutex.Unlock()
+
+	stats := fm.getStatsUnsafe(ptx.sourceChainID, ptx.targetChainID, ptx.sourceEventType)
+	stats.NumDropped++
+}
+
+// proposeFeeUpdate proposes a new cross-chain fee for the transfers originating from the given chain. The fee should
+// cover the cost of the relay txs on the target chain, i.e. the average cost of the most expensive event type plus a
+// margin, converted from wei of the target chain into wei of the chain charging the fee
+func (fm *relayFeeManager) proposeFeeUpdate(feeChainID *big.Int, relayTargetChainID *big.Int) {
+	currentFee, proposedFee, ok := fm.getFeeProposal(feeChainID, relayTargetChainID)
+	if !ok {
+		return
+	}
+
+	logger.Infof("Proposing cross-chain fee update on chain %v: %v -> %v", feeChainID, currentFee, proposedFee)
+	txHash, err := fm.submitFeeUpdate(feeChainID, proposedFee)
+	proposal := FeeProposal{
+		ChainID: feeChainID,
+		OldFee:  currentFee,
+		NewFee:  proposedFee,
+		TxHash:  txHash,
+		Time:    time.Now(),
+	}
+	if err != nil {
+		logger.Warnf("Failed to update the cross-chain fee on chain %v: %v", feeChainID, err)
+		proposal.Error = err.Error()
+	}
+
+	fm.mutex.Lock()
+	defer fm.mutex.Unlock()
+	fm.proposals = append(fm.proposals, proposal)
+	if len(fm.proposals) > 100 {
+		fm.proposals = fm.proposals[len(fm.proposals)-100:]
+	}
+}
+
+// getFeeProposal returns the current fee and the fee to propose, clamped to the configured bounds. It returns
+// false if no update should be proposed.
+func (fm *relayFeeManager) getFeeProposal(feeChainID *big.Int, relayTargetChainID *big.Int) (*big.Int, *big.Int, bool) {
+	fm.mutex.Lock()
+	proposedFee := big.NewInt(0)
+	for _, stats := range fm.stats {
+		if stats.SourceChainID.Cmp(feeChainID) != 0 || stats.TargetChainID.Cmp(relayTargetChainID) != 0 || stats.NumRelays == 0 {
+			continue
+		}
+		if stats.AverageCostInFeeWei.Cmp(proposedFee) > 0 {
+			proposedFee = new(big.Int).Set(stats.AverageCostInFeeWei)
+		}
+	}
+	currentFee := new(big.Int).Set(fm.getFeeUnsafe(feeChainID))
+	fm.mutex.Unlock()
+
+	if proposedFee.Sign() == 0 {
+		return nil, nil, false // no relay cost observed yet
+	}
+	proposedFee.Mul(proposedFee, big.NewInt(100+fm.marginPercent))
+	proposedFee.Div(proposedFee, big.NewInt(100))
+	if proposedFee.Cmp(fm.minFee) < 0 {
+		proposedFee.Set(fm.minFee)
+	}
+	if proposedFee.Cmp(fm.maxFee) > 0 {
+		proposedFee.Set(fm.maxFee)
+	}
+
+	// Avoid churning the fee on small cost fluctuations
+	change := new(big.Int).Abs(new(big.Int).Sub(proposedFee, currentFee))
+	change.Mul(change, big.NewInt(100))
+	if currentFee.Sign() > 0 && change.Cmp(new(big.Int).Mul(currentFee, big.NewInt(fm.minChangePercent))) < 0 {
+		return nil, nil, false
+	}
+	if proposedFee.Cmp(currentFee) == 0 {
+		return nil, nil, false
+	}
+	return currentFee, proposedFee, true
+}
+
+// submitFeeUpdate calls UpdateCrossChainFee on the registrar of the given chain. The update is simulated
+// first, so nothing is submitted if the validator does not hold the fee setter role.
+func (fm *relayFeeManager) submitFeeUpdate(chainID *big.Int, newFee *big.Int) (common.Hash, error) {
+	oc := fm.oc
+	unlock := oc.lockTxSubmission(chainID)
+	defer unlock()
+
+	ecClient := oc.getEthRpcClient(chainID)
+	txOpts, err := oc.buildTxOpts(chainID, ecClient)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	txOpts.NoSend = true
+
+	var tx *types.Transaction
+	if chainID.Cmp(oc.mainchainID) == 0 {
+		tx, err = oc.chainRegistrarOnMainchain.UpdateCrossChainFee(txOpts, newFee)
+	} else {
+		tx, err = oc.subchainRegister.UpdateCrossChainFee(txOpts, newFee)
+	}
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err = oc.simulateTx(ecClient, tx); err != nil {
+		return tx.Hash(), err
+	}
+	if err = ecClient.SendTransaction(context.Background(), tx); err != nil {
+		return tx.Hash(), err
+	}
+	return tx.Hash(), nil
+}
+
+func (fm *relayFeeManager) getFeeUnsafe(chainID *big.Int) *big.Int {
+	if chainID != nil && chainID.Cmp(fm.oc.mainchainID) == 0 {
+		return fm.mainchainFee
+	}
+	return fm.subchainFee
+}
+
+// convertAmount converts an amount in wei of one chain into wei of another chain, at the configured price of
+// the subchain gas token. The chains other than the mainchain are priced as the subchain.
+func (fm *relayFeeManager) convertAmount(amount *big.Int, fromChainID *big.Int, toChainID *big.Int) *big.Int {
+	fromMainchain := fromChainID.Cmp(fm.oc.mainchainID) == 0
+	toMainchain := toChainID.Cmp(fm.oc.mainchainID) == 0
+	converted := new(big.Int).Set(amount)
+	if fromMainchain == toMainchain {
+		return converted
+	}
+	if toMainchain {
+		converted.Mul(converted, fm.gasTokenPrice)
+		return converted.Div(converted, big.NewInt(1e18))
+	}
+	converted.Mul(converted, big.NewInt(1e18))
+	return converted.Div(converted, fm.gasTokenPrice)
+}
+
+func (fm *relayFeeManager) getStatsUnsafe(sourceChainID *big.Int, targetChainID *big.Int, eventType score.InterChainMessageEventType) *RelayCostStats {
+	key := relayStreamKey(sourceChainID, targetChainID, eventType)
+	stats, exists := fm.stats[key]
+	if !exists {
+		stats = &RelayCostStats{
+			SourceChainID:       sourceChainID,
+			TargetChainID:       targetChainID,
+			EventType:           eventType,
+			TotalCost:           big.NewInt(0),
+			AverageCost:         big.NewInt(0),
+			TotalCostInFeeWei:   big.NewInt(0),
+			AverageCostInFeeWei: big.NewInt(0),
+			TotalFeeIncome:      big.NewInt(0),
+			Profit:              big.NewInt(0),
+		}
+		fm.stats[key] = stats
+	}
+	return stats
+}
+
+func (fm *relayFeeManager) getReport() *RelayFeeReport {
+	fm.mutex.Lock()
+	defer fm.mutex.Unlock()
+
+	report := &RelayFeeReport{
+		Validator:      fm.oc.privateKey.PublicKey().Address(),
+		Streams:        []RelayCostStats{},
+		TotalCost:      big.NewInt(0),
+		TotalFeeIncome: big.NewInt(0),
+		MainchainFee:   new(big.Int).Set(fm.mainchainFee),
+		SubchainFee:    new(big.Int).Set(fm.subchainFee),
+		ProposedFees:   append([]FeeProposal{}, fm.proposals...),
+	}
+	keys := []string{}
+	for key := range fm.stats {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		stats := fm.stats[key]
+		report.Streams = append(report.Streams, RelayCostStats{
+			SourceChainID:       stats.SourceChainID,
+			TargetChainID:       stats.TargetChainID,
+			EventType:           stats.EventType,
+			NumRelays:           stats.NumRelays,
+			NumDropped:          stats.NumDropped,
+			TotalGasUsed:        stats.TotalGasUsed,
+			TotalCost:           new(big.Int).Set(stats.TotalCost),
+			AverageCost:         new(big.Int).Set(stats.AverageCost),
+			TotalCostInFeeWei:   new(big.Int).Set(stats.TotalCostInFeeWei),
+			AverageCostInFeeWei: new(big.Int).Set(stats.AverageCostInFeeWei),
+			TotalFeeIncome:      new(big.Int).Set(stats.TotalFeeIncome),
+			Profit:              new(big.Int).Set(stats.Profit),
+		})
+		report.TotalCost.Add(report.TotalCost, fm.convertAmount(stats.TotalCost, stats.TargetChainID, fm.oc.mainchainID))
+		report.TotalFeeIncome.Add(report.TotalFeeIncome, fm.convertAmount(stats.TotalFeeIncome, stats.SourceChainID, fm.oc.mainchainID))
+	}
+	report.Profit = new(big.Int).Sub(report.TotalFeeIncome, report.TotalCost)
+	return report
+}
+
+// GetRelayFeeReport returns the relay profit or loss of this validator, and the cross-chain fee updates it proposed
+func (oc *Orchestrator) GetRelayFeeReport() *RelayFeeReport {
+	return oc.feeManager.getReport()
+}
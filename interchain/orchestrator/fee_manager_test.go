@@ -0,0 +1,147 @@
+package orchestrator
+
+import (
+	"math/big"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/thetasubchain/eth/core/types"
+	ec "github.com/thetatoken/thetasubchain/eth/ethclient"
+
+	score "github.com/thetatoken/thetasubchain/core"
+)
+
+// newTestFeeManager creates a fee manager bounded to [1000, 100000] wei, with a 20% margin, which only
+// proposes changes of at least 10%. The subchain gas token is worth 0.02 mainchain TFuel.
+func newTestFeeManager(oc *Orchestrator) *relayFeeManager {
+	return &relayFeeManager{
+		oc:               oc,
+		enabled:          true,
+		minFee:           big.NewInt(1000),
+		maxFee:           big.NewInt(100000),
+		marginPercent:    20,
+		minChangePercent: 10,
+		gasTokenPrice:    big.NewInt(2e16),
+		mutex:            &sync.Mutex{},
+		stats:            make(map[string]*RelayCostStats),
+		mainchainFee:     big.NewInt(0),
+		subchainFee:      big.NewInt(0),
+	}
+}
+
+func TestFeeProposalBounds(t *testing.T) {
+	assert := assert.New(t)
+
+	oc := &Orchestrator{mainchainID: testMainchainID, subchainID: testSubchainID}
+	tests := []struct {
+		name        string
+		numRelays   uint64
+		averageCost int64 // in mainchain wei
+		currentFee  int64
+		proposed    bool
+		proposedFee int64
+	}{
+		{"no relay cost", 0, 0, 5000, false, 0},
+		{"cost plus margin", 10, 50000, 0, true, 60000},
+		{"below the minimum", 10, 500, 0, true, 1000},
+		{"above the maximum", 10, 200000, 0, true, 100000},
+		{"decrease", 10, 50000, 100000, true, 60000},
+		{"change below the threshold", 10, 50000, 57000, false, 0},
+		{"change at the threshold", 10, 50000, 54545, true, 60000},
+		{"already at the maximum", 10, 200000, 100000, false, 0},
+		{"already at the minimum", 10, 10, 1000, false, 0},
+	}
+	for _, test := range tests {
+		fm := newTestFeeManager(oc)
+		fm.mainchainFee = big.NewInt(test.currentFee)
+		stats := fm.getStatsUnsafe(testMainchainID, testSubchainID, score.IMCEventTypeCrossChainTokenLockTFuel)
+		stats.NumRelays = test.numRelays
+		stats.AverageCostInFeeWei = big.NewInt(test.averageCost)
+
+		// A cheaper event type, or the costs of the other direction, do not drive the fee of the chain
+		cheaper := fm.getStatsUnsafe(testMainchainID, testSubchainID, score.IMCEventTypeCrossChainTokenLockTNT20)
+		cheaper.NumRelays = 10
+		cheaper.AverageCostInFeeWei = big.NewInt(test.averageCost / 2)
+		otherDirection := fm.getStatsUnsafe(testSubchainID, testMainchainID, score.IMCEventTypeCrossChainVoucherBurnTFuel)
+		otherDirection.NumRelays = 10
+		otherDirection.AverageCostInFeeWei = big.NewInt(10 * test.averageCost)
+
+		currentFee, proposedFee, proposed := fm.getFeeProposal(testMainchainID, testSubchainID)
+		assert.Equal(test.proposed, proposed, test.name)
+		if proposed {
+			assert.Equal(0, big.NewInt(test.currentFee).Cmp(currentFee), test.name)
+			assert.Equal(0, big.NewInt(test.proposedFee).Cmp(proposedFee), "%v: %v", test.name, proposedFee)
+		}
+	}
+}
+
+func TestRelayCostConversion(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestEthRpcServer(http.StatusOK, nil, map[string]interface{}{
+		"eth_gasPrice":            "0x48c27395000", // 5000 gwei on the mainchain
+		"eth_getTransactionCount": "0x7",
+	})
+	defer server.Close()
+	ecClient, err := ec.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ecClient.Close()
+
+	// The gas price of the subchain is the one approved by governance for the next block
+	ledger := &testLedger{height: 1000, minGasPrice: big.NewInt(4e12)}
+	oc := &Orchestrator{ledger: ledger, privateKey: privKey, mainchainID: testMainchainID, subchainID: testSubchainID}
+	fm := newTestFeeManager(oc)
+	fm.mainchainFee = big.NewInt(3e15)
+	fm.subchainFee = big.NewInt(4e18)
+	to := common.HexToAddress("0x5a443704dd4B594B382c22a083e2BD3090A6feF3")
+
+	// A token lock relayed to the subchain costs 50000 gas at the governed gas price, i.e. 2e17 subchain wei,
+	// and is charged in mainchain wei, 2e17 * 0.02 = 4e15
+	txOpts, err := oc.buildTxOpts(testSubchainID, ecClient)
+	assert.Nil(err)
+	assert.Equal(0, big.NewInt(4e12).Cmp(txOpts.GasPrice))
+	assert.Equal(int64(7), txOpts.Nonce.Int64())
+	lock := newTestEvent(score.IMCEventTypeCrossChainTokenLockTFuel, testMainchainID, testSubchainID, 1)
+	lockTx := types.NewTransaction(txOpts.Nonce.Uint64(), to, big.NewInt(0), txOpts.GasLimit, txOpts.GasPrice, nil)
+	fm.trackRelayTx(lock, testSubchainID, lockTx)
+	fm.accountReceipt(fm.pendingTxs[0], &types.Receipt{Status: types.ReceiptStatusSuccessful, GasUsed: 50000})
+
+	stats := fm.stats[relayStreamKey(testMainchainID, testSubchainID, score.IMCEventTypeCrossChainTokenLockTFuel)]
+	assert.Equal(uint64(1), stats.NumRelays)
+	assert.Equal(0, big.NewInt(2e17).Cmp(stats.TotalCost))
+	assert.Equal(0, big.NewInt(4e15).Cmp(stats.TotalCostInFeeWei))
+	assert.Equal(0, big.NewInt(3e15).Cmp(stats.TotalFeeIncome))
+	assert.Equal(0, big.NewInt(-1e15).Cmp(stats.Profit))
+
+	// A voucher burn relayed to the mainchain costs 100000 gas at the suggested gas price, i.e. 5e17 mainchain wei,
+	// and is charged in subchain wei, 5e17 / 0.02 = 2.5e19. A failed relay earns no fee.
+	txOpts, err = oc.buildTxOpts(testMainchainID, ecClient)
+	assert.Nil(err)
+	assert.Equal(0, big.NewInt(5e12).Cmp(txOpts.GasPrice))
+	burn := newTestEvent(score.IMCEventTypeCrossChainVoucherBurnTFuel, testSubchainID, testMainchainID, 1)
+	burnTx := types.NewTransaction(txOpts.Nonce.Uint64(), to, big.NewInt(0), txOpts.GasLimit, txOpts.GasPrice, nil)
+	fm.trackRelayTx(burn, testMainchainID, burnTx)
+	fm.accountReceipt(fm.pendingTxs[1], &types.Receipt{Status: types.ReceiptStatusFailed, GasUsed: 100000})
+
+	stats = fm.stats[relayStreamKey(testSubchainID, testMainchainID, score.IMCEventTypeCrossChainVoucherBurnTFuel)]
+	assert.Equal(0, big.NewInt(5e17).Cmp(stats.TotalCost))
+	assert.Equal(0, new(big.Int).Mul(big.NewInt(25), big.NewInt(1e18)).Cmp(stats.TotalCostInFeeWei))
+	assert.Equal(0, stats.TotalFeeIncome.Sign())
+
+	// The report sums up everything in mainchain wei
+	oc.feeManager = fm
+	report := oc.GetRelayFeeReport()
+	assert.Equal(2, len(report.Streams))
+	assert.Equal(0, big.NewInt(5e17+4e15).Cmp(report.TotalCost))
+	assert.Equal(0, big.NewInt(3e15).Cmp(report.TotalFeeIncome))
+}
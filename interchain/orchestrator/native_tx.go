@@ -0,0 +1,69 @@
+package orchestrator
+
+import (
+	"errors"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	ttypes "github.com/thetatoken/theta/ledger/types"
+
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+var ErrTxPoolNotSet = errors.New("the subchain tx pool is not set")
+
+// TxPool accepts the native subchain transactions submitted by the orchestrator
+type TxPool interface {
+	InsertTransaction(rawTx common.Bytes) error
+	BroadcastTx(tx common.Bytes)
+}
+
+type signableTx interface {
+	ttypes.Tx
+	SetSignature(addr common.Address, sig *crypto.Signature) bool
+}
+
+// SetTxPool sets the pool the native subchain transactions are submitted to
+func (oc *Orchestrator) SetTxPool(txPool TxPool) {
+	oc.txPool = txPool
+}
+
+// nextNativeTxSequence returns the sequence of the next native transaction sent from the node account. The
+// transactions still pending in the mempool are not counted, a conflicting submission is rejected and retried.
+func (oc *Orchestrator) nextNativeTxSequence() (uint64, error) {
+	sequence, err := oc.ledger.GetFinalizedAccountSequence(oc.privateKey.PublicKey().Address())
+	if err != nil {
+		return 0, err
+	}
+	return sequence + 1, nil
+}
+
+// submitNativeTx signs the native subchain transaction with the node key, then adds it to the mempool and
+// broadcasts it to the other nodes
+func (oc *Orchestrator) submitNativeTx(tx signableTx) error {
+	if oc.txPool == nil {
+		return ErrTxPoolNotSet
+	}
+	currentBlock := oc.ledger.GetCurrentBlock()
+	if currentBlock == nil {
+		return errors.New("the current block is not available")
+	}
+
+	nodeAddress := oc.privateKey.PublicKey().Address()
+	sig, err := oc.privateKey.Sign(tx.SignBytes(currentBlock.ChainID))
+	if err != nil {
+		return err
+	}
+	if !tx.SetSignature(nodeAddress, sig) {
+		return errors.New("the transaction is not sent from the node account")
+	}
+	rawTx, err := stypes.TxToBytes(tx)
+	if err != nil {
+		return err
+	}
+	if err := oc.txPool.InsertTransaction(rawTx); err != nil {
+		return err
+	}
+	oc.txPool.BroadcastTx(rawTx)
+	return nil
+}
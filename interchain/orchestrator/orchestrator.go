@@ -3,6 +3,7 @@ package orchestrator
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
 	"sync"
 	"time"
@@ -13,6 +14,8 @@ import (
 	ts "github.com/thetatoken/theta/store"
 	"github.com/thetatoken/theta/store/database"
 	"github.com/thetatoken/thetasubchain/eth/abi/bind"
+	"github.com/thetatoken/thetasubchain/eth/core/types"
+	"github.com/thetatoken/thetasubchain/eth/event"
 	siu "github.com/thetatoken/thetasubchain/interchain/utils"
 	"github.com/thetatoken/thetasubchain/interchain/witness"
 
@@ -33,12 +36,41 @@ var (
 )
 
 type Orchestrator struct {
-	updateInterval        int
-	privateKey            *crypto.PrivateKey
-	ledger                score.Ledger
-	eventProcessingTicker *time.Ticker
-	metachainWitness      witness.ChainWitness
-	eventProcessedTime    map[string]time.Time
+	updateInterval          int
+	sweepInterval           int
+	privateKey              *crypto.PrivateKey
+	ledger                  score.Ledger
+	newEventsCh             chan []*score.InterChainMessageEvent
+	newEventsSub            event.Subscription
+	metachainWitness        witness.ChainWitness
+	eventProcessedTime      map[string]time.Time
+	eventProcessedTimeMutex *sync.Mutex
+
+	// Relay streams, each processed by its own worker
+	relayStreams      map[string]*relayStream
+	streamsMutex      *sync.Mutex
+	txSubmissionLocks map[string]*sync.Mutex
+	txLocksMutex      *sync.Mutex
+	relayNextEvent    func(sourceChainID *big.Int, targetChainID *big.Int, eventType score.InterChainMessageEventType) (bool, error)
+
+	// Latest relay attempt outcome of each pending event
+	relayOutcomes      map[string]*RelayOutcome
+	relayOutcomesMutex *sync.Mutex
+
+	// Relay gas cost accounting and cross-chain fee updates
+	feeManager *relayFeeManager
+
+	// Submission of the recorded equivocations to the mainchain for slashing
+	slasher *equivocationSlasher
+
+	// Relay of the stakes made on the subchain to the chain registrar on the mainchain
+	stakeRelayer *nativeStakeRelayer
+
+	// Minting of the staker rewards accrued on the subchain on the mainchain
+	rewardMinter *stakerRewardMinter
+
+	// Pool of the native subchain transactions, e.g. the governance votes on the downtime slashes
+	txPool TxPool
 
 	// The mainchain
 	mainchainID                  *big.Int
@@ -68,7 +100,8 @@ type Orchestrator struct {
 	interChainEventCache *siu.InterChainEventCache
 
 	// Inter-subchain clients
-	interSubchainChannels map[string]*ec.Client
+	interSubchainChannels      map[string]*ec.Client
+	interSubchainChannelsMutex *sync.RWMutex
 	// Life cycle
 	wg     *sync.WaitGroup
 	ctx    context.Context
@@ -117,10 +150,21 @@ func NewOrchestrator(db database.Database, updateInterval int, interChainEventCa
 	eventProcessedTime := make(map[string]time.Time)
 	interSubchainChannels := make(map[string]*ec.Client)
 	oc := &Orchestrator{
-		updateInterval:     updateInterval,
-		privateKey:         privateKey,
-		metachainWitness:   metachainWitness,
-		eventProcessedTime: eventProcessedTime,
+		updateInterval:          updateInterval,
+		sweepInterval:           viper.GetInt(scom.CfgSubchainOrchestratorSweepIntervalInMilliseconds),
+		newEventsCh:             make(chan []*score.InterChainMessageEvent, 16),
+		privateKey:              privateKey,
+		metachainWitness:        metachainWitness,
+		eventProcessedTime:      eventProcessedTime,
+		eventProcessedTimeMutex: &sync.Mutex{},
+
+		relayStreams:      make(map[string]*relayStream),
+		streamsMutex:      &sync.Mutex{},
+		txSubmissionLocks: make(map[string]*sync.Mutex),
+		txLocksMutex:      &sync.Mutex{},
+
+		relayOutcomes:      make(map[string]*RelayOutcome),
+		relayOutcomesMutex: &sync.Mutex{},
 
 		mainchainID:                  mainchainID,
 		mainchainEthRpcURL:           mainchainEthRpcURL,
@@ -137,11 +181,18 @@ func NewOrchestrator(db database.Database, updateInterval int, interChainEventCa
 		subchainEthRpcURL:    subchainEthRpcURL,
 		subchainEthRpcClient: subchainEthRpcClient,
 
-		interChainEventCache:  interChainEventCache,
-		interSubchainChannels: interSubchainChannels,
+		interChainEventCache:       interChainEventCache,
+		interSubchainChannels:      interSubchainChannels,
+		interSubchainChannelsMutex: &sync.RWMutex{},
 
 		wg: &sync.WaitGroup{},
 	}
+	oc.relayNextEvent = oc.processNextEvent
+	oc.feeManager = newRelayFeeManager(oc)
+	oc.slasher = newEquivocationSlasher(oc, db)
+	oc.stakeRelayer = newNativeStakeRelayer(oc, db)
+	oc.rewardMinter = newStakerRewardMinter(oc, db)
+
 	// if oc.subchainID.Cmp(big.NewInt(360888)) != 0 {
 	// 	cl, err := ec.Dial("http://localhost:19988/rpc")
 	// 	if err != nil {
@@ -157,14 +208,44 @@ func (oc *Orchestrator) Start(ctx context.Context) {
 	oc.ctx = c
 	oc.cancel = cancel
 
+	oc.newEventsSub = oc.interChainEventCache.SubscribeNewEvents(oc.newEventsCh)
+
+	oc.wg.Add(1)
+	go oc.notificationLoop(c)
+
+	// Each (source chain, target chain, event type) stream is relayed independently, so that a
+	// stuck stream (e.g. an unreachable target chain) does not delay the others
+	for _, eventType := range []score.InterChainMessageEventType{
+		score.IMCEventTypeCrossChainTokenLockTFuel,
+		score.IMCEventTypeCrossChainTokenLockTNT20,
+		score.IMCEventTypeCrossChainTokenLockTNT721,
+		score.IMCEventTypeCrossChainVoucherBurnTFuel,
+		score.IMCEventTypeCrossChainVoucherBurnTNT20,
+		score.IMCEventTypeCrossChainVoucherBurnTNT721,
+	} {
+		oc.addRelayStream(oc.mainchainID, oc.subchainID, eventType)
+		oc.addRelayStream(oc.subchainID, oc.mainchainID, eventType)
+	}
+	oc.addRelayStream(nil, common.Big0, score.IMCEInterSubchainChannelRegistered)
+
+	oc.wg.Add(1)
+	go oc.feeManager.mainloop(c)
+
+	oc.wg.Add(1)
+	go oc.slasher.mainloop(c)
+
+	oc.wg.Add(1)
+	go oc.stakeRelayer.mainloop(c)
+
 	oc.wg.Add(1)
-	go oc.mainloop(ctx)
+	go oc.rewardMinter.mainloop(c)
+
 	logger.Info("Metachain orchestrator started")
 }
 
 func (oc *Orchestrator) Stop() {
-	if oc.eventProcessingTicker != nil {
-		oc.eventProcessingTicker.Stop()
+	if oc.newEventsSub != nil {
+		oc.newEventsSub.Unsubscribe()
 	}
 	oc.cancel()
 	logger.Info("Metachain orchestrator stopped")
@@ -174,6 +255,11 @@ func (oc *Orchestrator) Wait() {
 	oc.wg.Wait()
 }
 
+// SetDowntimeReporter sets the source of the downtime reports used for downtime slashing
+func (oc *Orchestrator) SetDowntimeReporter(reporter score.DowntimeReporter) {
+	oc.slasher.setDowntimeReporter(reporter)
+}
+
 func (oc *Orchestrator) SetLedgerAndSubchainTokenBanks(ledger score.Ledger) {
 	oc.ledger = ledger
 
@@ -219,128 +305,67 @@ func (oc *Orchestrator) SetLedgerAndSubchainTokenBanks(ledger score.Ledger) {
 	}
 }
 
-func (oc *Orchestrator) mainloop(ctx context.Context) {
-	oc.eventProcessingTicker = time.NewTicker(time.Duration(oc.updateInterval) * time.Millisecond)
+// notificationLoop drains the events inserted by the witness and wakes up the relay streams they concern,
+// so that the witness is never blocked by a relay in progress
+func (oc *Orchestrator) notificationLoop(ctx context.Context) {
+	defer oc.wg.Done()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-oc.eventProcessingTicker.C:
-			// Handle token lock events
-			oc.processNextTokenLockEvent(oc.mainchainID, oc.subchainID) // send token from the mainchain to the subchain
-			oc.processNextTokenLockEvent(oc.subchainID, oc.mainchainID) // send token from the subchain to the mainchain
-
-			// Handle voucher burn events
-			oc.processNextVoucherBurnEvent(oc.mainchainID, oc.subchainID) // burn voucher to send token from the mainchain back to the subchain
-			oc.processNextVoucherBurnEvent(oc.subchainID, oc.mainchainID) // burn voucher to send token from the subchain back to the mainchain
-
-			// Handle subchain channel events
-			oc.processNextSubchainRegisterEvent()
-			for chainIDString := range oc.interSubchainChannels {
-				targetChainID, success := big.NewInt(0).SetString(chainIDString, 10)
-				if !success {
-					logger.Fatalf("failed to convert the target chain ID")
-				}
-				oc.processNextTokenLockEvent(oc.subchainID, targetChainID)
+		case err := <-oc.newEventsSub.Err():
+			if err != nil {
+				logger.Warnf("New inter-chain event subscription failed: %v", err)
 			}
+			return
+		case events := <-oc.newEventsCh:
+			logger.Debugf("Witnessed %v new inter-chain events, triggering relay", len(events))
+			oc.notifyRelayStreams(events)
 		}
 	}
 }
 
-func (oc *Orchestrator) processNextTokenLockEvent(sourceChainID *big.Int, targetChainID *big.Int) {
-	oc.processNextTFuelTokenLockEvent(sourceChainID, targetChainID)
-	oc.processNextTNT20TokenLockEvent(sourceChainID, targetChainID)
-	oc.processNextTNT721TokenLockEvent(sourceChainID, targetChainID)
-}
-
-func (oc *Orchestrator) processNextTFuelTokenLockEvent(sourceChainID *big.Int, targetChainID *big.Int) {
-	targetChainTokenBank := oc.getTFuelTokenBank(targetChainID)
-	maxProcessedTokenLockNonce, err := targetChainTokenBank.GetMaxProcessedTokenLockNonce(nil, sourceChainID)
-	if err != nil {
-		logger.Warnf("Failed to query the max processed TFuel token lock nonce for chain: %v", targetChainID.String())
-		return // ignore
-	}
-
-	oc.processNextEvent(sourceChainID, targetChainID, score.IMCEventTypeCrossChainTokenLockTFuel, maxProcessedTokenLockNonce)
-}
-
-func (oc *Orchestrator) processNextTNT20TokenLockEvent(sourceChainID *big.Int, targetChainID *big.Int) {
-	targetChainTokenBank := oc.getTNT20TokenBank(targetChainID)
-	maxProcessedTokenLockNonce, err := targetChainTokenBank.GetMaxProcessedTokenLockNonce(nil, sourceChainID)
-	if err != nil {
-		logger.Warnf("Failed to query the max processed TNT20 token lock nonce for chain: %v", targetChainID.String())
-		return // ignore
-	}
-	oc.processNextEvent(sourceChainID, targetChainID, score.IMCEventTypeCrossChainTokenLockTNT20, maxProcessedTokenLockNonce)
-}
-
-func (oc *Orchestrator) processNextTNT721TokenLockEvent(sourceChainID *big.Int, targetChainID *big.Int) {
-	targetChainTokenBank := oc.getTNT721TokenBank(targetChainID)
-	maxProcessedTokenLockNonce, err := targetChainTokenBank.GetMaxProcessedTokenLockNonce(nil, sourceChainID)
-	if err != nil {
-		logger.Warnf("Failed to query the max processed TNT20 token lock nonce for chain: %v", targetChainID.String())
-		return // ignore
-	}
-	oc.processNextEvent(sourceChainID, targetChainID, score.IMCEventTypeCrossChainTokenLockTNT721, maxProcessedTokenLockNonce)
-}
-
-func (oc *Orchestrator) processNextVoucherBurnEvent(sourceChainID *big.Int, targetChainID *big.Int) {
-	oc.processNextTFuelVoucherBurnEvent(sourceChainID, targetChainID)
-	oc.processNextTNT20VoucherBurnEvent(sourceChainID, targetChainID)
-	oc.processNextTNT721VoucherBurnEvent(sourceChainID, targetChainID)
-}
-
-func (oc *Orchestrator) processNextTFuelVoucherBurnEvent(sourceChainID *big.Int, targetChainID *big.Int) {
-	targetChainTokenBank := oc.getTFuelTokenBank(targetChainID)
-	maxProcessedVoucherBurnNonce, err := targetChainTokenBank.GetMaxProcessedVoucherBurnNonce(nil, sourceChainID)
-	if err != nil {
-		logger.Warnf("Failed to query the max processed TFuel voucher burn nonce for chain: %v", targetChainID.String())
-		return // ignore
-	}
-
-	oc.processNextEvent(sourceChainID, targetChainID, score.IMCEventTypeCrossChainVoucherBurnTFuel, maxProcessedVoucherBurnNonce)
-}
-
-func (oc *Orchestrator) processNextTNT20VoucherBurnEvent(sourceChainID *big.Int, targetChainID *big.Int) {
-	targetChainTokenBank := oc.getTNT20TokenBank(targetChainID)
-	maxProcessedVoucherBurnNonce, err := targetChainTokenBank.GetMaxProcessedVoucherBurnNonce(nil, sourceChainID)
-	if err != nil {
-		logger.Warnf("Failed to query the max processed TNT20 voucher burn nonce for chain: %v", targetChainID.String())
-		return // ignore
-	}
-
-	oc.processNextEvent(sourceChainID, targetChainID, score.IMCEventTypeCrossChainVoucherBurnTNT20, maxProcessedVoucherBurnNonce)
-}
-
-func (oc *Orchestrator) processNextTNT721VoucherBurnEvent(sourceChainID *big.Int, targetChainID *big.Int) {
-	targetChainTokenBank := oc.getTNT721TokenBank(targetChainID)
-	maxProcessedVoucherBurnNonce, err := targetChainTokenBank.GetMaxProcessedVoucherBurnNonce(nil, sourceChainID)
-	if err != nil {
-		logger.Warnf("Failed to query the max processed TNT721 voucher burn nonce for chain: %v", targetChainID.String())
-		return // ignore
+// getMaxProcessedNonce queries the target chain for the nonce of the last source chain event of the given type it has processed
+func (oc *Orchestrator) getMaxProcessedNonce(sourceChainID *big.Int, targetChainID *big.Int, eventType score.InterChainMessageEventType) (*big.Int, error) {
+	switch eventType {
+	case score.IMCEventTypeCrossChainTokenLockTFuel:
+		return oc.getTFuelTokenBank(targetChainID).GetMaxProcessedTokenLockNonce(nil, sourceChainID)
+	case score.IMCEventTypeCrossChainTokenLockTNT20:
+		return oc.getTNT20TokenBank(targetChainID).GetMaxProcessedTokenLockNonce(nil, sourceChainID)
+	case score.IMCEventTypeCrossChainTokenLockTNT721:
+		return oc.getTNT721TokenBank(targetChainID).GetMaxProcessedTokenLockNonce(nil, sourceChainID)
+	case score.IMCEventTypeCrossChainVoucherBurnTFuel:
+		return oc.getTFuelTokenBank(targetChainID).GetMaxProcessedVoucherBurnNonce(nil, sourceChainID)
+	case score.IMCEventTypeCrossChainVoucherBurnTNT20:
+		return oc.getTNT20TokenBank(targetChainID).GetMaxProcessedVoucherBurnNonce(nil, sourceChainID)
+	case score.IMCEventTypeCrossChainVoucherBurnTNT721:
+		return oc.getTNT721TokenBank(targetChainID).GetMaxProcessedVoucherBurnNonce(nil, sourceChainID)
+	case score.IMCEInterSubchainChannelRegistered:
+		return oc.subchainRegister.GetMaxProcessedNonce(nil)
+	default:
+		return nil, fmt.Errorf("unsupported relay event type: %v", eventType)
 	}
-
-	oc.processNextEvent(sourceChainID, targetChainID, score.IMCEventTypeCrossChainVoucherBurnTNT721, maxProcessedVoucherBurnNonce)
 }
 
-func (oc *Orchestrator) processNextSubchainRegisterEvent() {
-	subchainRegister := oc.subchainRegister
-	maxProcessedSubchainRegisteredNonce, err := subchainRegister.GetMaxProcessedNonce(nil)
+// processNextEvent relays the next unprocessed event of the stream to the target chain. It returns true
+// if a transaction was submitted to the target chain.
+func (oc *Orchestrator) processNextEvent(sourceChainID *big.Int, targetChainID *big.Int, sourceChainEventType score.InterChainMessageEventType) (bool, error) {
+	maxProcessedNonce, err := oc.getMaxProcessedNonce(sourceChainID, targetChainID, sourceChainEventType)
 	if err != nil {
-		logger.Warnf("Failed to query the max processed subchain channel register nonce ")
-		return // ignore
+		return false, fmt.Errorf("failed to query the max processed nonce for event type %v on chain %v: %v",
+			sourceChainEventType, targetChainID, err)
 	}
 
-	oc.processNextEvent(nil, common.Big0, score.IMCEInterSubchainChannelRegistered, maxProcessedSubchainRegisteredNonce)
-}
-
-func (oc *Orchestrator) processNextEvent(sourceChainID *big.Int, targetChainID *big.Int, sourceChainEventType score.InterChainMessageEventType, maxProcessedNonce *big.Int) {
 	oc.cleanUpInterChainEventCache(sourceChainID, targetChainID, sourceChainEventType, maxProcessedNonce)
 
 	nextNonce := big.NewInt(0).Add(maxProcessedNonce, big.NewInt(1))
 	sourceEvent, err := oc.interChainEventCache.Get(sourceChainID, targetChainID, sourceChainEventType, nextNonce)
 	if err == ts.ErrKeyNotFound {
-		return // the next event (e.g. Token Lock, or Voucher Burn) has not occurred yet
+		return false, nil // the next event (e.g. Token Lock, or Voucher Burn) has not occurred yet
+	}
+	if err != nil {
+		return false, err
 	}
 
 	logger.Debugf("Process next event, sourceChainID: %v, targetChainID: %v, sourceChainEventType: %v, nextNonce: %v",
@@ -348,21 +373,24 @@ func (oc *Orchestrator) processNextEvent(sourceChainID *big.Int, targetChainID *
 
 	targetEventType := oc.getTargetChainCorrespondingEventType(sourceChainEventType)
 	retryThreshold := oc.getRetryThreshold(targetChainID)
-	if oc.timeElapsedSinceEventProcessed(sourceEvent) > retryThreshold { // retry if the tx has been submitted for a long time
-		var err error
-		if sourceChainEventType == score.IMCEInterSubchainChannelRegistered {
-			err = oc.verifyChannelValidity(sourceEvent)
-		} else {
-			err = oc.callTargetContract(targetChainID, targetEventType, sourceEvent)
-		}
+	if oc.timeElapsedSinceEventProcessed(sourceEvent) <= retryThreshold && !oc.isRelayTxFailed(targetChainID, sourceEvent) {
+		// The tx has been submitted recently, wait for it to be finalized. A failed tx produces no event to
+		// wake up the stream, so the stream checks the tx again after a block interval of the target chain.
+		oc.scheduleRelayRetry(sourceChainID, targetChainID, sourceChainEventType, oc.getBlockInterval(targetChainID))
+		return false, nil
+	}
 
-		if err == nil {
-			oc.updateEventProcessedTime(sourceEvent)
-		} else {
-			logger.Warnf("Failed to call target contract: %v", err)
-		}
+	if sourceChainEventType == score.IMCEInterSubchainChannelRegistered {
+		err = oc.verifyChannelValidity(sourceEvent)
+	} else {
+		err = oc.callTargetContract(targetChainID, targetEventType, sourceEvent)
+	}
+	if err != nil {
+		return false, err
 	}
 
+	oc.updateEventProcessedTime(sourceEvent)
+	return true, nil
 }
 
 func (oc *Orchestrator) verifyChannelValidity(event *score.InterChainMessageEvent) error {
@@ -385,16 +413,27 @@ func (oc *Orchestrator) verifyChannelValidity(event *score.InterChainMessageEven
 		logger.Warnf("subchainID mismatch")
 		return ErrTargetChainIDMismatch
 	}
+	unlock := oc.lockTxSubmission(oc.subchainID)
 	txOpts, err := oc.buildTxOpts(oc.subchainID, oc.subchainEthRpcClient)
 	if err != nil {
+		unlock()
 		return err
 	}
 	txOpts.GasPrice = big.NewInt(4000000000000)
 	err = oc.callVerifySubchainChannelValidity(txOpts, se.ChainID, channelValidity, se.Nonce)
+	unlock()
 	if err != nil {
 		return err
 	}
+
+	oc.interSubchainChannelsMutex.Lock()
 	oc.interSubchainChannels[event.TargetChainID.String()] = newSubchainChannel
+	oc.interSubchainChannelsMutex.Unlock()
+
+	// Relay the token locks from this subchain to the newly connected subchain
+	oc.addRelayStream(oc.subchainID, event.TargetChainID, score.IMCEventTypeCrossChainTokenLockTFuel)
+	oc.addRelayStream(oc.subchainID, event.TargetChainID, score.IMCEventTypeCrossChainTokenLockTNT20)
+	oc.addRelayStream(oc.subchainID, event.TargetChainID, score.IMCEventTypeCrossChainTokenLockTNT721)
 	// oc.metachainWitness.InsertIntoSubchainChannelWatchList(event.TargetChainID)
 	return nil
 }
@@ -405,11 +444,17 @@ func (oc *Orchestrator) cleanUpInterChainEventCache(sourceChainID *big.Int, targ
 		return
 	}
 	if exists {
+		if processedEvent, err := oc.interChainEventCache.Get(sourceChainID, targetChainID, eventType, maxProcessedNonce); err == nil {
+			oc.removeRelayOutcome(processedEvent)
+		}
 		oc.interChainEventCache.Delete(sourceChainID, targetChainID, eventType, maxProcessedNonce)
 	}
 }
 
 func (oc *Orchestrator) timeElapsedSinceEventProcessed(event *score.InterChainMessageEvent) time.Duration {
+	oc.eventProcessedTimeMutex.Lock()
+	defer oc.eventProcessedTimeMutex.Unlock()
+
 	eventID := event.ID()
 	if processedTime, ok := oc.eventProcessedTime[eventID]; ok {
 		return time.Since(processedTime)
@@ -419,12 +464,16 @@ func (oc *Orchestrator) timeElapsedSinceEventProcessed(event *score.InterChainMe
 }
 
 func (oc *Orchestrator) updateEventProcessedTime(event *score.InterChainMessageEvent) {
+	oc.eventProcessedTimeMutex.Lock()
+	defer oc.eventProcessedTimeMutex.Unlock()
+
 	eventID := event.ID()
 	oc.eventProcessedTime[eventID] = time.Now()
 }
 
 // For Token Lock events on the source chain, call the Mint Voucher method of the corresponding TokenBank contract on the target chain
 // For Voucher Burn events on the source chain, call the Unlock Token method  of the corresponding TokenBank contract on the target chain
+// The tx is simulated with eth_call before submission, so reverts are caught (and their reasons recorded) before any gas is spent
 func (oc *Orchestrator) callTargetContract(targetChainID *big.Int, targetEventType score.InterChainMessageEventType, sourceEvent *score.InterChainMessageEvent) error {
 	var err error
 
@@ -438,36 +487,76 @@ func (oc *Orchestrator) callTargetContract(targetChainID *big.Int, targetEventTy
 		logger.Debugf("Subchain %v adjusted ValSet queried from the Subchain  for dynasty %v: %v", oc.subchainID, dynasty, vsQueriedFromSC)
 	}
 
+	unlock := oc.lockTxSubmission(targetChainID)
+	defer unlock()
+
+	// Another orchestrator may have relayed the event in the meantime
+	processed, err := oc.isEventProcessed(sourceEvent)
+	if err != nil {
+		oc.recordRelayOutcome(sourceEvent, RelayStatusSimulationFailed, common.Hash{}, err)
+		return err
+	}
+	if processed {
+		logger.Debugf("Event %v has already been processed by chain %v, skip submission", sourceEvent.ID(), targetChainID)
+		oc.recordRelayOutcome(sourceEvent, RelayStatusAlreadyProcessed, common.Hash{}, nil)
+		return nil
+	}
+
 	targetChainEthRpcClient := oc.getEthRpcClient(targetChainID)
 	txOpts, err := oc.buildTxOpts(targetChainID, targetChainEthRpcClient)
 	if err != nil {
+		oc.recordRelayOutcome(sourceEvent, RelayStatusPreparationFailed, common.Hash{}, err)
 		return err
 	}
+	txOpts.NoSend = true // only sign the tx, it is submitted after the simulation succeeds
+
+	var tx *types.Transaction
 	switch targetEventType {
 	// Voucher Mint events
 	case score.IMCEventTypeCrossChainVoucherMintTFuel:
-		err = oc.mintTFuelVouchers(txOpts, targetChainID, sourceEvent)
+		tx, err = oc.mintTFuelVouchers(txOpts, targetChainID, sourceEvent)
 	case score.IMCEventTypeCrossChainVoucherMintTNT20:
-		err = oc.mintTNT20Vouchers(txOpts, targetChainID, sourceEvent)
+		tx, err = oc.mintTNT20Vouchers(txOpts, targetChainID, sourceEvent)
 	case score.IMCEventTypeCrossChainVoucherMintTNT721:
-		err = oc.mintTN721Vouchers(txOpts, targetChainID, sourceEvent)
+		tx, err = oc.mintTN721Vouchers(txOpts, targetChainID, sourceEvent)
 
 	// Token Unlock events
 	case score.IMCEventTypeCrossChainTokenUnlockTFuel:
-		err = oc.unlockTFuelTokens(txOpts, targetChainID, sourceEvent)
+		tx, err = oc.unlockTFuelTokens(txOpts, targetChainID, sourceEvent)
 	case score.IMCEventTypeCrossChainTokenUnlockTNT20:
-		err = oc.unlockTNT20Tokens(txOpts, targetChainID, sourceEvent)
+		tx, err = oc.unlockTNT20Tokens(txOpts, targetChainID, sourceEvent)
 	case score.IMCEventTypeCrossChainTokenUnlockTNT721:
-		err = oc.unlockTNT721Tokens(txOpts, targetChainID, sourceEvent)
+		tx, err = oc.unlockTNT721Tokens(txOpts, targetChainID, sourceEvent)
 	default:
 		return nil
 	}
+	if err != nil {
+		logger.Warnf("Failed to build the target contract call: %v", err)
+		oc.recordRelayOutcome(sourceEvent, RelayStatusPreparationFailed, common.Hash{}, err)
+		return err
+	}
 
+	err = oc.simulateTx(targetChainEthRpcClient, tx)
 	if err != nil {
-		logger.Warnf("Failed to call the target contract: ", err)
+		if revertErr, ok := err.(*RevertError); ok {
+			logger.Warnf("Simulation of the target contract call for event %v reverted: %v", sourceEvent.ID(), revertErr.Reason)
+			oc.recordRelayOutcome(sourceEvent, RelayStatusReverted, tx.Hash(), err)
+		} else {
+			logger.Warnf("Failed to simulate the target contract call for event %v: %v", sourceEvent.ID(), err)
+			oc.recordRelayOutcome(sourceEvent, RelayStatusSimulationFailed, tx.Hash(), err)
+		}
 		return err
 	}
 
+	err = targetChainEthRpcClient.SendTransaction(context.Background(), tx)
+	if err != nil {
+		logger.Warnf("Failed to call the target contract: %v", err)
+		oc.recordRelayOutcome(sourceEvent, RelayStatusSubmissionFailed, tx.Hash(), err)
+		return err
+	}
+	oc.recordRelayOutcome(sourceEvent, RelayStatusSubmitted, tx.Hash(), nil)
+	oc.feeManager.trackRelayTx(sourceEvent, targetChainID, tx)
+
 	return nil
 }
 
@@ -484,118 +573,90 @@ func (oc *Orchestrator) callVerifySubchainChannelValidity(txOpts *bind.TransactO
 	return nil
 }
 
-func (oc *Orchestrator) mintTFuelVouchers(txOpts *bind.TransactOpts, targetChainID *big.Int, sourceEvent *score.InterChainMessageEvent) error {
+func (oc *Orchestrator) mintTFuelVouchers(txOpts *bind.TransactOpts, targetChainID *big.Int, sourceEvent *score.InterChainMessageEvent) (*types.Transaction, error) {
 	se, err := score.ParseToCrossChainTFuelTokenLockedEvent(sourceEvent)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	dynasty := oc.getDynasty()
 	if dynasty == nil {
-		return ErrDynastyIsNil
+		return nil, ErrDynastyIsNil
 	}
 	tfuelTokenBank := oc.getTFuelTokenBank(targetChainID)
-	_, err = tfuelTokenBank.MintVouchers(txOpts, se.Denom, se.TargetChainVoucherReceiver, se.LockedAmount, dynasty, se.TokenLockNonce)
-	if err != nil {
-		return err
-	}
-	return nil
+	return tfuelTokenBank.MintVouchers(txOpts, se.Denom, se.TargetChainVoucherReceiver, se.LockedAmount, dynasty, se.TokenLockNonce)
 }
 
-func (oc *Orchestrator) mintTNT20Vouchers(txOpts *bind.TransactOpts, targetChainID *big.Int, sourceEvent *score.InterChainMessageEvent) error {
+func (oc *Orchestrator) mintTNT20Vouchers(txOpts *bind.TransactOpts, targetChainID *big.Int, sourceEvent *score.InterChainMessageEvent) (*types.Transaction, error) {
 	se, err := score.ParseToCrossChainTNT20TokenLockedEvent(sourceEvent)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	dynasty := oc.getDynasty()
 	if dynasty == nil {
-		return ErrDynastyIsNil
+		return nil, ErrDynastyIsNil
 	}
 	if targetChainID.Cmp(big.NewInt(oc.mainchainID.Int64())) != 0 && targetChainID.Cmp(big.NewInt(oc.subchainID.Int64())) != 0 {
-		sidechainTNT20TokenBank, err := scta.NewTNT20TokenBank(oc.subchainTNT20TokenBankAddr, oc.interSubchainChannels[targetChainID.String()])
+		sidechainTNT20TokenBank, err := scta.NewTNT20TokenBank(oc.subchainTNT20TokenBankAddr, oc.getInterSubchainChannel(targetChainID))
 		if err != nil {
 			logger.Fatalf("failed to set the SubchainTNT20TokenBank contract: %v\n", err)
 		}
-		_, err = sidechainTNT20TokenBank.MintVouchers(txOpts, se.Denom, se.Name, se.Symbol, se.Decimals, se.TargetChainVoucherReceiver, se.LockedAmount, dynasty, se.TokenLockNonce)
-		if err != nil {
-			return err
-		}
-		return nil
+		return sidechainTNT20TokenBank.MintVouchers(txOpts, se.Denom, se.Name, se.Symbol, se.Decimals, se.TargetChainVoucherReceiver, se.LockedAmount, dynasty, se.TokenLockNonce)
 	}
 	TNT20TokenBank := oc.getTNT20TokenBank(targetChainID)
-	_, err = TNT20TokenBank.MintVouchers(txOpts, se.Denom, se.Name, se.Symbol, se.Decimals, se.TargetChainVoucherReceiver, se.LockedAmount, dynasty, se.TokenLockNonce)
-	if err != nil {
-		return err
-	}
-	return nil
+	return TNT20TokenBank.MintVouchers(txOpts, se.Denom, se.Name, se.Symbol, se.Decimals, se.TargetChainVoucherReceiver, se.LockedAmount, dynasty, se.TokenLockNonce)
 }
 
-func (oc *Orchestrator) mintTN721Vouchers(txOpts *bind.TransactOpts, targetChainID *big.Int, sourceEvent *score.InterChainMessageEvent) error {
+func (oc *Orchestrator) mintTN721Vouchers(txOpts *bind.TransactOpts, targetChainID *big.Int, sourceEvent *score.InterChainMessageEvent) (*types.Transaction, error) {
 	se, err := score.ParseToCrossChainTNT721TokenLockedEvent(sourceEvent)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	dynasty := oc.getDynasty()
 	if dynasty == nil {
-		return ErrDynastyIsNil
+		return nil, ErrDynastyIsNil
 	}
 	TNT721TokenBank := oc.getTNT721TokenBank(targetChainID)
-	_, err = TNT721TokenBank.MintVouchers(txOpts, se.Denom, se.Name, se.Symbol, se.TargetChainVoucherReceiver, se.TokenID, se.TokenURI, dynasty, se.TokenLockNonce)
-	if err != nil {
-		return err
-	}
-	return nil
+	return TNT721TokenBank.MintVouchers(txOpts, se.Denom, se.Name, se.Symbol, se.TargetChainVoucherReceiver, se.TokenID, se.TokenURI, dynasty, se.TokenLockNonce)
 }
 
-func (oc *Orchestrator) unlockTFuelTokens(txOpts *bind.TransactOpts, targetChainID *big.Int, sourceEvent *score.InterChainMessageEvent) error {
+func (oc *Orchestrator) unlockTFuelTokens(txOpts *bind.TransactOpts, targetChainID *big.Int, sourceEvent *score.InterChainMessageEvent) (*types.Transaction, error) {
 	se, err := score.ParseToCrossChainTFuelVoucherBurnedEvent(sourceEvent)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	dynasty := oc.getDynasty()
 	if dynasty == nil {
-		return ErrDynastyIsNil
+		return nil, ErrDynastyIsNil
 	}
 	tfuelTokenBank := oc.getTFuelTokenBank(targetChainID)
-	_, err = tfuelTokenBank.UnlockTokens(txOpts, sourceEvent.SourceChainID, se.TargetChainTokenReceiver, se.BurnedAmount, dynasty, se.VoucherBurnNonce)
-	if err != nil {
-		return err
-	}
-	return nil
+	return tfuelTokenBank.UnlockTokens(txOpts, sourceEvent.SourceChainID, se.TargetChainTokenReceiver, se.BurnedAmount, dynasty, se.VoucherBurnNonce)
 }
 
-func (oc *Orchestrator) unlockTNT20Tokens(txOpts *bind.TransactOpts, targetChainID *big.Int, sourceEvent *score.InterChainMessageEvent) error {
+func (oc *Orchestrator) unlockTNT20Tokens(txOpts *bind.TransactOpts, targetChainID *big.Int, sourceEvent *score.InterChainMessageEvent) (*types.Transaction, error) {
 	se, err := score.ParseToCrossChainTNT20VoucherBurnedEvent(sourceEvent)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	dynasty := oc.getDynasty()
 	if dynasty == nil {
-		return ErrDynastyIsNil
+		return nil, ErrDynastyIsNil
 	}
 	TNT20TokenBank := oc.getTNT20TokenBank(targetChainID)
-	_, err = TNT20TokenBank.UnlockTokens(txOpts, sourceEvent.SourceChainID, se.Denom, se.TargetChainTokenReceiver, se.BurnedAmount, dynasty, se.VoucherBurnNonce)
-	if err != nil {
-		return err
-	}
-	return nil
+	return TNT20TokenBank.UnlockTokens(txOpts, sourceEvent.SourceChainID, se.Denom, se.TargetChainTokenReceiver, se.BurnedAmount, dynasty, se.VoucherBurnNonce)
 }
 
-func (oc *Orchestrator) unlockTNT721Tokens(txOpts *bind.TransactOpts, targetChainID *big.Int, sourceEvent *score.InterChainMessageEvent) error {
+func (oc *Orchestrator) unlockTNT721Tokens(txOpts *bind.TransactOpts, targetChainID *big.Int, sourceEvent *score.InterChainMessageEvent) (*types.Transaction, error) {
 	se, err := score.ParseToCrossChainTNT721VoucherBurnedEvent(sourceEvent)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	dynasty := oc.getDynasty()
 	if dynasty == nil {
-		return ErrDynastyIsNil
+		return nil, ErrDynastyIsNil
 	}
 	TNT721TokenBank := oc.getTNT721TokenBank(targetChainID)
-	_, err = TNT721TokenBank.UnlockTokens(txOpts, sourceEvent.SourceChainID, se.Denom, se.TargetChainTokenReceiver, se.TokenID, dynasty, se.VoucherBurnNonce)
-	if err != nil {
-		return err
-	}
-	return nil
+	return TNT721TokenBank.UnlockTokens(txOpts, sourceEvent.SourceChainID, se.Denom, se.TargetChainTokenReceiver, se.TokenID, dynasty, se.VoucherBurnNonce)
 }
 
 func (oc *Orchestrator) buildTxOpts(chainID *big.Int, ecClient *ec.Client) (*bind.TransactOpts, error) {
@@ -606,11 +667,19 @@ func (oc *Orchestrator) buildTxOpts(chainID *big.Int, ecClient *ec.Client) (*bin
 		if err != nil {
 			return nil, err
 		}
+	} else if chainID.Cmp(oc.subchainID) == 0 {
+		// eth_gasPrice returns a hardcoded nubmer for the mainchain, which could be much higher than min gasPrice required by the subchain.
+		// The minimum gas price approved by the governance proposals is read from the ledger instead
+		var height uint64
+		if currentBlock := oc.ledger.GetCurrentBlock(); currentBlock != nil {
+			height = currentBlock.Height + 1
+		}
+		gasPrice = oc.ledger.GetMinimumGasPrice(height)
 	} else {
-		// eth_gasPrice returns a hardcoded nubmer for the mainchain, which could be much higher than min gasPrice required by the subchain
-		// TODO: parameterize the subchain ETH RPC service to suggest the proper gasPrice for different chains
-		// gasPrice = big.NewInt(int64(scom.MinimumGasPrice) * 2)
-		gasPrice = common.Big0
+		gasPrice, err = ecClient.SuggestGasPrice(context.Background())
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	nonce, err := ecClient.PendingNonceAt(context.Background(), oc.privateKey.PublicKey().Address())
@@ -634,27 +703,39 @@ func (oc *Orchestrator) getDynasty() *big.Int {
 }
 
 func (oc *Orchestrator) getRetryThreshold(chainID *big.Int) time.Duration {
-	var blockIntervalInSeconds int
-	if chainID.Cmp(oc.mainchainID) == 0 {
-		blockIntervalInSeconds = viper.GetInt(scom.CfgSubchainMainchainBlockIntervalInSeconds)
-	} else {
-		blockIntervalInSeconds = viper.GetInt(scom.CfgConsensusMinBlockInterval)
-	}
 	numBlocks := 4 // typically a tx should be finalized within 2 block intervals, here we conservatively use 4
-	retryThreshold := time.Duration(numBlocks*blockIntervalInSeconds) * time.Second
+	retryThreshold := time.Duration(numBlocks) * oc.getBlockInterval(chainID)
 	return retryThreshold
 }
 
+func (oc *Orchestrator) getBlockInterval(chainID *big.Int) time.Duration {
+	if chainID.Cmp(oc.mainchainID) == 0 {
+		return time.Duration(viper.GetInt(scom.CfgSubchainMainchainBlockIntervalInSeconds)) * time.Second
+	}
+	var height uint64
+	if currentBlock := oc.ledger.GetCurrentBlock(); currentBlock != nil {
+		height = currentBlock.Height
+	}
+	return oc.ledger.GetMinBlockInterval(height) // the interval may be changed by governance
+}
+
 func (oc *Orchestrator) getEthRpcClient(chainID *big.Int) *ec.Client {
 	if chainID.Cmp(oc.mainchainID) == 0 {
 		return oc.mainchainEthRpcClient
 	} else if chainID.Cmp(oc.subchainID) == 0 {
 		return oc.subchainEthRpcClient
 	} else {
-		return oc.interSubchainChannels[chainID.String()]
+		return oc.getInterSubchainChannel(chainID)
 	}
 }
 
+func (oc *Orchestrator) getInterSubchainChannel(chainID *big.Int) *ec.Client {
+	oc.interSubchainChannelsMutex.RLock()
+	defer oc.interSubchainChannelsMutex.RUnlock()
+
+	return oc.interSubchainChannels[chainID.String()]
+}
+
 func (oc *Orchestrator) getTFuelTokenBank(chainID *big.Int) *scta.TFuelTokenBank {
 	if chainID.Cmp(oc.mainchainID) == 0 {
 		return oc.mainchainTFuelTokenBank
@@ -669,7 +750,7 @@ func (oc *Orchestrator) getTNT20TokenBank(chainID *big.Int) *scta.TNT20TokenBank
 	} else if chainID.Cmp(oc.subchainID) == 0 {
 		return oc.subchainTNT20TokenBank
 	} else {
-		targetSubchainTNT20TokenBank, err := scta.NewTNT20TokenBank(oc.subchainTNT20TokenBankAddr, oc.interSubchainChannels[chainID.String()])
+		targetSubchainTNT20TokenBank, err := scta.NewTNT20TokenBank(oc.subchainTNT20TokenBankAddr, oc.getInterSubchainChannel(chainID))
 		if err != nil {
 			logger.Fatalf("failed to set the SubchainTNT20TokenBank contract: %v\n", err)
 		}
@@ -0,0 +1,194 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/hexutil"
+	ethereum "github.com/thetatoken/thetasubchain/eth"
+	"github.com/thetatoken/thetasubchain/eth/abi"
+	"github.com/thetatoken/thetasubchain/eth/core/types"
+	ec "github.com/thetatoken/thetasubchain/eth/ethclient"
+	"github.com/thetatoken/thetasubchain/eth/rpc"
+
+	score "github.com/thetatoken/thetasubchain/core"
+)
+
+// Relay outcome status
+const (
+	RelayStatusSubmitted         = "submitted"          // the simulation succeeded and the tx was submitted
+	RelayStatusAlreadyProcessed  = "already_processed"  // the target chain had already processed the event, nothing was submitted
+	RelayStatusReverted          = "reverted"           // the simulation reverted, nothing was submitted
+	RelayStatusSimulationFailed  = "simulation_failed"  // the simulation could not be performed, nothing was submitted
+	RelayStatusSubmissionFailed  = "submission_failed"  // the simulation succeeded but the tx could not be submitted
+	RelayStatusTxFailed          = "tx_failed"          // the tx was submitted but failed on the target chain
+	RelayStatusPreparationFailed = "preparation_failed" // the tx could not be built
+)
+
+// RelayOutcome records the result of the latest relay attempt of an inter-chain event
+type RelayOutcome struct {
+	EventID       string                           `json:"event_id"`
+	SourceChainID *big.Int                         `json:"source_chain_id"`
+	TargetChainID *big.Int                         `json:"target_chain_id"`
+	EventType     score.InterChainMessageEventType `json:"event_type"`
+	Nonce         *big.Int                         `json:"nonce"`
+	Status        string                           `json:"status"`
+	RevertReason  string                           `json:"revert_reason"`
+	Error         string                           `json:"error"`
+	TxHash        common.Hash                      `json:"tx_hash"`
+	NumAttempts   uint64                           `json:"num_attempts"`
+	Time          time.Time                        `json:"time"`
+}
+
+// RevertError is returned when the simulation of a relay tx reverts on the target chain
+type RevertError struct {
+	Reason string
+	Err    error
+}
+
+func (e *RevertError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("execution reverted: %v", e.Err)
+	}
+	return fmt.Sprintf("execution reverted: %v", e.Reason)
+}
+
+// simulateTx runs the signed (but not yet submitted) tx through eth_call against the latest state of the target chain
+func (oc *Orchestrator) simulateTx(ecClient *ec.Client, tx *types.Transaction) error {
+	return oc.simulateTxFrom(ecClient, oc.privateKey.PublicKey().Address(), tx)
+}
+
+// simulateTxFrom runs the call of the tx through eth_call as if it was sent from the given account
+func (oc *Orchestrator) simulateTxFrom(ecClient *ec.Client, from common.Address, tx *types.Transaction) error {
+	msg := ethereum.CallMsg{
+		From:     from,
+		To:       tx.To(),
+		Gas:      tx.Gas(),
+		GasPrice: tx.GasPrice(),
+		Value:    tx.Value(),
+		Data:     tx.Data(),
+	}
+	_, err := ecClient.CallContract(context.Background(), msg, nil)
+	if err == nil {
+		return nil
+	}
+
+	// The revert reason, if any, is returned as the data of the JSON-RPC error. Any JSON-RPC error carries a data
+	// field, so the errors without data (e.g. insufficient funds) are only reverts if reported as such.
+	var revertData interface{}
+	if dataErr, ok := err.(rpc.DataError); ok {
+		revertData = dataErr.ErrorData()
+	}
+	if revertData == nil && !strings.HasPrefix(err.Error(), "execution reverted") {
+		return err
+	}
+	reason := ""
+	if hexData, ok := revertData.(string); ok {
+		if data, decodeErr := hexutil.Decode(hexData); decodeErr == nil {
+			reason, _ = abi.UnpackRevert(data)
+		}
+	}
+	return &RevertError{Reason: reason, Err: err}
+}
+
+// isEventProcessed checks whether the target chain has already processed the event
+func (oc *Orchestrator) isEventProcessed(sourceEvent *score.InterChainMessageEvent) (bool, error) {
+	maxProcessedNonce, err := oc.getMaxProcessedNonce(sourceEvent.SourceChainID, sourceEvent.TargetChainID, sourceEvent.Type)
+	if err != nil {
+		return false, err
+	}
+	return sourceEvent.Nonce.Cmp(maxProcessedNonce) <= 0, nil
+}
+
+// isRelayTxFailed checks whether the tx last submitted for the event has failed on the target chain, in which
+// case the event can be relayed again without waiting for the retry threshold
+func (oc *Orchestrator) isRelayTxFailed(targetChainID *big.Int, sourceEvent *score.InterChainMessageEvent) bool {
+	oc.relayOutcomesMutex.Lock()
+	outcome, exists := oc.relayOutcomes[sourceEvent.ID()]
+	submitted := exists && outcome.Status == RelayStatusSubmitted
+	var txHash common.Hash
+	if submitted {
+		txHash = outcome.TxHash
+	}
+	oc.relayOutcomesMutex.Unlock()
+	if !submitted {
+		return false
+	}
+
+	ecClient := oc.getEthRpcClient(targetChainID)
+	if ecClient == nil {
+		return false
+	}
+	receipt, err := ecClient.TransactionReceipt(context.Background(), txHash)
+	if err != nil || receipt.Status != types.ReceiptStatusFailed {
+		return false // still pending, or succeeded and about to be witnessed
+	}
+
+	logger.Warnf("Relay tx %v for event %v failed on chain %v, relaying the event again", txHash.Hex(), sourceEvent.ID(), targetChainID)
+	oc.relayOutcomesMutex.Lock()
+	defer oc.relayOutcomesMutex.Unlock()
+	outcome.Status = RelayStatusTxFailed // the outcome of the same attempt, so NumAttempts is left unchanged
+	outcome.Error = fmt.Sprintf("tx %v failed on the target chain", txHash.Hex())
+	outcome.Time = time.Now()
+	return true
+}
+
+// recordRelayOutcome records the result of a relay attempt of the event. The err should be
+// nil iff the status is RelayStatusSubmitted or RelayStatusAlreadyProcessed.
+func (oc *Orchestrator) recordRelayOutcome(sourceEvent *score.InterChainMessageEvent, status string, txHash common.Hash, err error) {
+	oc.relayOutcomesMutex.Lock()
+	defer oc.relayOutcomesMutex.Unlock()
+
+	eventID := sourceEvent.ID()
+	outcome, exists := oc.relayOutcomes[eventID]
+	if !exists {
+		outcome = &RelayOutcome{
+			EventID:       eventID,
+			SourceChainID: sourceEvent.SourceChainID,
+			TargetChainID: sourceEvent.TargetChainID,
+			EventType:     sourceEvent.Type,
+			Nonce:         sourceEvent.Nonce,
+		}
+		oc.relayOutcomes[eventID] = outcome
+	}
+	outcome.Status = status
+	outcome.TxHash = txHash
+	outcome.RevertReason = ""
+	outcome.Error = ""
+	if revertErr, ok := err.(*RevertError); ok {
+		outcome.RevertReason = revertErr.Reason
+	}
+	if err != nil {
+		outcome.Error = err.Error()
+	}
+	outcome.NumAttempts++
+	outcome.Time = time.Now()
+}
+
+// removeRelayOutcome drops the outcome of an event once the event has been processed by the target chain
+func (oc *Orchestrator) removeRelayOutcome(sourceEvent *score.InterChainMessageEvent) {
+	oc.relayOutcomesMutex.Lock()
+	defer oc.relayOutcomesMutex.Unlock()
+
+	delete(oc.relayOutcomes, sourceEvent.ID())
+}
+
+// GetRelayOutcomes returns the outcomes of the latest relay attempts of the events not yet processed by their target chains
+func (oc *Orchestrator) GetRelayOutcomes() []RelayOutcome {
+	oc.relayOutcomesMutex.Lock()
+	defer oc.relayOutcomesMutex.Unlock()
+
+	outcomes := []RelayOutcome{}
+	for _, outcome := range oc.relayOutcomes {
+		outcomes = append(outcomes, *outcome)
+	}
+	sort.Slice(outcomes, func(i, j int) bool {
+		return outcomes[i].Time.Before(outcomes[j].Time)
+	})
+	return outcomes
+}
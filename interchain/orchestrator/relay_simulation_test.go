@@ -0,0 +1,183 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/hexutil"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/thetasubchain/eth/abi"
+	"github.com/thetatoken/thetasubchain/eth/core/types"
+	ec "github.com/thetatoken/thetasubchain/eth/ethclient"
+
+	score "github.com/thetatoken/thetasubchain/core"
+)
+
+// newTestEthRpcServer answers all the JSON-RPC calls with the given HTTP status and error, or with the result
+// given for the method, "0x" by default
+func newTestEthRpcServer(status int, rpcErr map[string]interface{}, results map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(newTestEthRpcHandler(status, rpcErr, results))
+}
+
+func newTestEthRpcHandler(status int, rpcErr map[string]interface{}, results map[string]interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != http.StatusOK {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		if rpcErr != nil {
+			resp["error"] = rpcErr
+		} else if result, ok := results[req.Method]; ok {
+			resp["result"] = result
+		} else {
+			resp["result"] = "0x"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+}
+
+// packRevertReason encodes the reason the way the EVM returns it, i.e. as an Error(string) call
+func packRevertReason(t *testing.T, reason string) string {
+	stringType, err := abi.NewType("string", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packed, err := abi.Arguments{{Type: stringType}}.Pack(reason)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hexutil.Encode(append(crypto.Keccak256([]byte("Error(string)"))[:4], packed...))
+}
+
+func TestSimulateTx(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oc := &Orchestrator{privateKey: privKey}
+	tx := types.NewTransaction(1, common.HexToAddress("0x5a443704dd4B594B382c22a083e2BD3090A6feF3"), big.NewInt(0),
+		100000, big.NewInt(4000000000000), common.Hex2Bytes("a9059cbb"))
+
+	tests := []struct {
+		name     string
+		status   int
+		rpcErr   map[string]interface{}
+		isRevert bool
+		reason   string
+	}{
+		{"success", http.StatusOK, nil, false, ""},
+		{
+			"revert with a reason",
+			http.StatusOK,
+			map[string]interface{}{"code": 3, "message": "execution reverted: invalid nonce", "data": packRevertReason(t, "invalid nonce")},
+			true,
+			"invalid nonce",
+		},
+		{
+			"revert without data",
+			http.StatusOK,
+			map[string]interface{}{"code": -32000, "message": "execution reverted"},
+			true,
+			"",
+		},
+		{
+			"revert with malformed data",
+			http.StatusOK,
+			map[string]interface{}{"code": 3, "message": "execution reverted", "data": "0x1234"},
+			true,
+			"",
+		},
+		{
+			"non-revert RPC error",
+			http.StatusOK,
+			map[string]interface{}{"code": -32000, "message": "insufficient funds for gas * price + value"},
+			false,
+			"",
+		},
+		{"unavailable RPC server", http.StatusServiceUnavailable, nil, false, ""},
+	}
+	for _, test := range tests {
+		server := newTestEthRpcServer(test.status, test.rpcErr, nil)
+		ecClient, err := ec.Dial(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = oc.simulateTx(ecClient, tx)
+		revertErr, isRevert := err.(*RevertError)
+		assert.Equal(test.status == http.StatusOK && test.rpcErr == nil, err == nil, "%v: %v", test.name, err)
+		assert.Equal(test.isRevert, isRevert, "%v: %v", test.name, err)
+		if isRevert {
+			assert.Equal(test.reason, revertErr.Reason, test.name)
+		}
+
+		ecClient.Close()
+		server.Close()
+	}
+}
+
+func TestRecordRelayOutcome(t *testing.T) {
+	assert := assert.New(t)
+
+	oc := &Orchestrator{
+		relayOutcomes:      make(map[string]*RelayOutcome),
+		relayOutcomesMutex: &sync.Mutex{},
+	}
+	lock1 := newTestEvent(score.IMCEventTypeCrossChainTokenLockTFuel, testMainchainID, testSubchainID, 1)
+	lock2 := newTestEvent(score.IMCEventTypeCrossChainTokenLockTFuel, testMainchainID, testSubchainID, 2)
+	burn1 := newTestEvent(score.IMCEventTypeCrossChainVoucherBurnTFuel, testSubchainID, testMainchainID, 1)
+	txHash := common.HexToHash("0x01")
+
+	// The revert reason is recorded with the event it was simulated for
+	oc.recordRelayOutcome(lock1, RelayStatusReverted, txHash, &RevertError{Reason: "invalid nonce"})
+	oc.recordRelayOutcome(burn1, RelayStatusSimulationFailed, txHash, errors.New("connection refused"))
+	oc.recordRelayOutcome(lock2, RelayStatusReverted, txHash, &RevertError{Err: errors.New("execution reverted")})
+
+	outcomes := map[string]RelayOutcome{}
+	for _, outcome := range oc.GetRelayOutcomes() {
+		outcomes[outcome.EventID] = outcome
+	}
+	assert.Equal(3, len(outcomes))
+	assert.Equal(RelayStatusReverted, outcomes[lock1.ID()].Status)
+	assert.Equal("invalid nonce", outcomes[lock1.ID()].RevertReason)
+	assert.Equal("execution reverted: invalid nonce", outcomes[lock1.ID()].Error)
+	assert.Equal(0, big.NewInt(1).Cmp(outcomes[lock1.ID()].Nonce))
+	assert.Equal(RelayStatusSimulationFailed, outcomes[burn1.ID()].Status)
+	assert.Equal("", outcomes[burn1.ID()].RevertReason)
+	assert.Equal("connection refused", outcomes[burn1.ID()].Error)
+	assert.Equal(RelayStatusReverted, outcomes[lock2.ID()].Status)
+	assert.Equal("", outcomes[lock2.ID()].RevertReason)
+	assert.Equal("execution reverted: execution reverted", outcomes[lock2.ID()].Error)
+
+	// A later attempt replaces the reason of the earlier one
+	oc.recordRelayOutcome(lock1, RelayStatusSubmitted, common.HexToHash("0x02"), nil)
+	outcomes = map[string]RelayOutcome{}
+	for _, outcome := range oc.GetRelayOutcomes() {
+		outcomes[outcome.EventID] = outcome
+	}
+	assert.Equal(RelayStatusSubmitted, outcomes[lock1.ID()].Status)
+	assert.Equal("", outcomes[lock1.ID()].RevertReason)
+	assert.Equal("", outcomes[lock1.ID()].Error)
+	assert.Equal(uint64(2), outcomes[lock1.ID()].NumAttempts)
+	assert.Equal(uint64(1), outcomes[lock2.ID()].NumAttempts)
+
+	// The outcome is dropped once the target chain has processed the event
+	oc.removeRelayOutcome(lock1)
+	assert.Equal(2, len(oc.GetRelayOutcomes()))
+}
@@ -0,0 +1,289 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	score "github.com/thetatoken/thetasubchain/core"
+)
+
+// maxRelayBackoff caps the exponential backoff of a failing relay stream
+const maxRelayBackoff = 5 * time.Minute
+
+// RelayStreamHealth summarizes the relay progress of a single (source chain, target chain, event type) stream
+type RelayStreamHealth struct {
+	SourceChainID       *big.Int                         `json:"source_chain_id"`
+	TargetChainID       *big.Int                         `json:"target_chain_id"`
+	EventType           score.InterChainMessageEventType `json:"event_type"`
+	Healthy             bool                             `json:"healthy"`
+	NumRelayed          uint64                           `json:"num_relayed"`
+	NumFailures         uint64                           `json:"num_failures"`
+	ConsecutiveFailures uint64                           `json:"consecutive_failures"`
+	LastError           string                           `json:"last_error"`
+	LastErrorTime       time.Time                        `json:"last_error_time"`
+	LastSuccessTime     time.Time                        `json:"last_success_time"`
+	BackoffUntil        time.Time                        `json:"backoff_until"`
+}
+
+// relayStream relays the events of one (source chain, target chain, event type) combination in order of
+// their nonces. Each stream runs in its own worker with its own backoff, so a slow or failing stream does
+// not delay the others.
+type relayStream struct {
+	sourceChainID *big.Int
+	targetChainID *big.Int
+	eventType     score.InterChainMessageEventType
+	trigger       chan struct{}
+
+	mutex      *sync.Mutex
+	health     RelayStreamHealth
+	retryTimer *time.Timer
+}
+
+func newRelayStream(sourceChainID *big.Int, targetChainID *big.Int, eventType score.InterChainMessageEventType) *relayStream {
+	return &relayStream{
+		sourceChainID: sourceChainID,
+		targetChainID: targetChainID,
+		eventType:     eventType,
+		trigger:       make(chan struct{}, 1),
+		mutex:         &sync.Mutex{},
+		health: RelayStreamHealth{
+			SourceChainID: sourceChainID,
+			TargetChainID: targetChainID,
+			EventType:     eventType,
+			Healthy:       true,
+		},
+	}
+}
+
+func relayStreamKey(sourceChainID *big.Int, targetChainID *big.Int, eventType score.InterChainMessageEventType) string {
+	return fmt.Sprintf("%v/%v/%v", sourceChainID, targetChainID, eventType)
+}
+
+func (rs *relayStream) key() string {
+	return relayStreamKey(rs.sourceChainID, rs.targetChainID, rs.eventType)
+}
+
+// notify wakes up the stream worker, without blocking if a wake up is already pending
+func (rs *relayStream) notify() {
+	select {
+	case rs.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// scheduleRetry wakes up the stream worker after the given delay, replacing the wake up scheduled before if any
+func (rs *relayStream) scheduleRetry(delay time.Duration) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	if rs.retryTimer != nil {
+		rs.retryTimer.Stop()
+	}
+	rs.retryTimer = time.AfterFunc(delay, rs.notify)
+}
+
+func (rs *relayStream) stopRetry() {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	if rs.retryTimer != nil {
+		rs.retryTimer.Stop()
+		rs.retryTimer = nil
+	}
+}
+
+// matches returns whether the witnessed event could unblock this stream. Source chain events (token lock, voucher
+// burn, channel registration) are matched exactly, while target chain events (voucher mint, token unlock) signal
+// that the previous event of the stream has been processed and the next one can be relayed.
+func (rs *relayStream) matches(event *score.InterChainMessageEvent) bool {
+	if event.Type == rs.eventType {
+		if rs.eventType == score.IMCEInterSubchainChannelRegistered {
+			return true
+		}
+		return event.SourceChainID != nil && event.TargetChainID != nil &&
+			event.SourceChainID.Cmp(rs.sourceChainID) == 0 && event.TargetChainID.Cmp(rs.targetChainID) == 0
+	}
+	if event.TargetChainID == nil || event.TargetChainID.Cmp(rs.targetChainID) != 0 {
+		return false
+	}
+	switch event.Type {
+	case score.IMCEventTypeCrossChainVoucherMintTFuel:
+		return rs.eventType == score.IMCEventTypeCrossChainTokenLockTFuel
+	case score.IMCEventTypeCrossChainVoucherMintTNT20:
+		return rs.eventType == score.IMCEventTypeCrossChainTokenLockTNT20
+	case score.IMCEventTypeCrossChainVoucherMintTNT721:
+		return rs.eventType == score.IMCEventTypeCrossChainTokenLockTNT721
+	case score.IMCEventTypeCrossChainTokenUnlockTFuel:
+		return rs.eventType == score.IMCEventTypeCrossChainVoucherBurnTFuel
+	case score.IMCEventTypeCrossChainTokenUnlockTNT20:
+		return rs.eventType == score.IMCEventTypeCrossChainVoucherBurnTNT20
+	case score.IMCEventTypeCrossChainTokenUnlockTNT721:
+		return rs.eventType == score.IMCEventTypeCrossChainVoucherBurnTNT721
+	}
+	return false
+}
+
+// backoffRemaining returns how long the stream should still wait before the next attempt
+func (rs *relayStream) backoffRemaining() time.Duration {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	return time.Until(rs.health.BackoffUntil)
+}
+
+// recordResult updates the health counters, and doubles the backoff for each consecutive failure
+func (rs *relayStream) recordResult(relayed bool, err error, baseBackoff time.Duration) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	now := time.Now()
+	if err == nil {
+		if relayed {
+			rs.health.NumRelayed++
+			rs.health.LastSuccessTime = now
+		}
+		rs.health.ConsecutiveFailures = 0
+		rs.health.BackoffUntil = time.Time{}
+		rs.health.Healthy = true
+		return
+	}
+
+	rs.health.NumFailures++
+	rs.health.ConsecutiveFailures++
+	rs.health.LastError = err.Error()
+	rs.health.LastErrorTime = now
+	rs.health.Healthy = false
+
+	backoff := baseBackoff
+	for i := uint64(1); i < rs.health.ConsecutiveFailures && backoff < maxRelayBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRelayBackoff {
+		backoff = maxRelayBackoff
+	}
+	rs.health.BackoffUntil = now.Add(backoff)
+}
+
+func (rs *relayStream) getHealth() RelayStreamHealth {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	return rs.health
+}
+
+// addRelayStream registers a stream and starts its worker if the stream does not exist yet
+func (oc *Orchestrator) addRelayStream(sourceChainID *big.Int, targetChainID *big.Int, eventType score.InterChainMessageEventType) {
+	oc.streamsMutex.Lock()
+	defer oc.streamsMutex.Unlock()
+
+	key := relayStreamKey(sourceChainID, targetChainID, eventType)
+	if _, exists := oc.relayStreams[key]; exists {
+		return
+	}
+	rs := newRelayStream(sourceChainID, targetChainID, eventType)
+	oc.relayStreams[key] = rs
+
+	oc.wg.Add(1)
+	go oc.runRelayStream(oc.ctx, rs)
+	rs.notify() // process the pending events right away
+}
+
+// notifyRelayStreams wakes up the streams that might be able to make progress given the witnessed events
+func (oc *Orchestrator) notifyRelayStreams(events []*score.InterChainMessageEvent) {
+	oc.streamsMutex.Lock()
+	defer oc.streamsMutex.Unlock()
+
+	for _, rs := range oc.relayStreams {
+		for _, event := range events {
+			if rs.matches(event) {
+				rs.notify()
+				break
+			}
+		}
+	}
+}
+
+// scheduleRelayRetry wakes up the worker of the given stream after the delay
+func (oc *Orchestrator) scheduleRelayRetry(sourceChainID *big.Int, targetChainID *big.Int, eventType score.InterChainMessageEventType, delay time.Duration) {
+	oc.streamsMutex.Lock()
+	rs, exists := oc.relayStreams[relayStreamKey(sourceChainID, targetChainID, eventType)]
+	oc.streamsMutex.Unlock()
+
+	if exists {
+		rs.scheduleRetry(delay)
+	}
+}
+
+func (oc *Orchestrator) runRelayStream(ctx context.Context, rs *relayStream) {
+	defer oc.wg.Done()
+	defer rs.stopRetry()
+
+	// Relaying is triggered by the witness notifications, the ticker only serves as a slow safety sweep
+	sweepTicker := time.NewTicker(time.Duration(oc.sweepInterval) * time.Millisecond)
+	defer sweepTicker.Stop()
+
+	baseBackoff := time.Duration(oc.updateInterval) * time.Millisecond
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-rs.trigger:
+		case <-sweepTicker.C:
+		}
+
+		if wait := rs.backoffRemaining(); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+
+		relayed, err := oc.relayNextEvent(rs.sourceChainID, rs.targetChainID, rs.eventType)
+		if err != nil {
+			logger.Warnf("Failed to relay event stream %v: %v", rs.key(), err)
+			rs.notify() // retry once the backoff expires
+		}
+		rs.recordResult(relayed, err, baseBackoff)
+	}
+}
+
+// GetRelayStreamHealth returns the health of all the relay streams, sorted by stream key
+func (oc *Orchestrator) GetRelayStreamHealth() []RelayStreamHealth {
+	oc.streamsMutex.Lock()
+	keys := []string{}
+	for key := range oc.relayStreams {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	streams := []*relayStream{}
+	for _, key := range keys {
+		streams = append(streams, oc.relayStreams[key])
+	}
+	oc.streamsMutex.Unlock()
+
+	healthList := []RelayStreamHealth{}
+	for _, rs := range streams {
+		healthList = append(healthList, rs.getHealth())
+	}
+	return healthList
+}
+
+// lockTxSubmission serializes the transaction submissions to the same chain, since the stream workers
+// share the same account and thus the same account nonce on each chain
+func (oc *Orchestrator) lockTxSubmission(chainID *big.Int) func() {
+	oc.txLocksMutex.Lock()
+	lock, exists := oc.txSubmissionLocks[chainID.String()]
+	if !exists {
+		lock = &sync.Mutex{}
+		oc.txSubmissionLocks[chainID.String()] = lock
+	}
+	oc.txLocksMutex.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
@@ -0,0 +1,332 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/store/database/backend"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+	siu "github.com/thetatoken/thetasubchain/interchain/utils"
+)
+
+var (
+	testMainchainID = big.NewInt(366)
+	testSubchainID  = big.NewInt(360777)
+)
+
+// relayRecorder replaces the relay of the stream workers, and records the streams relayed
+type relayRecorder struct {
+	mutex   *sync.Mutex
+	relayed []string
+	err     error
+}
+
+func newRelayRecorder() *relayRecorder {
+	return &relayRecorder{mutex: &sync.Mutex{}}
+}
+
+func (rr *relayRecorder) relay(sourceChainID *big.Int, targetChainID *big.Int, eventType score.InterChainMessageEventType) (bool, error) {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+
+	rr.relayed = append(rr.relayed, relayStreamKey(sourceChainID, targetChainID, eventType))
+	return rr.err == nil, rr.err
+}
+
+func (rr *relayRecorder) setError(err error) {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+
+	rr.err = err
+}
+
+// take waits until the given number of relays have been made, or the timeout expires, and returns the relays made
+func (rr *relayRecorder) take(num int, timeout time.Duration) []string {
+	deadline := time.Now().Add(timeout)
+	for {
+		rr.mutex.Lock()
+		if len(rr.relayed) >= num || time.Now().After(deadline) {
+			relayed := rr.relayed
+			rr.relayed = nil
+			rr.mutex.Unlock()
+			return relayed
+		}
+		rr.mutex.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// newTestOrchestrator creates an orchestrator whose stream workers relay through the given recorder. The
+// sweep is disabled in effect, so the workers only relay when they are notified.
+func newTestOrchestrator(rr *relayRecorder) *Orchestrator {
+	oc := &Orchestrator{
+		updateInterval:          100,
+		sweepInterval:           3600 * 1000,
+		newEventsCh:             make(chan []*score.InterChainMessageEvent, 16),
+		eventProcessedTime:      make(map[string]time.Time),
+		eventProcessedTimeMutex: &sync.Mutex{},
+		relayStreams:            make(map[string]*relayStream),
+		streamsMutex:            &sync.Mutex{},
+		txSubmissionLocks:       make(map[string]*sync.Mutex),
+		txLocksMutex:            &sync.Mutex{},
+		relayOutcomes:           make(map[string]*RelayOutcome),
+		relayOutcomesMutex:      &sync.Mutex{},
+		mainchainID:             testMainchainID,
+		subchainID:              testSubchainID,
+		interChainEventCache:    siu.NewInterChainEventCache(backend.NewMemDatabase()),
+		wg:                      &sync.WaitGroup{},
+	}
+	oc.relayNextEvent = rr.relay
+	oc.ctx, oc.cancel = context.WithCancel(context.Background())
+	oc.newEventsSub = oc.interChainEventCache.SubscribeNewEvents(oc.newEventsCh)
+	oc.wg.Add(1)
+	go oc.notificationLoop(oc.ctx)
+	return oc
+}
+
+func stopTestOrchestrator(oc *Orchestrator) {
+	oc.Stop()
+	oc.Wait()
+}
+
+func newTestEvent(eventType score.InterChainMessageEventType, sourceChainID *big.Int, targetChainID *big.Int, nonce int64) *score.InterChainMessageEvent {
+	return score.NewInterChainMessageEvent(eventType, sourceChainID, targetChainID, common.Address{}, common.Address{},
+		common.Bytes{}, big.NewInt(nonce), big.NewInt(100))
+}
+
+func TestWitnessNotificationTriggersRelay(t *testing.T) {
+	assert := assert.New(t)
+
+	rr := newRelayRecorder()
+	oc := newTestOrchestrator(rr)
+	defer stopTestOrchestrator(oc)
+
+	lockStream := relayStreamKey(testMainchainID, testSubchainID, score.IMCEventTypeCrossChainTokenLockTFuel)
+	burnStream := relayStreamKey(testSubchainID, testMainchainID, score.IMCEventTypeCrossChainVoucherBurnTFuel)
+	oc.addRelayStream(testMainchainID, testSubchainID, score.IMCEventTypeCrossChainTokenLockTFuel)
+	oc.addRelayStream(testSubchainID, testMainchainID, score.IMCEventTypeCrossChainVoucherBurnTFuel)
+	oc.addRelayStream(testSubchainID, testMainchainID, score.IMCEventTypeCrossChainTokenLockTNT20)
+
+	// Each stream processes its pending events once it is added
+	assert.ElementsMatch([]string{lockStream, burnStream,
+		relayStreamKey(testSubchainID, testMainchainID, score.IMCEventTypeCrossChainTokenLockTNT20)}, rr.take(3, time.Second))
+
+	tests := []struct {
+		name    string
+		events  []*score.InterChainMessageEvent
+		relayed []string
+	}{
+		{
+			"token lock",
+			[]*score.InterChainMessageEvent{newTestEvent(score.IMCEventTypeCrossChainTokenLockTFuel, testMainchainID, testSubchainID, 1)},
+			[]string{lockStream},
+		},
+		{
+			"voucher mint of the previous token lock",
+			[]*score.InterChainMessageEvent{newTestEvent(score.IMCEventTypeCrossChainVoucherMintTFuel, testMainchainID, testSubchainID, 1)},
+			[]string{lockStream},
+		},
+		{
+			"events of two streams",
+			[]*score.InterChainMessageEvent{
+				newTestEvent(score.IMCEventTypeCrossChainTokenLockTFuel, testMainchainID, testSubchainID, 2),
+				newTestEvent(score.IMCEventTypeCrossChainVoucherBurnTFuel, testSubchainID, testMainchainID, 1),
+			},
+			[]string{lockStream, burnStream},
+		},
+		{
+			"token lock for another subchain",
+			[]*score.InterChainMessageEvent{newTestEvent(score.IMCEventTypeCrossChainTokenLockTFuel, testSubchainID, big.NewInt(360888), 1)},
+			[]string{},
+		},
+		{
+			"event type without a stream",
+			[]*score.InterChainMessageEvent{newTestEvent(score.IMCEventTypeCrossChainTokenLockTNT721, testMainchainID, testSubchainID, 1)},
+			[]string{},
+		},
+	}
+	for _, test := range tests {
+		assert.Nil(oc.interChainEventCache.InsertList(test.events, testMainchainID, testSubchainID), test.name)
+
+		// Wait for the expected relays, and for any extra relay to show up
+		relayed := rr.take(len(test.relayed), time.Second)
+		time.Sleep(100 * time.Millisecond)
+		relayed = append(relayed, rr.take(0, 0)...)
+		assert.ElementsMatch(test.relayed, relayed, test.name)
+	}
+}
+
+func TestRelayRetryScheduled(t *testing.T) {
+	assert := assert.New(t)
+
+	rr := newRelayRecorder()
+	oc := newTestOrchestrator(rr)
+	defer stopTestOrchestrator(oc)
+
+	lockStream := relayStreamKey(testMainchainID, testSubchainID, score.IMCEventTypeCrossChainTokenLockTFuel)
+	oc.addRelayStream(testMainchainID, testSubchainID, score.IMCEventTypeCrossChainTokenLockTFuel)
+	assert.Equal([]string{lockStream}, rr.take(1, time.Second))
+
+	// A stream waiting for its submitted tx to be finalized checks the tx again without being notified
+	oc.scheduleRelayRetry(testMainchainID, testSubchainID, score.IMCEventTypeCrossChainTokenLockTFuel, 50*time.Millisecond)
+	assert.Equal(0, len(rr.take(0, 0)))
+	assert.Equal([]string{lockStream}, rr.take(1, time.Second))
+
+	// Rescheduling replaces the previous retry
+	oc.scheduleRelayRetry(testMainchainID, testSubchainID, score.IMCEventTypeCrossChainTokenLockTFuel, 50*time.Millisecond)
+	oc.scheduleRelayRetry(testMainchainID, testSubchainID, score.IMCEventTypeCrossChainTokenLockTFuel, 100*time.Millisecond)
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal([]string{lockStream}, rr.take(0, 0))
+}
+
+func TestRelayStreamBackoff(t *testing.T) {
+	assert := assert.New(t)
+
+	rs := newRelayStream(testMainchainID, testSubchainID, score.IMCEventTypeCrossChainTokenLockTFuel)
+	baseBackoff := time.Second
+	relayErr := errors.New("target chain unreachable")
+
+	// The backoff doubles for each consecutive failure, up to the cap
+	tests := []struct {
+		name    string
+		backoff time.Duration
+	}{
+		{"first failure", time.Second},
+		{"second failure", 2 * time.Second},
+		{"third failure", 4 * time.Second},
+		{"fourth failure", 8 * time.Second},
+	}
+	for i, test := range tests {
+		rs.recordResult(false, relayErr, baseBackoff)
+		health := rs.getHealth()
+		assert.False(health.Healthy, test.name)
+		assert.Equal(uint64(i+1), health.ConsecutiveFailures, test.name)
+		assert.Equal(uint64(i+1), health.NumFailures, test.name)
+		assert.Equal(relayErr.Error(), health.LastError, test.name)
+		assert.InDelta(float64(test.backoff), float64(rs.backoffRemaining()), float64(100*time.Millisecond), test.name)
+	}
+	for i := 0; i < 20; i++ {
+		rs.recordResult(false, relayErr, baseBackoff)
+	}
+	assert.InDelta(float64(maxRelayBackoff), float64(rs.backoffRemaining()), float64(100*time.Millisecond), "capped backoff")
+
+	// A successful attempt resets the backoff, only a submitted tx counts as a relay
+	rs.recordResult(false, nil, baseBackoff)
+	health := rs.getHealth()
+	assert.True(health.Healthy)
+	assert.Equal(uint64(0), health.ConsecutiveFailures)
+	assert.Equal(uint64(24), health.NumFailures)
+	assert.Equal(uint64(0), health.NumRelayed)
+	assert.True(rs.backoffRemaining() <= 0)
+
+	rs.recordResult(true, nil, baseBackoff)
+	health = rs.getHealth()
+	assert.Equal(uint64(1), health.NumRelayed)
+	assert.False(health.LastSuccessTime.IsZero())
+
+	// The next failure backs off from the base again
+	rs.recordResult(false, relayErr, baseBackoff)
+	assert.Equal(uint64(1), rs.getHealth().ConsecutiveFailures)
+	assert.InDelta(float64(baseBackoff), float64(rs.backoffRemaining()), float64(100*time.Millisecond))
+}
+
+func TestRelayStreamHealth(t *testing.T) {
+	assert := assert.New(t)
+
+	rr := newRelayRecorder()
+	oc := newTestOrchestrator(rr)
+	defer stopTestOrchestrator(oc)
+
+	oc.addRelayStream(testSubchainID, testMainchainID, score.IMCEventTypeCrossChainVoucherBurnTFuel)
+	oc.addRelayStream(testMainchainID, testSubchainID, score.IMCEventTypeCrossChainTokenLockTFuel)
+	assert.Equal(2, len(rr.take(2, time.Second)))
+
+	// The health is listed in order of the stream keys
+	healthList := oc.GetRelayStreamHealth()
+	assert.Equal(2, len(healthList))
+	assert.Equal(0, testSubchainID.Cmp(healthList[0].SourceChainID))
+	assert.Equal(0, testMainchainID.Cmp(healthList[1].SourceChainID))
+	for _, health := range healthList {
+		assert.True(health.Healthy)
+		assert.Equal(uint64(1), health.NumRelayed)
+	}
+
+	// A failing stream retries by itself with an increasing backoff (100ms, 200ms, ...), without affecting the other stream
+	rr.setError(errors.New("target chain unreachable"))
+	oc.scheduleRelayRetry(testMainchainID, testSubchainID, score.IMCEventTypeCrossChainTokenLockTFuel, 0)
+	assert.Equal(3, len(rr.take(3, 2*time.Second)))
+	time.Sleep(20 * time.Millisecond)
+	healthList = oc.GetRelayStreamHealth()
+	lockHealth := healthList[1]
+	assert.False(lockHealth.Healthy)
+	assert.Equal(uint64(3), lockHealth.ConsecutiveFailures)
+	assert.Equal("target chain unreachable", lockHealth.LastError)
+	assert.True(lockHealth.BackoffUntil.After(lockHealth.LastErrorTime))
+	assert.True(healthList[0].Healthy)
+	assert.Equal(uint64(0), healthList[0].NumFailures)
+
+	// Once the target chain is back, the stream recovers at its next retry
+	rr.setError(nil)
+	assert.Equal(1, len(rr.take(1, 2*time.Second)))
+	time.Sleep(20 * time.Millisecond)
+	lockHealth = oc.GetRelayStreamHealth()[1]
+	assert.True(lockHealth.Healthy)
+	assert.Equal(uint64(0), lockHealth.ConsecutiveFailures)
+	assert.Equal(uint64(3), lockHealth.NumFailures)
+	assert.Equal(uint64(2), lockHealth.NumRelayed)
+}
+
+// testLedger provides the block interval and the gas price governed on the subchain
+type testLedger struct {
+	score.Ledger
+	height        uint64
+	blockInterval time.Duration
+	minGasPrice   *big.Int // for the next block
+}
+
+func (tl *testLedger) GetCurrentBlock() *score.Block {
+	block := score.NewBlock()
+	block.Height = tl.height
+	return block
+}
+
+func (tl *testLedger) GetMinBlockInterval(height uint64) time.Duration {
+	if height != tl.height {
+		return 0
+	}
+	return tl.blockInterval
+}
+
+func (tl *testLedger) GetMinimumGasPrice(height uint64) *big.Int {
+	if height != tl.height+1 {
+		return big.NewInt(0)
+	}
+	return tl.minGasPrice
+}
+
+func TestGetRetryThreshold(t *testing.T) {
+	assert := assert.New(t)
+
+	prevMainchainBlockInterval := viper.GetInt(scom.CfgSubchainMainchainBlockIntervalInSeconds)
+	defer viper.Set(scom.CfgSubchainMainchainBlockIntervalInSeconds, prevMainchainBlockInterval)
+	viper.Set(scom.CfgSubchainMainchainBlockIntervalInSeconds, 6)
+
+	ledger := &testLedger{height: 1000, blockInterval: 2 * time.Second}
+	oc := &Orchestrator{ledger: ledger, mainchainID: testMainchainID, subchainID: testSubchainID}
+	assert.Equal(24*time.Second, oc.getRetryThreshold(testMainchainID))
+	assert.Equal(8*time.Second, oc.getRetryThreshold(testSubchainID))
+
+	// The subchain threshold follows the block interval changed by governance
+	ledger.blockInterval = 500 * time.Millisecond
+	assert.Equal(500*time.Millisecond, oc.getBlockInterval(testSubchainID))
+	assert.Equal(2*time.Second, oc.getRetryThreshold(testSubchainID))
+}
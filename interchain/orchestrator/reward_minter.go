@@ -0,0 +1,277 @@
+package orchestrator
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/store/database"
+	"github.com/thetatoken/theta/store/kvstore"
+	"github.com/thetatoken/thetasubchain/eth/abi/bind"
+	"github.com/thetatoken/thetasubchain/eth/core/types"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+	scta "github.com/thetatoken/thetasubchain/interchain/contracts/accessors"
+)
+
+// maximum number of accounts the staker rewards are minted for per round
+const maxRewardMintsPerRound = 16
+
+func stakerRewardMintKey(account common.Address) common.Bytes {
+	return append(common.Bytes("oc/srm/"), account[:]...)
+}
+
+// stakerRewardMint tracks the staker rewards minted for an account on the mainchain
+type stakerRewardMint struct {
+	Minted        *big.Int    // total confirmed by the receipts of the mint txs
+	PendingTx     common.Hash // the mint tx waiting for its receipt, empty if none
+	PendingNonce  uint64
+	PendingAmount *big.Int
+}
+
+// stakerRewardMinter mints the staker rewards accrued on the subchain through SubchainGovernanceToken.MintStakerReward
+// on the mainchain. Only the minter of the governance token can mint, so the minter runs on the node holding that key,
+// and stays idle on the other nodes. The accrued rewards in the subchain state only grow, and the minter keeps the total
+// minted for each account, so that it mints the difference. A mint tx is recorded before it is sent, and nothing more is
+// minted for the account until the tx has a receipt, or another tx has taken its nonce, so a reward is never minted twice.
+// The minter also keeps SubchainGovernanceToken.stakerRewardPerBlock in line with the reward schedule of the subchain
+// through UpdateStakerRewardPerBlock.
+type stakerRewardMinter struct {
+	oc *Orchestrator
+	db database.Database
+
+	enabled bool
+
+	mutex *sync.Mutex
+}
+
+func newStakerRewardMinter(oc *Orchestrator, db database.Database) *stakerRewardMinter {
+	return &stakerRewardMinter{
+		oc:      oc,
+		db:      db,
+		enabled: viper.GetBool(scom.CfgSubchainStakerRewardMintEnabled),
+		mutex:   &sync.Mutex{},
+	}
+}
+
+func (rm *stakerRewardMinter) mainloop(ctx context.Context) {
+	defer rm.oc.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(rm.oc.updateInterval) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if rm.enabled {
+				rm.mintAccruedRewards()
+			}
+		}
+	}
+}
+
+// mintAccruedRewards mints the staker rewards accrued since the previous mints, in the order of the account addresses
+func (rm *stakerRewardMinter) mintAccruedRewards() {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	oc := rm.oc
+	schedule, rewards, height, err := oc.ledger.GetFinalizedStakerRewards()
+	if err != nil {
+		logger.Warnf("Failed to get the finalized staker rewards: %v", err)
+		return
+	}
+	if schedule == nil {
+		return // the subchain pays no staker rewards
+	}
+
+	token, err := scta.NewSubchainGovernanceToken(schedule.GovernanceToken, oc.getEthRpcClient(oc.mainchainID))
+	if err != nil {
+		logger.Warnf("Failed to create the SubchainGovernanceToken contract: %v", err)
+		return
+	}
+	minter, err := token.Minter(nil)
+	if err != nil {
+		logger.Warnf("Failed to query the minter of the governance token: %v", err)
+		return
+	}
+	if minterAddr := oc.privateKey.PublicKey().Address(); minter != minterAddr {
+		logger.Errorf("The node key %v is not the minter %v of the governance token %v, disabling the staker reward minting",
+			minterAddr.Hex(), minter.Hex(), schedule.GovernanceToken.Hex())
+		rm.enabled = false
+		return
+	}
+
+	rm.updateStakerRewardPerBlock(token, schedule.StakerRewardPerBlock(height))
+
+	numMints := 0
+	for _, reward := range rewards {
+		if numMints >= maxRewardMintsPerRound {
+			return
+		}
+		mint := rm.getMint(reward.Account)
+		if !mint.PendingTx.IsEmpty() {
+			if !rm.checkPendingMint(reward.Account, mint) {
+				continue
+			}
+		}
+
+		amount := new(big.Int).Sub(reward.Amount, mint.Minted)
+		if amount.Sign() <= 0 {
+			continue
+		}
+		tx, err := rm.submitMint(token, reward, mint, amount)
+		numMints++
+		if revertErr, ok := err.(*RevertError); ok {
+			logger.Errorf("Failed to mint %v staker reward for %v, the mainchain rejected it: %v", amount, reward.Account.Hex(), revertErr)
+		} else if err != nil {
+			logger.Warnf("Failed to mint %v staker reward for %v, will retry: %v", amount, reward.Account.Hex(), err)
+			return
+		} else {
+			logger.Infof("Minted %v staker reward for %v, tx: %v", amount, reward.Account.Hex(), tx.Hash().Hex())
+		}
+	}
+}
+
+// checkPendingMint accounts the receipt of the pending mint tx of the account. It returns false if the tx is still pending.
+func (rm *stakerRewardMinter) checkPendingMint(account common.Address, mint *stakerRewardMint) bool {
+	oc := rm.oc
+	ecClient := oc.getEthRpcClient(oc.mainchainID)
+	receipt, err := ecClient.TransactionReceipt(context.Background(), mint.PendingTx)
+	if err != nil {
+		// without a receipt, the tx has been dropped once the nonce is taken by another tx
+		nonce, err := ecClient.NonceAt(context.Background(), oc.privateKey.PublicKey().Address(), nil)
+		if err != nil || nonce <= mint.PendingNonce {
+			return false
+		}
+		if _, err := ecClient.TransactionReceipt(context.Background(), mint.PendingTx); err == nil {
+			return false // included in the meantime, the receipt is accounted in the next round
+		}
+		logger.Warnf("The staker reward mint tx %v for %v has been dropped", mint.PendingTx.Hex(), account.Hex())
+	} else if receipt.Status == types.ReceiptStatusSuccessful {
+		mint.Minted.Add(mint.Minted, mint.PendingAmount)
+	} else {
+		logger.Errorf("The staker reward mint tx %v for %v failed", mint.PendingTx.Hex(), account.Hex())
+	}
+
+	mint.PendingTx = common.Hash{}
+	mint.PendingNonce = 0
+	mint.PendingAmount = big.NewInt(0)
+	if err := rm.setMint(account, mint); err != nil {
+		logger.Warnf("Failed to save the staker rewards minted for %v: %v", account.Hex(), err)
+		return false
+	}
+	return true
+}
+
+// submitMint calls MintStakerReward on the governance token. The call is simulated first, so nothing is submitted
+// if the mainchain would reject it, and the tx is recorded as pending before it is sent.
+func (rm *stakerRewardMinter) submitMint(token *scta.SubchainGovernanceToken, reward score.StakerReward, mint *stakerRewardMint,
+	amount *big.Int) (*types.Transaction, error) {
+	oc := rm.oc
+	unlock := oc.lockTxSubmission(oc.mainchainID)
+	defer unlock()
+
+	ecClient := oc.getEthRpcClient(oc.mainchainID)
+	txOpts, err := oc.buildTxOpts(oc.mainchainID, ecClient)
+	if err != nil {
+		return nil, err
+	}
+	txOpts.NoSend = true
+
+	tx, err := token.MintStakerReward(txOpts, reward.Account, amount)
+	if err != nil {
+		return nil, err
+	}
+	if err = oc.simulateTx(ecClient, tx); err != nil {
+		return tx, err
+	}
+
+	mint.PendingTx = tx.Hash()
+	mint.PendingNonce = tx.Nonce()
+	mint.PendingAmount = amount
+	if err = rm.setMint(reward.Account, mint); err != nil {
+		return tx, err
+	}
+	if err = ecClient.SendTransaction(context.Background(), tx); err != nil {
+		// the tx is recorded as pending, and is considered dropped once another tx takes its nonce
+		return tx, err
+	}
+	return tx, nil
+}
+
+// updateStakerRewardPerBlock updates the staker reward per block of the governance token if it differs from the
+// reward schedule of the subchain at the given height
+func (rm *stakerRewardMinter) updateStakerRewardPerBlock(token *scta.SubchainGovernanceToken, stakerRewardPerBlock *big.Int) {
+	current, err := token.StakerRewardPerBlock(nil)
+	if err != nil {
+		logger.Warnf("Failed to query the staker reward per block of the governance token: %v", err)
+		return
+	}
+	if current.Cmp(stakerRewardPerBlock) == 0 {
+		return
+	}
+
+	tx, err := rm.submitTx(func(txOpts *bind.TransactOpts) (*types.Transaction, error) {
+		return token.UpdateStakerRewardPerBlock(txOpts, stakerRewardPerBlock)
+	})
+	if err != nil {
+		logger.Warnf("Failed to update the staker reward per block of the governance token from %v to %v: %v",
+			current, stakerRewardPerBlock, err)
+		return
+	}
+	logger.Infof("Updated the staker reward per block of the governance token from %v to %v, tx: %v",
+		current, stakerRewardPerBlock, tx.Hash().Hex())
+}
+
+// submitTx simulates, then sends the tx created with the given function to the mainchain
+func (rm *stakerRewardMinter) submitTx(createTx func(txOpts *bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	oc := rm.oc
+	unlock := oc.lockTxSubmission(oc.mainchainID)
+	defer unlock()
+
+	ecClient := oc.getEthRpcClient(oc.mainchainID)
+	txOpts, err := oc.buildTxOpts(oc.mainchainID, ecClient)
+	if err != nil {
+		return nil, err
+	}
+	txOpts.NoSend = true
+
+	tx, err := createTx(txOpts)
+	if err != nil {
+		return nil, err
+	}
+	if err = oc.simulateTx(ecClient, tx); err != nil {
+		return tx, err
+	}
+	if err = ecClient.SendTransaction(context.Background(), tx); err != nil {
+		return tx, err
+	}
+	return tx, nil
+}
+
+func (rm *stakerRewardMinter) getMint(account common.Address) *stakerRewardMint {
+	mint := &stakerRewardMint{}
+	store := kvstore.NewKVStore(rm.db)
+	if err := store.Get(stakerRewardMintKey(account), mint); err != nil {
+		return &stakerRewardMint{Minted: big.NewInt(0), PendingAmount: big.NewInt(0)}
+	}
+	if mint.Minted == nil {
+		mint.Minted = big.NewInt(0)
+	}
+	if mint.PendingAmount == nil {
+		mint.PendingAmount = big.NewInt(0)
+	}
+	return mint
+}
+
+func (rm *stakerRewardMinter) setMint(account common.Address, mint *stakerRewardMint) error {
+	store := kvstore.NewKVStore(rm.db)
+	return store.Put(stakerRewardMintKey(account), mint)
+}
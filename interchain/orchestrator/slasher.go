@@ -0,0 +1,410 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+	ttypes "github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/store/database"
+	"github.com/thetatoken/theta/store/kvstore"
+	"github.com/thetatoken/thetasubchain/eth/core/types"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+// maximum number of equivocation records or governance proposals processed for slashing per round
+const maxSlashSubmissionsPerRound = 16
+
+// number of blocks the validators have to approve the slashing of a validator for its downtime
+const downtimeSlashVotingPeriodInBlocks uint64 = 1000
+
+func nextEquivocationRecordIndexKey() common.Bytes {
+	return common.Bytes("oc/neri")
+}
+
+func downtimeVoteProgressKey() common.Bytes {
+	return common.Bytes("oc/dvp")
+}
+
+func nextGovernanceProposalIDKey() common.Bytes {
+	return common.Bytes("oc/ngpi")
+}
+
+// downtimeVoteProgress points to the next offender of the downtime reports to vote on
+type downtimeVoteProgress struct {
+	Dynasty     *big.Int
+	OffenderIdx uint64
+}
+
+// equivocationSlasher submits the equivocation records finalized on the subchain to the chain registrar
+// on the mainchain, which slashes the collateral of the offending validators. SlashValidatorCollateral
+// is a privileged call, so the slasher should only be enabled on the node holding the authorized key.
+// If a downtime slash amount is configured, the validators whose downtime has been approved through
+// the governance proposals are slashed as well. The validators voting on the downtime run the slasher
+// with downtime voting enabled, which proposes or votes for the offenders of their downtime reports.
+type equivocationSlasher struct {
+	oc *Orchestrator
+	db database.Database
+
+	enabled             bool
+	voteDowntime        bool
+	slashAmount         *big.Int
+	downtimeSlashAmount *big.Int
+	guarantors          map[common.Address]common.Address
+	downtimeReporter    score.DowntimeReporter
+
+	mutex *sync.Mutex
+}
+
+func newEquivocationSlasher(oc *Orchestrator, db database.Database) *equivocationSlasher {
+	slashAmount, ok := new(big.Int).SetString(viper.GetString(scom.CfgSubchainSlashingValidatorCollateralSlashAmount), 10)
+	if !ok || slashAmount.Sign() <= 0 {
+		logger.Fatalf("invalid validator collateral slash amount: %v", viper.GetString(scom.CfgSubchainSlashingValidatorCollateralSlashAmount))
+	}
+
+	downtimeSlashAmount, ok := new(big.Int).SetString(viper.GetString(scom.CfgSubchainSlashingDowntimeSlashAmount), 10)
+	if !ok || downtimeSlashAmount.Sign() < 0 {
+		logger.Fatalf("invalid downtime slash amount: %v", viper.GetString(scom.CfgSubchainSlashingDowntimeSlashAmount))
+	}
+
+	guarantors := make(map[common.Address]common.Address)
+	for _, pair := range viper.GetStringSlice(scom.CfgSubchainSlashingGuarantors) {
+		addrs := strings.Split(pair, ":")
+		if len(addrs) != 2 || !common.IsHexAddress(addrs[0]) || !common.IsHexAddress(addrs[1]) {
+			logger.Fatalf("invalid validator guarantor pair: %v, expected format: validator:guarantor", pair)
+		}
+		guarantors[common.HexToAddress(addrs[0])] = common.HexToAddress(addrs[1])
+	}
+
+	return &equivocationSlasher{
+		oc:                  oc,
+		db:                  db,
+		enabled:             viper.GetBool(scom.CfgSubchainSlashingEnabled),
+		voteDowntime:        viper.GetBool(scom.CfgSubchainSlashingVoteDowntime),
+		slashAmount:         slashAmount,
+		downtimeSlashAmount: downtimeSlashAmount,
+		guarantors:          guarantors,
+		mutex:               &sync.Mutex{},
+	}
+}
+
+func (sl *equivocationSlasher) setDowntimeReporter(reporter score.DowntimeReporter) {
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+
+	sl.downtimeReporter = reporter
+}
+
+func (sl *equivocationSlasher) mainloop(ctx context.Context) {
+	defer sl.oc.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(sl.oc.updateInterval) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if sl.enabled {
+				sl.submitPendingRecords()
+				sl.submitApprovedDowntimeSlashes()
+			}
+			if sl.voteDowntime {
+				sl.voteDowntimeSlashes()
+			}
+		}
+	}
+}
+
+// submitPendingRecords submits the finalized equivocation records in the order of their indices. A record whose
+// simulation reverts (e.g. the collateral has already been withdrawn) is skipped, other failures are retried.
+func (sl *equivocationSlasher) submitPendingRecords() {
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+
+	nextIndex := sl.getNextIndex()
+	records, err := sl.oc.ledger.GetFinalizedEquivocationRecords(nextIndex, maxSlashSubmissionsPerRound)
+	if err != nil {
+		logger.Warnf("Failed to get the finalized equivocation records: %v", err)
+		return
+	}
+
+	for _, record := range records {
+		tx, err := sl.submitSlash(record.Evidence.Offender, sl.slashAmount)
+		if revertErr, ok := err.(*RevertError); ok {
+			logger.Errorf("Skipped slashing for equivocation record %v, the mainchain rejected it: %v, evidence: %v",
+				record.Index, revertErr, record.Evidence.String())
+		} else if err != nil {
+			logger.Warnf("Failed to submit slashing for equivocation record %v, will retry: %v", record.Index, err)
+			return
+		} else {
+			logger.Infof("Submitted slashing for equivocation record %v, offender: %v, amount: %v, tx: %v",
+				record.Index, record.Evidence.Offender.Hex(), sl.slashAmount, tx.Hash().Hex())
+		}
+
+		if err := sl.setNextIndex(record.Index + 1); err != nil {
+			logger.Warnf("Failed to save the next equivocation record index: %v", err)
+			return
+		}
+	}
+}
+
+// voteDowntimeSlashes proposes, or votes for, slashing the validators flagged in the downtime reports of the
+// dynasties that have ended. Reports not covering a whole dynasty (e.g. the node started in the middle of it)
+// are skipped, so that the validator never votes based on partial figures. A report only reflects what this
+// node observed, hence a validator is slashed only once validators holding 2/3 of the stake agree.
+func (sl *equivocationSlasher) voteDowntimeSlashes() {
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+
+	if sl.downtimeReporter == nil {
+		return
+	}
+	latestDynasty := sl.downtimeReporter.GetLatestLivenessDynasty()
+	if latestDynasty == nil {
+		return
+	}
+
+	progress := sl.getDowntimeVoteProgress()
+	if progress == nil {
+		// Start from the ongoing dynasty, the past ones are not voted on retroactively
+		progress = &downtimeVoteProgress{Dynasty: latestDynasty}
+		if err := sl.setDowntimeVoteProgress(progress); err != nil {
+			logger.Warnf("Failed to save the downtime vote progress: %v", err)
+		}
+		return
+	}
+
+	for progress.Dynasty.Cmp(latestDynasty) < 0 {
+		report, err := sl.downtimeReporter.GetDowntimeReport(progress.Dynasty)
+		if err != nil {
+			logger.Infof("No downtime report for dynasty %v: %v", progress.Dynasty, err)
+		} else if !report.Complete {
+			logger.Infof("Skipped the downtime report of dynasty %v, it does not cover the whole dynasty", progress.Dynasty)
+		} else {
+			for ; progress.OffenderIdx < uint64(len(report.Offenders)); progress.OffenderIdx++ {
+				offender := report.Offenders[progress.OffenderIdx]
+				if err := sl.voteDowntimeSlash(progress.Dynasty, offender); err != nil {
+					logger.Warnf("Failed to vote for the downtime slashing of validator %v in dynasty %v, will retry: %v",
+						offender.Liveness.Address.Hex(), progress.Dynasty, err)
+					return
+				}
+
+				next := &downtimeVoteProgress{Dynasty: progress.Dynasty, OffenderIdx: progress.OffenderIdx + 1}
+				if err := sl.setDowntimeVoteProgress(next); err != nil {
+					logger.Warnf("Failed to save the downtime vote progress: %v", err)
+					return
+				}
+			}
+		}
+
+		progress = &downtimeVoteProgress{Dynasty: new(big.Int).Add(progress.Dynasty, big.NewInt(1))}
+		if err := sl.setDowntimeVoteProgress(progress); err != nil {
+			logger.Warnf("Failed to save the downtime vote progress: %v", err)
+			return
+		}
+	}
+}
+
+// voteDowntimeSlash votes for the open proposal to slash the offender for the dynasty, or submits the proposal
+// if there is none. A vote or a proposal rejected by the mempool (e.g. one is pending already) is retried.
+func (sl *equivocationSlasher) voteDowntimeSlash(dynasty *big.Int, offender *score.DowntimeRecord) error {
+	oc := sl.oc
+	// the downtime reports refer to the validators by the keys they sign with, the proposals by their addresses
+	validator, err := oc.ledger.GetValidatorIdentity(offender.Liveness.Address)
+	if err != nil {
+		return err
+	}
+	value := score.DowntimeSlashValue(dynasty, validator)
+	proposal, err := oc.ledger.GetFinalizedDowntimeSlashProposal(value)
+	if err != nil {
+		return err
+	}
+	currentBlock := oc.ledger.GetCurrentBlock()
+	if currentBlock == nil {
+		return errors.New("the current block is not available")
+	}
+	nextHeight := currentBlock.Height + 1
+
+	voter, err := oc.ledger.GetValidatorIdentity(oc.privateKey.PublicKey().Address())
+	if err != nil {
+		return err
+	}
+	if voter != oc.privateKey.PublicKey().Address() {
+		logger.Warnf("The node key signs for validator %v, only the validator address can vote for the downtime slashing of %v",
+			voter.Hex(), validator.Hex())
+		return nil
+	}
+	isOpen := proposal != nil && nextHeight < proposal.ActivationHeight
+	if proposal != nil && (proposal.Approved || (isOpen && proposal.HasVoted(voter))) {
+		return nil
+	}
+
+	sequence, err := oc.nextNativeTxSequence()
+	if err != nil {
+		return err
+	}
+	tx := &stypes.SubchainGovernanceVoteTx{
+		Fee: ttypes.Coins{
+			ThetaWei: big.NewInt(0),
+			TFuelWei: ttypes.GetMinimumTransactionFeeTFuelWei(nextHeight),
+		},
+		Validator: ttypes.TxInput{
+			Address:  voter,
+			Sequence: sequence,
+		},
+	}
+	if isOpen {
+		tx.ProposalID = proposal.ID
+	} else {
+		tx.Param = score.GovParamDowntimeSlash
+		tx.Value = value
+		tx.ActivationHeight = nextHeight + downtimeSlashVotingPeriodInBlocks
+	}
+
+	if err := oc.submitNativeTx(tx); err != nil {
+		return err
+	}
+	logger.Infof("Voted for the downtime slashing of validator %v in dynasty %v, reasons: %v, tx: %v",
+		validator.Hex(), dynasty, offender.Reasons, tx)
+	return nil
+}
+
+// submitApprovedDowntimeSlashes slashes the validators whose downtime has been approved through the governance
+// proposals, in the order of the proposal IDs. It stops at the first downtime slash proposal still open for votes.
+func (sl *equivocationSlasher) submitApprovedDowntimeSlashes() {
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+
+	if sl.downtimeSlashAmount.Sign() == 0 {
+		return
+	}
+
+	nextID := sl.getNextProposalID()
+	proposals, finalizedHeight, err := sl.oc.ledger.GetFinalizedGovernanceProposals(nextID, maxSlashSubmissionsPerRound)
+	if err != nil {
+		logger.Warnf("Failed to get the finalized governance proposals: %v", err)
+		return
+	}
+
+	for _, proposal := range proposals {
+		if proposal.Param == score.GovParamDowntimeSlash {
+			if !proposal.Approved && finalizedHeight < proposal.ActivationHeight {
+				return // still open for votes
+			}
+			if proposal.Approved {
+				dynasty, validator, err := score.ParseDowntimeSlashValue(proposal.Value)
+				if err != nil {
+					logger.Errorf("Skipped invalid downtime slash proposal %v: %v", proposal, err) // should not happen
+				} else {
+					tx, err := sl.submitSlash(validator, sl.downtimeSlashAmount)
+					if revertErr, ok := err.(*RevertError); ok {
+						logger.Errorf("Skipped downtime slashing for validator %v in dynasty %v, the mainchain rejected it: %v",
+							validator.Hex(), dynasty, revertErr)
+					} else if err != nil {
+						logger.Warnf("Failed to submit downtime slashing for validator %v in dynasty %v, will retry: %v",
+							validator.Hex(), dynasty, err)
+						return
+					} else {
+						logger.Infof("Submitted downtime slashing for validator %v in dynasty %v, proposal: %v, amount: %v, tx: %v",
+							validator.Hex(), dynasty, proposal.ID, sl.downtimeSlashAmount, tx.Hash().Hex())
+					}
+				}
+			}
+		}
+
+		if err := sl.setNextProposalID(proposal.ID + 1); err != nil {
+			logger.Warnf("Failed to save the next governance proposal ID: %v", err)
+			return
+		}
+	}
+}
+
+// submitSlash calls SlashValidatorCollateral on the chain registrar on the mainchain. The call is simulated
+// first, so nothing is submitted if the mainchain would reject it.
+func (sl *equivocationSlasher) submitSlash(validator common.Address, slashAmount *big.Int) (*types.Transaction, error) {
+	oc := sl.oc
+	unlock := oc.lockTxSubmission(oc.mainchainID)
+	defer unlock()
+
+	// the evidence refers to the validators by the keys they sign with, while the chain registrar slashes
+	// the validator addresses
+	validator, err := oc.ledger.GetValidatorIdentity(validator)
+	if err != nil {
+		return nil, err
+	}
+
+	ecClient := oc.getEthRpcClient(oc.mainchainID)
+	txOpts, err := oc.buildTxOpts(oc.mainchainID, ecClient)
+	if err != nil {
+		return nil, err
+	}
+	txOpts.NoSend = true
+
+	guarantor, ok := sl.guarantors[validator]
+	if !ok {
+		guarantor = validator
+	}
+	tx, err := oc.chainRegistrarOnMainchain.SlashValidatorCollateral(txOpts, oc.subchainID, validator, guarantor, slashAmount)
+	if err != nil {
+		return nil, err
+	}
+	if err = oc.simulateTx(ecClient, tx); err != nil {
+		return tx, err
+	}
+	if err = ecClient.SendTransaction(context.Background(), tx); err != nil {
+		return tx, err
+	}
+	return tx, nil
+}
+
+func (sl *equivocationSlasher) getNextIndex() uint64 {
+	var nextIndex uint64
+	store := kvstore.NewKVStore(sl.db)
+	if err := store.Get(nextEquivocationRecordIndexKey(), &nextIndex); err != nil {
+		return 0
+	}
+	return nextIndex
+}
+
+func (sl *equivocationSlasher) setNextIndex(nextIndex uint64) error {
+	store := kvstore.NewKVStore(sl.db)
+	return store.Put(nextEquivocationRecordIndexKey(), nextIndex)
+}
+
+func (sl *equivocationSlasher) getNextProposalID() uint64 {
+	nextID := uint64(1) // the proposal IDs start from 1
+	store := kvstore.NewKVStore(sl.db)
+	if err := store.Get(nextGovernanceProposalIDKey(), &nextID); err != nil {
+		return 1
+	}
+	return nextID
+}
+
+func (sl *equivocationSlasher) setNextProposalID(nextID uint64) error {
+	store := kvstore.NewKVStore(sl.db)
+	return store.Put(nextGovernanceProposalIDKey(), nextID)
+}
+
+func (sl *equivocationSlasher) getDowntimeVoteProgress() *downtimeVoteProgress {
+	progress := &downtimeVoteProgress{}
+	store := kvstore.NewKVStore(sl.db)
+	if err := store.Get(downtimeVoteProgressKey(), progress); err != nil {
+		return nil
+	}
+	return progress
+}
+
+func (sl *equivocationSlasher) setDowntimeVoteProgress(progress *downtimeVoteProgress) error {
+	store := kvstore.NewKVStore(sl.db)
+	return store.Put(downtimeVoteProgressKey(), progress)
+}
@@ -0,0 +1,181 @@
+package orchestrator
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/hexutil"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/thetasubchain/eth/core/types"
+	ec "github.com/thetatoken/thetasubchain/eth/ethclient"
+
+	score "github.com/thetatoken/thetasubchain/core"
+	scta "github.com/thetatoken/thetasubchain/interchain/contracts/accessors"
+)
+
+// slasherTestLedger serves the governance proposals finalized at the given height. The validators sign with
+// their addresses.
+type slasherTestLedger struct {
+	*testLedger
+	proposals       []*score.GovernanceProposal
+	finalizedHeight uint64
+}
+
+func (tl *slasherTestLedger) GetFinalizedGovernanceProposals(startID uint64, maxCount int) ([]*score.GovernanceProposal, uint64, error) {
+	proposals := []*score.GovernanceProposal{}
+	for _, proposal := range tl.proposals {
+		if proposal.ID >= startID && len(proposals) < maxCount {
+			proposals = append(proposals, proposal)
+		}
+	}
+	return proposals, tl.finalizedHeight, nil
+}
+
+func (tl *slasherTestLedger) GetValidatorIdentity(key common.Address) (common.Address, error) {
+	return key, nil
+}
+
+// slashRecorder records the validators of the SlashValidatorCollateral txs sent to the mainchain
+type slashRecorder struct {
+	mutex   *sync.Mutex
+	slashed []common.Address
+}
+
+func (sr *slashRecorder) take() []common.Address {
+	sr.mutex.Lock()
+	defer sr.mutex.Unlock()
+
+	slashed := sr.slashed
+	sr.slashed = nil
+	return slashed
+}
+
+// wrap records the txs sent through eth_sendRawTransaction before passing the calls to the handler
+func (sr *slashRecorder) wrap(t *testing.T, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		var req struct {
+			Method string   `json:"method"`
+			Params []string `json:"params"`
+		}
+		if json.Unmarshal(body, &req) == nil && req.Method == "eth_sendRawTransaction" {
+			raw, err := hexutil.Decode(req.Params[0])
+			tx := &types.Transaction{}
+			if err == nil {
+				err = tx.UnmarshalBinary(raw)
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			// slashValidatorCollateral(subchainID, validator, guarantor, slashAmount)
+			sr.mutex.Lock()
+			sr.slashed = append(sr.slashed, common.BytesToAddress(tx.Data()[4+32:4+64]))
+			sr.mutex.Unlock()
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func TestSubmitApprovedDowntimeSlashes(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sr := &slashRecorder{mutex: &sync.Mutex{}}
+	server := httptest.NewServer(sr.wrap(t, newTestEthRpcHandler(http.StatusOK, nil, map[string]interface{}{
+		"eth_gasPrice":            "0x48c27395000",
+		"eth_getTransactionCount": "0x7",
+	})))
+	defer server.Close()
+	ecClient, err := ec.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ecClient.Close()
+	registrar, err := scta.NewChainRegistrarOnMainchain(common.HexToAddress("0x08425D9Df219f93d5763c3e85204cb5B4cE33aAa"), ecClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valA := common.HexToAddress("0x000000000000000000000000000000000000000a")
+	valB := common.HexToAddress("0x000000000000000000000000000000000000000b")
+	valC := common.HexToAddress("0x000000000000000000000000000000000000000c")
+	valD := common.HexToAddress("0x000000000000000000000000000000000000000d")
+	downtimeSlash := func(id uint64, validator common.Address, activationHeight uint64, approved bool) *score.GovernanceProposal {
+		return &score.GovernanceProposal{
+			ID:               id,
+			Param:            score.GovParamDowntimeSlash,
+			Value:            score.DowntimeSlashValue(big.NewInt(5), validator),
+			ActivationHeight: activationHeight,
+			Approved:         approved,
+		}
+	}
+	ledger := &slasherTestLedger{
+		testLedger: &testLedger{height: 1000},
+		proposals: []*score.GovernanceProposal{
+			downtimeSlash(1, valA, 1100, true),
+			{ID: 2, Param: score.GovParamBlockGasLimit, Value: "50000000", ActivationHeight: 1100, Approved: true},
+			downtimeSlash(3, valB, 900, false), // expired below the 2/3 of the stake
+			downtimeSlash(4, valC, 1100, false),
+			downtimeSlash(5, valD, 1100, true),
+		},
+		finalizedHeight: 1000,
+	}
+	oc := &Orchestrator{
+		ledger:                    ledger,
+		privateKey:                privKey,
+		mainchainID:               testMainchainID,
+		subchainID:                testSubchainID,
+		mainchainEthRpcClient:     ecClient,
+		chainRegistrarOnMainchain: registrar,
+		txSubmissionLocks:         make(map[string]*sync.Mutex),
+		txLocksMutex:              &sync.Mutex{},
+	}
+	sl := &equivocationSlasher{
+		oc:                  oc,
+		db:                  backend.NewMemDatabase(),
+		enabled:             true,
+		slashAmount:         big.NewInt(1e18),
+		downtimeSlashAmount: big.NewInt(0),
+		guarantors:          make(map[common.Address]common.Address),
+		mutex:               &sync.Mutex{},
+	}
+
+	// Nothing is slashed for the downtime without a slash amount
+	sl.submitApprovedDowntimeSlashes()
+	assert.Equal(0, len(sr.take()))
+	assert.Equal(uint64(1), sl.getNextProposalID())
+
+	// Only the approved downtime slashes are submitted, up to the first one still open for votes
+	sl.downtimeSlashAmount = big.NewInt(1e17)
+	sl.submitApprovedDowntimeSlashes()
+	assert.Equal([]common.Address{valA}, sr.take())
+	assert.Equal(uint64(4), sl.getNextProposalID())
+
+	// An approved downtime slash is submitted exactly once
+	sl.submitApprovedDowntimeSlashes()
+	assert.Equal(0, len(sr.take()))
+	assert.Equal(uint64(4), sl.getNextProposalID())
+
+	// Once the open proposal reaches the 2/3 of the stake, the following ones are submitted as well
+	ledger.proposals[3].Approved = true
+	sl.submitApprovedDowntimeSlashes()
+	assert.Equal([]common.Address{valC, valD}, sr.take())
+	assert.Equal(uint64(6), sl.getNextProposalID())
+
+	sl.submitApprovedDowntimeSlashes()
+	assert.Equal(0, len(sr.take()))
+}
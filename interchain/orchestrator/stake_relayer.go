@@ -0,0 +1,530 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+	ttypes "github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/store/database"
+	"github.com/thetatoken/theta/store/kvstore"
+	"github.com/thetatoken/thetasubchain/eth/abi/bind"
+	"github.com/thetatoken/thetasubchain/eth/core/types"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+// maximum number of native stake records or governance proposals processed per round
+const maxStakeRelaysPerRound = 16
+
+// number of blocks the validators have to approve the rejection of a native stake deposit
+const nativeStakeRejectionVotingPeriodInBlocks uint64 = 1000
+
+func nextNativeStakeRecordIndexKey() common.Bytes {
+	return common.Bytes("oc/nnsr")
+}
+
+func nativeStakeRelayKey(recordIndex uint64) common.Bytes {
+	return append(common.Bytes("oc/nsrl/"), score.Itobytes(recordIndex)...)
+}
+
+func relayedNativeStakeKey(validator common.Address) common.Bytes {
+	return append(common.Bytes("oc/rns/"), validator[:]...)
+}
+
+func nextNativeStakeRejectionProposalIDKey() common.Bytes {
+	return common.Bytes("oc/nnsrp")
+}
+
+// nativeStakeRelay tracks the relay of a native stake record to the mainchain
+type nativeStakeRelay struct {
+	PendingTx                 common.Hash // the relay tx waiting for its receipt, empty if none
+	PendingNonce              uint64
+	RejectionActivationHeight uint64 // activation height of the rejection proposed for the deposit, 0 if none
+}
+
+// nativeStakeRelayer relays the stake and unstake transactions of the subchain to the chain registrar on the
+// mainchain, so that they take effect in the validator sets of the next dynasties. The records are relayed only
+// by the node whose key is the native stake relayer designated through the governance proposals, and the
+// stakes are deposited from, and held by, that account on the mainchain. The vouchers locked on the subchain
+// stay in escrow until the stakes are unstaked, or their deposits are rejected. The shares of a withdrawal are
+// taken in proportion to the amount relayed for the validator.
+//
+// The records are relayed one at a time in the order of their indices. A relay tx is recorded before it is sent,
+// and the record is only relayed again once the tx has failed, or has been dropped for another tx taking its nonce,
+// so a record is never relayed twice. If the mainchain rejects a deposit, the relayer proposes its rejection with
+// the validator address of its key, so it should be a validator, and waits for the votes. The other validators
+// running the relayer vote for the rejection if the deposit would revert for the designated relayer. Once the
+// rejection is approved, the ledger returns the stake to the staker, and the relayer skips the record.
+type nativeStakeRelayer struct {
+	oc *Orchestrator
+	db database.Database
+
+	enabled bool
+
+	mutex *sync.Mutex
+}
+
+func newNativeStakeRelayer(oc *Orchestrator, db database.Database) *nativeStakeRelayer {
+	return &nativeStakeRelayer{
+		oc:      oc,
+		db:      db,
+		enabled: viper.GetBool(scom.CfgSubchainStakeRelayEnabled),
+		mutex:   &sync.Mutex{},
+	}
+}
+
+func (sr *nativeStakeRelayer) mainloop(ctx context.Context) {
+	defer sr.oc.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(sr.oc.updateInterval) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if sr.enabled {
+				sr.relayPendingRecords()
+				sr.voteNativeStakeRejections()
+			}
+		}
+	}
+}
+
+// relayPendingRecords relays the finalized native stake records in the order of their indices, if the node key
+// is the designated native stake relayer
+func (sr *nativeStakeRelayer) relayPendingRecords() {
+	sr.mutex.Lock()
+	defer sr.mutex.Unlock()
+
+	oc := sr.oc
+	relayer, designated, err := oc.ledger.GetFinalizedNativeStakeRelayer()
+	if err != nil {
+		logger.Warnf("Failed to get the designated native stake relayer: %v", err)
+		return
+	}
+	if !designated || relayer != oc.privateKey.PublicKey().Address() {
+		return // the records are relayed by the designated relayer only
+	}
+
+	nextIndex := sr.getNextIndex()
+	records, err := oc.ledger.GetFinalizedNativeStakeRecords(nextIndex, maxStakeRelaysPerRound)
+	if err != nil {
+		logger.Warnf("Failed to get the finalized native stake records: %v", err)
+		return
+	}
+
+	for _, record := range records {
+		done, err := sr.relayRecord(record)
+		if err != nil {
+			logger.Warnf("Failed to relay native stake record %v, will retry: %v", record.Index, err)
+			return
+		}
+		if !done {
+			return
+		}
+		if err := sr.setNextIndex(record.Index + 1); err != nil {
+			logger.Warnf("Failed to save the next native stake record index: %v", err)
+			return
+		}
+	}
+}
+
+// relayRecord moves the relay of the record forward. It returns true once the relay tx of the record has succeeded,
+// or the record has been skipped.
+func (sr *nativeStakeRelayer) relayRecord(record *score.NativeStakeRecord) (bool, error) {
+	oc := sr.oc
+	relay := sr.getRelay(record.Index)
+	if !relay.PendingTx.IsEmpty() {
+		succeeded, pending := sr.checkPendingRelay(relay)
+		if pending {
+			return false, nil
+		}
+		if succeeded {
+			relayed := sr.getRelayedStake(record.Validator)
+			if record.Type == score.NativeStakeRecordTypeDeposit {
+				relayed.Add(relayed, record.Amount)
+			} else {
+				relayed.Sub(relayed, record.Amount)
+				if relayed.Sign() < 0 {
+					relayed.SetInt64(0) // part of the stake was never relayed, e.g. the deposit was rejected
+				}
+			}
+			if err := sr.setRelayedStake(record.Validator, relayed); err != nil {
+				return false, err
+			}
+			logger.Infof("Relayed native stake record %v, tx: %v", record, relay.PendingTx.Hex())
+			return true, nil
+		}
+
+		logger.Warnf("The relay tx %v of native stake record %v has failed or been dropped, relaying again", relay.PendingTx.Hex(), record.Index)
+		relay.PendingTx = common.Hash{}
+		relay.PendingNonce = 0
+		if err := sr.setRelay(record.Index, relay); err != nil {
+			return false, err
+		}
+	}
+
+	if record.Type == score.NativeStakeRecordTypeDeposit {
+		proposal, finalizedHeight, err := oc.ledger.GetFinalizedNativeStakeRejectionProposal(record.Index)
+		if err != nil {
+			return false, err
+		}
+		if proposal != nil && proposal.Approved {
+			logger.Infof("Skipped native stake record %v, its rejection has been approved in proposal %v", record, proposal.ID)
+			return true, nil
+		}
+		if (proposal != nil && finalizedHeight < proposal.ActivationHeight) || finalizedHeight < relay.RejectionActivationHeight {
+			return false, nil // the rejection is open for votes
+		}
+	}
+
+	var tx *types.Transaction
+	var err error
+	switch record.Type {
+	case score.NativeStakeRecordTypeDeposit:
+		tx, err = sr.submitDeposit(record, relay)
+	case score.NativeStakeRecordTypeWithdrawal:
+		tx, err = sr.submitWithdrawal(record, relay)
+	default:
+		logger.Errorf("Skipped native stake record %v of unknown type", record)
+		return true, nil
+	}
+
+	if revertErr, ok := err.(*RevertError); ok {
+		if record.Type == score.NativeStakeRecordTypeWithdrawal {
+			// the unstaked vouchers are released on the subchain regardless, after the unbonding period
+			logger.Errorf("Skipped native stake record %v, the mainchain rejected it: %v", record, revertErr)
+			return true, nil
+		}
+		logger.Errorf("The mainchain rejects native stake record %v, proposing to return the stake: %v", record, revertErr)
+		return false, sr.proposeNativeStakeRejection(record, relay)
+	} else if err != nil {
+		return false, err
+	}
+	if tx == nil {
+		return true, nil // nothing to withdraw
+	}
+	logger.Infof("Submitted native stake record %v, tx: %v", record, tx.Hash().Hex())
+	return false, nil
+}
+
+// checkPendingRelay checks the receipt of the pending relay tx. It returns whether the tx has succeeded, and
+// whether it is still pending.
+func (sr *nativeStakeRelayer) checkPendingRelay(relay *nativeStakeRelay) (bool, bool) {
+	oc := sr.oc
+	ecClient := oc.getEthRpcClient(oc.mainchainID)
+	receipt, err := ecClient.TransactionReceipt(context.Background(), relay.PendingTx)
+	if err == nil {
+		return receipt.Status == types.ReceiptStatusSuccessful, false
+	}
+
+	// without a receipt, the tx has been dropped once the nonce is taken by another tx
+	nonce, err := ecClient.NonceAt(context.Background(), oc.privateKey.PublicKey().Address(), nil)
+	if err != nil || nonce <= relay.PendingNonce {
+		return false, true
+	}
+	if _, err := ecClient.TransactionReceipt(context.Background(), relay.PendingTx); err == nil {
+		return false, true // included in the meantime, the receipt is accounted in the next round
+	}
+	return false, false
+}
+
+// submitDeposit calls DepositStake on the chain registrar on the mainchain. The call is simulated first, so
+// nothing is submitted if the mainchain would reject it, and the tx is recorded as pending before it is sent.
+func (sr *nativeStakeRelayer) submitDeposit(record *score.NativeStakeRecord, relay *nativeStakeRelay) (*types.Transaction, error) {
+	oc := sr.oc
+	return sr.submitRelay(record, relay, func(txOpts *bind.TransactOpts) (*types.Transaction, error) {
+		return oc.chainRegistrarOnMainchain.DepositStake(txOpts, oc.subchainID, record.Validator, record.Amount)
+	})
+}
+
+// submitWithdrawal calls WithdrawStake on the chain registrar on the mainchain, for the shares of the relayer
+// account in proportion to the amount withdrawn out of the amount relayed for the validator so far. The
+// shares are read from the stake events mirrored from the mainchain, so the withdrawal waits until the
+// deposits have been mirrored.
+func (sr *nativeStakeRelayer) submitWithdrawal(record *score.NativeStakeRecord, relay *nativeStakeRelay) (*types.Transaction, error) {
+	oc := sr.oc
+	relayed := sr.getRelayedStake(record.Validator)
+	if relayed.Sign() <= 0 {
+		logger.Warnf("No stake has been relayed for validator %v, nothing to withdraw for native stake record %v",
+			record.Validator.Hex(), record.Index)
+		return nil, nil
+	}
+
+	relayerAddr := oc.privateKey.PublicKey().Address()
+	shares, err := oc.ledger.GetFinalizedDelegatedShares(record.Validator, relayerAddr)
+	if err != nil {
+		return nil, err
+	}
+	if shares.Sign() <= 0 {
+		return nil, fmt.Errorf("the shares of %v for validator %v have not been mirrored yet", relayerAddr.Hex(), record.Validator.Hex())
+	}
+	shareAmount := new(big.Int).Mul(shares, record.Amount)
+	shareAmount.Div(shareAmount, relayed)
+	if shareAmount.Cmp(shares) > 0 {
+		shareAmount = shares
+	}
+
+	return sr.submitRelay(record, relay, func(txOpts *bind.TransactOpts) (*types.Transaction, error) {
+		return oc.chainRegistrarOnMainchain.WithdrawStake(txOpts, oc.subchainID, record.Validator, shareAmount)
+	})
+}
+
+// submitRelay simulates, records as pending, then sends the relay tx of the record created with the given function
+func (sr *nativeStakeRelayer) submitRelay(record *score.NativeStakeRecord, relay *nativeStakeRelay,
+	createTx func(txOpts *bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	oc := sr.oc
+	unlock := oc.lockTxSubmission(oc.mainchainID)
+	defer unlock()
+
+	ecClient := oc.getEthRpcClient(oc.mainchainID)
+	txOpts, err := oc.buildTxOpts(oc.mainchainID, ecClient)
+	if err != nil {
+		return nil, err
+	}
+	txOpts.NoSend = true
+
+	tx, err := createTx(txOpts)
+	if err != nil {
+		return nil, err
+	}
+	if err = oc.simulateTx(ecClient, tx); err != nil {
+		return tx, err
+	}
+
+	relay.PendingTx = tx.Hash()
+	relay.PendingNonce = tx.Nonce()
+	if err = sr.setRelay(record.Index, relay); err != nil {
+		return tx, err
+	}
+	if err = ecClient.SendTransaction(context.Background(), tx); err != nil {
+		// the tx is recorded as pending, and is considered dropped once another tx takes its nonce
+		return tx, err
+	}
+	return tx, nil
+}
+
+// proposeNativeStakeRejection proposes to reject the deposit of the record, with the validator address of the node key
+func (sr *nativeStakeRelayer) proposeNativeStakeRejection(record *score.NativeStakeRecord, relay *nativeStakeRelay) error {
+	oc := sr.oc
+	currentBlock := oc.ledger.GetCurrentBlock()
+	if currentBlock == nil {
+		return errors.New("the current block is not available")
+	}
+	nextHeight := currentBlock.Height + 1
+
+	proposer, err := oc.ledger.GetValidatorIdentity(oc.privateKey.PublicKey().Address())
+	if err != nil {
+		return err
+	}
+	if proposer != oc.privateKey.PublicKey().Address() {
+		return fmt.Errorf("the node key signs for validator %v, only the validator address can propose the rejection", proposer.Hex())
+	}
+	sequence, err := oc.nextNativeTxSequence()
+	if err != nil {
+		return err
+	}
+	tx := &stypes.SubchainGovernanceVoteTx{
+		Fee: ttypes.Coins{
+			ThetaWei: big.NewInt(0),
+			TFuelWei: ttypes.GetMinimumTransactionFeeTFuelWei(nextHeight),
+		},
+		Validator: ttypes.TxInput{
+			Address:  proposer,
+			Sequence: sequence,
+		},
+		Param:            score.GovParamNativeStakeRejection,
+		Value:            score.NativeStakeRejectionValue(record.Index),
+		ActivationHeight: nextHeight + nativeStakeRejectionVotingPeriodInBlocks,
+	}
+	if err := oc.submitNativeTx(tx); err != nil {
+		return err
+	}
+
+	// no other rejection is proposed for the record until the voting on this one is over
+	relay.RejectionActivationHeight = tx.ActivationHeight
+	if err := sr.setRelay(record.Index, relay); err != nil {
+		return err
+	}
+	logger.Infof("Proposed the rejection of native stake record %v, tx: %v", record, tx)
+	return nil
+}
+
+// voteNativeStakeRejections votes for the rejections of the native stake deposits, in the order of the proposal
+// IDs, if the deposits would revert for the designated relayer. It stops at the first rejection still open for votes.
+func (sr *nativeStakeRelayer) voteNativeStakeRejections() {
+	sr.mutex.Lock()
+	defer sr.mutex.Unlock()
+
+	oc := sr.oc
+	relayer, designated, err := oc.ledger.GetFinalizedNativeStakeRelayer()
+	if err != nil {
+		logger.Warnf("Failed to get the designated native stake relayer: %v", err)
+		return
+	}
+	if !designated {
+		return
+	}
+	voter, err := oc.ledger.GetValidatorIdentity(oc.privateKey.PublicKey().Address())
+	if err != nil {
+		logger.Warnf("Failed to get the validator identity of the node key: %v", err)
+		return
+	}
+
+	nextID := sr.getNextProposalID()
+	proposals, finalizedHeight, err := oc.ledger.GetFinalizedGovernanceProposals(nextID, maxStakeRelaysPerRound)
+	if err != nil {
+		logger.Warnf("Failed to get the finalized governance proposals: %v", err)
+		return
+	}
+
+	for _, proposal := range proposals {
+		if proposal.Param == score.GovParamNativeStakeRejection && !proposal.Approved && finalizedHeight < proposal.ActivationHeight {
+			if !proposal.HasVoted(voter) {
+				if err := sr.voteNativeStakeRejection(proposal, relayer, voter); err != nil {
+					logger.Warnf("Failed to vote for native stake rejection proposal %v, will retry: %v", proposal.ID, err)
+				}
+			}
+			return // still open for votes
+		}
+
+		if err := sr.setNextProposalID(proposal.ID + 1); err != nil {
+			logger.Warnf("Failed to save the next native stake rejection proposal ID: %v", err)
+			return
+		}
+	}
+}
+
+// voteNativeStakeRejection votes for the rejection proposal if the deposit of the record reverts for the relayer
+func (sr *nativeStakeRelayer) voteNativeStakeRejection(proposal *score.GovernanceProposal, relayer common.Address, voter common.Address) error {
+	oc := sr.oc
+	if voter != oc.privateKey.PublicKey().Address() {
+		logger.Warnf("The node key signs for validator %v, only the validator address can vote for native stake rejection proposal %v",
+			voter.Hex(), proposal.ID)
+		return nil
+	}
+	recordIndex, err := score.ParseNativeStakeRejectionValue(proposal.Value)
+	if err != nil {
+		return err // should not happen
+	}
+	records, err := oc.ledger.GetFinalizedNativeStakeRecords(recordIndex, 1)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("native stake record %v not found", recordIndex) // should not happen
+	}
+	record := records[0]
+
+	ecClient := oc.getEthRpcClient(oc.mainchainID)
+	txOpts, err := oc.buildTxOpts(oc.mainchainID, ecClient)
+	if err != nil {
+		return err
+	}
+	txOpts.NoSend = true
+	deposit, err := oc.chainRegistrarOnMainchain.DepositStake(txOpts, oc.subchainID, record.Validator, record.Amount)
+	if err != nil {
+		return err
+	}
+	simErr := oc.simulateTxFrom(ecClient, relayer, deposit)
+	if simErr == nil {
+		logger.Infof("The mainchain accepts native stake record %v from the relayer %v, not voting for its rejection in proposal %v",
+			record, relayer.Hex(), proposal.ID)
+		return nil
+	}
+	revertErr, ok := simErr.(*RevertError)
+	if !ok {
+		return simErr
+	}
+
+	currentBlock := oc.ledger.GetCurrentBlock()
+	if currentBlock == nil {
+		return errors.New("the current block is not available")
+	}
+	sequence, err := oc.nextNativeTxSequence()
+	if err != nil {
+		return err
+	}
+	tx := &stypes.SubchainGovernanceVoteTx{
+		Fee: ttypes.Coins{
+			ThetaWei: big.NewInt(0),
+			TFuelWei: ttypes.GetMinimumTransactionFeeTFuelWei(currentBlock.Height + 1),
+		},
+		Validator: ttypes.TxInput{
+			Address:  voter,
+			Sequence: sequence,
+		},
+		ProposalID: proposal.ID,
+	}
+	if err := oc.submitNativeTx(tx); err != nil {
+		return err
+	}
+	logger.Infof("Voted for the rejection of native stake record %v, reason: %v, tx: %v", record, revertErr, tx)
+	return nil
+}
+
+func (sr *nativeStakeRelayer) getNextIndex() uint64 {
+	var nextIndex uint64
+	store := kvstore.NewKVStore(sr.db)
+	if err := store.Get(nextNativeStakeRecordIndexKey(), &nextIndex); err != nil {
+		return 0
+	}
+	return nextIndex
+}
+
+func (sr *nativeStakeRelayer) setNextIndex(nextIndex uint64) error {
+	store := kvstore.NewKVStore(sr.db)
+	return store.Put(nextNativeStakeRecordIndexKey(), nextIndex)
+}
+
+func (sr *nativeStakeRelayer) getRelay(recordIndex uint64) *nativeStakeRelay {
+	relay := &nativeStakeRelay{}
+	store := kvstore.NewKVStore(sr.db)
+	if err := store.Get(nativeStakeRelayKey(recordIndex), relay); err != nil {
+		return &nativeStakeRelay{}
+	}
+	return relay
+}
+
+func (sr *nativeStakeRelayer) setRelay(recordIndex uint64, relay *nativeStakeRelay) error {
+	store := kvstore.NewKVStore(sr.db)
+	return store.Put(nativeStakeRelayKey(recordIndex), relay)
+}
+
+func (sr *nativeStakeRelayer) getNextProposalID() uint64 {
+	nextID := uint64(1) // the proposal IDs start from 1
+	store := kvstore.NewKVStore(sr.db)
+	if err := store.Get(nextNativeStakeRejectionProposalIDKey(), &nextID); err != nil {
+		return 1
+	}
+	return nextID
+}
+
+func (sr *nativeStakeRelayer) setNextProposalID(nextID uint64) error {
+	store := kvstore.NewKVStore(sr.db)
+	return store.Put(nextNativeStakeRejectionProposalIDKey(), nextID)
+}
+
+func (sr *nativeStakeRelayer) getRelayedStake(validator common.Address) *big.Int {
+	relayed := new(big.Int)
+	store := kvstore.NewKVStore(sr.db)
+	if err := store.Get(relayedNativeStakeKey(validator), relayed); err != nil {
+		return big.NewInt(0)
+	}
+	return relayed
+}
+
+func (sr *nativeStakeRelayer) setRelayedStake(validator common.Address, relayed *big.Int) error {
+	store := kvstore.NewKVStore(sr.db)
+	return store.Put(relayedNativeStakeKey(validator), relayed)
+}
@@ -44,6 +44,15 @@ type ChainIDRPCResult struct {
 	Result  string `json:"result"`
 }
 
+type BlockRPCResult struct {
+	Jsonrpc string `json:"jsonrpc"`
+	Id      int64  `json:"id"`
+	Result  *struct {
+		Number string `json:"number"`
+		Hash   string `json:"hash"`
+	} `json:"result"`
+}
+
 type TransferEvent struct {
 	Denom  string
 	Amount *big.Int
@@ -95,6 +104,158 @@ var EventSelectors = map[score.InterChainMessageEventType]string{
 	score.IMCEInterSubchainChannelRegistered: crypto.Keccak256Hash([]byte("ChannelRegistered(address,uint256,string,uint256)")).Hex(),
 }
 
+// StakeEventSelectors are the selectors of the events the ValidatorStakeManager on the mainchain emits when
+// a staker deposits stake to or withdraws stake from a validator of a subchain
+var StakeEventSelectors = map[score.StakeEventType]string{
+	score.StakeEventTypeDeposit:    crypto.Keccak256Hash([]byte("DepositStake(uint256,address,address,uint256)")).Hex(),
+	score.StakeEventTypeWithdrawal: crypto.Keccak256Hash([]byte("WithdrawStake(uint256,address,address,uint256)")).Hex(),
+}
+
+// queryLogs returns the logs emitted by the given contracts within the block range [fromBlock, toBlock]
+// whose first topic is one of the given selectors
+func queryLogs(fromBlock *big.Int, toBlock *big.Int, addresses []common.Address, selectors []string, url string) ([]LogData, error) {
+	quotedAddresses := make([]string, len(addresses))
+	for i, address := range addresses {
+		quotedAddresses[i] = fmt.Sprintf("\"%v\"", address.Hex())
+	}
+	quotedSelectors := make([]string, len(selectors))
+	for i, selector := range selectors {
+		quotedSelectors[i] = fmt.Sprintf("\"%v\"", selector)
+	}
+	queryStr := fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_getLogs","params":[{"fromBlock":"0x%x","toBlock":"0x%x", "address":[%v],"topics":[[%v]]}],"id":74}`,
+		fromBlock, toBlock, strings.Join(quotedAddresses, ","), strings.Join(quotedSelectors, ","))
+
+	request, err := http.NewRequest("POST", url, bytes.NewBuffer([]byte(queryStr)))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	var rpcres RPCResult
+	if err := json.Unmarshal(body, &rpcres); err != nil {
+		return nil, fmt.Errorf("failed to decode the logs: %v, response: %q", err, body)
+	}
+	return rpcres.Result, nil
+}
+
+// QueryStakeEventLog returns the stake events of the given subchain emitted by the given contracts within
+// the block range [fromBlock, toBlock], ordered as they were emitted
+func QueryStakeEventLog(subchainID *big.Int, fromBlock *big.Int, toBlock *big.Int, addresses []common.Address, url string) ([]*score.StakeEvent, error) {
+	selectors := []string{StakeEventSelectors[score.StakeEventTypeDeposit], StakeEventSelectors[score.StakeEventTypeWithdrawal]}
+	logs, err := queryLogs(fromBlock, toBlock, addresses, selectors, url)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []*score.StakeEvent{}
+	for _, logData := range logs {
+		if len(logData.Topics) == 0 {
+			continue
+		}
+		var eventType score.StakeEventType
+		switch logData.Topics[0] {
+		case StakeEventSelectors[score.StakeEventTypeDeposit]:
+			eventType = score.StakeEventTypeDeposit
+		case StakeEventSelectors[score.StakeEventTypeWithdrawal]:
+			eventType = score.StakeEventTypeWithdrawal
+		default:
+			continue
+		}
+
+		// The event fields are not indexed: subchainID, validator, staker, shareAmount
+		data, err := hex.DecodeString(strings.TrimPrefix(logData.Data, "0x"))
+		if err != nil || len(data) < 4*32 {
+			return nil, fmt.Errorf("invalid stake event data: %v", logData.Data)
+		}
+		if new(big.Int).SetBytes(data[0:32]).Cmp(subchainID) != 0 {
+			continue
+		}
+		blockHeight, ok := new(big.Int).SetString(strings.TrimPrefix(logData.BlockNumber, "0x"), 16)
+		if !ok {
+			return nil, fmt.Errorf("invalid block number of the stake event: %v", logData.BlockNumber)
+		}
+		event := &score.StakeEvent{
+			Type:            eventType,
+			Validator:       common.BytesToAddress(data[32:64]),
+			Staker:          common.BytesToAddress(data[64:96]),
+			ShareAmount:     new(big.Int).SetBytes(data[96:128]),
+			MainchainHeight: blockHeight,
+		}
+		logger.Debugf("got stake event: %v", event)
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// KeyRotationEventSelector is the selector of the event the ChainRegistrar on the mainchain emits when a
+// validator of a subchain registers a new signing key
+var KeyRotationEventSelector = crypto.Keccak256Hash([]byte("ValidatorKeyRotated(uint256,address,address,uint256,bytes)")).Hex()
+
+// QueryKeyRotationLog returns the key rotations of the validators of the given subchain emitted by the given
+// contracts within the block range [fromBlock, toBlock], ordered as they were emitted
+func QueryKeyRotationLog(subchainID *big.Int, fromBlock *big.Int, toBlock *big.Int, addresses []common.Address, url string) ([]*score.KeyRotation, error) {
+	logs, err := queryLogs(fromBlock, toBlock, addresses, []string{KeyRotationEventSelector}, url)
+	if err != nil {
+		return nil, err
+	}
+
+	rotations := []*score.KeyRotation{}
+	for _, logData := range logs {
+		if len(logData.Topics) == 0 || logData.Topics[0] != KeyRotationEventSelector {
+			continue
+		}
+
+		// The event fields are not indexed: subchainID, validator, newKey, effectiveDynasty, signature
+		data, err := hex.DecodeString(strings.TrimPrefix(logData.Data, "0x"))
+		if err != nil || len(data) < 6*32 {
+			return nil, fmt.Errorf("invalid key rotation event data: %v", logData.Data)
+		}
+		if new(big.Int).SetBytes(data[0:32]).Cmp(subchainID) != 0 {
+			continue
+		}
+		sigOffset := new(big.Int).SetBytes(data[128:160])
+		if !sigOffset.IsUint64() || sigOffset.Uint64()+32 > uint64(len(data)) {
+			return nil, fmt.Errorf("invalid signature offset of the key rotation event: %v", sigOffset)
+		}
+		sigStart := sigOffset.Uint64() + 32
+		sigLen := new(big.Int).SetBytes(data[sigOffset.Uint64():sigStart])
+		if !sigLen.IsUint64() || sigStart+sigLen.Uint64() > uint64(len(data)) {
+			return nil, fmt.Errorf("invalid signature length of the key rotation event: %v", sigLen)
+		}
+		signature, err := crypto.SignatureFromBytes(data[sigStart : sigStart+sigLen.Uint64()])
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature of the key rotation event: %v", err)
+		}
+		blockHeight, ok := new(big.Int).SetString(strings.TrimPrefix(logData.BlockNumber, "0x"), 16)
+		if !ok {
+			return nil, fmt.Errorf("invalid block number of the key rotation event: %v", logData.BlockNumber)
+		}
+		rotation := &score.KeyRotation{
+			Validator:        common.BytesToAddress(data[32:64]),
+			NewKey:           common.BytesToAddress(data[64:96]),
+			EffectiveDynasty: new(big.Int).SetBytes(data[96:128]),
+			Signature:        signature,
+			MainchainHeight:  blockHeight,
+		}
+		logger.Debugf("got key rotation event: %v", rotation)
+		rotations = append(rotations, rotation)
+	}
+
+	return rotations, nil
+}
+
 func QueryInterChainEventLog(queriedChainID *big.Int, fromBlock *big.Int, toBlock *big.Int, tfuelTokenBankAddress common.Address, tnt20TokenBankAddress common.Address, tnt721TokenBankAddress common.Address, subchainRegisterAddr common.Address, queryTopics string, url string) []*score.InterChainMessageEvent {
 
 	var events []*score.InterChainMessageEvent
@@ -178,6 +339,41 @@ func QueryInterChainEventLog(queriedChainID *big.Int, fromBlock *big.Int, toBloc
 	return events
 }
 
+// QueryBlockHash returns the hash of the block at the given height, as reported by the RPC endpoint
+func QueryBlockHash(height *big.Int, url string) (common.Hash, error) {
+	queryStr := fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_getBlockByNumber","params":["0x%x", false],"id":1}`, height)
+	request, err := http.NewRequest("POST", url, bytes.NewBuffer([]byte(queryStr)))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	response, err := client.Do(request)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var rpcres BlockRPCResult
+	if err := json.Unmarshal(body, &rpcres); err != nil {
+		return common.Hash{}, fmt.Errorf("failed to decode the block: %v, response: %q", err, body)
+	}
+	if rpcres.Result == nil {
+		return common.Hash{}, fmt.Errorf("block %v not found", height)
+	}
+	number, ok := new(big.Int).SetString(strings.TrimPrefix(rpcres.Result.Number, "0x"), 16)
+	if !ok || number.Cmp(height) != 0 {
+		return common.Hash{}, fmt.Errorf("got block %v while querying block %v", rpcres.Result.Number, height)
+	}
+
+	return common.HexToHash(rpcres.Result.Hash), nil
+}
+
 func QuerySubchainID(queriedChainID *big.Int, url string) bool {
 	queryStr := `{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":67}`
 	var jsonData = []byte(queryStr)
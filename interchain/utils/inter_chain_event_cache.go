@@ -13,6 +13,7 @@ import (
 	"github.com/thetatoken/theta/store/database"
 	"github.com/thetatoken/theta/store/kvstore"
 	score "github.com/thetatoken/thetasubchain/core"
+	"github.com/thetatoken/thetasubchain/eth/event"
 )
 
 var logger *log.Entry = log.WithFields(log.Fields{"prefix": "interchain"})
@@ -34,6 +35,8 @@ func InterChainEventIndexKey(sourceChainID *big.Int, targetChainID *big.Int, icm
 type InterChainEventCache struct {
 	mutex *sync.Mutex // mutex to for concurrency protection, e.g., the witness thread and consensus thread may access it concurrently
 	db    database.Database
+
+	newEventsFeed event.Feed // notifies subscribers (e.g. the orchestrator) about newly inserted events
 }
 
 // NewInterChainEventCache creates a new crosschain transfer event cache instance.
@@ -55,9 +58,18 @@ func (c *InterChainEventCache) Insert(event *score.InterChainMessageEvent) error
 }
 
 func (c *InterChainEventCache) InsertList(events []*score.InterChainMessageEvent, mainchainID *big.Int, localchainID *big.Int) error {
+	inserted, err := c.insertList(events, mainchainID, localchainID)
+	if len(inserted) > 0 {
+		c.newEventsFeed.Send(inserted) // notify outside of the lock, Send blocks until all subscribers receive the events
+	}
+	return err // the caller should handle the error
+}
+
+func (c *InterChainEventCache) insertList(events []*score.InterChainMessageEvent, mainchainID *big.Int, localchainID *big.Int) ([]*score.InterChainMessageEvent, error) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
+	inserted := []*score.InterChainMessageEvent{}
 	store := kvstore.NewKVStore(c.db)
 	for _, event := range events {
 		if event.SourceChainID.Cmp(mainchainID) == 0 && event.TargetChainID.Cmp(localchainID) != 0 {
@@ -66,10 +78,17 @@ func (c *InterChainEventCache) InsertList(events []*score.InterChainMessageEvent
 		}
 		err := store.Put(InterChainEventIndexKey(event.SourceChainID, event.TargetChainID, event.Type, event.Nonce), event)
 		if err != nil {
-			return err // the caller should handle the error
+			return inserted, err
 		}
+		inserted = append(inserted, event)
 	}
-	return nil
+	return inserted, nil
+}
+
+// SubscribeNewEvents registers a channel to receive the events inserted by each InsertList call.
+// The channel should be drained promptly, since InsertList blocks until the events are delivered.
+func (c *InterChainEventCache) SubscribeNewEvents(ch chan<- []*score.InterChainMessageEvent) event.Subscription {
+	return c.newEventsFeed.Subscribe(ch)
 }
 
 func (c *InterChainEventCache) Delete(sourceChainID *big.Int, targetChainID *big.Int, imceType score.InterChainMessageEventType, nonce *big.Int) error {
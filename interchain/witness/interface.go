@@ -4,6 +4,8 @@ import (
 	"context"
 	"math/big"
 
+	"github.com/thetatoken/theta/common"
+
 	score "github.com/thetatoken/thetasubchain/core"
 	siu "github.com/thetatoken/thetasubchain/interchain/utils"
 )
@@ -15,7 +17,12 @@ type ChainWitness interface {
 	GetMainchainBlockHeight() (*big.Int, error)
 	GetValidatorSetByDynasty(dynasty *big.Int) (*score.ValidatorSet, error)
 	GetValidatorSetByDynastyForChain(dynasty *big.Int, subchainID *big.Int) (*score.ValidatorSet, error)
+	GetValidatorSetByDynastyAtMainchainHeight(dynasty *big.Int, mainchainHeight *big.Int) (*score.ValidatorSet, error)
+	GetMainchainBlockHash(mainchainHeight *big.Int) (common.Hash, error)
 	GetInterChainEventCache() *siu.InterChainEventCache
 	GetInterSubchainChannelWatchList() []*big.Int
+	GetStakeEvents(fromHeight *big.Int, toHeight *big.Int) ([]*score.StakeEvent, error)
+	GetSubchainRegistrationHeight() (*big.Int, error)
+	GetKeyRotations(fromHeight *big.Int, toHeight *big.Int) ([]*score.KeyRotation, error)
 	// InsertIntoSubchainChannelWatchList(*big.Int)
 }
@@ -17,7 +17,7 @@ import (
 	scta "github.com/thetatoken/thetasubchain/interchain/contracts/accessors"
 	siu "github.com/thetatoken/thetasubchain/interchain/utils"
 
-	// "github.com/thetatoken/thetasubchain/eth/abi/bind"
+	"github.com/thetatoken/thetasubchain/eth/abi/bind"
 	//"github.com/ethereum/go-ethereum/common"
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/store"
@@ -40,6 +40,7 @@ type MetachainWitness struct {
 	mainchainEthRpcUrl           string
 	mainchainEthRpcClient        *ec.Client
 	witnessedDynasty             *big.Int
+	chainRegistrarAddr           common.Address
 	chainRegistrarOnMainchain    *scta.ChainRegistrarOnMainchain // the ChainRegistrarOnMainchain contract deployed on the mainchain
 	mainchainTFuelTokenBankAddr  common.Address
 	mainchainTFuelTokenBank      *scta.TFuelTokenBank // the TFuelTokenBank contract deployed on the mainchain
@@ -135,6 +136,7 @@ func NewMetachainWitness(db database.Database, updateInterval int, interChainEve
 		mainchainEthRpcUrl:           mainchainEthRpcURL,
 		mainchainEthRpcClient:        mainchainEthRpcClient,
 		witnessedDynasty:             big.NewInt(0),
+		chainRegistrarAddr:           chainRegistrarOnMainchainAddr,
 		chainRegistrarOnMainchain:    chainRegistrarOnMainchain,
 		mainchainTFuelTokenBankAddr:  mainchainTFuelTokenBankAddr,
 		mainchainTFuelTokenBank:      mainchainTFuelTokenBank,
@@ -270,6 +272,69 @@ func (mw *MetachainWitness) GetValidatorSetByDynastyForChain(dynasty *big.Int, s
 	return validatorSet, nil
 }
 
+// GetValidatorSetByDynastyAtMainchainHeight reads the validator set of the dynasty from the registrar state at the
+// given mainchain height. Unlike GetValidatorSetByDynasty, the result does not depend on when the registrar is queried.
+func (mw *MetachainWitness) GetValidatorSetByDynastyAtMainchainHeight(dynasty *big.Int, mainchainHeight *big.Int) (*score.ValidatorSet, error) {
+	return mw.queryValidatorSet(&bind.CallOpts{BlockNumber: mainchainHeight}, dynasty, mw.subchainID)
+}
+
+// CheckNumMainchainBlocksPerDynasty warns if the dynasty length of the local dynasty schedule disagrees with the
+// one of the chain registrar on the mainchain, in which case the validator set updates would not line up with the
+// dynasties of the registrar
+func (mw *MetachainWitness) CheckNumMainchainBlocksPerDynasty() {
+	mbh, err := mw.mainchainEthRpcClient.BlockNumber(context.Background())
+	if err != nil {
+		logger.Warnf("Failed to get the mainchain block height: %v", err)
+		return
+	}
+	mainchainHeight := new(big.Int).SetUint64(mbh)
+	registrarNumBlocks, err := mw.chainRegistrarOnMainchain.GetNumBlocksPerDynasty(&bind.CallOpts{BlockNumber: mainchainHeight})
+	if err != nil {
+		logger.Warnf("Failed to get the number of mainchain blocks per dynasty from the chain registrar: %v", err)
+		return
+	}
+	numBlocks := scom.GetDynastySchedule().NumMainchainBlocksPerDynasty(mainchainHeight)
+	if registrarNumBlocks.Cmp(big.NewInt(numBlocks)) != 0 {
+		logger.Warnf("Dynasty length mismatch at mainchain height %v: %v blocks on the subchain, %v blocks on the chain registrar",
+			mainchainHeight, numBlocks, registrarNumBlocks)
+	}
+}
+
+// GetMainchainBlockHash returns the hash of the mainchain block at the given height
+func (mw *MetachainWitness) GetMainchainBlockHash(mainchainHeight *big.Int) (common.Hash, error) {
+	return siu.QueryBlockHash(mainchainHeight, mw.mainchainEthRpcUrl)
+}
+
+// GetStakeEvents returns the stake events of the subchain emitted on the mainchain within the
+// block range [fromHeight, toHeight]
+func (mw *MetachainWitness) GetStakeEvents(fromHeight *big.Int, toHeight *big.Int) ([]*score.StakeEvent, error) {
+	vsmAddr, err := mw.chainRegistrarOnMainchain.Vsm(nil)
+	if err != nil {
+		return nil, err
+	}
+	addresses := []common.Address{mw.chainRegistrarAddr, vsmAddr}
+	return siu.QueryStakeEventLog(mw.subchainID, fromHeight, toHeight, addresses, mw.mainchainEthRpcUrl)
+}
+
+// GetKeyRotations returns the key rotations of the validators of the subchain registered on the mainchain
+// within the block range [fromHeight, toHeight]
+func (mw *MetachainWitness) GetKeyRotations(fromHeight *big.Int, toHeight *big.Int) ([]*score.KeyRotation, error) {
+	addresses := []common.Address{mw.chainRegistrarAddr}
+	return siu.QueryKeyRotationLog(mw.subchainID, fromHeight, toHeight, addresses, mw.mainchainEthRpcUrl)
+}
+
+// GetSubchainRegistrationHeight returns the mainchain height at which the subchain was registered
+func (mw *MetachainWitness) GetSubchainRegistrationHeight() (*big.Int, error) {
+	height, registered, err := mw.chainRegistrarOnMainchain.GetSubchainRegistrationHeight(nil, mw.subchainID)
+	if err != nil {
+		return nil, err
+	}
+	if !registered {
+		return nil, fmt.Errorf("subchain %v is not registered on the mainchain", mw.subchainID)
+	}
+	return height, nil
+}
+
 func (mw *MetachainWitness) AddNewSubchainChannel(targetChainID *big.Int) {
 	mw.interSubchainChannelWatchList = append(mw.interSubchainChannelWatchList, targetChainID)
 }
@@ -545,26 +610,11 @@ func (mw *MetachainWitness) updateValidatorSetCache(dynasty *big.Int) (*score.Va
 	mw.cacheMutex.Lock()
 	defer mw.cacheMutex.Unlock()
 
-	queryBlockHeight := big.NewInt(1).Mul(dynasty, big.NewInt(1).SetInt64(scom.NumMainchainBlocksPerDynasty))
-	queryBlockHeight = big.NewInt(0).Add(queryBlockHeight, big.NewInt(1)) // increment by one to make sure the query block height falls into the dynasty
-	vs, err := mw.chainRegistrarOnMainchain.GetValidatorSet(nil, mw.subchainID, queryBlockHeight)
-	validatorAddrs := vs.Validators
-	validatorStakes := vs.ShareAmounts
-
+	validatorSet, err := mw.queryValidatorSet(nil, dynasty, mw.subchainID)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(validatorAddrs) != len(validatorStakes) {
-		return nil, fmt.Errorf("the length of validatorAddrs and validatorStakes are not equal")
-	}
-
-	validatorSet := score.NewValidatorSet(dynasty)
-	for i := 0; i < len(validatorAddrs); i++ {
-		validator := score.NewValidator(validatorAddrs[i].Hex(), validatorStakes[i])
-		validatorSet.AddValidator(validator)
-	}
-
 	mw.validatorSetCache[dynasty.String()] = validatorSet
 
 	return validatorSet, nil
@@ -574,16 +624,30 @@ func (mw *MetachainWitness) updateValidatorSetCacheForChain(dynasty *big.Int, su
 	mw.cacheMutex.Lock()
 	defer mw.cacheMutex.Unlock()
 
-	queryBlockHeight := big.NewInt(1).Mul(dynasty, big.NewInt(1).SetInt64(scom.NumMainchainBlocksPerDynasty))
-	queryBlockHeight = big.NewInt(0).Add(queryBlockHeight, big.NewInt(1)) // increment by one to make sure the query block height falls into the dynasty
-	vs, err := mw.chainRegistrarOnMainchain.GetValidatorSet(nil, subchainID, queryBlockHeight)
-	validatorAddrs := vs.Validators
-	validatorStakes := vs.ShareAmounts
+	validatorSet, err := mw.queryValidatorSet(nil, dynasty, subchainID)
+	if err != nil {
+		return nil, err
+	}
 
+	if mw.validatorSetCacheForAll[dynasty.String()][subchainID.String()] == nil {
+		mw.validatorSetCacheForAll[dynasty.String()] = make(map[string]*score.ValidatorSet)
+	}
+	mw.validatorSetCacheForAll[dynasty.String()][subchainID.String()] = validatorSet
+
+	return validatorSet, nil
+}
+
+// queryValidatorSet reads the validator set of the given subchain and dynasty from the registrar on the mainchain
+func (mw *MetachainWitness) queryValidatorSet(opts *bind.CallOpts, dynasty *big.Int, subchainID *big.Int) (*score.ValidatorSet, error) {
+	queryBlockHeight := scom.DynastyStartHeight(dynasty)
+	queryBlockHeight = big.NewInt(0).Add(queryBlockHeight, big.NewInt(1)) // increment by one to make sure the query block height falls into the dynasty
+	vs, err := mw.chainRegistrarOnMainchain.GetValidatorSet(opts, subchainID, queryBlockHeight)
 	if err != nil {
 		return nil, err
 	}
 
+	validatorAddrs := vs.Validators
+	validatorStakes := vs.ShareAmounts
 	if len(validatorAddrs) != len(validatorStakes) {
 		return nil, fmt.Errorf("the length of validatorAddrs and validatorStakes are not equal")
 	}
@@ -593,10 +657,6 @@ func (mw *MetachainWitness) updateValidatorSetCacheForChain(dynasty *big.Int, su
 		validator := score.NewValidator(validatorAddrs[i].Hex(), validatorStakes[i])
 		validatorSet.AddValidator(validator)
 	}
-	if mw.validatorSetCacheForAll[dynasty.String()][subchainID.String()] == nil {
-		mw.validatorSetCacheForAll[dynasty.String()] = make(map[string]*score.ValidatorSet)
-	}
-	mw.validatorSetCacheForAll[dynasty.String()][subchainID.String()] = validatorSet
 
 	return validatorSet, nil
 }
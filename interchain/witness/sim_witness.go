@@ -12,6 +12,7 @@ import (
 	siu "github.com/thetatoken/thetasubchain/interchain/utils"
 
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
 )
 
 const mainchainBlockIntervalMilliseconds int64 = 2000 // millseconds
@@ -246,6 +247,26 @@ func (mw *SimulatedMetachainWitness) updateValidatorSetCache(dynasty *big.Int) (
 	return validatorSet, nil
 }
 
+func (mw *SimulatedMetachainWitness) GetValidatorSetByDynastyAtMainchainHeight(dynasty *big.Int, mainchainHeight *big.Int) (*score.ValidatorSet, error) {
+	return mw.GetValidatorSetByDynasty(dynasty)
+}
+
+func (mw *SimulatedMetachainWitness) GetMainchainBlockHash(mainchainHeight *big.Int) (common.Hash, error) {
+	return crypto.Keccak256Hash(mainchainHeight.Bytes()), nil
+}
+
+func (mw *SimulatedMetachainWitness) GetStakeEvents(fromHeight *big.Int, toHeight *big.Int) ([]*score.StakeEvent, error) {
+	return []*score.StakeEvent{}, nil
+}
+
+func (mw *SimulatedMetachainWitness) GetSubchainRegistrationHeight() (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (mw *SimulatedMetachainWitness) GetKeyRotations(fromHeight *big.Int, toHeight *big.Int) ([]*score.KeyRotation, error) {
+	return []*score.KeyRotation{}, nil
+}
+
 func (mw *SimulatedMetachainWitness) GetInterChainEventCache() *siu.InterChainEventCache {
 	return mw.crossChainEventCache
 }
@@ -59,6 +59,17 @@ func getValidatorAddresses(validatorSet *score.ValidatorSet) []common.Address {
 	return validatorAddresses
 }
 
+// getValidatorIdentities returns the validators' addresses their stakes are bound to, which differ from the
+// addresses they sign with for the validators who have rotated their keys
+func getValidatorIdentities(validatorSet *score.ValidatorSet) []common.Address {
+	validators := validatorSet.Validators()
+	validatorIdentities := make([]common.Address, len(validators))
+	for i, v := range validators {
+		validatorIdentities[i] = validatorSet.ValidatorIdentity(v.Address)
+	}
+	return validatorIdentities
+}
+
 func isAValidator(address common.Address, validatorAddresses []common.Address) result.Result {
 	proposerIsAValidator := false
 	for _, validatorAddr := range validatorAddresses {
@@ -204,7 +215,7 @@ func validateInputAdvanced(acc *types.Account, signBytes []byte, in types.TxInpu
 
 	// Check signatures
 	signatureValid := in.Signature.Verify(signBytes, acc.Address)
-	if blockHeight >= common.HeightTxWrapperExtension {
+	if scom.IsForkActive(scom.ForkTxWrapperExtension, blockHeight) {
 		signBytesV2 := types.ChangeEthereumTxWrapper(signBytes, 2)
 		signatureValid = signatureValid || in.Signature.Verify(signBytesV2, acc.Address)
 	}
@@ -273,7 +284,7 @@ func sanityCheckForGasPrice(view *slst.StoreView, fromAddr common.Address, contr
 		return true
 	}
 
-	minimumGasPrice := scom.GetMinimumGasPrice()
+	minimumGasPrice := view.GetMinimumGasPrice(blockHeight)
 	if gasPrice.Cmp(minimumGasPrice) < 0 {
 		return false
 	}
@@ -343,6 +354,37 @@ func isVotingForInterchainEvents(contractAddr common.Address, calldata common.By
 	return false
 }
 
+// recordVoucherBurns stores the voucher burn events emitted by the token banks of this chain in the ledger
+// state, so the burns can be proven to the target chain against the state root of a checkpoint block
+func recordVoucherBurns(view *slst.StoreView, chainID string, logs []*types.Log, blockHeight uint64) {
+	if !scom.IsForkActive(scom.ForkVoucherBurnRecords, blockHeight) {
+		return
+	}
+
+	tokenBanks := map[score.InterChainMessageEventType]*common.Address{
+		score.IMCEventTypeCrossChainVoucherBurnTFuel:  view.GetTFuelTokenBankContractAddress(),
+		score.IMCEventTypeCrossChainVoucherBurnTNT20:  view.GetTNT20TokenBankContractAddress(),
+		score.IMCEventTypeCrossChainVoucherBurnTNT721: view.GetTNT721TokenBankContractAddress(),
+	}
+	sourceChainID := scom.MapChainID(chainID)
+	for _, l := range logs {
+		for eventType, tokenBankAddr := range tokenBanks {
+			if tokenBankAddr == nil || l.Address != *tokenBankAddr {
+				continue
+			}
+			event, err := score.ExtractVoucherBurnEventFromLog(sourceChainID, eventType, l.Topics, l.Data, blockHeight)
+			if err != nil {
+				logger.Warnf("Failed to extract voucher burn event from log: %v", err)
+				continue
+			}
+			if event == nil {
+				continue
+			}
+			view.SetVoucherBurnRecord(event)
+		}
+	}
+}
+
 func sanityCheckForFee(fee types.Coins, blockHeight uint64) (minimumFee *big.Int, success bool) {
 	fee = fee.NoNil()
 	minimumFee = types.GetMinimumTransactionFeeTFuelWei(blockHeight)
@@ -368,6 +410,16 @@ func chargeFee(account *types.Account, fee types.Coins) bool {
 	return true
 }
 
+// collectFee adds the gas fee to the pool paid out by the next coinbase transaction. The fees are burned
+// if the chain has no reward schedule.
+func collectFee(view *slst.StoreView, fee *big.Int) {
+	if view.GetRewardSchedule() == nil {
+		return
+	}
+	fees := view.GetCollectedFees()
+	view.SetCollectedFees(fees.Add(fees, fee))
+}
+
 func getBlockHeight(ledgerState *slst.LedgerState) uint64 {
 	blockHeight := ledgerState.Height() + 1
 	return blockHeight
@@ -375,7 +427,7 @@ func getBlockHeight(ledgerState *slst.LedgerState) uint64 {
 
 func getRegularTxGas(ledgerState *slst.LedgerState) uint64 {
 	blockHeight := getBlockHeight(ledgerState)
-	if blockHeight < common.HeightJune2021FeeAdjustment {
+	if !scom.IsForkActive(scom.ForkJune2021FeeAdjustment, blockHeight) {
 		return types.GasRegularTx
 	}
 	return types.GasRegularTxJune2021
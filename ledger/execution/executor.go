@@ -8,6 +8,7 @@ import (
 	"github.com/thetatoken/theta/ledger/types"
 	"github.com/thetatoken/theta/store/database"
 	sbc "github.com/thetatoken/thetasubchain/blockchain"
+	scom "github.com/thetatoken/thetasubchain/common"
 	score "github.com/thetatoken/thetasubchain/core"
 	"github.com/thetatoken/thetasubchain/interchain/witness"
 	slst "github.com/thetatoken/thetasubchain/ledger/state"
@@ -39,6 +40,15 @@ type Executor struct {
 	coinbaseTxExec                           *CoinbaseTxExecutor
 	subchainValidatorSetUpdateTxExec         *SubchainValidatorSetUpdateTxExecutor
 	subchainValidatorSetUpdateForChainTxExec *SubchainValidatorSetUpdateForChainTxExecutor
+	subchainEquivocationEvidenceTxExec       *SubchainEquivocationEvidenceTxExecutor
+	subchainBLSKeyRegistrationTxExec         *SubchainBLSKeyRegistrationTxExecutor
+	subchainStakeEventsTxExec                *SubchainStakeEventsTxExecutor
+	subchainCommissionRateUpdateTxExec       *SubchainCommissionRateUpdateTxExecutor
+	subchainRewardClaimTxExec                *SubchainRewardClaimTxExecutor
+	subchainGovernanceVoteTxExec             *SubchainGovernanceVoteTxExecutor
+	subchainUpgradePlanTxExec                *SubchainUpgradePlanTxExecutor
+	subchainStakeTxExec                      *SubchainStakeTxExecutor
+	subchainUnstakeTxExec                    *SubchainUnstakeTxExecutor
 	sendTxExec                               *SendTxExecutor
 	smartContractTxExec                      *SmartContractTxExecutor
 
@@ -57,6 +67,15 @@ func NewExecutor(db database.Database, chain *sbc.Chain, state *slst.LedgerState
 		coinbaseTxExec:                           NewCoinbaseTxExecutor(db, chain, state, consensus, valMgr),
 		subchainValidatorSetUpdateTxExec:         NewSubchainValidatorSetUpdateTxExecutor(db, chain, state, consensus, valMgr, metachainWitness),
 		subchainValidatorSetUpdateForChainTxExec: NewSubchainValidatorSetUpdateForChainTxExecutor(db, chain, state, consensus, valMgr, metachainWitness),
+		subchainEquivocationEvidenceTxExec:       NewSubchainEquivocationEvidenceTxExecutor(state, consensus, valMgr),
+		subchainBLSKeyRegistrationTxExec:         NewSubchainBLSKeyRegistrationTxExecutor(state, consensus, valMgr),
+		subchainStakeEventsTxExec:                NewSubchainStakeEventsTxExecutor(state, consensus, valMgr, metachainWitness),
+		subchainCommissionRateUpdateTxExec:       NewSubchainCommissionRateUpdateTxExecutor(state, consensus, valMgr),
+		subchainRewardClaimTxExec:                NewSubchainRewardClaimTxExecutor(state),
+		subchainGovernanceVoteTxExec:             NewSubchainGovernanceVoteTxExecutor(state, consensus, valMgr),
+		subchainUpgradePlanTxExec:                NewSubchainUpgradePlanTxExecutor(state, consensus, valMgr),
+		subchainStakeTxExec:                      NewSubchainStakeTxExecutor(state),
+		subchainUnstakeTxExec:                    NewSubchainUnstakeTxExecutor(state),
 		sendTxExec:                               NewSendTxExecutor(state),
 		smartContractTxExec:                      NewSmartContractTxExecutor(chain, state, ledger, valMgr),
 		skipSanityCheck:                          false,
@@ -168,11 +187,31 @@ func (exec *Executor) isTxTypeSupported(view *slst.StoreView, tx types.Tx) bool
 
 	switch tx.(type) {
 	case *types.SmartContractTx:
-		if blockHeight < common.HeightEnableSmartContract {
+		if !scom.IsForkActive(scom.ForkSmartContract, blockHeight) {
 			return false
 		}
 	case *types.StakeRewardDistributionTx:
-		if blockHeight < common.HeightEnableTheta3 {
+		if !scom.IsForkActive(scom.ForkTheta3, blockHeight) {
+			return false
+		}
+	case *stypes.SubchainBLSKeyRegistrationTx:
+		if !scom.IsForkActive(scom.ForkBLSCommitCertificate, blockHeight) {
+			return false
+		}
+	case *stypes.SubchainGovernanceVoteTx, *stypes.SubchainUpgradePlanTx:
+		if !scom.IsForkActive(scom.ForkGovernance, blockHeight) {
+			return false
+		}
+	case *stypes.SubchainStakeTx, *stypes.SubchainUnstakeTx:
+		if !scom.IsForkActive(scom.ForkNativeStaking, blockHeight) {
+			return false
+		}
+	case *stypes.SubchainEquivocationEvidenceTx:
+		if !scom.IsForkActive(scom.ForkEquivocationEvidence, blockHeight) {
+			return false
+		}
+	case *stypes.SubchainStakeEventsTx, *stypes.SubchainCommissionRateUpdateTx, *stypes.SubchainRewardClaimTx:
+		if !scom.IsForkActive(scom.ForkStakeEvents, blockHeight) {
 			return false
 		}
 	default:
@@ -191,6 +230,24 @@ func (exec *Executor) getTxExecutor(tx types.Tx) TxExecutor {
 		txExecutor = exec.subchainValidatorSetUpdateTxExec
 	case *stypes.SubchainValidatorSetUpdateForChainTx:
 		txExecutor = exec.subchainValidatorSetUpdateForChainTxExec
+	case *stypes.SubchainEquivocationEvidenceTx:
+		txExecutor = exec.subchainEquivocationEvidenceTxExec
+	case *stypes.SubchainBLSKeyRegistrationTx:
+		txExecutor = exec.subchainBLSKeyRegistrationTxExec
+	case *stypes.SubchainStakeEventsTx:
+		txExecutor = exec.subchainStakeEventsTxExec
+	case *stypes.SubchainCommissionRateUpdateTx:
+		txExecutor = exec.subchainCommissionRateUpdateTxExec
+	case *stypes.SubchainRewardClaimTx:
+		txExecutor = exec.subchainRewardClaimTxExec
+	case *stypes.SubchainGovernanceVoteTx:
+		txExecutor = exec.subchainGovernanceVoteTxExec
+	case *stypes.SubchainUpgradePlanTx:
+		txExecutor = exec.subchainUpgradePlanTxExec
+	case *stypes.SubchainStakeTx:
+		txExecutor = exec.subchainStakeTxExec
+	case *stypes.SubchainUnstakeTx:
+		txExecutor = exec.subchainUnstakeTxExec
 	case *types.SendTx:
 		txExecutor = exec.sendTxExec
 	case *types.SmartContractTx:
@@ -9,6 +9,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/common/result"
@@ -17,32 +18,49 @@ import (
 	"github.com/thetatoken/theta/store/database/backend"
 
 	sbc "github.com/thetatoken/thetasubchain/blockchain"
+	scom "github.com/thetatoken/thetasubchain/common"
 	score "github.com/thetatoken/thetasubchain/core"
 	slst "github.com/thetatoken/thetasubchain/ledger/state"
+	ssigner "github.com/thetatoken/thetasubchain/signer"
 )
 
 // --------------- Test Utilities with Mocked Consensus Engine --------------- //
 
 type TestConsensusEngine struct {
 	privKey *crypto.PrivateKey
+	ledger  *TestLedger
 }
 
-func (tce *TestConsensusEngine) ID() string                         { return tce.privKey.PublicKey().Address().Hex() }
-func (tce *TestConsensusEngine) PrivateKey() *crypto.PrivateKey     { return tce.privKey }
-func (tce *TestConsensusEngine) GetTip(bool) *score.ExtendedBlock   { return nil }
-func (tce *TestConsensusEngine) GetEpoch() uint64                   { return 100 }
-func (tce *TestConsensusEngine) AddMessage(msg interface{})         {}
-func (tce *TestConsensusEngine) FinalizedBlocks() chan *score.Block { return nil }
-func (tce *TestConsensusEngine) GetLedger() score.Ledger            { return nil }
+func (tce *TestConsensusEngine) ID() string                          { return tce.privKey.PublicKey().Address().Hex() }
+func (tce *TestConsensusEngine) PrivateKey() *crypto.PrivateKey      { return tce.privKey }
+func (tce *TestConsensusEngine) GetTip(bool) *score.ExtendedBlock    { return nil }
+func (tce *TestConsensusEngine) GetEpoch() uint64                    { return 100 }
+func (tce *TestConsensusEngine) AddMessage(msg interface{})          {}
+func (tce *TestConsensusEngine) FinalizedBlocks() chan *score.Block  { return nil }
+func (tce *TestConsensusEngine) GetLedger() score.Ledger             { return tce.ledger }
+func (tce *TestConsensusEngine) GetEvidencePool() score.EvidencePool { return nil }
+func (tce *TestConsensusEngine) GetSigner() score.Signer             { return ssigner.NewLocalSigner(tce.privKey) }
 func (tce *TestConsensusEngine) GetLastFinalizedBlock() *score.ExtendedBlock {
 	return &score.ExtendedBlock{}
 }
 
 func NewTestConsensusEngine(seed string) *TestConsensusEngine {
 	privKey, _, _ := crypto.TEST_GenerateKeyPairWithSeed(seed)
-	return &TestConsensusEngine{privKey}
+	ledger := &TestLedger{
+		currentBlock: &score.Block{BlockHeader: &score.BlockHeader{}},
+	}
+	return &TestConsensusEngine{privKey, ledger}
+}
+
+// TestLedger is a mocked ledger which serves the current block to the executors. The other methods of the
+// Ledger interface are not implemented, and panic if called.
+type TestLedger struct {
+	score.Ledger
+	currentBlock *score.Block
 }
 
+func (tl *TestLedger) GetCurrentBlock() *score.Block { return tl.currentBlock }
+
 type TestValidatorManager struct {
 	proposer score.Validator
 	valSet   *score.ValidatorSet
@@ -91,7 +109,7 @@ func NewExecTest() *execTest {
 	return et
 }
 
-//reset everything. state is empty
+// reset everything. state is empty
 func (et *execTest) reset() {
 	et.accIn = types.MakeAccWithInitBalance("foo", types.NewCoins(700000, 50*getMinimumTxFee()))
 	et.accOut = types.MakeAccWithInitBalance("bar", types.NewCoins(700000, 50*getMinimumTxFee()))
@@ -208,6 +226,17 @@ func (et *execTest) SetAcc(accs ...types.PrivAccount) {
 	et.acc2State(accs...)
 }
 
+// setForkHeight activates the fork of the given config key at the given height, and returns a function restoring the previous height
+func setForkHeight(cfgKey string, height uint64) func() {
+	previous := viper.Get(cfgKey)
+	viper.Set(cfgKey, height)
+	scom.InitForkSchedule(nil)
+	return func() {
+		viper.Set(cfgKey, previous)
+		scom.InitForkSchedule(nil)
+	}
+}
+
 func getMinimumTxFee() int64 {
 	return int64(types.MinimumTransactionFeeTFuelWeiJune2021)
 }
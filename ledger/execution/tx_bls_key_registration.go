@@ -0,0 +1,111 @@
+package execution
+
+import (
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/crypto/bls"
+	"github.com/thetatoken/theta/ledger/types"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+	slst "github.com/thetatoken/thetasubchain/ledger/state"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+var _ TxExecutor = (*SubchainBLSKeyRegistrationTxExecutor)(nil)
+
+// ------------------------------- SubchainBLSKeyRegistration Transaction -----------------------------------
+
+// SubchainBLSKeyRegistrationTxExecutor implements the TxExecutor interface
+type SubchainBLSKeyRegistrationTxExecutor struct {
+	state     *slst.LedgerState
+	consensus score.ConsensusEngine
+	valMgr    score.ValidatorManager
+}
+
+// NewSubchainBLSKeyRegistrationTxExecutor creates a new instance of SubchainBLSKeyRegistrationTxExecutor
+func NewSubchainBLSKeyRegistrationTxExecutor(state *slst.LedgerState, consensus score.ConsensusEngine,
+	valMgr score.ValidatorManager) *SubchainBLSKeyRegistrationTxExecutor {
+	return &SubchainBLSKeyRegistrationTxExecutor{
+		state:     state,
+		consensus: consensus,
+		valMgr:    valMgr,
+	}
+}
+
+func (exec *SubchainBLSKeyRegistrationTxExecutor) sanityCheck(chainID string, view *slst.StoreView, viewSel score.ViewSelector, transaction types.Tx) result.Result {
+	tx := transaction.(*stypes.SubchainBLSKeyRegistrationTx)
+	validatorSet := getValidatorSet(exec.consensus.GetLedger(), exec.valMgr)
+	validatorAddresses := getValidatorAddresses(validatorSet)
+
+	// Validate proposer, basic
+	res := tx.Proposer.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// verify the proposer is one of the validators
+	res = isAValidator(tx.Proposer.Address, validatorAddresses)
+	if res.IsError() {
+		return res
+	}
+
+	proposerAccount, res := getOrMakeInput(view, tx.Proposer)
+	if res.IsError() {
+		return res
+	}
+
+	// verify the proposer's signature
+	signBytes := tx.SignBytes(chainID)
+	if !tx.Proposer.Signature.Verify(signBytes, proposerAccount.Address) {
+		return result.Error("SignBytes: %X", signBytes)
+	}
+
+	if view.GetBLSPubKey(tx.Proposer.Address) != nil {
+		return result.Error("The BLS public key of %v has already been registered", tx.Proposer.Address.Hex())
+	}
+
+	// verify the proof of possession, otherwise a validator could register a key derived from the keys
+	// of the others, and forge their votes in an aggregated signature
+	pubKey, err := bls.PublicKeyFromBytes(tx.BLSPubKey)
+	if err != nil {
+		return result.Error("Invalid BLS public key: %v", err)
+	}
+	pop, err := bls.SignatureFromBytes(tx.BLSPop)
+	if err != nil {
+		return result.Error("Invalid BLS proof of possession: %v", err)
+	}
+	if !pubKey.PopVerify(pop) {
+		return result.Error("BLS proof of possession verification failed")
+	}
+
+	return result.OK
+}
+
+func (exec *SubchainBLSKeyRegistrationTxExecutor) process(chainID string, view *slst.StoreView, viewSel score.ViewSelector, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*stypes.SubchainBLSKeyRegistrationTx)
+
+	if view.GetBLSPubKey(tx.Proposer.Address) != nil {
+		return common.Hash{}, result.Error("The BLS public key of %v has already been registered", tx.Proposer.Address.Hex())
+	}
+
+	selfChainIDInt := scom.MapChainID(chainID)
+	view.SetBLSPubKey(selfChainIDInt, tx.Proposer.Address, tx.BLSPubKey)
+	txHash := types.TxID(chainID, tx)
+
+	logger.Infof("BLS public key registered, validator: %v, viewSel: %v, blockHeight: %v", tx.Proposer.Address.Hex(), viewSel, view.Height()+1)
+
+	return txHash, result.OK
+}
+
+func (exec *SubchainBLSKeyRegistrationTxExecutor) getTxInfo(transaction types.Tx) *score.TxInfo {
+	return &score.TxInfo{
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *SubchainBLSKeyRegistrationTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	return new(big.Int).SetUint64(0)
+}
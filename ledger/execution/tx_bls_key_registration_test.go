@@ -0,0 +1,70 @@
+package execution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/types"
+
+	score "github.com/thetatoken/thetasubchain/core"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+	ssigner "github.com/thetatoken/thetasubchain/signer"
+)
+
+func createBLSKeyRegistrationTx(et *execTest, signer types.PrivAccount, keyOwner types.PrivAccount, popOwner types.PrivAccount) *stypes.SubchainBLSKeyRegistrationTx {
+	blsKey, _ := ssigner.DeriveBLSKey(keyOwner.PrivKey)
+	popKey, _ := ssigner.DeriveBLSKey(popOwner.PrivKey)
+	tx := &stypes.SubchainBLSKeyRegistrationTx{
+		Proposer:  types.TxInput{Address: signer.PrivKey.PublicKey().Address()},
+		BLSPubKey: blsKey.PublicKey().ToBytes(),
+		BLSPop:    popKey.PopProve().ToBytes(),
+	}
+	sig, _ := signer.PrivKey.Sign(tx.SignBytes(et.chainID))
+	tx.SetSignature(signer.PrivKey.PublicKey().Address(), sig)
+	return tx
+}
+
+func TestBLSKeyRegistrationTx(t *testing.T) {
+	assert := assert.New(t)
+
+	et := NewExecTest()
+	exec := et.executor.subchainBLSKeyRegistrationTxExec
+	outsider := types.MakeAcc("outsider")
+
+	garbled := createBLSKeyRegistrationTx(et, et.accProposer, et.accProposer, et.accProposer)
+	garbled.BLSPubKey = common.Bytes{0x01, 0x02}
+	sig, _ := et.accProposer.PrivKey.Sign(garbled.SignBytes(et.chainID))
+	garbled.SetSignature(et.accProposer.PrivKey.PublicKey().Address(), sig)
+
+	tampered := createBLSKeyRegistrationTx(et, et.accProposer, et.accProposer, et.accProposer)
+	other := createBLSKeyRegistrationTx(et, et.accVal2, et.accVal2, et.accVal2)
+	tampered.BLSPubKey, tampered.BLSPop = other.BLSPubKey, other.BLSPop
+
+	tests := []struct {
+		name  string
+		tx    *stypes.SubchainBLSKeyRegistrationTx
+		valid bool
+	}{
+		{"proof of possession of another key", createBLSKeyRegistrationTx(et, et.accProposer, et.accProposer, et.accVal2), false},
+		{"invalid public key", garbled, false},
+		{"key replaced after signing", tampered, false},
+		{"not a validator", createBLSKeyRegistrationTx(et, outsider, outsider, outsider), false},
+		{"valid registration", createBLSKeyRegistrationTx(et, et.accVal2, et.accVal2, et.accVal2), true},
+	}
+	for _, test := range tests {
+		res := exec.sanityCheck(et.chainID, et.state().Delivered(), score.DeliveredView, test.tx)
+		assert.Equal(test.valid, res.IsOK(), "%v: %v", test.name, res.Message)
+	}
+
+	// A key can only be registered once
+	tx := createBLSKeyRegistrationTx(et, et.accVal2, et.accVal2, et.accVal2)
+	_, res := exec.process(et.chainID, et.state().Delivered(), score.DeliveredView, tx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(tx.BLSPubKey, et.state().Delivered().GetBLSPubKey(et.accVal2.PrivKey.PublicKey().Address()))
+
+	res = exec.sanityCheck(et.chainID, et.state().Delivered(), score.DeliveredView, tx)
+	assert.True(res.IsError())
+	_, res = exec.process(et.chainID, et.state().Delivered(), score.DeliveredView, tx)
+	assert.True(res.IsError())
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/thetatoken/theta/store/database"
 
 	sbc "github.com/thetatoken/thetasubchain/blockchain"
+	scom "github.com/thetatoken/thetasubchain/common"
 	score "github.com/thetatoken/thetasubchain/core"
 	slst "github.com/thetatoken/thetasubchain/ledger/state"
 )
@@ -75,6 +76,12 @@ func (exec *CoinbaseTxExecutor) sanityCheck(chainID string, view *slst.StoreView
 			tx.BlockHeight, exec.state.Height())
 	}
 
+	// verify the outputs pay the commissions on the collected gas fees to the validators who signed the HCC
+	expectedOutputs := CalculateCoinbaseOutputs(view, exec.consensus.GetLedger().GetCurrentBlock(), exec.valMgr)
+	if !coinbaseOutputsEqual(tx.Outputs, expectedOutputs) {
+		return result.Error("invalid coinbase outputs, outputs = %v, expected = %v", tx.Outputs, expectedOutputs)
+	}
+
 	return result.OK
 }
 
@@ -85,12 +92,128 @@ func (exec *CoinbaseTxExecutor) process(chainID string, view *slst.StoreView, vi
 		return common.Hash{}, result.Error("Another coinbase transaction has been processed for the current block")
 	}
 
+	if schedule := view.GetRewardSchedule(); schedule != nil {
+		block := exec.consensus.GetLedger().GetCurrentBlock()
+
+		// pay the commissions on the collected gas fees to the validators, the rest of the fees can be
+		// claimed by their delegators
+		accounts, res := getOrMakeOutputs(view, nil, tx.Outputs)
+		if res.IsError() {
+			return common.Hash{}, res
+		}
+		adjustByOutputs(view, accounts, tx.Outputs)
+		recipients := getRewardRecipients(block, exec.valMgr)
+		remainingFees := view.GetCollectedFees()
+		feeShares := score.SplitByStake(remainingFees, recipients)
+		for i, recipient := range recipients {
+			_, delegations, delegatorRewards := splitByCommission(view, recipient.Address, feeShares[i])
+			for j, delegation := range delegations {
+				if delegatorRewards[j].Sign() > 0 {
+					view.AddClaimableReward(delegation.Staker, delegatorRewards[j])
+				}
+			}
+			remainingFees.Sub(remainingFees, feeShares[i])
+		}
+		view.SetCollectedFees(remainingFees) // the rounding remainder is carried over to the next block
+
+		// accrue the staker rewards, to be minted on the mainchain through SubchainGovernanceToken.MintStakerReward
+		stakerRewards := score.SplitByStake(schedule.StakerRewardPerBlock(block.Height), recipients)
+		for i, recipient := range recipients {
+			commission, delegations, delegatorRewards := splitByCommission(view, recipient.Address, stakerRewards[i])
+			if commission.Sign() > 0 {
+				view.AddAccruedStakerReward(recipient.Address, commission)
+			}
+			for j, delegation := range delegations {
+				if delegatorRewards[j].Sign() > 0 {
+					view.AddAccruedStakerReward(delegation.Staker, delegatorRewards[j])
+				}
+			}
+		}
+	}
+
+	if scom.IsForkActive(scom.ForkGovernance, view.Height()+1) {
+		activateGovernanceParams(view, exec.state.ParentBlock())
+	}
+	if scom.IsForkActive(scom.ForkNativeStaking, view.Height()+1) {
+		releaseNativeUnbondings(view, exec.state.ParentBlock())
+	}
+
 	view.SetCoinbaseTransactionProcessed(true)
 
 	txHash := types.TxID(chainID, tx)
 	return txHash, result.OK
 }
 
+// CalculateCoinbaseOutputs splits the gas fees collected since the last coinbase transaction among the
+// validators who signed the HCC of the block, in proportion to their stakes, and pays each validator
+// its commission. It returns no output if the chain has no reward schedule.
+func CalculateCoinbaseOutputs(view *slst.StoreView, block *score.Block, valMgr score.ValidatorManager) []types.TxOutput {
+	outputs := []types.TxOutput{}
+	if view.GetRewardSchedule() == nil {
+		return outputs
+	}
+
+	recipients := getRewardRecipients(block, valMgr)
+	feeShares := score.SplitByStake(view.GetCollectedFees(), recipients)
+	for i, recipient := range recipients {
+		commission, _, _ := splitByCommission(view, recipient.Address, feeShares[i])
+		if commission.Sign() == 0 {
+			continue
+		}
+		outputs = append(outputs, types.TxOutput{
+			Address: recipient.Address,
+			Coins: types.Coins{
+				ThetaWei: big.NewInt(0),
+				TFuelWei: commission,
+			},
+		})
+	}
+	return outputs
+}
+
+// getRewardRecipients returns the validators who signed the HCC of the block, identified by the addresses
+// their stakes are bound to
+func getRewardRecipients(block *score.Block, valMgr score.ValidatorManager) []score.Validator {
+	recipients := []score.Validator{}
+	if block == nil || block.HCC.IsEmpty() {
+		return recipients
+	}
+	hccValidators := valMgr.GetValidatorSet(block.HCC.BlockHash)
+	if hccValidators == nil {
+		return recipients
+	}
+	for _, voter := range block.HCC.Voters(hccValidators) {
+		validator, err := hccValidators.GetValidator(voter)
+		if err != nil {
+			continue
+		}
+		recipients = append(recipients, score.Validator{
+			Address: hccValidators.ValidatorIdentity(validator.Address),
+			Stake:   validator.Stake,
+		})
+	}
+	return recipients
+}
+
+// splitByCommission splits the reward of the validator into its commission and the rewards of its delegators
+func splitByCommission(view *slst.StoreView, validator common.Address, amount *big.Int) (*big.Int, []score.Delegation, []*big.Int) {
+	delegations := view.GetDelegations(validator)
+	commission, delegatorRewards := score.SplitByCommission(amount, view.GetCommissionRate(validator), delegations)
+	return commission, delegations, delegatorRewards
+}
+
+func coinbaseOutputsEqual(outputs []types.TxOutput, expected []types.TxOutput) bool {
+	if len(outputs) != len(expected) {
+		return false
+	}
+	for i := range outputs {
+		if outputs[i].Address != expected[i].Address || !outputs[i].Coins.NoNil().IsEqual(expected[i].Coins) {
+			return false
+		}
+	}
+	return true
+}
+
 func (exec *CoinbaseTxExecutor) getTxInfo(transaction types.Tx) *score.TxInfo {
 	return &score.TxInfo{
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
@@ -0,0 +1,118 @@
+package execution
+
+import (
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/ledger/types"
+
+	score "github.com/thetatoken/thetasubchain/core"
+	slst "github.com/thetatoken/thetasubchain/ledger/state"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+var _ TxExecutor = (*SubchainCommissionRateUpdateTxExecutor)(nil)
+
+// ------------------------------- SubchainCommissionRateUpdate Transaction -----------------------------------
+
+// SubchainCommissionRateUpdateTxExecutor implements the TxExecutor interface
+type SubchainCommissionRateUpdateTxExecutor struct {
+	state     *slst.LedgerState
+	consensus score.ConsensusEngine
+	valMgr    score.ValidatorManager
+}
+
+// NewSubchainCommissionRateUpdateTxExecutor creates a new instance of SubchainCommissionRateUpdateTxExecutor
+func NewSubchainCommissionRateUpdateTxExecutor(state *slst.LedgerState, consensus score.ConsensusEngine,
+	valMgr score.ValidatorManager) *SubchainCommissionRateUpdateTxExecutor {
+	return &SubchainCommissionRateUpdateTxExecutor{
+		state:     state,
+		consensus: consensus,
+		valMgr:    valMgr,
+	}
+}
+
+func (exec *SubchainCommissionRateUpdateTxExecutor) sanityCheck(chainID string, view *slst.StoreView, viewSel score.ViewSelector, transaction types.Tx) result.Result {
+	tx := transaction.(*stypes.SubchainCommissionRateUpdateTx)
+	blockHeight := view.Height() + 1
+
+	res := tx.Validator.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// only the validators of the current dynasty can set their commission rates
+	validatorSet := getValidatorSet(exec.consensus.GetLedger(), exec.valMgr)
+	res = isAValidator(tx.Validator.Address, getValidatorIdentities(validatorSet))
+	if res.IsError() {
+		return res
+	}
+
+	validatorAccount, res := getInput(view, tx.Validator)
+	if res.IsError() {
+		return res
+	}
+
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(validatorAccount, signBytes, tx.Validator, blockHeight)
+	if res.IsError() {
+		return res
+	}
+
+	if minTxFee, success := sanityCheckForFee(tx.Fee, blockHeight); !success {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v TFuelWei",
+			minTxFee).WithErrorCode(result.CodeInvalidFee)
+	}
+	if !validatorAccount.Balance.IsGTE(tx.Fee) {
+		return result.Error("Insufficient fund to pay the fee: balance is %v, fee is %v",
+			validatorAccount.Balance, tx.Fee).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	if tx.CommissionRateInBasisPoints > score.MaxCommissionRateInBasisPoints {
+		return result.Error("Commission rate %v exceeds the maximum of %v basis points",
+			tx.CommissionRateInBasisPoints, score.MaxCommissionRateInBasisPoints)
+	}
+
+	return result.OK
+}
+
+func (exec *SubchainCommissionRateUpdateTxExecutor) process(chainID string, view *slst.StoreView, viewSel score.ViewSelector, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*stypes.SubchainCommissionRateUpdateTx)
+
+	validatorAccount, res := getInput(view, tx.Validator)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+	if !chargeFee(validatorAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee")
+	}
+	collectFee(view, tx.Fee.NoNil().TFuelWei)
+	validatorAccount.Sequence++
+	view.SetAccount(tx.Validator.Address, validatorAccount)
+
+	view.SetCommissionRate(tx.Validator.Address, tx.CommissionRateInBasisPoints)
+	txHash := types.TxID(chainID, tx)
+
+	logger.Infof("Commission rate updated, validator: %v, rate: %v, viewSel: %v, blockHeight: %v",
+		tx.Validator.Address.Hex(), tx.CommissionRateInBasisPoints, viewSel, view.Height()+1)
+
+	return txHash, result.OK
+}
+
+func (exec *SubchainCommissionRateUpdateTxExecutor) getTxInfo(transaction types.Tx) *score.TxInfo {
+	tx := transaction.(*stypes.SubchainCommissionRateUpdateTx)
+	return &score.TxInfo{
+		Address:           tx.Validator.Address,
+		Sequence:          tx.Validator.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *SubchainCommissionRateUpdateTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*stypes.SubchainCommissionRateUpdateTx)
+	fee := tx.Fee.NoNil()
+	gas := new(big.Int).SetUint64(getRegularTxGas(exec.state))
+	effectiveGasPrice := new(big.Int).Div(fee.TFuelWei, gas)
+	return effectiveGasPrice
+}
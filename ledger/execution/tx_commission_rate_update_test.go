@@ -0,0 +1,74 @@
+package execution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/ledger/types"
+
+	score "github.com/thetatoken/thetasubchain/core"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+func createCommissionRateUpdateTx(et *execTest, validator types.PrivAccount, sequence uint64, fee int64, rate uint64) *stypes.SubchainCommissionRateUpdateTx {
+	tx := &stypes.SubchainCommissionRateUpdateTx{
+		Fee:                         types.NewCoins(0, fee),
+		Validator:                   types.TxInput{Address: validator.PrivKey.PublicKey().Address(), Sequence: sequence},
+		CommissionRateInBasisPoints: rate,
+	}
+	sig, _ := validator.PrivKey.Sign(tx.SignBytes(et.chainID))
+	tx.SetSignature(validator.PrivKey.PublicKey().Address(), sig)
+	return tx
+}
+
+func TestCommissionRateUpdateTx(t *testing.T) {
+	assert := assert.New(t)
+
+	et := NewExecTest()
+	exec := et.executor.subchainCommissionRateUpdateTxExec
+	minFee := getMinimumTxFee()
+
+	validator := et.accVal2
+	validator.Account.Balance = types.NewCoins(0, 10*minFee)
+	et.acc2State(validator, et.accIn)
+	address := validator.PrivKey.PublicKey().Address()
+
+	tampered := createCommissionRateUpdateTx(et, validator, 1, minFee, 2000)
+	tampered.CommissionRateInBasisPoints = 0
+
+	tests := []struct {
+		name  string
+		tx    *stypes.SubchainCommissionRateUpdateTx
+		valid bool
+	}{
+		{"not a validator", createCommissionRateUpdateTx(et, et.accIn, 1, minFee, 2000), false},
+		{"validator without an account", createCommissionRateUpdateTx(et, et.accProposer, 1, minFee, 2000), false},
+		{"rate above the maximum", createCommissionRateUpdateTx(et, validator, 1, minFee, score.MaxCommissionRateInBasisPoints+1), false},
+		{"wrong sequence", createCommissionRateUpdateTx(et, validator, 2, minFee, 2000), false},
+		{"insufficient fee", createCommissionRateUpdateTx(et, validator, 1, minFee-1, 2000), false},
+		{"fee above the balance", createCommissionRateUpdateTx(et, validator, 1, 11*minFee, 2000), false},
+		{"rate changed after signing", tampered, false},
+		{"zero rate", createCommissionRateUpdateTx(et, validator, 1, minFee, 0), true},
+		{"maximum rate", createCommissionRateUpdateTx(et, validator, 1, minFee, score.MaxCommissionRateInBasisPoints), true},
+		{"valid update", createCommissionRateUpdateTx(et, validator, 1, minFee, 2000), true},
+	}
+	for _, test := range tests {
+		res := exec.sanityCheck(et.chainID, et.state().Delivered(), score.DeliveredView, test.tx)
+		assert.Equal(test.valid, res.IsOK(), "%v: %v", test.name, res.Message)
+	}
+
+	view := et.state().Delivered()
+	assert.Equal(score.DefaultCommissionRateInBasisPoints, view.GetCommissionRate(address))
+	tx := createCommissionRateUpdateTx(et, validator, 1, minFee, 2000)
+	_, res := exec.process(et.chainID, view, score.DeliveredView, tx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(uint64(2000), view.GetCommissionRate(address))
+
+	account := view.GetAccount(address)
+	assert.Equal(uint64(1), account.Sequence)
+	assert.True(types.NewCoins(0, 9*minFee).IsEqual(account.Balance))
+
+	// The same tx can not be replayed
+	res = exec.sanityCheck(et.chainID, view, score.DeliveredView, tx)
+	assert.True(res.IsError())
+}
@@ -0,0 +1,118 @@
+package execution
+
+import (
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rlp"
+
+	score "github.com/thetatoken/thetasubchain/core"
+	slst "github.com/thetatoken/thetasubchain/ledger/state"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+var _ TxExecutor = (*SubchainEquivocationEvidenceTxExecutor)(nil)
+
+// ------------------------------- SubchainEquivocationEvidence Transaction -----------------------------------
+
+// SubchainEquivocationEvidenceTxExecutor implements the TxExecutor interface
+type SubchainEquivocationEvidenceTxExecutor struct {
+	state     *slst.LedgerState
+	consensus score.ConsensusEngine
+	valMgr    score.ValidatorManager
+}
+
+// NewSubchainEquivocationEvidenceTxExecutor creates a new instance of SubchainEquivocationEvidenceTxExecutor
+func NewSubchainEquivocationEvidenceTxExecutor(state *slst.LedgerState, consensus score.ConsensusEngine,
+	valMgr score.ValidatorManager) *SubchainEquivocationEvidenceTxExecutor {
+	return &SubchainEquivocationEvidenceTxExecutor{
+		state:     state,
+		consensus: consensus,
+		valMgr:    valMgr,
+	}
+}
+
+func (exec *SubchainEquivocationEvidenceTxExecutor) sanityCheck(chainID string, view *slst.StoreView, viewSel score.ViewSelector, transaction types.Tx) result.Result {
+	tx := transaction.(*stypes.SubchainEquivocationEvidenceTx)
+	validatorSet := getValidatorSet(exec.consensus.GetLedger(), exec.valMgr)
+	validatorAddresses := getValidatorAddresses(validatorSet)
+
+	// Validate proposer, basic
+	res := tx.Proposer.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// verify the proposer is one of the validators
+	res = isAValidator(tx.Proposer.Address, validatorAddresses)
+	if res.IsError() {
+		return res
+	}
+
+	proposerAccount, res := getOrMakeInput(view, tx.Proposer)
+	if res.IsError() {
+		return res
+	}
+
+	// verify the proposer's signature
+	signBytes := tx.SignBytes(chainID)
+	if !tx.Proposer.Signature.Verify(signBytes, proposerAccount.Address) {
+		return result.Error("SignBytes: %X", signBytes)
+	}
+
+	// verify the evidence itself, and that the offender is a validator who can be slashed
+	res = tx.Evidence.Validate(chainID)
+	if res.IsError() {
+		return res
+	}
+	if isAValidator(tx.Evidence.Offender, validatorAddresses).IsError() {
+		return result.Error("The offender %v is not a validator", tx.Evidence.Offender.Hex())
+	}
+
+	if view.EquivocationEvidenceProcessed(tx.Evidence.ID()) {
+		return result.Error("The equivocation of %v in epoch %v has already been recorded", tx.Evidence.Offender.Hex(), tx.Evidence.Epoch)
+	}
+
+	return result.OK
+}
+
+func (exec *SubchainEquivocationEvidenceTxExecutor) process(chainID string, view *slst.StoreView, viewSel score.ViewSelector, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*stypes.SubchainEquivocationEvidenceTx)
+	evidence := &tx.Evidence
+
+	if view.EquivocationEvidenceProcessed(evidence.ID()) {
+		return common.Hash{}, result.Error("The equivocation of %v in epoch %v has already been recorded", evidence.Offender.Hex(), evidence.Epoch)
+	}
+
+	// The record is relayed to the mainchain by the orchestrators to slash the validator collateral
+	blockHeight := view.Height() + 1
+	record := view.AddEquivocationRecord(evidence, blockHeight)
+
+	proof, err := rlp.EncodeToBytes(evidence)
+	if err != nil {
+		return common.Hash{}, result.Error("Failed to encode the equivocation evidence: %v", err)
+	}
+	view.AddSlashIntent(types.SlashIntent{
+		Address:         evidence.Offender,
+		ReserveSequence: record.Index,
+		Proof:           proof,
+	})
+
+	txHash := types.TxID(chainID, tx)
+
+	logger.Infof("Equivocation evidence recorded, index: %v, evidence: %v, viewSel: %v, blockHeight: %v", record.Index, evidence, viewSel, blockHeight)
+
+	return txHash, result.OK
+}
+
+func (exec *SubchainEquivocationEvidenceTxExecutor) getTxInfo(transaction types.Tx) *score.TxInfo {
+	return &score.TxInfo{
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *SubchainEquivocationEvidenceTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	return new(big.Int).SetUint64(0)
+}
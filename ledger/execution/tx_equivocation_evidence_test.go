@@ -0,0 +1,141 @@
+package execution
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rlp"
+
+	score "github.com/thetatoken/thetasubchain/core"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+// createSignedHeader creates a block header at height 10 in the given epoch, signed by the proposer
+func createSignedHeader(et *execTest, proposer types.PrivAccount, epoch uint64, timestamp int64) *score.BlockHeader {
+	header := &score.BlockHeader{
+		ChainID:   et.chainID,
+		Epoch:     epoch,
+		Height:    10,
+		Parent:    common.HexToHash("0x01"),
+		HCC:       score.CommitCertificate{BlockHash: common.HexToHash("0x01")},
+		Timestamp: big.NewInt(timestamp),
+		Proposer:  proposer.PrivKey.PublicKey().Address(),
+	}
+	sig, _ := proposer.PrivKey.Sign(header.SignBytes())
+	header.SetSignature(sig)
+	return header
+}
+
+// createVote creates a vote of the voter on the block, signed with the key of the signer
+func createVote(voter types.PrivAccount, signer types.PrivAccount, header *score.BlockHeader, epoch uint64) score.Vote {
+	vote := score.Vote{Block: header.Hash(), Height: header.Height, Epoch: epoch, ID: voter.PrivKey.PublicKey().Address()}
+	sig, _ := signer.PrivKey.Sign(vote.SignBytes())
+	vote.SetSignature(sig)
+	return vote
+}
+
+// createVoteEquivocationEvidence creates the evidence of the offender voting for two blocks at height 10 in the given epoch
+func createVoteEquivocationEvidence(et *execTest, offender types.PrivAccount, epoch uint64) *score.EquivocationEvidence {
+	headerA := createSignedHeader(et, et.accProposer, epoch, 1000)
+	headerB := createSignedHeader(et, et.accProposer, epoch, 1001)
+	return score.NewVoteEquivocationEvidence(createVote(offender, offender, headerA, epoch), headerA,
+		createVote(offender, offender, headerB, epoch), headerB)
+}
+
+func createEquivocationEvidenceTx(et *execTest, proposer types.PrivAccount, signer types.PrivAccount,
+	evidence *score.EquivocationEvidence) *stypes.SubchainEquivocationEvidenceTx {
+	tx := &stypes.SubchainEquivocationEvidenceTx{
+		Proposer: types.TxInput{Address: proposer.PrivKey.PublicKey().Address()},
+		Evidence: *evidence,
+	}
+	sig, _ := signer.PrivKey.Sign(tx.SignBytes(et.chainID))
+	tx.SetSignature(proposer.PrivKey.PublicKey().Address(), sig)
+	return tx
+}
+
+func TestEquivocationEvidenceTx(t *testing.T) {
+	assert := assert.New(t)
+
+	et := NewExecTest()
+	exec := et.executor.subchainEquivocationEvidenceTxExec
+	proposer, offender := et.accProposer, et.accVal2
+	offenderAddr := offender.PrivKey.PublicKey().Address()
+	et.acc2State(proposer, offender, et.accIn)
+
+	evidence := createVoteEquivocationEvidence(et, offender, 5)
+
+	// A vote signed by another key on behalf of the offender
+	headerA := createSignedHeader(et, proposer, 5, 1000)
+	headerB := createSignedHeader(et, proposer, 5, 1001)
+	forged := score.NewVoteEquivocationEvidence(createVote(offender, offender, headerA, 5), headerA,
+		createVote(offender, et.accIn, headerB, 5), headerB)
+
+	// Votes which do not match the voted blocks
+	mismatched := score.NewVoteEquivocationEvidence(createVote(offender, offender, headerA, 5), headerB,
+		createVote(offender, offender, headerB, 5), headerA)
+
+	tests := []struct {
+		name  string
+		tx    *stypes.SubchainEquivocationEvidenceTx
+		valid bool
+	}{
+		{"proposer not a validator", createEquivocationEvidenceTx(et, et.accIn, et.accIn, evidence), false},
+		{"tx not signed by the proposer", createEquivocationEvidenceTx(et, proposer, offender, evidence), false},
+		{"forged vote signature", createEquivocationEvidenceTx(et, proposer, proposer, forged), false},
+		{"votes not matching the blocks", createEquivocationEvidenceTx(et, proposer, proposer, mismatched), false},
+		{"offender not a validator", createEquivocationEvidenceTx(et, proposer, proposer, createVoteEquivocationEvidence(et, et.accIn, 5)), false},
+		{"valid evidence", createEquivocationEvidenceTx(et, proposer, proposer, evidence), true},
+	}
+	for _, test := range tests {
+		res := exec.sanityCheck(et.chainID, et.state().Delivered(), score.DeliveredView, test.tx)
+		assert.Equal(test.valid, res.IsOK(), "%v: %v", test.name, res.Message)
+	}
+
+	// The evidence is recorded for the relay to the mainchain, and the offender is slashed
+	view := et.state().Delivered()
+	blockHeight := view.Height() + 1
+	_, res := exec.process(et.chainID, view, score.DeliveredView, createEquivocationEvidenceTx(et, proposer, proposer, evidence))
+	assert.True(res.IsOK(), res.Message)
+	assert.True(view.EquivocationEvidenceProcessed(evidence.ID()))
+	assert.Equal(uint64(1), view.GetEquivocationRecordCount())
+	record := view.GetEquivocationRecord(0)
+	assert.NotNil(record)
+	assert.Equal(uint64(0), record.Index)
+	assert.Equal(blockHeight, record.BlockHeight)
+	assert.Equal(evidence.ID(), record.Evidence.ID())
+
+	slashIntents := view.GetSlashIntents()
+	assert.Equal(1, len(slashIntents))
+	assert.Equal(offenderAddr, slashIntents[0].Address)
+	assert.Equal(uint64(0), slashIntents[0].ReserveSequence)
+	proof := &score.EquivocationEvidence{}
+	assert.Nil(rlp.DecodeBytes(slashIntents[0].Proof, proof))
+	assert.Equal(evidence.ID(), proof.ID())
+	assert.True(proof.Validate(et.chainID).IsOK())
+
+	// Another evidence of the same offence is rejected, the offender is slashed once
+	headerC := createSignedHeader(et, proposer, 5, 1002)
+	sameOffence := score.NewVoteEquivocationEvidence(createVote(offender, offender, headerA, 5), headerA,
+		createVote(offender, offender, headerC, 5), headerC)
+	assert.True(sameOffence.Validate(et.chainID).IsOK())
+	res = exec.sanityCheck(et.chainID, view, score.DeliveredView, createEquivocationEvidenceTx(et, proposer, proposer, sameOffence))
+	assert.True(res.IsError())
+	_, res = exec.process(et.chainID, view, score.DeliveredView, createEquivocationEvidenceTx(et, proposer, proposer, evidence))
+	assert.True(res.IsError())
+	assert.Equal(uint64(1), view.GetEquivocationRecordCount())
+	assert.Equal(1, len(view.GetSlashIntents()))
+
+	// An offence in another epoch is slashed again
+	nextOffence := createVoteEquivocationEvidence(et, offender, 6)
+	res = exec.sanityCheck(et.chainID, view, score.DeliveredView, createEquivocationEvidenceTx(et, proposer, proposer, nextOffence))
+	assert.True(res.IsOK(), res.Message)
+	_, res = exec.process(et.chainID, view, score.DeliveredView, createEquivocationEvidenceTx(et, proposer, proposer, nextOffence))
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(uint64(2), view.GetEquivocationRecordCount())
+	slashIntents = view.GetSlashIntents()
+	assert.Equal(2, len(slashIntents))
+	assert.Equal(uint64(1), slashIntents[1].ReserveSequence)
+}
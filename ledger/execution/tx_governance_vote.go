@@ -0,0 +1,254 @@
+package execution
+
+import (
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+
+	score "github.com/thetatoken/thetasubchain/core"
+	slst "github.com/thetatoken/thetasubchain/ledger/state"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+	svm "github.com/thetatoken/thetasubchain/ledger/vm"
+)
+
+var _ TxExecutor = (*SubchainGovernanceVoteTxExecutor)(nil)
+
+// systemContractCallGasLimit is the gas limit of the contract calls made by the ledger itself
+const systemContractCallGasLimit uint64 = 1000000
+
+// ------------------------------- SubchainGovernanceVote Transaction -----------------------------------
+
+// SubchainGovernanceVoteTxExecutor implements the TxExecutor interface
+type SubchainGovernanceVoteTxExecutor struct {
+	state     *slst.LedgerState
+	consensus score.ConsensusEngine
+	valMgr    score.ValidatorManager
+}
+
+// NewSubchainGovernanceVoteTxExecutor creates a new instance of SubchainGovernanceVoteTxExecutor
+func NewSubchainGovernanceVoteTxExecutor(state *slst.LedgerState, consensus score.ConsensusEngine,
+	valMgr score.ValidatorManager) *SubchainGovernanceVoteTxExecutor {
+	return &SubchainGovernanceVoteTxExecutor{
+		state:     state,
+		consensus: consensus,
+		valMgr:    valMgr,
+	}
+}
+
+func (exec *SubchainGovernanceVoteTxExecutor) sanityCheck(chainID string, view *slst.StoreView, viewSel score.ViewSelector, transaction types.Tx) result.Result {
+	tx := transaction.(*stypes.SubchainGovernanceVoteTx)
+	blockHeight := view.Height() + 1
+
+	res := tx.Validator.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// only the validators of the current dynasty can propose and vote, the votes are weighted by
+	// their stakes in the validator set of the ledger state
+	res = isAValidator(tx.Validator.Address, getValidatorIdentities(view.GetValidatorSet()))
+	if res.IsError() {
+		return res
+	}
+
+	validatorAccount, res := getInput(view, tx.Validator)
+	if res.IsError() {
+		return res
+	}
+
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(validatorAccount, signBytes, tx.Validator, blockHeight)
+	if res.IsError() {
+		return res
+	}
+
+	if minTxFee, success := sanityCheckForFee(tx.Fee, blockHeight); !success {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v TFuelWei",
+			minTxFee).WithErrorCode(result.CodeInvalidFee)
+	}
+	if !validatorAccount.Balance.IsGTE(tx.Fee) {
+		return result.Error("Insufficient fund to pay the fee: balance is %v, fee is %v",
+			validatorAccount.Balance, tx.Fee).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	if tx.IsProposal() {
+		if err := score.ValidateGovernanceParam(tx.Param, tx.Value, tx.ActivationHeight); err != nil {
+			return result.Error("Invalid proposal: %v", err)
+		}
+		if tx.ActivationHeight < blockHeight+score.MinGovernanceActivationDelayInBlocks {
+			return result.Error("The activation height %v needs to be at least %v blocks after the current height %v",
+				tx.ActivationHeight, score.MinGovernanceActivationDelayInBlocks, blockHeight)
+		}
+		if tx.Param == score.GovParamNativeStakeRejection {
+			recordIndex, _ := score.ParseNativeStakeRejectionValue(tx.Value)
+			record := view.GetNativeStakeRecord(recordIndex)
+			if record == nil || record.Type != score.NativeStakeRecordTypeDeposit {
+				return result.Error("Native stake record %v is not a deposit", recordIndex)
+			}
+		}
+		// the votes on a case must go to a single proposal for it to reach the majority
+		if score.IsDecisionParam(tx.Param) {
+			if existing := view.GetDecisionProposal(tx.Param, tx.Value); existing != nil {
+				if existing.Approved {
+					return result.Error("%v %v has been approved in proposal %v", tx.Param, tx.Value, existing.ID)
+				}
+				if blockHeight < existing.ActivationHeight {
+					return result.Error("%v %v is open for votes in proposal %v", tx.Param, tx.Value, existing.ID)
+				}
+			}
+		}
+		return result.OK
+	}
+
+	if tx.Param != "" || tx.Value != "" || tx.ActivationHeight != 0 {
+		return result.Error("A vote cannot set the parameter, the value or the activation height")
+	}
+	proposal := view.GetGovernanceProposal(tx.ProposalID)
+	if proposal == nil {
+		return result.Error("Governance proposal %v does not exist", tx.ProposalID)
+	}
+	if proposal.Approved {
+		return result.Error("Governance proposal %v has been approved", tx.ProposalID)
+	}
+	if blockHeight >= proposal.ActivationHeight {
+		return result.Error("Governance proposal %v has expired at height %v", tx.ProposalID, proposal.ActivationHeight)
+	}
+	if proposal.HasVoted(tx.Validator.Address) {
+		return result.Error("Validator %v has voted for governance proposal %v", tx.Validator.Address.Hex(), tx.ProposalID)
+	}
+
+	return result.OK
+}
+
+func (exec *SubchainGovernanceVoteTxExecutor) process(chainID string, view *slst.StoreView, viewSel score.ViewSelector, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*stypes.SubchainGovernanceVoteTx)
+	blockHeight := view.Height() + 1
+
+	validatorAccount, res := getInput(view, tx.Validator)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+	if !chargeFee(validatorAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee")
+	}
+	collectFee(view, tx.Fee.NoNil().TFuelWei)
+	validatorAccount.Sequence++
+	view.SetAccount(tx.Validator.Address, validatorAccount)
+
+	var proposal *score.GovernanceProposal
+	if tx.IsProposal() {
+		proposal = &score.GovernanceProposal{
+			Proposer:         tx.Validator.Address,
+			Param:            tx.Param,
+			Value:            tx.Value,
+			ActivationHeight: tx.ActivationHeight,
+			Voters:           []common.Address{tx.Validator.Address},
+		}
+		view.AddGovernanceProposal(proposal)
+	} else {
+		proposal = view.GetGovernanceProposal(tx.ProposalID)
+		if proposal == nil {
+			return common.Hash{}, result.Error("Governance proposal %v does not exist", tx.ProposalID)
+		}
+		proposal.Voters = append(proposal.Voters, tx.Validator.Address)
+	}
+
+	if proposal.HasMajority(view.GetValidatorSet()) {
+		proposal.Approved = true
+		proposal.ApprovalHeight = blockHeight
+		if proposal.Param == score.GovParamNativeStakeRejection {
+			recordIndex, _ := score.ParseNativeStakeRejectionValue(proposal.Value)
+			returnRejectedNativeStake(view, view.GetNativeStakeRecord(recordIndex), blockHeight)
+		} else if !score.IsDecisionParam(proposal.Param) { // the approved decisions are read from the proposals
+			view.AddGovernanceParamValue(proposal.Param, score.GovernanceParamValue{
+				ActivationHeight: proposal.ActivationHeight,
+				Value:            proposal.Value,
+			})
+		}
+		logger.Infof("Governance proposal approved: %v, blockHeight: %v", proposal, blockHeight)
+	}
+	view.SetGovernanceProposal(proposal)
+
+	txHash := types.TxID(chainID, tx)
+
+	logger.Debugf("Governance vote processed, validator: %v, proposal: %v, viewSel: %v, blockHeight: %v",
+		tx.Validator.Address.Hex(), proposal.ID, viewSel, blockHeight)
+
+	return txHash, result.OK
+}
+
+func (exec *SubchainGovernanceVoteTxExecutor) getTxInfo(transaction types.Tx) *score.TxInfo {
+	tx := transaction.(*stypes.SubchainGovernanceVoteTx)
+	return &score.TxInfo{
+		Address:           tx.Validator.Address,
+		Sequence:          tx.Validator.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *SubchainGovernanceVoteTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*stypes.SubchainGovernanceVoteTx)
+	fee := tx.Fee.NoNil()
+	gas := new(big.Int).SetUint64(getRegularTxGas(exec.state))
+	effectiveGasPrice := new(big.Int).Div(fee.TFuelWei, gas)
+	return effectiveGasPrice
+}
+
+// activateGovernanceParams applies the approved runtime parameter values taking effect at the current block
+// which are not read from the ledger state at the time of use. So far only the cross-chain fee setter, which
+// is kept by the chain registrar contract.
+func activateGovernanceParams(view *slst.StoreView, parentBlock *score.Block) {
+	blockHeight := view.Height() + 1
+	for _, v := range view.GetGovernanceParamValues(score.GovParamCrossChainFeeSetter) {
+		if v.ActivationHeight == blockHeight {
+			updateCrossChainFeeSetter(view, parentBlock, common.HexToAddress(v.Value))
+		}
+	}
+}
+
+// updateCrossChainFeeSetter calls ChainRegistrarOnSubchain.updateFeeSetter on behalf of the current fee setter
+func updateCrossChainFeeSetter(view *slst.StoreView, parentBlock *score.Block, newFeeSetter common.Address) {
+	registrar := view.GetChainRegistrarContractAddress()
+	if registrar == nil {
+		logger.Warnf("Chain registrar contract is not set, failed to update the fee setter to %v", newFeeSetter.Hex())
+		return
+	}
+	parentBlockInfo := svm.NewBlockInfo(parentBlock.Height, parentBlock.TimestampInSeconds(), parentBlock.ChainID, view.GetBlockGasLimit(view.Height()+1))
+
+	// read the current fee setter on a copy of the view, so that the call leaves no trace in the ledger state
+	readView, err := view.Copy()
+	if err != nil {
+		logger.Errorf("Failed to copy the store view: %v", err)
+		return
+	}
+	ret, _, _, evmErr := svm.Execute(parentBlockInfo, systemContractCall(common.Address{}, *registrar, "feeSetter()", nil), readView)
+	if evmErr != nil || len(ret) != 32 {
+		logger.Errorf("Failed to read the fee setter of the chain registrar: %v", evmErr)
+		return
+	}
+	currentFeeSetter := common.BytesToAddress(ret[12:])
+
+	arg := common.LeftPadBytes(newFeeSetter.Bytes(), 32)
+	_, _, _, evmErr = svm.Execute(parentBlockInfo, systemContractCall(currentFeeSetter, *registrar, "updateFeeSetter(address)", arg), view)
+	if evmErr != nil {
+		logger.Errorf("Failed to update the fee setter of the chain registrar to %v: %v", newFeeSetter.Hex(), evmErr)
+		return
+	}
+	logger.Infof("Cross-chain fee setter updated from %v to %v", currentFeeSetter.Hex(), newFeeSetter.Hex())
+}
+
+// systemContractCall builds a smart contract transaction for a contract call made by the ledger itself,
+// which is neither signed nor charged
+func systemContractCall(from common.Address, contract common.Address, method string, args common.Bytes) *types.SmartContractTx {
+	data := append(crypto.Keccak256(common.Bytes(method))[:4], args...)
+	return &types.SmartContractTx{
+		From:     types.NewTxInput(from, types.NewCoins(0, 0), 0),
+		To:       types.TxOutput{Address: contract},
+		GasLimit: systemContractCallGasLimit,
+		GasPrice: big.NewInt(0),
+		Data:     data,
+	}
+}
@@ -0,0 +1,183 @@
+package execution
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/ledger/types"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+func createGovernanceVoteTx(et *execTest, validator types.PrivAccount, sequence uint64, fee int64, proposalID uint64,
+	param, value string, activationHeight uint64) *stypes.SubchainGovernanceVoteTx {
+	tx := &stypes.SubchainGovernanceVoteTx{
+		Fee:              types.NewCoins(0, fee),
+		Validator:        types.TxInput{Address: validator.PrivKey.PublicKey().Address(), Sequence: sequence},
+		ProposalID:       proposalID,
+		Param:            param,
+		Value:            value,
+		ActivationHeight: activationHeight,
+	}
+	sig, _ := validator.PrivKey.Sign(tx.SignBytes(et.chainID))
+	tx.SetSignature(validator.PrivKey.PublicKey().Address(), sig)
+	return tx
+}
+
+// setupGovernanceTest funds the validators, and sets up a validator set in which the proposer and the second
+// validator hold more than 2/3 of the stake together, but neither of them alone, and the third validator
+// holds the rest
+func setupGovernanceTest(et *execTest) (proposer, val2, val3 types.PrivAccount) {
+	proposer, val2, val3 = et.accProposer, et.accVal2, et.accOut
+	proposer.Account.Balance = types.NewCoins(0, 10*getMinimumTxFee())
+	val2.Account.Balance = types.NewCoins(0, 10*getMinimumTxFee())
+	et.acc2State(proposer, val2, val3, et.accIn)
+
+	vs := score.NewValidatorSet(big.NewInt(5))
+	vs.AddValidator(score.NewValidator(proposer.PrivKey.PublicKey().Address().Hex(), big.NewInt(100)))
+	vs.AddValidator(score.NewValidator(val2.PrivKey.PublicKey().Address().Hex(), big.NewInt(100)))
+	vs.AddValidator(score.NewValidator(val3.PrivKey.PublicKey().Address().Hex(), big.NewInt(50)))
+	et.state().Delivered().UpdateValidatorSet(scom.MapChainID(et.chainID), vs)
+	return proposer, val2, val3
+}
+
+func TestGovernanceVoteTx(t *testing.T) {
+	assert := assert.New(t)
+
+	et := NewExecTest()
+	exec := et.executor.subchainGovernanceVoteTxExec
+	proposer, val2, val3 := setupGovernanceTest(et)
+	minFee := getMinimumTxFee()
+	gasLimit := score.GovParamBlockGasLimit
+	blockHeight := et.state().Delivered().Height() + 1
+	activationHeight := blockHeight + score.MinGovernanceActivationDelayInBlocks
+
+	tests := []struct {
+		name  string
+		tx    *stypes.SubchainGovernanceVoteTx
+		valid bool
+	}{
+		{"not a validator", createGovernanceVoteTx(et, et.accIn, 1, minFee, 0, gasLimit, "50000000", activationHeight), false},
+		{"wrong sequence", createGovernanceVoteTx(et, proposer, 2, minFee, 0, gasLimit, "50000000", activationHeight), false},
+		{"insufficient fee", createGovernanceVoteTx(et, proposer, 1, minFee-1, 0, gasLimit, "50000000", activationHeight), false},
+		{"fee above the balance", createGovernanceVoteTx(et, proposer, 1, 11*minFee, 0, gasLimit, "50000000", activationHeight), false},
+		{"unknown parameter", createGovernanceVoteTx(et, proposer, 1, minFee, 0, "unknown_param", "1", activationHeight), false},
+		{"block gas limit below the bound", createGovernanceVoteTx(et, proposer, 1, minFee, 0, gasLimit, "999999", activationHeight), false},
+		{"block gas limit above the bound", createGovernanceVoteTx(et, proposer, 1, minFee, 0, gasLimit, "1000000001", activationHeight), false},
+		{"activation too early", createGovernanceVoteTx(et, proposer, 1, minFee, 0, gasLimit, "50000000", activationHeight-1), false},
+		{"vote on a missing proposal", createGovernanceVoteTx(et, proposer, 1, minFee, 1, "", "", 0), false},
+		{"vote setting the parameter", createGovernanceVoteTx(et, proposer, 1, minFee, 1, gasLimit, "50000000", 0), false},
+		{"earliest activation", createGovernanceVoteTx(et, proposer, 1, minFee, 0, gasLimit, "50000000", activationHeight), true},
+		{"valid proposal", createGovernanceVoteTx(et, proposer, 1, minFee, 0, gasLimit, "50000000", 200), true},
+	}
+	for _, test := range tests {
+		res := exec.sanityCheck(et.chainID, et.state().Delivered(), score.DeliveredView, test.tx)
+		assert.Equal(test.valid, res.IsOK(), "%v: %v", test.name, res.Message)
+	}
+
+	// The proposer alone does not hold the majority
+	view := et.state().Delivered()
+	_, res := exec.process(et.chainID, view, score.DeliveredView, createGovernanceVoteTx(et, proposer, 1, minFee, 0, gasLimit, "50000000", 200))
+	assert.True(res.IsOK(), res.Message)
+	proposal := view.GetGovernanceProposal(1)
+	assert.NotNil(proposal)
+	assert.False(proposal.Approved)
+	assert.True(proposal.HasVoted(proposer.PrivKey.PublicKey().Address()))
+	assert.Equal(0, len(view.GetGovernanceParamValues(gasLimit)))
+
+	res = exec.sanityCheck(et.chainID, view, score.DeliveredView, createGovernanceVoteTx(et, proposer, 2, minFee, 1, "", "", 0))
+	assert.True(res.IsError(), "voted twice")
+
+	// The vote of the second validator approves the proposal
+	vote := createGovernanceVoteTx(et, val2, 1, minFee, 1, "", "", 0)
+	res = exec.sanityCheck(et.chainID, view, score.DeliveredView, vote)
+	assert.True(res.IsOK(), res.Message)
+	_, res = exec.process(et.chainID, view, score.DeliveredView, vote)
+	assert.True(res.IsOK(), res.Message)
+	proposal = view.GetGovernanceProposal(1)
+	assert.True(proposal.Approved)
+	assert.Equal(blockHeight, proposal.ApprovalHeight)
+	assert.Equal([]score.GovernanceParamValue{{ActivationHeight: 200, Value: "50000000"}}, view.GetGovernanceParamValues(gasLimit))
+	assert.Equal(uint64(50000000), view.GetBlockGasLimit(200))
+
+	res = exec.sanityCheck(et.chainID, view, score.DeliveredView, createGovernanceVoteTx(et, val3, 1, minFee, 1, "", "", 0))
+	assert.True(res.IsError(), "vote on an approved proposal")
+
+	// The votes on a downtime slash go to a single proposal while it is open
+	slash := score.DowntimeSlashValue(big.NewInt(5), val3.PrivKey.PublicKey().Address())
+	_, res = exec.process(et.chainID, view, score.DeliveredView, createGovernanceVoteTx(et, proposer, 2, minFee, 0, score.GovParamDowntimeSlash, slash, activationHeight))
+	assert.True(res.IsOK(), res.Message)
+	res = exec.sanityCheck(et.chainID, view, score.DeliveredView, createGovernanceVoteTx(et, val2, 2, minFee, 0, score.GovParamDowntimeSlash, slash, activationHeight+1))
+	assert.True(res.IsError(), "second proposal of an open downtime slash")
+
+	// No votes are accepted from the activation height on
+	et.fastforwardTo(activationHeight - 1)
+	view = et.state().Delivered()
+	res = exec.sanityCheck(et.chainID, view, score.DeliveredView, createGovernanceVoteTx(et, val2, 2, minFee, 2, "", "", 0))
+	assert.True(res.IsError(), "vote on an expired proposal")
+
+	// The downtime slash can be proposed again once the previous proposal has expired without approval
+	res = exec.sanityCheck(et.chainID, view, score.DeliveredView, createGovernanceVoteTx(et, val2, 2, minFee, 0, score.GovParamDowntimeSlash, slash, activationHeight+200))
+	assert.True(res.IsOK(), res.Message)
+}
+
+func TestDowntimeSlashApproval(t *testing.T) {
+	assert := assert.New(t)
+
+	et := NewExecTest()
+	exec := et.executor.subchainGovernanceVoteTxExec
+	proposer, val2, val3 := setupGovernanceTest(et)
+	offender := et.accIn
+	minFee := getMinimumTxFee()
+	blockHeight := et.state().Delivered().Height() + 1
+	activationHeight := blockHeight + score.MinGovernanceActivationDelayInBlocks
+
+	// The proposer and the second validator hold exactly 2/3 of the stake together
+	vs := score.NewValidatorSet(big.NewInt(5))
+	vs.AddValidator(score.NewValidator(proposer.PrivKey.PublicKey().Address().Hex(), big.NewInt(100)))
+	vs.AddValidator(score.NewValidator(val2.PrivKey.PublicKey().Address().Hex(), big.NewInt(100)))
+	vs.AddValidator(score.NewValidator(val3.PrivKey.PublicKey().Address().Hex(), big.NewInt(50)))
+	vs.AddValidator(score.NewValidator(offender.PrivKey.PublicKey().Address().Hex(), big.NewInt(50)))
+	view := et.state().Delivered()
+	view.UpdateValidatorSet(scom.MapChainID(et.chainID), vs)
+
+	slash := score.DowntimeSlashValue(big.NewInt(4), offender.PrivKey.PublicKey().Address())
+	tests := []struct {
+		name     string
+		tx       *stypes.SubchainGovernanceVoteTx
+		approved bool
+	}{
+		{"proposal, 1/3 of the stake", createGovernanceVoteTx(et, proposer, 1, minFee, 0, score.GovParamDowntimeSlash, slash, activationHeight), false},
+		{"vote at exactly 2/3 of the stake", createGovernanceVoteTx(et, val2, 1, minFee, 1, "", "", 0), false},
+		{"vote above 2/3 of the stake", createGovernanceVoteTx(et, val3, 1, minFee, 1, "", "", 0), true},
+	}
+	for _, test := range tests {
+		res := exec.sanityCheck(et.chainID, view, score.DeliveredView, test.tx)
+		assert.True(res.IsOK(), "%v: %v", test.name, res.Message)
+		_, res = exec.process(et.chainID, view, score.DeliveredView, test.tx)
+		assert.True(res.IsOK(), "%v: %v", test.name, res.Message)
+
+		proposal := view.GetDowntimeSlashProposal(slash)
+		assert.NotNil(proposal, test.name)
+		assert.Equal(uint64(1), proposal.ID, test.name)
+		assert.Equal(test.approved, proposal.Approved, test.name)
+	}
+
+	// The approved downtime slash is read from the proposal, it does not change any runtime parameter
+	proposal := view.GetDecisionProposal(score.GovParamDowntimeSlash, slash)
+	assert.True(proposal.Approved)
+	assert.Equal(blockHeight, proposal.ApprovalHeight)
+	assert.Equal(0, len(view.GetGovernanceParamValues(score.GovParamDowntimeSlash)))
+
+	// The downtime is slashed once, it cannot be voted on or proposed again
+	res := exec.sanityCheck(et.chainID, view, score.DeliveredView, createGovernanceVoteTx(et, offender, 1, minFee, 1, "", "", 0))
+	assert.True(res.IsError(), "vote on an approved downtime slash")
+	res = exec.sanityCheck(et.chainID, view, score.DeliveredView, createGovernanceVoteTx(et, val2, 2, minFee, 0, score.GovParamDowntimeSlash, slash, activationHeight+1))
+	assert.True(res.IsError(), "proposal of an approved downtime slash")
+	et.fastforwardTo(activationHeight + 1)
+	res = exec.sanityCheck(et.chainID, et.state().Delivered(), score.DeliveredView, createGovernanceVoteTx(et, val2, 2, minFee, 0, score.GovParamDowntimeSlash, slash, activationHeight+200))
+	assert.True(res.IsError(), "proposal of an approved downtime slash after the activation")
+}
@@ -0,0 +1,365 @@
+package execution
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/ledger/types"
+
+	score "github.com/thetatoken/thetasubchain/core"
+	"github.com/thetatoken/thetasubchain/eth/abi"
+	scta "github.com/thetatoken/thetasubchain/interchain/contracts/accessors"
+	slst "github.com/thetatoken/thetasubchain/ledger/state"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+	svm "github.com/thetatoken/thetasubchain/ledger/vm"
+)
+
+var _ TxExecutor = (*SubchainStakeTxExecutor)(nil)
+var _ TxExecutor = (*SubchainUnstakeTxExecutor)(nil)
+
+// ------------------------------- SubchainStake Transaction -----------------------------------
+
+// SubchainStakeTxExecutor implements the TxExecutor interface
+type SubchainStakeTxExecutor struct {
+	state *slst.LedgerState
+}
+
+// NewSubchainStakeTxExecutor creates a new instance of SubchainStakeTxExecutor
+func NewSubchainStakeTxExecutor(state *slst.LedgerState) *SubchainStakeTxExecutor {
+	return &SubchainStakeTxExecutor{
+		state: state,
+	}
+}
+
+func (exec *SubchainStakeTxExecutor) sanityCheck(chainID string, view *slst.StoreView, viewSel score.ViewSelector, transaction types.Tx) result.Result {
+	tx := transaction.(*stypes.SubchainStakeTx)
+	blockHeight := view.Height() + 1
+
+	res := tx.Staker.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	stakerAccount, res := getInput(view, tx.Staker)
+	if res.IsError() {
+		return res
+	}
+
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(stakerAccount, signBytes, tx.Staker, blockHeight)
+	if res.IsError() {
+		return res
+	}
+
+	if minTxFee, success := sanityCheckForFee(tx.Fee, blockHeight); !success {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v TFuelWei",
+			minTxFee).WithErrorCode(result.CodeInvalidFee)
+	}
+	if !stakerAccount.Balance.IsGTE(tx.Fee) {
+		return result.Error("Insufficient fund to pay the fee: balance is %v, fee is %v",
+			stakerAccount.Balance, tx.Fee).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	return sanityCheckNativeStake(tx.Validator, tx.Amount)
+}
+
+func (exec *SubchainStakeTxExecutor) process(chainID string, view *slst.StoreView, viewSel score.ViewSelector, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*stypes.SubchainStakeTx)
+	blockHeight := view.Height() + 1
+
+	// lock the vouchers first, the transaction is rejected if the staker does not hold enough of them
+	err := transferGovernanceTokenVouchers(view, exec.state.ParentBlock(), tx.Staker.Address, score.NativeStakeEscrowAddress, tx.Amount)
+	if err != nil {
+		return common.Hash{}, result.Error("Failed to lock the governance token vouchers: %v", err)
+	}
+
+	stakerAccount, res := getInput(view, tx.Staker)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+	if !chargeFee(stakerAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee")
+	}
+	collectFee(view, tx.Fee.NoNil().TFuelWei)
+	stakerAccount.Sequence++
+	view.SetAccount(tx.Staker.Address, stakerAccount)
+
+	stake := view.GetNativeStake(tx.Validator, tx.Staker.Address)
+	view.SetNativeStake(tx.Validator, tx.Staker.Address, stake.Add(stake, tx.Amount))
+
+	record := &score.NativeStakeRecord{
+		Type:        score.NativeStakeRecordTypeDeposit,
+		Staker:      tx.Staker.Address,
+		Validator:   tx.Validator,
+		Amount:      tx.Amount,
+		BlockHeight: blockHeight,
+	}
+	view.AddNativeStakeRecord(record)
+
+	txHash := types.TxID(chainID, tx)
+
+	logger.Debugf("Native stake locked: %v, blockHeight: %v", record, blockHeight)
+
+	return txHash, result.OK
+}
+
+func (exec *SubchainStakeTxExecutor) getTxInfo(transaction types.Tx) *score.TxInfo {
+	tx := transaction.(*stypes.SubchainStakeTx)
+	return &score.TxInfo{
+		Address:           tx.Staker.Address,
+		Sequence:          tx.Staker.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *SubchainStakeTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*stypes.SubchainStakeTx)
+	fee := tx.Fee.NoNil()
+	gas := new(big.Int).SetUint64(getRegularTxGas(exec.state))
+	effectiveGasPrice := new(big.Int).Div(fee.TFuelWei, gas)
+	return effectiveGasPrice
+}
+
+// ------------------------------- SubchainUnstake Transaction -----------------------------------
+
+// SubchainUnstakeTxExecutor implements the TxExecutor interface
+type SubchainUnstakeTxExecutor struct {
+	state *slst.LedgerState
+}
+
+// NewSubchainUnstakeTxExecutor creates a new instance of SubchainUnstakeTxExecutor
+func NewSubchainUnstakeTxExecutor(state *slst.LedgerState) *SubchainUnstakeTxExecutor {
+	return &SubchainUnstakeTxExecutor{
+		state: state,
+	}
+}
+
+func (exec *SubchainUnstakeTxExecutor) sanityCheck(chainID string, view *slst.StoreView, viewSel score.ViewSelector, transaction types.Tx) result.Result {
+	tx := transaction.(*stypes.SubchainUnstakeTx)
+	blockHeight := view.Height() + 1
+
+	res := tx.Staker.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	stakerAccount, res := getInput(view, tx.Staker)
+	if res.IsError() {
+		return res
+	}
+
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(stakerAccount, signBytes, tx.Staker, blockHeight)
+	if res.IsError() {
+		return res
+	}
+
+	if minTxFee, success := sanityCheckForFee(tx.Fee, blockHeight); !success {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v TFuelWei",
+			minTxFee).WithErrorCode(result.CodeInvalidFee)
+	}
+	if !stakerAccount.Balance.IsGTE(tx.Fee) {
+		return result.Error("Insufficient fund to pay the fee: balance is %v, fee is %v",
+			stakerAccount.Balance, tx.Fee).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	res = sanityCheckNativeStake(tx.Validator, tx.Amount)
+	if res.IsError() {
+		return res
+	}
+
+	stake := view.GetNativeStake(tx.Validator, tx.Staker.Address)
+	if stake.Cmp(tx.Amount) < 0 {
+		return result.Error("Insufficient stake: %v has staked %v to validator %v, cannot unstake %v",
+			tx.Staker.Address.Hex(), stake, tx.Validator.Hex(), tx.Amount)
+	}
+
+	return result.OK
+}
+
+func (exec *SubchainUnstakeTxExecutor) process(chainID string, view *slst.StoreView, viewSel score.ViewSelector, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*stypes.SubchainUnstakeTx)
+	blockHeight := view.Height() + 1
+
+	stakerAccount, res := getInput(view, tx.Staker)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+	if !chargeFee(stakerAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee")
+	}
+	collectFee(view, tx.Fee.NoNil().TFuelWei)
+	stakerAccount.Sequence++
+	view.SetAccount(tx.Staker.Address, stakerAccount)
+
+	stake := view.GetNativeStake(tx.Validator, tx.Staker.Address)
+	view.SetNativeStake(tx.Validator, tx.Staker.Address, stake.Sub(stake, tx.Amount))
+
+	unbondings := view.GetNativeUnbondings()
+	unbondings = append(unbondings, score.NativeUnbonding{
+		Staker:        tx.Staker.Address,
+		Validator:     tx.Validator,
+		Amount:        tx.Amount,
+		ReleaseHeight: blockHeight + score.NativeStakeUnbondingPeriodInBlocks,
+	})
+	view.SetNativeUnbondings(unbondings)
+
+	record := &score.NativeStakeRecord{
+		Type:        score.NativeStakeRecordTypeWithdrawal,
+		Staker:      tx.Staker.Address,
+		Validator:   tx.Validator,
+		Amount:      tx.Amount,
+		BlockHeight: blockHeight,
+	}
+	view.AddNativeStakeRecord(record)
+
+	txHash := types.TxID(chainID, tx)
+
+	logger.Debugf("Native stake unbonding: %v, blockHeight: %v", record, blockHeight)
+
+	return txHash, result.OK
+}
+
+func (exec *SubchainUnstakeTxExecutor) getTxInfo(transaction types.Tx) *score.TxInfo {
+	tx := transaction.(*stypes.SubchainUnstakeTx)
+	return &score.TxInfo{
+		Address:           tx.Staker.Address,
+		Sequence:          tx.Staker.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *SubchainUnstakeTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*stypes.SubchainUnstakeTx)
+	fee := tx.Fee.NoNil()
+	gas := new(big.Int).SetUint64(getRegularTxGas(exec.state))
+	effectiveGasPrice := new(big.Int).Div(fee.TFuelWei, gas)
+	return effectiveGasPrice
+}
+
+func sanityCheckNativeStake(validator common.Address, amount *big.Int) result.Result {
+	if validator == (common.Address{}) {
+		return result.Error("The validator address cannot be empty")
+	}
+	if amount == nil || amount.Sign() <= 0 {
+		return result.Error("Invalid stake amount: %v", amount)
+	}
+	return result.OK
+}
+
+// releaseNativeUnbondings returns the unstaked amounts whose unbonding period has ended to the stakers. An
+// unbonding whose transfer fails stays in the list, and is retried in the next blocks.
+func releaseNativeUnbondings(view *slst.StoreView, parentBlock *score.Block) {
+	blockHeight := view.Height() + 1
+	unbondings := view.GetNativeUnbondings()
+	remaining := []score.NativeUnbonding{}
+	for _, unbonding := range unbondings {
+		if unbonding.ReleaseHeight > blockHeight {
+			remaining = append(remaining, unbonding)
+			continue
+		}
+		err := transferGovernanceTokenVouchers(view, parentBlock, score.NativeStakeEscrowAddress, unbonding.Staker, unbonding.Amount)
+		if err != nil {
+			// should not happen, the escrow holds all the locked vouchers
+			logger.Errorf("Failed to release the native stake unbonding %v, will retry: %v", unbonding, err)
+			remaining = append(remaining, unbonding)
+			continue
+		}
+		logger.Infof("Released the native stake unbonding %v", unbonding)
+	}
+	if len(remaining) != len(unbondings) {
+		view.SetNativeUnbondings(remaining)
+	}
+}
+
+// returnRejectedNativeStake returns the stake of the deposit the mainchain has rejected to the staker. The part
+// of the stake already unstaked is left to its unbonding, the rest is released in the next block.
+func returnRejectedNativeStake(view *slst.StoreView, record *score.NativeStakeRecord, blockHeight uint64) {
+	stake := view.GetNativeStake(record.Validator, record.Staker)
+	amount := new(big.Int).Set(record.Amount)
+	if stake.Cmp(amount) < 0 {
+		amount.Set(stake)
+	}
+	if amount.Sign() == 0 {
+		logger.Infof("Native stake record %v has been rejected, the stake has been unstaked", record)
+		return
+	}
+	view.SetNativeStake(record.Validator, record.Staker, stake.Sub(stake, amount))
+
+	unbondings := view.GetNativeUnbondings()
+	unbondings = append(unbondings, score.NativeUnbonding{
+		Staker:        record.Staker,
+		Validator:     record.Validator,
+		Amount:        amount,
+		ReleaseHeight: blockHeight + 1,
+	})
+	view.SetNativeUnbondings(unbondings)
+
+	logger.Infof("Native stake record %v has been rejected, returning %v to the staker", record, amount)
+}
+
+// transferGovernanceTokenVouchers transfers the vouchers of the governance token on behalf of the sender
+func transferGovernanceTokenVouchers(view *slst.StoreView, parentBlock *score.Block, from, to common.Address, amount *big.Int) error {
+	voucher, err := getGovernanceTokenVoucher(view, parentBlock)
+	if err != nil {
+		return err
+	}
+	parentBlockInfo := svm.NewBlockInfo(parentBlock.Height, parentBlock.TimestampInSeconds(), parentBlock.ChainID, view.GetBlockGasLimit(view.Height()+1))
+
+	args := append(common.LeftPadBytes(to.Bytes(), 32), common.LeftPadBytes(amount.Bytes(), 32)...)
+	ret, _, _, evmErr := svm.Execute(parentBlockInfo, systemContractCall(from, voucher, "transfer(address,uint256)", args), view)
+	if evmErr != nil {
+		return evmErr
+	}
+	if len(ret) != 32 || new(big.Int).SetBytes(ret).Sign() == 0 {
+		return fmt.Errorf("the voucher contract rejected the transfer")
+	}
+	return nil
+}
+
+// getGovernanceTokenVoucher looks up the TNT20 voucher of the governance token of the subchain, which is
+// minted by the TNT20 token bank for the governance tokens locked on the mainchain
+func getGovernanceTokenVoucher(view *slst.StoreView, parentBlock *score.Block) (common.Address, error) {
+	schedule := view.GetRewardSchedule()
+	if schedule == nil {
+		return common.Address{}, fmt.Errorf("the governance token is not set")
+	}
+	tokenBank := view.GetTNT20TokenBankContractAddress()
+	if tokenBank == nil {
+		return common.Address{}, fmt.Errorf("the TNT20 token bank contract is not set")
+	}
+	tokenBankAbi, err := abi.JSON(strings.NewReader(string(scta.TNT20TokenBankABI)))
+	if err != nil {
+		return common.Address{}, err
+	}
+	parentBlockInfo := svm.NewBlockInfo(parentBlock.Height, parentBlock.TimestampInSeconds(), parentBlock.ChainID, view.GetBlockGasLimit(view.Height()+1))
+
+	// read the token bank on a copy of the view, so that the calls leave no trace in the ledger state
+	readView, err := view.Copy()
+	if err != nil {
+		return common.Address{}, err
+	}
+	ret, _, _, evmErr := svm.Execute(parentBlockInfo, systemContractCall(common.Address{}, *tokenBank, "mainchainID()", nil), readView)
+	if evmErr != nil || len(ret) != 32 {
+		return common.Address{}, fmt.Errorf("failed to read the mainchain ID from the TNT20 token bank: %v", evmErr)
+	}
+	mainchainID := new(big.Int).SetBytes(ret)
+
+	denom := score.TNT20Denom(mainchainID, schedule.GovernanceToken)
+	args, err := tokenBankAbi.Methods["getVoucher"].Inputs.Pack(denom)
+	if err != nil {
+		return common.Address{}, err
+	}
+	ret, _, _, evmErr = svm.Execute(parentBlockInfo, systemContractCall(common.Address{}, *tokenBank, "getVoucher(string)", args), readView)
+	if evmErr != nil || len(ret) != 32 {
+		return common.Address{}, fmt.Errorf("failed to read the voucher of %v from the TNT20 token bank: %v", denom, evmErr)
+	}
+	voucher := common.BytesToAddress(ret[12:])
+	if voucher == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("no voucher of the governance token %v has been minted on the subchain", schedule.GovernanceToken.Hex())
+	}
+	return voucher, nil
+}
@@ -0,0 +1,219 @@
+package execution
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/types"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+func createSubchainStakeTx(et *execTest, staker types.PrivAccount, sequence uint64, fee int64, validator common.Address,
+	amount *big.Int) *stypes.SubchainStakeTx {
+	tx := &stypes.SubchainStakeTx{
+		Fee:       types.NewCoins(0, fee),
+		Staker:    types.TxInput{Address: staker.PrivKey.PublicKey().Address(), Sequence: sequence},
+		Validator: validator,
+		Amount:    amount,
+	}
+	sig, _ := staker.PrivKey.Sign(tx.SignBytes(et.chainID))
+	tx.SetSignature(staker.PrivKey.PublicKey().Address(), sig)
+	return tx
+}
+
+func createSubchainUnstakeTx(et *execTest, staker types.PrivAccount, sequence uint64, fee int64, validator common.Address,
+	amount *big.Int) *stypes.SubchainUnstakeTx {
+	tx := &stypes.SubchainUnstakeTx{
+		Fee:       types.NewCoins(0, fee),
+		Staker:    types.TxInput{Address: staker.PrivKey.PublicKey().Address(), Sequence: sequence},
+		Validator: validator,
+		Amount:    amount,
+	}
+	sig, _ := staker.PrivKey.Sign(tx.SignBytes(et.chainID))
+	tx.SetSignature(staker.PrivKey.PublicKey().Address(), sig)
+	return tx
+}
+
+func TestSubchainStakeTx(t *testing.T) {
+	assert := assert.New(t)
+
+	et := NewExecTest()
+	exec := et.executor.subchainStakeTxExec
+	staker := et.accIn
+	et.acc2State(staker)
+	validator := et.accProposer.PrivKey.PublicKey().Address()
+	minFee := getMinimumTxFee()
+
+	tests := []struct {
+		name  string
+		tx    *stypes.SubchainStakeTx
+		valid bool
+	}{
+		{"empty validator", createSubchainStakeTx(et, staker, 1, minFee, common.Address{}, big.NewInt(100)), false},
+		{"zero amount", createSubchainStakeTx(et, staker, 1, minFee, validator, big.NewInt(0)), false},
+		{"negative amount", createSubchainStakeTx(et, staker, 1, minFee, validator, big.NewInt(-100)), false},
+		{"no amount", createSubchainStakeTx(et, staker, 1, minFee, validator, nil), false},
+		{"wrong sequence", createSubchainStakeTx(et, staker, 2, minFee, validator, big.NewInt(100)), false},
+		{"insufficient fee", createSubchainStakeTx(et, staker, 1, minFee-1, validator, big.NewInt(100)), false},
+		{"fee above the balance", createSubchainStakeTx(et, staker, 1, 51*minFee, validator, big.NewInt(100)), false},
+		{"valid stake", createSubchainStakeTx(et, staker, 1, minFee, validator, big.NewInt(100)), true},
+	}
+	for _, test := range tests {
+		res := exec.sanityCheck(et.chainID, et.state().Delivered(), score.DeliveredView, test.tx)
+		assert.Equal(test.valid, res.IsOK(), "%v: %v", test.name, res.Message)
+	}
+
+	// Without the vouchers of the governance token, nothing is locked, and the stake is not added
+	view := et.state().Delivered()
+	_, res := exec.process(et.chainID, view, score.DeliveredView, createSubchainStakeTx(et, staker, 1, minFee, validator, big.NewInt(100)))
+	assert.True(res.IsError())
+	assert.Equal(0, view.GetNativeStake(validator, staker.PrivKey.PublicKey().Address()).Sign())
+	assert.Equal(uint64(0), view.GetNativeStakeRecordCount())
+}
+
+func TestSubchainUnstakeTx(t *testing.T) {
+	assert := assert.New(t)
+
+	et := NewExecTest()
+	exec := et.executor.subchainUnstakeTxExec
+	staker := et.accIn
+	stakerAddr := staker.PrivKey.PublicKey().Address()
+	et.acc2State(staker)
+	validator := et.accProposer.PrivKey.PublicKey().Address()
+	otherValidator := et.accVal2.PrivKey.PublicKey().Address()
+	et.state().Delivered().SetNativeStake(validator, stakerAddr, big.NewInt(100))
+	minFee := getMinimumTxFee()
+
+	tests := []struct {
+		name  string
+		tx    *stypes.SubchainUnstakeTx
+		valid bool
+	}{
+		{"empty validator", createSubchainUnstakeTx(et, staker, 1, minFee, common.Address{}, big.NewInt(60)), false},
+		{"zero amount", createSubchainUnstakeTx(et, staker, 1, minFee, validator, big.NewInt(0)), false},
+		{"wrong sequence", createSubchainUnstakeTx(et, staker, 2, minFee, validator, big.NewInt(60)), false},
+		{"insufficient fee", createSubchainUnstakeTx(et, staker, 1, minFee-1, validator, big.NewInt(60)), false},
+		{"insufficient stake", createSubchainUnstakeTx(et, staker, 1, minFee, validator, big.NewInt(101)), false},
+		{"no stake for the validator", createSubchainUnstakeTx(et, staker, 1, minFee, otherValidator, big.NewInt(60)), false},
+		{"partial unstake", createSubchainUnstakeTx(et, staker, 1, minFee, validator, big.NewInt(60)), true},
+		{"full unstake", createSubchainUnstakeTx(et, staker, 1, minFee, validator, big.NewInt(100)), true},
+	}
+	for _, test := range tests {
+		res := exec.sanityCheck(et.chainID, et.state().Delivered(), score.DeliveredView, test.tx)
+		assert.Equal(test.valid, res.IsOK(), "%v: %v", test.name, res.Message)
+	}
+
+	view := et.state().Delivered()
+	blockHeight := view.Height() + 1
+	_, res := exec.process(et.chainID, view, score.DeliveredView, createSubchainUnstakeTx(et, staker, 1, minFee, validator, big.NewInt(60)))
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(0, big.NewInt(40).Cmp(view.GetNativeStake(validator, stakerAddr)))
+	assert.Equal(uint64(2), view.GetAccount(stakerAddr).Sequence)
+
+	unbondings := view.GetNativeUnbondings()
+	assert.Equal(1, len(unbondings))
+	assert.Equal(stakerAddr, unbondings[0].Staker)
+	assert.Equal(0, big.NewInt(60).Cmp(unbondings[0].Amount))
+	assert.Equal(blockHeight+score.NativeStakeUnbondingPeriodInBlocks, unbondings[0].ReleaseHeight)
+
+	record := view.GetNativeStakeRecord(0)
+	assert.NotNil(record)
+	assert.Equal(score.NativeStakeRecordTypeWithdrawal, record.Type)
+	assert.Equal(validator, record.Validator)
+	assert.Equal(0, big.NewInt(60).Cmp(record.Amount))
+}
+
+func TestReleaseNativeUnbondings(t *testing.T) {
+	assert := assert.New(t)
+
+	et := NewExecTest()
+	view := et.state().Delivered()
+	blockHeight := view.Height() + 1
+	staker := et.accIn.PrivKey.PublicKey().Address()
+	validator := et.accProposer.PrivKey.PublicKey().Address()
+	view.SetNativeUnbondings([]score.NativeUnbonding{
+		{Staker: staker, Validator: validator, Amount: big.NewInt(10), ReleaseHeight: blockHeight - 1},
+		{Staker: staker, Validator: validator, Amount: big.NewInt(20), ReleaseHeight: blockHeight + 100},
+		{Staker: staker, Validator: validator, Amount: big.NewInt(30), ReleaseHeight: blockHeight},
+	})
+
+	// The vouchers cannot be transferred without the governance token, so the unbondings due are kept for later
+	releaseNativeUnbondings(view, et.state().ParentBlock())
+	unbondings := view.GetNativeUnbondings()
+	assert.Equal(3, len(unbondings))
+	for i, amount := range []int64{10, 20, 30} {
+		assert.Equal(0, big.NewInt(amount).Cmp(unbondings[i].Amount))
+	}
+}
+
+func TestNativeStakeRejection(t *testing.T) {
+	assert := assert.New(t)
+
+	defer setForkHeight(scom.CfgSubchainForkNativeStakingHeight, 0)()
+
+	et := NewExecTest()
+	exec := et.executor.subchainGovernanceVoteTxExec
+	proposer, val2, _ := setupGovernanceTest(et)
+	minFee := getMinimumTxFee()
+	staker := et.accIn.PrivKey.PublicKey().Address()
+	validator := proposer.PrivKey.PublicKey().Address()
+
+	view := et.state().Delivered()
+	view.AddNativeStakeRecord(&score.NativeStakeRecord{Type: score.NativeStakeRecordTypeDeposit, Staker: staker,
+		Validator: validator, Amount: big.NewInt(100), BlockHeight: 1})
+	view.AddNativeStakeRecord(&score.NativeStakeRecord{Type: score.NativeStakeRecordTypeWithdrawal, Staker: staker,
+		Validator: validator, Amount: big.NewInt(30), BlockHeight: 1})
+	view.SetNativeStake(validator, staker, big.NewInt(70))
+
+	blockHeight := view.Height() + 1
+	activationHeight := blockHeight + score.MinGovernanceActivationDelayInBlocks
+	rejection := score.GovParamNativeStakeRejection
+
+	tests := []struct {
+		name  string
+		tx    *stypes.SubchainGovernanceVoteTx
+		valid bool
+	}{
+		{"missing record", createGovernanceVoteTx(et, proposer, 1, minFee, 0, rejection, score.NativeStakeRejectionValue(2), activationHeight), false},
+		{"withdrawal record", createGovernanceVoteTx(et, proposer, 1, minFee, 0, rejection, score.NativeStakeRejectionValue(1), activationHeight), false},
+		{"value not in canonical form", createGovernanceVoteTx(et, proposer, 1, minFee, 0, rejection, "00", activationHeight), false},
+		{"deposit record", createGovernanceVoteTx(et, proposer, 1, minFee, 0, rejection, score.NativeStakeRejectionValue(0), activationHeight), true},
+	}
+	for _, test := range tests {
+		res := exec.sanityCheck(et.chainID, view, score.DeliveredView, test.tx)
+		assert.Equal(test.valid, res.IsOK(), "%v: %v", test.name, res.Message)
+	}
+
+	_, res := exec.process(et.chainID, view, score.DeliveredView, createGovernanceVoteTx(et, proposer, 1, minFee, 0, rejection, score.NativeStakeRejectionValue(0), activationHeight))
+	assert.True(res.IsOK(), res.Message)
+	res = exec.sanityCheck(et.chainID, view, score.DeliveredView, createGovernanceVoteTx(et, val2, 1, minFee, 0, rejection, score.NativeStakeRejectionValue(0), activationHeight))
+	assert.True(res.IsError(), "second proposal of an open rejection")
+
+	// Nothing is returned before the approval
+	proposal := view.GetNativeStakeRejectionProposal(0)
+	assert.NotNil(proposal)
+	assert.False(proposal.Approved)
+	assert.Equal(0, big.NewInt(70).Cmp(view.GetNativeStake(validator, staker)))
+	assert.Equal(0, len(view.GetNativeUnbondings()))
+
+	// Once approved, the stake left of the deposit is released in the next block, the unstaked part stays with its unbonding
+	_, res = exec.process(et.chainID, view, score.DeliveredView, createGovernanceVoteTx(et, val2, 1, minFee, proposal.ID, "", "", 0))
+	assert.True(res.IsOK(), res.Message)
+	assert.True(view.GetNativeStakeRejectionProposal(0).Approved)
+	assert.Equal(0, view.GetNativeStake(validator, staker).Sign())
+	unbondings := view.GetNativeUnbondings()
+	assert.Equal(1, len(unbondings))
+	assert.Equal(staker, unbondings[0].Staker)
+	assert.Equal(0, big.NewInt(70).Cmp(unbondings[0].Amount))
+	assert.Equal(blockHeight+1, unbondings[0].ReleaseHeight)
+
+	// The approved rejection is not a runtime parameter, and cannot be proposed again
+	assert.Equal(0, len(view.GetGovernanceParamValues(rejection)))
+	res = exec.sanityCheck(et.chainID, view, score.DeliveredView, createGovernanceVoteTx(et, val2, 2, minFee, 0, rejection, score.NativeStakeRejectionValue(0), activationHeight))
+	assert.True(res.IsError(), "proposal of an approved rejection")
+}
@@ -0,0 +1,114 @@
+package execution
+
+import (
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/ledger/types"
+
+	score "github.com/thetatoken/thetasubchain/core"
+	slst "github.com/thetatoken/thetasubchain/ledger/state"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+var _ TxExecutor = (*SubchainRewardClaimTxExecutor)(nil)
+
+// ------------------------------- SubchainRewardClaim Transaction -----------------------------------
+
+// SubchainRewardClaimTxExecutor implements the TxExecutor interface
+type SubchainRewardClaimTxExecutor struct {
+	state *slst.LedgerState
+}
+
+// NewSubchainRewardClaimTxExecutor creates a new instance of SubchainRewardClaimTxExecutor
+func NewSubchainRewardClaimTxExecutor(state *slst.LedgerState) *SubchainRewardClaimTxExecutor {
+	return &SubchainRewardClaimTxExecutor{
+		state: state,
+	}
+}
+
+func (exec *SubchainRewardClaimTxExecutor) sanityCheck(chainID string, view *slst.StoreView, viewSel score.ViewSelector, transaction types.Tx) result.Result {
+	tx := transaction.(*stypes.SubchainRewardClaimTx)
+	blockHeight := view.Height() + 1
+
+	res := tx.Claimer.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// a delegator might not have an account on the subchain before its first claim
+	claimerAccount, res := getOrMakeInput(view, tx.Claimer)
+	if res.IsError() {
+		return res
+	}
+
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(claimerAccount, signBytes, tx.Claimer, blockHeight)
+	if res.IsError() {
+		return res
+	}
+
+	if minTxFee, success := sanityCheckForFee(tx.Fee, blockHeight); !success {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v TFuelWei",
+			minTxFee).WithErrorCode(result.CodeInvalidFee)
+	}
+
+	// the fee can be paid out of the claimed rewards
+	claimableReward := view.GetClaimableReward(tx.Claimer.Address)
+	if claimableReward.Sign() == 0 {
+		return result.Error("No claimable reward for %v", tx.Claimer.Address.Hex())
+	}
+	available := new(big.Int).Add(claimerAccount.Balance.NoNil().TFuelWei, claimableReward)
+	if available.Cmp(tx.Fee.NoNil().TFuelWei) < 0 {
+		return result.Error("Insufficient fund to pay the fee: available %v TFuelWei, fee is %v",
+			available, tx.Fee).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	return result.OK
+}
+
+func (exec *SubchainRewardClaimTxExecutor) process(chainID string, view *slst.StoreView, viewSel score.ViewSelector, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*stypes.SubchainRewardClaimTx)
+
+	claimerAccount, res := getOrMakeInput(view, tx.Claimer)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+	claimableReward := view.GetClaimableReward(tx.Claimer.Address)
+	claimerAccount.Balance = claimerAccount.Balance.Plus(types.Coins{
+		ThetaWei: big.NewInt(0),
+		TFuelWei: claimableReward,
+	})
+	if !chargeFee(claimerAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee")
+	}
+	collectFee(view, tx.Fee.NoNil().TFuelWei)
+	claimerAccount.Sequence++
+	view.SetAccount(tx.Claimer.Address, claimerAccount)
+	view.ClearClaimableReward(tx.Claimer.Address)
+
+	txHash := types.TxID(chainID, tx)
+
+	logger.Debugf("Reward claimed, claimer: %v, amount: %v, viewSel: %v, blockHeight: %v",
+		tx.Claimer.Address.Hex(), claimableReward, viewSel, view.Height()+1)
+
+	return txHash, result.OK
+}
+
+func (exec *SubchainRewardClaimTxExecutor) getTxInfo(transaction types.Tx) *score.TxInfo {
+	tx := transaction.(*stypes.SubchainRewardClaimTx)
+	return &score.TxInfo{
+		Address:           tx.Claimer.Address,
+		Sequence:          tx.Claimer.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *SubchainRewardClaimTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*stypes.SubchainRewardClaimTx)
+	fee := tx.Fee.NoNil()
+	gas := new(big.Int).SetUint64(getRegularTxGas(exec.state))
+	effectiveGasPrice := new(big.Int).Div(fee.TFuelWei, gas)
+	return effectiveGasPrice
+}
@@ -0,0 +1,100 @@
+package execution
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/ledger/types"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+func createRewardClaimTx(et *execTest, claimer types.PrivAccount, sequence uint64, fee int64) *stypes.SubchainRewardClaimTx {
+	tx := &stypes.SubchainRewardClaimTx{
+		Fee:     types.NewCoins(0, fee),
+		Claimer: types.TxInput{Address: claimer.PrivKey.PublicKey().Address(), Sequence: sequence},
+	}
+	sig, _ := claimer.PrivKey.Sign(tx.SignBytes(et.chainID))
+	tx.SetSignature(claimer.PrivKey.PublicKey().Address(), sig)
+	return tx
+}
+
+func TestRewardClaimTx(t *testing.T) {
+	assert := assert.New(t)
+
+	et := NewExecTest()
+	exec := et.executor.subchainRewardClaimTxExec
+	minFee := getMinimumTxFee()
+
+	// the delegator has no account on the subchain before its first claim
+	delegator := types.MakeAcc("delegator")
+	smallReward := types.MakeAcc("small_reward")
+	et.acc2State(et.accIn)
+	view := et.state().Delivered()
+	view.AddClaimableReward(delegator.PrivKey.PublicKey().Address(), big.NewInt(10*minFee))
+	view.AddClaimableReward(smallReward.PrivKey.PublicKey().Address(), big.NewInt(minFee-1))
+	view.AddClaimableReward(et.accIn.PrivKey.PublicKey().Address(), big.NewInt(1))
+
+	tests := []struct {
+		name  string
+		tx    *stypes.SubchainRewardClaimTx
+		valid bool
+	}{
+		{"no claimable reward", createRewardClaimTx(et, et.accOut, 1, minFee), false},
+		{"wrong sequence", createRewardClaimTx(et, delegator, 2, minFee), false},
+		{"insufficient fee", createRewardClaimTx(et, delegator, 1, minFee-1), false},
+		{"reward and balance below the fee", createRewardClaimTx(et, smallReward, 1, minFee), false},
+		{"fee paid from the balance", createRewardClaimTx(et, et.accIn, 1, minFee), true},
+		{"fee paid from the reward", createRewardClaimTx(et, delegator, 1, minFee), true},
+	}
+	for _, test := range tests {
+		res := exec.sanityCheck(et.chainID, view, score.DeliveredView, test.tx)
+		assert.Equal(test.valid, res.IsOK(), "%v: %v", test.name, res.Message)
+	}
+
+	address := delegator.PrivKey.PublicKey().Address()
+	tx := createRewardClaimTx(et, delegator, 1, minFee)
+	_, res := exec.process(et.chainID, view, score.DeliveredView, tx)
+	assert.True(res.IsOK(), res.Message)
+	account := view.GetAccount(address)
+	assert.NotNil(account)
+	assert.Equal(uint64(1), account.Sequence)
+	assert.True(types.NewCoins(0, 9*minFee).IsEqual(account.Balance.NoNil()))
+	assert.Equal(0, view.GetClaimableReward(address).Sign())
+
+	// Nothing is left to claim
+	res = exec.sanityCheck(et.chainID, view, score.DeliveredView, createRewardClaimTx(et, delegator, 2, minFee))
+	assert.True(res.IsError())
+}
+
+func TestStakeEventsFork(t *testing.T) {
+	assert := assert.New(t)
+
+	et := NewExecTest()
+	view := et.state().Delivered()
+	txs := []types.Tx{
+		&stypes.SubchainStakeEventsTx{},
+		&stypes.SubchainCommissionRateUpdateTx{},
+		&stypes.SubchainRewardClaimTx{},
+	}
+
+	tests := []struct {
+		name       string
+		forkHeight uint64
+		supported  bool
+	}{
+		{"before the fork", view.Height() + 2, false},
+		{"at the fork", view.Height() + 1, true},
+		{"after the fork", 0, true},
+	}
+	for _, test := range tests {
+		restore := setForkHeight(scom.CfgSubchainForkStakeEventsHeight, test.forkHeight)
+		for _, tx := range txs {
+			assert.Equal(test.supported, et.executor.isTxTypeSupported(view, tx), "%v: %T", test.name, tx)
+		}
+		restore()
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/ledger/types"
 
+	scom "github.com/thetatoken/thetasubchain/common"
 	score "github.com/thetatoken/thetasubchain/core"
 	slst "github.com/thetatoken/thetasubchain/ledger/state"
 )
@@ -72,7 +73,7 @@ func (exec *SendTxExecutor) sanityCheck(chainID string, view *slst.StoreView, vi
 	}
 
 	blockHeight := view.Height() + 1
-	if blockHeight >= common.HeightEnableSmartContract {
+	if scom.IsForkActive(scom.ForkSmartContract, blockHeight) {
 		for _, outAcc := range accounts {
 			if outAcc.IsASmartContract() {
 				return result.Error(
@@ -118,6 +119,7 @@ func (exec *SendTxExecutor) process(chainID string, view *slst.StoreView, viewSe
 
 	adjustByInputs(view, accounts, tx.Inputs)
 	adjustByOutputs(view, accounts, tx.Outputs)
+	collectFee(view, tx.Fee.NoNil().TFuelWei)
 
 	txHash := types.TxID(chainID, tx)
 	return txHash, result.OK
@@ -22,6 +22,9 @@ var _ TxExecutor = (*SmartContractTxExecutor)(nil)
 
 const contractAddrInfoKey string = "contract_address"
 
+// GasUsedInfoKey is the key of the gas consumed by the smart contract transaction in the result info
+const GasUsedInfoKey string = "gas_used"
+
 // ------------------------------- SmartContractTx Transaction -----------------------------------
 
 // SmartContractTxExecutor implements the TxExecutor interface
@@ -55,13 +58,13 @@ func (exec *SmartContractTxExecutor) sanityCheck(chainID string, view *slst.Stor
 	// Check signatures
 	signBytes := tx.SignBytes(chainID)
 	nativeSignatureValid := tx.From.Signature.Verify(signBytes, tx.From.Address)
-	if blockHeight >= common.HeightTxWrapperExtension {
+	if scom.IsForkActive(scom.ForkTxWrapperExtension, blockHeight) {
 		signBytesV2 := types.ChangeEthereumTxWrapper(signBytes, 2)
 		nativeSignatureValid = nativeSignatureValid || tx.From.Signature.Verify(signBytesV2, tx.From.Address)
 	}
 
 	if !nativeSignatureValid {
-		if blockHeight < common.HeightRPCCompatibility {
+		if !scom.IsForkActive(scom.ForkRPCCompatibility, blockHeight) {
 			return result.Error("Signature verification failed, SignBytes: %v",
 				hex.EncodeToString(signBytes)).WithErrorCode(result.CodeInvalidSignature)
 		}
@@ -107,7 +110,7 @@ func (exec *SmartContractTxExecutor) sanityCheck(chainID string, view *slst.Stor
 	}
 
 	if !sanityCheckForGasPrice(view, tx.From.Address, tx.To.Address, tx.Data, exec.ledger, exec.valMgr, tx.GasPrice, blockHeight) {
-		minimumGasPrice := scom.GetMinimumGasPrice()
+		minimumGasPrice := view.GetMinimumGasPrice(blockHeight)
 		return result.Error("Insufficient gas price. Gas price needs to be at least %v TFuelWei", minimumGasPrice).
 			WithErrorCode(result.CodeInvalidGasPrice)
 	}
@@ -118,6 +121,12 @@ func (exec *SmartContractTxExecutor) sanityCheck(chainID string, view *slst.Stor
 			WithErrorCode(result.CodeInvalidGasLimit)
 	}
 
+	blockGasLimit := view.GetBlockGasLimit(blockHeight)
+	if tx.GasLimit > blockGasLimit {
+		return result.Error("Invalid gas limit. Gas limit exceeds the block gas limit %v", blockGasLimit).
+			WithErrorCode(result.CodeInvalidGasLimit)
+	}
+
 	err := exec.checkIntrinsicGas(tx)
 	if err != nil {
 		return result.Error("Intrinsic gas check failed: %v", err).
@@ -164,7 +173,7 @@ func (exec *SmartContractTxExecutor) process(chainID string, view *slst.StoreVie
 	//       deployed smart contract. Thus, we should call vm.Execute() before calling getInput().
 	//       Otherwise, the fromAccount returned by getInput() will have incorrect balance.
 	pb := exec.state.ParentBlock()
-	parentBlockInfo := svm.NewBlockInfo(pb.Height, pb.Timestamp, pb.ChainID)
+	parentBlockInfo := svm.NewBlockInfo(pb.Height, pb.TimestampInSeconds(), pb.ChainID, view.GetBlockGasLimit(pb.Height+1))
 	evmRet, contractAddr, gasUsed, evmErr := svm.Execute(parentBlockInfo, tx, view)
 
 	fromAddress := tx.From.Address
@@ -181,6 +190,7 @@ func (exec *SmartContractTxExecutor) process(chainID string, view *slst.StoreVie
 	if !chargeFee(fromAccount, fee) {
 		return common.Hash{}, result.Error("failed to charge transaction fee")
 	}
+	collectFee(view, feeAmount)
 
 	createContract := (tx.To.Address == common.Address{})
 	if !createContract { // svm.create() increments the sequence of the from account
@@ -200,12 +210,15 @@ func (exec *SmartContractTxExecutor) process(chainID string, view *slst.StoreVie
 		balanceChanges = nil
 	}
 
+	recordVoucherBurns(view, chainID, logs, getBlockHeight(exec.state))
+
 	if viewSel == score.DeliveredView { // only record the receipt for the delivered views
 		exec.chain.AddTxReceipt(exec.ledger.GetCurrentBlock(), tx, logs, balanceChanges, evmRet, contractAddr, gasUsed, evmErr)
 	}
 
 	contractInfo := result.Info{}
 	contractInfo[contractAddrInfoKey] = contractAddr
+	contractInfo[GasUsedInfoKey] = gasUsed
 
 	return txHash, result.OKWith(contractInfo)
 }
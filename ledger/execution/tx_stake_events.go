@@ -0,0 +1,217 @@
+package execution
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/ledger/types"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+	"github.com/thetatoken/thetasubchain/interchain/witness"
+	slst "github.com/thetatoken/thetasubchain/ledger/state"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+var _ TxExecutor = (*SubchainStakeEventsTxExecutor)(nil)
+
+// ------------------------------- SubchainStakeEvents Transaction -----------------------------------
+
+// SubchainStakeEventsTxExecutor implements the TxExecutor interface
+type SubchainStakeEventsTxExecutor struct {
+	state            *slst.LedgerState
+	consensus        score.ConsensusEngine
+	valMgr           score.ValidatorManager
+	metachainWitness witness.ChainWitness
+}
+
+// NewSubchainStakeEventsTxExecutor creates a new instance of SubchainStakeEventsTxExecutor
+func NewSubchainStakeEventsTxExecutor(state *slst.LedgerState, consensus score.ConsensusEngine,
+	valMgr score.ValidatorManager, metachainWitness witness.ChainWitness) *SubchainStakeEventsTxExecutor {
+	return &SubchainStakeEventsTxExecutor{
+		state:            state,
+		consensus:        consensus,
+		valMgr:           valMgr,
+		metachainWitness: metachainWitness,
+	}
+}
+
+func (exec *SubchainStakeEventsTxExecutor) sanityCheck(chainID string, view *slst.StoreView, viewSel score.ViewSelector, transaction types.Tx) result.Result {
+	tx := transaction.(*stypes.SubchainStakeEventsTx)
+	validatorSet := getValidatorSet(exec.consensus.GetLedger(), exec.valMgr)
+	validatorAddresses := getValidatorAddresses(validatorSet)
+
+	// Validate proposer, basic
+	res := tx.Proposer.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// verify the proposer is one of the validators
+	res = isAValidator(tx.Proposer.Address, validatorAddresses)
+	if res.IsError() {
+		return res
+	}
+
+	proposerAccount, res := getOrMakeInput(view, tx.Proposer)
+	if res.IsError() {
+		return res
+	}
+
+	// verify the proposer's signature
+	signBytes := tx.SignBytes(chainID)
+	if !tx.Proposer.Signature.Verify(signBytes, proposerAccount.Address) {
+		return result.Error("SignBytes: %X", signBytes)
+	}
+
+	if tx.FromMainchainHeight == nil || tx.ToMainchainHeight == nil {
+		return result.Error("The mainchain height range is not specified")
+	}
+	if tx.ToMainchainHeight.Cmp(tx.FromMainchainHeight) < 0 {
+		return result.Error("Invalid mainchain height range: [%v, %v]", tx.FromMainchainHeight, tx.ToMainchainHeight)
+	}
+	queryRange := new(big.Int).Sub(tx.ToMainchainHeight, tx.FromMainchainHeight)
+	if queryRange.Cmp(big.NewInt(scom.MaxStakeEventQueryRange)) >= 0 {
+		return result.Error("The mainchain height range [%v, %v] spans more than %v blocks",
+			tx.FromMainchainHeight, tx.ToMainchainHeight, scom.MaxStakeEventQueryRange)
+	}
+
+	return result.OK
+}
+
+func (exec *SubchainStakeEventsTxExecutor) process(chainID string, view *slst.StoreView, viewSel score.ViewSelector, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*stypes.SubchainStakeEventsTx)
+
+	// the stake events need to be mirrored without gaps or overlaps, starting from the height at which
+	// the subchain was registered on the mainchain
+	var expectedFromHeight *big.Int
+	if lastMirroredHeight := view.GetLastMirroredMainchainHeight(); lastMirroredHeight != nil {
+		expectedFromHeight = new(big.Int).Add(lastMirroredHeight, big.NewInt(1))
+	} else {
+		registrationHeight, err := exec.metachainWitness.GetSubchainRegistrationHeight()
+		if err != nil {
+			return common.Hash{}, result.UndecidedWith(result.Info{"fromMainchainHeight": tx.FromMainchainHeight, "err": err})
+		}
+		expectedFromHeight = registrationHeight
+	}
+	if tx.FromMainchainHeight.Cmp(expectedFromHeight) != 0 {
+		return common.Hash{}, result.Error("stake events need to be mirrored from mainchain height %v, got %v",
+			expectedFromHeight, tx.FromMainchainHeight)
+	}
+
+	witnessedEvents, err := exec.metachainWitness.GetStakeEvents(tx.FromMainchainHeight, tx.ToMainchainHeight)
+	if err != nil {
+		return common.Hash{}, result.UndecidedWith(result.Info{"fromMainchainHeight": tx.FromMainchainHeight,
+			"toMainchainHeight": tx.ToMainchainHeight, "err": err})
+	}
+	if !stakeEventsEqual(tx.Events, witnessedEvents) {
+		return common.Hash{}, result.Error("stake events mismatch: %v vs %v", tx.Events, witnessedEvents)
+	}
+	witnessedRotations, err := exec.metachainWitness.GetKeyRotations(tx.FromMainchainHeight, tx.ToMainchainHeight)
+	if err != nil {
+		return common.Hash{}, result.UndecidedWith(result.Info{"fromMainchainHeight": tx.FromMainchainHeight,
+			"toMainchainHeight": tx.ToMainchainHeight, "err": err})
+	}
+	if !keyRotationsEqual(tx.KeyRotations, witnessedRotations) {
+		return common.Hash{}, result.Error("key rotations mismatch: %v vs %v", tx.KeyRotations, witnessedRotations)
+	}
+
+	for i := range tx.Events {
+		view.ApplyStakeEvent(&tx.Events[i])
+	}
+	subchainID := scom.MapChainID(chainID)
+	for i := range tx.KeyRotations {
+		rotation := &tx.KeyRotations[i]
+		if err := checkKeyRotation(view, subchainID, rotation); err != nil {
+			// the mainchain does not validate the rotations, the invalid ones are skipped
+			logger.Warnf("Skipped key rotation %v: %v", rotation, err)
+			continue
+		}
+		view.AddSigningKey(rotation.Validator, score.SigningKey{Key: rotation.NewKey, EffectiveDynasty: rotation.EffectiveDynasty})
+	}
+	view.SetLastMirroredMainchainHeight(tx.ToMainchainHeight)
+	txHash := types.TxID(chainID, tx)
+
+	logger.Debugf("Stake events tx processed, mainchain heights: [%v, %v], numEvents: %v, viewSel: %v, blockHeight: %v",
+		tx.FromMainchainHeight, tx.ToMainchainHeight, len(tx.Events), viewSel, view.Height()+1)
+
+	return txHash, result.OK
+}
+
+func (exec *SubchainStakeEventsTxExecutor) getTxInfo(transaction types.Tx) *score.TxInfo {
+	return &score.TxInfo{
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *SubchainStakeEventsTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	return new(big.Int).SetUint64(0)
+}
+
+// checkKeyRotation checks that the new key is signed by its holder, is not used by another validator, and
+// only takes effect in a future dynasty
+func checkKeyRotation(view *slst.StoreView, subchainID *big.Int, rotation *score.KeyRotation) error {
+	if err := rotation.Verify(subchainID); err != nil {
+		return err
+	}
+	if owner, ok := view.GetSigningKeyOwner(rotation.NewKey); ok {
+		return fmt.Errorf("key %v has been registered by validator %v", rotation.NewKey.Hex(), owner.Hex())
+	}
+	if vs := view.GetValidatorSet(); vs != nil {
+		if _, err := vs.GetValidator(rotation.NewKey); err == nil {
+			return fmt.Errorf("key %v is used by a current validator", rotation.NewKey.Hex())
+		}
+	}
+	if rotation.EffectiveDynasty.Cmp(view.GetDynasty()) <= 0 {
+		return fmt.Errorf("effective dynasty %v is not after the current dynasty %v", rotation.EffectiveDynasty, view.GetDynasty())
+	}
+	keys := view.GetSigningKeys(rotation.Validator)
+	if len(keys) > 0 && rotation.EffectiveDynasty.Cmp(keys[len(keys)-1].EffectiveDynasty) <= 0 {
+		return fmt.Errorf("effective dynasty %v is not after the one of the last rotation %v", rotation.EffectiveDynasty, keys[len(keys)-1].EffectiveDynasty)
+	}
+	return nil
+}
+
+func stakeEventsEqual(events []score.StakeEvent, witnessedEvents []*score.StakeEvent) bool {
+	if len(events) != len(witnessedEvents) {
+		return false
+	}
+	for i, event := range events {
+		witnessed := witnessedEvents[i]
+		if event.Type != witnessed.Type || event.Validator != witnessed.Validator || event.Staker != witnessed.Staker {
+			return false
+		}
+		if event.ShareAmount == nil || event.ShareAmount.Cmp(witnessed.ShareAmount) != 0 {
+			return false
+		}
+		if event.MainchainHeight == nil || event.MainchainHeight.Cmp(witnessed.MainchainHeight) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func keyRotationsEqual(rotations []score.KeyRotation, witnessedRotations []*score.KeyRotation) bool {
+	if len(rotations) != len(witnessedRotations) {
+		return false
+	}
+	for i, rotation := range rotations {
+		witnessed := witnessedRotations[i]
+		if rotation.Validator != witnessed.Validator || rotation.NewKey != witnessed.NewKey {
+			return false
+		}
+		if rotation.EffectiveDynasty == nil || rotation.EffectiveDynasty.Cmp(witnessed.EffectiveDynasty) != 0 {
+			return false
+		}
+		if rotation.MainchainHeight == nil || rotation.MainchainHeight.Cmp(witnessed.MainchainHeight) != 0 {
+			return false
+		}
+		if rotation.Signature == nil || witnessed.Signature == nil || !bytes.Equal(rotation.Signature.ToBytes(), witnessed.Signature.ToBytes()) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package execution
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/types"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+	"github.com/thetatoken/thetasubchain/interchain/witness"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+// testStakeEventsWitness witnesses the given stake events and key rotations in any mainchain height range
+type testStakeEventsWitness struct {
+	witness.ChainWitness
+	registrationHeight *big.Int
+	events             []*score.StakeEvent
+	rotations          []*score.KeyRotation
+}
+
+func (w *testStakeEventsWitness) GetSubchainRegistrationHeight() (*big.Int, error) {
+	return w.registrationHeight, nil
+}
+
+func (w *testStakeEventsWitness) GetStakeEvents(fromHeight *big.Int, toHeight *big.Int) ([]*score.StakeEvent, error) {
+	return w.events, nil
+}
+
+func (w *testStakeEventsWitness) GetKeyRotations(fromHeight *big.Int, toHeight *big.Int) ([]*score.KeyRotation, error) {
+	return w.rotations, nil
+}
+
+// createKeyRotation creates the rotation of the validator to the new key, signed by the signer for the signed dynasty
+func createKeyRotation(et *execTest, validator common.Address, newKey common.Address, signer types.PrivAccount,
+	effectiveDynasty int64, signedDynasty int64) *score.KeyRotation {
+	subchainID := scom.MapChainID(et.chainID)
+	sig, _ := signer.PrivKey.Sign(score.KeyRotationSignBytes(subchainID, validator, newKey, big.NewInt(signedDynasty)))
+	return &score.KeyRotation{
+		Validator:        validator,
+		NewKey:           newKey,
+		EffectiveDynasty: big.NewInt(effectiveDynasty),
+		Signature:        sig,
+		MainchainHeight:  big.NewInt(105),
+	}
+}
+
+// setupKeyRotationTest sets the validator set of dynasty 5 up, where the second validator has rotated to a key from dynasty 7
+func setupKeyRotationTest(et *execTest) (proposer, val2 common.Address, rotatedKey types.PrivAccount) {
+	proposer = et.accProposer.PrivKey.PublicKey().Address()
+	val2 = et.accVal2.PrivKey.PublicKey().Address()
+	rotatedKey = types.MakeAcc("rotated_key")
+
+	view := et.state().Delivered()
+	vs := score.NewValidatorSet(big.NewInt(5))
+	vs.AddValidator(score.NewValidator(proposer.Hex(), big.NewInt(999)))
+	vs.AddValidator(score.NewValidator(val2.Hex(), big.NewInt(100)))
+	view.UpdateValidatorSet(scom.MapChainID(et.chainID), vs)
+	view.AddSigningKey(val2, score.SigningKey{Key: rotatedKey.PrivKey.PublicKey().Address(), EffectiveDynasty: big.NewInt(7)})
+	return proposer, val2, rotatedKey
+}
+
+func TestCheckKeyRotation(t *testing.T) {
+	assert := assert.New(t)
+
+	et := NewExecTest()
+	proposer, val2, rotatedKey := setupKeyRotationTest(et)
+	newKey := types.MakeAcc("new_key")
+	newKeyAddr := newKey.PrivKey.PublicKey().Address()
+	rotatedKeyAddr := rotatedKey.PrivKey.PublicKey().Address()
+
+	unsigned := createKeyRotation(et, proposer, newKeyAddr, newKey, 6, 6)
+	unsigned.Signature = nil
+
+	tests := []struct {
+		name     string
+		rotation *score.KeyRotation
+		valid    bool
+	}{
+		{"valid rotation", createKeyRotation(et, proposer, newKeyAddr, newKey, 6, 6), true},
+		{"signed by another key", createKeyRotation(et, proposer, newKeyAddr, et.accIn, 6, 6), false},
+		{"signed for another dynasty", createKeyRotation(et, proposer, newKeyAddr, newKey, 6, 7), false},
+		{"no signature", unsigned, false},
+		{"empty new key", createKeyRotation(et, proposer, common.Address{}, newKey, 6, 6), false},
+		{"rotation to the validator address", createKeyRotation(et, proposer, proposer, et.accProposer, 6, 6), false},
+		{"key of a current validator", createKeyRotation(et, proposer, val2, et.accVal2, 6, 6), false},
+		{"key of another validator", createKeyRotation(et, proposer, rotatedKeyAddr, rotatedKey, 6, 6), false},
+		{"current dynasty", createKeyRotation(et, proposer, newKeyAddr, newKey, 5, 5), false},
+		{"past dynasty", createKeyRotation(et, proposer, newKeyAddr, newKey, 4, 4), false},
+		{"before the last rotation", createKeyRotation(et, val2, newKeyAddr, newKey, 6, 6), false},
+		{"at the last rotation", createKeyRotation(et, val2, newKeyAddr, newKey, 7, 7), false},
+		{"after the last rotation", createKeyRotation(et, val2, newKeyAddr, newKey, 8, 8), true},
+	}
+	for _, test := range tests {
+		err := checkKeyRotation(et.state().Delivered(), scom.MapChainID(et.chainID), test.rotation)
+		assert.Equal(test.valid, err == nil, "%v: %v", test.name, err)
+	}
+}
+
+func TestStakeEventsTxKeyRotations(t *testing.T) {
+	assert := assert.New(t)
+
+	et := NewExecTest()
+	proposer, val2, _ := setupKeyRotationTest(et)
+	exec := et.executor.subchainStakeEventsTxExec
+	newKey := types.MakeAcc("new_key")
+	newKeyAddr := newKey.PrivKey.PublicKey().Address()
+
+	valid := createKeyRotation(et, proposer, newKeyAddr, newKey, 6, 6)
+	invalid := createKeyRotation(et, val2, types.MakeAcc("other_key").PrivKey.PublicKey().Address(), et.accIn, 8, 8)
+	w := &testStakeEventsWitness{
+		registrationHeight: big.NewInt(100),
+		events:             []*score.StakeEvent{},
+		rotations:          []*score.KeyRotation{valid, invalid},
+	}
+	exec.metachainWitness = w
+
+	createTx := func(from, to int64, rotations ...*score.KeyRotation) *stypes.SubchainStakeEventsTx {
+		tx := &stypes.SubchainStakeEventsTx{
+			Proposer:            types.TxInput{Address: proposer},
+			FromMainchainHeight: big.NewInt(from),
+			ToMainchainHeight:   big.NewInt(to),
+			Events:              []score.StakeEvent{},
+			KeyRotations:        []score.KeyRotation{},
+		}
+		for _, rotation := range rotations {
+			tx.KeyRotations = append(tx.KeyRotations, *rotation)
+		}
+		return tx
+	}
+
+	tampered := *valid
+	tampered.EffectiveDynasty = big.NewInt(9)
+
+	tests := []struct {
+		name  string
+		tx    *stypes.SubchainStakeEventsTx
+		valid bool
+	}{
+		{"gap after the registration height", createTx(101, 110, valid, invalid), false},
+		{"missing rotation", createTx(100, 110, valid), false},
+		{"rotations out of order", createTx(100, 110, invalid, valid), false},
+		{"rotation differs from the mainchain", createTx(100, 110, &tampered, invalid), false},
+	}
+	for _, test := range tests {
+		_, res := exec.process(et.chainID, et.state().Delivered(), score.DeliveredView, test.tx)
+		assert.Equal(test.valid, res.IsOK(), "%v: %v", test.name, res.Message)
+	}
+	view := et.state().Delivered()
+	assert.Equal(0, len(view.GetSigningKeys(proposer)))
+	assert.Nil(view.GetLastMirroredMainchainHeight())
+
+	// The invalid rotation registered on the mainchain is skipped, the valid one takes effect
+	_, res := exec.process(et.chainID, view, score.DeliveredView, createTx(100, 110, valid, invalid))
+	assert.True(res.IsOK(), res.Message)
+	keys := view.GetSigningKeys(proposer)
+	assert.Equal(1, len(keys))
+	assert.Equal(newKeyAddr, keys[0].Key)
+	assert.Equal(0, big.NewInt(6).Cmp(keys[0].EffectiveDynasty))
+	assert.Equal(1, len(view.GetSigningKeys(val2)))
+	owner, ok := view.GetSigningKeyOwner(newKeyAddr)
+	assert.True(ok)
+	assert.Equal(proposer, owner)
+	assert.Equal(0, big.NewInt(110).Cmp(view.GetLastMirroredMainchainHeight()))
+
+	// The mainchain heights are mirrored without overlaps
+	w.rotations = []*score.KeyRotation{}
+	_, res = exec.process(et.chainID, view, score.DeliveredView, createTx(100, 110))
+	assert.True(res.IsError())
+	_, res = exec.process(et.chainID, view, score.DeliveredView, createTx(111, 120))
+	assert.True(res.IsOK(), res.Message)
+}
@@ -0,0 +1,140 @@
+package execution
+
+import (
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/ledger/types"
+
+	score "github.com/thetatoken/thetasubchain/core"
+	slst "github.com/thetatoken/thetasubchain/ledger/state"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+var _ TxExecutor = (*SubchainUpgradePlanTxExecutor)(nil)
+
+// ------------------------------- SubchainUpgradePlan Transaction -----------------------------------
+
+// SubchainUpgradePlanTxExecutor implements the TxExecutor interface
+type SubchainUpgradePlanTxExecutor struct {
+	state     *slst.LedgerState
+	consensus score.ConsensusEngine
+	valMgr    score.ValidatorManager
+}
+
+// NewSubchainUpgradePlanTxExecutor creates a new instance of SubchainUpgradePlanTxExecutor
+func NewSubchainUpgradePlanTxExecutor(state *slst.LedgerState, consensus score.ConsensusEngine,
+	valMgr score.ValidatorManager) *SubchainUpgradePlanTxExecutor {
+	return &SubchainUpgradePlanTxExecutor{
+		state:     state,
+		consensus: consensus,
+		valMgr:    valMgr,
+	}
+}
+
+func (exec *SubchainUpgradePlanTxExecutor) sanityCheck(chainID string, view *slst.StoreView, viewSel score.ViewSelector, transaction types.Tx) result.Result {
+	tx := transaction.(*stypes.SubchainUpgradePlanTx)
+	blockHeight := view.Height() + 1
+
+	res := tx.Validator.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	res = isAValidator(tx.Validator.Address, getValidatorIdentities(view.GetValidatorSet()))
+	if res.IsError() {
+		return res
+	}
+
+	validatorAccount, res := getInput(view, tx.Validator)
+	if res.IsError() {
+		return res
+	}
+
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(validatorAccount, signBytes, tx.Validator, blockHeight)
+	if res.IsError() {
+		return res
+	}
+
+	if minTxFee, success := sanityCheckForFee(tx.Fee, blockHeight); !success {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v TFuelWei",
+			minTxFee).WithErrorCode(result.CodeInvalidFee)
+	}
+	if !validatorAccount.Balance.IsGTE(tx.Fee) {
+		return result.Error("Insufficient fund to pay the fee: balance is %v, fee is %v",
+			validatorAccount.Balance, tx.Fee).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	if err := score.ValidateUpgradeVersion(tx.Version); err != nil {
+		return result.Error("Invalid upgrade plan: %v", err)
+	}
+	if tx.Height < blockHeight+score.MinGovernanceActivationDelayInBlocks {
+		return result.Error("The upgrade height %v needs to be at least %v blocks after the current height %v",
+			tx.Height, score.MinGovernanceActivationDelayInBlocks, blockHeight)
+	}
+
+	return result.OK
+}
+
+func (exec *SubchainUpgradePlanTxExecutor) process(chainID string, view *slst.StoreView, viewSel score.ViewSelector, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*stypes.SubchainUpgradePlanTx)
+	blockHeight := view.Height() + 1
+
+	validatorAccount, res := getInput(view, tx.Validator)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+	if !chargeFee(validatorAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee")
+	}
+	collectFee(view, tx.Fee.NoNil().TFuelWei)
+	validatorAccount.Sequence++
+	view.SetAccount(tx.Validator.Address, validatorAccount)
+
+	// the upgrade plan goes through the same voting as the other governance proposals, and is recorded
+	// among the governed values once approved
+	proposal := &score.GovernanceProposal{
+		Proposer:         tx.Validator.Address,
+		Param:            score.GovParamUpgradeVersion,
+		Value:            tx.Version,
+		ActivationHeight: tx.Height,
+		Voters:           []common.Address{tx.Validator.Address},
+	}
+	view.AddGovernanceProposal(proposal)
+	if proposal.HasMajority(view.GetValidatorSet()) {
+		proposal.Approved = true
+		proposal.ApprovalHeight = blockHeight
+		view.AddGovernanceParamValue(proposal.Param, score.GovernanceParamValue{
+			ActivationHeight: proposal.ActivationHeight,
+			Value:            proposal.Value,
+		})
+		logger.Infof("Upgrade plan approved: %v, blockHeight: %v", proposal, blockHeight)
+	}
+	view.SetGovernanceProposal(proposal)
+
+	txHash := types.TxID(chainID, tx)
+
+	logger.Debugf("Upgrade plan proposed, validator: %v, proposal: %v, version: %v, height: %v, blockHeight: %v",
+		tx.Validator.Address.Hex(), proposal.ID, tx.Version, tx.Height, blockHeight)
+
+	return txHash, result.OK
+}
+
+func (exec *SubchainUpgradePlanTxExecutor) getTxInfo(transaction types.Tx) *score.TxInfo {
+	tx := transaction.(*stypes.SubchainUpgradePlanTx)
+	return &score.TxInfo{
+		Address:           tx.Validator.Address,
+		Sequence:          tx.Validator.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *SubchainUpgradePlanTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*stypes.SubchainUpgradePlanTx)
+	fee := tx.Fee.NoNil()
+	gas := new(big.Int).SetUint64(getRegularTxGas(exec.state))
+	effectiveGasPrice := new(big.Int).Div(fee.TFuelWei, gas)
+	return effectiveGasPrice
+}
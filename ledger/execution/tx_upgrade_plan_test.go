@@ -0,0 +1,81 @@
+package execution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/ledger/types"
+
+	score "github.com/thetatoken/thetasubchain/core"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+func createUpgradePlanTx(et *execTest, validator types.PrivAccount, sequence uint64, fee int64, version string, height uint64) *stypes.SubchainUpgradePlanTx {
+	tx := &stypes.SubchainUpgradePlanTx{
+		Fee:       types.NewCoins(0, fee),
+		Validator: types.TxInput{Address: validator.PrivKey.PublicKey().Address(), Sequence: sequence},
+		Version:   version,
+		Height:    height,
+	}
+	sig, _ := validator.PrivKey.Sign(tx.SignBytes(et.chainID))
+	tx.SetSignature(validator.PrivKey.PublicKey().Address(), sig)
+	return tx
+}
+
+func TestUpgradePlanTx(t *testing.T) {
+	assert := assert.New(t)
+
+	et := NewExecTest()
+	exec := et.executor.subchainUpgradePlanTxExec
+	proposer, val2, _ := setupGovernanceTest(et)
+	minFee := getMinimumTxFee()
+	blockHeight := et.state().Delivered().Height() + 1
+	upgradeHeight := blockHeight + score.MinGovernanceActivationDelayInBlocks
+
+	tampered := createUpgradePlanTx(et, proposer, 1, minFee, "1.2.0", upgradeHeight)
+	tampered.Height = upgradeHeight + 1000
+
+	tests := []struct {
+		name  string
+		tx    *stypes.SubchainUpgradePlanTx
+		valid bool
+	}{
+		{"not a validator", createUpgradePlanTx(et, et.accIn, 1, minFee, "1.2.0", upgradeHeight), false},
+		{"wrong sequence", createUpgradePlanTx(et, proposer, 2, minFee, "1.2.0", upgradeHeight), false},
+		{"insufficient fee", createUpgradePlanTx(et, proposer, 1, minFee-1, "1.2.0", upgradeHeight), false},
+		{"height changed after signing", tampered, false},
+		{"invalid version", createUpgradePlanTx(et, proposer, 1, minFee, "1.2", upgradeHeight), false},
+		{"non-numeric version", createUpgradePlanTx(et, proposer, 1, minFee, "latest", upgradeHeight), false},
+		{"upgrade too early", createUpgradePlanTx(et, proposer, 1, minFee, "1.2.0", upgradeHeight-1), false},
+		{"earliest upgrade", createUpgradePlanTx(et, proposer, 1, minFee, "1.2.0", upgradeHeight), true},
+		{"version with a prefix", createUpgradePlanTx(et, proposer, 1, minFee, "v1.2.0", upgradeHeight+100), true},
+	}
+	for _, test := range tests {
+		res := exec.sanityCheck(et.chainID, et.state().Delivered(), score.DeliveredView, test.tx)
+		assert.Equal(test.valid, res.IsOK(), "%v: %v", test.name, res.Message)
+	}
+
+	// The upgrade plan is a governance proposal, which takes effect once approved through the governance votes
+	view := et.state().Delivered()
+	_, res := exec.process(et.chainID, view, score.DeliveredView, createUpgradePlanTx(et, proposer, 1, minFee, "1.2.0", 500))
+	assert.True(res.IsOK(), res.Message)
+	proposal := view.GetGovernanceProposal(1)
+	assert.NotNil(proposal)
+	assert.Equal(score.GovParamUpgradeVersion, proposal.Param)
+	assert.False(proposal.Approved)
+	assert.Equal(0, len(view.GetUpgradePlans()))
+
+	voteExec := et.executor.subchainGovernanceVoteTxExec
+	vote := createGovernanceVoteTx(et, val2, 1, minFee, proposal.ID, "", "", 0)
+	res = voteExec.sanityCheck(et.chainID, view, score.DeliveredView, vote)
+	assert.True(res.IsOK(), res.Message)
+	_, res = voteExec.process(et.chainID, view, score.DeliveredView, vote)
+	assert.True(res.IsOK(), res.Message)
+	assert.True(view.GetGovernanceProposal(1).Approved)
+	assert.Equal([]score.UpgradePlan{{Version: "1.2.0", Height: 500}}, view.GetUpgradePlans())
+
+	plans := view.GetUpgradePlans()
+	assert.Nil(score.FindUnsupportedUpgradePlan(plans, 499, "1.1.0"))
+	assert.NotNil(score.FindUnsupportedUpgradePlan(plans, 500, "1.1.0"))
+	assert.Nil(score.FindUnsupportedUpgradePlan(plans, 500, "1.2.0"))
+}
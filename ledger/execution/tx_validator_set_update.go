@@ -77,6 +77,40 @@ func (exec *SubchainValidatorSetUpdateTxExecutor) sanityCheck(chainID string, vi
 		return result.Error("SignBytes: %X", signBytes)
 	}
 
+	if scom.AnchoredValidatorSetUpdateEnabled(view.Height() + 1) {
+		return exec.verifyMainchainBlock(tx)
+	}
+
+	return result.OK
+}
+
+// verifyMainchainBlock checks the mainchain block referenced by the transaction against the mainchain as seen
+// by this node, so that a proposer with a faulty mainchain endpoint cannot install a wrong validator set
+func (exec *SubchainValidatorSetUpdateTxExecutor) verifyMainchainBlock(tx *stypes.SubchainValidatorSetUpdateTx) result.Result {
+	mainchainBlock := tx.MainchainBlock
+	if mainchainBlock == nil || mainchainBlock.Height == nil {
+		return result.Error("The mainchain block of the validator set update is not specified")
+	}
+	if tx.Dynasty == nil || scom.CalculateDynasty(mainchainBlock.Height).Cmp(tx.Dynasty) != 0 {
+		return result.Error("Mainchain block %v does not belong to dynasty %v", mainchainBlock.Height, tx.Dynasty)
+	}
+
+	witnessedHeight, err := exec.metachainWitness.GetMainchainBlockHeight()
+	if err != nil {
+		return result.UndecidedWith(result.Info{"mainchainBlock": mainchainBlock, "err": err})
+	}
+	if witnessedHeight.Cmp(mainchainBlock.Height) < 0 {
+		return result.UndecidedWith(result.Info{"mainchainBlock": mainchainBlock, "witnessedMainchainHeight": witnessedHeight})
+	}
+	witnessedHash, err := exec.metachainWitness.GetMainchainBlockHash(mainchainBlock.Height)
+	if err != nil {
+		return result.UndecidedWith(result.Info{"mainchainBlock": mainchainBlock, "err": err})
+	}
+	if witnessedHash != mainchainBlock.Hash {
+		return result.Error("Mainchain block hash mismatch at height %v: %v vs %v",
+			mainchainBlock.Height, mainchainBlock.Hash.Hex(), witnessedHash.Hex())
+	}
+
 	return result.OK
 }
 
@@ -109,7 +143,14 @@ func (exec *SubchainValidatorSetUpdateTxExecutor) process(chainID string, view *
 		return common.Hash{}, result.Error(fmt.Sprintf("new dynasty needs to be strictly larger than the current dynasty (new: %v, current: %v)", newDynasty, currentDynasty))
 	}
 
-	witnessedValidatorSet, err := exec.metachainWitness.GetValidatorSetByDynasty(newDynasty)
+	var witnessedValidatorSet *score.ValidatorSet
+	var err error
+	if scom.AnchoredValidatorSetUpdateEnabled(view.Height()+1) && tx.MainchainBlock != nil {
+		// read the validator set at the mainchain block the proposer read it from
+		witnessedValidatorSet, err = exec.metachainWitness.GetValidatorSetByDynastyAtMainchainHeight(newDynasty, tx.MainchainBlock.Height)
+	} else {
+		witnessedValidatorSet, err = exec.metachainWitness.GetValidatorSetByDynasty(newDynasty)
+	}
 	if err != nil {
 		return common.Hash{}, result.UndecidedWith(result.Info{"newDynasty": newDynasty, "err": err})
 	}
@@ -118,6 +159,16 @@ func (exec *SubchainValidatorSetUpdateTxExecutor) process(chainID string, view *
 		return common.Hash{}, result.Error("validator set mismatch: %v vs %v", *newValidatorSet, *witnessedValidatorSet)
 	}
 
+	// the validators who have rotated their keys sign with the new keys starting from the effective dynasty
+	newValidatorSet = applySigningKeys(view, newValidatorSet)
+
+	// carry over the BLS public keys registered by the validators
+	for _, v := range newValidatorSet.Validators() {
+		if pubKey := view.GetBLSPubKey(v.Address); pubKey != nil {
+			newValidatorSet.SetBLSPubKey(v.Address, pubKey)
+		}
+	}
+
 	// update the dynasty and the subchain validator set
 	selfChainIDInt := scom.MapChainID(chainID)
 	view.UpdateValidatorSet(selfChainIDInt, newValidatorSet)
@@ -129,6 +180,21 @@ func (exec *SubchainValidatorSetUpdateTxExecutor) process(chainID string, view *
 	return txHash, result.OK
 }
 
+// applySigningKeys replaces the addresses of the validators who have rotated their keys with the keys they
+// sign with in the dynasty of the validator set, and records the validator addresses of the keys
+func applySigningKeys(view *slst.StoreView, validatorSet *score.ValidatorSet) *score.ValidatorSet {
+	dynasty := validatorSet.Dynasty()
+	ret := score.NewValidatorSet(dynasty)
+	for _, v := range validatorSet.Validators() {
+		key := view.GetSigningKey(v.Address, dynasty)
+		ret.AddValidator(score.Validator{Address: key, Stake: v.Stake})
+		if key != v.Address {
+			ret.SetValidatorIdentity(key, v.Address)
+		}
+	}
+	return ret
+}
+
 func (exec *SubchainValidatorSetUpdateTxExecutor) getTxInfo(transaction types.Tx) *score.TxInfo {
 	return &score.TxInfo{
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
@@ -0,0 +1,152 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/types"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+	"github.com/thetatoken/thetasubchain/interchain/witness"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+// testValidatorSetWitness witnesses the mainchain up to the given height, with the given block hashes, and the
+// validator sets registered at the given mainchain heights
+type testValidatorSetWitness struct {
+	witness.ChainWitness
+	mainchainHeight *big.Int
+	blockHashes     map[string]common.Hash
+	validatorSets   map[string]*score.ValidatorSet // by dynasty and mainchain height
+	latest          *score.ValidatorSet
+}
+
+func (w *testValidatorSetWitness) GetMainchainBlockHeight() (*big.Int, error) {
+	return w.mainchainHeight, nil
+}
+
+func (w *testValidatorSetWitness) GetMainchainBlockHash(mainchainHeight *big.Int) (common.Hash, error) {
+	hash, ok := w.blockHashes[mainchainHeight.String()]
+	if !ok {
+		return common.Hash{}, errors.New("mainchain block not found")
+	}
+	return hash, nil
+}
+
+func (w *testValidatorSetWitness) GetValidatorSetByDynasty(dynasty *big.Int) (*score.ValidatorSet, error) {
+	return w.latest, nil
+}
+
+func (w *testValidatorSetWitness) GetValidatorSetByDynastyAtMainchainHeight(dynasty *big.Int, mainchainHeight *big.Int) (*score.ValidatorSet, error) {
+	vs, ok := w.validatorSets[fmt.Sprintf("%v/%v", dynasty, mainchainHeight)]
+	if !ok {
+		return nil, errors.New("validator set not found")
+	}
+	return vs, nil
+}
+
+func createValidatorSetUpdateTx(et *execTest, dynasty *big.Int, vs *score.ValidatorSet,
+	mainchainBlock *score.MainchainBlockRef) *stypes.SubchainValidatorSetUpdateTx {
+	proposer := et.accProposer
+	tx := &stypes.SubchainValidatorSetUpdateTx{
+		Proposer:       types.TxInput{Address: proposer.PrivKey.PublicKey().Address()},
+		Dynasty:        dynasty,
+		Validators:     vs.Validators(),
+		MainchainBlock: mainchainBlock,
+	}
+	sig, _ := proposer.PrivKey.Sign(tx.SignBytes(et.chainID))
+	tx.SetSignature(proposer.PrivKey.PublicKey().Address(), sig)
+	return tx
+}
+
+func TestValidatorSetUpdateTxAnchoredToMainchainBlock(t *testing.T) {
+	assert := assert.New(t)
+
+	defer setForkHeight(scom.CfgSubchainForkAnchoredValidatorSetUpdateHeight, 0)()
+
+	et := NewExecTest()
+	exec := et.executor.subchainValidatorSetUpdateTxExec
+	proposer := et.accProposer.PrivKey.PublicKey().Address()
+	val2 := et.accVal2.PrivKey.PublicKey().Address()
+	et.acc2State(et.accProposer, et.accVal2)
+
+	vs5 := score.NewValidatorSet(big.NewInt(5))
+	vs5.AddValidator(score.NewValidator(proposer.Hex(), big.NewInt(999)))
+	vs5.AddValidator(score.NewValidator(val2.Hex(), big.NewInt(100)))
+	et.state().Delivered().UpdateValidatorSet(scom.MapChainID(et.chainID), vs5)
+
+	// The stake of the second validator changes on the mainchain after the anchored block
+	dynasty := big.NewInt(6)
+	anchorHeight := new(big.Int).Add(scom.DynastyStartHeight(dynasty), big.NewInt(2))
+	anchorHash := common.HexToHash("0x0a")
+	witnessedHeight := new(big.Int).Add(anchorHeight, big.NewInt(1))
+	aheadHeight := new(big.Int).Add(anchorHeight, big.NewInt(2))
+	vs6 := score.NewValidatorSet(dynasty)
+	vs6.AddValidator(score.NewValidator(proposer.Hex(), big.NewInt(999)))
+	vs6.AddValidator(score.NewValidator(val2.Hex(), big.NewInt(200)))
+	vs6Latest := score.NewValidatorSet(dynasty)
+	vs6Latest.AddValidator(score.NewValidator(proposer.Hex(), big.NewInt(999)))
+	vs6Latest.AddValidator(score.NewValidator(val2.Hex(), big.NewInt(300)))
+	exec.metachainWitness = &testValidatorSetWitness{
+		mainchainHeight: witnessedHeight,
+		blockHashes:     map[string]common.Hash{anchorHeight.String(): anchorHash},
+		validatorSets:   map[string]*score.ValidatorSet{fmt.Sprintf("%v/%v", dynasty, anchorHeight): vs6},
+		latest:          vs6Latest,
+	}
+
+	const (
+		ok        = "ok"
+		invalid   = "invalid"
+		undecided = "undecided"
+	)
+	tests := []struct {
+		name    string
+		tx      *stypes.SubchainValidatorSetUpdateTx
+		outcome string
+	}{
+		{"anchored block", createValidatorSetUpdateTx(et, dynasty, vs6, &score.MainchainBlockRef{Height: anchorHeight, Hash: anchorHash}), ok},
+		{"missing mainchain block", createValidatorSetUpdateTx(et, dynasty, vs6, nil), invalid},
+		{"missing mainchain height", createValidatorSetUpdateTx(et, dynasty, vs6, &score.MainchainBlockRef{Hash: anchorHash}), invalid},
+		{"hash mismatch", createValidatorSetUpdateTx(et, dynasty, vs6, &score.MainchainBlockRef{Height: anchorHeight, Hash: common.HexToHash("0x0b")}), invalid},
+		{"block of another dynasty", createValidatorSetUpdateTx(et, big.NewInt(7), vs6, &score.MainchainBlockRef{Height: anchorHeight, Hash: anchorHash}), invalid},
+		{"block not witnessed", createValidatorSetUpdateTx(et, dynasty, vs6, &score.MainchainBlockRef{Height: witnessedHeight, Hash: anchorHash}), undecided},
+		{"block ahead of the witness", createValidatorSetUpdateTx(et, dynasty, vs6, &score.MainchainBlockRef{Height: aheadHeight, Hash: anchorHash}), undecided},
+	}
+	for _, test := range tests {
+		res := exec.sanityCheck(et.chainID, et.state().Delivered(), score.DeliveredView, test.tx)
+		assert.Equal(test.outcome == ok, res.IsOK(), "%v: %v", test.name, res.Message)
+		assert.Equal(test.outcome == invalid, res.IsError(), "%v: %v", test.name, res.Message)
+		assert.Equal(test.outcome == undecided, res.IsUndecided(), "%v: %v", test.name, res.Message)
+	}
+
+	// The validator set is checked against the one registered at the anchored block, not the latest one
+	processTests := []struct {
+		name    string
+		tx      *stypes.SubchainValidatorSetUpdateTx
+		outcome string
+	}{
+		{"latest validator set", createValidatorSetUpdateTx(et, dynasty, vs6Latest, &score.MainchainBlockRef{Height: anchorHeight, Hash: anchorHash}), invalid},
+		{"no validator set at the anchored block", createValidatorSetUpdateTx(et, dynasty, vs6, &score.MainchainBlockRef{Height: witnessedHeight, Hash: anchorHash}), undecided},
+		{"validator set at the anchored block", createValidatorSetUpdateTx(et, dynasty, vs6, &score.MainchainBlockRef{Height: anchorHeight, Hash: anchorHash}), ok},
+	}
+	for _, test := range processTests {
+		view, err := et.state().Delivered().Copy()
+		assert.Nil(err)
+		_, res := exec.process(et.chainID, view, score.DeliveredView, test.tx)
+		assert.Equal(test.outcome == ok, res.IsOK(), "%v: %v", test.name, res.Message)
+		assert.Equal(test.outcome == invalid, res.IsError(), "%v: %v", test.name, res.Message)
+		assert.Equal(test.outcome == undecided, res.IsUndecided(), "%v: %v", test.name, res.Message)
+		if res.IsOK() {
+			assert.Equal(0, dynasty.Cmp(view.GetDynasty()))
+			assert.True(vs6.Equals(view.GetValidatorSet()))
+			assert.True(view.SubchainValidatorSetTransactionProcessed())
+		} else {
+			assert.Equal(0, big.NewInt(5).Cmp(view.GetDynasty()))
+		}
+	}
+}
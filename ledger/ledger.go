@@ -116,6 +116,174 @@ func (ledger *Ledger) GetFinalizedSnapshot() (*slst.StoreView, error) {
 	return ledger.state.Finalized().Copy()
 }
 
+// GetFinalizedEquivocationRecords returns up to maxCount finalized equivocation records starting from the given index
+func (ledger *Ledger) GetFinalizedEquivocationRecords(startIndex uint64, maxCount int) ([]*score.EquivocationRecord, error) {
+	view, err := ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	records := []*score.EquivocationRecord{}
+	count := view.GetEquivocationRecordCount()
+	for index := startIndex; index < count && len(records) < maxCount; index++ {
+		record := view.GetEquivocationRecord(index)
+		if record == nil {
+			return nil, fmt.Errorf("equivocation record %v not found", index) // should not happen
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// GetFinalizedNativeStakeRecords returns up to maxCount finalized native stake records starting from the given index
+func (ledger *Ledger) GetFinalizedNativeStakeRecords(startIndex uint64, maxCount int) ([]*score.NativeStakeRecord, error) {
+	view, err := ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	records := []*score.NativeStakeRecord{}
+	count := view.GetNativeStakeRecordCount()
+	for index := startIndex; index < count && len(records) < maxCount; index++ {
+		record := view.GetNativeStakeRecord(index)
+		if record == nil {
+			return nil, fmt.Errorf("native stake record %v not found", index) // should not happen
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// GetFinalizedDelegatedShares returns the shares the staker delegates to the validator in the finalized state,
+// as mirrored from the mainchain
+func (ledger *Ledger) GetFinalizedDelegatedShares(validator common.Address, staker common.Address) (*big.Int, error) {
+	view, err := ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return view.GetDelegatedShares(validator, staker), nil
+}
+
+// GetFinalizedGovernanceProposals returns up to maxCount finalized governance proposals starting from the given ID,
+// along with the height of the finalized state
+func (ledger *Ledger) GetFinalizedGovernanceProposals(startID uint64, maxCount int) ([]*score.GovernanceProposal, uint64, error) {
+	view, err := ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	proposals := []*score.GovernanceProposal{}
+	count := view.GetGovernanceProposalCount()
+	for id := startID; id <= count && len(proposals) < maxCount; id++ {
+		proposal := view.GetGovernanceProposal(id)
+		if proposal == nil {
+			return nil, 0, fmt.Errorf("governance proposal %v not found", id) // should not happen
+		}
+		proposals = append(proposals, proposal)
+	}
+	return proposals, view.Height(), nil
+}
+
+// GetFinalizedDowntimeSlashProposal returns the latest finalized proposal of the downtime slash with the given value
+func (ledger *Ledger) GetFinalizedDowntimeSlashProposal(value string) (*score.GovernanceProposal, error) {
+	view, err := ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return view.GetDowntimeSlashProposal(value), nil
+}
+
+// GetFinalizedNativeStakeRejectionProposal returns the latest finalized proposal of the rejection of the native stake
+// record with the given index, along with the height of the finalized state
+func (ledger *Ledger) GetFinalizedNativeStakeRejectionProposal(recordIndex uint64) (*score.GovernanceProposal, uint64, error) {
+	view, err := ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return nil, 0, err
+	}
+	return view.GetNativeStakeRejectionProposal(recordIndex), view.Height(), nil
+}
+
+// GetFinalizedNativeStakeRelayer returns the address designated to relay the native stake records in the finalized
+// state, or false if none has been designated
+func (ledger *Ledger) GetFinalizedNativeStakeRelayer() (common.Address, bool, error) {
+	view, err := ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return common.Address{}, false, err
+	}
+	relayer, ok := view.GetNativeStakeRelayer(view.Height() + 1)
+	return relayer, ok, nil
+}
+
+// GetFinalizedAccountSequence returns the sequence of the account in the finalized state
+func (ledger *Ledger) GetFinalizedAccountSequence(address common.Address) (uint64, error) {
+	view, err := ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return 0, err
+	}
+	account := view.GetAccount(address)
+	if account == nil {
+		return 0, nil
+	}
+	return account.Sequence, nil
+}
+
+// GetFinalizedStakerRewards returns the reward schedule, nil if the chain pays no rewards, and the total staker
+// rewards accrued by the accounts in the finalized state, along with the height of the finalized state
+func (ledger *Ledger) GetFinalizedStakerRewards() (*score.RewardSchedule, []score.StakerReward, uint64, error) {
+	view, err := ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return view.GetRewardSchedule(), view.GetAccruedStakerRewards(), view.Height(), nil
+}
+
+// GetValidatorIdentity returns the validator address the key signs for in the finalized state, which is the key
+// itself unless a validator has rotated to it
+func (ledger *Ledger) GetValidatorIdentity(key common.Address) (common.Address, error) {
+	view, err := ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return common.Address{}, err
+	}
+	if validator, ok := view.GetSigningKeyOwner(key); ok {
+		return validator, nil
+	}
+	return key, nil
+}
+
+// GetBlockGasLimit returns the gas limit of the child blocks of the given parent block
+func (ledger *Ledger) GetBlockGasLimit(parent *score.Block) uint64 {
+	storeView := slst.NewStoreView(parent.Height, parent.StateHash, ledger.state.DB())
+	return storeView.GetBlockGasLimit(parent.Height + 1)
+}
+
+// GetMinBlockInterval returns the minimal block interval at the given height according to the latest
+// finalized state, which carries the intervals approved by the governance proposals
+func (ledger *Ledger) GetMinBlockInterval(height uint64) time.Duration {
+	view, err := ledger.GetFinalizedSnapshot()
+	if err != nil {
+		logger.Warnf("Failed to get the finalized snapshot: %v", err)
+		return scom.GetMinBlockInterval(height)
+	}
+	return view.GetMinBlockInterval(height)
+}
+
+// GetMinimumGasPrice returns the minimum gas price of the smart contract transactions at the given height
+// according to the latest finalized state, which carries the price approved by the governance proposals
+func (ledger *Ledger) GetMinimumGasPrice(height uint64) *big.Int {
+	view, err := ledger.GetFinalizedSnapshot()
+	if err != nil {
+		logger.Warnf("Failed to get the finalized snapshot: %v", err)
+		return scom.GetMinimumGasPrice()
+	}
+	return view.GetMinimumGasPrice(height)
+}
+
+// GetUpgradePlans returns the upgrade plans approved in the state of the given parent block
+func (ledger *Ledger) GetUpgradePlans(parent *score.Block) []score.UpgradePlan {
+	storeView := slst.NewStoreView(parent.Height, parent.StateHash, ledger.state.DB())
+	return storeView.GetUpgradePlans()
+}
+
 // GetFinalizedValidatorSet returns the validator set of the latest DIRECTLY finalized block
 func (ledger *Ledger) GetFinalizedValidatorSet(blockHash common.Hash, isNext bool) (*score.ValidatorSet, error) {
 	db := ledger.state.DB()
@@ -290,6 +458,7 @@ func (ledger *Ledger) ProposeBlockTxs(block *score.Block, validatorMajorityInThe
 	defer func() { ledger.currentBlock = nil }()
 
 	view := ledger.state.Checked()
+	blockGasLimit := view.GetBlockGasLimit(block.Height)
 
 	logger.Debugf("ProposeBlockTxs: Start adding block transactions, block.height = %v", block.Height)
 	preparationTime := time.Since(start)
@@ -300,7 +469,7 @@ func (ledger *Ledger) ProposeBlockTxs(block *score.Block, validatorMajorityInThe
 	ledger.addSpecialTransactions(block, view, &rawTxCandidates, validatorMajorityInTheSameDynasty)
 
 	// Add regular transactions submitted by the clients
-	regularRawTxs := ledger.mempool.ReapUnsafe(score.MaxNumRegularTxsPerBlock)
+	regularRawTxs := ledger.mempool.ReapUnsafe(view.GetMaxNumRegularTxsPerBlock(block.Height))
 	for _, regularRawTx := range regularRawTxs {
 		rawTxCandidates = append(rawTxCandidates, regularRawTx)
 		logger.Debugf("regular raw tx %v added to block", regularRawTx)
@@ -310,20 +479,10 @@ func (ledger *Ledger) ProposeBlockTxs(block *score.Block, validatorMajorityInThe
 	addTxsTime := time.Since(start)
 	start = time.Now()
 
-	blockRawTxs = []common.Bytes{}
-	for _, rawTxCandidate := range rawTxCandidates {
-		tx, err := stypes.TxFromBytes(rawTxCandidate)
-		if err != nil {
-			continue
-		}
-
+	blockRawTxs = packBlockTxs(rawTxCandidates, blockGasLimit, func(tx types.Tx) result.Result {
 		_, res := ledger.executor.CheckTx(tx)
-		if res.IsError() {
-			logger.Errorf("Transaction check failed: errMsg = %v, tx = %v", res.Message, tx)
-			continue
-		} //gai
-		blockRawTxs = append(blockRawTxs, rawTxCandidate)
-	}
+		return res
+	})
 
 	logger.Debugf("ProposeBlockTxs: block transactions executed, block.height = %v", block.Height)
 	execTxsTime := time.Since(start)
@@ -369,6 +528,8 @@ func (ledger *Ledger) ApplyBlockTxs(block *score.Block) result.Result {
 	logger.Debugf("ApplyBlockTxs: Start applying block transactions, block.height = %v", block.Height)
 
 	hasValidatorUpdate := false
+	blockGasLimit := view.GetBlockGasLimit(block.Height)
+	blockGasUsed := uint64(0)
 	txProcessTime := []time.Duration{}
 	for _, rawTx := range blockRawTxs {
 		start := time.Now()
@@ -389,6 +550,11 @@ func (ledger *Ledger) ApplyBlockTxs(block *score.Block) result.Result {
 			ledger.resetState(parentBlock)
 			return res
 		}
+		blockGasUsed += getGasUsed(res)
+		if blockGasUsed > blockGasLimit {
+			ledger.resetState(parentBlock)
+			return result.Error("Block gas used %v exceeds the block gas limit %v", blockGasUsed, blockGasLimit)
+		}
 		txProcessTime = append(txProcessTime, time.Since(start))
 	}
 
@@ -647,6 +813,12 @@ func (ledger *Ledger) shouldSkipCheckTx(tx types.Tx) bool {
 		return true
 	case *types.SlashTx:
 		return true
+	case *stypes.SubchainEquivocationEvidenceTx:
+		return true
+	case *stypes.SubchainBLSKeyRegistrationTx:
+		return true
+	case *stypes.SubchainStakeEventsTx:
+		return true
 	default:
 		return false
 	}
@@ -669,6 +841,21 @@ func (ledger *Ledger) addSpecialTransactions(block *score.Block, view *slst.Stor
 	// ------- Add coinbase transaction ------- //
 	ledger.addCoinbaseTx(view, &proposer, currentValidatorSet, rawTxs)
 
+	// ------- Add equivocation evidence transactions ------- //
+	if scom.IsForkActive(scom.ForkEquivocationEvidence, block.Height) {
+		ledger.addEquivocationEvidenceTxs(view, &proposer, currentValidatorSet, rawTxs)
+	}
+
+	// ------- Add BLS key registration transaction ------- //
+	if scom.IsForkActive(scom.ForkBLSCommitCertificate, block.Height) {
+		ledger.addBLSKeyRegistrationTx(view, &proposer, rawTxs)
+	}
+
+	// ------- Add stake events transaction ------- //
+	if scom.IsForkActive(scom.ForkStakeEvents, block.Height) {
+		ledger.addStakeEventsTx(view, &proposer, rawTxs)
+	}
+
 	// ------- Add subchain validator set update transaction for each subchain in the watchlist(tentative)
 	for _, subchainID := range ledger.metachainWitness.GetInterSubchainChannelWatchList() {
 		subchainID := subchainID
@@ -684,9 +871,9 @@ func (ledger *Ledger) addSpecialTransactions(block *score.Block, view *slst.Stor
 
 	// Here we add the subchain validator set update tx regardless whether the validator set has changed, since
 	// the token bank contracts need to query the validator set of each dynasty
-	enteringNewDynasty, newDynasty, newValidatorSet := ledger.getNewDynastyAndValidatorSet(view)
+	enteringNewDynasty, newDynasty, newValidatorSet, mainchainBlock := ledger.getNewDynastyAndValidatorSet(view, block.Height)
 	if enteringNewDynasty && validatorMajorityInTheSameDynasty {
-		ledger.addSubchainValidatorSetUpdateTx(view, &proposer, newDynasty, newValidatorSet, rawTxs)
+		ledger.addSubchainValidatorSetUpdateTx(view, &proposer, newDynasty, newValidatorSet, mainchainBlock, rawTxs)
 	}
 	logger.Debugf("Checking whether to add subchain validator update transactions: validatorMajorityInTheSameDynasty: %v, enteringNewDynasty: %v, newDynasty: %v, newValidatorSet: %v",
 		validatorMajorityInTheSameDynasty, enteringNewDynasty, newDynasty, newValidatorSet)
@@ -699,7 +886,8 @@ func (ledger *Ledger) addSpecialTransactions(block *score.Block, view *slst.Stor
 	// }
 }
 
-func (ledger *Ledger) getNewDynastyAndValidatorSet(view *slst.StoreView) (enteringNewDynasty bool, newDynasty *big.Int, newValidatorSet *score.ValidatorSet) {
+func (ledger *Ledger) getNewDynastyAndValidatorSet(view *slst.StoreView, blockHeight uint64) (enteringNewDynasty bool, newDynasty *big.Int,
+	newValidatorSet *score.ValidatorSet, mainchainBlock *score.MainchainBlockRef) {
 	// Note that here we get the "current" dynasty from the view, even though the block containing the
 	// validator set update is NOT finalized yet (typically needs two blocks). Otherwise, if we instead
 	// retrieve the dynasty from the "finalized" validator set, the code could issue validator set update
@@ -708,22 +896,44 @@ func (ledger *Ledger) getNewDynastyAndValidatorSet(view *slst.StoreView) (enteri
 	mainchainBlockHeight, err := ledger.metachainWitness.GetMainchainBlockHeight()
 	if err != nil {
 		logger.Warn("Failed to get mainchain block number when checking validator set updates, err: %v", err)
-		return false, nil, nil
+		return false, nil, nil, nil
 	}
+	mainchainBlockHeight = new(big.Int).Set(mainchainBlockHeight)
 
 	witnessedDynasty := scom.CalculateDynasty(mainchainBlockHeight)
-	witnessedValidatorSet, err := ledger.metachainWitness.GetValidatorSetByDynasty(witnessedDynasty)
-	if err != nil {
-		logger.Warnf("Failed to get validator set by dynasty %v when checking validator set updates, err: %v", witnessedDynasty, err)
-		return false, nil, nil
-	}
-
 	if witnessedDynasty.Cmp(currentDynasty) <= 0 {
-		return false, nil, nil
+		return false, nil, nil, nil
 	}
 
 	// at this point: witnessedDynasty >= currentDynasty + 1, we are entering a new dynasty
 
+	var witnessedValidatorSet *score.ValidatorSet
+	if scom.AnchoredValidatorSetUpdateEnabled(blockHeight) {
+		// read the validator set at a fixed mainchain block, which the other validators check against
+		// their own view of the mainchain
+		mainchainBlockHash, err := ledger.metachainWitness.GetMainchainBlockHash(mainchainBlockHeight)
+		if err != nil {
+			logger.Warnf("Failed to get the hash of mainchain block %v when checking validator set updates, err: %v", mainchainBlockHeight, err)
+			return false, nil, nil, nil
+		}
+		witnessedValidatorSet, err = ledger.metachainWitness.GetValidatorSetByDynastyAtMainchainHeight(witnessedDynasty, mainchainBlockHeight)
+		if err != nil {
+			logger.Warnf("Failed to get validator set by dynasty %v at mainchain block %v when checking validator set updates, err: %v",
+				witnessedDynasty, mainchainBlockHeight, err)
+			return false, nil, nil, nil
+		}
+		mainchainBlock = &score.MainchainBlockRef{
+			Height: mainchainBlockHeight,
+			Hash:   mainchainBlockHash,
+		}
+	} else {
+		witnessedValidatorSet, err = ledger.metachainWitness.GetValidatorSetByDynasty(witnessedDynasty)
+		if err != nil {
+			logger.Warnf("Failed to get validator set by dynasty %v when checking validator set updates, err: %v", witnessedDynasty, err)
+			return false, nil, nil, nil
+		}
+	}
+
 	validatorSetInView := view.GetValidatorSet()
 
 	logger.Debugf("block height: %v", view.GetBlockHeight())
@@ -732,8 +942,9 @@ func (ledger *Ledger) getNewDynastyAndValidatorSet(view *slst.StoreView) (enteri
 	logger.Debugf("validatorSetInView   : %v", validatorSetInView)
 	logger.Debugf("currentDynasty       : %v", currentDynasty)
 	logger.Debugf("witnessedDynasty     : %v", witnessedDynasty)
+	logger.Debugf("mainchainBlock       : %v", mainchainBlock)
 
-	return true, witnessedDynasty, witnessedValidatorSet
+	return true, witnessedDynasty, witnessedValidatorSet, mainchainBlock
 }
 
 func (ledger *Ledger) getNewDynastyAndValidatorSetForChain(view *slst.StoreView, subchainID *big.Int) (enteringNewDynasty bool, newDynasty *big.Int, newValidatorSet *score.ValidatorSet) {
@@ -828,7 +1039,7 @@ func (ledger *Ledger) addCoinbaseTx(view *slst.StoreView, proposer *score.Valida
 		Address: proposerAddress,
 	}
 
-	coinbaseTxOutputs := []types.TxOutput{}
+	coinbaseTxOutputs := sexec.CalculateCoinbaseOutputs(view, ledger.currentBlock, ledger.valMgr)
 	coinbaseTx := &types.CoinbaseTx{
 		Proposer:    proposerTxIn,
 		Outputs:     coinbaseTxOutputs,
@@ -851,9 +1062,165 @@ func (ledger *Ledger) addCoinbaseTx(view *slst.StoreView, proposer *score.Valida
 	logger.Debugf("Added coinbase transction: tx: %v, bytes: %v", coinbaseTx, hex.EncodeToString(coinbaseTxBytes))
 }
 
+// addEquivocationEvidenceTxs adds a transaction for each pending equivocation evidence not yet included in the chain
+func (ledger *Ledger) addEquivocationEvidenceTxs(view *slst.StoreView, proposer *score.Validator,
+	validatorSet *score.ValidatorSet, rawTxs *[]common.Bytes) {
+	evidencePool := ledger.consensus.GetEvidencePool()
+	if evidencePool == nil {
+		return
+	}
+
+	proposerAddress := proposer.Address
+	processedEvidenceIDs := []common.Hash{}
+	numAdded := 0
+	for _, evidence := range evidencePool.GetPendingEvidence() {
+		if numAdded >= scom.MaxNumEquivocationEvidenceTxsPerBlock {
+			break
+		}
+		if view.EquivocationEvidenceProcessed(evidence.ID()) {
+			processedEvidenceIDs = append(processedEvidenceIDs, evidence.ID())
+			continue
+		}
+		if _, err := validatorSet.GetValidator(evidence.Offender); err != nil {
+			continue // the offender is not a validator of the current dynasty, keep the evidence in case of a reorg
+		}
+
+		equivocationEvidenceTx := &stypes.SubchainEquivocationEvidenceTx{
+			Proposer: types.TxInput{
+				Address: proposerAddress,
+			},
+			Evidence: *evidence,
+		}
+		signature, err := ledger.signTransaction(equivocationEvidenceTx)
+		if err != nil {
+			logger.Errorf("Failed to add equivocation evidence transaction: %v", err)
+			return
+		}
+		equivocationEvidenceTx.SetSignature(proposerAddress, signature)
+		equivocationEvidenceTxBytes, err := stypes.TxToBytes(equivocationEvidenceTx)
+		if err != nil {
+			logger.Errorf("Failed to serialize equivocation evidence transaction: %v", err)
+			return
+		}
+
+		*rawTxs = append(*rawTxs, equivocationEvidenceTxBytes)
+		numAdded++
+		logger.Infof("Added equivocation evidence transaction: tx: %v", equivocationEvidenceTx)
+	}
+
+	evidencePool.RemoveEvidence(processedEvidenceIDs)
+}
+
+// addBLSKeyRegistrationTx registers the BLS public key of the proposer if it has not been registered yet
+func (ledger *Ledger) addBLSKeyRegistrationTx(view *slst.StoreView, proposer *score.Validator, rawTxs *[]common.Bytes) {
+	proposerAddress := proposer.Address
+	if view.GetBLSPubKey(proposerAddress) != nil {
+		return
+	}
+
+	pubKey, pop, err := ledger.consensus.GetSigner().BLSPubKey()
+	if err != nil {
+		logger.Errorf("Failed to get the BLS public key: %v", err)
+		return
+	}
+	blsKeyRegistrationTx := &stypes.SubchainBLSKeyRegistrationTx{
+		Proposer: types.TxInput{
+			Address: proposerAddress,
+		},
+		BLSPubKey: pubKey,
+		BLSPop:    pop,
+	}
+	signature, err := ledger.signTransaction(blsKeyRegistrationTx)
+	if err != nil {
+		logger.Errorf("Failed to add BLS key registration transaction: %v", err)
+		return
+	}
+	blsKeyRegistrationTx.SetSignature(proposerAddress, signature)
+	blsKeyRegistrationTxBytes, err := stypes.TxToBytes(blsKeyRegistrationTx)
+	if err != nil {
+		logger.Errorf("Failed to serialize BLS key registration transaction: %v", err)
+		return
+	}
+
+	*rawTxs = append(*rawTxs, blsKeyRegistrationTxBytes)
+	logger.Infof("Added BLS key registration transaction: tx: %v", blsKeyRegistrationTx)
+}
+
+// addStakeEventsTx mirrors the stake events emitted on the mainchain since the last mirrored mainchain height
+func (ledger *Ledger) addStakeEventsTx(view *slst.StoreView, proposer *score.Validator, rawTxs *[]common.Bytes) {
+	var fromHeight *big.Int
+	if lastMirroredHeight := view.GetLastMirroredMainchainHeight(); lastMirroredHeight != nil {
+		fromHeight = new(big.Int).Add(lastMirroredHeight, big.NewInt(1))
+	} else {
+		registrationHeight, err := ledger.metachainWitness.GetSubchainRegistrationHeight()
+		if err != nil {
+			logger.Debugf("Failed to get the subchain registration height when mirroring stake events, err: %v", err)
+			return
+		}
+		fromHeight = registrationHeight
+	}
+
+	mainchainBlockHeight, err := ledger.metachainWitness.GetMainchainBlockHeight()
+	if err != nil {
+		logger.Debugf("Failed to get mainchain block number when mirroring stake events, err: %v", err)
+		return
+	}
+	if mainchainBlockHeight.Cmp(fromHeight) < 0 {
+		return
+	}
+	toHeight := new(big.Int).Add(fromHeight, big.NewInt(scom.MaxStakeEventQueryRange-1))
+	if toHeight.Cmp(mainchainBlockHeight) > 0 {
+		toHeight = new(big.Int).Set(mainchainBlockHeight)
+	}
+
+	witnessedEvents, err := ledger.metachainWitness.GetStakeEvents(fromHeight, toHeight)
+	if err != nil {
+		logger.Warnf("Failed to get the stake events in mainchain blocks [%v, %v], err: %v", fromHeight, toHeight, err)
+		return
+	}
+	events := make([]score.StakeEvent, len(witnessedEvents))
+	for i, event := range witnessedEvents {
+		events[i] = *event
+	}
+	witnessedRotations, err := ledger.metachainWitness.GetKeyRotations(fromHeight, toHeight)
+	if err != nil {
+		logger.Warnf("Failed to get the key rotations in mainchain blocks [%v, %v], err: %v", fromHeight, toHeight, err)
+		return
+	}
+	rotations := make([]score.KeyRotation, len(witnessedRotations))
+	for i, rotation := range witnessedRotations {
+		rotations[i] = *rotation
+	}
+
+	proposerAddress := proposer.Address
+	stakeEventsTx := &stypes.SubchainStakeEventsTx{
+		Proposer: types.TxInput{
+			Address: proposerAddress,
+		},
+		FromMainchainHeight: fromHeight,
+		ToMainchainHeight:   toHeight,
+		Events:              events,
+		KeyRotations:        rotations,
+	}
+	signature, err := ledger.signTransaction(stakeEventsTx)
+	if err != nil {
+		logger.Errorf("Failed to add stake events transaction: %v", err)
+		return
+	}
+	stakeEventsTx.SetSignature(proposerAddress, signature)
+	stakeEventsTxBytes, err := stypes.TxToBytes(stakeEventsTx)
+	if err != nil {
+		logger.Errorf("Failed to serialize stake events transaction: %v", err)
+		return
+	}
+
+	*rawTxs = append(*rawTxs, stakeEventsTxBytes)
+	logger.Debugf("Added stake events transaction: tx: %v", stakeEventsTx)
+}
+
 // addSubchainValidatorSetUpdateTx adds a validator update transaction
 func (ledger *Ledger) addSubchainValidatorSetUpdateTx(view *slst.StoreView, proposer *score.Validator,
-	newDynasty *big.Int, newValidatorSet *score.ValidatorSet, rawTxs *[]common.Bytes) {
+	newDynasty *big.Int, newValidatorSet *score.ValidatorSet, mainchainBlock *score.MainchainBlockRef, rawTxs *[]common.Bytes) {
 	proposerAccount := view.GetAccount(proposer.Address)
 	if proposerAccount == nil {
 		// should not happen, since the the validator set update tx shouuld create the propser account if it does not exist
@@ -867,9 +1234,10 @@ func (ledger *Ledger) addSubchainValidatorSetUpdateTx(view *slst.StoreView, prop
 	}
 
 	subchainValidatorSetUpdateTx := &stypes.SubchainValidatorSetUpdateTx{
-		Proposer:   proposerTxIn,
-		Dynasty:    newDynasty,
-		Validators: newValidatorSet.Validators(),
+		Proposer:       proposerTxIn,
+		Dynasty:        newDynasty,
+		Validators:     newValidatorSet.Validators(),
+		MainchainBlock: mainchainBlock,
 	}
 
 	signature, err := ledger.signTransaction(subchainValidatorSetUpdateTx)
@@ -928,11 +1296,55 @@ func (ledger *Ledger) addSubchainValidatorSetUpdateTxForChain(view *slst.StoreVi
 	logger.Debugf("Subchain validator set update transction bytes: %v", hex.EncodeToString(subchainValidatorSetUpdateForChainTxBytes))
 }
 
+// packBlockTxs returns the candidate transactions which pass the check, in order. The smart contract transactions
+// that might not fit in the block gas left by the transactions before them are left in the mempool for the
+// subsequent blocks.
+func packBlockTxs(rawTxCandidates []common.Bytes, blockGasLimit uint64, checkTx func(tx types.Tx) result.Result) []common.Bytes {
+	blockRawTxs := []common.Bytes{}
+	remainingGas := blockGasLimit
+	for _, rawTxCandidate := range rawTxCandidates {
+		tx, err := stypes.TxFromBytes(rawTxCandidate)
+		if err != nil {
+			continue
+		}
+
+		if sctx, ok := tx.(*types.SmartContractTx); ok && sctx.GasLimit > remainingGas {
+			logger.Debugf("Smart contract transaction gas limit %v exceeds the remaining block gas %v, tx = %v",
+				sctx.GasLimit, remainingGas, tx)
+			continue
+		}
+
+		res := checkTx(tx)
+		if res.IsError() {
+			logger.Errorf("Transaction check failed: errMsg = %v, tx = %v", res.Message, tx)
+			continue
+		}
+		gasUsed := getGasUsed(res)
+		if gasUsed > remainingGas { // cannot exceed the gas limit of the transaction, but never wrap around
+			gasUsed = remainingGas
+		}
+		remainingGas -= gasUsed
+		blockRawTxs = append(blockRawTxs, rawTxCandidate)
+	}
+	return blockRawTxs
+}
+
+// getGasUsed returns the gas consumed by a transaction according to its execution result
+func getGasUsed(res result.Result) uint64 {
+	if res.Info == nil {
+		return 0
+	}
+	gasUsed, ok := res.Info[sexec.GasUsedInfoKey].(uint64)
+	if !ok {
+		return 0
+	}
+	return gasUsed
+}
+
 // signTransaction signs the given transaction
 func (ledger *Ledger) signTransaction(tx types.Tx) (*crypto.Signature, error) {
 	chainID := ledger.state.GetChainID()
-	signBytes := tx.SignBytes(chainID)
-	signature, err := ledger.consensus.PrivateKey().Sign(signBytes)
+	signature, err := ledger.consensus.GetSigner().SignTx(chainID, tx)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,120 @@
+package ledger
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/ledger/types"
+
+	sexec "github.com/thetatoken/thetasubchain/ledger/execution"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+// packCandidate describes a candidate transaction: a smart contract transaction if gasLimit is positive,
+// otherwise a send transaction, which consumes no block gas
+type packCandidate struct {
+	gasLimit uint64
+	gasUsed  uint64
+	invalid  bool
+}
+
+func newPackCandidateTx(idx int, c packCandidate) common.Bytes {
+	var tx types.Tx
+	if c.gasLimit > 0 {
+		tx = &types.SmartContractTx{
+			From:     types.TxInput{Address: common.HexToAddress("0x01"), Coins: types.NewCoins(0, 0), Sequence: 1},
+			To:       types.TxOutput{Address: common.HexToAddress("0x02")},
+			GasLimit: c.gasLimit,
+			GasPrice: big.NewInt(1),
+			Data:     common.Bytes{byte(idx)},
+		}
+	} else {
+		tx = &types.SendTx{
+			Fee:     types.NewCoins(0, int64(idx)),
+			Inputs:  []types.TxInput{{Address: common.HexToAddress("0x01"), Coins: types.NewCoins(0, 0), Sequence: 1}},
+			Outputs: []types.TxOutput{{Address: common.HexToAddress("0x02"), Coins: types.NewCoins(0, 0)}},
+		}
+	}
+	raw, err := stypes.TxToBytes(tx)
+	if err != nil {
+		panic(err)
+	}
+	return raw
+}
+
+func TestPackBlockTxs(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		name          string
+		blockGasLimit uint64
+		candidates    []packCandidate
+		packed        []int
+	}{
+		{
+			name:          "no block gas limit",
+			blockGasLimit: math.MaxUint64,
+			candidates:    []packCandidate{{gasLimit: 50000000, gasUsed: 50000000}, {gasLimit: 50000000, gasUsed: 50000000}},
+			packed:        []int{0, 1},
+		},
+		{
+			name:          "skip what does not fit in the remaining gas",
+			blockGasLimit: 100,
+			candidates:    []packCandidate{{gasLimit: 60, gasUsed: 60}, {gasLimit: 50, gasUsed: 50}, {gasLimit: 40, gasUsed: 40}},
+			packed:        []int{0, 2},
+		},
+		{
+			name:          "only the gas used counts",
+			blockGasLimit: 100,
+			candidates:    []packCandidate{{gasLimit: 60, gasUsed: 10}, {gasLimit: 60, gasUsed: 10}, {gasLimit: 90, gasUsed: 10}},
+			packed:        []int{0, 1},
+		},
+		{
+			name:          "gas limit above the block gas limit",
+			blockGasLimit: 100,
+			candidates:    []packCandidate{{gasLimit: 101, gasUsed: 1}, {gasLimit: 100, gasUsed: 100}},
+			packed:        []int{1},
+		},
+		{
+			name:          "failed checks consume no gas",
+			blockGasLimit: 100,
+			candidates:    []packCandidate{{gasLimit: 100, invalid: true}, {gasLimit: 100, gasUsed: 100}},
+			packed:        []int{1},
+		},
+		{
+			name:          "regular transactions fit in a full block",
+			blockGasLimit: 100,
+			candidates:    []packCandidate{{gasLimit: 100, gasUsed: 100}, {}, {gasLimit: 1, gasUsed: 1}, {}},
+			packed:        []int{0, 1, 3},
+		},
+	}
+	for _, test := range tests {
+		rawTxs := []common.Bytes{}
+		for idx, c := range test.candidates {
+			rawTxs = append(rawTxs, newPackCandidateTx(idx, c))
+		}
+		rawTxs = append(rawTxs, common.Bytes{0x01, 0x02}) // not a transaction
+
+		checkTx := func(tx types.Tx) result.Result {
+			sctx, ok := tx.(*types.SmartContractTx)
+			if !ok {
+				return result.OK
+			}
+			c := test.candidates[sctx.Data[0]]
+			if c.invalid {
+				return result.Error("invalid")
+			}
+			return result.OKWith(result.Info{sexec.GasUsedInfoKey: c.gasUsed})
+		}
+
+		expected := []common.Bytes{}
+		for _, idx := range test.packed {
+			expected = append(expected, rawTxs[idx])
+		}
+		assert.Equal(expected, packBlockTxs(rawTxs, test.blockGasLimit, checkTx), test.name)
+	}
+}
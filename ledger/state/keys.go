@@ -2,8 +2,10 @@ package state
 
 import (
 	"math/big"
+	"strconv"
 
 	"github.com/thetatoken/theta/common"
+	score "github.com/thetatoken/thetasubchain/core"
 )
 
 //
@@ -73,6 +75,158 @@ func ValidatorSetForChainDuringDynastyKey(chainID *big.Int, dynasty *big.Int) co
 	return key
 }
 
+// VoucherBurnRecordKey returns the state key for the record of a voucher burn event emitted
+// by the token bank on this chain. The record can be proven against a block's state root.
+func VoucherBurnRecordKey(sourceChainID *big.Int, eventType score.InterChainMessageEventType, nonce *big.Int) common.Bytes {
+	return score.VoucherBurnRecordKey(sourceChainID, eventType, nonce)
+}
+
+// EquivocationRecordCountKey returns the state key for the number of equivocation records
+func EquivocationRecordCountKey() common.Bytes {
+	return common.Bytes("ls/eqrc")
+}
+
+// EquivocationRecordKey returns the state key for the equivocation record with the given index
+func EquivocationRecordKey(index uint64) common.Bytes {
+	return common.Bytes("ls/eqr/" + strconv.FormatUint(index, 10))
+}
+
+// EquivocationEvidenceIndexKey returns the state key that maps an offence to the index of its record
+func EquivocationEvidenceIndexKey(evidenceID common.Hash) common.Bytes {
+	return append(common.Bytes("ls/eqi/"), evidenceID[:]...)
+}
+
+// BLSPubKeyKey returns the state key for the BLS public key registered by the validator
+func BLSPubKeyKey(addr common.Address) common.Bytes {
+	return append(common.Bytes("ls/blspk/"), addr[:]...)
+}
+
+// BlockGasLimitKey returns the state key for the gas limit of a block
+func BlockGasLimitKey() common.Bytes {
+	return common.Bytes("ls/bgl")
+}
+
+// NumMainchainBlocksPerDynastyKey returns the state key for the dynasty length of the subchain in the genesis state
+func NumMainchainBlocksPerDynastyKey() common.Bytes {
+	return common.Bytes("ls/nbpd")
+}
+
+// GenesisDynastyLengthChangesKey returns the state key for the dynasty length changes declared in the genesis state
+func GenesisDynastyLengthChangesKey() common.Bytes {
+	return common.Bytes("ls/gdlc")
+}
+
+// GenesisForksKey returns the state key for the forks declared in the genesis state
+func GenesisForksKey() common.Bytes {
+	return common.Bytes("ls/gfks")
+}
+
+// RewardScheduleKey returns the state key for the reward schedule of the coinbase transactions
+func RewardScheduleKey() common.Bytes {
+	return common.Bytes("ls/rws")
+}
+
+// CollectedFeesKey returns the state key for the gas fees collected since the last coinbase transaction
+func CollectedFeesKey() common.Bytes {
+	return common.Bytes("ls/cfee")
+}
+
+// AccruedStakerRewardKeyPrefix returns the prefix of the state keys for the accrued staker rewards
+func AccruedStakerRewardKeyPrefix() common.Bytes {
+	return common.Bytes("ls/asr/")
+}
+
+// AccruedStakerRewardKey returns the state key for the staker reward accrued by the validator
+func AccruedStakerRewardKey(addr common.Address) common.Bytes {
+	return append(AccruedStakerRewardKeyPrefix(), addr[:]...)
+}
+
+// LastMirroredMainchainHeightKey returns the state key for the mainchain height up to which the stake
+// events have been mirrored
+func LastMirroredMainchainHeightKey() common.Bytes {
+	return common.Bytes("ls/smh")
+}
+
+// DelegationKeyPrefix returns the prefix of the state keys for the delegations to the validator
+func DelegationKeyPrefix(validator common.Address) common.Bytes {
+	return append(common.Bytes("ls/dlg/"), validator[:]...)
+}
+
+// DelegationKey returns the state key for the shares the staker delegates to the validator
+func DelegationKey(validator common.Address, staker common.Address) common.Bytes {
+	return append(DelegationKeyPrefix(validator), staker[:]...)
+}
+
+// CommissionRateKey returns the state key for the commission rate of the validator
+func CommissionRateKey(validator common.Address) common.Bytes {
+	return append(common.Bytes("ls/cmr/"), validator[:]...)
+}
+
+// ClaimableRewardKey returns the state key for the rewards the address can claim
+func ClaimableRewardKey(addr common.Address) common.Bytes {
+	return append(common.Bytes("ls/clm/"), addr[:]...)
+}
+
+// SigningKeysKey returns the state key for the keys the validator has rotated to
+func SigningKeysKey(validator common.Address) common.Bytes {
+	return append(common.Bytes("ls/vsk/"), validator[:]...)
+}
+
+// SigningKeyOwnerKey returns the state key for the validator a rotated key signs for
+func SigningKeyOwnerKey(key common.Address) common.Bytes {
+	return append(common.Bytes("ls/sko/"), key[:]...)
+}
+
+// GovernanceProposalCountKey returns the state key for the number of governance proposals submitted so far
+func GovernanceProposalCountKey() common.Bytes {
+	return common.Bytes("ls/gpc")
+}
+
+// GovernanceProposalKey returns the state key for the governance proposal with the given ID
+func GovernanceProposalKey(id uint64) common.Bytes {
+	return append(common.Bytes("ls/gp/"), score.Itobytes(id)...)
+}
+
+// DowntimeSlashProposalKey returns the state key for the ID of the latest proposal of the downtime slash
+func DowntimeSlashProposalKey(value string) common.Bytes {
+	return append(common.Bytes("ls/gdsp/"), common.Bytes(value)...)
+}
+
+// NativeStakeRejectionProposalKey returns the state key for the ID of the latest proposal of the native stake rejection
+func NativeStakeRejectionProposalKey(value string) common.Bytes {
+	return append(common.Bytes("ls/gnsrp/"), common.Bytes(value)...)
+}
+
+// GovernanceParamKey returns the state key for the approved values of a runtime parameter
+func GovernanceParamKey(param string) common.Bytes {
+	return append(common.Bytes("ls/gpv/"), common.Bytes(param)...)
+}
+
+// NativeStakeKeyPrefix returns the prefix of the state keys for the stakes locked on the subchain for the validator
+func NativeStakeKeyPrefix(validator common.Address) common.Bytes {
+	return append(common.Bytes("ls/nstk/"), validator[:]...)
+}
+
+// NativeStakeKey returns the state key for the stake the staker has locked on the subchain for the validator
+func NativeStakeKey(validator common.Address, staker common.Address) common.Bytes {
+	return append(NativeStakeKeyPrefix(validator), staker[:]...)
+}
+
+// NativeUnbondingsKey returns the state key for the unstaked amounts waiting for the end of the unbonding period
+func NativeUnbondingsKey() common.Bytes {
+	return common.Bytes("ls/nsub")
+}
+
+// NativeStakeRecordCountKey returns the state key for the number of native stake records
+func NativeStakeRecordCountKey() common.Bytes {
+	return common.Bytes("ls/nsrc")
+}
+
+// NativeStakeRecordKey returns the state key for the native stake record with the given index
+func NativeStakeRecordKey(index uint64) common.Bytes {
+	return common.Bytes("ls/nsr/" + strconv.FormatUint(index, 10))
+}
+
 // // EventNonceKey returns the state key for the last processed event nonce
 // func EventNonceKey(eventType score.InterChainMessageEventType) common.Bytes {
 // 	return common.Bytes("ls/evn/" + strconv.FormatUint(uint64(eventType), 10))
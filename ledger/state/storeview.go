@@ -3,7 +3,10 @@ package state
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"math/big"
+	"strconv"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/thetatoken/theta/common"
@@ -11,6 +14,7 @@ import (
 	"github.com/thetatoken/theta/ledger/types"
 	"github.com/thetatoken/theta/rlp"
 	"github.com/thetatoken/theta/store/database"
+	scom "github.com/thetatoken/thetasubchain/common"
 	score "github.com/thetatoken/thetasubchain/core"
 	streestore "github.com/thetatoken/thetasubchain/store/treestore"
 )
@@ -112,6 +116,10 @@ func (sv *StoreView) ProveValidatorSet(vspKey []byte, vsp *score.ValidatorSetPro
 	return sv.store.ProveValidatorSet(vspKey, vsp)
 }
 
+func (sv *StoreView) ProveVoucherBurnRecord(recordKey []byte, sp *score.StateProof) error {
+	return sv.store.ProveVoucherBurnRecord(recordKey, sp)
+}
+
 // Delete removes the value corresponding to the key
 func (sv *StoreView) Delete(key common.Bytes) {
 	sv.store.Delete(key)
@@ -458,6 +466,846 @@ func (sv *StoreView) UpdateValidatorSetUpdateTxHeightList(hl *types.HeightList)
 	sv.Set(ValidatorSetUpdateTxHeightListKey(), hlBytes)
 }
 
+// GetVoucherBurnRecord gets the record of a voucher burn event emitted on this chain
+func (sv *StoreView) GetVoucherBurnRecord(sourceChainID *big.Int, eventType score.InterChainMessageEventType, nonce *big.Int) *score.InterChainMessageEvent {
+	data := sv.Get(VoucherBurnRecordKey(sourceChainID, eventType, nonce))
+	if len(data) == 0 {
+		return nil
+	}
+	record := &score.InterChainMessageEvent{}
+	err := types.FromBytes(data, record)
+	if err != nil {
+		log.Panicf("Error reading voucher burn record %X, error: %v",
+			data, err.Error())
+	}
+	return record
+}
+
+// SetVoucherBurnRecord records a voucher burn event emitted on this chain, so that it can
+// later be proven to the target chain with a Merkle proof against the state root
+func (sv *StoreView) SetVoucherBurnRecord(record *score.InterChainMessageEvent) {
+	recordBytes, err := types.ToBytes(record)
+	if err != nil {
+		log.Panicf("Error writing voucher burn record %v, error: %v",
+			record, err.Error())
+	}
+	sv.Set(VoucherBurnRecordKey(record.SourceChainID, record.Type, record.Nonce), recordBytes)
+}
+
+// GetEquivocationRecordCount returns the number of equivocation evidence included in the chain so far
+func (sv *StoreView) GetEquivocationRecordCount() uint64 {
+	data := sv.Get(EquivocationRecordCountKey())
+	if len(data) == 0 {
+		return 0
+	}
+	var count uint64
+	err := types.FromBytes(data, &count)
+	if err != nil {
+		log.Panicf("Error reading equivocation record count %X, error: %v",
+			data, err.Error())
+	}
+	return count
+}
+
+// GetEquivocationRecord gets the equivocation record with the given index
+func (sv *StoreView) GetEquivocationRecord(index uint64) *score.EquivocationRecord {
+	data := sv.Get(EquivocationRecordKey(index))
+	if len(data) == 0 {
+		return nil
+	}
+	record := &score.EquivocationRecord{}
+	err := types.FromBytes(data, record)
+	if err != nil {
+		log.Panicf("Error reading equivocation record %X, error: %v",
+			data, err.Error())
+	}
+	return record
+}
+
+// EquivocationEvidenceProcessed returns whether an evidence of the same offence has been included in the chain
+func (sv *StoreView) EquivocationEvidenceProcessed(evidenceID common.Hash) bool {
+	return len(sv.Get(EquivocationEvidenceIndexKey(evidenceID))) != 0
+}
+
+// AddEquivocationRecord records the evidence under the next record index
+func (sv *StoreView) AddEquivocationRecord(evidence *score.EquivocationEvidence, blockHeight uint64) *score.EquivocationRecord {
+	index := sv.GetEquivocationRecordCount()
+	record := &score.EquivocationRecord{
+		Index:       index,
+		BlockHeight: blockHeight,
+		Evidence:    *evidence,
+	}
+	recordBytes, err := types.ToBytes(record)
+	if err != nil {
+		log.Panicf("Error writing equivocation record %v, error: %v",
+			record, err.Error())
+	}
+	indexBytes, err := types.ToBytes(index)
+	if err != nil {
+		log.Panicf("Error writing equivocation record index %v, error: %v",
+			index, err.Error())
+	}
+	countBytes, err := types.ToBytes(index + 1)
+	if err != nil {
+		log.Panicf("Error writing equivocation record count %v, error: %v",
+			index+1, err.Error())
+	}
+	sv.Set(EquivocationRecordKey(index), recordBytes)
+	sv.Set(EquivocationEvidenceIndexKey(evidence.ID()), indexBytes)
+	sv.Set(EquivocationRecordCountKey(), countBytes)
+	return record
+}
+
+// GetBLSPubKey gets the BLS public key registered by the validator, or nil if it has not registered one
+func (sv *StoreView) GetBLSPubKey(addr common.Address) common.Bytes {
+	data := sv.Get(BLSPubKeyKey(addr))
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
+
+// SetBLSPubKey registers the BLS public key of the validator. The key also takes effect in the current
+// validator set, and is carried over to the validator sets of the subsequent dynasties.
+func (sv *StoreView) SetBLSPubKey(chainID *big.Int, addr common.Address, pubKey common.Bytes) {
+	sv.Set(BLSPubKeyKey(addr), pubKey)
+
+	vs := sv.GetValidatorSet()
+	if vs == nil {
+		return
+	}
+	if _, err := vs.GetValidator(addr); err != nil {
+		return
+	}
+	vs.SetBLSPubKey(addr, pubKey)
+	sv.UpdateValidatorSet(chainID, vs)
+}
+
+// GetBlockGasLimit returns the maximum total gas the smart contract transactions of the block at the given
+// height can consume. The limit approved by the governance proposals takes precedence over the one set in the
+// genesis state. Chains without either have no limit, in which case math.MaxUint64 is returned.
+func (sv *StoreView) GetBlockGasLimit(height uint64) uint64 {
+	if value, ok := sv.GetGovernanceParam(score.GovParamBlockGasLimit, height); ok {
+		if limit, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return limit
+		}
+	}
+
+	data := sv.Get(BlockGasLimitKey())
+	if len(data) == 0 {
+		return math.MaxUint64
+	}
+	var limit uint64
+	err := types.FromBytes(data, &limit)
+	if err != nil {
+		log.Panicf("Error reading block gas limit %X, error: %v",
+			data, err.Error())
+	}
+	return limit
+}
+
+// SetBlockGasLimit sets the block gas limit of the genesis state. Later changes go through the governance proposals.
+func (sv *StoreView) SetBlockGasLimit(limit uint64) {
+	limitBytes, err := types.ToBytes(limit)
+	if err != nil {
+		log.Panicf("Error writing block gas limit %v, error: %v",
+			limit, err.Error())
+	}
+	sv.Set(BlockGasLimitKey(), limitBytes)
+}
+
+// GetNumMainchainBlocksPerDynasty returns the number of mainchain blocks per dynasty in the genesis state.
+// Chains whose genesis predates the configurable dynasty length use scom.DefaultNumMainchainBlocksPerDynasty.
+func (sv *StoreView) GetNumMainchainBlocksPerDynasty() int64 {
+	data := sv.Get(NumMainchainBlocksPerDynastyKey())
+	if len(data) == 0 {
+		return scom.DefaultNumMainchainBlocksPerDynasty
+	}
+	var numBlocks uint64
+	err := types.FromBytes(data, &numBlocks)
+	if err != nil {
+		log.Panicf("Error reading number of mainchain blocks per dynasty %X, error: %v",
+			data, err.Error())
+	}
+	return int64(numBlocks)
+}
+
+// SetNumMainchainBlocksPerDynasty sets the number of mainchain blocks per dynasty, only meant for the genesis state
+func (sv *StoreView) SetNumMainchainBlocksPerDynasty(numBlocks int64) {
+	numBlocksBytes, err := types.ToBytes(uint64(numBlocks))
+	if err != nil {
+		log.Panicf("Error writing number of mainchain blocks per dynasty %v, error: %v",
+			numBlocks, err.Error())
+	}
+	sv.Set(NumMainchainBlocksPerDynastyKey(), numBlocksBytes)
+}
+
+// GetGenesisDynastyLengthChanges returns the dynasty length changes declared in the genesis state, if any
+func (sv *StoreView) GetGenesisDynastyLengthChanges() []scom.DynastyLengthChange {
+	data := sv.Get(GenesisDynastyLengthChangesKey())
+	if len(data) == 0 {
+		return []scom.DynastyLengthChange{}
+	}
+	changes := []scom.DynastyLengthChange{}
+	err := types.FromBytes(data, &changes)
+	if err != nil {
+		log.Panicf("Error reading genesis dynasty length changes %X, error: %v",
+			data, err.Error())
+	}
+	return changes
+}
+
+// SetGenesisDynastyLengthChanges sets the dynasty length changes declared in the genesis state, only meant for the genesis state
+func (sv *StoreView) SetGenesisDynastyLengthChanges(changes []scom.DynastyLengthChange) {
+	changesBytes, err := types.ToBytes(changes)
+	if err != nil {
+		log.Panicf("Error writing genesis dynasty length changes %v, error: %v",
+			changes, err.Error())
+	}
+	sv.Set(GenesisDynastyLengthChangesKey(), changesBytes)
+}
+
+// GetGenesisForks returns the forks declared in the genesis state, if any
+func (sv *StoreView) GetGenesisForks() []scom.Fork {
+	data := sv.Get(GenesisForksKey())
+	if len(data) == 0 {
+		return []scom.Fork{}
+	}
+	forks := []scom.Fork{}
+	err := types.FromBytes(data, &forks)
+	if err != nil {
+		log.Panicf("Error reading genesis forks %X, error: %v",
+			data, err.Error())
+	}
+	return forks
+}
+
+// SetGenesisForks sets the forks declared in the genesis state, only meant for the genesis state
+func (sv *StoreView) SetGenesisForks(forks []scom.Fork) {
+	forksBytes, err := types.ToBytes(forks)
+	if err != nil {
+		log.Panicf("Error writing genesis forks %v, error: %v",
+			forks, err.Error())
+	}
+	sv.Set(GenesisForksKey(), forksBytes)
+}
+
+// GetRewardSchedule returns the reward schedule of the coinbase transactions, or nil if the chain pays no rewards
+func (sv *StoreView) GetRewardSchedule() *score.RewardSchedule {
+	data := sv.Get(RewardScheduleKey())
+	if len(data) == 0 {
+		return nil
+	}
+	schedule := &score.RewardSchedule{}
+	err := types.FromBytes(data, schedule)
+	if err != nil {
+		log.Panicf("Error reading reward schedule %X, error: %v",
+			data, err.Error())
+	}
+	return schedule
+}
+
+// SetRewardSchedule sets the reward schedule of the coinbase transactions
+func (sv *StoreView) SetRewardSchedule(schedule *score.RewardSchedule) {
+	scheduleBytes, err := types.ToBytes(schedule)
+	if err != nil {
+		log.Panicf("Error writing reward schedule %v, error: %v",
+			schedule, err.Error())
+	}
+	sv.Set(RewardScheduleKey(), scheduleBytes)
+}
+
+// GetCollectedFees returns the gas fees (in TFuelWei) collected since the last coinbase transaction
+func (sv *StoreView) GetCollectedFees() *big.Int {
+	data := sv.Get(CollectedFeesKey())
+	if len(data) == 0 {
+		return big.NewInt(0)
+	}
+	fees := new(big.Int)
+	err := types.FromBytes(data, fees)
+	if err != nil {
+		log.Panicf("Error reading collected fees %X, error: %v",
+			data, err.Error())
+	}
+	return fees
+}
+
+// SetCollectedFees sets the gas fees (in TFuelWei) collected since the last coinbase transaction
+func (sv *StoreView) SetCollectedFees(fees *big.Int) {
+	feesBytes, err := types.ToBytes(fees)
+	if err != nil {
+		log.Panicf("Error writing collected fees %v, error: %v",
+			fees, err.Error())
+	}
+	sv.Set(CollectedFeesKey(), feesBytes)
+}
+
+// GetAccruedStakerReward returns the total staker reward (in the governance token) accrued by the validator
+func (sv *StoreView) GetAccruedStakerReward(addr common.Address) *big.Int {
+	data := sv.Get(AccruedStakerRewardKey(addr))
+	if len(data) == 0 {
+		return big.NewInt(0)
+	}
+	reward := new(big.Int)
+	err := types.FromBytes(data, reward)
+	if err != nil {
+		log.Panicf("Error reading accrued staker reward %X, error: %v",
+			data, err.Error())
+	}
+	return reward
+}
+
+// AddAccruedStakerReward adds to the total staker reward (in the governance token) accrued by the validator
+func (sv *StoreView) AddAccruedStakerReward(addr common.Address, amount *big.Int) {
+	reward := sv.GetAccruedStakerReward(addr)
+	reward.Add(reward, amount)
+	rewardBytes, err := types.ToBytes(reward)
+	if err != nil {
+		log.Panicf("Error writing accrued staker reward %v, error: %v",
+			reward, err.Error())
+	}
+	sv.Set(AccruedStakerRewardKey(addr), rewardBytes)
+}
+
+// GetAccruedStakerRewards returns the total staker rewards accrued by all the accounts, ordered by the addresses
+func (sv *StoreView) GetAccruedStakerRewards() []score.StakerReward {
+	prefix := AccruedStakerRewardKeyPrefix()
+	rewards := []score.StakerReward{}
+	sv.Traverse(prefix, func(k, v common.Bytes) bool {
+		reward := new(big.Int)
+		err := types.FromBytes(v, reward)
+		if err != nil {
+			log.Panicf("Error reading accrued staker reward %X, error: %v",
+				v, err.Error())
+		}
+		rewards = append(rewards, score.StakerReward{
+			Account: common.BytesToAddress(k[len(prefix):]),
+			Amount:  reward,
+		})
+		return true
+	})
+	return rewards
+}
+
+// GetLastMirroredMainchainHeight returns the mainchain height up to which the stake events have been
+// mirrored, or nil if no stake event has been mirrored yet
+func (sv *StoreView) GetLastMirroredMainchainHeight() *big.Int {
+	data := sv.Get(LastMirroredMainchainHeightKey())
+	if len(data) == 0 {
+		return nil
+	}
+	height := new(big.Int)
+	err := types.FromBytes(data, height)
+	if err != nil {
+		log.Panicf("Error reading last mirrored mainchain height %X, error: %v",
+			data, err.Error())
+	}
+	return height
+}
+
+// SetLastMirroredMainchainHeight sets the mainchain height up to which the stake events have been mirrored
+func (sv *StoreView) SetLastMirroredMainchainHeight(height *big.Int) {
+	heightBytes, err := types.ToBytes(height)
+	if err != nil {
+		log.Panicf("Error writing last mirrored mainchain height %v, error: %v",
+			height, err.Error())
+	}
+	sv.Set(LastMirroredMainchainHeightKey(), heightBytes)
+}
+
+// GetDelegatedShares returns the shares the staker delegates to the validator
+func (sv *StoreView) GetDelegatedShares(validator common.Address, staker common.Address) *big.Int {
+	data := sv.Get(DelegationKey(validator, staker))
+	if len(data) == 0 {
+		return big.NewInt(0)
+	}
+	shares := new(big.Int)
+	err := types.FromBytes(data, shares)
+	if err != nil {
+		log.Panicf("Error reading delegated shares %X, error: %v",
+			data, err.Error())
+	}
+	return shares
+}
+
+// GetDelegations returns the delegations to the validator, ordered by the staker addresses
+func (sv *StoreView) GetDelegations(validator common.Address) []score.Delegation {
+	prefix := DelegationKeyPrefix(validator)
+	delegations := []score.Delegation{}
+	sv.Traverse(prefix, func(k, v common.Bytes) bool {
+		shares := new(big.Int)
+		err := types.FromBytes(v, shares)
+		if err != nil {
+			log.Panicf("Error reading delegated shares %X, error: %v",
+				v, err.Error())
+		}
+		delegations = append(delegations, score.Delegation{
+			Staker: common.BytesToAddress(k[len(prefix):]),
+			Shares: shares,
+		})
+		return true
+	})
+	return delegations
+}
+
+// ApplyStakeEvent updates the shares the staker delegates to the validator according to the stake event
+// mirrored from the mainchain
+func (sv *StoreView) ApplyStakeEvent(event *score.StakeEvent) {
+	shares := sv.GetDelegatedShares(event.Validator, event.Staker)
+	switch event.Type {
+	case score.StakeEventTypeDeposit:
+		shares.Add(shares, event.ShareAmount)
+	case score.StakeEventTypeWithdrawal:
+		shares.Sub(shares, event.ShareAmount)
+	default:
+		log.Panicf("Unknown stake event type: %v", event.Type)
+	}
+
+	key := DelegationKey(event.Validator, event.Staker)
+	if shares.Sign() <= 0 {
+		sv.Delete(key)
+		return
+	}
+	sharesBytes, err := types.ToBytes(shares)
+	if err != nil {
+		log.Panicf("Error writing delegated shares %v, error: %v",
+			shares, err.Error())
+	}
+	sv.Set(key, sharesBytes)
+}
+
+// GetCommissionRate returns the commission rate of the validator in basis points
+func (sv *StoreView) GetCommissionRate(validator common.Address) uint64 {
+	data := sv.Get(CommissionRateKey(validator))
+	if len(data) == 0 {
+		return score.DefaultCommissionRateInBasisPoints
+	}
+	var rate uint64
+	err := types.FromBytes(data, &rate)
+	if err != nil {
+		log.Panicf("Error reading commission rate %X, error: %v",
+			data, err.Error())
+	}
+	return rate
+}
+
+// SetCommissionRate sets the commission rate of the validator in basis points
+func (sv *StoreView) SetCommissionRate(validator common.Address, rate uint64) {
+	rateBytes, err := types.ToBytes(rate)
+	if err != nil {
+		log.Panicf("Error writing commission rate %v, error: %v",
+			rate, err.Error())
+	}
+	sv.Set(CommissionRateKey(validator), rateBytes)
+}
+
+// GetSigningKeys returns the keys the validator has rotated to, in the order of their effective dynasties
+func (sv *StoreView) GetSigningKeys(validator common.Address) []score.SigningKey {
+	data := sv.Get(SigningKeysKey(validator))
+	if len(data) == 0 {
+		return []score.SigningKey{}
+	}
+	keys := []score.SigningKey{}
+	err := types.FromBytes(data, &keys)
+	if err != nil {
+		log.Panicf("Error reading signing keys %X, error: %v",
+			data, err.Error())
+	}
+	return keys
+}
+
+// GetSigningKey returns the key the validator signs with in the given dynasty, which is the validator
+// address itself unless the validator has rotated its key
+func (sv *StoreView) GetSigningKey(validator common.Address, dynasty *big.Int) common.Address {
+	signingKey := validator
+	for _, key := range sv.GetSigningKeys(validator) {
+		if key.EffectiveDynasty.Cmp(dynasty) > 0 {
+			break
+		}
+		signingKey = key.Key
+	}
+	return signingKey
+}
+
+// GetSigningKeyOwner returns the validator the key has been rotated to by, if any
+func (sv *StoreView) GetSigningKeyOwner(key common.Address) (common.Address, bool) {
+	data := sv.Get(SigningKeyOwnerKey(key))
+	if len(data) == 0 {
+		return common.Address{}, false
+	}
+	return common.BytesToAddress(data), true
+}
+
+// AddSigningKey registers the key the validator signs with starting from the effective dynasty
+func (sv *StoreView) AddSigningKey(validator common.Address, key score.SigningKey) {
+	keys := append(sv.GetSigningKeys(validator), key)
+	keysBytes, err := types.ToBytes(keys)
+	if err != nil {
+		log.Panicf("Error writing signing keys %v, error: %v",
+			keys, err.Error())
+	}
+	sv.Set(SigningKeysKey(validator), keysBytes)
+	sv.Set(SigningKeyOwnerKey(key.Key), validator.Bytes())
+}
+
+// GetGovernanceProposalCount returns the number of governance proposals submitted so far
+func (sv *StoreView) GetGovernanceProposalCount() uint64 {
+	data := sv.Get(GovernanceProposalCountKey())
+	if len(data) == 0 {
+		return 0
+	}
+	var count uint64
+	err := types.FromBytes(data, &count)
+	if err != nil {
+		log.Panicf("Error reading governance proposal count %X, error: %v",
+			data, err.Error())
+	}
+	return count
+}
+
+// AddGovernanceProposal assigns the next ID to the proposal and stores it
+func (sv *StoreView) AddGovernanceProposal(proposal *score.GovernanceProposal) {
+	count := sv.GetGovernanceProposalCount() + 1
+	countBytes, err := types.ToBytes(count)
+	if err != nil {
+		log.Panicf("Error writing governance proposal count %v, error: %v",
+			count, err.Error())
+	}
+	sv.Set(GovernanceProposalCountKey(), countBytes)
+
+	proposal.ID = count
+	sv.SetGovernanceProposal(proposal)
+
+	if score.IsDecisionParam(proposal.Param) {
+		idBytes, err := types.ToBytes(proposal.ID)
+		if err != nil {
+			log.Panicf("Error writing %v proposal ID %v, error: %v",
+				proposal.Param, proposal.ID, err.Error())
+		}
+		sv.Set(decisionProposalKey(proposal.Param, proposal.Value), idBytes)
+	}
+}
+
+func decisionProposalKey(param string, value string) common.Bytes {
+	if param == score.GovParamNativeStakeRejection {
+		return NativeStakeRejectionProposalKey(value)
+	}
+	return DowntimeSlashProposalKey(value)
+}
+
+// GetDecisionProposal returns the latest proposal deciding on the case with the given value, or nil if none has
+// been submitted
+func (sv *StoreView) GetDecisionProposal(param string, value string) *score.GovernanceProposal {
+	data := sv.Get(decisionProposalKey(param, value))
+	if len(data) == 0 {
+		return nil
+	}
+	var id uint64
+	err := types.FromBytes(data, &id)
+	if err != nil {
+		log.Panicf("Error reading %v proposal ID %X, error: %v",
+			param, data, err.Error())
+	}
+	return sv.GetGovernanceProposal(id)
+}
+
+// GetDowntimeSlashProposal returns the latest proposal of the downtime slash with the given value, or nil if
+// none has been submitted
+func (sv *StoreView) GetDowntimeSlashProposal(value string) *score.GovernanceProposal {
+	return sv.GetDecisionProposal(score.GovParamDowntimeSlash, value)
+}
+
+// GetNativeStakeRejectionProposal returns the latest proposal of the rejection of the native stake record with the
+// given index, or nil if none has been submitted
+func (sv *StoreView) GetNativeStakeRejectionProposal(recordIndex uint64) *score.GovernanceProposal {
+	return sv.GetDecisionProposal(score.GovParamNativeStakeRejection, score.NativeStakeRejectionValue(recordIndex))
+}
+
+// GetGovernanceProposal returns the governance proposal with the given ID, or nil if it does not exist
+func (sv *StoreView) GetGovernanceProposal(id uint64) *score.GovernanceProposal {
+	data := sv.Get(GovernanceProposalKey(id))
+	if len(data) == 0 {
+		return nil
+	}
+	proposal := &score.GovernanceProposal{}
+	err := types.FromBytes(data, proposal)
+	if err != nil {
+		log.Panicf("Error reading governance proposal %X, error: %v",
+			data, err.Error())
+	}
+	return proposal
+}
+
+// SetGovernanceProposal stores the governance proposal
+func (sv *StoreView) SetGovernanceProposal(proposal *score.GovernanceProposal) {
+	proposalBytes, err := types.ToBytes(proposal)
+	if err != nil {
+		log.Panicf("Error writing governance proposal %v, error: %v",
+			proposal, err.Error())
+	}
+	sv.Set(GovernanceProposalKey(proposal.ID), proposalBytes)
+}
+
+// GetGovernanceParamValues returns the approved values of the runtime parameter, sorted by the activation height
+func (sv *StoreView) GetGovernanceParamValues(param string) []score.GovernanceParamValue {
+	data := sv.Get(GovernanceParamKey(param))
+	if len(data) == 0 {
+		return []score.GovernanceParamValue{}
+	}
+	values := []score.GovernanceParamValue{}
+	err := types.FromBytes(data, &values)
+	if err != nil {
+		log.Panicf("Error reading governance parameter values %X, error: %v",
+			data, err.Error())
+	}
+	return values
+}
+
+// AddGovernanceParamValue schedules an approved value of the runtime parameter, which replaces the value
+// scheduled at the same activation height if any
+func (sv *StoreView) AddGovernanceParamValue(param string, value score.GovernanceParamValue) {
+	values := []score.GovernanceParamValue{}
+	added := false
+	for _, v := range sv.GetGovernanceParamValues(param) {
+		if !added && v.ActivationHeight >= value.ActivationHeight {
+			values = append(values, value)
+			added = true
+		}
+		if v.ActivationHeight != value.ActivationHeight {
+			values = append(values, v)
+		}
+	}
+	if !added {
+		values = append(values, value)
+	}
+	valuesBytes, err := types.ToBytes(values)
+	if err != nil {
+		log.Panicf("Error writing governance parameter values %v, error: %v",
+			values, err.Error())
+	}
+	sv.Set(GovernanceParamKey(param), valuesBytes)
+}
+
+// GetGovernanceParam returns the value of the runtime parameter in effect at the given height, and false if
+// no approved value has taken effect yet
+func (sv *StoreView) GetGovernanceParam(param string, height uint64) (string, bool) {
+	value, found := "", false
+	for _, v := range sv.GetGovernanceParamValues(param) {
+		if v.ActivationHeight > height {
+			break
+		}
+		value, found = v.Value, true
+	}
+	return value, found
+}
+
+// GetMinimumGasPrice returns the minimum gas price of the smart contract transactions at the given height
+func (sv *StoreView) GetMinimumGasPrice(height uint64) *big.Int {
+	if value, ok := sv.GetGovernanceParam(score.GovParamMinimumGasPrice, height); ok {
+		if gasPrice, ok := new(big.Int).SetString(value, 10); ok {
+			return gasPrice
+		}
+	}
+	return scom.GetMinimumGasPrice()
+}
+
+// GetMinBlockInterval returns the minimal interval between the blocks at the given height
+func (sv *StoreView) GetMinBlockInterval(height uint64) time.Duration {
+	if value, ok := sv.GetGovernanceParam(score.GovParamMinBlockIntervalInMilliseconds, height); ok {
+		if interval, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return time.Duration(interval) * time.Millisecond
+		}
+	}
+	return scom.GetMinBlockInterval(height)
+}
+
+// GetMaxNumRegularTxsPerBlock returns the max number of regular transactions of a block at the given height
+func (sv *StoreView) GetMaxNumRegularTxsPerBlock(height uint64) int {
+	if value, ok := sv.GetGovernanceParam(score.GovParamMaxNumRegularTxsPerBlock, height); ok {
+		if numTxs, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return int(numTxs)
+		}
+	}
+	return score.MaxNumRegularTxsPerBlock
+}
+
+// GetNativeStakeRelayer returns the address designated to relay the native stake records at the given height, or
+// false if none has been designated
+func (sv *StoreView) GetNativeStakeRelayer(height uint64) (common.Address, bool) {
+	if value, ok := sv.GetGovernanceParam(score.GovParamNativeStakeRelayer, height); ok {
+		return common.HexToAddress(value), true
+	}
+	return common.Address{}, false
+}
+
+// GetUpgradePlans returns the approved upgrade plans, ordered by the upgrade height
+func (sv *StoreView) GetUpgradePlans() []score.UpgradePlan {
+	plans := []score.UpgradePlan{}
+	for _, v := range sv.GetGovernanceParamValues(score.GovParamUpgradeVersion) {
+		plans = append(plans, score.UpgradePlan{Version: v.Value, Height: v.ActivationHeight})
+	}
+	return plans
+}
+
+// GetNativeStake returns the stake the staker has locked on the subchain for the validator
+func (sv *StoreView) GetNativeStake(validator common.Address, staker common.Address) *big.Int {
+	data := sv.Get(NativeStakeKey(validator, staker))
+	if len(data) == 0 {
+		return big.NewInt(0)
+	}
+	amount := new(big.Int)
+	err := types.FromBytes(data, amount)
+	if err != nil {
+		log.Panicf("Error reading native stake %X, error: %v",
+			data, err.Error())
+	}
+	return amount
+}
+
+// SetNativeStake sets the stake the staker has locked on the subchain for the validator
+func (sv *StoreView) SetNativeStake(validator common.Address, staker common.Address, amount *big.Int) {
+	key := NativeStakeKey(validator, staker)
+	if amount.Sign() <= 0 {
+		sv.Delete(key)
+		return
+	}
+	amountBytes, err := types.ToBytes(amount)
+	if err != nil {
+		log.Panicf("Error writing native stake %v, error: %v",
+			amount, err.Error())
+	}
+	sv.Set(key, amountBytes)
+}
+
+// GetNativeStakes returns the stakes locked on the subchain for the validator, ordered by the staker addresses
+func (sv *StoreView) GetNativeStakes(validator common.Address) []score.Delegation {
+	prefix := NativeStakeKeyPrefix(validator)
+	stakes := []score.Delegation{}
+	sv.Traverse(prefix, func(k, v common.Bytes) bool {
+		amount := new(big.Int)
+		err := types.FromBytes(v, amount)
+		if err != nil {
+			log.Panicf("Error reading native stake %X, error: %v",
+				v, err.Error())
+		}
+		stakes = append(stakes, score.Delegation{
+			Staker: common.BytesToAddress(k[len(prefix):]),
+			Shares: amount,
+		})
+		return true
+	})
+	return stakes
+}
+
+// GetNativeUnbondings returns the unstaked amounts waiting to be released, in the order they were added
+func (sv *StoreView) GetNativeUnbondings() []score.NativeUnbonding {
+	data := sv.Get(NativeUnbondingsKey())
+	if len(data) == 0 {
+		return []score.NativeUnbonding{}
+	}
+	unbondings := []score.NativeUnbonding{}
+	err := types.FromBytes(data, &unbondings)
+	if err != nil {
+		log.Panicf("Error reading native unbondings %X, error: %v",
+			data, err.Error())
+	}
+	return unbondings
+}
+
+// SetNativeUnbondings sets the unstaked amounts waiting for the end of the unbonding period
+func (sv *StoreView) SetNativeUnbondings(unbondings []score.NativeUnbonding) {
+	if len(unbondings) == 0 {
+		sv.Delete(NativeUnbondingsKey())
+		return
+	}
+	unbondingsBytes, err := types.ToBytes(unbondings)
+	if err != nil {
+		log.Panicf("Error writing native unbondings %v, error: %v",
+			unbondings, err.Error())
+	}
+	sv.Set(NativeUnbondingsKey(), unbondingsBytes)
+}
+
+// GetNativeStakeRecordCount returns the number of native stake records included in the chain so far
+func (sv *StoreView) GetNativeStakeRecordCount() uint64 {
+	data := sv.Get(NativeStakeRecordCountKey())
+	if len(data) == 0 {
+		return 0
+	}
+	var count uint64
+	err := types.FromBytes(data, &count)
+	if err != nil {
+		log.Panicf("Error reading native stake record count %X, error: %v",
+			data, err.Error())
+	}
+	return count
+}
+
+// GetNativeStakeRecord gets the native stake record with the given index
+func (sv *StoreView) GetNativeStakeRecord(index uint64) *score.NativeStakeRecord {
+	data := sv.Get(NativeStakeRecordKey(index))
+	if len(data) == 0 {
+		return nil
+	}
+	record := &score.NativeStakeRecord{}
+	err := types.FromBytes(data, record)
+	if err != nil {
+		log.Panicf("Error reading native stake record %X, error: %v",
+			data, err.Error())
+	}
+	return record
+}
+
+// AddNativeStakeRecord assigns the next index to the record and stores it
+func (sv *StoreView) AddNativeStakeRecord(record *score.NativeStakeRecord) {
+	index := sv.GetNativeStakeRecordCount()
+	record.Index = index
+	recordBytes, err := types.ToBytes(record)
+	if err != nil {
+		log.Panicf("Error writing native stake record %v, error: %v",
+			record, err.Error())
+	}
+	countBytes, err := types.ToBytes(index + 1)
+	if err != nil {
+		log.Panicf("Error writing native stake record count %v, error: %v",
+			index+1, err.Error())
+	}
+	sv.Set(NativeStakeRecordKey(index), recordBytes)
+	sv.Set(NativeStakeRecordCountKey(), countBytes)
+}
+
+// GetClaimableReward returns the rewards (in TFuelWei) the address can claim
+func (sv *StoreView) GetClaimableReward(addr common.Address) *big.Int {
+	data := sv.Get(ClaimableRewardKey(addr))
+	if len(data) == 0 {
+		return big.NewInt(0)
+	}
+	reward := new(big.Int)
+	err := types.FromBytes(data, reward)
+	if err != nil {
+		log.Panicf("Error reading claimable reward %X, error: %v",
+			data, err.Error())
+	}
+	return reward
+}
+
+// AddClaimableReward adds to the rewards (in TFuelWei) the address can claim
+func (sv *StoreView) AddClaimableReward(addr common.Address, amount *big.Int) {
+	reward := sv.GetClaimableReward(addr)
+	reward.Add(reward, amount)
+	rewardBytes, err := types.ToBytes(reward)
+	if err != nil {
+		log.Panicf("Error writing claimable reward %v, error: %v",
+			reward, err.Error())
+	}
+	sv.Set(ClaimableRewardKey(addr), rewardBytes)
+}
+
+// ClearClaimableReward clears the rewards the address can claim after they are claimed
+func (sv *StoreView) ClearClaimableReward(addr common.Address) {
+	sv.Delete(ClaimableRewardKey(addr))
+}
+
 type StakeWithHolder struct {
 	Holder common.Address
 	Stake  score.Stake
@@ -0,0 +1,64 @@
+package state
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/store/database/backend"
+
+	score "github.com/thetatoken/thetasubchain/core"
+)
+
+func TestGetBlockGasLimit(t *testing.T) {
+	assert := assert.New(t)
+
+	sv := NewStoreView(0, common.Hash{}, backend.NewMemDatabase())
+	assert.Equal(uint64(math.MaxUint64), sv.GetBlockGasLimit(1))
+
+	sv.SetBlockGasLimit(30000000)
+	sv.AddGovernanceParamValue(score.GovParamBlockGasLimit, score.GovernanceParamValue{ActivationHeight: 200, Value: "50000000"})
+	sv.AddGovernanceParamValue(score.GovParamBlockGasLimit, score.GovernanceParamValue{ActivationHeight: 100, Value: "40000000"})
+
+	tests := []struct {
+		height uint64
+		limit  uint64
+	}{
+		{1, 30000000},
+		{99, 30000000},
+		{100, 40000000},
+		{199, 40000000},
+		{200, 50000000},
+		{1000, 50000000},
+	}
+	for _, test := range tests {
+		assert.Equal(test.limit, sv.GetBlockGasLimit(test.height), "height %v", test.height)
+	}
+
+	// A later proposal for the same activation height replaces the approved value
+	sv.AddGovernanceParamValue(score.GovParamBlockGasLimit, score.GovernanceParamValue{ActivationHeight: 100, Value: "35000000"})
+	assert.Equal(uint64(35000000), sv.GetBlockGasLimit(150))
+}
+
+func TestGetAccruedStakerRewards(t *testing.T) {
+	assert := assert.New(t)
+
+	sv := NewStoreView(0, common.Hash{}, backend.NewMemDatabase())
+	assert.Equal(0, len(sv.GetAccruedStakerRewards()))
+
+	a := common.HexToAddress("0x0a")
+	b := common.HexToAddress("0x0b")
+	sv.AddAccruedStakerReward(b, big.NewInt(300))
+	sv.AddAccruedStakerReward(a, big.NewInt(100))
+	sv.AddAccruedStakerReward(b, big.NewInt(50))
+	sv.SetCollectedFees(big.NewInt(1000)) // not a staker reward
+
+	rewards := sv.GetAccruedStakerRewards()
+	assert.Equal(2, len(rewards))
+	assert.Equal(a, rewards[0].Account)
+	assert.Equal(0, big.NewInt(100).Cmp(rewards[0].Amount))
+	assert.Equal(b, rewards[1].Account)
+	assert.Equal(0, big.NewInt(350).Cmp(rewards[1].Amount))
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -21,6 +22,15 @@ const maxTxSize = 8 * 1024 * 1024
 const (
 	TxSubchainValidatorSetUpdate         types.TxType = 201
 	TxSubchainValidatorSetUpdateForChain types.TxType = 202
+	TxSubchainEquivocationEvidence       types.TxType = 203
+	TxSubchainBLSKeyRegistration         types.TxType = 204
+	TxSubchainStakeEvents                types.TxType = 205
+	TxSubchainCommissionRateUpdate       types.TxType = 206
+	TxSubchainRewardClaim                types.TxType = 207
+	TxSubchainGovernanceVote             types.TxType = 208
+	TxSubchainUpgradePlan                types.TxType = 209
+	TxSubchainStake                      types.TxType = 210
+	TxSubchainUnstake                    types.TxType = 211
 )
 
 //---------------------------------SubchainValidatorSetUpdateTx--------------------------------------------
@@ -29,27 +39,34 @@ type SubchainValidatorSetUpdateTx struct {
 	Proposer   types.TxInput
 	Dynasty    *big.Int
 	Validators []score.Validator
+
+	// MainchainBlock is the mainchain block the validator set is read from, required after the
+	// anchored validator set update fork
+	MainchainBlock *score.MainchainBlockRef `rlp:"nil"`
 }
 
 type SubchainValidatorSetUpdateTxJSON struct {
-	Proposer   types.TxInput     `json:"proposer"`
-	Dynasty    *big.Int          `json:"dynasty"`
-	Validators []score.Validator `json:"validators"`
+	Proposer       types.TxInput            `json:"proposer"`
+	Dynasty        *big.Int                 `json:"dynasty"`
+	Validators     []score.Validator        `json:"validators"`
+	MainchainBlock *score.MainchainBlockRef `json:"mainchain_block"`
 }
 
 func NewValidatorSetUpdateTxJSON(a SubchainValidatorSetUpdateTx) SubchainValidatorSetUpdateTxJSON {
 	return SubchainValidatorSetUpdateTxJSON{
-		Proposer:   a.Proposer,
-		Dynasty:    a.Dynasty,
-		Validators: a.Validators,
+		Proposer:       a.Proposer,
+		Dynasty:        a.Dynasty,
+		Validators:     a.Validators,
+		MainchainBlock: a.MainchainBlock,
 	}
 }
 
 func (a SubchainValidatorSetUpdateTxJSON) ValidatorSetUpdateTx() SubchainValidatorSetUpdateTx {
 	return SubchainValidatorSetUpdateTx{
-		Proposer:   a.Proposer,
-		Dynasty:    a.Dynasty,
-		Validators: a.Validators,
+		Proposer:       a.Proposer,
+		Dynasty:        a.Dynasty,
+		Validators:     a.Validators,
+		MainchainBlock: a.MainchainBlock,
 	}
 }
 
@@ -89,7 +106,7 @@ func (tx *SubchainValidatorSetUpdateTx) SetSignature(addr common.Address, sig *c
 }
 
 func (tx *SubchainValidatorSetUpdateTx) String() string {
-	return fmt.Sprintf("SubchainValidatorSetUpdateTx{%v}", tx.Validators)
+	return fmt.Sprintf("SubchainValidatorSetUpdateTx{%v, mainchain block: %v}", tx.Validators, tx.MainchainBlock)
 }
 
 //---------------------------------SubchainValidatorSetUpdateForChainTx--------------------------------------------
@@ -165,6 +182,684 @@ func (tx *SubchainValidatorSetUpdateForChainTx) String() string {
 	return fmt.Sprintf("SubchainValidatorSetUpdateForChainTx{%v}", tx.Validators)
 }
 
+//---------------------------------SubchainEquivocationEvidenceTx--------------------------------------------
+
+// SubchainEquivocationEvidenceTx is added by the block proposer to record the proof that a validator
+// signed conflicting votes or proposals
+type SubchainEquivocationEvidenceTx struct {
+	Proposer types.TxInput
+	Evidence score.EquivocationEvidence
+}
+
+type SubchainEquivocationEvidenceTxJSON struct {
+	Proposer types.TxInput              `json:"proposer"`
+	Evidence score.EquivocationEvidence `json:"evidence"`
+}
+
+func NewEquivocationEvidenceTxJSON(a SubchainEquivocationEvidenceTx) SubchainEquivocationEvidenceTxJSON {
+	return SubchainEquivocationEvidenceTxJSON{
+		Proposer: a.Proposer,
+		Evidence: a.Evidence,
+	}
+}
+
+func (a SubchainEquivocationEvidenceTxJSON) EquivocationEvidenceTx() SubchainEquivocationEvidenceTx {
+	return SubchainEquivocationEvidenceTx{
+		Proposer: a.Proposer,
+		Evidence: a.Evidence,
+	}
+}
+
+func (a SubchainEquivocationEvidenceTxJSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(SubchainEquivocationEvidenceTxJSON(a))
+}
+
+func (a *SubchainEquivocationEvidenceTx) UnmarshalJSON(data []byte) error {
+	var b SubchainEquivocationEvidenceTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.EquivocationEvidenceTx()
+	return nil
+}
+
+func (_ *SubchainEquivocationEvidenceTx) AssertIsTx() {}
+
+func (tx *SubchainEquivocationEvidenceTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Proposer.Signature
+	tx.Proposer.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Proposer.Signature = sig
+	return signBytes
+}
+
+func (tx *SubchainEquivocationEvidenceTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Proposer.Address == addr {
+		tx.Proposer.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *SubchainEquivocationEvidenceTx) String() string {
+	return fmt.Sprintf("SubchainEquivocationEvidenceTx{%v}", tx.Evidence.String())
+}
+
+//---------------------------------SubchainBLSKeyRegistrationTx--------------------------------------------
+
+// SubchainBLSKeyRegistrationTx is added by a block proposer to register its own BLS public key, which its
+// votes are aggregated with. The proof of possession prevents rogue key attacks on the aggregation.
+type SubchainBLSKeyRegistrationTx struct {
+	Proposer  types.TxInput
+	BLSPubKey common.Bytes
+	BLSPop    common.Bytes
+}
+
+type SubchainBLSKeyRegistrationTxJSON struct {
+	Proposer  types.TxInput `json:"proposer"`
+	BLSPubKey common.Bytes  `json:"bls_pub_key"`
+	BLSPop    common.Bytes  `json:"bls_pop"`
+}
+
+func NewBLSKeyRegistrationTxJSON(a SubchainBLSKeyRegistrationTx) SubchainBLSKeyRegistrationTxJSON {
+	return SubchainBLSKeyRegistrationTxJSON{
+		Proposer:  a.Proposer,
+		BLSPubKey: a.BLSPubKey,
+		BLSPop:    a.BLSPop,
+	}
+}
+
+func (a SubchainBLSKeyRegistrationTxJSON) BLSKeyRegistrationTx() SubchainBLSKeyRegistrationTx {
+	return SubchainBLSKeyRegistrationTx{
+		Proposer:  a.Proposer,
+		BLSPubKey: a.BLSPubKey,
+		BLSPop:    a.BLSPop,
+	}
+}
+
+func (a SubchainBLSKeyRegistrationTxJSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(SubchainBLSKeyRegistrationTxJSON(a))
+}
+
+func (a *SubchainBLSKeyRegistrationTx) UnmarshalJSON(data []byte) error {
+	var b SubchainBLSKeyRegistrationTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.BLSKeyRegistrationTx()
+	return nil
+}
+
+func (_ *SubchainBLSKeyRegistrationTx) AssertIsTx() {}
+
+func (tx *SubchainBLSKeyRegistrationTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Proposer.Signature
+	tx.Proposer.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Proposer.Signature = sig
+	return signBytes
+}
+
+func (tx *SubchainBLSKeyRegistrationTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Proposer.Address == addr {
+		tx.Proposer.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *SubchainBLSKeyRegistrationTx) String() string {
+	return fmt.Sprintf("SubchainBLSKeyRegistrationTx{%v, %v}", tx.Proposer.Address.Hex(), hex.EncodeToString(tx.BLSPubKey))
+}
+
+//---------------------------------SubchainStakeEventsTx--------------------------------------------
+
+// SubchainStakeEventsTx is added by a block proposer to mirror the DepositStake and WithdrawStake events
+// emitted on the mainchain within a range of mainchain blocks, which update the delegations to the validators,
+// along with the key rotations of the validators registered within the same range
+type SubchainStakeEventsTx struct {
+	Proposer            types.TxInput
+	FromMainchainHeight *big.Int
+	ToMainchainHeight   *big.Int
+	Events              []score.StakeEvent
+	KeyRotations        []score.KeyRotation
+}
+
+type SubchainStakeEventsTxJSON struct {
+	Proposer            types.TxInput       `json:"proposer"`
+	FromMainchainHeight *big.Int            `json:"from_mainchain_height"`
+	ToMainchainHeight   *big.Int            `json:"to_mainchain_height"`
+	Events              []score.StakeEvent  `json:"events"`
+	KeyRotations        []score.KeyRotation `json:"key_rotations"`
+}
+
+func NewStakeEventsTxJSON(a SubchainStakeEventsTx) SubchainStakeEventsTxJSON {
+	return SubchainStakeEventsTxJSON{
+		Proposer:            a.Proposer,
+		FromMainchainHeight: a.FromMainchainHeight,
+		ToMainchainHeight:   a.ToMainchainHeight,
+		Events:              a.Events,
+		KeyRotations:        a.KeyRotations,
+	}
+}
+
+func (a SubchainStakeEventsTxJSON) StakeEventsTx() SubchainStakeEventsTx {
+	return SubchainStakeEventsTx{
+		Proposer:            a.Proposer,
+		FromMainchainHeight: a.FromMainchainHeight,
+		ToMainchainHeight:   a.ToMainchainHeight,
+		Events:              a.Events,
+		KeyRotations:        a.KeyRotations,
+	}
+}
+
+func (a SubchainStakeEventsTxJSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(SubchainStakeEventsTxJSON(a))
+}
+
+func (a *SubchainStakeEventsTx) UnmarshalJSON(data []byte) error {
+	var b SubchainStakeEventsTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.StakeEventsTx()
+	return nil
+}
+
+func (_ *SubchainStakeEventsTx) AssertIsTx() {}
+
+func (tx *SubchainStakeEventsTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Proposer.Signature
+	tx.Proposer.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Proposer.Signature = sig
+	return signBytes
+}
+
+func (tx *SubchainStakeEventsTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Proposer.Address == addr {
+		tx.Proposer.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *SubchainStakeEventsTx) String() string {
+	return fmt.Sprintf("SubchainStakeEventsTx{%v-%v, %v, %v}", tx.FromMainchainHeight, tx.ToMainchainHeight, tx.Events, tx.KeyRotations)
+}
+
+//---------------------------------SubchainCommissionRateUpdateTx--------------------------------------------
+
+// SubchainCommissionRateUpdateTx is submitted by a validator to set the share of the rewards it keeps
+// before the rest is split among its delegators
+type SubchainCommissionRateUpdateTx struct {
+	Fee                         types.Coins
+	Validator                   types.TxInput
+	CommissionRateInBasisPoints uint64
+}
+
+type SubchainCommissionRateUpdateTxJSON struct {
+	Fee                         types.Coins   `json:"fee"`
+	Validator                   types.TxInput `json:"validator"`
+	CommissionRateInBasisPoints uint64        `json:"commission_rate_in_basis_points"`
+}
+
+func NewCommissionRateUpdateTxJSON(a SubchainCommissionRateUpdateTx) SubchainCommissionRateUpdateTxJSON {
+	return SubchainCommissionRateUpdateTxJSON{
+		Fee:                         a.Fee,
+		Validator:                   a.Validator,
+		CommissionRateInBasisPoints: a.CommissionRateInBasisPoints,
+	}
+}
+
+func (a SubchainCommissionRateUpdateTxJSON) CommissionRateUpdateTx() SubchainCommissionRateUpdateTx {
+	return SubchainCommissionRateUpdateTx{
+		Fee:                         a.Fee,
+		Validator:                   a.Validator,
+		CommissionRateInBasisPoints: a.CommissionRateInBasisPoints,
+	}
+}
+
+func (a SubchainCommissionRateUpdateTxJSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(SubchainCommissionRateUpdateTxJSON(a))
+}
+
+func (a *SubchainCommissionRateUpdateTx) UnmarshalJSON(data []byte) error {
+	var b SubchainCommissionRateUpdateTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.CommissionRateUpdateTx()
+	return nil
+}
+
+func (_ *SubchainCommissionRateUpdateTx) AssertIsTx() {}
+
+func (tx *SubchainCommissionRateUpdateTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Validator.Signature
+	tx.Validator.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Validator.Signature = sig
+	return signBytes
+}
+
+func (tx *SubchainCommissionRateUpdateTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Validator.Address == addr {
+		tx.Validator.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *SubchainCommissionRateUpdateTx) String() string {
+	return fmt.Sprintf("SubchainCommissionRateUpdateTx{%v, %v}", tx.Validator.Address.Hex(), tx.CommissionRateInBasisPoints)
+}
+
+//---------------------------------SubchainRewardClaimTx--------------------------------------------
+
+// SubchainRewardClaimTx is submitted by a staker or a validator to transfer its claimable rewards to its account
+type SubchainRewardClaimTx struct {
+	Fee     types.Coins
+	Claimer types.TxInput
+}
+
+type SubchainRewardClaimTxJSON struct {
+	Fee     types.Coins   `json:"fee"`
+	Claimer types.TxInput `json:"claimer"`
+}
+
+func NewRewardClaimTxJSON(a SubchainRewardClaimTx) SubchainRewardClaimTxJSON {
+	return SubchainRewardClaimTxJSON{
+		Fee:     a.Fee,
+		Claimer: a.Claimer,
+	}
+}
+
+func (a SubchainRewardClaimTxJSON) RewardClaimTx() SubchainRewardClaimTx {
+	return SubchainRewardClaimTx{
+		Fee:     a.Fee,
+		Claimer: a.Claimer,
+	}
+}
+
+func (a SubchainRewardClaimTxJSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(SubchainRewardClaimTxJSON(a))
+}
+
+func (a *SubchainRewardClaimTx) UnmarshalJSON(data []byte) error {
+	var b SubchainRewardClaimTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.RewardClaimTx()
+	return nil
+}
+
+func (_ *SubchainRewardClaimTx) AssertIsTx() {}
+
+func (tx *SubchainRewardClaimTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Claimer.Signature
+	tx.Claimer.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Claimer.Signature = sig
+	return signBytes
+}
+
+func (tx *SubchainRewardClaimTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Claimer.Address == addr {
+		tx.Claimer.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *SubchainRewardClaimTx) String() string {
+	return fmt.Sprintf("SubchainRewardClaimTx{%v}", tx.Claimer.Address.Hex())
+}
+
+//---------------------------------SubchainGovernanceVoteTx--------------------------------------------
+
+// SubchainGovernanceVoteTx is submitted by a validator to propose a new value of a runtime parameter, or to
+// vote for a pending proposal. A new proposal has a zero ProposalID, and counts as the vote of its proposer.
+type SubchainGovernanceVoteTx struct {
+	Fee              types.Coins
+	Validator        types.TxInput
+	ProposalID       uint64
+	Param            string // only set for the new proposals
+	Value            string // only set for the new proposals
+	ActivationHeight uint64 // only set for the new proposals
+}
+
+type SubchainGovernanceVoteTxJSON struct {
+	Fee              types.Coins   `json:"fee"`
+	Validator        types.TxInput `json:"validator"`
+	ProposalID       uint64        `json:"proposal_id"`
+	Param            string        `json:"param"`
+	Value            string        `json:"value"`
+	ActivationHeight uint64        `json:"activation_height"`
+}
+
+func NewGovernanceVoteTxJSON(a SubchainGovernanceVoteTx) SubchainGovernanceVoteTxJSON {
+	return SubchainGovernanceVoteTxJSON{
+		Fee:              a.Fee,
+		Validator:        a.Validator,
+		ProposalID:       a.ProposalID,
+		Param:            a.Param,
+		Value:            a.Value,
+		ActivationHeight: a.ActivationHeight,
+	}
+}
+
+func (a SubchainGovernanceVoteTxJSON) GovernanceVoteTx() SubchainGovernanceVoteTx {
+	return SubchainGovernanceVoteTx{
+		Fee:              a.Fee,
+		Validator:        a.Validator,
+		ProposalID:       a.ProposalID,
+		Param:            a.Param,
+		Value:            a.Value,
+		ActivationHeight: a.ActivationHeight,
+	}
+}
+
+func (a SubchainGovernanceVoteTxJSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(SubchainGovernanceVoteTxJSON(a))
+}
+
+func (a *SubchainGovernanceVoteTx) UnmarshalJSON(data []byte) error {
+	var b SubchainGovernanceVoteTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.GovernanceVoteTx()
+	return nil
+}
+
+func (_ *SubchainGovernanceVoteTx) AssertIsTx() {}
+
+func (tx *SubchainGovernanceVoteTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Validator.Signature
+	tx.Validator.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Validator.Signature = sig
+	return signBytes
+}
+
+func (tx *SubchainGovernanceVoteTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Validator.Address == addr {
+		tx.Validator.Signature = sig
+		return true
+	}
+	return false
+}
+
+// IsProposal returns whether the transaction submits a new proposal
+func (tx *SubchainGovernanceVoteTx) IsProposal() bool {
+	return tx.ProposalID == 0
+}
+
+func (tx *SubchainGovernanceVoteTx) String() string {
+	if tx.IsProposal() {
+		return fmt.Sprintf("SubchainGovernanceVoteTx{%v, proposal: %v = %v at %v}",
+			tx.Validator.Address.Hex(), tx.Param, tx.Value, tx.ActivationHeight)
+	}
+	return fmt.Sprintf("SubchainGovernanceVoteTx{%v, vote: %v}", tx.Validator.Address.Hex(), tx.ProposalID)
+}
+
+//---------------------------------SubchainUpgradePlanTx--------------------------------------------
+
+// SubchainUpgradePlanTx is submitted by a validator to propose a software upgrade, which requires the
+// validators to run at least the given version from the given height on. The proposal counts as the vote
+// of its proposer, and the other validators vote for it with the SubchainGovernanceVoteTx.
+type SubchainUpgradePlanTx struct {
+	Fee       types.Coins
+	Validator types.TxInput
+	Version   string
+	Height    uint64
+}
+
+type SubchainUpgradePlanTxJSON struct {
+	Fee       types.Coins   `json:"fee"`
+	Validator types.TxInput `json:"validator"`
+	Version   string        `json:"version"`
+	Height    uint64        `json:"height"`
+}
+
+func NewUpgradePlanTxJSON(a SubchainUpgradePlanTx) SubchainUpgradePlanTxJSON {
+	return SubchainUpgradePlanTxJSON{
+		Fee:       a.Fee,
+		Validator: a.Validator,
+		Version:   a.Version,
+		Height:    a.Height,
+	}
+}
+
+func (a SubchainUpgradePlanTxJSON) UpgradePlanTx() SubchainUpgradePlanTx {
+	return SubchainUpgradePlanTx{
+		Fee:       a.Fee,
+		Validator: a.Validator,
+		Version:   a.Version,
+		Height:    a.Height,
+	}
+}
+
+func (a SubchainUpgradePlanTxJSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(SubchainUpgradePlanTxJSON(a))
+}
+
+func (a *SubchainUpgradePlanTx) UnmarshalJSON(data []byte) error {
+	var b SubchainUpgradePlanTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.UpgradePlanTx()
+	return nil
+}
+
+func (_ *SubchainUpgradePlanTx) AssertIsTx() {}
+
+func (tx *SubchainUpgradePlanTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Validator.Signature
+	tx.Validator.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Validator.Signature = sig
+	return signBytes
+}
+
+func (tx *SubchainUpgradePlanTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Validator.Address == addr {
+		tx.Validator.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *SubchainUpgradePlanTx) String() string {
+	return fmt.Sprintf("SubchainUpgradePlanTx{%v, version: %v, height: %v}",
+		tx.Validator.Address.Hex(), tx.Version, tx.Height)
+}
+
+//---------------------------------SubchainStakeTx--------------------------------------------
+
+// SubchainStakeTx locks the given amount of the governance token vouchers held by the staker on the subchain,
+// and stakes it to the validator. The stake is relayed to the chain registrar on the mainchain by the
+// orchestrator, and counts in the validator set of the dynasties after it is deposited there.
+type SubchainStakeTx struct {
+	Fee       types.Coins
+	Staker    types.TxInput
+	Validator common.Address
+	Amount    *big.Int
+}
+
+type SubchainStakeTxJSON struct {
+	Fee       types.Coins    `json:"fee"`
+	Staker    types.TxInput  `json:"staker"`
+	Validator common.Address `json:"validator"`
+	Amount    *big.Int       `json:"amount"`
+}
+
+func NewStakeTxJSON(a SubchainStakeTx) SubchainStakeTxJSON {
+	return SubchainStakeTxJSON{
+		Fee:       a.Fee,
+		Staker:    a.Staker,
+		Validator: a.Validator,
+		Amount:    a.Amount,
+	}
+}
+
+func (a SubchainStakeTxJSON) StakeTx() SubchainStakeTx {
+	return SubchainStakeTx{
+		Fee:       a.Fee,
+		Staker:    a.Staker,
+		Validator: a.Validator,
+		Amount:    a.Amount,
+	}
+}
+
+func (a SubchainStakeTxJSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(SubchainStakeTxJSON(a))
+}
+
+func (a *SubchainStakeTx) UnmarshalJSON(data []byte) error {
+	var b SubchainStakeTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.StakeTx()
+	return nil
+}
+
+func (_ *SubchainStakeTx) AssertIsTx() {}
+
+func (tx *SubchainStakeTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Staker.Signature
+	tx.Staker.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Staker.Signature = sig
+	return signBytes
+}
+
+func (tx *SubchainStakeTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Staker.Address == addr {
+		tx.Staker.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *SubchainStakeTx) String() string {
+	return fmt.Sprintf("SubchainStakeTx{%v -> %v, amount: %v}",
+		tx.Staker.Address.Hex(), tx.Validator.Hex(), tx.Amount)
+}
+
+//---------------------------------SubchainUnstakeTx--------------------------------------------
+
+// SubchainUnstakeTx withdraws the given amount of the stake the staker has locked with the SubchainStakeTx.
+// The amount is released to the staker after the unbonding period, and the withdrawal is relayed to the
+// chain registrar on the mainchain by the orchestrator.
+type SubchainUnstakeTx struct {
+	Fee       types.Coins
+	Staker    types.TxInput
+	Validator common.Address
+	Amount    *big.Int
+}
+
+type SubchainUnstakeTxJSON struct {
+	Fee       types.Coins    `json:"fee"`
+	Staker    types.TxInput  `json:"staker"`
+	Validator common.Address `json:"validator"`
+	Amount    *big.Int       `json:"amount"`
+}
+
+func NewUnstakeTxJSON(a SubchainUnstakeTx) SubchainUnstakeTxJSON {
+	return SubchainUnstakeTxJSON{
+		Fee:       a.Fee,
+		Staker:    a.Staker,
+		Validator: a.Validator,
+		Amount:    a.Amount,
+	}
+}
+
+func (a SubchainUnstakeTxJSON) UnstakeTx() SubchainUnstakeTx {
+	return SubchainUnstakeTx{
+		Fee:       a.Fee,
+		Staker:    a.Staker,
+		Validator: a.Validator,
+		Amount:    a.Amount,
+	}
+}
+
+func (a SubchainUnstakeTxJSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(SubchainUnstakeTxJSON(a))
+}
+
+func (a *SubchainUnstakeTx) UnmarshalJSON(data []byte) error {
+	var b SubchainUnstakeTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.UnstakeTx()
+	return nil
+}
+
+func (_ *SubchainUnstakeTx) AssertIsTx() {}
+
+func (tx *SubchainUnstakeTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Staker.Signature
+	tx.Staker.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Staker.Signature = sig
+	return signBytes
+}
+
+func (tx *SubchainUnstakeTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Staker.Address == addr {
+		tx.Staker.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *SubchainUnstakeTx) String() string {
+	return fmt.Sprintf("SubchainUnstakeTx{%v -> %v, amount: %v}",
+		tx.Staker.Address.Hex(), tx.Validator.Hex(), tx.Amount)
+}
+
 // --------------- Utils --------------- //
 
 func encodeToBytes(str string) []byte {
@@ -216,6 +911,24 @@ func TxToBytes(t types.Tx) ([]byte, error) {
 		txType = TxSubchainValidatorSetUpdate
 	case *SubchainValidatorSetUpdateForChainTx:
 		txType = TxSubchainValidatorSetUpdateForChain
+	case *SubchainEquivocationEvidenceTx:
+		txType = TxSubchainEquivocationEvidence
+	case *SubchainBLSKeyRegistrationTx:
+		txType = TxSubchainBLSKeyRegistration
+	case *SubchainStakeEventsTx:
+		txType = TxSubchainStakeEvents
+	case *SubchainCommissionRateUpdateTx:
+		txType = TxSubchainCommissionRateUpdate
+	case *SubchainRewardClaimTx:
+		txType = TxSubchainRewardClaim
+	case *SubchainGovernanceVoteTx:
+		txType = TxSubchainGovernanceVote
+	case *SubchainUpgradePlanTx:
+		txType = TxSubchainUpgradePlan
+	case *SubchainStakeTx:
+		txType = TxSubchainStake
+	case *SubchainUnstakeTx:
+		txType = TxSubchainUnstake
 	default:
 		return nil, errors.New("unsupported message type")
 	}
@@ -258,6 +971,42 @@ func TxFromBytes(raw []byte) (types.Tx, error) {
 		data := &SubchainValidatorSetUpdateForChainTx{}
 		err = s.Decode(data)
 		return data, err
+	} else if txType == TxSubchainEquivocationEvidence {
+		data := &SubchainEquivocationEvidenceTx{}
+		err = s.Decode(data)
+		return data, err
+	} else if txType == TxSubchainBLSKeyRegistration {
+		data := &SubchainBLSKeyRegistrationTx{}
+		err = s.Decode(data)
+		return data, err
+	} else if txType == TxSubchainStakeEvents {
+		data := &SubchainStakeEventsTx{}
+		err = s.Decode(data)
+		return data, err
+	} else if txType == TxSubchainCommissionRateUpdate {
+		data := &SubchainCommissionRateUpdateTx{}
+		err = s.Decode(data)
+		return data, err
+	} else if txType == TxSubchainRewardClaim {
+		data := &SubchainRewardClaimTx{}
+		err = s.Decode(data)
+		return data, err
+	} else if txType == TxSubchainGovernanceVote {
+		data := &SubchainGovernanceVoteTx{}
+		err = s.Decode(data)
+		return data, err
+	} else if txType == TxSubchainUpgradePlan {
+		data := &SubchainUpgradePlanTx{}
+		err = s.Decode(data)
+		return data, err
+	} else if txType == TxSubchainStake {
+		data := &SubchainStakeTx{}
+		err = s.Decode(data)
+		return data, err
+	} else if txType == TxSubchainUnstake {
+		data := &SubchainUnstakeTx{}
+		err = s.Decode(data)
+		return data, err
 	} else {
 		return nil, fmt.Errorf("unknown TX type: %v", txType)
 	}
@@ -27,6 +27,7 @@ import (
 	"github.com/thetatoken/theta/crypto"
 	"github.com/thetatoken/theta/crypto/bn256"
 	"github.com/thetatoken/theta/ledger/vm/params"
+	scom "github.com/thetatoken/thetasubchain/common"
 	"github.com/thetatoken/thetasubchain/eth/abi"
 	"golang.org/x/crypto/ripemd160"
 )
@@ -278,7 +279,7 @@ type bn256Add struct{}
 
 // RequiredGas returns the gas required to execute the pre-compiled contract.
 func (c *bn256Add) RequiredGas(input []byte, blockHeight uint64) uint64 {
-	if blockHeight < common.HeightJune2021FeeAdjustment {
+	if !scom.IsForkActive(scom.ForkJune2021FeeAdjustment, blockHeight) {
 		return params.Bn256AddGas
 	}
 	return params.Bn256AddGasIstanbul
@@ -303,7 +304,7 @@ type bn256ScalarMul struct{}
 
 // RequiredGas returns the gas required to execute the pre-compiled contract.
 func (c *bn256ScalarMul) RequiredGas(input []byte, blockHeight uint64) uint64 {
-	if blockHeight < common.HeightJune2021FeeAdjustment {
+	if !scom.IsForkActive(scom.ForkJune2021FeeAdjustment, blockHeight) {
 		return params.Bn256ScalarMulGas
 	}
 
@@ -336,7 +337,7 @@ type bn256Pairing struct{}
 
 // RequiredGas returns the gas required to execute the pre-compiled contract.
 func (c *bn256Pairing) RequiredGas(input []byte, blockHeight uint64) uint64 {
-	if blockHeight < common.HeightJune2021FeeAdjustment {
+	if !scom.IsForkActive(scom.ForkJune2021FeeAdjustment, blockHeight) {
 		return params.Bn256PairingBaseGas + uint64(len(input)/192)*params.Bn256PairingPerPointGas
 	}
 
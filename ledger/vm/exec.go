@@ -13,25 +13,33 @@ type BlockInfo struct {
 	Height    uint64
 	Timestamp *big.Int
 	ChainID   string
+	GasLimit  uint64 // block gas limit, math.MaxUint64 if the chain has no block gas limit
 }
 
-func NewBlockInfo(height uint64, timestamp *big.Int, chainID string) *BlockInfo {
+func NewBlockInfo(height uint64, timestamp *big.Int, chainID string, gasLimit uint64) *BlockInfo {
 	return &BlockInfo{
 		Height:    height,
 		Timestamp: timestamp,
 		ChainID:   chainID,
+		GasLimit:  gasLimit,
 	}
 }
 
 // Execute executes the given smart contract
 func Execute(parentBlockInfo *BlockInfo, tx *types.SmartContractTx, statedb StateDB) (evmRet common.Bytes,
 	contractAddr common.Address, gasUsed uint64, evmErr error) {
+	// The GASLIMIT opcode reports the block gas limit. Without a block gas limit
+	// it falls back to the gas limit of the transaction.
+	blockGasLimit := parentBlockInfo.GasLimit
+	if blockGasLimit == math.MaxUint64 {
+		blockGasLimit = tx.GasLimit
+	}
 	context := Context{
 		CanTransfer: CanTransfer,
 		Transfer:    Transfer,
 		Origin:      tx.From.Address,
 		GasPrice:    tx.GasPrice,
-		GasLimit:    tx.GasLimit,
+		GasLimit:    blockGasLimit,
 		BlockNumber: new(big.Int).SetUint64(parentBlockInfo.Height + 1),
 		Time:        parentBlockInfo.Timestamp,
 		Difficulty:  new(big.Int).SetInt64(0),
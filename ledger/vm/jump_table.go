@@ -21,6 +21,7 @@ import (
 	"math/big"
 
 	"github.com/thetatoken/theta/ledger/vm/params"
+	scom "github.com/thetatoken/thetasubchain/common"
 )
 
 type (
@@ -57,6 +58,15 @@ var (
 	constantinopleInstructionSet = newConstantinopleInstructionSet()
 )
 
+// instructionSetAt returns the instruction set of the EVM at the given block height according to the fork
+// schedule of the subchain
+func instructionSetAt(blockNumber *big.Int) [256]operation {
+	if blockNumber != nil && blockNumber.IsUint64() && !scom.IsForkActive(scom.ForkEVMConstantinople, blockNumber.Uint64()) {
+		return byzantiumInstructionSet
+	}
+	return constantinopleInstructionSet
+}
+
 // NewConstantinopleInstructionSet returns the frontier, homestead
 // byzantium and contantinople instructions.
 func newConstantinopleInstructionSet() [256]operation {
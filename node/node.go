@@ -31,6 +31,7 @@ import (
 	srpc "github.com/thetatoken/thetasubchain/rpc"
 	ssnst "github.com/thetatoken/thetasubchain/snapshot"
 	srollingdb "github.com/thetatoken/thetasubchain/store/rollingdb"
+	sversion "github.com/thetatoken/thetasubchain/version"
 )
 
 type Node struct {
@@ -61,6 +62,7 @@ type Params struct {
 	ChainID             string
 	GasPriceLimit       *big.Int
 	PrivateKey          *crypto.PrivateKey
+	Signer              score.Signer // signer of the consensus messages, the private key of the node is used if nil
 	Root                *score.Block
 	NetworkOld          p2p.Network
 	Network             p2pl.Network
@@ -76,7 +78,7 @@ func NewNode(params *Params) *Node {
 	chain := sbc.NewChain(params.ChainID, store, params.Root)
 	params.RollingDB.SetChain(chain)
 
-	validatorManager := sconsensus.NewRotatingValidatorManager()
+	validatorManager := sconsensus.NewRotatingValidatorManager(chain)
 	dispatcher := dp.NewDispatcher(params.NetworkOld, params.Network)
 
 	interChainEventCache := siu.NewInterChainEventCache(params.DB)
@@ -100,6 +102,9 @@ func NewNode(params *Params) *Node {
 	)
 
 	consensus := sconsensus.NewConsensusEngine(params.PrivateKey, store, chain, dispatcher, validatorManager, metachainWitness)
+	if params.Signer != nil {
+		consensus.SetSigner(params.Signer)
+	}
 	// reporter := srp.NewReporter(dispatcher, consensus, chain)
 
 	syncMgr := snsync.NewSyncManager(chain, consensus, params.NetworkOld, params.Network, dispatcher, consensus)
@@ -111,12 +116,15 @@ func NewNode(params *Params) *Node {
 	mempool.SetLedger(ledger)
 
 	txMsgHandler := smp.CreateMempoolMessageHandler(mempool)
+	evidenceMsgHandler := sconsensus.CreateEvidenceMessageHandler(consensus)
 
 	if !reflect.ValueOf(params.Network).IsNil() {
 		params.Network.RegisterMessageHandler(txMsgHandler)
+		params.Network.RegisterMessageHandler(evidenceMsgHandler)
 	}
 	if !reflect.ValueOf(params.NetworkOld).IsNil() {
 		params.NetworkOld.RegisterMessageHandler(txMsgHandler)
+		params.NetworkOld.RegisterMessageHandler(evidenceMsgHandler)
 	}
 
 	currentHeight := consensus.GetLastFinalizedBlock().Height
@@ -137,8 +145,25 @@ func NewNode(params *Params) *Node {
 			state.SetLastProposal(score.Proposal{})
 		}
 	}
+	finalizedView, err := ledger.GetFinalizedSnapshot()
+	if err != nil {
+		log.Fatalf("Failed to get the finalized snapshot: %v", err)
+	}
+	if err := scom.InitDynastySchedule(finalizedView.GetNumMainchainBlocksPerDynasty(), finalizedView.GetGenesisDynastyLengthChanges()); err != nil {
+		log.Fatalf("Failed to initialize the dynasty schedule: %v", err)
+	}
+	if err := scom.InitForkSchedule(finalizedView.GetGenesisForks()); err != nil {
+		log.Fatalf("Failed to initialize the fork schedule: %v", err)
+	}
+	if plan := score.FindUnsupportedUpgradePlan(finalizedView.GetUpgradePlans(), finalizedView.Height()+1, sversion.Version); plan != nil {
+		log.Fatalf("Refusing to start: the upgrade plan requires version %v or later of the node software from height %v, running version %v",
+			plan.Version, plan.Height, sversion.Version)
+	}
+	metachainWitness.CheckNumMainchainBlocksPerDynasty()
 	metachainWitness.SetSubchainTokenBanks(ledger)
 	orchestrator.SetLedgerAndSubchainTokenBanks(ledger)
+	orchestrator.SetDowntimeReporter(consensus.GetLivenessTracker())
+	orchestrator.SetTxPool(mempool)
 	node := &Node{
 		Store:                store,
 		Chain:                chain,
@@ -155,7 +180,7 @@ func NewNode(params *Params) *Node {
 	}
 
 	if viper.GetBool(common.CfgRPCEnabled) {
-		node.RPC = srpc.NewThetaRPCServer(mempool, ledger, dispatcher, chain, consensus)
+		node.RPC = srpc.NewThetaRPCServer(mempool, ledger, dispatcher, chain, consensus, orchestrator)
 	}
 	return node
 }
@@ -177,6 +202,17 @@ func (n *Node) Start(ctx context.Context) {
 	if viper.GetBool(common.CfgRPCEnabled) {
 		n.RPC.Start(n.ctx)
 	}
+
+	go n.stopOnHalt()
+}
+
+// stopOnHalt stops the node once the consensus engine halts for an upgrade plan
+func (n *Node) stopOnHalt() {
+	select {
+	case <-n.Consensus.Halted():
+		n.Stop()
+	case <-n.ctx.Done():
+	}
 }
 
 // Stop notifies all sub components to stop without blocking.
@@ -166,6 +166,6 @@ func isSyncing(block *score.ExtendedBlock) bool {
 	currentTime := big.NewInt(time.Now().Unix())
 	maxDiff := new(big.Int).SetUint64(30) // thirty seconds, about 5 blocks
 	threshold := new(big.Int).Sub(currentTime, maxDiff)
-	isSyncing := block.Timestamp.Cmp(threshold) < 0
+	isSyncing := block.TimestampInSeconds().Cmp(threshold) < 0
 	return isSyncing
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/ledger/types"
 
+	scom "github.com/thetatoken/thetasubchain/common"
 	sldst "github.com/thetatoken/thetasubchain/ledger/state"
 	stypes "github.com/thetatoken/thetasubchain/ledger/types"
 	svm "github.com/thetatoken/thetasubchain/ledger/vm"
@@ -36,8 +37,8 @@ func (t *ThetaRPCService) CallSmartContract(args *CallSmartContractArgs, result
 	}
 
 	blockHeight := ledgerState.Height() + 1 // the view points to the parent of the current block
-	if blockHeight < common.HeightEnableSmartContract {
-		return fmt.Errorf("Smart contract feature not enabled until block height %v.", common.HeightEnableSmartContract)
+	if !scom.IsForkActive(scom.ForkSmartContract, blockHeight) {
+		return fmt.Errorf("Smart contract feature not enabled until block height %v.", scom.GetForkSchedule().ActivationHeight(scom.ForkSmartContract))
 	}
 
 	sctxBytes, err := hex.DecodeString(args.SctxBytes)
@@ -55,7 +56,7 @@ func (t *ThetaRPCService) CallSmartContract(args *CallSmartContractArgs, result
 	}
 
 	pb := t.ledger.State().ParentBlock()
-	parentBlockInfo := svm.NewBlockInfo(pb.Height, pb.Timestamp, pb.ChainID)
+	parentBlockInfo := svm.NewBlockInfo(pb.Height, pb.TimestampInSeconds(), pb.ChainID, ledgerState.GetBlockGasLimit(blockHeight))
 	vmRet, contractAddr, gasUsed, vmErr := svm.Execute(parentBlockInfo, sctx, ledgerState)
 	ledgerState.Save()
 
@@ -4,8 +4,10 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"math/rand"
+	"strconv"
 	"time"
 
 	"github.com/spf13/viper"
@@ -13,10 +15,13 @@ import (
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/crypto"
 	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rlp"
 
 	sbc "github.com/thetatoken/thetasubchain/blockchain"
+	scom "github.com/thetatoken/thetasubchain/common"
 	"github.com/thetatoken/thetasubchain/core"
 	score "github.com/thetatoken/thetasubchain/core"
+	"github.com/thetatoken/thetasubchain/interchain/orchestrator"
 	"github.com/thetatoken/thetasubchain/ledger/state"
 	slst "github.com/thetatoken/thetasubchain/ledger/state"
 	stypes "github.com/thetatoken/thetasubchain/ledger/types"
@@ -29,16 +34,29 @@ import (
 type GetVersionArgs struct {
 }
 
+type Fork struct {
+	Name   string            `json:"name"`
+	Height common.JSONUint64 `json:"height"` // activation height
+}
+
 type GetVersionResult struct {
 	Version   string `json:"version"`
 	GitHash   string `json:"git_hash"`
 	Timestamp string `json:"timestamp"`
+	Forks     []Fork `json:"forks"` // the fork schedule of the subchain
 }
 
 func (t *ThetaRPCService) GetVersion(args *GetVersionArgs, result *GetVersionResult) (err error) {
 	result.Version = sversion.Version
 	result.GitHash = sversion.GitHash
 	result.Timestamp = sversion.Timestamp
+	result.Forks = []Fork{}
+	for _, fork := range scom.GetForkSchedule().Forks() {
+		result.Forks = append(result.Forks, Fork{
+			Name:   fork.Name,
+			Height: common.JSONUint64(fork.Height),
+		})
+	}
 	return nil
 }
 
@@ -245,17 +263,18 @@ type GetBlockResult struct {
 type GetBlocksResult []*GetBlockResultInner
 
 type GetBlockResultInner struct {
-	ChainID   string                  `json:"chain_id"`
-	Epoch     common.JSONUint64       `json:"epoch"`
-	Height    common.JSONUint64       `json:"height"`
-	Parent    common.Hash             `json:"parent"`
-	TxHash    common.Hash             `json:"transactions_hash"`
-	StateHash common.Hash             `json:"state_hash"`
-	Timestamp *common.JSONBig         `json:"timestamp"`
-	Proposer  common.Address          `json:"proposer"`
-	HCC       score.CommitCertificate `json:"hcc"`
-	Children  []common.Hash           `json:"children"`
-	Status    score.BlockStatus       `json:"status"`
+	ChainID     string                  `json:"chain_id"`
+	Epoch       common.JSONUint64       `json:"epoch"`
+	Height      common.JSONUint64       `json:"height"`
+	Parent      common.Hash             `json:"parent"`
+	TxHash      common.Hash             `json:"transactions_hash"`
+	StateHash   common.Hash             `json:"state_hash"`
+	Timestamp   *common.JSONBig         `json:"timestamp"`    // in seconds, as expected by the Ethereum tooling
+	TimestampMs *common.JSONBig         `json:"timestamp_ms"` // in milliseconds
+	Proposer    common.Address          `json:"proposer"`
+	HCC         score.CommitCertificate `json:"hcc"`
+	Children    []common.Hash           `json:"children"`
+	Status      score.BlockStatus       `json:"status"`
 
 	Hash common.Hash   `json:"hash"`
 	Txs  []interface{} `json:"transactions"` // for backward conpatibility, see function ThetaRPCService.gatherTxs()
@@ -277,8 +296,17 @@ const (
 	TxTypeDepositStakeTxV2
 	TxTypeStakeRewardDistributionTx
 
-	TxSubchainValidatorSetUpdate = byte(201)
-	TxInterChainMessage          = byte(202)
+	TxSubchainValidatorSetUpdate   = byte(201)
+	TxInterChainMessage            = byte(202)
+	TxSubchainEquivocationEvidence = byte(203)
+	TxSubchainBLSKeyRegistration   = byte(204)
+	TxSubchainStakeEvents          = byte(205)
+	TxSubchainCommissionRateUpdate = byte(206)
+	TxSubchainRewardClaim          = byte(207)
+	TxSubchainGovernanceVote       = byte(208)
+	TxSubchainUpgradePlan          = byte(209)
+	TxSubchainStake                = byte(210)
+	TxSubchainUnstake              = byte(211)
 )
 
 func (t *ThetaRPCService) GetBlock(args *GetBlockArgs, result *GetBlockResult) (err error) {
@@ -298,7 +326,8 @@ func (t *ThetaRPCService) GetBlock(args *GetBlockArgs, result *GetBlockResult) (
 	result.Parent = block.Parent
 	result.TxHash = block.TxHash
 	result.StateHash = block.StateHash
-	result.Timestamp = (*common.JSONBig)(block.Timestamp)
+	result.Timestamp = (*common.JSONBig)(block.TimestampInSeconds())
+	result.TimestampMs = (*common.JSONBig)(block.TimestampInMilliseconds())
 	result.Proposer = block.Proposer
 	result.Children = block.Children
 	result.Status = block.Status
@@ -346,6 +375,7 @@ func (t *ThetaRPCService) GetBlockByHeight(args *GetBlockByHeightArgs, result *G
 		result.Children = []common.Hash{}
 		result.Status = score.BlockStatusDirectlyFinalized
 		result.Timestamp = (*common.JSONBig)(big.NewInt(0))
+		result.TimestampMs = (*common.JSONBig)(big.NewInt(0))
 		result.Hash = genesisHash
 		return
 	}
@@ -361,7 +391,8 @@ func (t *ThetaRPCService) GetBlockByHeight(args *GetBlockByHeightArgs, result *G
 	result.Parent = block.Parent
 	result.TxHash = block.TxHash
 	result.StateHash = block.StateHash
-	result.Timestamp = (*common.JSONBig)(block.Timestamp)
+	result.Timestamp = (*common.JSONBig)(block.TimestampInSeconds())
+	result.TimestampMs = (*common.JSONBig)(block.TimestampInMilliseconds())
 	result.Proposer = block.Proposer
 	result.Children = block.Children
 	result.Status = block.Status
@@ -396,6 +427,7 @@ func (t *ThetaRPCService) GetBlocksByRange(args *GetBlocksByRangeArgs, result *G
 	genesisBlock.Children = []common.Hash{}
 	genesisBlock.Status = score.BlockStatusDirectlyFinalized
 	genesisBlock.Timestamp = (*common.JSONBig)(big.NewInt(0))
+	genesisBlock.TimestampMs = (*common.JSONBig)(big.NewInt(0))
 	genesisBlock.Hash = genesisHash
 
 	if args.End == 0 {
@@ -438,7 +470,8 @@ func (t *ThetaRPCService) GetBlocksByRange(args *GetBlocksByRangeArgs, result *G
 		blkInner.Parent = block.Parent
 		blkInner.TxHash = block.TxHash
 		blkInner.StateHash = block.StateHash
-		blkInner.Timestamp = (*common.JSONBig)(block.Timestamp)
+		blkInner.Timestamp = (*common.JSONBig)(block.TimestampInSeconds())
+		blkInner.TimestampMs = (*common.JSONBig)(block.TimestampInMilliseconds())
 		blkInner.Proposer = block.Proposer
 		blkInner.Children = block.Children
 		blkInner.Status = block.Status
@@ -480,6 +513,8 @@ type GetStatusResult struct {
 	GenesisBlockHash           common.Hash       `json:"genesis_block_hash"`
 	SnapshotBlockHeight        common.JSONUint64 `json:"snapshot_block_height"`
 	SnapshotBlockHash          common.Hash       `json:"snapshot_block_hash"`
+	ActiveForks                []string          `json:"active_forks"`  // the forks active at the latest finalized height
+	PendingForks               []Fork            `json:"pending_forks"` // the forks scheduled after the latest finalized height
 }
 
 func (t *ThetaRPCService) GetStatus(args *GetStatusArgs, result *GetStatusResult) (err error) {
@@ -498,7 +533,7 @@ func (t *ThetaRPCService) GetStatus(args *GetStatusArgs, result *GetStatusResult
 		}
 		result.LatestFinalizedBlockEpoch = common.JSONUint64(latestFinalizedBlock.Epoch)
 		result.LatestFinalizedBlockHeight = common.JSONUint64(latestFinalizedBlock.Height)
-		result.LatestFinalizedBlockTime = (*common.JSONBig)(latestFinalizedBlock.Timestamp)
+		result.LatestFinalizedBlockTime = (*common.JSONBig)(latestFinalizedBlock.TimestampInSeconds())
 	}
 	result.CurrentEpoch = common.JSONUint64(s.Epoch)
 	result.CurrentTime = (*common.JSONBig)(big.NewInt(time.Now().Unix()))
@@ -529,6 +564,19 @@ func (t *ThetaRPCService) GetStatus(args *GetStatusArgs, result *GetStatusResult
 	result.SnapshotBlockHeight = common.JSONUint64(t.chain.Root().Block.BlockHeader.Height)
 	result.SnapshotBlockHash = t.chain.Root().Block.BlockHeader.Hash()
 
+	result.ActiveForks = []string{}
+	result.PendingForks = []Fork{}
+	for _, fork := range scom.GetForkSchedule().Forks() {
+		if fork.Height <= uint64(result.LatestFinalizedBlockHeight) {
+			result.ActiveForks = append(result.ActiveForks, fork.Name)
+		} else if fork.Height != math.MaxUint64 {
+			result.PendingForks = append(result.PendingForks, Fork{
+				Name:   fork.Name,
+				Height: common.JSONUint64(fork.Height),
+			})
+		}
+	}
+
 	return
 }
 
@@ -588,6 +636,7 @@ type GetValidatorSetResult struct {
 type ValidatorSet struct {
 	Dynasty    *big.Int
 	Validators []score.Validator
+	BLSPubKeys []score.ValidatorBLSPubKey
 }
 
 type BlockHashVSPair struct {
@@ -614,9 +663,7 @@ func (t *ThetaRPCService) GetValidatorSetByHeight(args *GetValidatorSetByHeightA
 		if blockStoreView == nil { // might have been pruned
 			return fmt.Errorf("the validator set for height %v does not exists, it might have been pruned", height)
 		}
-		vs := blockStoreView.GetValidatorSet()
-		valSet := ValidatorSet{Dynasty: vs.Dynasty()}
-		valSet.Validators = append(valSet.Validators, vs.Validators()...)
+		valSet := toValidatorSet(blockStoreView.GetValidatorSet())
 		hl := blockStoreView.GetValidatorSetUpdateTxHeightList()
 		blockHashVSPairs = append(blockHashVSPairs, BlockHashVSPair{
 			BlockHash:    blockHash,
@@ -630,6 +677,471 @@ func (t *ThetaRPCService) GetValidatorSetByHeight(args *GetValidatorSetByHeightA
 	return nil
 }
 
+// ------------------------------ GetValidatorSetByDynasty -----------------------------------
+
+type GetValidatorSetByDynastyArgs struct {
+	Dynasty *common.JSONBig `json:"dynasty"`
+}
+
+type GetValidatorSetByDynastyResult struct {
+	ValidatorSet ValidatorSet `json:"validator_set"`
+}
+
+// GetValidatorSetByDynasty returns the validator set of the given dynasty as registered on the mainchain
+func (t *ThetaRPCService) GetValidatorSetByDynasty(args *GetValidatorSetByDynastyArgs, result *GetValidatorSetByDynastyResult) (err error) {
+	if args.Dynasty == nil {
+		return errors.New("dynasty is required")
+	}
+	vs, err := t.consensus.GetMetachainWitness().GetValidatorSetByDynasty((*big.Int)(args.Dynasty))
+	if err != nil {
+		return err
+	}
+	result.ValidatorSet = toValidatorSet(vs)
+	return nil
+}
+
+// ------------------------------ GetDynastyTransition -----------------------------------
+
+type GetDynastyTransitionArgs struct {
+}
+
+type ValidatorStakeChange struct {
+	Address      common.Address  `json:"address"`
+	CurrentStake *common.JSONBig `json:"current_stake"`
+	NextStake    *common.JSONBig `json:"next_stake"`
+}
+
+type GetDynastyTransitionResult struct {
+	MainchainHeight            *common.JSONBig        `json:"mainchain_height"`
+	CurrentDynasty             *common.JSONBig        `json:"current_dynasty"`
+	NextDynasty                *common.JSONBig        `json:"next_dynasty"`
+	NextDynastyMainchainHeight *common.JSONBig        `json:"next_dynasty_mainchain_height"` // mainchain height at which the next dynasty starts
+	CurrentValidatorSet        ValidatorSet           `json:"current_validator_set"`         // validator set in the finalized subchain state
+	NextValidatorSet           ValidatorSet           `json:"next_validator_set"`            // validator set of the next dynasty registered on the mainchain
+	JoiningValidators          []score.Validator      `json:"joining_validators"`
+	LeavingValidators          []score.Validator      `json:"leaving_validators"`
+	StakeChanges               []ValidatorStakeChange `json:"stake_changes"`
+}
+
+// GetDynastyTransition returns the current and the next validator sets, the mainchain height at which the
+// next dynasty starts, and the changes between the two validator sets
+func (t *ThetaRPCService) GetDynastyTransition(args *GetDynastyTransitionArgs, result *GetDynastyTransitionResult) (err error) {
+	metachainWitness := t.consensus.GetMetachainWitness()
+	mainchainHeight, err := metachainWitness.GetMainchainBlockHeight()
+	if err != nil {
+		return err
+	}
+	currentDynasty := scom.CalculateDynasty(mainchainHeight)
+	nextDynasty := new(big.Int).Add(currentDynasty, big.NewInt(1))
+	nextDynastyMainchainHeight := scom.DynastyStartHeight(nextDynasty)
+
+	finalizedView, err := t.ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return err
+	}
+	currentVS := finalizedView.GetValidatorSet()
+	if currentVS == nil {
+		return errors.New("failed to retrieve the current validator set")
+	}
+	nextVS, err := metachainWitness.GetValidatorSetByDynasty(nextDynasty)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the validator set of dynasty %v: %v", nextDynasty, err)
+	}
+
+	result.MainchainHeight = (*common.JSONBig)(mainchainHeight)
+	result.CurrentDynasty = (*common.JSONBig)(currentDynasty)
+	result.NextDynasty = (*common.JSONBig)(nextDynasty)
+	result.NextDynastyMainchainHeight = (*common.JSONBig)(nextDynastyMainchainHeight)
+	result.CurrentValidatorSet = toValidatorSet(currentVS)
+	result.NextValidatorSet = toValidatorSet(nextVS)
+
+	result.JoiningValidators = []score.Validator{}
+	result.LeavingValidators = []score.Validator{}
+	result.StakeChanges = []ValidatorStakeChange{}
+	for _, v := range nextVS.Validators() {
+		current, err := currentVS.GetValidator(v.Address)
+		if err != nil {
+			result.JoiningValidators = append(result.JoiningValidators, v)
+		} else if current.Stake.Cmp(v.Stake) != 0 {
+			result.StakeChanges = append(result.StakeChanges, ValidatorStakeChange{
+				Address:      v.Address,
+				CurrentStake: (*common.JSONBig)(current.Stake),
+				NextStake:    (*common.JSONBig)(v.Stake),
+			})
+		}
+	}
+	for _, v := range currentVS.Validators() {
+		if _, err := nextVS.GetValidator(v.Address); err != nil {
+			result.LeavingValidators = append(result.LeavingValidators, v)
+		}
+	}
+
+	return nil
+}
+
+func toValidatorSet(vs *score.ValidatorSet) ValidatorSet {
+	valSet := ValidatorSet{Dynasty: vs.Dynasty()}
+	valSet.Validators = append(valSet.Validators, vs.Validators()...)
+	valSet.BLSPubKeys = append(valSet.BLSPubKeys, vs.BLSPubKeys()...)
+	return valSet
+}
+
+// ------------------------------ GetRewardSchedule -----------------------------------
+
+type GetRewardScheduleArgs struct {
+}
+
+type RewardScheduleEntry struct {
+	StartHeight          common.JSONUint64 `json:"start_height"`
+	StakerRewardPerBlock *common.JSONBig   `json:"staker_reward_per_block"`
+}
+
+type AccruedStakerReward struct {
+	Address common.Address  `json:"address"`
+	Amount  *common.JSONBig `json:"amount"`
+}
+
+type GetRewardScheduleResult struct {
+	Height                      common.JSONUint64     `json:"height"`           // height of the finalized state
+	Enabled                     bool                  `json:"enabled"`          // false if the chain pays no rewards
+	GovernanceToken             common.Address        `json:"governance_token"` // address of the governance token on the mainchain
+	Entries                     []RewardScheduleEntry `json:"entries"`
+	CurrentStakerRewardPerBlock *common.JSONBig       `json:"current_staker_reward_per_block"` // in the governance token
+	CollectedFees               *common.JSONBig       `json:"collected_fees"`                  // in TFuelWei, to be paid by the next coinbase transaction
+	AccruedStakerRewards        []AccruedStakerReward `json:"accrued_staker_rewards"`          // of the current validators, to be minted on the mainchain
+}
+
+// GetRewardSchedule returns the reward schedule of the coinbase transactions, together with the gas fees
+// pending distribution and the staker rewards accrued by the current validators in the finalized state
+func (t *ThetaRPCService) GetRewardSchedule(args *GetRewardScheduleArgs, result *GetRewardScheduleResult) (err error) {
+	finalizedView, err := t.ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return err
+	}
+	height := finalizedView.Height()
+	result.Height = common.JSONUint64(height)
+
+	schedule := finalizedView.GetRewardSchedule()
+	if schedule == nil {
+		return nil
+	}
+	result.Enabled = true
+	result.GovernanceToken = schedule.GovernanceToken
+	result.Entries = []RewardScheduleEntry{}
+	for _, entry := range schedule.Entries {
+		result.Entries = append(result.Entries, RewardScheduleEntry{
+			StartHeight:          common.JSONUint64(entry.StartHeight),
+			StakerRewardPerBlock: (*common.JSONBig)(entry.StakerRewardPerBlock),
+		})
+	}
+	result.CurrentStakerRewardPerBlock = (*common.JSONBig)(schedule.StakerRewardPerBlock(height))
+	result.CollectedFees = (*common.JSONBig)(finalizedView.GetCollectedFees())
+
+	result.AccruedStakerRewards = []AccruedStakerReward{}
+	if vs := finalizedView.GetValidatorSet(); vs != nil {
+		for _, v := range vs.Validators() {
+			result.AccruedStakerRewards = append(result.AccruedStakerRewards, AccruedStakerReward{
+				Address: v.Address,
+				Amount:  (*common.JSONBig)(finalizedView.GetAccruedStakerReward(v.Address)),
+			})
+		}
+	}
+
+	return nil
+}
+
+// ------------------------------ GetDelegations -----------------------------------
+
+type GetDelegationsArgs struct {
+	Validator common.Address `json:"validator"`
+}
+
+type Delegation struct {
+	Staker common.Address  `json:"staker"`
+	Shares *common.JSONBig `json:"shares"`
+}
+
+type GetDelegationsResult struct {
+	Height                      common.JSONUint64 `json:"height"` // height of the finalized state
+	Validator                   common.Address    `json:"validator"`
+	CommissionRateInBasisPoints common.JSONUint64 `json:"commission_rate_in_basis_points"`
+	LastMirroredMainchainHeight *common.JSONBig   `json:"last_mirrored_mainchain_height"` // the stake events are mirrored up to this mainchain height
+	Delegations                 []Delegation      `json:"delegations"`
+}
+
+// GetDelegations returns the commission rate of the validator and the shares delegated to it, as mirrored
+// from the mainchain stake events in the finalized state
+func (t *ThetaRPCService) GetDelegations(args *GetDelegationsArgs, result *GetDelegationsResult) (err error) {
+	finalizedView, err := t.ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return err
+	}
+	result.Height = common.JSONUint64(finalizedView.Height())
+	result.Validator = args.Validator
+	result.CommissionRateInBasisPoints = common.JSONUint64(finalizedView.GetCommissionRate(args.Validator))
+	result.LastMirroredMainchainHeight = (*common.JSONBig)(finalizedView.GetLastMirroredMainchainHeight())
+	result.Delegations = []Delegation{}
+	for _, delegation := range finalizedView.GetDelegations(args.Validator) {
+		result.Delegations = append(result.Delegations, Delegation{
+			Staker: delegation.Staker,
+			Shares: (*common.JSONBig)(delegation.Shares),
+		})
+	}
+
+	return nil
+}
+
+// ------------------------------ GetNativeStakes -----------------------------------
+
+type GetNativeStakesArgs struct {
+	Validator common.Address `json:"validator"`
+}
+
+type NativeStake struct {
+	Staker common.Address  `json:"staker"`
+	Amount *common.JSONBig `json:"amount"`
+}
+
+type NativeUnbonding struct {
+	Staker        common.Address    `json:"staker"`
+	Amount        *common.JSONBig   `json:"amount"`
+	ReleaseHeight common.JSONUint64 `json:"release_height"`
+}
+
+type GetNativeStakesResult struct {
+	Height     common.JSONUint64 `json:"height"` // height of the finalized state
+	Validator  common.Address    `json:"validator"`
+	Stakes     []NativeStake     `json:"stakes"`
+	Unbondings []NativeUnbonding `json:"unbondings"`
+}
+
+// GetNativeStakes returns the stakes locked for the validator with the SubchainStakeTx, and the unstaked
+// amounts waiting for the end of the unbonding period in the finalized state
+func (t *ThetaRPCService) GetNativeStakes(args *GetNativeStakesArgs, result *GetNativeStakesResult) (err error) {
+	finalizedView, err := t.ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return err
+	}
+	result.Height = common.JSONUint64(finalizedView.Height())
+	result.Validator = args.Validator
+	result.Stakes = []NativeStake{}
+	for _, stake := range finalizedView.GetNativeStakes(args.Validator) {
+		result.Stakes = append(result.Stakes, NativeStake{
+			Staker: stake.Staker,
+			Amount: (*common.JSONBig)(stake.Shares),
+		})
+	}
+	result.Unbondings = []NativeUnbonding{}
+	for _, unbonding := range finalizedView.GetNativeUnbondings() {
+		if unbonding.Validator != args.Validator {
+			continue
+		}
+		result.Unbondings = append(result.Unbondings, NativeUnbonding{
+			Staker:        unbonding.Staker,
+			Amount:        (*common.JSONBig)(unbonding.Amount),
+			ReleaseHeight: common.JSONUint64(unbonding.ReleaseHeight),
+		})
+	}
+
+	return nil
+}
+
+// ------------------------------ GetClaimableReward -----------------------------------
+
+type GetClaimableRewardArgs struct {
+	Address common.Address `json:"address"`
+}
+
+type GetClaimableRewardResult struct {
+	Height common.JSONUint64 `json:"height"` // height of the finalized state
+	Amount *common.JSONBig   `json:"amount"` // in TFuelWei
+}
+
+// GetClaimableReward returns the gas fee rewards the address can claim with a SubchainRewardClaimTx
+func (t *ThetaRPCService) GetClaimableReward(args *GetClaimableRewardArgs, result *GetClaimableRewardResult) (err error) {
+	finalizedView, err := t.ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return err
+	}
+	result.Height = common.JSONUint64(finalizedView.Height())
+	result.Amount = (*common.JSONBig)(finalizedView.GetClaimableReward(args.Address))
+
+	return nil
+}
+
+// ------------------------------ GetSigningKeys -----------------------------------
+
+type GetSigningKeysArgs struct {
+	Validator common.Address `json:"validator"`
+}
+
+type SigningKey struct {
+	Key              common.Address  `json:"key"`
+	EffectiveDynasty *common.JSONBig `json:"effective_dynasty"`
+}
+
+type GetSigningKeysResult struct {
+	Height     common.JSONUint64 `json:"height"` // height of the finalized state
+	Validator  common.Address    `json:"validator"`
+	Dynasty    *common.JSONBig   `json:"dynasty"`
+	CurrentKey common.Address    `json:"current_key"` // the key the validator signs with in the current dynasty
+	Keys       []SigningKey      `json:"keys"`        // the keys the validator has rotated to
+}
+
+// GetSigningKeys returns the keys the validator has rotated to, and the key it signs with in the current dynasty
+func (t *ThetaRPCService) GetSigningKeys(args *GetSigningKeysArgs, result *GetSigningKeysResult) (err error) {
+	finalizedView, err := t.ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return err
+	}
+	dynasty := finalizedView.GetDynasty()
+	result.Height = common.JSONUint64(finalizedView.Height())
+	result.Validator = args.Validator
+	result.Dynasty = (*common.JSONBig)(dynasty)
+	result.CurrentKey = finalizedView.GetSigningKey(args.Validator, dynasty)
+	result.Keys = []SigningKey{}
+	for _, key := range finalizedView.GetSigningKeys(args.Validator) {
+		result.Keys = append(result.Keys, SigningKey{
+			Key:              key.Key,
+			EffectiveDynasty: (*common.JSONBig)(key.EffectiveDynasty),
+		})
+	}
+
+	return nil
+}
+
+// ------------------------------ GetGovernanceParams -----------------------------------
+
+type GetGovernanceParamsArgs struct{}
+
+type GovernanceParamValue struct {
+	ActivationHeight common.JSONUint64 `json:"activation_height"`
+	Value            string            `json:"value"`
+}
+
+type GovernanceParam struct {
+	Param        string                 `json:"param"`
+	CurrentValue string                 `json:"current_value"` // empty if the value is kept by a contract and never changed
+	Values       []GovernanceParamValue `json:"values"`        // the approved values, including the scheduled ones
+}
+
+type UpgradePlan struct {
+	Version   string            `json:"version"`
+	Height    common.JSONUint64 `json:"height"`
+	Supported bool              `json:"supported"` // whether the software version of the node satisfies the plan
+}
+
+type GetGovernanceParamsResult struct {
+	Height       common.JSONUint64 `json:"height"` // height of the finalized state
+	NumProposals common.JSONUint64 `json:"num_proposals"`
+	Params       []GovernanceParam `json:"params"`
+	UpgradePlans []UpgradePlan     `json:"upgrade_plans"`
+}
+
+// GetGovernanceParams returns the runtime parameters governed by the validators, with the values in effect
+// for the next block and the approved values scheduled to take effect
+func (t *ThetaRPCService) GetGovernanceParams(args *GetGovernanceParamsArgs, result *GetGovernanceParamsResult) (err error) {
+	finalizedView, err := t.ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return err
+	}
+	nextHeight := finalizedView.Height() + 1
+	result.Height = common.JSONUint64(finalizedView.Height())
+	result.NumProposals = common.JSONUint64(finalizedView.GetGovernanceProposalCount())
+	result.Params = []GovernanceParam{}
+	for _, param := range core.GovernanceParams() {
+		var currentValue string
+		switch param {
+		case core.GovParamMinimumGasPrice:
+			currentValue = finalizedView.GetMinimumGasPrice(nextHeight).String()
+		case core.GovParamMinBlockIntervalInMilliseconds:
+			currentValue = strconv.FormatInt(int64(finalizedView.GetMinBlockInterval(nextHeight)/time.Millisecond), 10)
+		case core.GovParamMaxNumRegularTxsPerBlock:
+			currentValue = strconv.Itoa(finalizedView.GetMaxNumRegularTxsPerBlock(nextHeight))
+		case core.GovParamBlockGasLimit:
+			currentValue = strconv.FormatUint(finalizedView.GetBlockGasLimit(nextHeight), 10)
+		default:
+			currentValue, _ = finalizedView.GetGovernanceParam(param, nextHeight)
+		}
+		values := []GovernanceParamValue{}
+		for _, v := range finalizedView.GetGovernanceParamValues(param) {
+			values = append(values, GovernanceParamValue{
+				ActivationHeight: common.JSONUint64(v.ActivationHeight),
+				Value:            v.Value,
+			})
+		}
+		result.Params = append(result.Params, GovernanceParam{
+			Param:        param,
+			CurrentValue: currentValue,
+			Values:       values,
+		})
+	}
+	result.UpgradePlans = []UpgradePlan{}
+	for _, plan := range finalizedView.GetUpgradePlans() {
+		result.UpgradePlans = append(result.UpgradePlans, UpgradePlan{
+			Version:   plan.Version,
+			Height:    common.JSONUint64(plan.Height),
+			Supported: plan.IsSupportedBy(sversion.Version),
+		})
+	}
+
+	return nil
+}
+
+// ------------------------------ GetGovernanceProposal -----------------------------------
+
+type GetGovernanceProposalArgs struct {
+	ID common.JSONUint64 `json:"id"`
+}
+
+type GetGovernanceProposalResult struct {
+	Height           common.JSONUint64 `json:"height"` // height of the finalized state
+	ID               common.JSONUint64 `json:"id"`
+	Proposer         common.Address    `json:"proposer"`
+	Param            string            `json:"param"`
+	Value            string            `json:"value"`
+	ActivationHeight common.JSONUint64 `json:"activation_height"`
+	Voters           []common.Address  `json:"voters"`
+	VotedStake       *common.JSONBig   `json:"voted_stake"` // stake of the voters in the current validator set
+	TotalStake       *common.JSONBig   `json:"total_stake"`
+	Approved         bool              `json:"approved"`
+	ApprovalHeight   common.JSONUint64 `json:"approval_height"`
+}
+
+// GetGovernanceProposal returns the governance proposal with the given ID, and the stake of its voters
+func (t *ThetaRPCService) GetGovernanceProposal(args *GetGovernanceProposalArgs, result *GetGovernanceProposalResult) (err error) {
+	finalizedView, err := t.ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return err
+	}
+	proposal := finalizedView.GetGovernanceProposal(uint64(args.ID))
+	if proposal == nil {
+		return fmt.Errorf("governance proposal %v does not exist", args.ID)
+	}
+
+	vs := finalizedView.GetValidatorSet()
+	votedStake := big.NewInt(0)
+	for _, v := range vs.Validators() {
+		if proposal.HasVoted(vs.ValidatorIdentity(v.ID())) {
+			votedStake.Add(votedStake, v.Stake)
+		}
+	}
+
+	result.Height = common.JSONUint64(finalizedView.Height())
+	result.ID = common.JSONUint64(proposal.ID)
+	result.Proposer = proposal.Proposer
+	result.Param = proposal.Param
+	result.Value = proposal.Value
+	result.ActivationHeight = common.JSONUint64(proposal.ActivationHeight)
+	result.Voters = proposal.Voters
+	result.VotedStake = (*common.JSONBig)(votedStake)
+	result.TotalStake = (*common.JSONBig)(vs.TotalStake())
+	result.Approved = proposal.Approved
+	result.ApprovalHeight = common.JSONUint64(proposal.ApprovalHeight)
+
+	return nil
+}
+
 // ------------------------------- GetTokenBankContractAddress -----------------------------------
 
 type GetTokenBankContractAddressArgs struct {
@@ -784,6 +1296,194 @@ func (t *ThetaRPCService) GetStorageAt(args *GetStorageAtArgs, result *GetStorag
 	return nil
 }
 
+// ------------------------------- GetWithdrawalProof -----------------------------------
+
+type GetWithdrawalProofArgs struct {
+	TokenType        core.CrossChainTokenType `json:"token_type"`
+	VoucherBurnNonce *common.JSONBig          `json:"voucher_burn_nonce"`
+	CheckpointHeight common.JSONUint64        `json:"checkpoint_height"` // optional, defaults to the latest finalized checkpoint
+}
+
+type GetWithdrawalProofResult struct {
+	Event            *score.InterChainMessageEvent `json:"event"`
+	CheckpointHeight common.JSONUint64             `json:"checkpoint_height"`
+	CheckpointHash   common.Hash                   `json:"checkpoint_hash"`
+	Dynasty          *common.JSONBig               `json:"dynasty"`
+	Proof            string                        `json:"proof"` // RLP encoded score.WithdrawalProof
+}
+
+func (t *ThetaRPCService) GetWithdrawalProof(args *GetWithdrawalProofArgs, result *GetWithdrawalProofResult) (err error) {
+	if args.VoucherBurnNonce == nil {
+		return errors.New("voucher_burn_nonce must be specified")
+	}
+	var eventType score.InterChainMessageEventType
+	switch args.TokenType {
+	case core.CrossChainTokenTypeTFuel:
+		eventType = score.IMCEventTypeCrossChainVoucherBurnTFuel
+	case core.CrossChainTokenTypeTNT20:
+		eventType = score.IMCEventTypeCrossChainVoucherBurnTNT20
+	case core.CrossChainTokenTypeTNT721:
+		eventType = score.IMCEventTypeCrossChainVoucherBurnTNT721
+	default:
+		return fmt.Errorf("unknown token type: %v", args.TokenType)
+	}
+
+	checkpointHeight := uint64(args.CheckpointHeight)
+	if checkpointHeight == 0 {
+		finalizedView, err := t.ledger.GetFinalizedSnapshot()
+		if err != nil {
+			return err
+		}
+		checkpointHeight = common.LastCheckPointHeight(finalizedView.Height())
+	}
+	if !common.IsCheckPointHeight(checkpointHeight) {
+		return fmt.Errorf("height %v is not a checkpoint height", checkpointHeight)
+	}
+
+	var checkpoint *score.ExtendedBlock
+	for _, b := range t.chain.FindBlocksByHeight(checkpointHeight) {
+		if b.Status.IsFinalized() {
+			checkpoint = b
+			break
+		}
+	}
+	if checkpoint == nil {
+		return fmt.Errorf("no finalized checkpoint block found at height %v", checkpointHeight)
+	}
+
+	// The votes that committed the checkpoint block are carried in the HCC of its child
+	var commitCert *score.CommitCertificate
+	for _, childHash := range checkpoint.Children {
+		child, err := t.chain.FindBlock(childHash)
+		if err != nil {
+			continue
+		}
+		if child.HCC.BlockHash == checkpoint.Hash() && !child.HCC.IsEmpty() {
+			cc := child.HCC.Copy()
+			commitCert = &cc
+			break
+		}
+	}
+	if commitCert == nil {
+		return fmt.Errorf("the commit certificate for checkpoint %v is not available yet", checkpoint.Hash().Hex())
+	}
+
+	deliveredView, err := t.ledger.GetDeliveredSnapshot()
+	if err != nil {
+		return err
+	}
+	checkpointView := slst.NewStoreView(checkpointHeight, checkpoint.StateHash, deliveredView.GetDB())
+	if checkpointView == nil { // might have been pruned
+		return fmt.Errorf("the state of checkpoint %v is not available, it might have been pruned", checkpointHeight)
+	}
+
+	nonce := args.VoucherBurnNonce.ToInt()
+	sourceChainID := scom.MapChainID(checkpoint.ChainID)
+	record := checkpointView.GetVoucherBurnRecord(sourceChainID, eventType, nonce)
+	if record == nil {
+		return fmt.Errorf("voucher burn %v of type %v is not recorded at checkpoint %v", nonce, eventType, checkpointHeight)
+	}
+
+	proof := &score.WithdrawalProof{
+		Event:            record,
+		CheckpointHeader: checkpoint.BlockHeader,
+		CommitCert:       *commitCert,
+	}
+	err = checkpointView.ProveVoucherBurnRecord(slst.VoucherBurnRecordKey(sourceChainID, eventType, nonce), &proof.RecordProof)
+	if err != nil {
+		return err
+	}
+	proofBytes, err := rlp.EncodeToBytes(proof)
+	if err != nil {
+		return err
+	}
+
+	result.Event = record
+	result.CheckpointHeight = common.JSONUint64(checkpointHeight)
+	result.CheckpointHash = checkpoint.Hash()
+	result.Dynasty = (*common.JSONBig)(checkpointView.GetDynasty())
+	result.Proof = hex.EncodeToString(proofBytes)
+
+	return nil
+}
+
+// ------------------------------- GetRelayStreamHealth -----------------------------------
+
+type GetRelayStreamHealthArgs struct{}
+
+type GetRelayStreamHealthResult struct {
+	Streams []orchestrator.RelayStreamHealth `json:"streams"`
+}
+
+func (t *ThetaRPCService) GetRelayStreamHealth(args *GetRelayStreamHealthArgs, result *GetRelayStreamHealthResult) (err error) {
+	if t.orchestrator == nil {
+		return errors.New("the orchestrator is not running on this node")
+	}
+	result.Streams = t.orchestrator.GetRelayStreamHealth()
+	return nil
+}
+
+// ------------------------------- GetRelayOutcomes -----------------------------------
+
+type GetRelayOutcomesArgs struct{}
+
+type GetRelayOutcomesResult struct {
+	Outcomes []orchestrator.RelayOutcome `json:"outcomes"`
+}
+
+func (t *ThetaRPCService) GetRelayOutcomes(args *GetRelayOutcomesArgs, result *GetRelayOutcomesResult) (err error) {
+	if t.orchestrator == nil {
+		return errors.New("the orchestrator is not running on this node")
+	}
+	result.Outcomes = t.orchestrator.GetRelayOutcomes()
+	return nil
+}
+
+// ------------------------------- GetRelayFeeReport -----------------------------------
+
+type GetRelayFeeReportArgs struct{}
+
+type GetRelayFeeReportResult struct {
+	*orchestrator.RelayFeeReport
+}
+
+func (t *ThetaRPCService) GetRelayFeeReport(args *GetRelayFeeReportArgs, result *GetRelayFeeReportResult) (err error) {
+	if t.orchestrator == nil {
+		return errors.New("the orchestrator is not running on this node")
+	}
+	result.RelayFeeReport = t.orchestrator.GetRelayFeeReport()
+	return nil
+}
+
+// ------------------------------- GetValidatorLiveness -----------------------------------
+
+type GetValidatorLivenessArgs struct {
+	Dynasty *common.JSONBig `json:"dynasty"` // optional, defaults to the dynasty of the last finalized block
+}
+
+type GetValidatorLivenessResult struct {
+	Liveness       *score.DynastyLiveness `json:"liveness"`
+	DowntimeReport *score.DowntimeReport  `json:"downtime_report"`
+}
+
+func (t *ThetaRPCService) GetValidatorLiveness(args *GetValidatorLivenessArgs, result *GetValidatorLivenessResult) (err error) {
+	tracker := t.consensus.GetLivenessTracker()
+	dynasty := (*big.Int)(args.Dynasty)
+	if dynasty == nil {
+		dynasty = tracker.GetLatestLivenessDynasty()
+		if dynasty == nil {
+			return errors.New("no finalized block has been processed for liveness tracking yet")
+		}
+	}
+
+	result.Liveness, err = tracker.GetDynastyLiveness(dynasty)
+	if err != nil {
+		return err
+	}
+	result.DowntimeReport, err = tracker.GetDowntimeReport(dynasty)
+	return err
+}
+
 // ------------------------------ Utils ------------------------------
 
 func (t *ThetaRPCService) gatherTxs(block *score.ExtendedBlock, txs *[]interface{}, includeEthTxHashes bool) error {
@@ -863,6 +1563,24 @@ func getTxType(tx types.Tx) byte {
 		t = TxTypeStakeRewardDistributionTx
 	case *stypes.SubchainValidatorSetUpdateTx:
 		t = TxSubchainValidatorSetUpdate
+	case *stypes.SubchainEquivocationEvidenceTx:
+		t = TxSubchainEquivocationEvidence
+	case *stypes.SubchainBLSKeyRegistrationTx:
+		t = TxSubchainBLSKeyRegistration
+	case *stypes.SubchainStakeEventsTx:
+		t = TxSubchainStakeEvents
+	case *stypes.SubchainCommissionRateUpdateTx:
+		t = TxSubchainCommissionRateUpdate
+	case *stypes.SubchainRewardClaimTx:
+		t = TxSubchainRewardClaim
+	case *stypes.SubchainGovernanceVoteTx:
+		t = TxSubchainGovernanceVote
+	case *stypes.SubchainUpgradePlanTx:
+		t = TxSubchainUpgradePlan
+	case *stypes.SubchainStakeTx:
+		t = TxSubchainStake
+	case *stypes.SubchainUnstakeTx:
+		t = TxSubchainUnstake
 	}
 
 	return t
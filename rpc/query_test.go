@@ -0,0 +1,119 @@
+package rpc
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/kvstore"
+
+	sbc "github.com/thetatoken/thetasubchain/blockchain"
+	scom "github.com/thetatoken/thetasubchain/common"
+	sconsensus "github.com/thetatoken/thetasubchain/consensus"
+	score "github.com/thetatoken/thetasubchain/core"
+	"github.com/thetatoken/thetasubchain/interchain/witness"
+	sld "github.com/thetatoken/thetasubchain/ledger"
+)
+
+// testChainWitness serves the mainchain height and the validator sets registered on the mainchain
+type testChainWitness struct {
+	witness.ChainWitness
+	mainchainHeight *big.Int
+	validatorSets   map[string]*score.ValidatorSet
+}
+
+func (w *testChainWitness) GetMainchainBlockHeight() (*big.Int, error) {
+	return w.mainchainHeight, nil
+}
+
+func (w *testChainWitness) GetValidatorSetByDynasty(dynasty *big.Int) (*score.ValidatorSet, error) {
+	vs, ok := w.validatorSets[dynasty.String()]
+	if !ok {
+		return nil, errors.New("validator set not found")
+	}
+	return vs, nil
+}
+
+func newTestValidatorSet(dynasty int64, stakes map[common.Address]int64) *score.ValidatorSet {
+	vs := score.NewValidatorSet(big.NewInt(dynasty))
+	for addr, stake := range stakes {
+		vs.AddValidator(score.NewValidator(addr.Hex(), big.NewInt(stake)))
+	}
+	return vs
+}
+
+func TestGetDynastyTransition(t *testing.T) {
+	assert := assert.New(t)
+
+	valA := common.HexToAddress("0x000000000000000000000000000000000000000a")
+	valB := common.HexToAddress("0x000000000000000000000000000000000000000b")
+	valC := common.HexToAddress("0x000000000000000000000000000000000000000c")
+	valD := common.HexToAddress("0x000000000000000000000000000000000000000d")
+
+	// In dynasty 11, the validator D joins, C leaves, and the stake of B doubles
+	vs10 := newTestValidatorSet(10, map[common.Address]int64{valA: 100, valB: 100, valC: 100})
+	vs11 := newTestValidatorSet(11, map[common.Address]int64{valA: 100, valB: 200, valD: 100})
+	vs12 := newTestValidatorSet(12, map[common.Address]int64{valA: 100, valB: 200, valD: 100})
+	mcw := &testChainWitness{
+		mainchainHeight: new(big.Int).Add(scom.DynastyStartHeight(big.NewInt(10)), big.NewInt(5)),
+		validatorSets:   map[string]*score.ValidatorSet{"10": vs10, "11": vs11, "12": vs12},
+	}
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := sbc.CreateTestChain()
+	consensus := sconsensus.NewConsensusEngine(privKey, kvstore.NewKVStore(backend.NewMemDatabase()), chain, nil, nil, mcw)
+	ledger := sld.NewLedger(chain.ChainID, backend.NewMemDatabase(), nil, chain, consensus, nil, nil, mcw)
+	ledger.State().ResetState(score.NewBlock())
+
+	// finalizeValidatorSet finalizes the validator set in the subchain state, as the validator set update tx does
+	finalizeValidatorSet := func(vs *score.ValidatorSet) {
+		view := ledger.State().Delivered()
+		view.UpdateValidatorSet(scom.MapChainID(chain.ChainID), vs)
+		stateHash := view.Save()
+		assert.True(ledger.State().Finalize(view.Height(), stateHash).IsOK())
+	}
+	finalizeValidatorSet(vs10)
+
+	service := &ThetaRPCService{ledger: ledger, consensus: consensus}
+
+	// Before the validator set update, the changes of the next dynasty are reported
+	result := &GetDynastyTransitionResult{}
+	assert.Nil(service.GetDynastyTransition(&GetDynastyTransitionArgs{}, result))
+	assert.Equal(0, mcw.mainchainHeight.Cmp((*big.Int)(result.MainchainHeight)))
+	assert.Equal(0, big.NewInt(10).Cmp((*big.Int)(result.CurrentDynasty)))
+	assert.Equal(0, big.NewInt(11).Cmp((*big.Int)(result.NextDynasty)))
+	assert.Equal(0, scom.DynastyStartHeight(big.NewInt(11)).Cmp((*big.Int)(result.NextDynastyMainchainHeight)))
+	assert.Equal(toValidatorSet(vs10), result.CurrentValidatorSet)
+	assert.Equal(toValidatorSet(vs11), result.NextValidatorSet)
+	assert.Equal([]score.Validator{score.NewValidator(valD.Hex(), big.NewInt(100))}, result.JoiningValidators)
+	assert.Equal([]score.Validator{score.NewValidator(valC.Hex(), big.NewInt(100))}, result.LeavingValidators)
+	assert.Equal(1, len(result.StakeChanges))
+	assert.Equal(valB, result.StakeChanges[0].Address)
+	assert.Equal(0, big.NewInt(100).Cmp((*big.Int)(result.StakeChanges[0].CurrentStake)))
+	assert.Equal(0, big.NewInt(200).Cmp((*big.Int)(result.StakeChanges[0].NextStake)))
+
+	// Once dynasty 11 has started and its validator set is finalized, the next transition changes nothing
+	mcw.mainchainHeight = scom.DynastyStartHeight(big.NewInt(11))
+	finalizeValidatorSet(vs11)
+	result = &GetDynastyTransitionResult{}
+	assert.Nil(service.GetDynastyTransition(&GetDynastyTransitionArgs{}, result))
+	assert.Equal(0, big.NewInt(11).Cmp((*big.Int)(result.CurrentDynasty)))
+	assert.Equal(0, big.NewInt(12).Cmp((*big.Int)(result.NextDynasty)))
+	assert.Equal(0, scom.DynastyStartHeight(big.NewInt(12)).Cmp((*big.Int)(result.NextDynastyMainchainHeight)))
+	assert.Equal(toValidatorSet(vs11), result.CurrentValidatorSet)
+	assert.Equal(toValidatorSet(vs12), result.NextValidatorSet)
+	assert.Equal(0, len(result.JoiningValidators))
+	assert.Equal(0, len(result.LeavingValidators))
+	assert.Equal(0, len(result.StakeChanges))
+
+	// The next validator set may not be registered on the mainchain yet
+	mcw.mainchainHeight = scom.DynastyStartHeight(big.NewInt(12))
+	assert.NotNil(service.GetDynastyTransition(&GetDynastyTransitionArgs{}, &GetDynastyTransitionResult{}))
+}
@@ -26,6 +26,7 @@ import (
 
 	sbc "github.com/thetatoken/thetasubchain/blockchain"
 	sconsensus "github.com/thetatoken/thetasubchain/consensus"
+	"github.com/thetatoken/thetasubchain/interchain/orchestrator"
 	sld "github.com/thetatoken/thetasubchain/ledger"
 	smp "github.com/thetatoken/thetasubchain/mempool"
 )
@@ -39,6 +40,8 @@ type ThetaRPCService struct {
 	chain      *sbc.Chain
 	consensus  *sconsensus.ConsensusEngine
 
+	orchestrator *orchestrator.Orchestrator
+
 	// Life cycle
 	wg      *sync.WaitGroup
 	ctx     context.Context
@@ -58,7 +61,7 @@ type ThetaRPCServer struct {
 
 // NewThetaRPCServer creates a new instance of ThetaRPCServer.
 func NewThetaRPCServer(mempool *smp.Mempool, ledger *sld.Ledger, dispatcher *dispatcher.Dispatcher,
-	chain *sbc.Chain, consensus *sconsensus.ConsensusEngine) *ThetaRPCServer {
+	chain *sbc.Chain, consensus *sconsensus.ConsensusEngine, orchestrator *orchestrator.Orchestrator) *ThetaRPCServer {
 	t := &ThetaRPCServer{
 		ThetaRPCService: &ThetaRPCService{
 			wg: &sync.WaitGroup{},
@@ -70,6 +73,7 @@ func NewThetaRPCServer(mempool *smp.Mempool, ledger *sld.Ledger, dispatcher *dis
 	t.dispatcher = dispatcher
 	t.chain = chain
 	t.consensus = consensus
+	t.orchestrator = orchestrator
 
 	s := rpc.NewServer()
 	s.RegisterName("theta", t.ThetaRPCService)
@@ -0,0 +1,16 @@
+package signer
+
+import (
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/crypto/bls"
+)
+
+// DeriveBLSKey derives the BLS key of a validator from its private key, so that no additional key needs
+// to be generated and backed up. The derivation is one-way, the BLS key reveals nothing of the private key.
+func DeriveBLSKey(privateKey *crypto.PrivateKey) (*bls.SecretKey, error) {
+	seed := ethcrypto.NewKeccakState()
+	seed.Write([]byte("thetasubchain/bls"))
+	seed.Write(privateKey.ToBytes())
+	return bls.GenKey(seed)
+}
@@ -0,0 +1,193 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+)
+
+const (
+	FailoverModePrimary = "primary"
+	FailoverModeStandby = "standby"
+)
+
+// ErrStandingBy is returned for the signing requests while the node does not hold the signing lease
+var ErrStandingBy = errors.New("standing by, the signing lease is held by another node")
+
+var _ score.Signer = (*FailoverSigner)(nil)
+var _ score.StandbySigner = (*FailoverSigner)(nil)
+
+// signingLease decides which node of an active/standby pair signs for the validator
+type signingLease interface {
+	// start keeps acquiring or renewing the lease until the context is canceled
+	start(ctx context.Context)
+
+	// held returns whether the node holds the lease, along with the number of times it has acquired the
+	// lease, which tells a new term from the current one
+	held() (bool, uint64)
+
+	// inheritedMarks returns the high-water marks of the previous holder, and whether they may be outdated
+	inheritedMarks() (*highWaterMarks, bool)
+
+	// record makes the high-water marks of the holder available to the next holder before a signature is released
+	record(hwm *highWaterMarks) error
+}
+
+// FailoverSigner lets a hot-standby node follow the chain with the validator key without signing, and take
+// over once it acquires the signing lease. Before its first signature in a term, it adopts the high-water
+// marks of the previous holder, so that it never signs a vote or a proposal conflicting with the ones
+// signed by the other node.
+type FailoverSigner struct {
+	signer score.Signer
+	lease  signingLease
+	guard  *guard
+
+	mu          *sync.Mutex
+	adoptedTerm uint64
+}
+
+// NewFailoverSignerFromConfig wraps the signer with a failover signer if the failover is configured,
+// otherwise returns the signer as is
+func NewFailoverSignerFromConfig(ctx context.Context, signer score.Signer) (score.Signer, error) {
+	mode := viper.GetString(scom.CfgSubchainFailoverMode)
+	if mode == "" {
+		return signer, nil
+	}
+	if mode != FailoverModePrimary && mode != FailoverModeStandby {
+		return nil, fmt.Errorf("invalid failover mode: %v", mode)
+	}
+
+	g, err := newGuard(viper.GetString(scom.CfgSubchainFailoverStatePath))
+	if err != nil {
+		return nil, err
+	}
+
+	duration := time.Duration(viper.GetInt(scom.CfgSubchainFailoverLeaseDurationInMilliseconds)) * time.Millisecond
+	var lease signingLease
+	if leaseFile := viper.GetString(scom.CfgSubchainFailoverLeaseFile); leaseFile != "" {
+		lease = newFileLease(leaseFile, mode, duration)
+	} else if address := viper.GetString(scom.CfgSubchainFailoverHeartbeatAddress); address != "" {
+		lease = newHeartbeatLease(address, mode, duration, signer.Address(), g)
+	} else {
+		return nil, fmt.Errorf("either the lease file or the heartbeat address needs to be configured for the failover")
+	}
+
+	fs := &FailoverSigner{
+		signer: signer,
+		lease:  lease,
+		guard:  g,
+		mu:     &sync.Mutex{},
+	}
+	lease.start(ctx)
+	logger.Infof("Failover enabled, mode: %v, lease duration: %v", mode, duration)
+	return fs, nil
+}
+
+// IsStandingBy implements the StandbySigner interface
+func (fs *FailoverSigner) IsStandingBy() bool {
+	held, _ := fs.lease.held()
+	return !held
+}
+
+// Address implements the Signer interface
+func (fs *FailoverSigner) Address() common.Address {
+	return fs.signer.Address()
+}
+
+// SignVote implements the Signer interface
+func (fs *FailoverSigner) SignVote(vote *score.Vote) error {
+	if err := fs.checkVote(vote); err != nil {
+		return err
+	}
+	return fs.signer.SignVote(vote)
+}
+
+// SignVoteBLS implements the Signer interface
+func (fs *FailoverSigner) SignVoteBLS(vote *score.Vote) error {
+	if err := fs.checkVote(vote); err != nil {
+		return err
+	}
+	return fs.signer.SignVoteBLS(vote)
+}
+
+// BLSPubKey implements the Signer interface
+func (fs *FailoverSigner) BLSPubKey() (common.Bytes, common.Bytes, error) {
+	return fs.signer.BLSPubKey()
+}
+
+// SignProposal implements the Signer interface
+func (fs *FailoverSigner) SignProposal(header *score.BlockHeader) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.prepare(); err != nil {
+		return err
+	}
+	if err := fs.guard.checkProposal(header); err != nil {
+		return err
+	}
+	if err := fs.lease.record(fs.guard.marks()); err != nil {
+		return err
+	}
+	return fs.signer.SignProposal(header)
+}
+
+// ProveVRF implements the Signer interface
+func (fs *FailoverSigner) ProveVRF(alpha []byte) (common.Bytes, error) {
+	return fs.signer.ProveVRF(alpha)
+}
+
+// SignTx implements the Signer interface
+func (fs *FailoverSigner) SignTx(chainID string, tx types.Tx) (*crypto.Signature, error) {
+	if fs.IsStandingBy() {
+		return nil, ErrStandingBy
+	}
+	return fs.signer.SignTx(chainID, tx)
+}
+
+func (fs *FailoverSigner) checkVote(vote *score.Vote) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.prepare(); err != nil {
+		return err
+	}
+	if err := fs.guard.checkVote(vote); err != nil {
+		return err
+	}
+	return fs.lease.record(fs.guard.marks())
+}
+
+// prepare checks the lease is held, and adopts the high-water marks of the previous holder at the
+// beginning of each term
+func (fs *FailoverSigner) prepare() error {
+	held, term := fs.lease.held()
+	if !held {
+		return ErrStandingBy
+	}
+	if fs.adoptedTerm == term {
+		return nil
+	}
+
+	inherited, outdated := fs.lease.inheritedMarks()
+	if inherited != nil {
+		if err := fs.guard.raise(inherited, outdated); err != nil {
+			return err
+		}
+	}
+	fs.adoptedTerm = term
+	hwm := fs.guard.marks()
+	logger.Infof("Acquired the signing lease, vote epoch high-water mark: %v, proposal epoch high-water mark: %v",
+		hwm.VoteEpoch, hwm.ProposalEpoch)
+	return nil
+}
@@ -0,0 +1,155 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// testLease is a signing lease whose state is set by the tests
+type testLease struct {
+	isHeld    bool
+	term      uint64
+	inherited *highWaterMarks
+	outdated  bool
+	recordErr error
+	recorded  []*highWaterMarks
+}
+
+func (tl *testLease) start(ctx context.Context) {}
+
+func (tl *testLease) held() (bool, uint64) { return tl.isHeld, tl.term }
+
+func (tl *testLease) inheritedMarks() (*highWaterMarks, bool) { return tl.inherited, tl.outdated }
+
+func (tl *testLease) record(hwm *highWaterMarks) error {
+	if tl.recordErr != nil {
+		return tl.recordErr
+	}
+	tl.recorded = append(tl.recorded, hwm)
+	return nil
+}
+
+func newTestFailoverSigner(t *testing.T) (*FailoverSigner, *testLease, func()) {
+	privKey, _, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, _, cleanup := newTestGuard(t)
+	lease := &testLease{}
+	fs := &FailoverSigner{
+		signer: NewLocalSigner(privKey),
+		lease:  lease,
+		guard:  g,
+		mu:     &sync.Mutex{},
+	}
+	return fs, lease, cleanup
+}
+
+func TestFailoverSignerStandby(t *testing.T) {
+	assert := assert.New(t)
+
+	fs, lease, cleanup := newTestFailoverSigner(t)
+	defer cleanup()
+
+	// A standby without the lease signs nothing, and records no marks
+	assert.True(fs.IsStandingBy())
+	vote := newTestVote(5, 10, "a1")
+	assert.Equal(ErrStandingBy, fs.SignVote(vote))
+	assert.Nil(vote.Signature)
+	assert.Equal(ErrStandingBy, fs.SignVoteBLS(vote))
+	header := newTestHeader(5, 10, "b1")
+	assert.Equal(ErrStandingBy, fs.SignProposal(header))
+	assert.Nil(header.Signature)
+	_, err := fs.SignTx("tsub_test", &types.SendTx{})
+	assert.Equal(ErrStandingBy, err)
+	assert.Equal(0, len(lease.recorded))
+	assert.Equal(uint64(0), fs.guard.marks().VoteEpoch)
+
+	// Once the lease is acquired, the signer signs
+	lease.isHeld, lease.term = true, 1
+	assert.False(fs.IsStandingBy())
+	assert.Nil(fs.SignVote(vote))
+	assert.NotNil(vote.Signature)
+	assert.Nil(fs.SignProposal(header))
+	assert.NotNil(header.Signature)
+	assert.Equal(2, len(lease.recorded))
+	assert.Equal(uint64(5), lease.recorded[1].ProposalEpoch)
+}
+
+func TestFailoverSignerTakeover(t *testing.T) {
+	assert := assert.New(t)
+
+	fs, lease, cleanup := newTestFailoverSigner(t)
+	defer cleanup()
+
+	// The previous holder voted for a1 at height 10 in epoch 5, and proposed in epoch 5
+	lease.isHeld, lease.term = true, 1
+	lease.inherited = &highWaterMarks{
+		VoteEpoch:     5,
+		VoteBlocks:    map[uint64]common.Hash{10: common.HexToHash("a1")},
+		ProposalEpoch: 5,
+	}
+
+	tests := []struct {
+		name  string
+		vote  *voteSpec
+		valid bool
+	}{
+		{"conflicting vote of the previous holder", &voteSpec{5, 10, "a2"}, false},
+		{"lower epoch", &voteSpec{4, 11, "a3"}, false},
+		{"same vote as the previous holder", &voteSpec{5, 10, "a1"}, true},
+		{"next height", &voteSpec{5, 11, "a3"}, true},
+	}
+	for _, test := range tests {
+		vote := newTestVote(test.vote.epoch, test.vote.height, test.vote.block)
+		err := fs.SignVote(vote)
+		assert.Equal(test.valid, err == nil, "%v: %v", test.name, err)
+		assert.Equal(test.valid, vote.Signature != nil, test.name)
+	}
+	assert.NotNil(fs.SignProposal(newTestHeader(5, 12, "b2")), "second proposal in the epoch of the previous holder")
+
+	// Marks that may be outdated skip the epochs they cover in the next term
+	lease.term = 2
+	lease.inherited = &highWaterMarks{VoteEpoch: 8, ProposalEpoch: 8}
+	lease.outdated = true
+	assert.NotNil(fs.SignVote(newTestVote(8, 20, "a4")))
+	assert.Nil(fs.SignVote(newTestVote(9, 20, "a4")))
+}
+
+// voteSpec describes a vote of the test table
+type voteSpec struct {
+	epoch  uint64
+	height uint64
+	block  string
+}
+
+func TestFailoverSignerLostLease(t *testing.T) {
+	assert := assert.New(t)
+
+	fs, lease, cleanup := newTestFailoverSigner(t)
+	defer cleanup()
+
+	lease.isHeld, lease.term = true, 1
+	assert.Nil(fs.SignVote(newTestVote(5, 10, "a1")))
+
+	// If the marks cannot be recorded into the lease, e.g. another node has taken it over, nothing is signed
+	lease.recordErr = ErrStandingBy
+	vote := newTestVote(5, 11, "a2")
+	assert.Equal(ErrStandingBy, fs.SignVote(vote))
+	assert.Nil(vote.Signature)
+	header := newTestHeader(6, 12, "b1")
+	assert.Equal(ErrStandingBy, fs.SignProposal(header))
+	assert.Nil(header.Signature)
+
+	lease.recordErr = errors.New("shared storage unavailable")
+	vote = newTestVote(6, 12, "a3")
+	assert.NotNil(fs.SignVote(vote))
+	assert.Nil(vote.Signature)
+}
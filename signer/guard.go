@@ -0,0 +1,207 @@
+package signer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+
+	score "github.com/thetatoken/thetasubchain/core"
+)
+
+// highWaterMarks records the latest votes and proposal signed by the remote signer
+type highWaterMarks struct {
+	VoteEpoch        uint64                 `json:"vote_epoch"`
+	VoteBlocks       map[uint64]common.Hash `json:"vote_blocks"` // height -> block voted in VoteEpoch
+	ProposalEpoch    uint64                 `json:"proposal_epoch"`
+	ProposalSignHash common.Hash            `json:"proposal_sign_hash"`
+}
+
+// guard refuses to sign the votes and proposals conflicting with the ones signed before. It never
+// signs for an epoch lower than the high-water marks, never signs two different blocks at the same
+// height in the same epoch, and never signs two different proposals in the same epoch. The marks are
+// persisted before a signature is released, so that the guarantee holds across restarts, and does not
+// depend on the state of the node requesting the signatures.
+type guard struct {
+	mu   *sync.Mutex
+	path string
+	hwm  *highWaterMarks
+}
+
+func newGuard(path string) (*guard, error) {
+	g := &guard{
+		mu:   &sync.Mutex{},
+		path: path,
+		hwm:  &highWaterMarks{VoteBlocks: make(map[uint64]common.Hash)},
+	}
+
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return g, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, g.hwm); err != nil {
+		return nil, fmt.Errorf("failed to parse the signer state %v: %v", path, err)
+	}
+	if g.hwm.VoteBlocks == nil {
+		g.hwm.VoteBlocks = make(map[uint64]common.Hash)
+	}
+	return g, nil
+}
+
+// checkVote returns an error if signing the vote could conflict with a signed vote, otherwise records the vote
+func (g *guard) checkVote(vote *score.Vote) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if vote.Epoch < g.hwm.VoteEpoch {
+		return fmt.Errorf("vote epoch %v is below the high-water mark %v", vote.Epoch, g.hwm.VoteEpoch)
+	}
+	if vote.Epoch == g.hwm.VoteEpoch {
+		if block, ok := g.hwm.VoteBlocks[vote.Height]; ok {
+			if block != vote.Block {
+				return fmt.Errorf("already voted for block %v at height %v in epoch %v", block.Hex(), vote.Height, vote.Epoch)
+			}
+			return nil
+		}
+	}
+
+	hwm := &highWaterMarks{
+		VoteEpoch:        vote.Epoch,
+		VoteBlocks:       make(map[uint64]common.Hash),
+		ProposalEpoch:    g.hwm.ProposalEpoch,
+		ProposalSignHash: g.hwm.ProposalSignHash,
+	}
+	if vote.Epoch == g.hwm.VoteEpoch {
+		for height, block := range g.hwm.VoteBlocks {
+			hwm.VoteBlocks[height] = block
+		}
+	}
+	hwm.VoteBlocks[vote.Height] = vote.Block
+	return g.commit(hwm)
+}
+
+// checkProposal returns an error if signing the header could conflict with a signed proposal, otherwise records the proposal
+func (g *guard) checkProposal(header *score.BlockHeader) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	signHash := crypto.Keccak256Hash(header.SignBytes())
+	if header.Epoch < g.hwm.ProposalEpoch {
+		return fmt.Errorf("proposal epoch %v is below the high-water mark %v", header.Epoch, g.hwm.ProposalEpoch)
+	}
+	if header.Epoch == g.hwm.ProposalEpoch && !g.hwm.ProposalSignHash.IsEmpty() {
+		if signHash != g.hwm.ProposalSignHash {
+			return fmt.Errorf("already signed a different proposal in epoch %v", header.Epoch)
+		}
+		return nil
+	}
+
+	hwm := &highWaterMarks{
+		VoteEpoch:        g.hwm.VoteEpoch,
+		VoteBlocks:       g.hwm.VoteBlocks,
+		ProposalEpoch:    header.Epoch,
+		ProposalSignHash: signHash,
+	}
+	return g.commit(hwm)
+}
+
+// marks returns a copy of the high-water marks
+func (g *guard) marks() *highWaterMarks {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.hwm.copy()
+}
+
+// raise adopts the high-water marks of another signer holding the same key, so that this signer does
+// not sign anything conflicting with what the other signer has signed. If the marks may be outdated,
+// the epochs they cover are skipped altogether.
+func (g *guard) raise(inherited *highWaterMarks, skipEpochs bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	hwm := g.hwm.copy()
+	if skipEpochs {
+		if inherited.VoteEpoch >= hwm.VoteEpoch {
+			hwm.VoteEpoch = inherited.VoteEpoch + 1
+			hwm.VoteBlocks = make(map[uint64]common.Hash)
+		}
+		if inherited.ProposalEpoch >= hwm.ProposalEpoch {
+			hwm.ProposalEpoch = inherited.ProposalEpoch + 1
+			hwm.ProposalSignHash = common.Hash{}
+		}
+		return g.commit(hwm)
+	}
+
+	if inherited.VoteEpoch > hwm.VoteEpoch {
+		hwm.VoteEpoch = inherited.VoteEpoch
+		hwm.VoteBlocks = make(map[uint64]common.Hash)
+	}
+	if inherited.VoteEpoch == hwm.VoteEpoch {
+		for height, block := range inherited.VoteBlocks {
+			hwm.VoteBlocks[height] = block
+		}
+	}
+	if inherited.ProposalEpoch > hwm.ProposalEpoch ||
+		(inherited.ProposalEpoch == hwm.ProposalEpoch && !inherited.ProposalSignHash.IsEmpty()) {
+		hwm.ProposalEpoch = inherited.ProposalEpoch
+		hwm.ProposalSignHash = inherited.ProposalSignHash
+	}
+	return g.commit(hwm)
+}
+
+func (hwm *highWaterMarks) copy() *highWaterMarks {
+	ret := &highWaterMarks{
+		VoteEpoch:        hwm.VoteEpoch,
+		VoteBlocks:       make(map[uint64]common.Hash),
+		ProposalEpoch:    hwm.ProposalEpoch,
+		ProposalSignHash: hwm.ProposalSignHash,
+	}
+	for height, block := range hwm.VoteBlocks {
+		ret.VoteBlocks[height] = block
+	}
+	return ret
+}
+
+// commit persists the high-water marks, and only then makes them effective
+func (g *guard) commit(hwm *highWaterMarks) error {
+	raw, err := json.Marshal(hwm)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := g.path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(raw); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, g.path); err != nil {
+		return err
+	}
+	if dir, err := os.Open(filepath.Dir(g.path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+
+	g.hwm = hwm
+	return nil
+}
@@ -0,0 +1,138 @@
+package signer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+
+	score "github.com/thetatoken/thetasubchain/core"
+)
+
+func newTestGuard(t *testing.T) (*guard, string, func()) {
+	dir, err := ioutil.TempDir("", "signer_guard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "signer_state.json")
+	g, err := newGuard(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g, path, func() { os.RemoveAll(dir) }
+}
+
+func newTestVote(epoch, height uint64, block string) *score.Vote {
+	return &score.Vote{Epoch: epoch, Height: height, Block: common.HexToHash(block)}
+}
+
+func newTestHeader(epoch, height uint64, parent string) *score.BlockHeader {
+	return &score.BlockHeader{ChainID: "tsub_test", Epoch: epoch, Height: height, Parent: common.HexToHash(parent)}
+}
+
+func TestGuardVotes(t *testing.T) {
+	assert := assert.New(t)
+
+	g, _, cleanup := newTestGuard(t)
+	defer cleanup()
+
+	tests := []struct {
+		name  string
+		vote  *score.Vote
+		valid bool
+	}{
+		{"first vote", newTestVote(5, 10, "a1"), true},
+		{"same vote again", newTestVote(5, 10, "a1"), true},
+		{"conflicting block at the same height", newTestVote(5, 10, "a2"), false},
+		{"another height in the same epoch", newTestVote(5, 11, "a3"), true},
+		{"conflicting block at the other height", newTestVote(5, 11, "a4"), false},
+		{"higher epoch", newTestVote(6, 10, "a2"), true},
+		{"lower epoch", newTestVote(5, 12, "a5"), false},
+		{"same height in the higher epoch", newTestVote(6, 10, "a1"), false},
+	}
+	for _, test := range tests {
+		err := g.checkVote(test.vote)
+		if test.valid {
+			assert.Nil(err, test.name)
+		} else {
+			assert.NotNil(err, test.name)
+		}
+	}
+}
+
+func TestGuardProposals(t *testing.T) {
+	assert := assert.New(t)
+
+	g, _, cleanup := newTestGuard(t)
+	defer cleanup()
+
+	tests := []struct {
+		name   string
+		header *score.BlockHeader
+		valid  bool
+	}{
+		{"first proposal", newTestHeader(5, 10, "b1"), true},
+		{"same proposal again", newTestHeader(5, 10, "b1"), true},
+		{"conflicting proposal in the same epoch", newTestHeader(5, 10, "b2"), false},
+		{"higher epoch", newTestHeader(7, 11, "b1"), true},
+		{"lower epoch", newTestHeader(6, 12, "b3"), false},
+	}
+	for _, test := range tests {
+		err := g.checkProposal(test.header)
+		if test.valid {
+			assert.Nil(err, test.name)
+		} else {
+			assert.NotNil(err, test.name)
+		}
+	}
+}
+
+func TestGuardRestart(t *testing.T) {
+	assert := assert.New(t)
+
+	g, path, cleanup := newTestGuard(t)
+	defer cleanup()
+
+	assert.Nil(g.checkVote(newTestVote(5, 10, "a1")))
+	assert.Nil(g.checkProposal(newTestHeader(5, 10, "b1")))
+
+	restarted, err := newGuard(path)
+	assert.Nil(err)
+	assert.Equal(g.marks(), restarted.marks())
+
+	assert.NotNil(restarted.checkVote(newTestVote(5, 10, "a2")))
+	assert.NotNil(restarted.checkVote(newTestVote(4, 10, "a1")))
+	assert.Nil(restarted.checkVote(newTestVote(5, 10, "a1")))
+	assert.NotNil(restarted.checkProposal(newTestHeader(5, 10, "b2")))
+	assert.Nil(restarted.checkProposal(newTestHeader(5, 10, "b1")))
+
+	// A corrupted state file must not silently reset the marks
+	assert.Nil(ioutil.WriteFile(path, []byte("{"), 0600))
+	_, err = newGuard(path)
+	assert.NotNil(err)
+}
+
+func TestGuardRaise(t *testing.T) {
+	assert := assert.New(t)
+
+	g, _, cleanup := newTestGuard(t)
+	defer cleanup()
+
+	inherited := &highWaterMarks{
+		VoteEpoch:  5,
+		VoteBlocks: map[uint64]common.Hash{10: common.HexToHash("a1")},
+	}
+	assert.Nil(g.raise(inherited, false))
+	assert.NotNil(g.checkVote(newTestVote(5, 10, "a2")))
+	assert.Nil(g.checkVote(newTestVote(5, 10, "a1")))
+
+	// Outdated marks skip the epochs they cover
+	assert.Nil(g.raise(&highWaterMarks{VoteEpoch: 8, ProposalEpoch: 8}, true))
+	assert.NotNil(g.checkVote(newTestVote(8, 20, "a3")))
+	assert.NotNil(g.checkProposal(newTestHeader(8, 20, "b1")))
+	assert.Nil(g.checkVote(newTestVote(9, 20, "a3")))
+	assert.Nil(g.checkProposal(newTestHeader(9, 20, "b1")))
+}
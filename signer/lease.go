@@ -0,0 +1,366 @@
+package signer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/rpc"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/thetatoken/theta/common"
+)
+
+// ------------------------------- File lease -----------------------------------
+
+// leaseRecord is the content of the lease file on the shared storage
+type leaseRecord struct {
+	Holder    string          `json:"holder"`
+	ExpiresAt int64           `json:"expires_at"` // unix time in milliseconds
+	Marks     *highWaterMarks `json:"marks"`
+}
+
+// fileLease is a lease kept in a file on the storage shared by the primary and the standby. The holder
+// renews it periodically, and writes its high-water marks into it before releasing each signature, so
+// the next holder inherits exactly what has been signed. The file is only modified under a lock file
+// created exclusively next to it.
+type fileLease struct {
+	path     string
+	holder   string
+	primary  bool
+	duration time.Duration
+
+	mu        *sync.Mutex
+	term      uint64
+	expiresAt time.Time
+	inherited *highWaterMarks
+}
+
+func newFileLease(path string, mode string, duration time.Duration) *fileLease {
+	hostname, _ := os.Hostname()
+	return &fileLease{
+		path:     path,
+		holder:   fmt.Sprintf("%v-%v-%v", hostname, os.Getpid(), time.Now().UnixNano()),
+		primary:  mode == FailoverModePrimary,
+		duration: duration,
+		mu:       &sync.Mutex{},
+	}
+}
+
+func (fl *fileLease) start(ctx context.Context) {
+	fl.acquireOrRenew()
+	go func() {
+		ticker := time.NewTicker(fl.duration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fl.acquireOrRenew()
+			}
+		}
+	}()
+}
+
+func (fl *fileLease) held() (bool, uint64) {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+
+	// stop signing a third of the lease duration before the lease expires, to tolerate clock drift
+	return time.Now().Before(fl.expiresAt.Add(-fl.duration / 3)), fl.term
+}
+
+func (fl *fileLease) inheritedMarks() (*highWaterMarks, bool) {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+
+	return fl.inherited, false
+}
+
+func (fl *fileLease) record(hwm *highWaterMarks) error {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+
+	return fl.withLock(func() error {
+		rec, err := fl.read()
+		if err != nil {
+			return err
+		}
+		if rec.Holder != fl.holder {
+			fl.expiresAt = time.Time{}
+			return ErrStandingBy
+		}
+		now := time.Now()
+		rec.ExpiresAt = now.Add(fl.duration).UnixNano() / int64(time.Millisecond)
+		rec.Marks = hwm
+		if err := fl.write(rec); err != nil {
+			return err
+		}
+		fl.expiresAt = now.Add(fl.duration)
+		return nil
+	})
+}
+
+func (fl *fileLease) acquireOrRenew() {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+
+	err := fl.withLock(func() error {
+		rec, err := fl.read()
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		expiresAt := time.Unix(0, rec.ExpiresAt*int64(time.Millisecond))
+		if rec.Holder != fl.holder {
+			// the standby waits for another lease duration after the lease expires, so that a restarted
+			// primary gets the lease back first
+			grace := time.Duration(0)
+			if !fl.primary {
+				grace = fl.duration
+			}
+			if rec.Holder != "" && now.Before(expiresAt.Add(grace)) {
+				fl.expiresAt = time.Time{}
+				return nil
+			}
+			logger.Infof("Acquiring the signing lease, previous holder: %v", rec.Holder)
+			fl.inherited = rec.Marks
+			fl.term++
+		}
+
+		rec.Holder = fl.holder
+		rec.ExpiresAt = now.Add(fl.duration).UnixNano() / int64(time.Millisecond)
+		if err := fl.write(rec); err != nil {
+			return err
+		}
+		fl.expiresAt = now.Add(fl.duration)
+		return nil
+	})
+	if err != nil {
+		logger.Warnf("Failed to renew the signing lease %v: %v", fl.path, err)
+	}
+}
+
+// withLock runs the function while holding the lock file. A lock file older than the lease duration is
+// left over by a crashed node, and is removed.
+func (fl *fileLease) withLock(fn func() error) error {
+	lockPath := fl.path + ".lock"
+	lockFile, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if os.IsExist(err) {
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > fl.duration {
+			os.Remove(lockPath)
+			lockFile, err = os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock the lease file: %v", err)
+	}
+	lockFile.Close()
+	defer os.Remove(lockPath)
+
+	return fn()
+}
+
+func (fl *fileLease) read() (*leaseRecord, error) {
+	rec := &leaseRecord{}
+	raw, err := ioutil.ReadFile(fl.path)
+	if os.IsNotExist(err) {
+		return rec, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, rec); err != nil {
+		return nil, fmt.Errorf("failed to parse the lease file %v: %v", fl.path, err)
+	}
+	return rec, nil
+}
+
+func (fl *fileLease) write(rec *leaseRecord) error {
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	tmpPath := fl.path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(raw); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, fl.path)
+}
+
+// ------------------------------- Heartbeat lease -----------------------------------
+
+const heartbeatServiceName = "FailoverHeartbeat"
+
+type HeartbeatArgs struct{}
+
+type HeartbeatReply struct {
+	Address common.Address
+	Marks   []byte // JSON encoded high-water marks
+}
+
+// heartbeatLease lets the primary serve heartbeats carrying its high-water marks, and the standby take over
+// once it has received no heartbeat for the lease duration. The primary cannot be fenced off, so the standby
+// skips the epochs covered by the last heartbeat it received, and the primary must not be restarted as the
+// primary after a takeover.
+type heartbeatLease struct {
+	address  string
+	primary  bool
+	duration time.Duration
+	signer   common.Address
+	guard    *guard
+
+	mu            *sync.Mutex
+	takenOver     bool
+	lastHeartbeat time.Time
+	inherited     *highWaterMarks
+}
+
+func newHeartbeatLease(address string, mode string, duration time.Duration, signer common.Address, g *guard) *heartbeatLease {
+	return &heartbeatLease{
+		address:       address,
+		primary:       mode == FailoverModePrimary,
+		duration:      duration,
+		signer:        signer,
+		guard:         g,
+		mu:            &sync.Mutex{},
+		lastHeartbeat: time.Now(),
+	}
+}
+
+func (hl *heartbeatLease) start(ctx context.Context) {
+	if hl.primary {
+		go hl.serve(ctx)
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(hl.duration / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if hl.poll() {
+					return
+				}
+			}
+		}
+	}()
+}
+
+func (hl *heartbeatLease) held() (bool, uint64) {
+	if hl.primary {
+		return true, 1
+	}
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+	return hl.takenOver, 1
+}
+
+func (hl *heartbeatLease) inheritedMarks() (*highWaterMarks, bool) {
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+	return hl.inherited, true
+}
+
+func (hl *heartbeatLease) record(hwm *highWaterMarks) error {
+	return nil // the primary serves its marks with the heartbeats
+}
+
+// poll requests a heartbeat from the primary, and returns true once the standby has taken over
+func (hl *heartbeatLease) poll() bool {
+	reply := &HeartbeatReply{}
+	err := hl.requestHeartbeat(reply)
+	if err == nil && reply.Address != hl.signer {
+		err = fmt.Errorf("the primary signs for %v instead of %v", reply.Address.Hex(), hl.signer.Hex())
+	}
+	var marks *highWaterMarks
+	if err == nil {
+		marks = &highWaterMarks{}
+		err = json.Unmarshal(reply.Marks, marks)
+	}
+
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+	if err == nil {
+		hl.lastHeartbeat = time.Now()
+		hl.inherited = marks
+		return false
+	}
+	if time.Since(hl.lastHeartbeat) < hl.duration {
+		logger.Debugf("Missed a heartbeat from the primary: %v", err)
+		return false
+	}
+	logger.Warnf("No heartbeat from the primary since %v, taking over: %v", hl.lastHeartbeat, err)
+	hl.takenOver = true
+	return true
+}
+
+func (hl *heartbeatLease) requestHeartbeat(reply *HeartbeatReply) error {
+	conn, err := dial(hl.address, nil)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(hl.duration / 4))
+	client := rpc.NewClient(conn)
+	defer client.Close()
+	return client.Call(heartbeatServiceName+".Heartbeat", &HeartbeatArgs{}, reply)
+}
+
+func (hl *heartbeatLease) serve(ctx context.Context) {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName(heartbeatServiceName, &heartbeatService{lease: hl}); err != nil {
+		logger.Errorf("Failed to register the heartbeat service: %v", err)
+		return
+	}
+	listener, err := Listen(hl.address, nil)
+	if err != nil {
+		logger.Errorf("Failed to listen for the heartbeat requests on %v: %v", hl.address, err)
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Errorf("Stopped serving the heartbeats: %v", err)
+			}
+			return
+		}
+		go srv.ServeConn(conn)
+	}
+}
+
+// heartbeatService exposes the heartbeats of the primary over net/rpc
+type heartbeatService struct {
+	lease *heartbeatLease
+}
+
+func (hs *heartbeatService) Heartbeat(args *HeartbeatArgs, reply *HeartbeatReply) error {
+	marks, err := json.Marshal(hs.lease.guard.marks())
+	if err != nil {
+		return err
+	}
+	reply.Address = hs.lease.signer
+	reply.Marks = marks
+	return nil
+}
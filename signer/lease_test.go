@@ -0,0 +1,185 @@
+package signer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+)
+
+const testLeaseDuration = 3 * time.Second
+
+func newTestLeaseFile(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "signer_lease")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "signing.lease"), func() { os.RemoveAll(dir) }
+}
+
+// setLeaseExpiry rewrites the expiry of the lease in the lease file, as if the holder had stopped renewing it
+func setLeaseExpiry(t *testing.T, fl *fileLease, expiresAt time.Time) {
+	rec, err := fl.read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.ExpiresAt = expiresAt.UnixNano() / int64(time.Millisecond)
+	if err := fl.write(rec); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileLeaseAcquireAndRenew(t *testing.T) {
+	assert := assert.New(t)
+
+	path, cleanup := newTestLeaseFile(t)
+	defer cleanup()
+
+	primary := newFileLease(path, FailoverModePrimary, testLeaseDuration)
+	held, _ := primary.held()
+	assert.False(held)
+
+	primary.acquireOrRenew()
+	held, term := primary.held()
+	assert.True(held)
+	assert.Equal(uint64(1), term)
+	rec, err := primary.read()
+	assert.Nil(err)
+	assert.Equal(primary.holder, rec.Holder)
+	firstExpiry := rec.ExpiresAt
+
+	// Renewing extends the lease within the same term
+	time.Sleep(10 * time.Millisecond)
+	primary.acquireOrRenew()
+	held, term = primary.held()
+	assert.True(held)
+	assert.Equal(uint64(1), term)
+	rec, err = primary.read()
+	assert.Nil(err)
+	assert.True(rec.ExpiresAt > firstExpiry)
+
+	// The holder records its marks into the lease file
+	marks := &highWaterMarks{VoteEpoch: 5, VoteBlocks: map[uint64]common.Hash{10: common.HexToHash("a1")}}
+	assert.Nil(primary.record(marks))
+	rec, err = primary.read()
+	assert.Nil(err)
+	assert.Equal(marks, rec.Marks)
+
+	// Nothing is left locked
+	_, err = os.Stat(path + ".lock")
+	assert.True(os.IsNotExist(err))
+}
+
+func TestFileLeaseTakeover(t *testing.T) {
+	assert := assert.New(t)
+
+	path, cleanup := newTestLeaseFile(t)
+	defer cleanup()
+
+	primary := newFileLease(path, FailoverModePrimary, testLeaseDuration)
+	standby := newFileLease(path, FailoverModeStandby, testLeaseDuration)
+
+	primary.acquireOrRenew()
+	marks := &highWaterMarks{VoteEpoch: 5, VoteBlocks: map[uint64]common.Hash{10: common.HexToHash("a1")}, ProposalEpoch: 4}
+	assert.Nil(primary.record(marks))
+
+	// The standby does not take a live lease
+	standby.acquireOrRenew()
+	held, _ := standby.held()
+	assert.False(held)
+
+	// Nor an expired lease within the grace period left to the primary
+	setLeaseExpiry(t, primary, time.Now().Add(-testLeaseDuration/2))
+	standby.acquireOrRenew()
+	held, _ = standby.held()
+	assert.False(held)
+
+	// Once the grace period is over, the standby takes over and inherits the marks of the primary
+	setLeaseExpiry(t, primary, time.Now().Add(-2*testLeaseDuration))
+	standby.acquireOrRenew()
+	held, term := standby.held()
+	assert.True(held)
+	assert.Equal(uint64(1), term)
+	inherited, outdated := standby.inheritedMarks()
+	assert.False(outdated)
+	assert.Equal(marks, inherited)
+
+	// The former primary can no longer renew the lease, nor record a signature
+	primary.acquireOrRenew()
+	held, _ = primary.held()
+	assert.False(held)
+	assert.Equal(ErrStandingBy, primary.record(marks))
+	held, _ = primary.held()
+	assert.False(held)
+
+	// A primary takes an expired lease back without waiting for the grace period
+	setLeaseExpiry(t, standby, time.Now().Add(-time.Millisecond))
+	primary.acquireOrRenew()
+	held, term = primary.held()
+	assert.True(held)
+	assert.Equal(uint64(2), term)
+	assert.Equal(ErrStandingBy, standby.record(marks))
+}
+
+func TestFileLeaseExpiryBoundary(t *testing.T) {
+	assert := assert.New(t)
+
+	path, cleanup := newTestLeaseFile(t)
+	defer cleanup()
+
+	fl := newFileLease(path, FailoverModePrimary, testLeaseDuration)
+	fl.acquireOrRenew()
+
+	// The holder stops signing a third of the lease duration before the expiry, to tolerate clock drift
+	tests := []struct {
+		name      string
+		expiresIn time.Duration
+		held      bool
+	}{
+		{"fresh lease", testLeaseDuration, true},
+		{"just before the safety margin", testLeaseDuration/3 + 500*time.Millisecond, true},
+		{"within the safety margin", testLeaseDuration/3 - 500*time.Millisecond, false},
+		{"expired", -time.Millisecond, false},
+	}
+	for _, test := range tests {
+		fl.mu.Lock()
+		fl.expiresAt = time.Now().Add(test.expiresIn)
+		fl.mu.Unlock()
+		held, _ := fl.held()
+		assert.Equal(test.held, held, test.name)
+	}
+
+	// A lease renewed by another node whose clock runs ahead keeps the other node out until it expires
+	// by the local clock
+	other := newFileLease(path, FailoverModePrimary, testLeaseDuration)
+	setLeaseExpiry(t, fl, time.Now().Add(time.Second))
+	other.acquireOrRenew()
+	held, _ := other.held()
+	assert.False(held)
+}
+
+func TestFileLeaseStaleLock(t *testing.T) {
+	assert := assert.New(t)
+
+	path, cleanup := newTestLeaseFile(t)
+	defer cleanup()
+
+	fl := newFileLease(path, FailoverModePrimary, testLeaseDuration)
+	assert.Nil(ioutil.WriteFile(path+".lock", []byte{}, 0600))
+
+	// A lock held by a live node blocks the lease
+	fl.acquireOrRenew()
+	held, _ := fl.held()
+	assert.False(held)
+
+	// A lock left over by a crashed node is removed once it is older than the lease duration
+	old := time.Now().Add(-2 * testLeaseDuration)
+	assert.Nil(os.Chtimes(path+".lock", old, old))
+	fl.acquireOrRenew()
+	held, _ = fl.held()
+	assert.True(held)
+}
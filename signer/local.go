@@ -0,0 +1,81 @@
+package signer
+
+import (
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+
+	score "github.com/thetatoken/thetasubchain/core"
+	"github.com/thetatoken/thetasubchain/crypto/vrf"
+)
+
+var _ score.Signer = (*LocalSigner)(nil)
+
+// LocalSigner signs the consensus messages with the private key loaded by the node
+type LocalSigner struct {
+	privateKey *crypto.PrivateKey
+}
+
+// NewLocalSigner creates an instance of LocalSigner
+func NewLocalSigner(privateKey *crypto.PrivateKey) *LocalSigner {
+	return &LocalSigner{
+		privateKey: privateKey,
+	}
+}
+
+// Address implements the Signer interface
+func (ls *LocalSigner) Address() common.Address {
+	return ls.privateKey.PublicKey().Address()
+}
+
+// SignVote implements the Signer interface
+func (ls *LocalSigner) SignVote(vote *score.Vote) error {
+	sig, err := ls.privateKey.Sign(vote.SignBytes())
+	if err != nil {
+		return err
+	}
+	vote.SetSignature(sig)
+	return nil
+}
+
+// SignVoteBLS implements the Signer interface
+func (ls *LocalSigner) SignVoteBLS(vote *score.Vote) error {
+	blsKey, err := DeriveBLSKey(ls.privateKey)
+	if err != nil {
+		return err
+	}
+	if err := ls.SignVote(vote); err != nil {
+		return err
+	}
+	vote.SetBLSSignature(blsKey.Sign(score.BLSVoteSignBytes(vote.Block)))
+	return nil
+}
+
+// BLSPubKey implements the Signer interface
+func (ls *LocalSigner) BLSPubKey() (common.Bytes, common.Bytes, error) {
+	blsKey, err := DeriveBLSKey(ls.privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return blsKey.PublicKey().ToBytes(), blsKey.PopProve().ToBytes(), nil
+}
+
+// SignProposal implements the Signer interface
+func (ls *LocalSigner) SignProposal(header *score.BlockHeader) error {
+	sig, err := ls.privateKey.Sign(header.SignBytes())
+	if err != nil {
+		return err
+	}
+	header.SetSignature(sig)
+	return nil
+}
+
+// ProveVRF implements the Signer interface
+func (ls *LocalSigner) ProveVRF(alpha []byte) (common.Bytes, error) {
+	return vrf.Prove(ls.privateKey, alpha)
+}
+
+// SignTx implements the Signer interface
+func (ls *LocalSigner) SignTx(chainID string, tx types.Tx) (*crypto.Signature, error) {
+	return ls.privateKey.Sign(tx.SignBytes(chainID))
+}
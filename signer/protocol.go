@@ -0,0 +1,142 @@
+package signer
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	"github.com/thetatoken/theta/common"
+)
+
+// The node and the remote signer talk over net/rpc. The consensus messages are exchanged RLP encoded,
+// so that the signer decodes and checks exactly what it signs.
+
+const serviceName = "ConsensusSigner"
+
+type AddressArgs struct{}
+
+type AddressReply struct {
+	Address common.Address
+}
+
+type SignVoteArgs struct {
+	Vote []byte // RLP encoded vote
+	BLS  bool   // whether to also sign the vote with the BLS key
+}
+
+type SignProposalArgs struct {
+	Header []byte // RLP encoded block header
+}
+
+type SignatureReply struct {
+	Signature    []byte
+	BLSSignature []byte
+}
+
+type BLSPubKeyArgs struct{}
+
+type BLSPubKeyReply struct {
+	PubKey []byte
+	Pop    []byte
+}
+
+type ProveVRFArgs struct {
+	Alpha []byte
+}
+
+type ProveVRFReply struct {
+	Proof []byte
+}
+
+type SignTxArgs struct {
+	ChainID string
+	Tx      []byte // encoded with TxToBytes
+}
+
+// parseAddress splits a signer address, e.g. unix:///var/run/signer.sock or tcp://10.0.0.2:7000
+func parseAddress(address string) (network string, addr string, err error) {
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		return "unix", strings.TrimPrefix(address, "unix://"), nil
+	case strings.HasPrefix(address, "tcp://"):
+		return "tcp", strings.TrimPrefix(address, "tcp://"), nil
+	default:
+		return "", "", fmt.Errorf("invalid signer address: %v, expected unix://<path> or tcp://<host:port>", address)
+	}
+}
+
+// Listen listens on the signer address. TCP connections are only accepted over mutually authenticated TLS.
+func Listen(address string, tlsConfig *tls.Config) (net.Listener, error) {
+	network, addr, err := parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if network == "unix" {
+		return net.Listen(network, addr)
+	}
+	if tlsConfig == nil {
+		return nil, fmt.Errorf("TLS is required to serve on %v", address)
+	}
+	return tls.Listen(network, addr, tlsConfig)
+}
+
+func dial(address string, tlsConfig *tls.Config) (net.Conn, error) {
+	network, addr, err := parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if network == "unix" {
+		return net.Dial(network, addr)
+	}
+	if tlsConfig == nil {
+		return nil, fmt.Errorf("TLS is required to connect to %v", address)
+	}
+	return tls.Dial(network, addr, tlsConfig)
+}
+
+// NewServerTLSConfig creates the TLS config of the signer, which requires the clients to present a
+// certificate signed by the given CA
+func NewServerTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, caPool, err := loadTLSFiles(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    caPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// NewClientTLSConfig creates the TLS config of the node, which only trusts a signer certificate signed by the given CA
+func NewClientTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, caPool, err := loadTLSFiles(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      caPool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+func loadTLSFiles(certFile, keyFile, caFile string) (tls.Certificate, *x509.CertPool, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to load the TLS key pair: %v", err)
+	}
+	caPEM, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to read the CA certificate: %v", err)
+	}
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM(caPEM) {
+		return tls.Certificate{}, nil, fmt.Errorf("no valid CA certificate in %v", caFile)
+	}
+	return cert, caPool, nil
+}
@@ -0,0 +1,197 @@
+package signer
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/rpc"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rlp"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+var _ score.Signer = (*RemoteSigner)(nil)
+
+// RemoteSigner requests the signatures from a signer server holding the validator key
+type RemoteSigner struct {
+	address   string
+	tlsConfig *tls.Config
+	timeout   time.Duration
+
+	mu            *sync.Mutex
+	client        *rpc.Client
+	signerAddress common.Address
+}
+
+// NewRemoteSigner connects to the signer server, and retrieves the address of the validator
+func NewRemoteSigner(address string, tlsConfig *tls.Config, timeout time.Duration) (*RemoteSigner, error) {
+	rs := &RemoteSigner{
+		address:   address,
+		tlsConfig: tlsConfig,
+		timeout:   timeout,
+		mu:        &sync.Mutex{},
+	}
+
+	reply := &AddressReply{}
+	if err := rs.call("Address", &AddressArgs{}, reply); err != nil {
+		return nil, fmt.Errorf("failed to connect to the remote signer %v: %v", address, err)
+	}
+	rs.signerAddress = reply.Address
+	return rs, nil
+}
+
+// NewSignerFromConfig creates a remote signer if configured, otherwise a signer using the key of the node
+func NewSignerFromConfig(privateKey *crypto.PrivateKey) (score.Signer, error) {
+	address := viper.GetString(scom.CfgSubchainSignerRemoteAddress)
+	if address == "" {
+		return NewLocalSigner(privateKey), nil
+	}
+
+	var tlsConfig *tls.Config
+	if certFile := viper.GetString(scom.CfgSubchainSignerTLSCert); certFile != "" {
+		var err error
+		tlsConfig, err = NewClientTLSConfig(certFile, viper.GetString(scom.CfgSubchainSignerTLSKey), viper.GetString(scom.CfgSubchainSignerTLSCA))
+		if err != nil {
+			return nil, err
+		}
+	}
+	timeout := time.Duration(viper.GetInt(scom.CfgSubchainSignerTimeoutInMilliseconds)) * time.Millisecond
+	return NewRemoteSigner(address, tlsConfig, timeout)
+}
+
+// Address implements the Signer interface
+func (rs *RemoteSigner) Address() common.Address {
+	return rs.signerAddress
+}
+
+// SignVote implements the Signer interface
+func (rs *RemoteSigner) SignVote(vote *score.Vote) error {
+	return rs.signVote(vote, false)
+}
+
+// SignVoteBLS implements the Signer interface
+func (rs *RemoteSigner) SignVoteBLS(vote *score.Vote) error {
+	return rs.signVote(vote, true)
+}
+
+func (rs *RemoteSigner) signVote(vote *score.Vote, withBLS bool) error {
+	raw, err := rlp.EncodeToBytes(vote)
+	if err != nil {
+		return err
+	}
+	reply := &SignatureReply{}
+	if err := rs.call("SignVote", &SignVoteArgs{Vote: raw, BLS: withBLS}, reply); err != nil {
+		return err
+	}
+	sig, err := crypto.SignatureFromBytes(reply.Signature)
+	if err != nil {
+		return err
+	}
+	if !sig.Verify(vote.SignBytes(), rs.signerAddress) {
+		return fmt.Errorf("invalid vote signature from the remote signer")
+	}
+	vote.SetSignature(sig)
+	if withBLS {
+		if len(reply.BLSSignature) == 0 {
+			return fmt.Errorf("no BLS vote signature from the remote signer")
+		}
+		vote.BLSSignature = reply.BLSSignature
+	}
+	return nil
+}
+
+// BLSPubKey implements the Signer interface
+func (rs *RemoteSigner) BLSPubKey() (common.Bytes, common.Bytes, error) {
+	reply := &BLSPubKeyReply{}
+	if err := rs.call("BLSPubKey", &BLSPubKeyArgs{}, reply); err != nil {
+		return nil, nil, err
+	}
+	return reply.PubKey, reply.Pop, nil
+}
+
+// SignProposal implements the Signer interface
+func (rs *RemoteSigner) SignProposal(header *score.BlockHeader) error {
+	raw, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return err
+	}
+	sig, err := rs.requestSignature("SignProposal", &SignProposalArgs{Header: raw})
+	if err != nil {
+		return err
+	}
+	if !sig.Verify(header.SignBytes(), rs.signerAddress) {
+		return fmt.Errorf("invalid block signature from the remote signer")
+	}
+	header.SetSignature(sig)
+	return nil
+}
+
+// ProveVRF implements the Signer interface
+func (rs *RemoteSigner) ProveVRF(alpha []byte) (common.Bytes, error) {
+	reply := &ProveVRFReply{}
+	if err := rs.call("ProveVRF", &ProveVRFArgs{Alpha: alpha}, reply); err != nil {
+		return nil, err
+	}
+	return reply.Proof, nil
+}
+
+// SignTx implements the Signer interface
+func (rs *RemoteSigner) SignTx(chainID string, tx types.Tx) (*crypto.Signature, error) {
+	raw, err := stypes.TxToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := rs.requestSignature("SignTx", &SignTxArgs{ChainID: chainID, Tx: raw})
+	if err != nil {
+		return nil, err
+	}
+	if !sig.Verify(tx.SignBytes(chainID), rs.signerAddress) {
+		return nil, fmt.Errorf("invalid transaction signature from the remote signer")
+	}
+	return sig, nil
+}
+
+func (rs *RemoteSigner) requestSignature(method string, args interface{}) (*crypto.Signature, error) {
+	reply := &SignatureReply{}
+	if err := rs.call(method, args, reply); err != nil {
+		return nil, err
+	}
+	return crypto.SignatureFromBytes(reply.Signature)
+}
+
+// call invokes the method on the signer server, reconnecting if the connection was lost
+func (rs *RemoteSigner) call(method string, args interface{}, reply interface{}) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.client == nil {
+		conn, err := dial(rs.address, rs.tlsConfig)
+		if err != nil {
+			return err
+		}
+		rs.client = rpc.NewClient(conn)
+	}
+
+	call := rs.client.Go(serviceName+"."+method, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+		if call.Error == rpc.ErrShutdown {
+			rs.client.Close()
+			rs.client = nil
+		}
+		return call.Error
+	case <-time.After(rs.timeout):
+		// The reply might still arrive, drop the connection so that it is not mistaken for a later call
+		rs.client.Close()
+		rs.client = nil
+		return fmt.Errorf("remote signer timed out on %v", method)
+	}
+}
@@ -0,0 +1,170 @@
+package signer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/rpc"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/crypto/bls"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rlp"
+
+	score "github.com/thetatoken/thetasubchain/core"
+	"github.com/thetatoken/thetasubchain/crypto/vrf"
+	stypes "github.com/thetatoken/thetasubchain/ledger/types"
+)
+
+var logger *log.Entry = log.WithFields(log.Fields{"prefix": "signer"})
+
+// Server holds the validator key in a separate process, and signs the consensus messages requested by
+// the node, as long as they do not conflict with the messages it signed before.
+type Server struct {
+	privateKey *crypto.PrivateKey
+	blsKey     *bls.SecretKey
+	guard      *guard
+}
+
+// NewServer creates a signer server. The high-water marks are persisted at statePath.
+func NewServer(privateKey *crypto.PrivateKey, statePath string) (*Server, error) {
+	g, err := newGuard(statePath)
+	if err != nil {
+		return nil, err
+	}
+	blsKey, err := DeriveBLSKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		privateKey: privateKey,
+		blsKey:     blsKey,
+		guard:      g,
+	}, nil
+}
+
+// Serve serves the signing requests on the listener until the context is canceled
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName(serviceName, &signerService{server: s}); err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	logger.Infof("Signing for %v on %v", s.privateKey.PublicKey().Address().Hex(), listener.Addr())
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		logger.Infof("Accepted connection from %v", conn.RemoteAddr())
+		go srv.ServeConn(conn)
+	}
+}
+
+// signerService exposes the signing methods over net/rpc
+type signerService struct {
+	server *Server
+}
+
+func (ss *signerService) Address(args *AddressArgs, reply *AddressReply) error {
+	reply.Address = ss.server.privateKey.PublicKey().Address()
+	return nil
+}
+
+func (ss *signerService) SignVote(args *SignVoteArgs, reply *SignatureReply) error {
+	vote := &score.Vote{}
+	if err := rlp.DecodeBytes(args.Vote, vote); err != nil {
+		return fmt.Errorf("failed to decode the vote: %v", err)
+	}
+	if vote.ID != ss.server.privateKey.PublicKey().Address() {
+		return fmt.Errorf("vote is not from the validator: %v", vote.ID.Hex())
+	}
+	if err := ss.server.guard.checkVote(vote); err != nil {
+		logger.Warnf("Refused to sign vote %v: %v", vote, err)
+		return err
+	}
+	if args.BLS {
+		reply.BLSSignature = ss.server.blsKey.Sign(score.BLSVoteSignBytes(vote.Block)).ToBytes()
+	}
+	return ss.sign(vote.SignBytes(), reply)
+}
+
+func (ss *signerService) BLSPubKey(args *BLSPubKeyArgs, reply *BLSPubKeyReply) error {
+	reply.PubKey = ss.server.blsKey.PublicKey().ToBytes()
+	reply.Pop = ss.server.blsKey.PopProve().ToBytes()
+	return nil
+}
+
+func (ss *signerService) SignProposal(args *SignProposalArgs, reply *SignatureReply) error {
+	header := &score.BlockHeader{}
+	if err := rlp.DecodeBytes(args.Header, header); err != nil {
+		return fmt.Errorf("failed to decode the block header: %v", err)
+	}
+	if header.Proposer != ss.server.privateKey.PublicKey().Address() {
+		return fmt.Errorf("block is not proposed by the validator: %v", header.Proposer.Hex())
+	}
+	if err := ss.server.guard.checkProposal(header); err != nil {
+		logger.Warnf("Refused to sign proposal at height %v: %v", header.Height, err)
+		return err
+	}
+	return ss.sign(header.SignBytes(), reply)
+}
+
+func (ss *signerService) ProveVRF(args *ProveVRFArgs, reply *ProveVRFReply) error {
+	proof, err := vrf.Prove(ss.server.privateKey, args.Alpha)
+	if err != nil {
+		return err
+	}
+	reply.Proof = proof
+	return nil
+}
+
+// SignTx only signs the transactions a block proposer issues, arbitrary bytes are never signed, so
+// that the high-water marks can not be bypassed.
+func (ss *signerService) SignTx(args *SignTxArgs, reply *SignatureReply) error {
+	tx, err := stypes.TxFromBytes(args.Tx)
+	if err != nil {
+		return fmt.Errorf("failed to decode the transaction: %v", err)
+	}
+	address := ss.server.privateKey.PublicKey().Address()
+	var proposer common.Address
+	switch t := tx.(type) {
+	case *types.CoinbaseTx:
+		proposer = t.Proposer.Address
+	case *stypes.SubchainValidatorSetUpdateTx:
+		proposer = t.Proposer.Address
+	case *stypes.SubchainValidatorSetUpdateForChainTx:
+		proposer = t.Proposer.Address
+	case *stypes.SubchainEquivocationEvidenceTx:
+		proposer = t.Proposer.Address
+	case *stypes.SubchainBLSKeyRegistrationTx:
+		proposer = t.Proposer.Address
+	case *stypes.SubchainStakeEventsTx:
+		proposer = t.Proposer.Address
+	default:
+		return fmt.Errorf("transaction type %T is not signed by the remote signer", tx)
+	}
+	if proposer != address {
+		return fmt.Errorf("transaction is not issued by the validator: %v", proposer.Hex())
+	}
+	return ss.sign(tx.SignBytes(args.ChainID), reply)
+}
+
+func (ss *signerService) sign(signBytes common.Bytes, reply *SignatureReply) error {
+	sig, err := ss.server.privateKey.Sign(signBytes)
+	if err != nil {
+		return err
+	}
+	reply.Signature = sig.ToBytes()
+	return nil
+}
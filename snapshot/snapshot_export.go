@@ -155,7 +155,7 @@ func ExportSnapshotV2(db database.Database, consensus *sconsensus.ConsensusEngin
 					if child.HCC.BlockHash != block.Hash() || grandChild.HCC.BlockHash != child.Hash() {
 						return "", fmt.Errorf("Invalid block HCC link for validator set changes")
 					}
-					if grandChild.HCC.Votes.IsEmpty() {
+					if grandChild.HCC.IsEmpty() {
 						return "", fmt.Errorf("Missing block HCC votes for validator set changes")
 					}
 					for _, vote := range grandChild.HCC.Votes.Votes() {
@@ -371,7 +371,7 @@ func ExportSnapshotV3(db database.Database, consensus *sconsensus.ConsensusEngin
 					if child.HCC.BlockHash != block.Hash() || grandChild.HCC.BlockHash != child.Hash() {
 						return "", fmt.Errorf("Invalid block HCC link for validator set changes")
 					}
-					if grandChild.HCC.Votes.IsEmpty() {
+					if grandChild.HCC.IsEmpty() {
 						return "", fmt.Errorf("Missing block HCC votes for validator set changes")
 					}
 					for _, vote := range grandChild.HCC.Votes.Votes() {
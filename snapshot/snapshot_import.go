@@ -23,6 +23,7 @@ import (
 	"github.com/thetatoken/theta/store/kvstore"
 	"github.com/thetatoken/theta/store/trie"
 	sbc "github.com/thetatoken/thetasubchain/blockchain"
+	scom "github.com/thetatoken/thetasubchain/common"
 	sconsensus "github.com/thetatoken/thetasubchain/consensus"
 	score "github.com/thetatoken/thetasubchain/core"
 	sld "github.com/thetatoken/thetasubchain/ledger"
@@ -262,6 +263,12 @@ func loadSnapshot(snapshotFilePath string, db database.Database, logStr string)
 		}
 	}
 
+	// The forks declared in the genesis state apply to the validity checks below, and to the blocks
+	// loaded after the snapshot, so the fork schedule is set up before anything depends on it
+	if err = scom.InitForkSchedule(sv.GetGenesisForks()); err != nil {
+		return nil, nil, fmt.Errorf("Failed to set up the fork schedule: %v", err)
+	}
+
 	// ----------------------------- Validity Checks -------------------------- //
 
 	if snapshotVersion >= 4 {
@@ -857,8 +864,8 @@ func checkProofTrios(proofTrios []score.SnapshotBlockTrio, db database.Database)
 					second.Header.Hash(), third.Header.HCC.BlockHash)
 			}
 
-			// third.Header.HCC.Votes contains the votes for the second block in the trio
-			if err := validateVotes(provenValSet, second.Header, third.Header.HCC.Votes); err != nil {
+			// third.Header.HCC contains the votes for the second block in the trio
+			if err := validateCommitCertificate(provenValSet, second.Header, third.Header.HCC); err != nil {
 				return nil, fmt.Errorf("Failed to validate voteSet, %v", err)
 			}
 			provenValSet, err = getValidatorSetFromVSProof(first.Header.StateHash, &first.Proof)
@@ -942,6 +949,21 @@ func getValidatorSetFromSV(sv *slst.StoreView) *score.ValidatorSet {
 	return sconsensus.FilterValidators(vs)
 }
 
+// validateCommitCertificate validates the commit certificate of the block, with either individual or
+// aggregated votes. The BLS public keys of the validators are part of the proven validator set.
+func validateCommitCertificate(validatorSet *score.ValidatorSet, block *score.BlockHeader, cc score.CommitCertificate) error {
+	if cc.Aggregated == nil {
+		return validateVotes(validatorSet, block, cc.Votes)
+	}
+	if cc.BlockHash != block.Hash() {
+		return fmt.Errorf("commit certificate is not for corresponding block")
+	}
+	if !cc.IsValid(validatorSet) {
+		return fmt.Errorf("aggregated commit certificate is not valid")
+	}
+	return nil
+}
+
 func validateVotes(validatorSet *score.ValidatorSet, block *score.BlockHeader, voteSet *score.VoteSet) error {
 	if !validatorSet.HasMajority(voteSet) {
 		return fmt.Errorf("block doesn't have majority votes")
@@ -0,0 +1,100 @@
+package snapshot
+
+import (
+	"bufio"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/store/database/backend"
+
+	scom "github.com/thetatoken/thetasubchain/common"
+	score "github.com/thetatoken/thetasubchain/core"
+	slst "github.com/thetatoken/thetasubchain/ledger/state"
+)
+
+const testSubchainID = "tsub360777"
+
+// writeGenesisSnapshot writes a genesis snapshot whose state declares the given forks, and returns its path
+// along with the hash of the genesis block
+func writeGenesisSnapshot(t *testing.T, dir string, forks []scom.Fork) (string, common.Hash) {
+	db := backend.NewMemDatabase()
+	sv := slst.NewStoreView(score.GenesisBlockHeight, common.Hash{}, db)
+	sv.SetGenesisForks(forks)
+
+	validatorSet := score.NewValidatorSet(big.NewInt(0))
+	validatorSet.AddValidator(score.NewValidator("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab", big.NewInt(100)))
+	sv.UpdateValidatorSet(scom.MapChainID(testSubchainID), validatorSet)
+	hl := &types.HeightList{}
+	hl.Append(score.GenesisBlockHeight)
+	sv.UpdateValidatorSetUpdateTxHeightList(hl)
+	sv.Save()
+
+	genesisBlock := score.NewBlock()
+	genesisBlock.ChainID = testSubchainID
+	genesisBlock.Height = score.GenesisBlockHeight
+	genesisBlock.Epoch = genesisBlock.Height
+	genesisBlock.StateHash = sv.Hash()
+	genesisBlock.Timestamp = big.NewInt(1700000000000)
+	metadata := &score.SnapshotMetadata{
+		TailTrio: score.SnapshotBlockTrio{Second: score.SnapshotSecondBlock{Header: genesisBlock.BlockHeader}},
+	}
+
+	snapshotPath := path.Join(dir, "genesis")
+	file, err := os.Create(snapshotPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+	if err := score.WriteMetadata(writer, metadata); err != nil {
+		t.Fatal(err)
+	}
+	writeStoreView(sv, true, writer, db)
+
+	return snapshotPath, genesisBlock.Hash()
+}
+
+func TestValidateSnapshotGenesisForks(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "snapshot_import")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	prevGenesisHash := viper.GetString(common.CfgGenesisHash)
+	defer func() {
+		viper.Set(common.CfgGenesisHash, prevGenesisHash)
+		scom.InitForkSchedule(nil)
+	}()
+
+	// The forks declared in the genesis state are in effect once the snapshot is loaded, without
+	// being configured
+	forks := []scom.Fork{{Name: scom.ForkMillisecondTimestamp, Height: 100}}
+	snapshotPath, genesisHash := writeGenesisSnapshot(t, dir, forks)
+	viper.Set(common.CfgGenesisHash, genesisHash.Hex())
+	assert.False(scom.IsForkActive(scom.ForkMillisecondTimestamp, 100))
+
+	header, err := ValidateSnapshot(snapshotPath, "", "")
+	assert.Nil(err)
+	assert.Equal(genesisHash, header.Hash())
+	assert.False(scom.IsForkActive(scom.ForkMillisecondTimestamp, 99))
+	assert.True(scom.IsForkActive(scom.ForkMillisecondTimestamp, 100))
+	assert.Equal(uint64(100), scom.GetForkSchedule().ActivationHeight(scom.ForkMillisecondTimestamp))
+
+	// A snapshot declaring a fork unknown to the node software is rejected
+	scom.InitForkSchedule(nil)
+	snapshotPath, genesisHash = writeGenesisSnapshot(t, dir, []scom.Fork{{Name: "unknownFork", Height: 10}})
+	viper.Set(common.CfgGenesisHash, genesisHash.Hex())
+	_, err = ValidateSnapshot(snapshotPath, "", "")
+	assert.NotNil(err)
+	assert.False(scom.IsForkActive(scom.ForkMillisecondTimestamp, 100))
+}
@@ -86,6 +86,10 @@ func (store *TreeStore) ProveValidatorSet(vsKey []byte, vsp *score.ValidatorSetP
 	return store.Trie.Prove(vsKey, 0, vsp)
 }
 
+func (store *TreeStore) ProveVoucherBurnRecord(recordKey []byte, sp *score.StateProof) error {
+	return store.Trie.Prove(recordKey, 0, sp)
+}
+
 // Set sets value of given key.
 func (store *TreeStore) Set(key, value common.Bytes) {
 	store.Trie.Update(key, value)